@@ -2,6 +2,7 @@ package acl
 
 import (
 	"fmt"
+	"strings"
 
 	iradix "github.com/hashicorp/go-immutable-radix"
 )
@@ -35,6 +36,12 @@ func (c capabilitySet) Clear() {
 	}
 }
 
+// hostVolumeCapability returns the capability granting the mount of the host
+// volumes matching the given name or wildcard
+func hostVolumeCapability(volume string) string {
+	return NamespaceCapabilityMountVolume + ":" + volume
+}
+
 // ACL object is used to convert a set of policies into a structure that
 // can be efficiently evaluated to determine if an action is allowed.
 type ACL struct {
@@ -102,6 +109,14 @@ func NewACL(management bool, policies []*Policy) (*ACL, error) {
 					capabilities.Set(NamespaceCapabilityDeny)
 					continue NAMESPACES
 				}
+
+				// Restrict the volumes that can be mounted if requested
+				if cap == NamespaceCapabilityMountVolume && len(ns.HostVolumes) != 0 {
+					for _, volume := range ns.HostVolumes {
+						capabilities.Set(hostVolumeCapability(volume))
+					}
+					continue
+				}
 				capabilities.Set(cap)
 			}
 		}
@@ -149,6 +164,39 @@ func (a *ACL) AllowNamespaceOperation(ns string, op string) bool {
 	return capabilities.Check(op)
 }
 
+// AllowHostVolume checks if the host volume with the given name can be mounted
+// by the jobs of a namespace
+func (a *ACL) AllowHostVolume(ns, volume string) bool {
+	// Hot path management tokens
+	if a.management {
+		return true
+	}
+
+	// Check for a matching capability set
+	raw, ok := a.namespaces.Get([]byte(ns))
+	if !ok {
+		return false
+	}
+
+	// Check if the capability has been granted for every volume or for
+	// this one
+	capabilities := raw.(capabilitySet)
+	if capabilities.Check(NamespaceCapabilityMountVolume) || capabilities.Check(hostVolumeCapability(volume)) {
+		return true
+	}
+
+	// Check the wildcards
+	for cap := range capabilities {
+		if !strings.HasPrefix(cap, hostVolumeCapability("")) || !strings.HasSuffix(cap, "*") {
+			continue
+		}
+		if strings.HasPrefix(hostVolumeCapability(volume), strings.TrimSuffix(cap, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
 // AllowNamespace checks if any operations are allowed for a namespace
 func (a *ACL) AllowNamespace(ns string) bool {
 	// Hot path management tokens
@@ -262,3 +262,49 @@ func TestAllowNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestAllowHostVolume(t *testing.T) {
+	tests := []struct {
+		Policy string
+		Allow  []string
+		Deny   []string
+	}{
+		{
+			Policy: `namespace "foo" { policy = "write" }`,
+			Deny:   []string{"certs", "data"},
+		},
+		{
+			Policy: `namespace "foo" { capabilities = ["mount-volume"] }`,
+			Allow:  []string{"certs", "data"},
+		},
+		{
+			Policy: `namespace "foo" { capabilities = ["mount-volume"] host_volumes = ["certs", "data-*"] }`,
+			Allow:  []string{"certs", "data-1", "data-"},
+			Deny:   []string{"cert", "certs-1", "data"},
+		},
+		{
+			Policy: `namespace "foo" { capabilities = ["mount-volume", "deny"] }`,
+			Deny:   []string{"certs"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Policy, func(t *testing.T) {
+			assert := assert.New(t)
+
+			policy, err := Parse(tc.Policy)
+			assert.Nil(err)
+
+			acl, err := NewACL(false, []*Policy{policy})
+			assert.Nil(err)
+
+			for _, volume := range tc.Allow {
+				assert.True(acl.AllowHostVolume("foo", volume), volume)
+				assert.False(acl.AllowHostVolume("bar", volume), volume)
+			}
+			for _, volume := range tc.Deny {
+				assert.False(acl.AllowHostVolume("foo", volume), volume)
+			}
+		})
+	}
+}
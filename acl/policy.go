@@ -28,11 +28,17 @@ const (
 	NamespaceCapabilityDispatchJob      = "dispatch-job"
 	NamespaceCapabilityReadLogs         = "read-logs"
 	NamespaceCapabilityReadFS           = "read-fs"
+	NamespaceCapabilityAllocExec        = "alloc-exec"
+	NamespaceCapabilityMountVolume      = "mount-volume"
 	NamespaceCapabilitySentinelOverride = "sentinel-override"
 )
 
 var (
 	validNamespace = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
+
+	// validHostVolume matches a host volume name with an optional trailing
+	// wildcard
+	validHostVolume = regexp.MustCompile(`^([^*]+\*?|\*)$`)
 )
 
 // Policy represents a parsed HCL or JSON policy.
@@ -60,6 +66,11 @@ type NamespacePolicy struct {
 	Name         string `hcl:",key"`
 	Policy       string
 	Capabilities []string
+
+	// HostVolumes restricts the mount-volume capability to the host volumes
+	// with these names. Names may end with a "*" wildcard. The capability
+	// applies to every host volume if empty.
+	HostVolumes []string `hcl:"host_volumes"`
 }
 
 type AgentPolicy struct {
@@ -93,7 +104,7 @@ func isNamespaceCapabilityValid(cap string) bool {
 	switch cap {
 	case NamespaceCapabilityDeny, NamespaceCapabilityListJobs, NamespaceCapabilityReadJob,
 		NamespaceCapabilitySubmitJob, NamespaceCapabilityDispatchJob, NamespaceCapabilityReadLogs,
-		NamespaceCapabilityReadFS:
+		NamespaceCapabilityReadFS, NamespaceCapabilityAllocExec, NamespaceCapabilityMountVolume:
 		return true
 	// Separate the enterprise-only capabilities
 	case NamespaceCapabilitySentinelOverride:
@@ -122,6 +133,7 @@ func expandNamespacePolicy(policy string) []string {
 			NamespaceCapabilityDispatchJob,
 			NamespaceCapabilityReadLogs,
 			NamespaceCapabilityReadFS,
+			NamespaceCapabilityAllocExec,
 		}
 	default:
 		return nil
@@ -170,6 +182,24 @@ func Parse(rules string) (*Policy, error) {
 			extraCap := expandNamespacePolicy(ns.Policy)
 			ns.Capabilities = append(ns.Capabilities, extraCap...)
 		}
+
+		// Host volumes only restrict the volumes that can be mounted
+		if len(ns.HostVolumes) != 0 {
+			mount := false
+			for _, cap := range ns.Capabilities {
+				if cap == NamespaceCapabilityMountVolume {
+					mount = true
+				}
+			}
+			if !mount {
+				return nil, fmt.Errorf("Host volumes require the %q capability: %#v", NamespaceCapabilityMountVolume, ns)
+			}
+		}
+		for _, volume := range ns.HostVolumes {
+			if !validHostVolume.MatchString(volume) {
+				return nil, fmt.Errorf("Invalid host volume name '%s': %#v", volume, ns)
+			}
+		}
 	}
 
 	if p.Agent != nil && !isPolicyValid(p.Agent.Policy) {
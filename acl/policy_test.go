@@ -80,6 +80,7 @@ func TestParse(t *testing.T) {
 							NamespaceCapabilityDispatchJob,
 							NamespaceCapabilityReadLogs,
 							NamespaceCapabilityReadFS,
+							NamespaceCapabilityAllocExec,
 						},
 					},
 					{
@@ -197,6 +198,47 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			`
+			namespace "default" {
+				capabilities = ["mount-volume"]
+				host_volumes = ["certs", "data-*"]
+			}
+			`,
+			"",
+			&Policy{
+				Namespaces: []*NamespacePolicy{
+					{
+						Name:   "default",
+						Policy: "",
+						Capabilities: []string{
+							NamespaceCapabilityMountVolume,
+						},
+						HostVolumes: []string{"certs", "data-*"},
+					},
+				},
+			},
+		},
+		{
+			`
+			namespace "default" {
+				policy = "write"
+				host_volumes = ["certs"]
+			}
+			`,
+			"Host volumes require the \"mount-volume\" capability",
+			nil,
+		},
+		{
+			`
+			namespace "default" {
+				capabilities = ["mount-volume"]
+				host_volumes = ["da*ta"]
+			}
+			`,
+			"Invalid host volume name",
+			nil,
+		},
 	}
 
 	for idx, tc := range tcases {
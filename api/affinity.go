@@ -0,0 +1,27 @@
+package api
+
+import "github.com/hashicorp/nomad/helper"
+
+// Affinity is used to serialize task group affinities
+type Affinity struct {
+	LTarget string // Left-hand target
+	RTarget string // Right-hand target
+	Operand string // Constraint operand (<=, <, =, !=, >, >=), set_contains, regexp, version
+	Weight  *int   // Weight applied to nodes that match the affinity. Can be negative
+}
+
+// NewAffinity generates a new job placement affinity.
+func NewAffinity(LTarget string, Operand string, RTarget string, Weight int) *Affinity {
+	return &Affinity{
+		LTarget: LTarget,
+		RTarget: RTarget,
+		Operand: Operand,
+		Weight:  helper.IntToPtr(Weight),
+	}
+}
+
+func (a *Affinity) Canonicalize() {
+	if a.Weight == nil {
+		a.Weight = helper.IntToPtr(50)
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// TerminalSize is the size of the terminal of an exec session.
+type TerminalSize struct {
+	Height int `json:"height,omitempty"`
+	Width  int `json:"width,omitempty"`
+}
+
+// ExecStreamingIOOperation is a write to or the close of a stream of an exec
+// session.
+type ExecStreamingIOOperation struct {
+	Data  []byte `json:"data,omitempty"`
+	Close bool   `json:"close,omitempty"`
+}
+
+// ExecStreamingInput is a message sent to an exec session carrying stdin or a
+// terminal resize.
+type ExecStreamingInput struct {
+	Stdin   *ExecStreamingIOOperation `json:"stdin,omitempty"`
+	TTYSize *TerminalSize             `json:"tty_size,omitempty"`
+}
+
+// ExecStreamingExitResult is the result of a command of an exec session.
+type ExecStreamingExitResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+// ExecStreamingOutput is a message sent from an exec session carrying output
+// or the exit result of the command.
+type ExecStreamingOutput struct {
+	Stdout *ExecStreamingIOOperation `json:"stdout,omitempty"`
+	Stderr *ExecStreamingIOOperation `json:"stderr,omitempty"`
+
+	Exited bool                     `json:"exited,omitempty"`
+	Result *ExecStreamingExitResult `json:"result,omitempty"`
+}
+
+// Exec is used to execute a command inside a running task of an allocation.
+// The command reads stdin until it is exhausted and its output is written to
+// stdout and stderr. When tty is set the command runs with a pseudo terminal,
+// all of its output is written to stdout and the terminal is resized to the
+// sizes received on terminalSizeCh. Exec returns the exit code of the
+// command once it exits.
+func (a *Allocations) Exec(ctx context.Context, alloc *Allocation, task string, tty bool, command []string,
+	stdin io.Reader, stdout, stderr io.Writer, terminalSizeCh <-chan TerminalSize, q *QueryOptions) (exitCode int, err error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	conn, err := a.execConn(alloc, task, tty, command, q)
+	if err != nil {
+		return -1, err
+	}
+	defer conn.Close()
+
+	// Close the connection to stop reading when cancelled
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	// Send the inputs from a single writer as required by the connection
+	inputs := make(chan *ExecStreamingInput, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case input := <-inputs:
+				if err := conn.WriteJSON(input); err != nil {
+					errCh <- fmt.Errorf("failed to send input: %v", err)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	send := func(input *ExecStreamingInput) bool {
+		select {
+		case inputs <- input:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// Forward stdin until it is exhausted
+	go func() {
+		if stdin == nil {
+			send(&ExecStreamingInput{Stdin: &ExecStreamingIOOperation{Close: true}})
+			return
+		}
+
+		buf := make([]byte, 4096)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				if !send(&ExecStreamingInput{Stdin: &ExecStreamingIOOperation{Data: data}}) {
+					return
+				}
+			}
+			if err != nil {
+				send(&ExecStreamingInput{Stdin: &ExecStreamingIOOperation{Close: true}})
+				return
+			}
+		}
+	}()
+
+	// Forward the terminal resizes
+	go func() {
+		for {
+			select {
+			case size, ok := <-terminalSizeCh:
+				if !ok {
+					return
+				}
+				if !send(&ExecStreamingInput{TTYSize: &size}) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Write the output until the command exits
+	for {
+		var frame ExecStreamingOutput
+		if err := conn.ReadJSON(&frame); err != nil {
+			select {
+			case err := <-errCh:
+				return -1, err
+			case <-ctx.Done():
+				return -1, ctx.Err()
+			default:
+			}
+
+			if closeErr, ok := err.(*websocket.CloseError); ok && closeErr.Code != websocket.CloseNormalClosure {
+				return -1, fmt.Errorf("%s (%d)", closeErr.Text, closeErr.Code)
+			}
+			return -1, fmt.Errorf("exec session ended without an exit code: %v", err)
+		}
+
+		if frame.Stdout != nil && len(frame.Stdout.Data) > 0 && stdout != nil {
+			stdout.Write(frame.Stdout.Data)
+		}
+		if frame.Stderr != nil && len(frame.Stderr.Data) > 0 && stderr != nil {
+			stderr.Write(frame.Stderr.Data)
+		}
+
+		if frame.Exited && frame.Result != nil {
+			return frame.Result.ExitCode, nil
+		}
+	}
+}
+
+// execConn opens the websocket connection of an exec session. The client
+// running the allocation is used directly if it is reachable and the servers
+// otherwise.
+func (a *Allocations) execConn(alloc *Allocation, task string, tty bool, command []string, q *QueryOptions) (*websocket.Conn, error) {
+	nodeClient, err := a.client.GetNodeClientWithTimeout(alloc.NodeID, ClientConnTimeout, q)
+	if err != nil {
+		return nil, err
+	}
+
+	commandBytes, err := json.Marshal(command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal command: %v", err)
+	}
+
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+
+	q.Params["task"] = task
+	q.Params["tty"] = fmt.Sprintf("%t", tty)
+	q.Params["command"] = string(commandBytes)
+
+	reqPath := fmt.Sprintf("/v1/client/allocation/%s/exec", alloc.ID)
+	conn, err := nodeClient.websocket(reqPath, q)
+	if err != nil {
+		// There was a networking error when talking directly to the client.
+		if _, ok := err.(net.Error); !ok {
+			return nil, err
+		}
+
+		// Try via the server
+		conn, err = a.client.websocket(reqPath, q)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// websocket opens a websocket connection to the specified endpoint.
+func (c *Client) websocket(endpoint string, q *QueryOptions) (*websocket.Conn, error) {
+	transport, ok := c.config.httpClient.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unsupported HTTP transport: %T", c.config.httpClient.Transport)
+	}
+
+	dialer := websocket.Dialer{
+		ReadBufferSize:   4096,
+		WriteBufferSize:  4096,
+		HandshakeTimeout: c.config.httpClient.Timeout,
+		Proxy:            transport.Proxy,
+		TLSClientConfig:  transport.TLSClientConfig,
+	}
+
+	r, err := c.newRequest("GET", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	r.setQueryOptions(q)
+
+	req, err := r.toHTTP()
+	if err != nil {
+		return nil, err
+	}
+
+	// The websocket handshake is not compressed
+	req.Header.Del("Accept-Encoding")
+
+	switch req.URL.Scheme {
+	case "https":
+		req.URL.Scheme = "wss"
+	default:
+		req.URL.Scheme = "ws"
+	}
+
+	conn, resp, err := dialer.Dial(req.URL.String(), req.Header)
+	if err == websocket.ErrBadHandshake && resp != nil {
+		// Surface the error returned by the agent
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, body)
+	}
+	return conn, err
+}
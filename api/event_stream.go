@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Topic is the kind of objects the events of the event stream are about
+type Topic string
+
+const (
+	TopicAll        Topic = "*"
+	TopicJob        Topic = "Job"
+	TopicAllocation Topic = "Allocation"
+	TopicDeployment Topic = "Deployment"
+	TopicEvaluation Topic = "Evaluation"
+	TopicNode       Topic = "Node"
+)
+
+// Events are the events of the changes applied at a Raft index
+type Events struct {
+	Index  uint64
+	Events []Event
+}
+
+// Event is a change of the state of the cluster
+type Event struct {
+	Topic      Topic
+	Type       string
+	Key        string
+	Namespace  string
+	FilterKeys []string
+	Index      uint64
+	Payload    map[string]interface{}
+}
+
+// IsHeartbeat returns whether the events are a heartbeat of the stream
+func (e *Events) IsHeartbeat() bool {
+	return e.Index == 0 && len(e.Events) == 0
+}
+
+// EventStream is used to stream the events of the changes applied to the
+// state of the cluster
+type EventStream struct {
+	client *Client
+}
+
+// EventStream returns a handle to the event stream
+func (c *Client) EventStream() *EventStream {
+	return &EventStream{client: c}
+}
+
+// Stream streams the events of the topics, mapped to the keys of the events
+// to receive ("*" for all), after the given index. Only new events are
+// streamed if the index is zero and all events if there are no topics. The
+// stream stops when the cancel channel is closed.
+func (e *EventStream) Stream(topics map[Topic][]string, index uint64,
+	cancel <-chan struct{}, q *QueryOptions) (<-chan *Events, <-chan error) {
+
+	errCh := make(chan error, 1)
+
+	params := url.Values{}
+	topicNames := make([]string, 0, len(topics))
+	for topic := range topics {
+		topicNames = append(topicNames, string(topic))
+	}
+	sort.Strings(topicNames)
+	for _, topic := range topicNames {
+		for _, key := range topics[Topic(topic)] {
+			params.Add("topic", fmt.Sprintf("%s:%s", topic, key))
+		}
+	}
+	if index != 0 {
+		params.Set("index", strconv.FormatUint(index, 10))
+	}
+
+	reqPath := "/v1/event/stream"
+	if len(params) != 0 {
+		reqPath += "?" + params.Encode()
+	}
+	r, err := e.client.rawQuery(reqPath, q)
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+
+	// Create the output channel
+	eventsCh := make(chan *Events, 10)
+
+	go func() {
+		// Close the body
+		defer r.Close()
+		defer close(eventsCh)
+
+		// Create a decoder
+		dec := json.NewDecoder(r)
+
+		for {
+			// Check if we have been cancelled
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+
+			// Decode the next events
+			var events Events
+			if err := dec.Decode(&events); err != nil {
+				errCh <- err
+				return
+			}
+
+			// Discard heartbeats
+			if events.IsHeartbeat() {
+				continue
+			}
+
+			select {
+			case eventsCh <- &events:
+			case <-cancel:
+				return
+			}
+		}
+	}()
+
+	return eventsCh, errCh
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStream(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+	})
+	defer s.Stop()
+
+	// Wait for the node of the agent to register
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		nodes, _, err := c.Nodes().List(nil)
+		if err != nil {
+			return false, err
+		}
+		if len(nodes) != 1 {
+			return false, nil
+		}
+		nodeID = nodes[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Resume the stream from the start to receive its registration
+	cancel := make(chan struct{})
+	defer close(cancel)
+	topics := map[Topic][]string{TopicNode: {nodeID}}
+	eventsCh, errCh := c.EventStream().Stream(topics, 1, cancel, nil)
+
+	select {
+	case events := <-eventsCh:
+		require.NotNil(events)
+		require.NotEmpty(events.Events)
+		event := events.Events[0]
+		require.Equal(TopicNode, event.Topic)
+		require.Equal("NodeRegistration", event.Type)
+		require.Equal(nodeID, event.Key)
+		require.Contains(event.Payload, "Node")
+	case err := <-errCh:
+		t.Fatalf("err: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for events")
+	}
+}
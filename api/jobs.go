@@ -320,26 +320,30 @@ type periodicForceResponse struct {
 
 // UpdateStrategy defines a task groups update strategy.
 type UpdateStrategy struct {
-	Stagger         *time.Duration `mapstructure:"stagger"`
-	MaxParallel     *int           `mapstructure:"max_parallel"`
-	HealthCheck     *string        `mapstructure:"health_check"`
-	MinHealthyTime  *time.Duration `mapstructure:"min_healthy_time"`
-	HealthyDeadline *time.Duration `mapstructure:"healthy_deadline"`
-	AutoRevert      *bool          `mapstructure:"auto_revert"`
-	Canary          *int           `mapstructure:"canary"`
+	Stagger          *time.Duration `mapstructure:"stagger"`
+	MaxParallel      *int           `mapstructure:"max_parallel"`
+	HealthCheck      *string        `mapstructure:"health_check"`
+	MinHealthyTime   *time.Duration `mapstructure:"min_healthy_time"`
+	HealthyDeadline  *time.Duration `mapstructure:"healthy_deadline"`
+	ProgressDeadline *time.Duration `mapstructure:"progress_deadline"`
+	AutoRevert       *bool          `mapstructure:"auto_revert"`
+	AutoPromote      *bool          `mapstructure:"auto_promote"`
+	Canary           *int           `mapstructure:"canary"`
 }
 
 // DefaultUpdateStrategy provides a baseline that can be used to upgrade
 // jobs with the old policy or for populating field defaults.
 func DefaultUpdateStrategy() *UpdateStrategy {
 	return &UpdateStrategy{
-		Stagger:         helper.TimeToPtr(30 * time.Second),
-		MaxParallel:     helper.IntToPtr(1),
-		HealthCheck:     helper.StringToPtr("checks"),
-		MinHealthyTime:  helper.TimeToPtr(10 * time.Second),
-		HealthyDeadline: helper.TimeToPtr(5 * time.Minute),
-		AutoRevert:      helper.BoolToPtr(false),
-		Canary:          helper.IntToPtr(0),
+		Stagger:          helper.TimeToPtr(30 * time.Second),
+		MaxParallel:      helper.IntToPtr(1),
+		HealthCheck:      helper.StringToPtr("checks"),
+		MinHealthyTime:   helper.TimeToPtr(10 * time.Second),
+		HealthyDeadline:  helper.TimeToPtr(5 * time.Minute),
+		ProgressDeadline: helper.TimeToPtr(0),
+		AutoRevert:       helper.BoolToPtr(false),
+		AutoPromote:      helper.BoolToPtr(false),
+		Canary:           helper.IntToPtr(0),
 	}
 }
 
@@ -370,10 +374,18 @@ func (u *UpdateStrategy) Copy() *UpdateStrategy {
 		copy.HealthyDeadline = helper.TimeToPtr(*u.HealthyDeadline)
 	}
 
+	if u.ProgressDeadline != nil {
+		copy.ProgressDeadline = helper.TimeToPtr(*u.ProgressDeadline)
+	}
+
 	if u.AutoRevert != nil {
 		copy.AutoRevert = helper.BoolToPtr(*u.AutoRevert)
 	}
 
+	if u.AutoPromote != nil {
+		copy.AutoPromote = helper.BoolToPtr(*u.AutoPromote)
+	}
+
 	if u.Canary != nil {
 		copy.Canary = helper.IntToPtr(*u.Canary)
 	}
@@ -406,10 +418,18 @@ func (u *UpdateStrategy) Merge(o *UpdateStrategy) {
 		u.HealthyDeadline = helper.TimeToPtr(*o.HealthyDeadline)
 	}
 
+	if o.ProgressDeadline != nil {
+		u.ProgressDeadline = helper.TimeToPtr(*o.ProgressDeadline)
+	}
+
 	if o.AutoRevert != nil {
 		u.AutoRevert = helper.BoolToPtr(*o.AutoRevert)
 	}
 
+	if o.AutoPromote != nil {
+		u.AutoPromote = helper.BoolToPtr(*o.AutoPromote)
+	}
+
 	if o.Canary != nil {
 		u.Canary = helper.IntToPtr(*o.Canary)
 	}
@@ -434,6 +454,10 @@ func (u *UpdateStrategy) Canonicalize() {
 		u.HealthyDeadline = d.HealthyDeadline
 	}
 
+	if u.ProgressDeadline == nil {
+		u.ProgressDeadline = d.ProgressDeadline
+	}
+
 	if u.MinHealthyTime == nil {
 		u.MinHealthyTime = d.MinHealthyTime
 	}
@@ -442,6 +466,10 @@ func (u *UpdateStrategy) Canonicalize() {
 		u.AutoRevert = d.AutoRevert
 	}
 
+	if u.AutoPromote == nil {
+		u.AutoPromote = d.AutoPromote
+	}
+
 	if u.Canary == nil {
 		u.Canary = d.Canary
 	}
@@ -473,10 +501,18 @@ func (u *UpdateStrategy) Empty() bool {
 		return false
 	}
 
+	if u.ProgressDeadline != nil && *u.ProgressDeadline != 0 {
+		return false
+	}
+
 	if u.AutoRevert != nil && *u.AutoRevert {
 		return false
 	}
 
+	if u.AutoPromote != nil && *u.AutoPromote {
+		return false
+	}
+
 	if u.Canary != nil && *u.Canary != 0 {
 		return false
 	}
@@ -488,9 +524,12 @@ func (u *UpdateStrategy) Empty() bool {
 type PeriodicConfig struct {
 	Enabled         *bool
 	Spec            *string
+	Specs           []string
 	SpecType        *string
-	ProhibitOverlap *bool   `mapstructure:"prohibit_overlap"`
-	TimeZone        *string `mapstructure:"time_zone"`
+	ProhibitOverlap *bool          `mapstructure:"prohibit_overlap"`
+	CatchUp         *bool          `mapstructure:"catchup"`
+	CatchUpWindow   *time.Duration `mapstructure:"catchup_window"`
+	TimeZone        *string        `mapstructure:"time_zone"`
 }
 
 func (p *PeriodicConfig) Canonicalize() {
@@ -506,23 +545,53 @@ func (p *PeriodicConfig) Canonicalize() {
 	if p.ProhibitOverlap == nil {
 		p.ProhibitOverlap = helper.BoolToPtr(false)
 	}
+	if p.CatchUp == nil {
+		p.CatchUp = helper.BoolToPtr(false)
+	}
+	if p.CatchUpWindow == nil {
+		p.CatchUpWindow = helper.TimeToPtr(0)
+	}
 	if p.TimeZone == nil || *p.TimeZone == "" {
 		p.TimeZone = helper.StringToPtr("UTC")
 	}
 }
 
-// Next returns the closest time instant matching the spec that is after the
-// passed time. If no matching instance exists, the zero value of time.Time is
-// returned. The `time.Location` of the returned value matches that of the
-// passed time.
+// Next returns the closest time instant matching any of the specs that is
+// after the passed time. If no matching instance exists, the zero value of
+// time.Time is returned. The `time.Location` of the returned value matches
+// that of the passed time.
 func (p *PeriodicConfig) Next(fromTime time.Time) time.Time {
-	if *p.SpecType == PeriodicSpecCron {
-		if e, err := cronexpr.Parse(*p.Spec); err == nil {
-			return e.Next(fromTime)
+	if *p.SpecType != PeriodicSpecCron {
+		return time.Time{}
+	}
+
+	specs := p.Specs
+	if p.Spec != nil && *p.Spec != "" {
+		specs = append([]string{*p.Spec}, specs...)
+	}
+
+	var next time.Time
+	for _, spec := range specs {
+		e, err := cronexpr.Parse(spec)
+		if err != nil {
+			continue
+		}
+		if n := e.Next(fromTime); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
 		}
 	}
 
-	return time.Time{}
+	return next
+}
+
+// NextN returns up to n of the next time instants matching any of the specs
+// that are after the passed time, in increasing order.
+func (p *PeriodicConfig) NextN(fromTime time.Time, n int) []time.Time {
+	var launches []time.Time
+	for next := p.Next(fromTime); !next.IsZero() && len(launches) < n; next = p.Next(next) {
+		launches = append(launches, next)
+	}
+	return launches
 }
 
 func (p *PeriodicConfig) GetLocation() (*time.Location, error) {
@@ -553,6 +622,8 @@ type Job struct {
 	AllAtOnce         *bool `mapstructure:"all_at_once"`
 	Datacenters       []string
 	Constraints       []*Constraint
+	Affinities        []*Affinity
+	Spreads           []*Spread
 	TaskGroups        []*TaskGroup
 	Update            *UpdateStrategy
 	Periodic          *PeriodicConfig
@@ -643,6 +714,12 @@ func (j *Job) Canonicalize() {
 	if j.Update != nil {
 		j.Update.Canonicalize()
 	}
+	for _, a := range j.Affinities {
+		a.Canonicalize()
+	}
+	for _, s := range j.Spreads {
+		s.Canonicalize()
+	}
 
 	for _, tg := range j.TaskGroups {
 		tg.Canonicalize(j)
@@ -778,6 +855,18 @@ func (j *Job) Constrain(c *Constraint) *Job {
 	return j
 }
 
+// AddAffinity is used to add an affinity to a job.
+func (j *Job) AddAffinity(a *Affinity) *Job {
+	j.Affinities = append(j.Affinities, a)
+	return j
+}
+
+// AddSpread is used to add a spread stanza to a job.
+func (j *Job) AddSpread(s *Spread) *Job {
+	j.Spreads = append(j.Spreads, s)
+	return j
+}
+
 // AddTaskGroup adds a task group to an existing job.
 func (j *Job) AddTaskGroup(grp *TaskGroup) *Job {
 	j.TaskGroups = append(j.TaskGroups, grp)
@@ -894,6 +983,7 @@ type JobPlanResponse struct {
 	Diff               *JobDiff
 	Annotations        *PlanAnnotations
 	FailedTGAllocs     map[string]*AllocationMetric
+	PlacementMetrics   map[string]*AllocationMetric
 	NextPeriodicLaunch time.Time
 
 	// Warnings contains any warnings about the given job. These may include
@@ -942,6 +1032,7 @@ type ObjectDiff struct {
 
 type PlanAnnotations struct {
 	DesiredTGUpdates map[string]*DesiredUpdates
+	PreemptedAllocs  []*AllocationListStub
 }
 
 type DesiredUpdates struct {
@@ -952,6 +1043,7 @@ type DesiredUpdates struct {
 	InPlaceUpdate     uint64
 	DestructiveUpdate uint64
 	Canary            uint64
+	Preemptions       uint64
 }
 
 type JobDispatchRequest struct {
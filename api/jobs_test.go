@@ -317,13 +317,15 @@ func TestJobs_Canonicalize(t *testing.T) {
 				JobModifyIndex:    helper.Uint64ToPtr(0),
 				Datacenters:       []string{"dc1"},
 				Update: &UpdateStrategy{
-					Stagger:         helper.TimeToPtr(30 * time.Second),
-					MaxParallel:     helper.IntToPtr(1),
-					HealthCheck:     helper.StringToPtr("checks"),
-					MinHealthyTime:  helper.TimeToPtr(10 * time.Second),
-					HealthyDeadline: helper.TimeToPtr(5 * time.Minute),
-					AutoRevert:      helper.BoolToPtr(false),
-					Canary:          helper.IntToPtr(0),
+					Stagger:          helper.TimeToPtr(30 * time.Second),
+					MaxParallel:      helper.IntToPtr(1),
+					HealthCheck:      helper.StringToPtr("checks"),
+					MinHealthyTime:   helper.TimeToPtr(10 * time.Second),
+					HealthyDeadline:  helper.TimeToPtr(5 * time.Minute),
+					ProgressDeadline: helper.TimeToPtr(0),
+					AutoRevert:       helper.BoolToPtr(false),
+					AutoPromote:      helper.BoolToPtr(false),
+					Canary:           helper.IntToPtr(0),
 				},
 				TaskGroups: []*TaskGroup{
 					{
@@ -350,13 +352,15 @@ func TestJobs_Canonicalize(t *testing.T) {
 						},
 
 						Update: &UpdateStrategy{
-							Stagger:         helper.TimeToPtr(30 * time.Second),
-							MaxParallel:     helper.IntToPtr(1),
-							HealthCheck:     helper.StringToPtr("checks"),
-							MinHealthyTime:  helper.TimeToPtr(10 * time.Second),
-							HealthyDeadline: helper.TimeToPtr(5 * time.Minute),
-							AutoRevert:      helper.BoolToPtr(false),
-							Canary:          helper.IntToPtr(0),
+							Stagger:          helper.TimeToPtr(30 * time.Second),
+							MaxParallel:      helper.IntToPtr(1),
+							HealthCheck:      helper.StringToPtr("checks"),
+							MinHealthyTime:   helper.TimeToPtr(10 * time.Second),
+							HealthyDeadline:  helper.TimeToPtr(5 * time.Minute),
+							ProgressDeadline: helper.TimeToPtr(0),
+							AutoRevert:       helper.BoolToPtr(false),
+							AutoPromote:      helper.BoolToPtr(false),
+							Canary:           helper.IntToPtr(0),
 						},
 						Migrate: DefaultMigrateStrategy(),
 						Tasks: []*Task{
@@ -466,6 +470,8 @@ func TestJobs_Canonicalize(t *testing.T) {
 					Spec:            helper.StringToPtr(""),
 					SpecType:        helper.StringToPtr(PeriodicSpecCron),
 					ProhibitOverlap: helper.BoolToPtr(false),
+					CatchUp:         helper.BoolToPtr(false),
+					CatchUpWindow:   helper.TimeToPtr(0),
 					TimeZone:        helper.StringToPtr("UTC"),
 				},
 			},
@@ -532,13 +538,15 @@ func TestJobs_Canonicalize(t *testing.T) {
 				ModifyIndex:       helper.Uint64ToPtr(0),
 				JobModifyIndex:    helper.Uint64ToPtr(0),
 				Update: &UpdateStrategy{
-					Stagger:         helper.TimeToPtr(1 * time.Second),
-					MaxParallel:     helper.IntToPtr(1),
-					HealthCheck:     helper.StringToPtr("checks"),
-					MinHealthyTime:  helper.TimeToPtr(10 * time.Second),
-					HealthyDeadline: helper.TimeToPtr(6 * time.Minute),
-					AutoRevert:      helper.BoolToPtr(false),
-					Canary:          helper.IntToPtr(0),
+					Stagger:          helper.TimeToPtr(1 * time.Second),
+					MaxParallel:      helper.IntToPtr(1),
+					HealthCheck:      helper.StringToPtr("checks"),
+					MinHealthyTime:   helper.TimeToPtr(10 * time.Second),
+					HealthyDeadline:  helper.TimeToPtr(6 * time.Minute),
+					ProgressDeadline: helper.TimeToPtr(0),
+					AutoRevert:       helper.BoolToPtr(false),
+					AutoPromote:      helper.BoolToPtr(false),
+					Canary:           helper.IntToPtr(0),
 				},
 				TaskGroups: []*TaskGroup{
 					{
@@ -564,13 +572,15 @@ func TestJobs_Canonicalize(t *testing.T) {
 							Unlimited:     helper.BoolToPtr(true),
 						},
 						Update: &UpdateStrategy{
-							Stagger:         helper.TimeToPtr(2 * time.Second),
-							MaxParallel:     helper.IntToPtr(2),
-							HealthCheck:     helper.StringToPtr("manual"),
-							MinHealthyTime:  helper.TimeToPtr(1 * time.Second),
-							HealthyDeadline: helper.TimeToPtr(6 * time.Minute),
-							AutoRevert:      helper.BoolToPtr(true),
-							Canary:          helper.IntToPtr(1),
+							Stagger:          helper.TimeToPtr(2 * time.Second),
+							MaxParallel:      helper.IntToPtr(2),
+							HealthCheck:      helper.StringToPtr("manual"),
+							MinHealthyTime:   helper.TimeToPtr(1 * time.Second),
+							HealthyDeadline:  helper.TimeToPtr(6 * time.Minute),
+							ProgressDeadline: helper.TimeToPtr(0),
+							AutoRevert:       helper.BoolToPtr(true),
+							AutoPromote:      helper.BoolToPtr(false),
+							Canary:           helper.IntToPtr(1),
 						},
 						Migrate: DefaultMigrateStrategy(),
 						Tasks: []*Task{
@@ -605,13 +615,15 @@ func TestJobs_Canonicalize(t *testing.T) {
 							Unlimited:     helper.BoolToPtr(true),
 						},
 						Update: &UpdateStrategy{
-							Stagger:         helper.TimeToPtr(1 * time.Second),
-							MaxParallel:     helper.IntToPtr(1),
-							HealthCheck:     helper.StringToPtr("checks"),
-							MinHealthyTime:  helper.TimeToPtr(10 * time.Second),
-							HealthyDeadline: helper.TimeToPtr(6 * time.Minute),
-							AutoRevert:      helper.BoolToPtr(false),
-							Canary:          helper.IntToPtr(0),
+							Stagger:          helper.TimeToPtr(1 * time.Second),
+							MaxParallel:      helper.IntToPtr(1),
+							HealthCheck:      helper.StringToPtr("checks"),
+							MinHealthyTime:   helper.TimeToPtr(10 * time.Second),
+							HealthyDeadline:  helper.TimeToPtr(6 * time.Minute),
+							ProgressDeadline: helper.TimeToPtr(0),
+							AutoRevert:       helper.BoolToPtr(false),
+							AutoPromote:      helper.BoolToPtr(false),
+							Canary:           helper.IntToPtr(0),
 						},
 						Migrate: DefaultMigrateStrategy(),
 						Tasks: []*Task{
@@ -1037,6 +1049,25 @@ func TestJobs_ForceEvaluate(t *testing.T) {
 	t.Fatalf("evaluation %q missing", evalID)
 }
 
+func TestPeriodicConfig_NextN(t *testing.T) {
+	t.Parallel()
+	p := &PeriodicConfig{
+		Spec:  helper.StringToPtr("0 8 * * 1-5"),
+		Specs: []string{"0 10 * * 0,6"},
+	}
+	p.Canonicalize()
+
+	// Friday November 10 2017
+	from := time.Date(2017, time.November, 10, 9, 0, 0, 0, time.UTC)
+	expected := []time.Time{
+		time.Date(2017, time.November, 11, 10, 0, 0, 0, time.UTC),
+		time.Date(2017, time.November, 12, 10, 0, 0, 0, time.UTC),
+		time.Date(2017, time.November, 13, 8, 0, 0, 0, time.UTC),
+	}
+	require.Equal(t, expected, p.NextN(from, 3))
+	require.Equal(t, expected[0], p.Next(from))
+}
+
 func TestJobs_PeriodicForce(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t, nil, nil)
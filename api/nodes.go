@@ -440,10 +440,18 @@ type Node struct {
 	StatusUpdatedAt       int64
 	Events                []*NodeEvent
 	Drivers               map[string]*DriverInfo
+	HostVolumes           map[string]*HostVolumeInfo
 	CreateIndex           uint64
 	ModifyIndex           uint64
 }
 
+// HostVolumeInfo is a directory of the host made available to tasks as a
+// volume.
+type HostVolumeInfo struct {
+	Path     string
+	ReadOnly bool
+}
+
 // DrainStrategy describes a Node's drain behavior.
 type DrainStrategy struct {
 	// DrainSpec is the user declared drain specification
@@ -1,5 +1,7 @@
 package api
 
+import "io"
+
 // Operator can be used to perform low-level operator tasks for Nomad.
 type Operator struct {
 	c *Client
@@ -106,3 +108,30 @@ func (op *Operator) RaftRemovePeerByID(id string, q *WriteOptions) error {
 	resp.Body.Close()
 	return nil
 }
+
+// SnapshotSave is used to save a snapshot of the state of the cluster. The
+// snapshot is a gzipped archive that must be closed once read.
+func (op *Operator) SnapshotSave(q *QueryOptions) (io.ReadCloser, error) {
+	return op.c.rawQuery("/v1/operator/snapshot", q)
+}
+
+// SnapshotRestore is used to restore the state of the cluster from a
+// snapshot saved by SnapshotSave.
+func (op *Operator) SnapshotRestore(in io.Reader, q *WriteOptions) (*WriteMeta, error) {
+	r, err := op.c.newRequest("PUT", "/v1/operator/snapshot")
+	if err != nil {
+		return nil, err
+	}
+	r.setWriteOptions(q)
+	r.body = in
+
+	rtt, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	parseWriteMeta(resp, wm)
+	return wm, nil
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"strconv"
+	"time"
+)
+
+// SchedulerConfiguration is used for querying/setting the scheduler
+// configuration of the cluster. Unset values take their defaults when the
+// configuration is set while values set to zero are kept.
+type SchedulerConfiguration struct {
+	// SchedulerAlgorithm is the algorithm used to score the fit of
+	// allocations on nodes. It is either "binpack" or "spread".
+	SchedulerAlgorithm string
+
+	// MinNodeLimit is the minimum number of feasible nodes the service and
+	// batch schedulers score before picking the best one.
+	MinNodeLimit *int
+
+	// MaxSkippedNodes is the number of low scoring nodes, such as nodes
+	// already running the job, the service and batch schedulers may pass
+	// over in search of a better node.
+	MaxSkippedNodes *int
+
+	// ServiceJobAntiAffinityPenalty is the penalty applied to the score for
+	// placing an alloc on a node that already has an alloc for the same
+	// service job.
+	ServiceJobAntiAffinityPenalty *float64
+
+	// BatchJobAntiAffinityPenalty is the same as the
+	// ServiceJobAntiAffinityPenalty but for batch type jobs.
+	BatchJobAntiAffinityPenalty *float64
+
+	// MaxServiceScheduleAttempts is the number of times the service scheduler
+	// attempts to schedule an evaluation without making progress.
+	MaxServiceScheduleAttempts *int
+
+	// MaxBatchScheduleAttempts is the same as the MaxServiceScheduleAttempts
+	// but for batch type jobs.
+	MaxBatchScheduleAttempts *int
+
+	// DisableBlockedEvals stops the schedulers from creating blocked
+	// evaluations for allocations that can't be placed.
+	DisableBlockedEvals bool
+
+	// MemoryOversubscriptionEnabled allows tasks to set a MemoryMaxMB above
+	// the memory reserved for them by the scheduler.
+	MemoryOversubscriptionEnabled bool
+
+	// CreateIndex holds the index corresponding the creation of this configuration.
+	// This is a read-only field.
+	CreateIndex uint64
+
+	// ModifyIndex will be set to the index of the last update when retrieving
+	// the scheduler configuration.
+	ModifyIndex uint64
+}
+
+const (
+	// SchedulerAlgorithmBinpack packs allocations tightly onto as few nodes
+	// as possible.
+	SchedulerAlgorithmBinpack = "binpack"
+
+	// SchedulerAlgorithmSpread spreads allocations across as many nodes as
+	// possible.
+	SchedulerAlgorithmSpread = "spread"
+)
+
+// SchedulerConfigurationResponse is the response object that wraps the
+// scheduler configuration.
+type SchedulerConfigurationResponse struct {
+	// SchedulerConfig contains the scheduler configuration.
+	SchedulerConfig *SchedulerConfiguration
+
+	QueryMeta
+}
+
+// SchedulerSetConfigurationResponse is the response object returned when
+// setting the scheduler configuration.
+type SchedulerSetConfigurationResponse struct {
+	// Updated is false if a check-and-set update was rejected because the
+	// configuration had been modified since it was read.
+	Updated bool
+
+	WriteMeta
+}
+
+// SchedulerGetConfiguration is used to query the current scheduler configuration.
+func (op *Operator) SchedulerGetConfiguration(q *QueryOptions) (*SchedulerConfigurationResponse, *QueryMeta, error) {
+	var resp SchedulerConfigurationResponse
+	qm, err := op.c.query("/v1/operator/scheduler/configuration", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// SchedulerSetConfiguration is used to set the current scheduler configuration.
+func (op *Operator) SchedulerSetConfiguration(conf *SchedulerConfiguration, q *WriteOptions) (*SchedulerSetConfigurationResponse, *WriteMeta, error) {
+	var out SchedulerSetConfigurationResponse
+	wm, err := op.c.write("/v1/operator/scheduler/configuration", conf, &out, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
+// SchedulerCASConfiguration is used to perform a Check-And-Set update on the
+// scheduler configuration. The ModifyIndex value will be respected. The
+// response's Updated field is false if the configuration was modified since
+// it was read.
+func (op *Operator) SchedulerCASConfiguration(conf *SchedulerConfiguration, q *WriteOptions) (*SchedulerSetConfigurationResponse, *WriteMeta, error) {
+	var out SchedulerSetConfigurationResponse
+	wm, err := op.c.write("/v1/operator/scheduler/configuration?cas="+strconv.FormatUint(conf.ModifyIndex, 10), conf, &out, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
+// SchedulerQueueResponse is the state of the queues of the leader's
+// evaluation broker.
+type SchedulerQueueResponse struct {
+	// FairQueueing is whether the ready evaluations are dequeued with a
+	// weighted round-robin across namespaces rather than purely by priority.
+	FairQueueing bool
+
+	// MaxReadyPerJob is the cap on the number of ready evaluations of a job
+	// when fair queueing is enabled. Zero means no cap.
+	MaxReadyPerJob int
+
+	TotalReady     int
+	TotalUnacked   int
+	TotalBlocked   int
+	TotalWaiting   int
+	TotalThrottled int
+
+	// Namespaces is the state of the queues of each namespace.
+	Namespaces map[string]*NamespaceQueueStats
+}
+
+// NamespaceQueueStats is the state of the evaluation broker queues of a
+// namespace.
+type NamespaceQueueStats struct {
+	// Weight is the weight of the namespace in the fair queueing round-robin.
+	Weight int
+
+	// Ready, Unacked and Throttled are the number of evaluations of the
+	// namespace ready to be dequeued, dequeued but not yet acknowledged, and
+	// held back by the cap on the ready evaluations of a job.
+	Ready     int
+	Unacked   int
+	Throttled int
+
+	// ReadyWait is how long the oldest ready evaluation of the namespace has
+	// waited to be dequeued.
+	ReadyWait time.Duration
+}
+
+// SchedulerQueue is used to query the state of the queues of the eval broker.
+func (op *Operator) SchedulerQueue(q *QueryOptions) (*SchedulerQueueResponse, *QueryMeta, error) {
+	var resp SchedulerQueueResponse
+	qm, err := op.c.query("/v1/operator/scheduler/queue", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// SchedulerSimulateRequest describes hypothetical changes to the nodes and
+// jobs of the cluster to simulate the schedulers against.
+type SchedulerSimulateRequest struct {
+	// AddNodes are the groups of nodes to add to the cluster.
+	AddNodes []*SimulatedNodes
+
+	// RemoveNodeIDs are the IDs or ID prefixes of the nodes to remove.
+	RemoveNodeIDs []string
+
+	// DrainNodeIDs are the IDs or ID prefixes of the nodes to drain.
+	DrainNodeIDs []string
+
+	// Jobs are the jobs to register or update.
+	Jobs []*Job
+}
+
+// SimulatedNodes describes a group of nodes to add that are copies of a
+// template node. If no template node is given, any node of the class is used.
+type SimulatedNodes struct {
+	Count          int
+	NodeClass      string
+	TemplateNodeID string
+}
+
+// SchedulerSimulateResponse is the result of a scheduler simulation.
+type SchedulerSimulateResponse struct {
+	// Jobs are the results of the jobs that were given or whose allocations
+	// the changes to the cluster affected.
+	Jobs []*SimulatedJobResult
+
+	// Nodes is the utilization of the nodes after the simulation.
+	Nodes []*SimulatedNodeUtilization
+
+	WriteMeta
+}
+
+// SimulatedJobResult is the outcome of evaluating a job in a simulation.
+type SimulatedJobResult struct {
+	Namespace        string
+	JobID            string
+	DesiredTGUpdates map[string]*DesiredUpdates
+	FailedTGAllocs   map[string]*AllocationMetric
+}
+
+// SimulatedNodeUtilization is the utilization of a node after a simulation.
+// Change is set to "added", "drained" or "removed" if the simulation changed
+// the node.
+type SimulatedNodeUtilization struct {
+	ID         string
+	Name       string
+	Datacenter string
+	NodeClass  string
+	Change     string
+	Allocs     int
+	Capacity   *Resources
+	Used       *Resources
+}
+
+// SchedulerSimulate is used to simulate how the schedulers would react to
+// hypothetical changes to the cluster. Nothing is committed.
+func (op *Operator) SchedulerSimulate(req *SchedulerSimulateRequest, q *WriteOptions) (*SchedulerSimulateResponse, *WriteMeta, error) {
+	var out SchedulerSimulateResponse
+	wm, err := op.c.write("/v1/operator/scheduler/simulate", req, &out, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
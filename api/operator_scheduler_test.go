@@ -0,0 +1,74 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/helper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_OperatorSchedulerGetSetConfiguration(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	// The default configuration bin packs
+	operator := c.Operator()
+	resp, _, err := operator.SchedulerGetConfiguration(nil)
+	require.Nil(err)
+	require.Equal(SchedulerAlgorithmBinpack, resp.SchedulerConfig.SchedulerAlgorithm)
+
+	// Switch to spreading
+	newConf := &SchedulerConfiguration{SchedulerAlgorithm: SchedulerAlgorithmSpread}
+	_, wm, err := operator.SchedulerSetConfiguration(newConf, nil)
+	require.Nil(err)
+	require.NotZero(wm.LastIndex)
+
+	resp, _, err = operator.SchedulerGetConfiguration(nil)
+	require.Nil(err)
+	require.Equal(SchedulerAlgorithmSpread, resp.SchedulerConfig.SchedulerAlgorithm)
+}
+
+func TestAPI_OperatorSchedulerQueue(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	resp, qm, err := c.Operator().SchedulerQueue(nil)
+	require.Nil(err)
+	require.NotNil(qm)
+	require.False(resp.FairQueueing)
+	require.NotNil(resp.Namespaces)
+}
+
+func TestAPI_OperatorSchedulerCASConfiguration(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	operator := c.Operator()
+	resp, _, err := operator.SchedulerGetConfiguration(nil)
+	require.Nil(err)
+	conf := resp.SchedulerConfig
+
+	// Update the config using the index that was read. Zero values are kept.
+	conf.MinNodeLimit = helper.IntToPtr(4)
+	conf.ServiceJobAntiAffinityPenalty = helper.Float64ToPtr(0)
+	setResp, _, err := operator.SchedulerCASConfiguration(conf, nil)
+	require.Nil(err)
+	require.True(setResp.Updated)
+
+	// The stale index is now rejected
+	conf.MinNodeLimit = helper.IntToPtr(8)
+	setResp, _, err = operator.SchedulerCASConfiguration(conf, nil)
+	require.Nil(err)
+	require.False(setResp.Updated)
+
+	resp, _, err = operator.SchedulerGetConfiguration(nil)
+	require.Nil(err)
+	require.Equal(4, *resp.SchedulerConfig.MinNodeLimit)
+	require.Zero(*resp.SchedulerConfig.ServiceJobAntiAffinityPenalty)
+}
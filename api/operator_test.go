@@ -1,8 +1,12 @@
 package api
 
 import (
+	"bytes"
+	"io/ioutil"
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
 )
 
 func TestOperator_RaftGetConfiguration(t *testing.T) {
@@ -51,3 +55,34 @@ func TestOperator_RaftRemovePeerByID(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 }
+
+func TestOperator_SnapshotSaveRestore(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	operator := c.Operator()
+	var snap []byte
+	testutil.WaitForResult(func() (bool, error) {
+		r, err := operator.SnapshotSave(nil)
+		if err != nil {
+			return false, err
+		}
+		defer r.Close()
+
+		snap, err = ioutil.ReadAll(r)
+		return len(snap) != 0, err
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	if _, err := operator.SnapshotRestore(bytes.NewReader(snap), nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A truncated snapshot is rejected
+	_, err := operator.SnapshotRestore(bytes.NewReader(snap[:len(snap)/2]), nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to restore from snapshot") {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -5,11 +5,18 @@ import "github.com/hashicorp/nomad/helper"
 // Resources encapsulates the required resources of
 // a given task or task group.
 type Resources struct {
-	CPU      *int
-	MemoryMB *int `mapstructure:"memory"`
-	DiskMB   *int `mapstructure:"disk"`
-	IOPS     *int
-	Networks []*NetworkResource
+	CPU         *int
+	MemoryMB    *int `mapstructure:"memory"`
+	MemoryMaxMB *int `mapstructure:"memory_max"`
+	DiskMB      *int `mapstructure:"disk"`
+	IOPS        *int
+	Cores       *int
+	Networks    []*NetworkResource
+	Devices     []*DeviceResource
+
+	// CoreIDs are the cores of a node that can be dedicated to tasks or the
+	// cores dedicated to a task of an allocation.
+	CoreIDs []uint16
 }
 
 // Canonicalize will supply missing values in the cases
@@ -28,6 +35,9 @@ func (r *Resources) Canonicalize() {
 	for _, n := range r.Networks {
 		n.Canonicalize()
 	}
+	for _, d := range r.Devices {
+		d.Canonicalize()
+	}
 }
 
 // DefaultResources is a small resources object that contains the
@@ -66,25 +76,36 @@ func (r *Resources) Merge(other *Resources) {
 	if other.MemoryMB != nil {
 		r.MemoryMB = other.MemoryMB
 	}
+	if other.MemoryMaxMB != nil {
+		r.MemoryMaxMB = other.MemoryMaxMB
+	}
 	if other.DiskMB != nil {
 		r.DiskMB = other.DiskMB
 	}
 	if other.IOPS != nil {
 		r.IOPS = other.IOPS
 	}
+	if other.Cores != nil {
+		r.Cores = other.Cores
+	}
 	if len(other.Networks) != 0 {
 		r.Networks = other.Networks
 	}
+	if len(other.Devices) != 0 {
+		r.Devices = other.Devices
+	}
 }
 
 type Port struct {
 	Label string
 	Value int `mapstructure:"static"`
+	To    int `mapstructure:"to"`
 }
 
 // NetworkResource is used to describe required network
 // resources of a given task.
 type NetworkResource struct {
+	Mode          string
 	Device        string
 	CIDR          string
 	IP            string
@@ -98,3 +119,27 @@ func (n *NetworkResource) Canonicalize() {
 		n.MBits = helper.IntToPtr(10)
 	}
 }
+
+// DeviceResource is used to request a number of instances of a device. The
+// device is identified by its vendor, type and name, of which only the type
+// is required. In allocations it lists the instances assigned to a task.
+type DeviceResource struct {
+	Vendor     string
+	Type       string
+	Name       string
+	Count      *uint64
+	Instances  []*DeviceInstance
+	Attributes map[string]string
+}
+
+// DeviceInstance is a single instance of a device.
+type DeviceInstance struct {
+	ID      string
+	Healthy bool
+}
+
+func (d *DeviceResource) Canonicalize() {
+	if d.Count == nil {
+		d.Count = helper.Uint64ToPtr(1)
+	}
+}
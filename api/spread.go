@@ -0,0 +1,41 @@
+package api
+
+import "github.com/hashicorp/nomad/helper"
+
+// Spread is used to serialize a job spread stanza, which distributes
+// allocations across the values of a node attribute.
+type Spread struct {
+	Attribute    string
+	Weight       *int
+	SpreadTarget []*SpreadTarget
+}
+
+// SpreadTarget is used to serialize the desired percentage of allocations
+// for a single value of the spread attribute.
+type SpreadTarget struct {
+	Value   string
+	Percent uint32
+}
+
+// NewSpread generates a new spread stanza for the given attribute.
+func NewSpread(attribute string, weight int, spreadTargets []*SpreadTarget) *Spread {
+	return &Spread{
+		Attribute:    attribute,
+		Weight:       helper.IntToPtr(weight),
+		SpreadTarget: spreadTargets,
+	}
+}
+
+// NewSpreadTarget generates a new spread target for the given attribute value.
+func NewSpreadTarget(value string, percent uint32) *SpreadTarget {
+	return &SpreadTarget{
+		Value:   value,
+		Percent: percent,
+	}
+}
+
+func (s *Spread) Canonicalize() {
+	if s.Weight == nil {
+		s.Weight = helper.IntToPtr(50)
+	}
+}
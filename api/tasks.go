@@ -410,6 +410,8 @@ type TaskGroup struct {
 	Name             *string
 	Count            *int
 	Constraints      []*Constraint
+	Affinities       []*Affinity
+	Spreads          []*Spread
 	Tasks            []*Task
 	RestartPolicy    *RestartPolicy
 	ReschedulePolicy *ReschedulePolicy
@@ -417,6 +419,8 @@ type TaskGroup struct {
 	Update           *UpdateStrategy
 	Migrate          *MigrateStrategy
 	Meta             map[string]string
+	Volumes          map[string]*VolumeRequest
+	Networks         []*NetworkResource
 }
 
 // NewTaskGroup creates a new TaskGroup.
@@ -442,6 +446,18 @@ func (g *TaskGroup) Canonicalize(job *Job) {
 	} else {
 		g.EphemeralDisk.Canonicalize()
 	}
+	for _, a := range g.Affinities {
+		a.Canonicalize()
+	}
+	for _, s := range g.Spreads {
+		s.Canonicalize()
+	}
+	for name, v := range g.Volumes {
+		v.Canonicalize(name)
+	}
+	for _, n := range g.Networks {
+		n.Canonicalize()
+	}
 
 	// Merge the update policy from the job
 	if ju, tu := job.Update != nil, g.Update != nil; ju && tu {
@@ -525,6 +541,18 @@ func (g *TaskGroup) Constrain(c *Constraint) *TaskGroup {
 	return g
 }
 
+// AddAffinity is used to add an affinity to a task group.
+func (g *TaskGroup) AddAffinity(a *Affinity) *TaskGroup {
+	g.Affinities = append(g.Affinities, a)
+	return g
+}
+
+// AddSpread is used to add a spread stanza to a task group.
+func (g *TaskGroup) AddSpread(s *Spread) *TaskGroup {
+	g.Spreads = append(g.Spreads, s)
+	return g
+}
+
 // AddMeta is used to add a meta k/v pair to a task group
 func (g *TaskGroup) SetMeta(key, val string) *TaskGroup {
 	if g.Meta == nil {
@@ -548,8 +576,12 @@ func (g *TaskGroup) RequireDisk(disk *EphemeralDisk) *TaskGroup {
 
 // LogConfig provides configuration for log rotation
 type LogConfig struct {
-	MaxFiles      *int `mapstructure:"max_files"`
-	MaxFileSizeMB *int `mapstructure:"max_file_size"`
+	MaxFiles         *int           `mapstructure:"max_files"`
+	MaxFileSizeMB    *int           `mapstructure:"max_file_size"`
+	RotationInterval *time.Duration `mapstructure:"rotation_interval"`
+	Compress         *bool          `mapstructure:"compress"`
+	MaxTotalSizeMB   *int           `mapstructure:"max_total_size"`
+	Shippers         []*LogShipper  `mapstructure:"shipper"`
 }
 
 func DefaultLogConfig() *LogConfig {
@@ -566,6 +598,45 @@ func (l *LogConfig) Canonicalize() {
 	if l.MaxFileSizeMB == nil {
 		l.MaxFileSizeMB = helper.IntToPtr(10)
 	}
+	if l.RotationInterval == nil {
+		l.RotationInterval = helper.TimeToPtr(0)
+	}
+	if l.Compress == nil {
+		l.Compress = helper.BoolToPtr(false)
+	}
+	if l.MaxTotalSizeMB == nil {
+		l.MaxTotalSizeMB = helper.IntToPtr(0)
+	}
+	for _, s := range l.Shippers {
+		s.Canonicalize()
+	}
+}
+
+// LogShipper configures shipping the log lines of a task to a sink
+type LogShipper struct {
+	Type          *string        `mapstructure:"type"`
+	Address       *string        `mapstructure:"address"`
+	BufferSize    *int           `mapstructure:"buffer_size"`
+	BatchSize     *int           `mapstructure:"batch_size"`
+	FlushInterval *time.Duration `mapstructure:"flush_interval"`
+}
+
+func (s *LogShipper) Canonicalize() {
+	if s.Type == nil {
+		s.Type = helper.StringToPtr("")
+	}
+	if s.Address == nil {
+		s.Address = helper.StringToPtr("")
+	}
+	if s.BufferSize == nil {
+		s.BufferSize = helper.IntToPtr(1024)
+	}
+	if s.BatchSize == nil {
+		s.BatchSize = helper.IntToPtr(100)
+	}
+	if s.FlushInterval == nil {
+		s.FlushInterval = helper.TimeToPtr(1 * time.Second)
+	}
 }
 
 // DispatchPayloadConfig configures how a task gets its input from a job dispatch
@@ -573,6 +644,37 @@ type DispatchPayloadConfig struct {
 	File string
 }
 
+// TaskLifecycle configures when a task is run relative to the main tasks of
+// its group.
+type TaskLifecycle struct {
+	Hook    string `mapstructure:"hook"`
+	Sidecar bool   `mapstructure:"sidecar"`
+}
+
+// VolumeRequest is a volume the tasks of a task group can mount.
+type VolumeRequest struct {
+	Name     string
+	Type     string
+	Source   string
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+func (v *VolumeRequest) Canonicalize(name string) {
+	if v.Name == "" {
+		v.Name = name
+	}
+	if v.Type == "" {
+		v.Type = "host"
+	}
+}
+
+// VolumeMount mounts a volume of the task group into a task.
+type VolumeMount struct {
+	Volume      string
+	Destination string
+	ReadOnly    bool `mapstructure:"read_only"`
+}
+
 // Task is a single process in a task group.
 type Task struct {
 	Name            string
@@ -580,6 +682,7 @@ type Task struct {
 	User            string
 	Config          map[string]interface{}
 	Constraints     []*Constraint
+	Affinities      []*Affinity
 	Env             map[string]string
 	Services        []*Service
 	Resources       *Resources
@@ -591,8 +694,10 @@ type Task struct {
 	Templates       []*Template
 	DispatchPayload *DispatchPayloadConfig
 	Leader          bool
-	ShutdownDelay   time.Duration `mapstructure:"shutdown_delay"`
-	KillSignal      string        `mapstructure:"kill_signal"`
+	Lifecycle       *TaskLifecycle
+	VolumeMounts    []*VolumeMount `mapstructure:"volume_mount"`
+	ShutdownDelay   time.Duration  `mapstructure:"shutdown_delay"`
+	KillSignal      string         `mapstructure:"kill_signal"`
 }
 
 func (t *Task) Canonicalize(tg *TaskGroup, job *Job) {
@@ -620,6 +725,9 @@ func (t *Task) Canonicalize(tg *TaskGroup, job *Job) {
 	for _, s := range t.Services {
 		s.Canonicalize(t, tg, job)
 	}
+	for _, a := range t.Affinities {
+		a.Canonicalize()
+	}
 }
 
 // TaskArtifact is used to download artifacts before running a task.
@@ -768,6 +876,12 @@ func (t *Task) Constrain(c *Constraint) *Task {
 	return t
 }
 
+// AddAffinity adds a new affinity to a single task.
+func (t *Task) AddAffinity(a *Affinity) *Task {
+	t.Affinities = append(t.Affinities, a)
+	return t
+}
+
 // SetLogConfig sets a log config to a task
 func (t *Task) SetLogConfig(l *LogConfig) *Task {
 	t.LogConfig = l
@@ -805,6 +919,7 @@ const (
 	TaskSignaling              = "Signaling"
 	TaskRestartSignal          = "Restart Signaled"
 	TaskLeaderDead             = "Leader Task Dead"
+	TaskMainDead               = "Main Tasks Dead"
 	TaskBuildingTaskDir        = "Building Task Directory"
 )
 
@@ -183,7 +183,7 @@ func TestTask_Require(t *testing.T) {
 			{
 				CIDR:          "0.0.0.0/0",
 				MBits:         helper.IntToPtr(100),
-				ReservedPorts: []Port{{"", 80}, {"", 443}},
+				ReservedPorts: []Port{{"", 80, 0}, {"", 443, 0}},
 			},
 		},
 	}
@@ -269,6 +269,29 @@ func TestTaskGroup_Canonicalize_Update(t *testing.T) {
 	assert.Nil(t, tg.Update)
 }
 
+func TestTaskGroup_Canonicalize_Volumes(t *testing.T) {
+	job := &Job{
+		ID: helper.StringToPtr("test"),
+	}
+	job.Canonicalize()
+	tg := &TaskGroup{
+		Name: helper.StringToPtr("foo"),
+		Volumes: map[string]*VolumeRequest{
+			"data": {
+				Source: "shared-data",
+			},
+		},
+	}
+	tg.Canonicalize(job)
+
+	expected := &VolumeRequest{
+		Name:   "data",
+		Type:   "host",
+		Source: "shared-data",
+	}
+	assert.Equal(t, expected, tg.Volumes["data"])
+}
+
 // Verifies that reschedule policy is merged correctly
 func TestTaskGroup_Canonicalize_ReschedulePolicy(t *testing.T) {
 	type testCase struct {
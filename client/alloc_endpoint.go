@@ -1,12 +1,26 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/client/driver"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
 	nstructs "github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+var (
+	commandNotPresentErr = fmt.Errorf("must provide a command")
 )
 
 // Allocations endpoint is used for interacting with client allocations
@@ -14,6 +28,26 @@ type Allocations struct {
 	c *Client
 }
 
+func NewAllocationsEndpoint(c *Client) *Allocations {
+	a := &Allocations{c}
+	a.c.streamingRpcs.Register("Allocations.Exec", a.exec)
+	return a
+}
+
+// handleStreamResultError is a helper for sending an error with a potential
+// error code. The transmission of the error is ignored if the error has been
+// generated by the closing of the underlying transport.
+func (a *Allocations) handleStreamResultError(err error, code *int64, encoder *codec.Encoder) {
+	// Nothing to do as the conn is closed
+	if err == io.EOF || strings.Contains(err.Error(), "closed") {
+		return
+	}
+
+	encoder.Encode(&cstructs.StreamErrWrapper{
+		Error: cstructs.NewRpcError(err, code),
+	})
+}
+
 // GarbageCollectAll is used to garbage collect all allocations on a client.
 func (a *Allocations) GarbageCollectAll(args *nstructs.NodeSpecificRequest, reply *nstructs.GenericResponse) error {
 	defer metrics.MeasureSince([]string{"client", "allocations", "garbage_collect_all"}, time.Now())
@@ -48,6 +82,44 @@ func (a *Allocations) GarbageCollect(args *nstructs.AllocSpecificRequest, reply
 	return nil
 }
 
+// Restart is used to restart the tasks of an allocation on a client.
+func (a *Allocations) Restart(args *nstructs.AllocRestartRequest, reply *nstructs.GenericResponse) error {
+	defer metrics.MeasureSince([]string{"client", "allocations", "restart"}, time.Now())
+
+	alloc, err := a.c.GetClientAlloc(args.AllocID)
+	if err != nil {
+		return err
+	}
+
+	// Check submit job permissions on the namespace of the allocation
+	if aclObj, err := a.c.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilitySubmitJob) {
+		return nstructs.ErrPermissionDenied
+	}
+
+	return a.c.RestartAllocation(args.AllocID, args.TaskName)
+}
+
+// Signal is used to send a signal to the tasks of an allocation on a client.
+func (a *Allocations) Signal(args *nstructs.AllocSignalRequest, reply *nstructs.GenericResponse) error {
+	defer metrics.MeasureSince([]string{"client", "allocations", "signal"}, time.Now())
+
+	alloc, err := a.c.GetClientAlloc(args.AllocID)
+	if err != nil {
+		return err
+	}
+
+	// Check submit job permissions on the namespace of the allocation
+	if aclObj, err := a.c.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilitySubmitJob) {
+		return nstructs.ErrPermissionDenied
+	}
+
+	return a.c.SignalAllocation(args.AllocID, args.Task, args.Signal)
+}
+
 // Stats is used to collect allocation statistics
 func (a *Allocations) Stats(args *cstructs.AllocStatsRequest, reply *cstructs.AllocStatsResponse) error {
 	defer metrics.MeasureSince([]string{"client", "allocations", "stats"}, time.Now())
@@ -73,3 +145,175 @@ func (a *Allocations) Stats(args *cstructs.AllocStatsRequest, reply *cstructs.Al
 	reply.Stats = stats
 	return nil
 }
+
+// exec is used to execute a command inside a running task. The request is
+// followed by a stream of ExecStreamingInput messages and answered with a
+// stream of JSON encoded ExecStreamingOutput frames.
+func (a *Allocations) exec(conn io.ReadWriteCloser) {
+	defer metrics.MeasureSince([]string{"client", "allocations", "exec"}, time.Now())
+	defer conn.Close()
+
+	// Decode the arguments
+	var req cstructs.AllocExecRequest
+	decoder := codec.NewDecoder(conn, nstructs.MsgpackHandle)
+	encoder := codec.NewEncoder(conn, nstructs.MsgpackHandle)
+
+	if err := decoder.Decode(&req); err != nil {
+		a.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+
+	// Validate the arguments
+	if req.AllocID == "" {
+		a.handleStreamResultError(allocIDNotPresentErr, helper.Int64ToPtr(400), encoder)
+		return
+	}
+	if req.Task == "" {
+		a.handleStreamResultError(taskNotPresentErr, helper.Int64ToPtr(400), encoder)
+		return
+	}
+	if len(req.Cmd) == 0 {
+		a.handleStreamResultError(commandNotPresentErr, helper.Int64ToPtr(400), encoder)
+		return
+	}
+
+	alloc, err := a.c.GetClientAlloc(req.AllocID)
+	if err != nil {
+		a.handleStreamResultError(err, helper.Int64ToPtr(404), encoder)
+		return
+	}
+
+	// Check exec permissions on the namespace of the allocation
+	if aclObj, err := a.c.ResolveToken(req.QueryOptions.AuthToken); err != nil {
+		a.handleStreamResultError(err, nil, encoder)
+		return
+	} else if aclObj != nil && !aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilityAllocExec) {
+		a.handleStreamResultError(nstructs.ErrPermissionDenied, nil, encoder)
+		return
+	}
+
+	handle, err := a.c.getTaskHandle(req.AllocID, req.Task)
+	if err != nil {
+		code := helper.Int64ToPtr(400)
+		if nstructs.IsErrUnknownAllocation(err) {
+			code = helper.Int64ToPtr(404)
+		}
+
+		a.handleStreamResultError(err, code, encoder)
+		return
+	}
+
+	executor, ok := handle.(driver.StreamingExecutor)
+	if !ok {
+		a.handleStreamResultError(fmt.Errorf("task %q driver does not support exec", req.Task),
+			helper.Int64ToPtr(400), encoder)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := &execOutput{encoder: encoder}
+	stdinR, stdinW := io.Pipe()
+	resizeCh := make(chan dstructs.TerminalSize, 1)
+
+	// Forward the input of the remote side until it closes the connection
+	go func() {
+		defer cancel()
+		for {
+			var input cstructs.ExecStreamingInput
+			if err := decoder.Decode(&input); err != nil {
+				stdinW.CloseWithError(err)
+				return
+			}
+
+			if input.Stdin != nil {
+				if len(input.Stdin.Data) > 0 {
+					if _, err := stdinW.Write(input.Stdin.Data); err != nil {
+						return
+					}
+				}
+				if input.Stdin.Close {
+					stdinW.Close()
+				}
+			}
+
+			if input.TTYSize != nil {
+				size := dstructs.TerminalSize{
+					Height: uint16(input.TTYSize.Height),
+					Width:  uint16(input.TTYSize.Width),
+				}
+
+				// Only the latest size matters
+				select {
+				case <-resizeCh:
+				default:
+				}
+				resizeCh <- size
+			}
+		}
+	}()
+
+	exitCode, err := executor.ExecStreaming(ctx, &dstructs.ExecStreamingOptions{
+		Command:  req.Cmd,
+		Tty:      req.Tty,
+		Stdin:    stdinR,
+		Stdout:   out.writer(false),
+		Stderr:   out.writer(true),
+		ResizeCh: resizeCh,
+	})
+	stdinR.Close()
+	if err != nil {
+		a.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+
+	out.send(&cstructs.ExecStreamingOutput{
+		Exited: true,
+		Result: &cstructs.ExecStreamingExitResult{ExitCode: exitCode},
+	})
+}
+
+// execOutput serializes the output frames of an exec session to the
+// connection.
+type execOutput struct {
+	encoder *codec.Encoder
+	buf     bytes.Buffer
+	l       sync.Mutex
+}
+
+// send encodes the frame as JSON and sends it as the payload of a stream
+// message.
+func (o *execOutput) send(frame *cstructs.ExecStreamingOutput) error {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	o.buf.Reset()
+	if err := codec.NewEncoder(&o.buf, nstructs.JsonHandle).Encode(frame); err != nil {
+		return err
+	}
+	return o.encoder.Encode(&cstructs.StreamErrWrapper{Payload: o.buf.Bytes()})
+}
+
+// writer returns a writer sending its writes as stdout or stderr frames.
+func (o *execOutput) writer(stderr bool) io.Writer {
+	return execOutputWriter{out: o, stderr: stderr}
+}
+
+type execOutputWriter struct {
+	out    *execOutput
+	stderr bool
+}
+
+func (w execOutputWriter) Write(p []byte) (int, error) {
+	op := &cstructs.ExecStreamingIOOperation{Data: p}
+	frame := &cstructs.ExecStreamingOutput{Stdout: op}
+	if w.stderr {
+		frame = &cstructs.ExecStreamingOutput{Stderr: op}
+	}
+
+	if err := w.out.send(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -1,16 +1,23 @@
 package client
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/client/config"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/mock"
 	nstructs "github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
 )
 
 func TestAllocations_GarbageCollectAll(t *testing.T) {
@@ -173,6 +180,278 @@ func TestAllocations_GarbageCollect_ACL(t *testing.T) {
 	}
 }
 
+// testRunningAlloc places an allocation running a mock_driver task on the
+// client through the server and waits for the task to run.
+func testRunningAlloc(t *testing.T, server *nomad.Server, client *Client) *nstructs.Allocation {
+	a := mock.Alloc()
+	a.NodeID = client.NodeID()
+	a.Job.TaskGroups[0].Count = 1
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"run_for": "20s",
+	}
+
+	// Wait for the client to connect
+	testutil.WaitForResult(func() (bool, error) {
+		node, err := server.State().NodeByID(nil, client.NodeID())
+		if err != nil {
+			return false, err
+		}
+		if node == nil {
+			return false, fmt.Errorf("unknown node")
+		}
+
+		return node.Status == nstructs.NodeStatusReady, fmt.Errorf("bad node status")
+	}, func(err error) {
+		t.Fatal(err)
+	})
+
+	state := server.State()
+	require.Nil(t, state.UpsertNamespace(998, &nstructs.Namespace{Name: nstructs.DefaultNamespace}))
+	require.Nil(t, state.UpsertJob(999, a.Job))
+	require.Nil(t, state.UpsertAllocs(1003, []*nstructs.Allocation{a}))
+
+	// Wait for the task to be running
+	testutil.WaitForResult(func() (bool, error) {
+		_, err := client.getTaskHandle(a.ID, task.Name)
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("task not running: %v", err)
+	})
+
+	return a
+}
+
+func TestAllocations_Restart(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	client := TestClient(t, nil)
+	defer client.Shutdown()
+
+	a := mock.Alloc()
+	a.Job.TaskGroups[0].Tasks[0].Driver = "mock_driver"
+	a.Job.TaskGroups[0].Tasks[0].Config = map[string]interface{}{
+		"run_for": "20s",
+	}
+	require.Nil(client.addAlloc(a, ""))
+
+	// Try with bad alloc
+	req := &nstructs.AllocRestartRequest{}
+	var resp nstructs.GenericResponse
+	err := client.ClientRPC("Allocations.Restart", &req, &resp)
+	require.True(nstructs.IsErrUnknownAllocation(err))
+
+	// Try with an unknown task
+	req.AllocID = a.ID
+	req.TaskName = "foo"
+	err = client.ClientRPC("Allocations.Restart", &req, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), "unknown task name")
+
+	// Wait for the task to run and restart it
+	req.TaskName = a.Job.TaskGroups[0].Tasks[0].Name
+	testutil.WaitForResult(func() (bool, error) {
+		ar, err := client.getAllocRunner(a.ID)
+		if err != nil {
+			return false, err
+		}
+		state := ar.Alloc().TaskStates[req.TaskName]
+		if state == nil || state.State != nstructs.TaskStateRunning {
+			return false, fmt.Errorf("task not running")
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	require.Nil(client.ClientRPC("Allocations.Restart", &req, &resp))
+
+	testutil.WaitForResult(func() (bool, error) {
+		ar, err := client.getAllocRunner(a.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, e := range ar.Alloc().TaskStates[req.TaskName].Events {
+			if e.Type == nstructs.TaskRestartSignal {
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("no restart event")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestAllocations_Restart_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	server, addr, root := testACLServer(t, nil)
+	defer server.Shutdown()
+
+	client := TestClient(t, func(c *config.Config) {
+		c.Servers = []string{addr}
+		c.ACLEnabled = true
+	})
+	defer client.Shutdown()
+
+	a := testRunningAlloc(t, server, client)
+
+	// Try request without a token and expect failure
+	{
+		req := &nstructs.AllocRestartRequest{AllocID: a.ID}
+		var resp nstructs.GenericResponse
+		err := client.ClientRPC("Allocations.Restart", &req, &resp)
+		require.NotNil(err)
+		require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+	}
+
+	// Try request with an invalid token and expect failure
+	{
+		token := mock.CreatePolicyAndToken(t, server.State(), 1005, "invalid",
+			mock.NamespacePolicy(nstructs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+		req := &nstructs.AllocRestartRequest{AllocID: a.ID}
+		req.AuthToken = token.SecretID
+		req.Namespace = nstructs.DefaultNamespace
+
+		var resp nstructs.GenericResponse
+		err := client.ClientRPC("Allocations.Restart", &req, &resp)
+		require.NotNil(err)
+		require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+	}
+
+	// Try request with a token for another namespace and expect failure
+	{
+		token := mock.CreatePolicyAndToken(t, server.State(), 1006, "other-namespace",
+			mock.NamespacePolicy("other", "", []string{acl.NamespaceCapabilitySubmitJob}))
+		req := &nstructs.AllocRestartRequest{AllocID: a.ID}
+		req.AuthToken = token.SecretID
+		req.Namespace = "other"
+
+		var resp nstructs.GenericResponse
+		err := client.ClientRPC("Allocations.Restart", &req, &resp)
+		require.NotNil(err)
+		require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+	}
+
+	// Try request with a valid token
+	{
+		token := mock.CreatePolicyAndToken(t, server.State(), 1007, "test-valid",
+			mock.NamespacePolicy(nstructs.DefaultNamespace, "", []string{acl.NamespaceCapabilitySubmitJob}))
+		req := &nstructs.AllocRestartRequest{AllocID: a.ID}
+		req.AuthToken = token.SecretID
+		req.Namespace = nstructs.DefaultNamespace
+
+		var resp nstructs.GenericResponse
+		require.Nil(client.ClientRPC("Allocations.Restart", &req, &resp))
+	}
+
+	// Try request with a management token
+	{
+		req := &nstructs.AllocRestartRequest{AllocID: a.ID}
+		req.AuthToken = root.SecretID
+
+		var resp nstructs.GenericResponse
+		require.Nil(client.ClientRPC("Allocations.Restart", &req, &resp))
+	}
+}
+
+func TestAllocations_Signal(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	client := TestClient(t, nil)
+	defer client.Shutdown()
+
+	a := mock.Alloc()
+	a.Job.TaskGroups[0].Tasks[0].Driver = "mock_driver"
+	a.Job.TaskGroups[0].Tasks[0].Config = map[string]interface{}{
+		"run_for": "20s",
+	}
+	require.Nil(client.addAlloc(a, ""))
+
+	// Try with bad alloc
+	req := &nstructs.AllocSignalRequest{Signal: "SIGUSR1"}
+	var resp nstructs.GenericResponse
+	err := client.ClientRPC("Allocations.Signal", &req, &resp)
+	require.True(nstructs.IsErrUnknownAllocation(err))
+
+	// Try with a bad signal
+	req.AllocID = a.ID
+	req.Signal = "SIGFOO"
+	err = client.ClientRPC("Allocations.Signal", &req, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid signal")
+
+	// Try with an unknown task
+	req.Signal = "SIGUSR1"
+	req.Task = "foo"
+	err = client.ClientRPC("Allocations.Signal", &req, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), "unknown task name")
+
+	// Signal all tasks of the allocation
+	req.Task = ""
+	require.Nil(client.ClientRPC("Allocations.Signal", &req, &resp))
+}
+
+func TestAllocations_Signal_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	server, addr, root := testACLServer(t, nil)
+	defer server.Shutdown()
+
+	client := TestClient(t, func(c *config.Config) {
+		c.Servers = []string{addr}
+		c.ACLEnabled = true
+	})
+	defer client.Shutdown()
+
+	a := testRunningAlloc(t, server, client)
+
+	// Try request without a token and expect failure
+	{
+		req := &nstructs.AllocSignalRequest{AllocID: a.ID, Signal: "SIGUSR1"}
+		var resp nstructs.GenericResponse
+		err := client.ClientRPC("Allocations.Signal", &req, &resp)
+		require.NotNil(err)
+		require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+	}
+
+	// Try request with a token for another namespace and expect failure
+	{
+		token := mock.CreatePolicyAndToken(t, server.State(), 1005, "other-namespace",
+			mock.NamespacePolicy("other", "", []string{acl.NamespaceCapabilitySubmitJob}))
+		req := &nstructs.AllocSignalRequest{AllocID: a.ID, Signal: "SIGUSR1"}
+		req.AuthToken = token.SecretID
+		req.Namespace = "other"
+
+		var resp nstructs.GenericResponse
+		err := client.ClientRPC("Allocations.Signal", &req, &resp)
+		require.NotNil(err)
+		require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+	}
+
+	// Try request with a valid token
+	{
+		token := mock.CreatePolicyAndToken(t, server.State(), 1007, "test-valid",
+			mock.NamespacePolicy(nstructs.DefaultNamespace, "", []string{acl.NamespaceCapabilitySubmitJob}))
+		req := &nstructs.AllocSignalRequest{AllocID: a.ID, Signal: "SIGUSR1"}
+		req.AuthToken = token.SecretID
+		req.Namespace = nstructs.DefaultNamespace
+
+		var resp nstructs.GenericResponse
+		require.Nil(client.ClientRPC("Allocations.Signal", &req, &resp))
+	}
+
+	// Try request with a management token
+	{
+		req := &nstructs.AllocSignalRequest{AllocID: a.ID, Signal: "SIGUSR1"}
+		req.AuthToken = root.SecretID
+
+		var resp nstructs.GenericResponse
+		require.Nil(client.ClientRPC("Allocations.Signal", &req, &resp))
+	}
+}
+
 func TestAllocations_Stats(t *testing.T) {
 	t.Parallel()
 	require := require.New(t)
@@ -262,3 +541,197 @@ func TestAllocations_Stats_ACL(t *testing.T) {
 		require.True(nstructs.IsErrUnknownAllocation(err))
 	}
 }
+
+// execStream runs an exec session against the client's streaming handler. It
+// sends the inputs after the request and returns the stdout and exit code of
+// the command or the error returned by the handler.
+func execStream(t *testing.T, c *Client, req *cstructs.AllocExecRequest, inputs ...*cstructs.ExecStreamingInput) (string, int, error) {
+	handler, err := c.StreamingRpcHandler("Allocations.Exec")
+	require.Nil(t, err)
+
+	// Create a pipe
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+	defer p2.Close()
+
+	// Start the handler
+	go handler(p2)
+
+	// Send the request and the inputs
+	go func() {
+		encoder := codec.NewEncoder(p1, nstructs.MsgpackHandle)
+		if err := encoder.Encode(req); err != nil {
+			return
+		}
+		for _, input := range inputs {
+			if err := encoder.Encode(input); err != nil {
+				return
+			}
+		}
+	}()
+
+	resultCh := make(chan error, 1)
+	var stdout bytes.Buffer
+	exitCode := -1
+	go func() {
+		decoder := codec.NewDecoder(p1, nstructs.MsgpackHandle)
+		for {
+			var msg cstructs.StreamErrWrapper
+			if err := decoder.Decode(&msg); err != nil {
+				resultCh <- fmt.Errorf("error decoding: %v", err)
+				return
+			}
+			if msg.Error != nil {
+				resultCh <- msg.Error
+				return
+			}
+
+			var frame cstructs.ExecStreamingOutput
+			if err := codec.NewDecoderBytes(msg.Payload, nstructs.JsonHandle).Decode(&frame); err != nil {
+				resultCh <- fmt.Errorf("error decoding frame: %v", err)
+				return
+			}
+			if frame.Stdout != nil {
+				stdout.Write(frame.Stdout.Data)
+			}
+			if frame.Exited {
+				exitCode = frame.Result.ExitCode
+				resultCh <- nil
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-resultCh:
+		return stdout.String(), exitCode, err
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout")
+		return "", 0, nil
+	}
+}
+
+func TestAllocations_Exec_NoAlloc(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	client := TestClient(t, nil)
+	defer client.Shutdown()
+
+	req := &cstructs.AllocExecRequest{
+		AllocID:      uuid.Generate(),
+		Task:         "web",
+		Cmd:          []string{"/bin/sh"},
+		QueryOptions: nstructs.QueryOptions{Region: "global"},
+	}
+	_, _, err := execStream(t, client, req)
+	require.True(nstructs.IsErrUnknownAllocation(err), "unexpected error: %v", err)
+
+	// Missing arguments are rejected
+	req.Cmd = nil
+	_, _, err = execStream(t, client, req)
+	require.EqualError(err, commandNotPresentErr.Error())
+}
+
+func TestAllocations_Exec_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	server, addr, root := testACLServer(t, nil)
+	defer server.Shutdown()
+
+	client := TestClient(t, func(c *config.Config) {
+		c.Servers = []string{addr}
+		c.ACLEnabled = true
+	})
+	defer client.Shutdown()
+
+	a := testRunningAlloc(t, server, client)
+	task := a.Job.TaskGroups[0].Tasks[0]
+	state := server.State()
+
+	newReq := func(namespace, token string) *cstructs.AllocExecRequest {
+		return &cstructs.AllocExecRequest{
+			AllocID: a.ID,
+			Task:    task.Name,
+			Cmd:     []string{"exit", "0"},
+			QueryOptions: nstructs.QueryOptions{
+				Region:    "global",
+				Namespace: namespace,
+				AuthToken: token,
+			},
+		}
+	}
+
+	// Try request without a token and expect failure
+	_, _, err := execStream(t, client, newReq(nstructs.DefaultNamespace, ""))
+	require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+
+	// Try request with a token that can only read logs and expect failure
+	token := mock.CreatePolicyAndToken(t, state, 1005, "invalid",
+		mock.NamespacePolicy(nstructs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadLogs}))
+	_, _, err = execStream(t, client, newReq(nstructs.DefaultNamespace, token.SecretID))
+	require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+
+	// Try request with a token for another namespace and expect failure
+	token = mock.CreatePolicyAndToken(t, state, 1006, "other-namespace",
+		mock.NamespacePolicy("other", "", []string{acl.NamespaceCapabilityAllocExec}))
+	_, _, err = execStream(t, client, newReq("other", token.SecretID))
+	require.EqualError(err, nstructs.ErrPermissionDenied.Error())
+
+	// Try request with a valid token
+	token = mock.CreatePolicyAndToken(t, state, 1007, "test-valid",
+		mock.NamespacePolicy(nstructs.DefaultNamespace, "", []string{acl.NamespaceCapabilityAllocExec}))
+	_, exitCode, err := execStream(t, client, newReq(nstructs.DefaultNamespace, token.SecretID))
+	require.NoError(err)
+	require.Zero(exitCode)
+
+	// Try request with a management token
+	_, exitCode, err = execStream(t, client, newReq(nstructs.DefaultNamespace, root.SecretID))
+	require.NoError(err)
+	require.Zero(exitCode)
+}
+
+func TestAllocations_Exec(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// Start a server and client
+	s := nomad.TestServer(t, nil)
+	defer s.Shutdown()
+	testutil.WaitForLeader(t, s.RPC)
+
+	client := TestClient(t, func(c *config.Config) {
+		c.Servers = []string{s.GetConfig().RPCAddr.String()}
+	})
+	defer client.Shutdown()
+
+	a := testRunningAlloc(t, s, client)
+	task := a.Job.TaskGroups[0].Tasks[0]
+
+	// Execute a command reading stdin
+	req := &cstructs.AllocExecRequest{
+		AllocID:      a.ID,
+		Task:         task.Name,
+		Cmd:          []string{"cat"},
+		QueryOptions: nstructs.QueryOptions{Region: "global"},
+	}
+	stdout, exitCode, err := execStream(t, client, req,
+		&cstructs.ExecStreamingInput{TTYSize: &cstructs.TerminalSize{Height: 40, Width: 80}},
+		&cstructs.ExecStreamingInput{Stdin: &cstructs.ExecStreamingIOOperation{Data: []byte("hello\n")}},
+		&cstructs.ExecStreamingInput{Stdin: &cstructs.ExecStreamingIOOperation{Close: true}},
+	)
+	require.NoError(err)
+	require.Zero(exitCode)
+	require.Equal("ExecStreaming([\"cat\"])\nhello\n", stdout)
+
+	// The exit code of the command is returned
+	req.Cmd = []string{"exit", "3"}
+	_, exitCode, err = execStream(t, client, req)
+	require.NoError(err)
+	require.Equal(3, exitCode)
+
+	// Unknown tasks are rejected
+	req.Task = "foo"
+	_, _, err = execStream(t, client, req)
+	require.Error(err)
+	require.True(strings.Contains(err.Error(), "unknown task"), "unexpected error: %v", err)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -50,7 +51,8 @@ type AllocRunner struct {
 	alloc                  *structs.Allocation
 	allocClientStatus      string // Explicit status of allocation. Set when there are failures
 	allocClientDescription string
-	allocHealth            *bool // Whether the allocation is healthy
+	allocHealth            *bool     // Whether the allocation is healthy
+	allocHealthTime        time.Time // Time at which the health was set
 	allocBroadcast         *cstructs.AllocBroadcaster
 	allocLock              sync.Mutex
 
@@ -62,8 +64,13 @@ type AllocRunner struct {
 	tasks      map[string]*TaskRunner
 	taskStates map[string]*structs.TaskState
 	restored   map[string]struct{}
+	started    map[string]struct{}
 	taskLock   sync.RWMutex
 
+	// taskStateUpdateCh is notified when the state of a task changes so the
+	// tasks waiting on it in their lifecycle can be started.
+	taskStateUpdateCh chan struct{}
+
 	taskStatusLock sync.RWMutex
 
 	updateCh chan *structs.Allocation
@@ -71,6 +78,26 @@ type AllocRunner struct {
 	vaultClient  vaultclient.VaultClient
 	consulClient ConsulServiceAPI
 
+	// cpusets confines the tasks without dedicated cores to the shared cores
+	cpusets *cpusetManager
+
+	// driverPlugins manages the plugin processes of the external drivers
+	driverPlugins *driver.DriverPluginManager
+
+	// hooks are run throughout the lifecycle of the allocation, in order
+	hooks []AllocRunnerHook
+
+	// networkIsolation is the network namespace the tasks are started in
+	// when the task group network isn't in host mode and allocNetwork the
+	// network of the tasks in it
+	networkIsolation     *cstructs.NetworkIsolationSpec
+	allocNetwork         *cstructs.DriverNetwork
+	networkIsolationLock sync.Mutex
+
+	// hookStates is the persisted state of the hooks by name
+	hookStates     map[string]*hookState
+	hookStatesLock sync.Mutex
+
 	// prevAlloc allows for Waiting until a previous allocation exits and
 	// the migrates it data. If sticky volumes aren't used and there's no
 	// previous allocation a noop implementation is used so it always safe
@@ -154,7 +181,8 @@ type allocRunnerMutableState struct {
 // NewAllocRunner is used to create a new allocation context
 func NewAllocRunner(logger *log.Logger, config *config.Config, stateDB *bolt.DB, updater AllocStateUpdater,
 	alloc *structs.Allocation, vaultClient vaultclient.VaultClient, consulClient ConsulServiceAPI,
-	prevAlloc prevAllocWatcher) *AllocRunner {
+	prevAlloc prevAllocWatcher, cpusets *cpusetManager,
+	driverPlugins *driver.DriverPluginManager) *AllocRunner {
 
 	ar := &AllocRunner{
 		config:         config,
@@ -170,14 +198,21 @@ func NewAllocRunner(logger *log.Logger, config *config.Config, stateDB *bolt.DB,
 		tasks:          make(map[string]*TaskRunner),
 		taskStates:     copyTaskStates(alloc.TaskStates),
 		restored:       make(map[string]struct{}),
+		started:        make(map[string]struct{}),
+		hookStates:     make(map[string]*hookState),
 		updateCh:       make(chan *structs.Allocation, 64),
 		waitCh:         make(chan struct{}),
 		vaultClient:    vaultClient,
 		consulClient:   consulClient,
+		cpusets:        cpusets,
+		driverPlugins:  driverPlugins,
 	}
 
+	ar.taskStateUpdateCh = make(chan struct{}, 1)
+
 	// TODO Should be passed a context
 	ar.ctx, ar.exitFn = context.WithCancel(context.TODO())
+	ar.initHooks()
 
 	return ar
 }
@@ -287,9 +322,9 @@ func (r *AllocRunner) RestoreState() error {
 			r.allocClientStatus = mutable.AllocClientStatus
 			r.allocClientDescription = mutable.AllocClientDescription
 			r.taskStates = mutable.TaskStates
-			r.alloc.ClientStatus = getClientStatus(r.taskStates)
+			r.alloc.ClientStatus = getClientStatus(r.taskStates, allocTaskGroup(r.alloc))
 			r.alloc.DeploymentStatus = mutable.DeploymentStatus
-			return nil
+			return r.restoreHookStates(bkt)
 		})
 
 		if err != nil {
@@ -343,14 +378,20 @@ func (r *AllocRunner) RestoreState() error {
 			continue
 		}
 
-		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, td, r.Alloc(), task, r.vaultClient, r.consulClient)
+		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, td, r.Alloc(), task, r.vaultClient, r.consulClient, r.cpusets, r.driverPlugins)
 		r.tasks[name] = tr
 
 		if restartReason, err := tr.RestoreState(); err != nil {
 			r.logger.Printf("[ERR] client: failed to restore state for alloc %s task %q: %v", r.allocID, name, err)
 			mErr.Errors = append(mErr.Errors, err)
 		} else if !r.alloc.TerminalStatus() {
-			// Only start if the alloc isn't in a terminal status.
+			// Only start if the alloc isn't in a terminal status. Tasks
+			// that never started are left for Run to start in the order of
+			// their lifecycle.
+			if state.StartedAt.IsZero() {
+				continue
+			}
+			r.started[name] = struct{}{}
 			go tr.Run()
 
 			if upgrading {
@@ -498,11 +539,6 @@ func (r *AllocRunner) DestroyState() error {
 	})
 }
 
-// DestroyContext is used to destroy the context
-func (r *AllocRunner) DestroyContext() error {
-	return r.allocDir.Destroy()
-}
-
 // GetAllocDir returns the alloc dir for the alloc runner
 func (r *AllocRunner) GetAllocDir() *allocdir.AllocDir {
 	return r.allocDir
@@ -580,13 +616,14 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 			alloc.DeploymentStatus = &structs.AllocDeploymentStatus{}
 		}
 		alloc.DeploymentStatus.Healthy = helper.BoolToPtr(*r.allocHealth)
+		alloc.DeploymentStatus.Timestamp = r.allocHealthTime
 	}
 	r.allocLock.Unlock()
 
 	// Scan the task states to determine the status of the alloc
 	r.taskStatusLock.RLock()
 	alloc.TaskStates = copyTaskStates(r.taskStates)
-	alloc.ClientStatus = getClientStatus(r.taskStates)
+	alloc.ClientStatus = getClientStatus(r.taskStates, allocTaskGroup(alloc))
 	r.taskStatusLock.RUnlock()
 
 	// If the client status is failed and we are part of a deployment, mark the
@@ -595,7 +632,8 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 	if alloc.ClientStatus == structs.AllocClientStatusFailed &&
 		alloc.DeploymentID != "" && !alloc.DeploymentStatus.IsUnhealthy() {
 		alloc.DeploymentStatus = &structs.AllocDeploymentStatus{
-			Healthy: helper.BoolToPtr(false),
+			Healthy:   helper.BoolToPtr(false),
+			Timestamp: time.Now(),
 		}
 	}
 	r.allocLock.Unlock()
@@ -604,15 +642,24 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 }
 
 // getClientStatus takes in the task states for a given allocation and computes
-// the client status
-func getClientStatus(taskStates map[string]*structs.TaskState) string {
+// the client status. The task group is used to account for the lifecycle of
+// the tasks and may be nil.
+func getClientStatus(taskStates map[string]*structs.TaskState, tg *structs.TaskGroup) string {
+	// Once the main tasks are dead the allocation keeps running until its
+	// poststop tasks have run
+	mainDead := tg != nil && mainTasksDead(taskStates, tg)
+
 	var pending, running, dead, failed bool
-	for _, state := range taskStates {
+	for name, state := range taskStates {
 		switch state.State {
 		case structs.TaskStateRunning:
 			running = true
 		case structs.TaskStatePending:
-			pending = true
+			if mainDead && tg.LookupTask(name).IsLifecycleHook(structs.TaskLifecycleHookPoststop) {
+				running = true
+			} else {
+				pending = true
+			}
 		case structs.TaskStateDead:
 			if state.Failed {
 				failed = true
@@ -636,6 +683,29 @@ func getClientStatus(taskStates map[string]*structs.TaskState) string {
 	return ""
 }
 
+// allocTaskGroup returns the task group of the allocation or nil if the job
+// isn't known.
+func allocTaskGroup(alloc *structs.Allocation) *structs.TaskGroup {
+	if alloc.Job == nil {
+		return nil
+	}
+	return alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+}
+
+// mainTasksDead returns whether all of the main tasks of the task group are
+// dead.
+func mainTasksDead(taskStates map[string]*structs.TaskState, tg *structs.TaskGroup) bool {
+	for _, task := range tg.Tasks {
+		if !task.IsMain() {
+			continue
+		}
+		if state, ok := taskStates[task.Name]; !ok || state.State != structs.TaskStateDead {
+			return false
+		}
+	}
+	return true
+}
+
 // dirtySyncState is used to watch for state being marked dirty to sync
 func (r *AllocRunner) dirtySyncState() {
 	for {
@@ -757,6 +827,11 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 		leader := false
 		for task, tr := range r.tasks {
 			if task != taskName {
+				// Poststop tasks are run once the other tasks are dead
+				// rather than being killed alongside them
+				if tr.task.IsLifecycleHook(structs.TaskLifecycleHookPoststop) {
+					continue
+				}
 				otherTaskRunners = append(otherTaskRunners, tr)
 				otherTaskNames = append(otherTaskNames, task)
 			} else if tr.task.Leader {
@@ -808,6 +883,11 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 	case r.dirtyCh <- struct{}{}:
 	default:
 	}
+
+	select {
+	case r.taskStateUpdateCh <- struct{}{}:
+	default:
+	}
 }
 
 // appendTaskEvent updates the task status by appending the new event.
@@ -842,44 +922,16 @@ func (r *AllocRunner) Run() {
 		return
 	}
 
-	// Build allocation directory (idempotent)
-	r.allocDirLock.Lock()
-	err := r.allocDir.Build()
-	r.allocDirLock.Unlock()
-
-	if err != nil {
-		r.logger.Printf("[ERR] client: alloc %q failed to build task directories: %v", r.allocID, err)
-		r.setStatus(structs.AllocClientStatusFailed, fmt.Sprintf("failed to build task dirs for '%s'", alloc.TaskGroup))
-		return
-	}
-
-	// Wait for a previous alloc - if any - to terminate
-	if err := r.prevAlloc.Wait(r.ctx); err != nil {
+	// Run the prerun hooks, building the allocation directory and
+	// migrating the data of the previous alloc if applicable
+	if err := r.prerun(); err != nil {
 		if err == context.Canceled {
 			return
 		}
-		r.setStatus(structs.AllocClientStatusFailed, fmt.Sprintf("error while waiting for previous alloc to terminate: %v", err))
+		r.setStatus(structs.AllocClientStatusFailed, err.Error())
 		return
 	}
 
-	// Wait for data to be migrated from a previous alloc if applicable
-	if err := r.prevAlloc.Migrate(r.ctx, r.allocDir); err != nil {
-		if err == context.Canceled {
-			return
-		}
-
-		// Soft-fail on migration errors
-		r.logger.Printf("[WARN] client: alloc %q error while migrating data from previous alloc: %v", r.allocID, err)
-
-		// Recreate alloc dir to ensure a clean slate
-		r.allocDir.Destroy()
-		if err := r.allocDir.Build(); err != nil {
-			r.logger.Printf("[ERR] client: alloc %q failed to clean task directories after failed migration: %v", r.allocID, err)
-			r.setStatus(structs.AllocClientStatusFailed, fmt.Sprintf("failed to rebuild task dirs for '%s'", alloc.TaskGroup))
-			return
-		}
-	}
-
 	// Check if the allocation is in a terminal status. In this case, we don't
 	// start any of the task runners and directly wait for the destroy signal to
 	// clean up the allocation.
@@ -904,10 +956,6 @@ func (r *AllocRunner) Run() {
 		metrics.IncrCounter([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, "start"}, 1)
 	}
 
-	// Start the watcher
-	wCtx, watcherCancel := context.WithCancel(r.ctx)
-	go r.watchHealth(wCtx)
-
 	// Start the task runners
 	r.logger.Printf("[DEBUG] client: starting task runners for alloc '%s'", r.allocID)
 	r.taskLock.Lock()
@@ -920,14 +968,18 @@ func (r *AllocRunner) Run() {
 		taskdir := r.allocDir.NewTaskDir(task.Name)
 		r.allocDirLock.Unlock()
 
-		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, taskdir, r.Alloc(), task.Copy(), r.vaultClient, r.consulClient)
+		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, taskdir, r.Alloc(), task.Copy(), r.vaultClient, r.consulClient, r.cpusets, r.driverPlugins)
+		tr.setNetworkIsolation(r.getNetworkIsolation())
 		r.tasks[task.Name] = tr
 		tr.MarkReceived()
-
-		go tr.Run()
 	}
 	r.taskLock.Unlock()
 
+	// Start the tasks in the order of their lifecycle
+	r.startReadyTasks(tg)
+	lCtx, lifecycleCancel := context.WithCancel(r.ctx)
+	go r.runTaskLifecycle(lCtx, tg)
+
 	// taskDestroyEvent contains an event that caused the destruction of a task
 	// in the allocation.
 	var taskDestroyEvent *structs.TaskEvent
@@ -948,10 +1000,11 @@ OUTER:
 			r.alloc = update
 			r.allocLock.Unlock()
 
-			// Create a new watcher
-			watcherCancel()
-			wCtx, watcherCancel = context.WithCancel(r.ctx)
-			go r.watchHealth(wCtx)
+			// Run the update hooks, for example to restart the health
+			// watcher
+			if err := r.updateHooks(update); err != nil {
+				r.logger.Printf("[WARN] client: alloc %q failed to run update hooks: %v", r.allocID, err)
+			}
 
 			// Check if we're in a terminal status
 			if update.TerminalStatus() {
@@ -977,17 +1030,112 @@ OUTER:
 	}
 
 	// Kill the task runners
+	lifecycleCancel()
 	r.destroyTaskRunners(taskDestroyEvent)
 
+	// Run the postrun hooks now that the tasks have stopped
+	r.postrun()
+
 	// Block until we should destroy the state of the alloc
 	r.handleDestroy()
 
-	// Free up the context. It has likely exited already
-	watcherCancel()
-
 	r.logger.Printf("[DEBUG] client: terminating runner for alloc '%s'", r.allocID)
 }
 
+// startTaskRunner starts the task runner of the given task if it hasn't been
+// started yet.
+func (r *AllocRunner) startTaskRunner(name string) {
+	r.taskLock.Lock()
+	defer r.taskLock.Unlock()
+
+	tr, ok := r.tasks[name]
+	if !ok {
+		return
+	}
+	if _, ok := r.started[name]; ok {
+		return
+	}
+	r.started[name] = struct{}{}
+	go tr.Run()
+}
+
+// runTaskLifecycle starts the tasks of the task group as the tasks they
+// depend on in their lifecycle change state, until the context is done.
+func (r *AllocRunner) runTaskLifecycle(ctx context.Context, tg *structs.TaskGroup) {
+	for {
+		select {
+		case <-r.taskStateUpdateCh:
+			r.startReadyTasks(tg)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// startReadyTasks starts the task runners whose lifecycle hook has been
+// reached. Prestart tasks are started first and the main tasks once the
+// ephemeral prestart tasks have exited successfully and the prestart sidecars
+// have started. Poststart tasks are started once the main tasks have started
+// and poststop tasks once the main tasks are dead, at which point the
+// sidecars are stopped.
+func (r *AllocRunner) startReadyTasks(tg *structs.TaskGroup) {
+	r.taskStatusLock.RLock()
+	states := copyTaskStates(r.taskStates)
+	r.taskStatusLock.RUnlock()
+
+	prestartDone, mainStarted := true, true
+	for _, task := range tg.Tasks {
+		state := states[task.Name]
+		switch {
+		case task.IsMain():
+			if state == nil || state.StartedAt.IsZero() {
+				mainStarted = false
+			}
+		case task.IsLifecycleHook(structs.TaskLifecycleHookPrestart) && task.IsSidecar():
+			if state == nil || state.StartedAt.IsZero() {
+				prestartDone = false
+			}
+		case task.IsLifecycleHook(structs.TaskLifecycleHookPrestart):
+			if state == nil || state.State != structs.TaskStateDead || state.Failed {
+				prestartDone = false
+			}
+		}
+	}
+	mainDead := mainTasksDead(states, tg)
+
+	for _, task := range tg.Tasks {
+		start := false
+		switch {
+		case task.IsLifecycleHook(structs.TaskLifecycleHookPrestart):
+			start = true
+		case task.IsMain():
+			start = prestartDone
+		case task.IsLifecycleHook(structs.TaskLifecycleHookPoststart):
+			start = prestartDone && mainStarted
+		case task.IsLifecycleHook(structs.TaskLifecycleHookPoststop):
+			start = mainDead
+		}
+
+		r.taskLock.RLock()
+		tr, ok := r.tasks[task.Name]
+		r.taskLock.RUnlock()
+		if !ok {
+			continue
+		}
+
+		// The sidecars only run for as long as the main tasks
+		if mainDead && task.IsSidecar() {
+			tr.Destroy(structs.NewTaskEvent(structs.TaskMainDead))
+		}
+
+		// Task runners that were destroyed before being started are run so
+		// they are marked as dead and exit
+		if destroyed, _ := tr.isDestroyed(); start || destroyed {
+			r.startTaskRunner(task.Name)
+		}
+	}
+}
+
 // destroyTaskRunners destroys the task runners, waits for them to terminate and
 // then saves state.
 func (r *AllocRunner) destroyTaskRunners(destroyEvent *structs.TaskEvent) {
@@ -1014,21 +1162,50 @@ func (r *AllocRunner) destroyTaskRunners(destroyEvent *structs.TaskEvent) {
 			r.logger.Printf("[DEBUG] client: alloc %q destroying leader task %q of task group %q first",
 				r.allocID, leader, r.alloc.TaskGroup)
 			tr.Destroy(destroyEvent)
+			r.startTaskRunner(leader)
 			<-tr.WaitCh()
 		}
 	}
 
-	// Then destroy non-leader tasks concurrently
+	// Then destroy non-leader tasks concurrently, leaving the poststop
+	// tasks to run once the others are dead
+	var poststop []string
 	r.taskLock.RLock()
 	for name, tr := range r.tasks {
-		if name != leader {
+		if tr.task.IsLifecycleHook(structs.TaskLifecycleHookPoststop) {
+			poststop = append(poststop, name)
+		} else if name != leader {
 			tr.Destroy(destroyEvent)
 		}
 	}
 	r.taskLock.RUnlock()
+	r.waitTaskRunners(false)
+
+	// Run the poststop tasks unless the alloc runner itself is being
+	// destroyed
+	for _, name := range poststop {
+		tr := r.getTaskRunner(name)
+		select {
+		case <-r.ctx.Done():
+			tr.Destroy(destroyEvent)
+		default:
+		}
+		r.startTaskRunner(name)
+	}
+	r.waitTaskRunners(true)
+}
 
-	// Wait for termination of the task runners
+// waitTaskRunners starts the task runners that were never started so they
+// exit and waits for the termination of either the poststop or the other task
+// runners.
+func (r *AllocRunner) waitTaskRunners(poststop bool) {
 	for _, tr := range r.getTaskRunners() {
+		if tr.task.IsLifecycleHook(structs.TaskLifecycleHookPoststop) != poststop {
+			continue
+		}
+		if !poststop {
+			r.startTaskRunner(tr.task.Name)
+		}
 		<-tr.WaitCh()
 	}
 }
@@ -1070,10 +1247,7 @@ func (r *AllocRunner) handleDestroy() {
 	for {
 		select {
 		case <-r.ctx.Done():
-			if err := r.DestroyContext(); err != nil {
-				r.logger.Printf("[ERR] client: failed to destroy context for alloc '%s': %v",
-					r.allocID, err)
-			}
+			r.destroyHooks()
 			if err := r.DestroyState(); err != nil {
 				r.logger.Printf("[ERR] client: failed to destroy state for alloc '%s': %v",
 					r.allocID, err)
@@ -1086,6 +1260,28 @@ func (r *AllocRunner) handleDestroy() {
 	}
 }
 
+// getNetworkIsolation returns the network namespace the tasks are started in
+// and the network of the tasks in it
+func (r *AllocRunner) getNetworkIsolation() (*cstructs.NetworkIsolationSpec, *cstructs.DriverNetwork) {
+	r.networkIsolationLock.Lock()
+	defer r.networkIsolationLock.Unlock()
+	return r.networkIsolation, r.allocNetwork
+}
+
+// setNetworkIsolation sets the network namespace the tasks are started in and
+// the network of the tasks in it, including the tasks restored before it was
+// created.
+func (r *AllocRunner) setNetworkIsolation(spec *cstructs.NetworkIsolationSpec, network *cstructs.DriverNetwork) {
+	r.networkIsolationLock.Lock()
+	r.networkIsolation = spec
+	r.allocNetwork = network
+	r.networkIsolationLock.Unlock()
+
+	for _, tr := range r.getTaskRunners() {
+		tr.setNetworkIsolation(spec, network)
+	}
+}
+
 // IsWaiting returns true if this alloc is waiting on a previous allocation to
 // terminate.
 func (r *AllocRunner) IsWaiting() bool {
@@ -1126,6 +1322,52 @@ func (r *AllocRunner) getTaskRunners() []*TaskRunner {
 	return runners
 }
 
+// getTaskRunner returns the task runner of the given task or nil if the task
+// does not have one.
+func (r *AllocRunner) getTaskRunner(task string) *TaskRunner {
+	r.taskLock.RLock()
+	defer r.taskLock.RUnlock()
+	return r.tasks[task]
+}
+
+// RestartTask restarts the given task of the allocation, or all of its tasks
+// if no task is given.
+func (r *AllocRunner) RestartTask(taskName, source, reason string) error {
+	if taskName == "" {
+		for _, tr := range r.getTaskRunners() {
+			tr.Restart(source, reason, false)
+		}
+		return nil
+	}
+
+	tr := r.getTaskRunner(taskName)
+	if tr == nil {
+		return fmt.Errorf("unknown task name %q", taskName)
+	}
+	tr.Restart(source, reason, false)
+	return nil
+}
+
+// SignalTask sends a signal to the given task of the allocation, or to all of
+// its tasks if no task is given.
+func (r *AllocRunner) SignalTask(taskName string, s os.Signal, source, reason string) error {
+	if taskName == "" {
+		var mErr multierror.Error
+		for _, tr := range r.getTaskRunners() {
+			if err := tr.Signal(source, reason, s); err != nil {
+				multierror.Append(&mErr, fmt.Errorf("failed to signal task %q: %v", tr.task.Name, err))
+			}
+		}
+		return mErr.ErrorOrNil()
+	}
+
+	tr := r.getTaskRunner(taskName)
+	if tr == nil {
+		return fmt.Errorf("unknown task name %q", taskName)
+	}
+	return tr.Signal(source, reason, s)
+}
+
 // LatestAllocStats returns the latest allocation stats. If the optional taskFilter is set
 // the allocation stats will only include the given task.
 func (r *AllocRunner) LatestAllocStats(taskFilter string) (*cstructs.AllocResourceUsage, error) {
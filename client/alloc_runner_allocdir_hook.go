@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// allocDirHook builds the allocation directory before the tasks are started
+// and destroys it with the allocation.
+type allocDirHook struct {
+	runner *AllocRunner
+}
+
+func newAllocDirHook(runner *AllocRunner) *allocDirHook {
+	return &allocDirHook{runner: runner}
+}
+
+func (h *allocDirHook) Name() string {
+	return "alloc_dir"
+}
+
+// Prerun builds the allocation directory. Building it is idempotent.
+func (h *allocDirHook) Prerun(ctx context.Context, req *AllocPrerunRequest, resp *AllocPrerunResponse) error {
+	r := h.runner
+	r.allocDirLock.Lock()
+	err := r.allocDir.Build()
+	r.allocDirLock.Unlock()
+
+	if err != nil {
+		r.logger.Printf("[ERR] client: alloc %q failed to build task directories: %v", r.allocID, err)
+		return fmt.Errorf("failed to build task dirs for '%s'", req.Alloc.TaskGroup)
+	}
+	return nil
+}
+
+// Destroy destroys the allocation directory.
+func (h *allocDirHook) Destroy() error {
+	r := h.runner
+	r.allocDirLock.Lock()
+	defer r.allocDirLock.Unlock()
+	return r.allocDir.Destroy()
+}
+
+// migrateHook waits for the previous allocation, if any, to terminate and
+// migrates its data into the allocation directory.
+type migrateHook struct {
+	runner *AllocRunner
+}
+
+func newMigrateHook(runner *AllocRunner) *migrateHook {
+	return &migrateHook{runner: runner}
+}
+
+func (h *migrateHook) Name() string {
+	return "migrate"
+}
+
+// Prerun waits for the previous allocation and migrates its data. Migration
+// errors are soft failures that leave a clean allocation directory. Once the
+// data has been migrated it isn't migrated again.
+func (h *migrateHook) Prerun(ctx context.Context, req *AllocPrerunRequest, resp *AllocPrerunResponse) error {
+	r := h.runner
+
+	// Wait for a previous alloc - if any - to terminate
+	if err := r.prevAlloc.Wait(ctx); err != nil {
+		if err == context.Canceled {
+			return err
+		}
+		return fmt.Errorf("error while waiting for previous alloc to terminate: %v", err)
+	}
+
+	// Wait for data to be migrated from a previous alloc if applicable
+	if err := r.prevAlloc.Migrate(ctx, r.allocDir); err != nil {
+		if err == context.Canceled {
+			return err
+		}
+
+		// Soft-fail on migration errors
+		r.logger.Printf("[WARN] client: alloc %q error while migrating data from previous alloc: %v", r.allocID, err)
+
+		// Recreate alloc dir to ensure a clean slate
+		r.allocDir.Destroy()
+		if err := r.allocDir.Build(); err != nil {
+			r.logger.Printf("[ERR] client: alloc %q failed to clean task directories after failed migration: %v", r.allocID, err)
+			return fmt.Errorf("failed to rebuild task dirs for '%s'", req.Alloc.TaskGroup)
+		}
+	}
+
+	resp.Done = true
+	return nil
+}
@@ -24,6 +24,67 @@ const (
 	allocHealthEventSource = "Alloc Unhealthy"
 )
 
+// allocHealthWatcherHook watches the health of the allocation while its
+// tasks run.
+type allocHealthWatcherHook struct {
+	runner *AllocRunner
+
+	// cancelFn stops the current watcher
+	cancelFn context.CancelFunc
+	lock     sync.Mutex
+}
+
+func newAllocHealthWatcherHook(runner *AllocRunner) *allocHealthWatcherHook {
+	return &allocHealthWatcherHook{runner: runner}
+}
+
+func (h *allocHealthWatcherHook) Name() string {
+	return "alloc_health_watcher"
+}
+
+// Prerun starts watching the health of the allocation unless it is terminal.
+func (h *allocHealthWatcherHook) Prerun(ctx context.Context, req *AllocPrerunRequest, resp *AllocPrerunResponse) error {
+	if req.Alloc.TerminalStatus() {
+		return nil
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.start()
+	return nil
+}
+
+// Update restarts the watcher with the updated allocation.
+func (h *allocHealthWatcherHook) Update(alloc *structs.Allocation) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.cancelFn != nil {
+		h.cancelFn()
+	}
+	h.start()
+	return nil
+}
+
+// Postrun stops the watcher.
+func (h *allocHealthWatcherHook) Postrun() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// Free up the context. It has likely exited already
+	if h.cancelFn != nil {
+		h.cancelFn()
+	}
+	return nil
+}
+
+// start starts a new watcher. The lock must be held.
+func (h *allocHealthWatcherHook) start() {
+	var wCtx context.Context
+	wCtx, h.cancelFn = context.WithCancel(h.runner.ctx)
+	go h.runner.watchHealth(wCtx)
+}
+
 // watchHealth is responsible for watching an allocation's task status and
 // potentially Consul health check status to determine if the allocation is
 // healthy or unhealthy.
@@ -112,6 +173,7 @@ func (r *AllocRunner) watchHealth(ctx context.Context) {
 
 	r.allocLock.Lock()
 	r.allocHealth = helper.BoolToPtr(allocHealthy)
+	r.allocHealthTime = time.Now()
 	r.allocLock.Unlock()
 
 	// If deployment is unhealthy emit task events explaining why
@@ -346,9 +408,20 @@ func (a *allocHealthTracker) watchTaskEvents() {
 
 		// Detect if the alloc is unhealthy or if all tasks have started yet
 		latestStartTime := time.Time{}
-		for _, state := range alloc.TaskStates {
+		for name, state := range alloc.TaskStates {
 			// One of the tasks has failed so we can exit watching
-			if state.Failed || !state.FinishedAt.IsZero() {
+			if state.Failed {
+				a.setTaskHealth(false, true)
+				return
+			}
+
+			// Ephemeral lifecycle tasks are expected to run to completion
+			// so only their failure makes the alloc unhealthy
+			if task := a.tg.LookupTask(name); task != nil && task.IsEphemeral() {
+				continue
+			}
+
+			if !state.FinishedAt.IsZero() {
 				a.setTaskHealth(false, true)
 				return
 			}
@@ -533,6 +606,9 @@ func (t *taskHealthState) event(deadline time.Time, minHealthyTime time.Duration
 		if t.state.Failed {
 			return "Unhealthy because of failed task", true
 		}
+		if t.task.IsEphemeral() {
+			return "", false
+		}
 		if t.state.State != structs.TaskStateRunning {
 			return "Task not running by deadline", true
 		}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// AllocRunnerHook is the interface implemented by the hooks run by the
+// AllocRunner throughout the lifecycle of an allocation. A hook implements
+// any of the AllocRunnerPrerunHook, AllocRunnerUpdateHook,
+// AllocRunnerPostrunHook and AllocRunnerDestroyHook interfaces to be called at
+// that point of the lifecycle. Hooks are called in the order they are
+// registered.
+type AllocRunnerHook interface {
+	// Name returns the name of the hook. It is used to persist the state of
+	// the hook and must be unique within an alloc runner.
+	Name() string
+}
+
+// AllocPrerunRequest is the request passed to the prerun hooks.
+type AllocPrerunRequest struct {
+	// Alloc is the allocation being run
+	Alloc *structs.Allocation
+
+	// HookData is the data returned by the hook the last time it was run,
+	// including before the client restarted.
+	HookData map[string]string
+}
+
+// AllocPrerunResponse is the response returned by the prerun hooks.
+type AllocPrerunResponse struct {
+	// HookData is persisted and passed back to the hook the next time it is
+	// run.
+	HookData map[string]string
+
+	// Done marks the hook as having completed. It won't be run again when
+	// the allocation is restored.
+	Done bool
+}
+
+// AllocRunnerPrerunHook is called before the tasks of the allocation are
+// started. Returning an error fails the allocation unless the error is
+// context.Canceled.
+type AllocRunnerPrerunHook interface {
+	AllocRunnerHook
+
+	// Prerun is called before the tasks are started. The context is
+	// cancelled when the alloc runner is destroyed.
+	Prerun(ctx context.Context, req *AllocPrerunRequest, resp *AllocPrerunResponse) error
+}
+
+// AllocRunnerUpdateHook is called when the allocation is updated.
+type AllocRunnerUpdateHook interface {
+	AllocRunnerHook
+
+	// Update is called with the updated allocation.
+	Update(alloc *structs.Allocation) error
+}
+
+// AllocRunnerPostrunHook is called once the tasks of the allocation have
+// been stopped.
+type AllocRunnerPostrunHook interface {
+	AllocRunnerHook
+
+	// Postrun is called after the tasks have stopped.
+	Postrun() error
+}
+
+// AllocRunnerDestroyHook is called when the allocation is destroyed and its
+// resources should be freed.
+type AllocRunnerDestroyHook interface {
+	AllocRunnerHook
+
+	// Destroy is called when the allocation is destroyed.
+	Destroy() error
+}
+
+// initHooks registers the hooks of the alloc runner in the order they are
+// run.
+func (r *AllocRunner) initHooks() {
+	r.hooks = []AllocRunnerHook{
+		newAllocDirHook(r),
+		newMigrateHook(r),
+		newNetworkHook(r),
+		newAllocHealthWatcherHook(r),
+	}
+}
+
+// getHookState returns a copy of the persisted state of the named hook or nil
+// if there is none.
+func (r *AllocRunner) getHookState(name string) *hookState {
+	r.hookStatesLock.Lock()
+	defer r.hookStatesLock.Unlock()
+
+	state, ok := r.hookStates[name]
+	if !ok {
+		return nil
+	}
+	c := *state
+	return &c
+}
+
+// setHookState stores the state of the named hook and persists it if it has
+// changed.
+func (r *AllocRunner) setHookState(name string, state *hookState) error {
+	r.hookStatesLock.Lock()
+	if old, ok := r.hookStates[name]; ok && reflect.DeepEqual(old, state) {
+		r.hookStatesLock.Unlock()
+		return nil
+	}
+	r.hookStates[name] = state
+	r.hookStatesLock.Unlock()
+
+	r.allocStateLock.Lock()
+	defer r.allocStateLock.Unlock()
+	if r.ctx.Err() == context.Canceled {
+		return nil
+	}
+
+	return r.stateDB.Batch(func(tx *bolt.Tx) error {
+		allocBkt, err := getAllocationBucket(tx, r.allocID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve allocation bucket: %v", err)
+		}
+
+		if err := putObject(allocBkt, hookStateKey(name), state); err != nil {
+			return fmt.Errorf("failed to write state of hook %q: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// restoreHookStates reads the persisted state of the hooks from the
+// allocation bucket.
+func (r *AllocRunner) restoreHookStates(bkt *bolt.Bucket) error {
+	r.hookStatesLock.Lock()
+	defer r.hookStatesLock.Unlock()
+
+	for _, hook := range r.hooks {
+		key := hookStateKey(hook.Name())
+		if bkt.Get(key) == nil {
+			continue
+		}
+
+		var state hookState
+		if err := getObject(bkt, key, &state); err != nil {
+			return fmt.Errorf("failed to read state of hook %q: %v", hook.Name(), err)
+		}
+		r.hookStates[hook.Name()] = &state
+	}
+	return nil
+}
+
+// prerun runs the prerun hooks that haven't completed in order and returns
+// the first error.
+func (r *AllocRunner) prerun() error {
+	for _, hook := range r.hooks {
+		pre, ok := hook.(AllocRunnerPrerunHook)
+		if !ok {
+			continue
+		}
+
+		name := pre.Name()
+		state := r.getHookState(name)
+		if state != nil && state.PrestartDone {
+			continue
+		}
+
+		req := &AllocPrerunRequest{Alloc: r.Alloc()}
+		if state != nil {
+			req.HookData = state.Data
+		}
+
+		var resp AllocPrerunResponse
+		if err := pre.Prerun(r.ctx, req, &resp); err != nil {
+			return err
+		}
+
+		state = &hookState{
+			PrestartDone: resp.Done,
+			Data:         resp.HookData,
+		}
+		if err := r.setHookState(name, state); err != nil {
+			r.logger.Printf("[WARN] client: failed to persist state of hook %q for alloc %q: %v",
+				name, r.allocID, err)
+		}
+	}
+	return nil
+}
+
+// updateHooks runs the update hooks with the updated allocation and returns
+// any errors.
+func (r *AllocRunner) updateHooks(alloc *structs.Allocation) error {
+	var mErr multierror.Error
+	for _, hook := range r.hooks {
+		upd, ok := hook.(AllocRunnerUpdateHook)
+		if !ok {
+			continue
+		}
+
+		if err := upd.Update(alloc); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("update hook %q failed: %v", upd.Name(), err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// postrun runs the postrun hooks. Errors are logged.
+func (r *AllocRunner) postrun() {
+	for _, hook := range r.hooks {
+		post, ok := hook.(AllocRunnerPostrunHook)
+		if !ok {
+			continue
+		}
+
+		if err := post.Postrun(); err != nil {
+			r.logger.Printf("[WARN] client: postrun hook %q failed for alloc %q: %v", post.Name(), r.allocID, err)
+		}
+	}
+}
+
+// destroyHooks runs the destroy hooks. Errors are logged.
+func (r *AllocRunner) destroyHooks() {
+	for _, hook := range r.hooks {
+		d, ok := hook.(AllocRunnerDestroyHook)
+		if !ok {
+			continue
+		}
+
+		if err := d.Destroy(); err != nil {
+			r.logger.Printf("[ERR] client: destroy hook %q failed for alloc %q: %v", d.Name(), r.allocID, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/nomad/client/driver"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// networkHookPathKey and networkHookLabelPrefix are the keys of the
+	// network isolation spec in the data of the network hook
+	networkHookPathKey     = "path"
+	networkHookLabelPrefix = "label."
+)
+
+// networkHook creates the network namespace shared by the tasks of an
+// allocation whose task group network isn't in host mode and configures it
+// for the network mode. The namespace is created by the driver of the tasks
+// when it manages namespaces itself.
+type networkHook struct {
+	runner *AllocRunner
+
+	// spec is the network namespace of the allocation, set once it is
+	// created or restored
+	spec     *cstructs.NetworkIsolationSpec
+	specLock sync.Mutex
+}
+
+func newNetworkHook(runner *AllocRunner) *networkHook {
+	return &networkHook{runner: runner}
+}
+
+func (h *networkHook) Name() string {
+	return "network"
+}
+
+// Prerun creates and configures the network namespace. It is run again when
+// the client restarts, reusing the namespace if it still exists.
+func (h *networkHook) Prerun(ctx context.Context, req *AllocPrerunRequest, resp *AllocPrerunResponse) error {
+	r := h.runner
+
+	tg := req.Alloc.Job.LookupTaskGroup(req.Alloc.TaskGroup)
+	if tg == nil {
+		return fmt.Errorf("task group %q not found in job", req.Alloc.TaskGroup)
+	}
+	mode := tg.NetworkMode()
+	if mode == structs.NetworkModeHost {
+		return nil
+	}
+
+	spec := networkSpecFromHookData(req.HookData)
+	if spec != nil {
+		spec.Mode = mode
+		h.setSpec(spec)
+	}
+
+	// The namespace of a terminal allocation is only restored to be
+	// destroyed
+	if req.Alloc.TerminalStatus() {
+		resp.HookData = req.HookData
+		return nil
+	}
+
+	configurator, err := newNetworkConfigurator(mode, r.config, r.logger)
+	if err != nil {
+		return err
+	}
+	manager, err := h.networkManager(tg)
+	if err != nil {
+		return err
+	}
+
+	// Creating the namespace returns the existing one if it still exists
+	spec, err = manager.CreateNetwork(r.allocID)
+	if err != nil {
+		return fmt.Errorf("failed to create network for alloc: %v", err)
+	}
+	spec.Mode = mode
+	h.setSpec(spec)
+	resp.HookData = networkSpecHookData(spec)
+
+	network, err := configurator.Setup(req.Alloc, spec)
+	if err != nil {
+		if terr := h.teardown(); terr != nil {
+			r.logger.Printf("[WARN] client: failed to tear down network of alloc %q: %v", r.allocID, terr)
+		}
+		return fmt.Errorf("failed to configure %s network for alloc: %v", mode, err)
+	}
+
+	r.setNetworkIsolation(spec, network)
+	return nil
+}
+
+// Postrun tears down the network namespace once the tasks have stopped.
+func (h *networkHook) Postrun() error {
+	return h.teardown()
+}
+
+// Destroy tears down the network namespace if it wasn't already, for
+// example when the allocation was terminal when the client restarted.
+func (h *networkHook) Destroy() error {
+	return h.teardown()
+}
+
+func (h *networkHook) teardown() error {
+	h.specLock.Lock()
+	defer h.specLock.Unlock()
+
+	spec := h.spec
+	if spec == nil {
+		return nil
+	}
+
+	r := h.runner
+	alloc := r.Alloc()
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil {
+		return fmt.Errorf("task group %q not found in job", alloc.TaskGroup)
+	}
+
+	configurator, err := newNetworkConfigurator(spec.Mode, r.config, r.logger)
+	if err != nil {
+		return err
+	}
+	if err := configurator.Teardown(alloc, spec); err != nil {
+		return fmt.Errorf("failed to tear down %s network: %v", spec.Mode, err)
+	}
+
+	manager, err := h.networkManager(tg)
+	if err != nil {
+		return err
+	}
+	if err := manager.DestroyNetwork(r.allocID, spec); err != nil {
+		return fmt.Errorf("failed to destroy network: %v", err)
+	}
+
+	h.spec = nil
+	return nil
+}
+
+func (h *networkHook) setSpec(spec *cstructs.NetworkIsolationSpec) {
+	h.specLock.Lock()
+	defer h.specLock.Unlock()
+	h.spec = spec
+}
+
+// networkManager returns the manager of the network namespace of the
+// allocation, the driver of its tasks if it manages namespaces itself.
+func (h *networkHook) networkManager(tg *structs.TaskGroup) (driver.DriverNetworkManager, error) {
+	r := h.runner
+	for _, task := range tg.Tasks {
+		driverCtx := driver.NewDriverContext(task.Name, r.allocID, r.config, r.config.Node, r.logger, nil, r.driverPlugins)
+		d, err := driver.NewDriver(task.Driver, driverCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create driver %q of task %q: %v", task.Driver, task.Name, err)
+		}
+
+		if manager, ok := d.(driver.DriverNetworkManager); ok {
+			return manager, nil
+		}
+	}
+	return netnsNetworkManager{}, nil
+}
+
+// networkSpecHookData returns the hook data persisting the spec
+func networkSpecHookData(spec *cstructs.NetworkIsolationSpec) map[string]string {
+	data := map[string]string{
+		networkHookPathKey: spec.Path,
+	}
+	for k, v := range spec.Labels {
+		data[networkHookLabelPrefix+k] = v
+	}
+	return data
+}
+
+// networkSpecFromHookData returns the spec persisted in the hook data or nil
+// if there is none
+func networkSpecFromHookData(data map[string]string) *cstructs.NetworkIsolationSpec {
+	path, ok := data[networkHookPathKey]
+	if !ok {
+		return nil
+	}
+
+	spec := &cstructs.NetworkIsolationSpec{Path: path}
+	for k, v := range data {
+		if !strings.HasPrefix(k, networkHookLabelPrefix) {
+			continue
+		}
+		if spec.Labels == nil {
+			spec.Labels = make(map[string]string)
+		}
+		spec.Labels[strings.TrimPrefix(k, networkHookLabelPrefix)] = v
+	}
+	return spec
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -25,6 +26,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/lib/nsutil"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/stretchr/testify/require"
 )
@@ -77,7 +80,7 @@ func testAllocRunnerFromAlloc(t *testing.T, alloc *structs.Allocation, restarts
 		alloc.Job.Type = structs.JobTypeBatch
 	}
 	vclient := vaultclient.NewMockVaultClient()
-	ar := NewAllocRunner(testlog.Logger(t), conf, db, upd.Update, alloc, vclient, newMockConsulServiceClient(t), noopPrevAlloc{})
+	ar := NewAllocRunner(testlog.Logger(t), conf, db, upd.Update, alloc, vclient, newMockConsulServiceClient(t), noopPrevAlloc{}, nil, nil)
 	return upd, ar
 }
 
@@ -875,7 +878,7 @@ func TestAllocRunner_SaveRestoreState(t *testing.T) {
 	alloc2 := &structs.Allocation{ID: ar.alloc.ID}
 	prevAlloc := newAllocWatcher(alloc2, ar, nil, ar.config, l2, "")
 	ar2 := NewAllocRunner(l2, ar.config, ar.stateDB, upd.Update,
-		alloc2, ar.vaultClient, ar.consulClient, prevAlloc)
+		alloc2, ar.vaultClient, ar.consulClient, prevAlloc, nil, nil)
 	err = ar2.RestoreState()
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -970,7 +973,7 @@ func TestAllocRunner_SaveRestoreState_TerminalAlloc(t *testing.T) {
 	alloc2 := &structs.Allocation{ID: ar.alloc.ID}
 	prevAlloc := newAllocWatcher(alloc2, ar, nil, ar.config, l2, "")
 	ar2 := NewAllocRunner(l2, ar.config, ar.stateDB, upd.Update,
-		alloc2, ar.vaultClient, ar.consulClient, prevAlloc)
+		alloc2, ar.vaultClient, ar.consulClient, prevAlloc, nil, nil)
 	err = ar2.RestoreState()
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -1084,7 +1087,7 @@ func TestAllocRunner_SaveRestoreState_Upgrade(t *testing.T) {
 	l2 := prefixedTestLogger("ar2: ")
 	alloc2 := &structs.Allocation{ID: ar.alloc.ID}
 	prevAlloc := newAllocWatcher(alloc2, ar, nil, origConfig, l2, "")
-	ar2 := NewAllocRunner(l2, origConfig, ar.stateDB, upd.Update, alloc2, ar.vaultClient, ar.consulClient, prevAlloc)
+	ar2 := NewAllocRunner(l2, origConfig, ar.stateDB, upd.Update, alloc2, ar.vaultClient, ar.consulClient, prevAlloc, nil, nil)
 	err = ar2.RestoreState()
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -1237,7 +1240,7 @@ func TestAllocRunner_RestoreOldState(t *testing.T) {
 	alloc.Job.Type = structs.JobTypeBatch
 	vclient := vaultclient.NewMockVaultClient()
 	cclient := newMockConsulServiceClient(t)
-	ar := NewAllocRunner(logger, conf, db, upd.Update, alloc, vclient, cclient, noopPrevAlloc{})
+	ar := NewAllocRunner(logger, conf, db, upd.Update, alloc, vclient, cclient, noopPrevAlloc{}, nil, nil)
 	defer ar.Destroy()
 
 	// RestoreState should fail on the task state since we only test the
@@ -1527,10 +1530,10 @@ func TestAllocRunner_TaskLeader_StopRestoredTG(t *testing.T) {
 	ar.tasks = map[string]*TaskRunner{
 		"leader": NewTaskRunner(ar.logger, ar.config, ar.stateDB, ar.setTaskState,
 			ar.allocDir.NewTaskDir(task2.Name), ar.Alloc(), task2.Copy(),
-			ar.vaultClient, ar.consulClient),
+			ar.vaultClient, ar.consulClient, nil, nil),
 		"follower1": NewTaskRunner(ar.logger, ar.config, ar.stateDB, ar.setTaskState,
 			ar.allocDir.NewTaskDir(task.Name), ar.Alloc(), task.Copy(),
-			ar.vaultClient, ar.consulClient),
+			ar.vaultClient, ar.consulClient, nil, nil),
 	}
 	ar.taskStates = map[string]*structs.TaskState{
 		"leader":    {State: structs.TaskStateDead},
@@ -1543,7 +1546,7 @@ func TestAllocRunner_TaskLeader_StopRestoredTG(t *testing.T) {
 	// Create a new AllocRunner to test RestoreState and Run
 	upd2 := &MockAllocStateUpdater{}
 	ar2 := NewAllocRunner(ar.logger, ar.config, ar.stateDB, upd2.Update, ar.alloc,
-		ar.vaultClient, ar.consulClient, ar.prevAlloc)
+		ar.vaultClient, ar.consulClient, ar.prevAlloc, nil, nil)
 	defer ar2.Destroy()
 
 	if err := ar2.RestoreState(); err != nil {
@@ -1583,6 +1586,226 @@ func TestAllocRunner_TaskLeader_StopRestoredTG(t *testing.T) {
 	}
 }
 
+// TestAllocRunner_Lifecycle_Prestart asserts that the main tasks are only
+// started once the prestart tasks have run to completion.
+func TestAllocRunner_Lifecycle_Prestart(t *testing.T) {
+	t.Parallel()
+	upd, ar := testAllocRunner(t, false)
+
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Name = "main"
+	task.KillTimeout = 10 * time.Millisecond
+	task.Config = map[string]interface{}{
+		"run_for": "10s",
+	}
+
+	init := task.Copy()
+	init.Name = "init"
+	init.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart}
+	init.Config = map[string]interface{}{
+		"run_for": "500ms",
+	}
+	ar.alloc.Job.TaskGroups[0].Tasks = append(ar.alloc.Job.TaskGroups[0].Tasks, init)
+	ar.alloc.TaskResources[init.Name] = init.Resources
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		last := upd.Last()
+		if last == nil {
+			return false, fmt.Errorf("No updates")
+		}
+		if last.ClientStatus != structs.AllocClientStatusRunning {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusRunning)
+		}
+
+		// The init task should have exited successfully
+		initState := last.TaskStates[init.Name]
+		if initState.State != structs.TaskStateDead {
+			return false, fmt.Errorf("got state %v; want %v", initState.State, structs.TaskStateDead)
+		}
+		if initState.Failed {
+			return false, fmt.Errorf("init task should not have failed")
+		}
+
+		// The main task should have started after it
+		mainState := last.TaskStates[task.Name]
+		if mainState.State != structs.TaskStateRunning {
+			return false, fmt.Errorf("got state %v; want %v", mainState.State, structs.TaskStateRunning)
+		}
+		if mainState.StartedAt.Before(initState.FinishedAt) {
+			return false, fmt.Errorf("main task started at %v before init task finished at %v",
+				mainState.StartedAt, initState.FinishedAt)
+		}
+
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+// TestAllocRunner_Lifecycle_PrestartFailed asserts that the main tasks are not
+// started when a prestart task fails.
+func TestAllocRunner_Lifecycle_PrestartFailed(t *testing.T) {
+	t.Parallel()
+	upd, ar := testAllocRunner(t, false)
+
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Name = "main"
+	task.KillTimeout = 10 * time.Millisecond
+	task.Config = map[string]interface{}{
+		"run_for": "10s",
+	}
+
+	init := task.Copy()
+	init.Name = "init"
+	init.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart}
+	init.Config = map[string]interface{}{
+		"start_error": "fail task please",
+	}
+	ar.alloc.Job.TaskGroups[0].Tasks = append(ar.alloc.Job.TaskGroups[0].Tasks, init)
+	ar.alloc.TaskResources[init.Name] = init.Resources
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		last := upd.Last()
+		if last == nil {
+			return false, fmt.Errorf("No updates")
+		}
+		if last.ClientStatus != structs.AllocClientStatusFailed {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusFailed)
+		}
+
+		initState := last.TaskStates[init.Name]
+		if !initState.Failed {
+			return false, fmt.Errorf("init task should have failed")
+		}
+
+		// The main task should never have been started
+		mainState := last.TaskStates[task.Name]
+		if mainState.State != structs.TaskStateDead {
+			return false, fmt.Errorf("got state %v; want %v", mainState.State, structs.TaskStateDead)
+		}
+		if !mainState.StartedAt.IsZero() {
+			return false, fmt.Errorf("main task should not have started")
+		}
+
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+// TestAllocRunner_Lifecycle_SidecarPoststop asserts that sidecars are stopped
+// once the main tasks are dead and that the poststop tasks are run after them.
+func TestAllocRunner_Lifecycle_SidecarPoststop(t *testing.T) {
+	t.Parallel()
+	upd, ar := testAllocRunner(t, false)
+
+	task := ar.alloc.Job.TaskGroups[0].Tasks[0]
+	task.Name = "main"
+	task.Config = map[string]interface{}{
+		"run_for": "500ms",
+	}
+
+	sidecar := task.Copy()
+	sidecar.Name = "sidecar"
+	sidecar.KillTimeout = 10 * time.Millisecond
+	sidecar.Lifecycle = &structs.TaskLifecycleConfig{
+		Hook:    structs.TaskLifecycleHookPoststart,
+		Sidecar: true,
+	}
+	sidecar.Config = map[string]interface{}{
+		"run_for": "10s",
+	}
+
+	poststop := task.Copy()
+	poststop.Name = "poststop"
+	poststop.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPoststop}
+	poststop.Config = map[string]interface{}{
+		"run_for": "100ms",
+	}
+
+	tg := ar.alloc.Job.TaskGroups[0]
+	tg.Tasks = append(tg.Tasks, sidecar, poststop)
+	ar.alloc.TaskResources[sidecar.Name] = sidecar.Resources
+	ar.alloc.TaskResources[poststop.Name] = poststop.Resources
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		last := upd.Last()
+		if last == nil {
+			return false, fmt.Errorf("No updates")
+		}
+		if last.ClientStatus != structs.AllocClientStatusComplete {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusComplete)
+		}
+
+		mainState := last.TaskStates[task.Name]
+		if mainState.State != structs.TaskStateDead || mainState.Failed {
+			return false, fmt.Errorf("main task should have completed: %#v", mainState)
+		}
+
+		// The sidecar should have been killed once the main task was dead
+		sidecarState := last.TaskStates[sidecar.Name]
+		if sidecarState.State != structs.TaskStateDead {
+			return false, fmt.Errorf("got state %v; want %v", sidecarState.State, structs.TaskStateDead)
+		}
+		found := false
+		for _, e := range sidecarState.Events {
+			if e.Type == structs.TaskMainDead {
+				found = true
+			}
+		}
+		if !found {
+			return false, fmt.Errorf("Did not find event %v", structs.TaskMainDead)
+		}
+
+		// The poststop task should have run after the main task
+		poststopState := last.TaskStates[poststop.Name]
+		if poststopState.State != structs.TaskStateDead || poststopState.Failed {
+			return false, fmt.Errorf("poststop task should have completed: %#v", poststopState)
+		}
+		if poststopState.StartedAt.Before(mainState.FinishedAt) {
+			return false, fmt.Errorf("poststop task started at %v before main task finished at %v",
+				poststopState.StartedAt, mainState.FinishedAt)
+		}
+
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestAllocRunner_GetClientStatus_Lifecycle(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	tg := mock.Job().TaskGroups[0]
+	main := tg.Tasks[0]
+	poststop := main.Copy()
+	poststop.Name = "poststop"
+	poststop.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPoststop}
+	tg.Tasks = append(tg.Tasks, poststop)
+
+	states := map[string]*structs.TaskState{
+		main.Name:     {State: structs.TaskStateRunning},
+		poststop.Name: {State: structs.TaskStatePending},
+	}
+	require.Equal(structs.AllocClientStatusRunning, getClientStatus(states, tg))
+
+	// A pending poststop task keeps the alloc running once the main tasks
+	// are dead
+	states[main.Name] = &structs.TaskState{State: structs.TaskStateDead}
+	require.Equal(structs.AllocClientStatusRunning, getClientStatus(states, tg))
+	require.Equal(structs.AllocClientStatusPending, getClientStatus(states, nil))
+
+	states[poststop.Name] = &structs.TaskState{State: structs.TaskStateDead}
+	require.Equal(structs.AllocClientStatusComplete, getClientStatus(states, tg))
+}
+
 // TestAllocRunner_MoveAllocDir asserts that a file written to an alloc's
 // local/ dir will be moved to a replacement alloc's local/ dir if sticky
 // volumes is on.
@@ -1661,3 +1884,103 @@ func TestAllocRunner_MoveAllocDir(t *testing.T) {
 		t.Fatalf("file %v not found", dataFile)
 	}
 }
+
+func TestAllocRunner_NetworkHook_HookData(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	spec := &cstructs.NetworkIsolationSpec{
+		Path: "/proc/1234/ns/net",
+		Labels: map[string]string{
+			"docker_sandbox_container_id": "abc",
+		},
+	}
+	data := networkSpecHookData(spec)
+	require.Equal("/proc/1234/ns/net", data["path"])
+	require.Equal(spec, networkSpecFromHookData(data))
+	require.Nil(networkSpecFromHookData(nil))
+}
+
+// TestAllocRunner_BridgeNetwork asserts the tasks of an allocation in the
+// bridge network mode share a network namespace attached to the bridge that
+// is destroyed with the allocation.
+func TestAllocRunner_BridgeNetwork(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Must be root to run in the bridge network mode")
+	}
+	for _, bin := range []string{"ip", "iptables"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+	require := require.New(t)
+
+	alloc := mock.Alloc()
+	tg := alloc.Job.TaskGroups[0]
+	tg.Networks = []*structs.NetworkResource{{Mode: structs.NetworkModeBridge}}
+	task := tg.Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "10s"}
+	task.Resources.Networks = nil
+	alloc.TaskResources[task.Name].Networks = nil
+	alloc.SharedResources.Networks = []*structs.NetworkResource{
+		{
+			Mode:         structs.NetworkModeBridge,
+			IP:           "127.0.0.1",
+			DynamicPorts: []structs.Port{{Label: "http", Value: 23456, To: 8080}},
+		},
+	}
+
+	_, ar := testAllocRunnerFromAlloc(t, alloc, false)
+	dir, err := ioutil.TempDir("", "nomadtest-bridge")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	ar.config.StateDir = dir
+	ar.config.BridgeNetworkName = "nomadtest0"
+	ar.config.BridgeNetworkSubnet = "172.31.250.0/24"
+	defer exec.Command("ip", "link", "del", "nomadtest0").Run()
+
+	go ar.Run()
+	defer ar.Destroy()
+
+	nsPath := filepath.Join(nsutil.NetNSRunDir, alloc.ID)
+	testutil.WaitForResult(func() (bool, error) {
+		tr := ar.getTaskRunner(task.Name)
+		if tr == nil {
+			return false, fmt.Errorf("task runner not created")
+		}
+		spec, network := tr.getNetworkIsolation()
+		if spec == nil {
+			return false, fmt.Errorf("network isolation not set")
+		}
+		if spec.Path != nsPath {
+			return false, fmt.Errorf("expected namespace %q; got %q", nsPath, spec.Path)
+		}
+		if network.IP != "172.31.250.2" || network.PortMap["http"] != 8080 {
+			return false, fmt.Errorf("unexpected network: %#v", network)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The namespace holds the address of the allocation
+	var out []byte
+	err = nsutil.WithNS(nsPath, func() error {
+		var err error
+		out, err = exec.Command("ip", "-o", "addr", "show", "dev", "eth0").Output()
+		return err
+	})
+	require.NoError(err)
+	require.Contains(string(out), "172.31.250.2/24")
+
+	// The namespace is destroyed with the allocation
+	ar.Destroy()
+	select {
+	case <-ar.WaitCh():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for alloc runner to exit")
+	}
+	require.False(nsutil.IsNS(nsPath))
+	require.Error(exec.Command("ip", "link", "show", "dev", bridgeVethName(alloc.ID)).Run())
+}
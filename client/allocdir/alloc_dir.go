@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -253,6 +254,16 @@ func (d *AllocDir) Destroy() error {
 		mErr.Errors = append(mErr.Errors, err)
 	}
 
+	// Never remove the alloc dir while host directories are still mounted in
+	// it as their contents would be removed as well
+	if mounts, err := mountPointsBelow(d.AllocDir); err != nil || len(mounts) != 0 {
+		if err == nil {
+			err = fmt.Errorf("directories are still mounted: %s", strings.Join(mounts, ", "))
+		}
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("failed to remove alloc dir %q: %v", d.AllocDir, err))
+		return mErr.ErrorOrNil()
+	}
+
 	if err := os.RemoveAll(d.AllocDir); err != nil {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("failed to remove alloc dir %q: %v", d.AllocDir, err))
 	}
@@ -288,6 +299,11 @@ func (d *AllocDir) UnmountAll() error {
 		if err := dir.unmountSpecialDirs(); err != nil {
 			mErr.Errors = append(mErr.Errors, err)
 		}
+
+		// Unmount the volumes and anything else left mounted
+		if err := unmountAll(dir.Dir); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
 	}
 
 	return mErr.ErrorOrNil()
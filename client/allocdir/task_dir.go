@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	cstructs "github.com/hashicorp/nomad/client/structs"
 )
@@ -132,6 +133,24 @@ func (t *TaskDir) Build(chrootCreated bool, chroot map[string]string, fsi cstruc
 	return nil
 }
 
+// MountVolume mounts the host directory source into the task directory at
+// dest, which is relative to the task directory, and returns the path of the
+// mount on the host. Mounting an already mounted volume is a no-op.
+func (t *TaskDir) MountVolume(source, dest string, readOnly bool) (string, error) {
+	path := filepath.Join(t.Dir, filepath.Clean(string(filepath.Separator)+dest))
+	if path == t.Dir || !strings.HasPrefix(path, t.Dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("volume destination %q escapes the task directory", dest)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return "", err
+	}
+	if err := mountVolume(source, path, readOnly); err != nil {
+		return "", fmt.Errorf("failed to mount volume %q at %q: %v", source, path, err)
+	}
+	return path, nil
+}
+
 // buildChroot takes a mapping of absolute directory or file paths on the host
 // to their intended, relative location within the task directory. This
 // attempts hardlink and then defaults to copying. If the path exists on the
@@ -240,3 +259,15 @@ func (t *TaskDir) embedDirs(entries map[string]string) error {
 
 	return nil
 }
+
+// symlinkVolume links dst to the host directory src. It is used where bind
+// mounts aren't available and can't make the volume read only.
+func symlinkVolume(src, dst string, readOnly bool) error {
+	if readOnly {
+		return fmt.Errorf("read only volumes can't be mounted without bind mounts")
+	}
+	if target, err := os.Readlink(dst); err == nil && target == src {
+		return nil
+	}
+	return os.Symlink(src, dst)
+}
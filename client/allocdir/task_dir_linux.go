@@ -1,12 +1,17 @@
 package allocdir
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/hashicorp/go-multierror"
+	"golang.org/x/sys/unix"
 )
 
 // mountSpecialDirs mounts the dev and proc file system from the host to the
@@ -75,3 +80,119 @@ func (t *TaskDir) unmountSpecialDirs() error {
 
 	return errs.ErrorOrNil()
 }
+
+// mountVolume bind mounts the host directory src at dst, read only if
+// requested. Without root privileges the volume is symlinked instead.
+func mountVolume(src, dst string, readOnly bool) error {
+	if unix.Geteuid() != 0 {
+		return symlinkVolume(src, dst, readOnly)
+	}
+
+	mounts, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		if m == dst {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dst, 0777); err != nil {
+		return err
+	}
+	if err := syscall.Mount(src, dst, "", syscall.MS_BIND, ""); err != nil {
+		return os.NewSyscallError("mount", err)
+	}
+
+	// Bind mounts can only be made read only by remounting them
+	if readOnly {
+		flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+		if err := syscall.Mount("", dst, "", flags, ""); err != nil {
+			syscall.Unmount(dst, 0)
+			return os.NewSyscallError("mount", err)
+		}
+	}
+	return nil
+}
+
+// unmountAll unmounts every mount left below the directory, such as the
+// volumes of the task, deepest first. The directory must not be removed if an
+// error is returned as it could still contain host directories.
+func unmountAll(dir string) error {
+	mounts, err := mountPointsBelow(dir)
+	if err != nil {
+		return err
+	}
+
+	var mErr multierror.Error
+	for _, m := range mounts {
+		if err := syscall.Unmount(m, 0); err != nil && err != syscall.EINVAL {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("failed to unmount %q: %v", m, os.NewSyscallError("unmount", err)))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// mountPointsBelow returns the mount points below the directory, deepest
+// first.
+func mountPointsBelow(dir string) ([]string, error) {
+	mounts, err := mountPoints()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	var below []string
+	for _, m := range mounts {
+		if strings.HasPrefix(m, prefix) {
+			below = append(below, m)
+		}
+	}
+	sort.Slice(below, func(i, j int) bool {
+		return len(below[i]) > len(below[j])
+	})
+	return below, nil
+}
+
+// mountPoints returns the mount points of the mount namespace of the client
+func mountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The mount point is the fifth field, with spaces and other special
+		// characters escaped in octal
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, unescapeMountPath(fields[4]))
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath unescapes the octal sequences of a path of mountinfo
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
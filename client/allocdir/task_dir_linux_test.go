@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"golang.org/x/sys/unix"
 )
 
@@ -70,3 +71,97 @@ func TestLinuxSpecialDirs(t *testing.T) {
 		t.Fatalf("error re-unmounting special dirs in %q: %v", td.Dir, err)
 	}
 }
+
+// TestLinuxMountVolume ensures volumes are bind mounted and unmounted before
+// the alloc dir is removed.
+func TestLinuxMountVolume(t *testing.T) {
+	if unix.Geteuid() != 0 {
+		t.Skip("Must be run as root")
+	}
+
+	hostDir, err := ioutil.TempDir("", "nomadtest-volume")
+	if err != nil {
+		t.Fatalf("unable to create tempdir for test: %v", err)
+	}
+	defer os.RemoveAll(hostDir)
+	hostFile := filepath.Join(hostDir, "file")
+	if err := ioutil.WriteFile(hostFile, []byte("foo"), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tmp, err := ioutil.TempDir("", "nomadtest-mountvolume")
+	if err != nil {
+		t.Fatalf("unable to create tempdir for test: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	d := NewAllocDir(testLogger(), tmp)
+	defer d.Destroy()
+	if err := d.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	td := d.NewTaskDir("test")
+	if err := td.Build(false, nil, cstructs.FSIsolationNone); err != nil {
+		t.Fatalf("TaskDir.Build() failed: %v", err)
+	}
+
+	path, err := td.MountVolume(hostDir, "/data", true)
+	if err != nil {
+		t.Fatalf("MountVolume() failed: %v", err)
+	}
+	if path != filepath.Join(td.Dir, "data") {
+		t.Fatalf("unexpected mount path %q", path)
+	}
+	if _, err := os.Stat(filepath.Join(path, "file")); err != nil {
+		t.Fatalf("expected the host file in the volume: %v", err)
+	}
+
+	// The volume is read only
+	if err := ioutil.WriteFile(filepath.Join(path, "other"), []byte("bar"), 0644); err == nil {
+		t.Fatalf("expected writing to a read only volume to fail")
+	}
+
+	// Mounting again is a no-op
+	if _, err := td.MountVolume(hostDir, "data", true); err != nil {
+		t.Fatalf("remounting failed: %v", err)
+	}
+	mounts, err := mountPointsBelow(td.Dir)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	found := 0
+	for _, m := range mounts {
+		if m == path {
+			found++
+		}
+	}
+	if found != 1 {
+		t.Fatalf("expected the volume to be mounted once; got %v", mounts)
+	}
+
+	// Destroying the alloc dir unmounts the volume and leaves the host
+	// directory intact
+	if err := d.Destroy(); err != nil {
+		t.Fatalf("Destroy() failed: %v", err)
+	}
+	if _, err := os.Stat(hostFile); err != nil {
+		t.Fatalf("expected the host file to be intact: %v", err)
+	}
+	if pathExists(d.AllocDir) {
+		t.Fatalf("expected the alloc dir to be removed")
+	}
+}
+
+func TestUnescapeMountPath(t *testing.T) {
+	cases := map[string]string{
+		"/var/lib/nomad":     "/var/lib/nomad",
+		`/mnt/with\040space`: "/mnt/with space",
+		`/mnt/back\134slash`: `/mnt/back\slash`,
+		`/mnt/trailing\04`:   `/mnt/trailing\04`,
+	}
+	for in, expected := range cases {
+		if out := unescapeMountPath(in); out != expected {
+			t.Fatalf("unescapeMountPath(%q) = %q; want %q", in, out, expected)
+		}
+	}
+}
@@ -11,3 +11,19 @@ func (d *TaskDir) mountSpecialDirs() error {
 func (d *TaskDir) unmountSpecialDirs() error {
 	return nil
 }
+
+// mountVolume symlinks the host directory src at dst as bind mounts are only
+// available on Linux
+func mountVolume(src, dst string, readOnly bool) error {
+	return symlinkVolume(src, dst, readOnly)
+}
+
+// currently a noop on non-Linux platforms as volumes are symlinked
+func unmountAll(dir string) error {
+	return nil
+}
+
+// currently a noop on non-Linux platforms as volumes are symlinked
+func mountPointsBelow(dir string) ([]string, error) {
+	return nil, nil
+}
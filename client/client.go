@@ -9,6 +9,7 @@ import (
 	"net/rpc"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,11 +18,13 @@ import (
 
 	metrics "github.com/armon/go-metrics"
 	"github.com/boltdb/bolt"
+	"github.com/hashicorp/consul-template/signals"
 	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/client/servers"
 	"github.com/hashicorp/nomad/client/stats"
 	cstructs "github.com/hashicorp/nomad/client/structs"
@@ -168,6 +171,14 @@ type Client struct {
 	// vaultClient is used to interact with Vault for token and secret renewals
 	vaultClient vaultclient.VaultClient
 
+	// cpusets confines the tasks without dedicated cores to the cores of the
+	// node that are neither reserved nor dedicated to a task
+	cpusets *cpusetManager
+
+	// driverPlugins manages the plugin processes of the external drivers that
+	// aren't tied to a task
+	driverPlugins *driver.DriverPluginManager
+
 	// garbageCollector is used to garbage collect terminal allocations present
 	// in the node automatically
 	garbageCollector *AllocGarbageCollector
@@ -220,6 +231,8 @@ func NewClient(cfg *config.Config, consulCatalog consul.CatalogAPI, consulServic
 		triggerDiscoveryCh:   make(chan struct{}),
 		triggerNodeUpdate:    make(chan struct{}, 8),
 		triggerEmitNodeEvent: make(chan *structs.NodeEvent, 8),
+		cpusets:              newCpusetManager(logger),
+		driverPlugins:        driver.NewDriverPluginManager(),
 	}
 
 	// Initialize the server manager
@@ -261,7 +274,7 @@ func NewClient(cfg *config.Config, consulCatalog consul.CatalogAPI, consulServic
 
 	fingerprintManager := NewFingerprintManager(c.GetConfig, c.config.Node,
 		c.shutdownCh, c.updateNodeFromFingerprint, c.updateNodeFromDriver,
-		c.logger)
+		c.driverPlugins, c.logger)
 
 	// Fingerprint the node and scan for drivers
 	if err := fingerprintManager.Run(); err != nil {
@@ -391,6 +404,33 @@ func (c *Client) init() error {
 	}
 
 	c.logger.Printf("[INFO] client: using alloc directory %v", c.config.AllocDir)
+
+	return c.setupDriverPlugins()
+}
+
+// setupDriverPlugins discovers the external driver plugins in the plugin
+// directory.
+func (c *Client) setupDriverPlugins() error {
+	plugins, err := driver.DiscoverDriverPlugins(c.config.PluginDir, c.config.PluginConfigs)
+	if err != nil {
+		return err
+	}
+
+	for name, p := range plugins {
+		if _, ok := driver.BuiltinDrivers[name]; ok {
+			c.logger.Printf("[WARN] client: ignoring driver plugin %q: a built-in driver of the same name exists", p.Path)
+			delete(plugins, name)
+			continue
+		}
+		c.logger.Printf("[INFO] client: using driver plugin %q for driver %q", p.Path, name)
+	}
+	for name := range c.config.PluginConfigs {
+		if _, ok := plugins[name]; !ok {
+			c.logger.Printf("[WARN] client: configuration given for unknown driver plugin %q", name)
+		}
+	}
+
+	c.config.DriverPlugins = plugins
 	return nil
 }
 
@@ -505,6 +545,9 @@ func (c *Client) Shutdown() error {
 		}
 	}
 
+	// Stop the driver plugins not tied to a task
+	c.driverPlugins.Shutdown()
+
 	c.shutdown = true
 	close(c.shutdownCh)
 	c.connPool.Shutdown()
@@ -602,6 +645,62 @@ func (c *Client) GetClientAlloc(allocID string) (*structs.Allocation, error) {
 	return alloc, nil
 }
 
+// RestartAllocation restarts the given task of an allocation, or all of its
+// tasks if no task is given.
+func (c *Client) RestartAllocation(allocID, taskName string) error {
+	ar, err := c.getAllocRunner(allocID)
+	if err != nil {
+		return err
+	}
+	return ar.RestartTask(taskName, "User", "Restart requested through the API")
+}
+
+// SignalAllocation sends a signal to the given task of an allocation, or to
+// all of its tasks if no task is given.
+func (c *Client) SignalAllocation(allocID, taskName, signal string) error {
+	ar, err := c.getAllocRunner(allocID)
+	if err != nil {
+		return err
+	}
+
+	s, err := signals.Parse(signal)
+	if err != nil {
+		return fmt.Errorf("invalid signal %q: %v", signal, err)
+	}
+	return ar.SignalTask(taskName, s, "User", "Signal requested through the API")
+}
+
+// getAllocRunner returns the runner of an allocation
+func (c *Client) getAllocRunner(allocID string) (*AllocRunner, error) {
+	c.allocLock.RLock()
+	defer c.allocLock.RUnlock()
+
+	ar, ok := c.allocs[allocID]
+	if !ok {
+		return nil, structs.NewErrUnknownAllocation(allocID)
+	}
+	return ar, nil
+}
+
+// getTaskHandle returns the driver handle of a running task of an allocation
+func (c *Client) getTaskHandle(allocID, task string) (driver.DriverHandle, error) {
+	ar, err := c.getAllocRunner(allocID)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := ar.getTaskRunner(task)
+	if tr == nil {
+		return nil, fmt.Errorf("unknown task name %q", task)
+	}
+
+	handle := tr.getHandle()
+	if handle == nil {
+		return nil, fmt.Errorf("task %q is not running", task)
+	}
+	return handle, nil
+}
+
 // GetServers returns the list of nomad servers this client is aware of.
 func (c *Client) GetServers() []string {
 	endpoints := c.servers.GetServers()
@@ -726,7 +825,7 @@ func (c *Client) restoreState() error {
 		watcher := noopPrevAlloc{}
 
 		c.configLock.RLock()
-		ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc, c.vaultClient, c.consulService, watcher)
+		ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc, c.vaultClient, c.consulService, watcher, c.cpusets, c.driverPlugins)
 		c.configLock.RUnlock()
 
 		c.allocLock.Lock()
@@ -1003,9 +1102,21 @@ func (c *Client) updateNodeFromFingerprint(response *cstructs.FingerprintRespons
 		}
 	}
 
-	if response.Resources != nil && !resourcesAreEqual(c.config.Node.Resources, response.Resources) {
+	// The response only holds the resources the fingerprinter detects so
+	// compare the node's resources once merged
+	if response.Resources != nil {
+		resources := c.config.Node.Resources.Copy()
+		resources.Merge(response.Resources)
+		if !resourcesAreEqual(c.config.Node.Resources, resources) {
+			nodeHasChanged = true
+			c.config.Node.Resources = resources
+			c.cpusets.SetNode(c.config.Node)
+		}
+	}
+
+	if response.HostVolumes != nil && !reflect.DeepEqual(c.config.Node.HostVolumes, response.HostVolumes) {
 		nodeHasChanged = true
-		c.config.Node.Resources.Merge(response.Resources)
+		c.config.Node.HostVolumes = response.HostVolumes
 	}
 
 	if nodeHasChanged {
@@ -1138,6 +1249,12 @@ func resourcesAreEqual(first, second *structs.Resources) bool {
 			return false
 		}
 	}
+	if !reflect.DeepEqual(first.Devices, second.Devices) {
+		return false
+	}
+	if !reflect.DeepEqual(first.CoreIDs, second.CoreIDs) {
+		return false
+	}
 	return true
 }
 
@@ -1899,7 +2016,7 @@ func (c *Client) addAlloc(alloc *structs.Allocation, migrateToken string) error
 	c.configLock.RLock()
 	prevAlloc := newAllocWatcher(alloc, prevAR, c, c.configCopy, c.logger, migrateToken)
 
-	ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc, c.vaultClient, c.consulService, prevAlloc)
+	ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc, c.vaultClient, c.consulService, prevAlloc, c.cpusets, c.driverPlugins)
 	c.configLock.RUnlock()
 
 	// Store the alloc runner.
@@ -126,6 +126,26 @@ type Config struct {
 	// task's chroot.
 	ChrootEnv map[string]string
 
+	// HostVolumes are the directories of the host made available to tasks
+	// as volumes, keyed by name.
+	HostVolumes map[string]*structs.ClientHostVolumeConfig
+
+	// BridgeNetworkName is the name of the bridge allocations in the bridge
+	// network mode are attached to.
+	BridgeNetworkName string
+
+	// BridgeNetworkSubnet is the subnet the addresses of the allocations in
+	// the bridge network mode are allocated from.
+	BridgeNetworkSubnet string
+
+	// CNIPath is the list of directories, separated like the PATH environment
+	// variable, the CNI plugins are searched in.
+	CNIPath string
+
+	// CNIConfigDir is the directory the CNI network configurations are loaded
+	// from.
+	CNIConfigDir string
+
 	// Options provides arbitrary key-value configuration for nomad internals,
 	// like fingerprinters and drivers. The format is:
 	//
@@ -200,6 +220,18 @@ type Config struct {
 	// displaying metrics for older versions, or to only show the new format
 	BackwardsCompatibleMetrics bool
 
+	// PluginDir is the directory in which external driver plugins are
+	// discovered.
+	PluginDir string
+
+	// PluginConfigs is the configuration of the external plugins, keyed by
+	// plugin name.
+	PluginConfigs map[string]map[string]interface{}
+
+	// DriverPlugins are the external driver plugins discovered in the
+	// PluginDir, keyed by driver name.
+	DriverPlugins map[string]*DriverPlugin
+
 	// RPCHoldTimeout is how long an RPC can be "held" before it is errored.
 	// This is used to paper over a loss of leadership by instead holding RPCs,
 	// so that the caller experiences a slow response rather than an error.
@@ -208,6 +240,19 @@ type Config struct {
 	RPCHoldTimeout time.Duration
 }
 
+// DriverPlugin is an external driver plugin of the client.
+type DriverPlugin struct {
+	// Name is the name of the driver the plugin provides.
+	Name string
+
+	// Path is the path to the plugin binary.
+	Path string
+
+	// Config is the configuration of the plugin given in the agent's
+	// configuration.
+	Config map[string]interface{}
+}
+
 func (c *Config) Copy() *Config {
 	nc := new(Config)
 	*nc = *c
@@ -215,6 +260,7 @@ func (c *Config) Copy() *Config {
 	nc.Servers = helper.CopySliceString(nc.Servers)
 	nc.Options = helper.CopyMapStringString(nc.Options)
 	nc.GloballyReservedPorts = helper.CopySliceInt(c.GloballyReservedPorts)
+	nc.HostVolumes = structs.CopyMapStringClientHostVolumeConfig(c.HostVolumes)
 	nc.ConsulConfig = c.ConsulConfig.Copy()
 	nc.VaultConfig = c.VaultConfig.Copy()
 	return nc
@@ -240,6 +286,10 @@ func DefaultConfig() *Config {
 		DisableTaggedMetrics:       false,
 		BackwardsCompatibleMetrics: false,
 		RPCHoldTimeout:             5 * time.Second,
+		BridgeNetworkName:          "nomad",
+		BridgeNetworkSubnet:        "172.26.64.0/20",
+		CNIPath:                    "/opt/cni/bin",
+		CNIConfigDir:               "/opt/cni/config",
 	}
 }
 
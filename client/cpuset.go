@@ -0,0 +1,133 @@
+package client
+
+import (
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// cpusetManager tracks the cores of the node dedicated to tasks and confines
+// the tasks without dedicated cores to the shared cores: the cores of the
+// node that are neither reserved nor dedicated to a task.
+type cpusetManager struct {
+	logger *log.Logger
+
+	// cores are the cores of the node that aren't reserved
+	cores []uint16
+
+	// pinned maps the tasks with dedicated cores to their cores
+	pinned map[string][]uint16
+
+	// shared maps the running tasks without dedicated cores to their handle
+	shared map[string]driver.CpusetUpdater
+
+	// cpuset is the last shared cpuset the shared tasks were updated with
+	cpuset string
+
+	lock sync.Mutex
+}
+
+func newCpusetManager(logger *log.Logger) *cpusetManager {
+	return &cpusetManager{
+		logger: logger,
+		pinned: make(map[string][]uint16),
+		shared: make(map[string]driver.CpusetUpdater),
+	}
+}
+
+// cpusetKey returns the key of a task of an allocation in the manager.
+func cpusetKey(allocID, task string) string {
+	return allocID + "/" + task
+}
+
+// SetNode updates the cores of the node from its resources.
+func (m *cpusetManager) SetNode(node *structs.Node) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.cores = structs.NewCoreAccounter(node).Cores
+	m.update()
+}
+
+// Shared returns the shared cpuset. It is empty if the cores of the node are
+// unknown.
+func (m *cpusetManager) Shared() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return structs.FormatCpuset(m.sharedCores())
+}
+
+// AddPinned removes the cores dedicated to a task from the shared cores.
+func (m *cpusetManager) AddPinned(key string, cores []uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.pinned[key] = cores
+	m.update()
+}
+
+// AddShared confines a running task without dedicated cores to the shared
+// cores until it is removed.
+func (m *cpusetManager) AddShared(key string, handle driver.CpusetUpdater) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.shared[key] = handle
+	if m.cpuset == "" {
+		return
+	}
+	if err := handle.UpdateCpuset(m.cpuset); err != nil {
+		m.logger.Printf("[WARN] client: failed to update the cpuset of task %q: %v", key, err)
+	}
+}
+
+// Remove releases the dedicated cores of a task or stops updating its cpuset.
+func (m *cpusetManager) Remove(key string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.shared, key)
+	if _, ok := m.pinned[key]; ok {
+		delete(m.pinned, key)
+		m.update()
+	}
+}
+
+// sharedCores returns the cores of the node not dedicated to any task, in
+// ascending order.
+func (m *cpusetManager) sharedCores() []uint16 {
+	used := make(map[uint16]struct{})
+	for _, cores := range m.pinned {
+		for _, id := range cores {
+			used[id] = struct{}{}
+		}
+	}
+
+	shared := make([]uint16, 0, len(m.cores))
+	for _, id := range m.cores {
+		if _, ok := used[id]; !ok {
+			shared = append(shared, id)
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool { return shared[i] < shared[j] })
+	return shared
+}
+
+// update updates the cpuset of the shared tasks if the shared cores changed.
+// The lock must be held.
+func (m *cpusetManager) update() {
+	cpuset := structs.FormatCpuset(m.sharedCores())
+	if cpuset == m.cpuset || cpuset == "" {
+		return
+	}
+	m.cpuset = cpuset
+
+	for key, handle := range m.shared {
+		if err := handle.UpdateCpuset(cpuset); err != nil {
+			m.logger.Printf("[WARN] client: failed to update the cpuset of task %q: %v", key, err)
+		}
+	}
+}
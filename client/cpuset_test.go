@@ -0,0 +1,67 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/helper/testlog"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCpusetUpdater records the cpusets a task was updated with.
+type mockCpusetUpdater struct {
+	cpusets []string
+}
+
+func (m *mockCpusetUpdater) UpdateCpuset(cpuset string) error {
+	m.cpusets = append(m.cpusets, cpuset)
+	return nil
+}
+
+func (m *mockCpusetUpdater) last() string {
+	if len(m.cpusets) == 0 {
+		return ""
+	}
+	return m.cpusets[len(m.cpusets)-1]
+}
+
+func TestCpusetManager_SharedExcludesPinned(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	m := newCpusetManager(testlog.Logger(t))
+
+	// The cores are unknown until the node is set
+	shared := &mockCpusetUpdater{}
+	m.AddShared(cpusetKey("alloc1", "web"), shared)
+	require.Empty(m.Shared())
+	require.Empty(shared.cpusets)
+
+	m.SetNode(&structs.Node{
+		Resources: &structs.Resources{CoreIDs: []uint16{0, 1, 2, 3}},
+		Reserved:  &structs.Resources{CoreIDs: []uint16{0}},
+	})
+	require.Equal("1,2,3", m.Shared())
+	require.Equal("1,2,3", shared.last())
+
+	// Pinning a task removes its cores from the shared tasks
+	m.AddPinned(cpusetKey("alloc2", "db"), []uint16{2})
+	require.Equal("1,3", m.Shared())
+	require.Equal("1,3", shared.last())
+
+	// A task started later is confined to the current shared cores
+	other := &mockCpusetUpdater{}
+	m.AddShared(cpusetKey("alloc3", "cache"), other)
+	require.Equal("1,3", other.last())
+
+	// The cores are returned once the pinned task is removed
+	m.Remove(cpusetKey("alloc2", "db"))
+	require.Equal("1,2,3", shared.last())
+	require.Equal("1,2,3", other.last())
+
+	// Removed shared tasks are no longer updated
+	m.Remove(cpusetKey("alloc3", "cache"))
+	m.AddPinned(cpusetKey("alloc4", "db"), []uint16{1})
+	require.Equal("2,3", shared.last())
+	require.Equal("1,2,3", other.last())
+}
@@ -748,8 +748,10 @@ func (d *DockerDriver) Validate(config map[string]interface{}) error {
 
 func (d *DockerDriver) Abilities() DriverAbilities {
 	return DriverAbilities{
-		SendSignals: true,
-		Exec:        true,
+		SendSignals:      true,
+		Exec:             true,
+		MountVolumes:     true,
+		NetworkIsolation: true,
 	}
 }
 
@@ -776,6 +778,13 @@ func (d *DockerDriver) Prestart(ctx *ExecContext, task *structs.Task) (*Prestart
 		return nil, err
 	}
 
+	// Log shippers read the log files of the task which are only written when
+	// Nomad collects the logs of the container
+	if task.LogConfig != nil && len(task.LogConfig.Shippers) != 0 &&
+		(len(driverConfig.Logging) != 0 || runtime.GOOS == "darwin") {
+		return nil, fmt.Errorf("log shippers are not supported when Nomad doesn't collect the docker logs")
+	}
+
 	// Set state needed by Start
 	d.driverConfig = driverConfig
 
@@ -1156,6 +1165,16 @@ func (d *DockerDriver) containerBinds(driverConfig *DockerDriverConfig, ctx *Exe
 		}
 	}
 
+	// Mount the host volumes of the task. They are never relabeled since
+	// they are directories of the host shared with other tasks.
+	for _, m := range ctx.Mounts {
+		bind := fmt.Sprintf("%s:%s", m.HostPath, m.TaskPath)
+		if m.Readonly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+	}
+
 	return binds, nil
 }
 
@@ -1193,6 +1212,14 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 
 	memLimit := int64(task.Resources.MemoryMB) * 1024 * 1024
 
+	// If the task may use more memory than it reserves, the reservation
+	// becomes the soft limit
+	var memReservation int64
+	if task.Resources.MemoryMaxMB > task.Resources.MemoryMB {
+		memReservation = memLimit
+		memLimit = int64(task.Resources.MemoryMaxMB) * 1024 * 1024
+	}
+
 	if len(driverConfig.Logging) == 0 {
 		if runtime.GOOS == "darwin" {
 			d.logger.Printf("[DEBUG] driver.docker: deferring logging to docker on Docker for Mac")
@@ -1206,7 +1233,8 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 
 	hostConfig := &docker.HostConfig{
 		// Convert MB to bytes. This is an absolute value.
-		Memory: memLimit,
+		Memory:            memLimit,
+		MemoryReservation: memReservation,
 		// Convert Mhz to shares. This is a relative value.
 		CPUShares: int64(task.Resources.CPU),
 
@@ -1218,6 +1246,14 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 		VolumeDriver: driverConfig.VolumeDriver,
 	}
 
+	// Pin the container to its dedicated cores or confine it to the shared
+	// ones. Windows containers don't support cpusets.
+	if len(task.Resources.CoreIDs) > 0 {
+		hostConfig.CPUSetCPUs = structs.FormatCpuset(task.Resources.CoreIDs)
+	} else if runtime.GOOS != "windows" {
+		hostConfig.CPUSetCPUs = ctx.Cpuset
+	}
+
 	// Calculate CPU Quota
 	// cfs_quota_us is the time per core, so we must
 	// multiply the time by the number of cores available
@@ -1362,6 +1398,20 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	hostConfig.ReadonlyRootfs = driverConfig.ReadonlyRootfs
 
 	hostConfig.NetworkMode = driverConfig.NetworkMode
+
+	// The tasks of an allocation with a group network join the network
+	// namespace of its pause container
+	if ctx.NetworkIsolation != nil {
+		if driverConfig.NetworkMode != "" {
+			return c, fmt.Errorf("network_mode can't be set when the task group network is in %q mode", ctx.NetworkIsolation.Mode)
+		}
+		id, ok := ctx.NetworkIsolation.Labels[dockerNetSpecLabelKey]
+		if !ok {
+			return c, fmt.Errorf("network namespace of the allocation wasn't created by the docker driver")
+		}
+		hostConfig.NetworkMode = "container:" + id
+	}
+
 	if hostConfig.NetworkMode == "" {
 		// docker default
 		d.logger.Printf("[DEBUG] driver.docker: networking mode not specified; defaulting to %s", defaultNetworkMode)
@@ -1858,6 +1908,73 @@ func (h *DockerHandle) Exec(ctx context.Context, cmd string, args []string) ([]b
 	return output.Bytes(), res.ExitCode, nil
 }
 
+func (h *DockerHandle) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	if len(opts.Command) == 0 {
+		return 0, fmt.Errorf("command is required")
+	}
+
+	// The output of commands with a tty is written to stdout only
+	createExecOpts := docker.CreateExecOptions{
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: !opts.Tty,
+		Tty:          opts.Tty,
+		Cmd:          opts.Command,
+		Container:    h.containerID,
+		Context:      ctx,
+	}
+	exec, err := h.client.CreateExec(createExecOpts)
+	if err != nil {
+		return 0, err
+	}
+
+	if opts.Tty {
+		doneCh := make(chan struct{})
+		defer close(doneCh)
+		go func() {
+			for {
+				select {
+				case size, ok := <-opts.ResizeCh:
+					if !ok {
+						return
+					}
+					if err := h.client.ResizeExecTTY(exec.ID, int(size.Height), int(size.Width)); err != nil {
+						h.logger.Printf("[DEBUG] driver.docker: failed to resize exec %s: %v", exec.ID, err)
+					}
+				case <-doneCh:
+					return
+				}
+			}
+		}()
+	}
+
+	startOpts := docker.StartExecOptions{
+		Detach:       false,
+		Tty:          opts.Tty,
+		RawTerminal:  opts.Tty,
+		InputStream:  opts.Stdin,
+		OutputStream: opts.Stdout,
+		ErrorStream:  opts.Stderr,
+		Context:      ctx,
+	}
+	if err := h.client.StartExec(exec.ID, startOpts); err != nil {
+		return 0, err
+	}
+	res, err := h.client.InspectExec(exec.ID)
+	if err != nil {
+		return 0, err
+	}
+	return res.ExitCode, nil
+}
+
+// UpdateCpuset confines the container to the cores of the cpuset.
+func (h *DockerHandle) UpdateCpuset(cpuset string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return h.client.UpdateContainer(h.containerID, docker.UpdateContainerOptions{CpusetCpus: cpuset})
+}
+
 func (h *DockerHandle) Signal(s os.Signal) error {
 	// Convert types
 	sysSig, ok := s.(syscall.Signal)
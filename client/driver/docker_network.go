@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"fmt"
+
+	docker "github.com/fsouza/go-dockerclient"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+)
+
+const (
+	// dockerInfraImageConfigOption is the key for the image of the
+	// container holding the network namespace of an allocation
+	dockerInfraImageConfigOption  = "docker.infra_image"
+	dockerInfraImageConfigDefault = "gcr.io/google_containers/pause-amd64:3.0"
+
+	// dockerNetSpecLabelKey is the label of the network isolation spec
+	// holding the ID of the container holding the network namespace
+	dockerNetSpecLabelKey = "docker_sandbox_container_id"
+)
+
+// CreateNetwork creates a pause container whose network namespace the tasks
+// of the allocation join. The container has no network but the loopback
+// interface, the namespace is configured by the client.
+func (d *DockerDriver) CreateNetwork(allocID string) (*cstructs.NetworkIsolationSpec, error) {
+	client, _, err := d.dockerClients()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to docker daemon: %s", err)
+	}
+
+	name := fmt.Sprintf("nomad_init_%s", allocID)
+
+	// Reuse the container if it's still running, for example after the
+	// client restarted
+	if c, err := client.InspectContainer(name); err == nil {
+		if c.State.Running {
+			return dockerNetworkSpec(c), nil
+		}
+		if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: c.ID, Force: true}); err != nil {
+			return nil, fmt.Errorf("failed to remove stopped network container %q: %v", c.ID, err)
+		}
+	}
+
+	image := d.config.ReadDefault(dockerInfraImageConfigOption, dockerInfraImageConfigDefault)
+	if _, err := client.InspectImage(image); err != nil {
+		repo, tag := docker.ParseRepositoryTag(image)
+		if tag == "" {
+			tag = "latest"
+		}
+		d.logger.Printf("[DEBUG] driver.docker: pulling infra image %s:%s", repo, tag)
+		opts := docker.PullImageOptions{Repository: repo, Tag: tag}
+		if err := client.PullImage(opts, docker.AuthConfiguration{}); err != nil {
+			return nil, fmt.Errorf("failed to pull infra image %q: %v", image, err)
+		}
+	}
+
+	config := docker.CreateContainerOptions{
+		Name: name,
+		Config: &docker.Config{
+			Image: image,
+			Labels: map[string]string{
+				"com.hashicorp.nomad.alloc_id": allocID,
+			},
+		},
+		HostConfig: &docker.HostConfig{
+			NetworkMode: "none",
+		},
+	}
+	c, err := client.CreateContainer(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network container: %v", err)
+	}
+	if err := client.StartContainer(c.ID, nil); err != nil {
+		client.RemoveContainer(docker.RemoveContainerOptions{ID: c.ID, Force: true})
+		return nil, fmt.Errorf("failed to start network container: %v", err)
+	}
+
+	// The PID of the container is only known once it is started
+	c, err = client.InspectContainer(c.ID)
+	if err != nil {
+		client.RemoveContainer(docker.RemoveContainerOptions{ID: config.Name, Force: true})
+		return nil, fmt.Errorf("failed to inspect network container: %v", err)
+	}
+	return dockerNetworkSpec(c), nil
+}
+
+// DestroyNetwork removes the pause container of the allocation.
+func (d *DockerDriver) DestroyNetwork(allocID string, spec *cstructs.NetworkIsolationSpec) error {
+	client, _, err := d.dockerClients()
+	if err != nil {
+		return fmt.Errorf("Failed to connect to docker daemon: %s", err)
+	}
+
+	id := spec.Labels[dockerNetSpecLabelKey]
+	if id == "" {
+		id = fmt.Sprintf("nomad_init_%s", allocID)
+	}
+
+	err = client.RemoveContainer(docker.RemoveContainerOptions{ID: id, Force: true})
+	if _, ok := err.(*docker.NoSuchContainer); ok {
+		return nil
+	}
+	return err
+}
+
+// dockerNetworkSpec returns the network isolation spec of a running pause
+// container
+func dockerNetworkSpec(c *docker.Container) *cstructs.NetworkIsolationSpec {
+	return &cstructs.NetworkIsolationSpec{
+		Path: fmt.Sprintf("/proc/%d/ns/net", c.State.Pid),
+		Labels: map[string]string{
+			dockerNetSpecLabelKey: c.ID,
+		},
+	}
+}
@@ -225,7 +225,7 @@ func TestDockerDriver_Fingerprint_Bridge(t *testing.T) {
 
 	conf := testConfig(t)
 	conf.Node = mock.Node()
-	dd := NewDockerDriver(NewDriverContext("", "", conf, conf.Node, testLogger(), nil))
+	dd := NewDockerDriver(NewDriverContext("", "", conf, conf.Node, testLogger(), nil, nil))
 
 	request := &cstructs.FingerprintRequest{Config: conf, Node: conf.Node}
 	var response cstructs.FingerprintResponse
@@ -277,7 +277,7 @@ func TestDockerDriver_Check_DockerHealthStatus(t *testing.T) {
 
 	conf := testConfig(t)
 	conf.Node = mock.Node()
-	dd := NewDockerDriver(NewDriverContext("", "", conf, conf.Node, testLogger(), nil))
+	dd := NewDockerDriver(NewDriverContext("", "", conf, conf.Node, testLogger(), nil, nil))
 
 	request := &cstructs.HealthCheckRequest{}
 	var response cstructs.HealthCheckResponse
@@ -577,6 +577,28 @@ func TestDockerDriver_Start_BadPull_Recoverable(t *testing.T) {
 	}
 }
 
+func TestDockerDriver_Prestart_ShipperWithLogging(t *testing.T) {
+	if !tu.IsTravis() {
+		t.Parallel()
+	}
+	task, _, _ := dockerTask(t)
+	task.Config["logging"] = []map[string]interface{}{
+		{"type": "json-file"},
+	}
+	task.LogConfig.Shippers = []*structs.LogShipper{
+		{Type: "tcp", Address: "127.0.0.1:5000"},
+	}
+
+	ctx := testDockerDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewDockerDriver(ctx.DriverCtx)
+
+	_, err := d.Prestart(ctx.ExecCtx, task)
+	if err == nil || !strings.Contains(err.Error(), "log shippers are not supported") {
+		t.Fatalf("expected log shipper error; got %v", err)
+	}
+}
+
 func TestDockerDriver_Start_Wait_AllocDir(t *testing.T) {
 	if !tu.IsTravis() {
 		t.Parallel()
@@ -1667,7 +1689,7 @@ func setupDockerVolumes(t *testing.T, cfg *config.Config, hostpath string) (*str
 	emitter := func(m string, args ...interface{}) {
 		logger.Printf("[EVENT] "+m, args...)
 	}
-	driverCtx := NewDriverContext(task.Name, alloc.ID, cfg, cfg.Node, testLogger(), emitter)
+	driverCtx := NewDriverContext(task.Name, alloc.ID, cfg, cfg.Node, testLogger(), emitter, nil)
 	driver := NewDockerDriver(driverCtx)
 
 	// Setup execCtx
@@ -1810,6 +1832,68 @@ func TestDockerDriver_VolumesEnabled(t *testing.T) {
 	}
 }
 
+func TestDockerDriver_HostVolumeMounts(t *testing.T) {
+	if !tu.IsTravis() {
+		t.Parallel()
+	}
+	if !testutil.DockerIsConnected(t) {
+		t.Skip("Docker not connected")
+	}
+
+	// Host volumes are mounted even when the volumes of the driver are
+	// disabled
+	cfg := testConfig(t)
+	cfg.Options = map[string]string{
+		dockerVolumesConfigOption: "false",
+		"docker.cleanup.image":    "false",
+	}
+
+	tmpvol, err := ioutil.TempDir("", "nomadtest_docker_hostvolumes")
+	if err != nil {
+		t.Fatalf("error creating temporary dir: %v", err)
+	}
+	defer os.RemoveAll(tmpvol)
+
+	// Evaluate symlinks so it works on MacOS
+	tmpvol, err = filepath.EvalSymlinks(tmpvol)
+	if err != nil {
+		t.Fatalf("error evaluating symlinks: %v", err)
+	}
+
+	task, driver, execCtx, hostpath, cleanup := setupDockerVolumes(t, cfg, tmpvol)
+	defer cleanup()
+	delete(task.Config, "volumes")
+	execCtx.Mounts = []*cstructs.MountConfig{
+		{
+			HostPath: tmpvol,
+			TaskPath: "/mnt/vol",
+		},
+	}
+
+	_, err = driver.Prestart(execCtx, task)
+	if err != nil {
+		t.Fatalf("error in prestart: %v", err)
+	}
+	resp, err := driver.Start(execCtx, task)
+	if err != nil {
+		t.Fatalf("Failed to start docker driver: %v", err)
+	}
+	defer resp.Handle.Kill()
+
+	select {
+	case res := <-resp.Handle.WaitCh():
+		if !res.Successful() {
+			t.Fatalf("unexpected err: %v", res)
+		}
+	case <-time.After(time.Duration(tu.TestMultiplier()*10) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if _, err := ioutil.ReadFile(hostpath); err != nil {
+		t.Fatalf("unexpected error reading %s: %v", hostpath, err)
+	}
+}
+
 func TestDockerDriver_Mounts(t *testing.T) {
 	if !tu.IsTravis() {
 		t.Parallel()
@@ -2458,3 +2542,37 @@ func TestDockerDriver_AdvertiseIPv6Address(t *testing.T) {
 		t.Fatalf("Got GlobalIPv6address %s want GlobalIPv6address with prefix %s", expectedPrefix, container.NetworkSettings.GlobalIPv6Address)
 	}
 }
+
+// TestDockerDriver_NetworkIsolation asserts the tasks of an allocation with a
+// group network join the network namespace of its pause container.
+func TestDockerDriver_NetworkIsolation(t *testing.T) {
+	if !tu.IsTravis() {
+		t.Parallel()
+	}
+
+	task, _, _ := dockerTask(t)
+	task.Resources.Networks = nil
+	tctx := testDockerDriverContexts(t, task)
+	defer tctx.AllocDir.Destroy()
+	tctx.ExecCtx.NetworkIsolation = &cstructs.NetworkIsolationSpec{
+		Mode: structs.NetworkModeBridge,
+		Path: "/proc/1/ns/net",
+		Labels: map[string]string{
+			dockerNetSpecLabelKey: "abc",
+		},
+	}
+	driver := NewDockerDriver(tctx.DriverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task, tctx.EnvBuilder.Build())
+	require.NoError(t, err)
+
+	c, err := driver.createContainerConfig(tctx.ExecCtx, task, driverConfig, "")
+	require.NoError(t, err)
+	require.Equal(t, "container:abc", c.HostConfig.NetworkMode)
+
+	// The network mode of the task can't be set
+	driverConfig.NetworkMode = "host"
+	_, err = driver.createContainerConfig(tctx.ExecCtx, task, driverConfig, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "network_mode")
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
 
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
@@ -37,11 +38,17 @@ var (
 )
 
 // NewDriver is used to instantiate and return a new driver
-// given the name and a logger
+// given the name and a logger. Built-in drivers take precedence over the
+// external driver plugins of the client.
 func NewDriver(name string, ctx *DriverContext) (Driver, error) {
 	// Lookup the factory function
 	factory, ok := BuiltinDrivers[name]
 	if !ok {
+		if ctx.config != nil {
+			if p, ok := ctx.config.DriverPlugins[name]; ok {
+				return NewExternalDriver(p, ctx), nil
+			}
+		}
 		return nil, fmt.Errorf("unknown driver '%s'", name)
 	}
 
@@ -50,6 +57,22 @@ func NewDriver(name string, ctx *DriverContext) (Driver, error) {
 	return d, nil
 }
 
+// DriverNames returns the names of the built-in drivers and of the external
+// driver plugins of the client, sorted by name.
+func DriverNames(config *config.Config) []string {
+	names := make([]string, 0, len(BuiltinDrivers)+len(config.DriverPlugins))
+	for name := range BuiltinDrivers {
+		names = append(names, name)
+	}
+	for name := range config.DriverPlugins {
+		if _, ok := BuiltinDrivers[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Factory is used to instantiate a new Driver
 type Factory func(*DriverContext) Driver
 
@@ -250,6 +273,28 @@ type DriverAbilities struct {
 	// Exec marks the driver as being able to execute arbitrary commands
 	// such as health checks. Used by the ScriptExecutor interface.
 	Exec bool
+
+	// MountVolumes marks a driver with image isolation as being able to
+	// mount the volumes of the ExecContext into the task. Volumes are
+	// mounted into the task directory for the other drivers.
+	MountVolumes bool
+
+	// NetworkIsolation marks the driver as being able to start the task in
+	// the network namespace of the ExecContext.
+	NetworkIsolation bool
+}
+
+// DriverNetworkManager is implemented by drivers that can only start tasks in
+// network namespaces they created. When a task group network isn't in host
+// mode and one of its tasks uses such a driver, the driver creates the network
+// namespace of the allocation.
+type DriverNetworkManager interface {
+	// CreateNetwork creates the network namespace of the allocation, or
+	// returns it if it already exists.
+	CreateNetwork(allocID string) (*cstructs.NetworkIsolationSpec, error)
+
+	// DestroyNetwork destroys the network namespace of the allocation.
+	DestroyNetwork(allocID string, spec *cstructs.NetworkIsolationSpec) error
 }
 
 // LogEventFn is a callback which allows Drivers to emit task events.
@@ -266,6 +311,13 @@ type DriverContext struct {
 	node     *structs.Node
 
 	emitEvent LogEventFn
+
+	// pluginConfig is the configuration of the driver plugin given in the
+	// client's configuration. It is only set for external drivers.
+	pluginConfig map[string]interface{}
+
+	// driverPlugins manages the plugin processes of the external drivers
+	driverPlugins *DriverPluginManager
 }
 
 // NewEmptyDriverContext returns a DriverContext with all fields set to their
@@ -279,14 +331,53 @@ func NewEmptyDriverContext() *DriverContext {
 // private to the driver. If we want to change this later we can gorename all of
 // the fields in DriverContext.
 func NewDriverContext(taskName, allocID string, config *config.Config, node *structs.Node,
-	logger *log.Logger, eventEmitter LogEventFn) *DriverContext {
+	logger *log.Logger, eventEmitter LogEventFn, driverPlugins *DriverPluginManager) *DriverContext {
 	return &DriverContext{
-		taskName:  taskName,
-		allocID:   allocID,
-		config:    config,
-		node:      node,
-		logger:    logger,
-		emitEvent: eventEmitter,
+		taskName:      taskName,
+		allocID:       allocID,
+		config:        config,
+		node:          node,
+		logger:        logger,
+		emitEvent:     eventEmitter,
+		driverPlugins: driverPlugins,
+	}
+}
+
+// TaskName returns the name of the task the driver was created for. It is
+// empty when the driver is created for fingerprinting.
+func (d *DriverContext) TaskName() string {
+	return d.taskName
+}
+
+// AllocID returns the ID of the allocation the driver was created for.
+func (d *DriverContext) AllocID() string {
+	return d.allocID
+}
+
+// ClientConfig returns the configuration of the client.
+func (d *DriverContext) ClientConfig() *config.Config {
+	return d.config
+}
+
+// Node returns the node of the client.
+func (d *DriverContext) Node() *structs.Node {
+	return d.node
+}
+
+// Logger returns the logger of the driver.
+func (d *DriverContext) Logger() *log.Logger {
+	return d.logger
+}
+
+// PluginConfig returns the configuration of an external driver plugin.
+func (d *DriverContext) PluginConfig() map[string]interface{} {
+	return d.pluginConfig
+}
+
+// EmitEvent emits a task event if the driver was created for a task.
+func (d *DriverContext) EmitEvent(message string, args ...interface{}) {
+	if d.emitEvent != nil {
+		d.emitEvent(message, args...)
 	}
 }
 
@@ -323,6 +414,23 @@ type ScriptExecutor interface {
 	Exec(ctx context.Context, cmd string, args []string) ([]byte, int, error)
 }
 
+// StreamingExecutor is implemented by the handles of drivers that support
+// executing interactive commands in the task's context.
+type StreamingExecutor interface {
+	// ExecStreaming executes the command, streaming its input and output,
+	// and returns its exit code once it exits. The command is killed if the
+	// context is cancelled.
+	ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error)
+}
+
+// CpusetUpdater is implemented by the handles of drivers that can change the
+// cores a running task is confined to.
+type CpusetUpdater interface {
+	// UpdateCpuset confines the task to the cores of the cpuset, formatted
+	// as accepted by the cpuset cgroup.
+	UpdateCpuset(cpuset string) error
+}
+
 // ExecContext is a task's execution context
 type ExecContext struct {
 	// TaskDir contains information about the task directory structure.
@@ -330,6 +438,19 @@ type ExecContext struct {
 
 	// TaskEnv contains the task's environment variables.
 	TaskEnv *env.TaskEnv
+
+	// Mounts are the volumes drivers with image isolation mount into the
+	// task.
+	Mounts []*cstructs.MountConfig
+
+	// NetworkIsolation is the network namespace of the allocation the task
+	// is started in. It is nil in the host network mode.
+	NetworkIsolation *cstructs.NetworkIsolationSpec
+
+	// Cpuset is the cores shared by the tasks without dedicated cores that
+	// the task is confined to if it has none. The task may run on all cores
+	// if it is empty.
+	Cpuset string
 }
 
 // NewExecContext is used to create a new execution context
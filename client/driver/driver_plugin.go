@@ -0,0 +1,553 @@
+package driver
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log"
+	"net/rpc"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/logutils"
+	"github.com/hashicorp/nomad/client/config"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/client/fingerprint"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// DriverPluginProtocolVersion is the version of the protocol spoken
+	// between the client and external driver plugins. It is incremented
+	// whenever the protocol changes in an incompatible way so that plugins
+	// built against another version fail the handshake.
+	DriverPluginProtocolVersion = 1
+
+	// DriverPluginPrefix is the prefix of the names of driver plugin
+	// binaries. The rest of the name is the name of the driver.
+	DriverPluginPrefix = "nomad-driver-"
+)
+
+func init() {
+	gob.Register([]map[string]interface{}{})
+}
+
+// DriverHandshakeConfig is the handshake of external driver plugins.
+var DriverHandshakeConfig = plugin.HandshakeConfig{
+	ProtocolVersion:  DriverPluginProtocolVersion,
+	MagicCookieKey:   "NOMAD_DRIVER_PLUGIN_MAGIC_COOKIE",
+	MagicCookieValue: "84a13e6fa1db4446e4c41d93b87fd7cc824b90ca66c6b8a109300ff6a012ea58",
+}
+
+// ServeDriverPlugin serves the driver created by the factory as an external
+// driver plugin. It is called from the main function of plugin binaries and
+// returns once the client is done with the plugin.
+func ServeDriverPlugin(factory Factory) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: DriverHandshakeConfig,
+		Plugins: map[string]plugin.Plugin{
+			"driver": &DriverPlugin{Factory: factory},
+		},
+	})
+}
+
+// DriverPluginContext is sent with every call to a driver plugin so that it
+// can create its driver with the same context as the built-in drivers.
+type DriverPluginContext struct {
+	TaskName string
+	AllocID  string
+	Node     *structs.Node
+
+	// Options are the options of the client.
+	Options map[string]string
+
+	// PluginConfig is the configuration of the plugin.
+	PluginConfig map[string]interface{}
+
+	// AllocDir and StateDir are the directories of the client.
+	AllocDir string
+	StateDir string
+
+	// ClientMinPort and ClientMaxPort are the range of ports the plugin may
+	// use to communicate with its own plugins such as the executor.
+	ClientMinPort uint
+	ClientMaxPort uint
+
+	// LogLevel is the level of the plugin's logs and LogFile is the file to
+	// write them to. If no file is given, the logs are written to stderr and
+	// forwarded to the client.
+	LogLevel string
+	LogFile  string
+}
+
+// DriverPluginError carries an error across the plugin protocol, preserving
+// whether it is recoverable.
+type DriverPluginError struct {
+	Message     string
+	Recoverable bool
+}
+
+func newDriverPluginError(err error) *DriverPluginError {
+	if err == nil {
+		return nil
+	}
+	return &DriverPluginError{
+		Message:     err.Error(),
+		Recoverable: structs.IsRecoverable(err),
+	}
+}
+
+// Err returns the error, which is nil if the plugin didn't return one.
+func (e *DriverPluginError) Err() error {
+	if e == nil {
+		return nil
+	}
+	return structs.NewRecoverableError(fmt.Errorf("%s", e.Message), e.Recoverable)
+}
+
+type DriverPluginTaskArgs struct {
+	Ctx      *DriverPluginContext
+	ExecCtx  *ExecContext
+	Task     *structs.Task
+	HandleID string
+}
+
+type DriverPluginPrestartReply struct {
+	Response *PrestartResponse
+	Err      *DriverPluginError
+}
+
+type DriverPluginStartReply struct {
+	HandleID string
+	Network  *cstructs.DriverNetwork
+	Err      *DriverPluginError
+}
+
+type DriverPluginCleanupArgs struct {
+	Ctx       *DriverPluginContext
+	ExecCtx   *ExecContext
+	Resources *CreatedResources
+}
+
+type DriverPluginCleanupReply struct {
+	Err *DriverPluginError
+}
+
+type DriverPluginValidateArgs struct {
+	Ctx    *DriverPluginContext
+	Config map[string]interface{}
+}
+
+type DriverPluginPeriodicReply struct {
+	Periodic bool
+	Period   time.Duration
+}
+
+type DriverPluginHandleArgs struct {
+	HandleID string
+	Task     *structs.Task
+	Signal   int
+	Deadline time.Time
+	Cmd      string
+	Args     []string
+}
+
+type DriverPluginWaitReply struct {
+	ExitCode int
+	Signal   int
+	Err      string
+}
+
+type DriverPluginExecReply struct {
+	Output []byte
+	Code   int
+}
+
+// DriverRPC is the client side of the driver plugin protocol.
+type DriverRPC struct {
+	client *rpc.Client
+}
+
+func (d *DriverRPC) Fingerprint(ctx *DriverPluginContext) (*cstructs.FingerprintResponse, error) {
+	var resp cstructs.FingerprintResponse
+	err := d.client.Call("Plugin.Fingerprint", ctx, &resp)
+	return &resp, err
+}
+
+func (d *DriverRPC) Periodic(ctx *DriverPluginContext) (bool, time.Duration, error) {
+	var reply DriverPluginPeriodicReply
+	err := d.client.Call("Plugin.Periodic", ctx, &reply)
+	return reply.Periodic, reply.Period, err
+}
+
+func (d *DriverRPC) HealthCheck(ctx *DriverPluginContext) (*cstructs.HealthCheckResponse, error) {
+	var resp cstructs.HealthCheckResponse
+	err := d.client.Call("Plugin.HealthCheck", ctx, &resp)
+	return &resp, err
+}
+
+func (d *DriverRPC) GetHealthCheckInterval(ctx *DriverPluginContext) (*cstructs.HealthCheckIntervalResponse, error) {
+	var resp cstructs.HealthCheckIntervalResponse
+	err := d.client.Call("Plugin.GetHealthCheckInterval", ctx, &resp)
+	return &resp, err
+}
+
+func (d *DriverRPC) Prestart(args *DriverPluginTaskArgs) (*PrestartResponse, error) {
+	var reply DriverPluginPrestartReply
+	if err := d.client.Call("Plugin.Prestart", args, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Response, reply.Err.Err()
+}
+
+func (d *DriverRPC) Start(args *DriverPluginTaskArgs) (*DriverPluginStartReply, error) {
+	var reply DriverPluginStartReply
+	if err := d.client.Call("Plugin.Start", args, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, reply.Err.Err()
+}
+
+func (d *DriverRPC) Open(args *DriverPluginTaskArgs) (string, error) {
+	var reply DriverPluginStartReply
+	if err := d.client.Call("Plugin.Open", args, &reply); err != nil {
+		return "", err
+	}
+	return reply.HandleID, reply.Err.Err()
+}
+
+func (d *DriverRPC) Cleanup(args *DriverPluginCleanupArgs) error {
+	var reply DriverPluginCleanupReply
+	if err := d.client.Call("Plugin.Cleanup", args, &reply); err != nil {
+		return err
+	}
+	return reply.Err.Err()
+}
+
+func (d *DriverRPC) Validate(args *DriverPluginValidateArgs) error {
+	return d.client.Call("Plugin.Validate", args, new(interface{}))
+}
+
+func (d *DriverRPC) Abilities(ctx *DriverPluginContext) (DriverAbilities, error) {
+	var abilities DriverAbilities
+	err := d.client.Call("Plugin.Abilities", ctx, &abilities)
+	return abilities, err
+}
+
+func (d *DriverRPC) FSIsolation(ctx *DriverPluginContext) (cstructs.FSIsolation, error) {
+	var isolation cstructs.FSIsolation
+	err := d.client.Call("Plugin.FSIsolation", ctx, &isolation)
+	return isolation, err
+}
+
+func (d *DriverRPC) Wait(id string) (*dstructs.WaitResult, error) {
+	var reply DriverPluginWaitReply
+	if err := d.client.Call("Plugin.Wait", &DriverPluginHandleArgs{HandleID: id}, &reply); err != nil {
+		return nil, err
+	}
+	var err error
+	if reply.Err != "" {
+		err = fmt.Errorf("%s", reply.Err)
+	}
+	return dstructs.NewWaitResult(reply.ExitCode, reply.Signal, err), nil
+}
+
+func (d *DriverRPC) Update(id string, task *structs.Task) error {
+	return d.client.Call("Plugin.Update", &DriverPluginHandleArgs{HandleID: id, Task: task}, new(interface{}))
+}
+
+func (d *DriverRPC) Kill(id string) error {
+	return d.client.Call("Plugin.Kill", &DriverPluginHandleArgs{HandleID: id}, new(interface{}))
+}
+
+func (d *DriverRPC) Stats(id string) (*cstructs.TaskResourceUsage, error) {
+	var usage cstructs.TaskResourceUsage
+	err := d.client.Call("Plugin.Stats", &DriverPluginHandleArgs{HandleID: id}, &usage)
+	return &usage, err
+}
+
+func (d *DriverRPC) Signal(id string, sig int) error {
+	return d.client.Call("Plugin.Signal", &DriverPluginHandleArgs{HandleID: id, Signal: sig}, new(interface{}))
+}
+
+func (d *DriverRPC) Exec(id string, deadline time.Time, cmd string, args []string) ([]byte, int, error) {
+	req := &DriverPluginHandleArgs{
+		HandleID: id,
+		Deadline: deadline,
+		Cmd:      cmd,
+		Args:     args,
+	}
+	var reply DriverPluginExecReply
+	err := d.client.Call("Plugin.Exec", req, &reply)
+	return reply.Output, reply.Code, err
+}
+
+// DriverRPCServer is the plugin side of the driver plugin protocol. A new
+// driver is created for every call, like the client does for the built-in
+// drivers, while the handles of the started tasks are kept until they exit.
+type DriverRPCServer struct {
+	factory Factory
+
+	logOutput io.Writer
+	logLock   sync.Mutex
+
+	handles     map[string]DriverHandle
+	handlesLock sync.Mutex
+}
+
+// newDriver creates the driver with the context sent by the client.
+func (s *DriverRPCServer) newDriver(ctx *DriverPluginContext) Driver {
+	d, _ := s.newDriverWithConfig(ctx)
+	return d
+}
+
+// newDriverWithConfig creates the driver with the context sent by the client
+// and returns it along with the client configuration it was created with.
+func (s *DriverRPCServer) newDriverWithConfig(ctx *DriverPluginContext) (Driver, *config.Config) {
+	conf := config.DefaultConfig()
+	conf.Node = ctx.Node
+	conf.Options = ctx.Options
+	conf.AllocDir = ctx.AllocDir
+	conf.StateDir = ctx.StateDir
+	conf.ClientMinPort = ctx.ClientMinPort
+	conf.ClientMaxPort = ctx.ClientMaxPort
+	conf.LogLevel = ctx.LogLevel
+	conf.LogOutput = s.logWriter(ctx)
+
+	logger := log.New(conf.LogOutput, "", log.LstdFlags|log.Lmicroseconds)
+	emitter := func(m string, args ...interface{}) {
+		logger.Printf("[INFO] driver: task %q event: %s", ctx.TaskName, fmt.Sprintf(m, args...))
+	}
+	dctx := NewDriverContext(ctx.TaskName, ctx.AllocID, conf, ctx.Node, logger, emitter, nil)
+	dctx.pluginConfig = ctx.PluginConfig
+	return s.factory(dctx), conf
+}
+
+// logWriter returns the writer of the plugin's logs. The first log file
+// given is used for the lifetime of the plugin so that a task's plugin
+// keeps logging after the client restarts.
+func (s *DriverRPCServer) logWriter(ctx *DriverPluginContext) io.Writer {
+	s.logLock.Lock()
+	defer s.logLock.Unlock()
+
+	if s.logOutput == nil {
+		var w io.Writer = os.Stderr
+		if ctx.LogFile != "" {
+			f, err := os.OpenFile(ctx.LogFile, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+			if err == nil {
+				w = f
+			}
+		}
+		level := ctx.LogLevel
+		if level == "" {
+			level = "INFO"
+		}
+		s.logOutput = &logutils.LevelFilter{
+			Levels:   []logutils.LogLevel{"TRACE", "DEBUG", "INFO", "WARN", "ERR"},
+			MinLevel: logutils.LogLevel(strings.ToUpper(level)),
+			Writer:   w,
+		}
+	}
+	return s.logOutput
+}
+
+func (s *DriverRPCServer) handle(id string) (DriverHandle, error) {
+	s.handlesLock.Lock()
+	defer s.handlesLock.Unlock()
+	h, ok := s.handles[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown handle %q", id)
+	}
+	return h, nil
+}
+
+func (s *DriverRPCServer) addHandle(h DriverHandle) string {
+	s.handlesLock.Lock()
+	defer s.handlesLock.Unlock()
+	id := h.ID()
+	s.handles[id] = h
+	return id
+}
+
+func (s *DriverRPCServer) Fingerprint(ctx *DriverPluginContext, resp *cstructs.FingerprintResponse) error {
+	d, conf := s.newDriverWithConfig(ctx)
+	req := &cstructs.FingerprintRequest{Config: conf, Node: ctx.Node}
+	return d.Fingerprint(req, resp)
+}
+
+func (s *DriverRPCServer) Periodic(ctx *DriverPluginContext, reply *DriverPluginPeriodicReply) error {
+	reply.Periodic, reply.Period = s.newDriver(ctx).Periodic()
+	return nil
+}
+
+func (s *DriverRPCServer) HealthCheck(ctx *DriverPluginContext, resp *cstructs.HealthCheckResponse) error {
+	hc, ok := s.newDriver(ctx).(fingerprint.HealthCheck)
+	if !ok {
+		return fmt.Errorf("driver doesn't support health checks")
+	}
+	return hc.HealthCheck(&cstructs.HealthCheckRequest{}, resp)
+}
+
+func (s *DriverRPCServer) GetHealthCheckInterval(ctx *DriverPluginContext, resp *cstructs.HealthCheckIntervalResponse) error {
+	// Drivers that don't support health checks are never health checked
+	hc, ok := s.newDriver(ctx).(fingerprint.HealthCheck)
+	if !ok {
+		return nil
+	}
+	return hc.GetHealthCheckInterval(&cstructs.HealthCheckIntervalRequest{}, resp)
+}
+
+func (s *DriverRPCServer) Prestart(args *DriverPluginTaskArgs, reply *DriverPluginPrestartReply) error {
+	resp, err := s.newDriver(args.Ctx).Prestart(args.ExecCtx, args.Task)
+	reply.Response = resp
+	reply.Err = newDriverPluginError(err)
+	return nil
+}
+
+func (s *DriverRPCServer) Start(args *DriverPluginTaskArgs, reply *DriverPluginStartReply) error {
+	resp, err := s.newDriver(args.Ctx).Start(args.ExecCtx, args.Task)
+	if err != nil {
+		reply.Err = newDriverPluginError(err)
+		return nil
+	}
+	reply.HandleID = s.addHandle(resp.Handle)
+	reply.Network = resp.Network
+	return nil
+}
+
+func (s *DriverRPCServer) Open(args *DriverPluginTaskArgs, reply *DriverPluginStartReply) error {
+	// The plugin may have survived a restart of the client
+	if _, err := s.handle(args.HandleID); err == nil {
+		reply.HandleID = args.HandleID
+		return nil
+	}
+
+	h, err := s.newDriver(args.Ctx).Open(args.ExecCtx, args.HandleID)
+	if err != nil {
+		reply.Err = newDriverPluginError(err)
+		return nil
+	}
+	reply.HandleID = s.addHandle(h)
+	return nil
+}
+
+func (s *DriverRPCServer) Cleanup(args *DriverPluginCleanupArgs, reply *DriverPluginCleanupReply) error {
+	err := s.newDriver(args.Ctx).Cleanup(args.ExecCtx, args.Resources)
+	reply.Err = newDriverPluginError(err)
+	return nil
+}
+
+func (s *DriverRPCServer) Validate(args *DriverPluginValidateArgs, resp *interface{}) error {
+	return s.newDriver(args.Ctx).Validate(args.Config)
+}
+
+func (s *DriverRPCServer) Abilities(ctx *DriverPluginContext, abilities *DriverAbilities) error {
+	*abilities = s.newDriver(ctx).Abilities()
+	return nil
+}
+
+func (s *DriverRPCServer) FSIsolation(ctx *DriverPluginContext, isolation *cstructs.FSIsolation) error {
+	*isolation = s.newDriver(ctx).FSIsolation()
+	return nil
+}
+
+func (s *DriverRPCServer) Wait(args *DriverPluginHandleArgs, reply *DriverPluginWaitReply) error {
+	h, err := s.handle(args.HandleID)
+	if err != nil {
+		return err
+	}
+
+	res, ok := <-h.WaitCh()
+	if !ok || res == nil {
+		reply.ExitCode = -1
+		reply.Err = "task handle closed"
+		return nil
+	}
+	reply.ExitCode = res.ExitCode
+	reply.Signal = res.Signal
+	if res.Err != nil {
+		reply.Err = res.Err.Error()
+	}
+	return nil
+}
+
+func (s *DriverRPCServer) Update(args *DriverPluginHandleArgs, resp *interface{}) error {
+	h, err := s.handle(args.HandleID)
+	if err != nil {
+		return err
+	}
+	return h.Update(args.Task)
+}
+
+func (s *DriverRPCServer) Kill(args *DriverPluginHandleArgs, resp *interface{}) error {
+	h, err := s.handle(args.HandleID)
+	if err != nil {
+		return err
+	}
+	return h.Kill()
+}
+
+func (s *DriverRPCServer) Stats(args *DriverPluginHandleArgs, usage *cstructs.TaskResourceUsage) error {
+	h, err := s.handle(args.HandleID)
+	if err != nil {
+		return err
+	}
+	ru, err := h.Stats()
+	if ru != nil {
+		*usage = *ru
+	}
+	return err
+}
+
+func (s *DriverRPCServer) Signal(args *DriverPluginHandleArgs, resp *interface{}) error {
+	h, err := s.handle(args.HandleID)
+	if err != nil {
+		return err
+	}
+	return h.Signal(syscall.Signal(args.Signal))
+}
+
+func (s *DriverRPCServer) Exec(args *DriverPluginHandleArgs, reply *DriverPluginExecReply) error {
+	h, err := s.handle(args.HandleID)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if !args.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, args.Deadline)
+		defer cancel()
+	}
+	out, code, err := h.Exec(ctx, args.Cmd, args.Args)
+	reply.Output = out
+	reply.Code = code
+	return err
+}
+
+// DriverPlugin is the go-plugin plugin of external drivers.
+type DriverPlugin struct {
+	// Factory creates the driver served by the plugin. It is only set on the
+	// plugin side.
+	Factory Factory
+}
+
+func (p *DriverPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	if p.Factory == nil {
+		return nil, fmt.Errorf("driver plugin has no driver to serve")
+	}
+	return &DriverRPCServer{
+		factory: p.Factory,
+		handles: make(map[string]DriverHandle),
+	}, nil
+}
+
+func (p *DriverPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &DriverRPC{client: c}, nil
+}
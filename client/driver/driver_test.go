@@ -145,7 +145,7 @@ func testDriverContexts(t *testing.T, task *structs.Task) *testContext {
 	emitter := func(m string, args ...interface{}) {
 		logger.Printf("[EVENT] "+m, args...)
 	}
-	driverCtx := NewDriverContext(task.Name, alloc.ID, cfg, cfg.Node, logger, emitter)
+	driverCtx := NewDriverContext(task.Name, alloc.ID, cfg, cfg.Node, logger, emitter, nil)
 
 	return &testContext{allocDir, driverCtx, execCtx, eb}
 }
@@ -235,6 +235,8 @@ func setupTaskEnv(t *testing.T, driver string) (*allocdir.TaskDir, map[string]st
 		"NOMAD_TASK_NAME":               task.Name,
 		"NOMAD_GROUP_NAME":              alloc.TaskGroup,
 		"NOMAD_JOB_NAME":                alloc.Job.Name,
+		"NOMAD_JOB_ID":                  alloc.JobID,
+		"NOMAD_NAMESPACE":               alloc.Namespace,
 		"NOMAD_DC":                      "dc1",
 		"NOMAD_REGION":                  "global",
 	}
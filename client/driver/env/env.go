@@ -32,9 +32,17 @@ const (
 	// MemLimit is the environment variable with the tasks memory limit in MBs.
 	MemLimit = "NOMAD_MEMORY_LIMIT"
 
+	// MemMaxLimit is the environment variable with the tasks maximum memory
+	// limit in MBs, when it may use more memory than it reserves.
+	MemMaxLimit = "NOMAD_MEMORY_MAX_LIMIT"
+
 	// CpuLimit is the environment variable with the tasks CPU limit in MHz.
 	CpuLimit = "NOMAD_CPU_LIMIT"
 
+	// CpuCores is the environment variable with the comma separated IDs of
+	// the cores dedicated to the task.
+	CpuCores = "NOMAD_CPU_CORES"
+
 	// AllocID is the environment variable for passing the allocation ID.
 	AllocID = "NOMAD_ALLOC_ID"
 
@@ -50,6 +58,13 @@ const (
 	// JobName is the environment variable for passing the job name.
 	JobName = "NOMAD_JOB_NAME"
 
+	// JobID is the environment variable for passing the job ID.
+	JobID = "NOMAD_JOB_ID"
+
+	// Namespace is the environment variable for passing the namespace of the
+	// job.
+	Namespace = "NOMAD_NAMESPACE"
+
 	// AllocIndex is the environment variable for passing the allocation index.
 	AllocIndex = "NOMAD_ALLOC_INDEX"
 
@@ -79,6 +94,11 @@ const (
 	// map is specified.
 	HostPortPrefix = "NOMAD_HOST_PORT_"
 
+	// DevicePrefix is the prefix for passing the IDs of the device instances
+	// assigned to the task, separated by commas.
+	// E.g $NOMAD_DEVICE_046d_usb_c52b=1-1.2,1-1.3
+	DevicePrefix = "NOMAD_DEVICE_"
+
 	// MetaPrefix is the prefix for passing task meta data.
 	MetaPrefix = "NOMAD_META_"
 
@@ -208,7 +228,9 @@ type Builder struct {
 	secretsDir string
 
 	cpuLimit         int
+	cpuCores         string
 	memLimit         int
+	memMaxLimit      int
 	taskName         string
 	allocIndex       int
 	datacenter       string
@@ -219,10 +241,15 @@ type Builder struct {
 	vaultToken       string
 	injectVaultToken bool
 	jobName          string
+	jobID            string
+	namespace        string
 
 	// otherPorts for tasks in the same alloc
 	otherPorts map[string]string
 
+	// devices maps the device env vars to the assigned instance IDs
+	devices map[string]string
+
 	// driverNetwork is the network defined by the driver (or nil if none
 	// was defined).
 	driverNetwork *cstructs.DriverNetwork
@@ -274,9 +301,20 @@ func (b *Builder) Build() *TaskEnv {
 	if b.memLimit != 0 {
 		envMap[MemLimit] = strconv.Itoa(b.memLimit)
 	}
+	if b.memMaxLimit != 0 {
+		envMap[MemMaxLimit] = strconv.Itoa(b.memMaxLimit)
+	}
 	if b.cpuLimit != 0 {
 		envMap[CpuLimit] = strconv.Itoa(b.cpuLimit)
 	}
+	if b.cpuCores != "" {
+		envMap[CpuCores] = b.cpuCores
+	}
+
+	// Add the assigned devices
+	for k, v := range b.devices {
+		envMap[k] = v
+	}
 
 	// Add the task metadata
 	if b.allocId != "" {
@@ -297,6 +335,12 @@ func (b *Builder) Build() *TaskEnv {
 	if b.jobName != "" {
 		envMap[JobName] = b.jobName
 	}
+	if b.jobID != "" {
+		envMap[JobID] = b.jobID
+	}
+	if b.namespace != "" {
+		envMap[Namespace] = b.namespace
+	}
 	if b.datacenter != "" {
 		envMap[Datacenter] = b.datacenter
 	}
@@ -370,18 +414,27 @@ func (b *Builder) setTask(task *structs.Task) *Builder {
 	for k, v := range task.Env {
 		b.envvars[k] = v
 	}
+	b.devices = make(map[string]string)
 	if task.Resources == nil {
 		b.memLimit = 0
+		b.memMaxLimit = 0
 		b.cpuLimit = 0
+		b.cpuCores = ""
 		b.networks = []*structs.NetworkResource{}
 	} else {
 		b.memLimit = task.Resources.MemoryMB
+		b.memMaxLimit = task.Resources.MemoryMaxMB
 		b.cpuLimit = task.Resources.CPU
+		b.cpuCores = structs.FormatCpuset(task.Resources.CoreIDs)
 		// Copy networks to prevent sharing
 		b.networks = make([]*structs.NetworkResource, len(task.Resources.Networks))
 		for i, n := range task.Resources.Networks {
 			b.networks[i] = n.Copy()
 		}
+		for _, d := range task.Resources.Devices {
+			key := helper.CleanEnvVar(DevicePrefix+strings.Replace(d.ID(), "/", "_", -1), '_')
+			b.devices[key] = strings.Join(d.InstanceIDs(), ",")
+		}
 	}
 	return b
 }
@@ -394,6 +447,8 @@ func (b *Builder) setAlloc(alloc *structs.Allocation) *Builder {
 	b.groupName = alloc.TaskGroup
 	b.allocIndex = int(alloc.Index())
 	b.jobName = alloc.Job.Name
+	b.jobID = alloc.JobID
+	b.namespace = alloc.Namespace
 
 	// Set meta
 	combined := alloc.Job.CombinedTaskMeta(alloc.TaskGroup, b.taskName)
@@ -418,6 +473,13 @@ func (b *Builder) setAlloc(alloc *structs.Allocation) *Builder {
 			}
 		}
 	}
+
+	// Add the ports of the group network shared by the tasks
+	if alloc.SharedResources != nil {
+		for _, n := range alloc.SharedResources.Networks {
+			b.networks = append(b.networks, n.Copy())
+		}
+	}
 	return b
 }
 
@@ -499,9 +561,12 @@ func buildPortEnv(envMap map[string]string, p structs.Port, ip string, driverNet
 	envMap[HostPortPrefix+p.Label] = portStr
 	envMap[AddrPrefix+p.Label] = net.JoinHostPort(ip, portStr)
 
-	// Set Port to task's value if there's a port map
+	// Set Port to task's value if there's a port map, or to the port it is
+	// mapped to in the network namespace of the allocation
 	if driverNet != nil && driverNet.PortMap[p.Label] != 0 {
 		envMap[PortPrefix+p.Label] = strconv.Itoa(driverNet.PortMap[p.Label])
+	} else if p.To != 0 {
+		envMap[PortPrefix+p.Label] = strconv.Itoa(p.To)
 	} else {
 		// Default to host's
 		envMap[PortPrefix+p.Label] = portStr
@@ -203,6 +203,8 @@ func TestEnvironment_AsList(t *testing.T) {
 		"NOMAD_META_foo=bar",
 		"NOMAD_META_owner=armon",
 		"NOMAD_JOB_NAME=my-job",
+		fmt.Sprintf("NOMAD_JOB_ID=%s", a.JobID),
+		"NOMAD_NAMESPACE=default",
 		fmt.Sprintf("NOMAD_ALLOC_ID=%s", a.ID),
 		"NOMAD_ALLOC_INDEX=0",
 	}
@@ -267,6 +269,88 @@ func TestEnvironment_Envvars(t *testing.T) {
 	}
 }
 
+func TestEnvironment_MemoryMaxLimit(t *testing.T) {
+	n := mock.Node()
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Resources.MemoryMB = 256
+	task.Resources.MemoryMaxMB = 1024
+
+	act := NewBuilder(n, a, task, "global").Build().All()
+	if v := act[MemLimit]; v != "256" {
+		t.Fatalf("expected %q but found %q", "256", v)
+	}
+	if v := act[MemMaxLimit]; v != "1024" {
+		t.Fatalf("expected %q but found %q", "1024", v)
+	}
+}
+
+func TestEnvironment_CpuCores(t *testing.T) {
+	n := mock.Node()
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Resources.CoreIDs = []uint16{2, 3}
+
+	act := NewBuilder(n, a, task, "global").Build().All()
+	if v := act[CpuCores]; v != "2,3" {
+		t.Fatalf("expected %q but found %q", "2,3", v)
+	}
+}
+
+func TestEnvironment_Devices(t *testing.T) {
+	n := mock.Node()
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Resources.Devices = []*structs.DeviceResource{
+		{
+			Vendor: "046d",
+			Type:   "usb",
+			Name:   "c52b",
+			Count:  2,
+			Instances: []*structs.DeviceInstance{
+				{ID: "1-1.2", Healthy: true},
+				{ID: "1-1.3", Healthy: true},
+			},
+		},
+	}
+
+	act := NewBuilder(n, a, task, "global").Build().All()
+	exp := "1-1.2,1-1.3"
+	if v := act["NOMAD_DEVICE_046d_usb_c52b"]; v != exp {
+		t.Fatalf("expected %q but found %q", exp, v)
+	}
+}
+
+func TestEnvironment_GroupNetwork(t *testing.T) {
+	n := mock.Node()
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Resources.Networks = nil
+	a.SharedResources.Networks = []*structs.NetworkResource{
+		{
+			Mode:          structs.NetworkModeBridge,
+			IP:            "192.168.0.100",
+			ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+			DynamicPorts:  []structs.Port{{Label: "http", Value: 23456, To: 8080}},
+		},
+	}
+
+	act := NewBuilder(n, a, task, "global").Build().All()
+	exp := map[string]string{
+		"NOMAD_IP_http":         "192.168.0.100",
+		"NOMAD_HOST_PORT_http":  "23456",
+		"NOMAD_PORT_http":       "8080",
+		"NOMAD_ADDR_http":       "192.168.0.100:23456",
+		"NOMAD_HOST_PORT_admin": "9000",
+		"NOMAD_PORT_admin":      "9000",
+	}
+	for k, v := range exp {
+		if act[k] != v {
+			t.Fatalf("expected %s=%q but found %q", k, v, act[k])
+		}
+	}
+}
+
 func TestEnvironment_Interpolate(t *testing.T) {
 	n := mock.Node()
 	n.Attributes["arch"] = "x86"
@@ -79,8 +79,9 @@ func (d *ExecDriver) Validate(config map[string]interface{}) error {
 
 func (d *ExecDriver) Abilities() DriverAbilities {
 	return DriverAbilities{
-		SendSignals: true,
-		Exec:        true,
+		SendSignals:      true,
+		Exec:             true,
+		NetworkIsolation: true,
 	}
 }
 
@@ -123,6 +124,7 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		LogDir:  ctx.TaskDir.LogDir,
 		TaskDir: ctx.TaskDir.Dir,
 		Task:    task,
+		Cpuset:  ctx.Cpuset,
 	}
 	if err := exec.SetContext(executorCtx); err != nil {
 		pluginClient.Kill()
@@ -135,12 +137,13 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 	}
 
 	execCmd := &executor.ExecCommand{
-		Cmd:            command,
-		Args:           driverConfig.Args,
-		TaskKillSignal: taskKillSignal,
-		FSIsolation:    true,
-		ResourceLimits: true,
-		User:           getExecutorUser(task),
+		Cmd:              command,
+		Args:             driverConfig.Args,
+		TaskKillSignal:   taskKillSignal,
+		FSIsolation:      true,
+		ResourceLimits:   true,
+		User:             getExecutorUser(task),
+		NetworkIsolation: ctx.NetworkIsolation,
 	}
 
 	ps, err := exec.LaunchCmd(execCmd)
@@ -266,6 +269,14 @@ func (h *execHandle) Exec(ctx context.Context, cmd string, args []string) ([]byt
 	return h.executor.Exec(deadline, cmd, args)
 }
 
+func (h *execHandle) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	return h.executor.ExecStreaming(ctx, opts)
+}
+
+func (h *execHandle) UpdateCpuset(cpuset string) error {
+	return h.executor.UpdateCpuset(cpuset)
+}
+
 func (h *execHandle) Signal(s os.Signal) error {
 	return h.executor.Signal(s)
 }
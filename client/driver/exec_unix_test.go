@@ -3,6 +3,8 @@
 package driver
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -12,8 +14,10 @@ import (
 	"testing"
 	"time"
 
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
 
 	ctestutils "github.com/hashicorp/nomad/client/testutil"
 )
@@ -168,3 +172,76 @@ done
 		t.Fatalf("Command outputted %v; want %v", act, exp)
 	}
 }
+
+func TestExecDriver_ExecStreaming(t *testing.T) {
+	if !testutil.IsTravis() {
+		t.Parallel()
+	}
+	ctestutils.ExecCompatible(t)
+	require := require.New(t)
+	task := &structs.Task{
+		Name:   "sleep",
+		Driver: "exec",
+		Config: map[string]interface{}{
+			"command": "/bin/sleep",
+			"args":    []string{"9000"},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: basicResources,
+	}
+
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewExecDriver(ctx.DriverCtx)
+
+	_, err := d.Prestart(ctx.ExecCtx, task)
+	require.NoError(err)
+	resp, err := d.Start(ctx.ExecCtx, task)
+	require.NoError(err)
+	defer resp.Handle.Kill()
+
+	executor, ok := resp.Handle.(StreamingExecutor)
+	require.True(ok)
+
+	// Without a terminal the output streams are separate
+	var stdout, stderr bytes.Buffer
+	code, err := executor.ExecStreaming(context.Background(), &dstructs.ExecStreamingOptions{
+		Command: []string{"/bin/sh", "-c", "cat; echo failed >&2; exit 3"},
+		Stdin:   strings.NewReader("hello\n"),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+	require.NoError(err)
+	require.Equal(3, code)
+	require.Equal("hello\n", stdout.String())
+	require.Equal("failed\n", stderr.String())
+
+	// The command runs in the chroot and cgroup of the task
+	stdout.Reset()
+	code, err = executor.ExecStreaming(context.Background(), &dstructs.ExecStreamingOptions{
+		Command: []string{"/bin/sh", "-c", "ls -d /alloc /local && cat /proc/self/cgroup"},
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+	require.NoError(err)
+	require.Zero(code)
+	require.Contains(stdout.String(), "/alloc\n/local\n")
+	require.Contains(stdout.String(), ":/nomad/")
+
+	// With a terminal the command runs with the requested size
+	stdout.Reset()
+	resizeCh := make(chan dstructs.TerminalSize, 1)
+	resizeCh <- dstructs.TerminalSize{Height: 40, Width: 120}
+	code, err = executor.ExecStreaming(context.Background(), &dstructs.ExecStreamingOptions{
+		Command:  []string{"/bin/sh", "-c", "sleep 1; stty size"},
+		Tty:      true,
+		Stdout:   &stdout,
+		ResizeCh: resizeCh,
+	})
+	require.NoError(err)
+	require.Zero(code)
+	require.Contains(stdout.String(), "40 120")
+}
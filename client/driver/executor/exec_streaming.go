@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"syscall"
+
+	"github.com/hashicorp/nomad/client/driver/env"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+// ExecStreaming executes a command in the directory with the task's
+// environment, streaming its input and output. It returns the exit code of
+// the command once it exits.
+func ExecStreaming(ctx context.Context, dir string, env *env.TaskEnv, attrs *syscall.SysProcAttr,
+	opts *dstructs.ExecStreamingOptions) (int, error) {
+	return execStreaming(ctx, dir, env, attrs, opts, nil)
+}
+
+// execStreaming is like ExecStreaming but calls started with the pid of the
+// command once it is started, if started is not nil. The command is killed
+// if started returns an error.
+func execStreaming(ctx context.Context, dir string, env *env.TaskEnv, attrs *syscall.SysProcAttr,
+	opts *dstructs.ExecStreamingOptions, started func(pid int) error) (int, error) {
+	if len(opts.Command) == 0 {
+		return 0, fmt.Errorf("command is required")
+	}
+
+	name := env.ReplaceEnv(opts.Command[0])
+	cmd := exec.CommandContext(ctx, name, env.ParseAndReplace(opts.Command[1:])...)
+	cmd.SysProcAttr = attrs
+	cmd.Dir = dir
+	cmd.Env = env.List()
+
+	if opts.Tty {
+		return execStreamingTty(cmd, opts, started)
+	}
+
+	// Copy the input through a pipe since the command may exit before the
+	// input is closed.
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	if err := execStarted(cmd, started); err != nil {
+		return 0, err
+	}
+	go func() {
+		if opts.Stdin != nil {
+			io.Copy(stdin, opts.Stdin)
+		}
+		stdin.Close()
+	}()
+
+	return execExitCode(cmd.Wait())
+}
+
+// execStarted calls started with the pid of the started command. The command
+// is killed if started fails.
+func execStarted(cmd *exec.Cmd, started func(pid int) error) error {
+	if started == nil {
+		return nil
+	}
+	if err := started(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// execExitCode returns the exit code of an exited command from the error
+// returned waiting for it. Commands killed by a signal exit with 128 plus the
+// signal number, like in shells.
+func execExitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, err
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return 0, err
+	}
+	if status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return status.ExitStatus(), nil
+}
@@ -0,0 +1,63 @@
+// +build !windows
+
+package executor
+
+import (
+	"io"
+	"os/exec"
+	"time"
+
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/kr/pty"
+)
+
+// ttyDrainTimeout is how long to wait for the remaining output of a terminal
+// after the command exits. Processes left running in the background may keep
+// the terminal open.
+const ttyDrainTimeout = 1 * time.Second
+
+// execStreamingTty runs the command in a pseudo terminal.
+func execStreamingTty(cmd *exec.Cmd, opts *dstructs.ExecStreamingOptions, started func(pid int) error) (int, error) {
+	tty, err := pty.Start(cmd)
+	if err != nil {
+		return 0, err
+	}
+	defer tty.Close()
+
+	if err := execStarted(cmd, started); err != nil {
+		return 0, err
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go func() {
+		for {
+			select {
+			case size, ok := <-opts.ResizeCh:
+				if !ok {
+					return
+				}
+				pty.Setsize(tty, &pty.Winsize{Rows: size.Height, Cols: size.Width})
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	if opts.Stdin != nil {
+		go io.Copy(tty, opts.Stdin)
+	}
+	outCh := make(chan struct{})
+	go func() {
+		io.Copy(opts.Stdout, tty)
+		close(outCh)
+	}()
+
+	code, err := execExitCode(cmd.Wait())
+
+	select {
+	case <-outCh:
+	case <-time.After(ttyDrainTimeout):
+	}
+	return code, err
+}
@@ -0,0 +1,13 @@
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+// execStreamingTty is not supported on Windows which has no pseudo terminals.
+func execStreamingTty(cmd *exec.Cmd, opts *dstructs.ExecStreamingOptions, started func(pid int) error) (int, error) {
+	return 0, fmt.Errorf("tty is not supported on Windows")
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/driver/env"
 	"github.com/hashicorp/nomad/client/driver/logging"
+	"github.com/hashicorp/nomad/client/lib/nsutil"
 	"github.com/hashicorp/nomad/client/stats"
 	shelpers "github.com/hashicorp/nomad/helper/stats"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -60,6 +61,8 @@ type Executor interface {
 	Stats() (*cstructs.TaskResourceUsage, error)
 	Signal(s os.Signal) error
 	Exec(deadline time.Time, cmd string, args []string) ([]byte, int, error)
+	ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error)
+	UpdateCpuset(cpuset string) error
 }
 
 // ExecutorContext holds context to configure the command user
@@ -87,6 +90,10 @@ type ExecutorContext struct {
 	// PortLowerBound is the lower bound of the ports that we can use to start
 	// the syslog server
 	PortLowerBound uint
+
+	// Cpuset is the cores the task is confined to if it has no dedicated
+	// cores. The task may run on all cores if it is empty.
+	Cpuset string
 }
 
 // ExecCommand holds the user command, args, and other isolation related
@@ -110,6 +117,10 @@ type ExecCommand struct {
 	// ResourceLimits determines whether resource limits are enforced by the
 	// executor.
 	ResourceLimits bool
+
+	// NetworkIsolation is the network namespace the command is started in,
+	// if any.
+	NetworkIsolation *cstructs.NetworkIsolationSpec
 }
 
 // ProcessState holds information about the state of a user process.
@@ -163,6 +174,8 @@ type UniversalExecutor struct {
 	lro         *logging.FileRotator
 	rotatorLock sync.Mutex
 
+	shipper *logging.LogShipper
+
 	syslogServer *logging.SyslogServer
 	syslogChan   chan *logging.SyslogMessage
 
@@ -274,7 +287,7 @@ func (e *UniversalExecutor) LaunchCmd(command *ExecCommand) (*ProcessState, erro
 	e.cmd.Env = e.ctx.TaskEnv.List()
 
 	// Start the process
-	if err := e.cmd.Start(); err != nil {
+	if err := e.withNetworkIsolation(e.cmd.Start); err != nil {
 		return nil, fmt.Errorf("failed to start command path=%q --- args=%q: %v", path, e.cmd.Args, err)
 	}
 	go e.collectPids()
@@ -287,7 +300,36 @@ func (e *UniversalExecutor) LaunchCmd(command *ExecCommand) (*ProcessState, erro
 func (e *UniversalExecutor) Exec(deadline time.Time, name string, args []string) ([]byte, int, error) {
 	ctx, cancel := context.WithDeadline(context.Background(), deadline)
 	defer cancel()
-	return ExecScript(ctx, e.cmd.Dir, e.ctx.TaskEnv, e.cmd.SysProcAttr, name, args)
+
+	var out []byte
+	var code int
+	err := e.withNetworkIsolation(func() error {
+		var err error
+		out, code, err = ExecScript(ctx, e.cmd.Dir, e.ctx.TaskEnv, e.cmd.SysProcAttr, name, args)
+		return err
+	})
+	return out, code, err
+}
+
+// ExecStreaming executes a command interactively inside the task's chroot,
+// network namespace and resource limits, and returns its exit code.
+func (e *UniversalExecutor) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	var code int
+	err := e.withNetworkIsolation(func() error {
+		var err error
+		code, err = execStreaming(ctx, e.cmd.Dir, e.ctx.TaskEnv, e.cmd.SysProcAttr, opts, e.joinCgroup)
+		return err
+	})
+	return code, err
+}
+
+// withNetworkIsolation calls f in the network namespace of the command, if
+// any, so the processes it starts are created in the namespace.
+func (e *UniversalExecutor) withNetworkIsolation(f func() error) error {
+	if e.command == nil || e.command.NetworkIsolation == nil {
+		return f()
+	}
+	return nsutil.WithNS(e.command.NetworkIsolation.Path, f)
 }
 
 // ExecScript executes cmd with args and returns the output, exit code, and
@@ -340,6 +382,7 @@ func (e *UniversalExecutor) configureLoggers() error {
 		if err != nil {
 			return fmt.Errorf("error creating new stdout log file for %q: %v", e.ctx.Task.Name, err)
 		}
+		lro.SetLogConfig(e.ctx.Task.LogConfig)
 		e.lro = lro
 	}
 
@@ -349,8 +392,21 @@ func (e *UniversalExecutor) configureLoggers() error {
 		if err != nil {
 			return fmt.Errorf("error creating new stderr log file for %q: %v", e.ctx.Task.Name, err)
 		}
+		lre.SetLogConfig(e.ctx.Task.LogConfig)
 		e.lre = lre
 	}
+
+	// Ship what is written to the log files if the task requests it
+	if e.shipper == nil && len(e.ctx.Task.LogConfig.Shippers) != 0 {
+		shipper, err := logging.NewLogShipper(e.ctx.Task.LogConfig, logging.NewLogMeta(e.ctx.TaskEnv),
+			e.ctx.LogDir, e.logger)
+		if err != nil {
+			return fmt.Errorf("error creating log shipper for %q: %v", e.ctx.Task.Name, err)
+		}
+		e.shipper = shipper
+		e.lro.SetShipper(shipper.Writer("stdout"))
+		e.lre.SetShipper(shipper.Writer("stderr"))
+	}
 	return nil
 }
 
@@ -367,14 +423,12 @@ func (e *UniversalExecutor) UpdateLogConfig(logConfig *structs.LogConfig) error
 	if e.lro == nil {
 		return fmt.Errorf("log rotator for stdout doesn't exist")
 	}
-	e.lro.MaxFiles = logConfig.MaxFiles
-	e.lro.FileSize = int64(logConfig.MaxFileSizeMB * 1024 * 1024)
+	e.lro.SetLogConfig(logConfig)
 
 	if e.lre == nil {
 		return fmt.Errorf("log rotator for stderr doesn't exist")
 	}
-	e.lre.MaxFiles = logConfig.MaxFiles
-	e.lre.FileSize = int64(logConfig.MaxFileSizeMB * 1024 * 1024)
+	e.lre.SetLogConfig(logConfig)
 	return nil
 }
 
@@ -384,11 +438,8 @@ func (e *UniversalExecutor) UpdateTask(task *structs.Task) error {
 	// Updating Log Config
 	e.rotatorLock.Lock()
 	if e.lro != nil && e.lre != nil {
-		fileSize := int64(task.LogConfig.MaxFileSizeMB * 1024 * 1024)
-		e.lro.MaxFiles = task.LogConfig.MaxFiles
-		e.lro.FileSize = fileSize
-		e.lre.MaxFiles = task.LogConfig.MaxFiles
-		e.lre.FileSize = fileSize
+		e.lro.SetLogConfig(task.LogConfig)
+		e.lre.SetLogConfig(task.LogConfig)
 	}
 	e.rotatorLock.Unlock()
 	return nil
@@ -459,6 +510,10 @@ func (e *UniversalExecutor) Exit() error {
 		e.lro.Close()
 	}
 
+	if e.shipper != nil {
+		e.shipper.Close()
+	}
+
 	// If the executor did not launch a process, return.
 	if e.command == nil {
 		return nil
@@ -25,10 +25,18 @@ func (e *UniversalExecutor) applyLimits(pid int) error {
 	return nil
 }
 
+func (e *UniversalExecutor) joinCgroup(pid int) error {
+	return nil
+}
+
 func (e *UniversalExecutor) configureIsolation() error {
 	return nil
 }
 
+func (e *UniversalExecutor) UpdateCpuset(cpuset string) error {
+	return nil
+}
+
 func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
 	pidStats, err := e.pidStats()
 	if err != nil {
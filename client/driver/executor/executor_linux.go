@@ -68,6 +68,36 @@ func (e *UniversalExecutor) applyLimits(pid int) error {
 	return nil
 }
 
+// joinCgroup puts a process in the cgroup of the task, which must already be
+// configured by applyLimits.
+func (e *UniversalExecutor) joinCgroup(pid int) error {
+	if e.command == nil || !e.command.ResourceLimits {
+		return nil
+	}
+
+	manager := getCgroupManager(e.resConCtx.groups, nil)
+	if err := manager.Apply(pid); err != nil {
+		e.logger.Printf("[ERR] executor: error applying pid to cgroup: %v", err)
+		return err
+	}
+	return nil
+}
+
+// UpdateCpuset confines the task to the cores of the cpuset. It is ignored if
+// the task has dedicated cores or runs without resource limits.
+func (e *UniversalExecutor) UpdateCpuset(cpuset string) error {
+	if e.command == nil || !e.command.ResourceLimits || len(e.ctx.Task.Resources.CoreIDs) > 0 {
+		return nil
+	}
+
+	e.resConCtx.groups.Resources.CpusetCpus = cpuset
+	manager := getCgroupManager(e.resConCtx.groups, e.resConCtx.cgPaths)
+	if err := manager.Set(&cgroupConfig.Config{Cgroups: e.resConCtx.groups}); err != nil {
+		return fmt.Errorf("failed to update cpuset: %v", err)
+	}
+	return nil
+}
+
 // configureCgroups converts a Nomad Resources specification into the equivalent
 // cgroup configuration. It returns an error if the resources are invalid.
 func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error {
@@ -82,6 +112,14 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	if resources.MemoryMB > 0 {
 		// Total amount of memory allowed to consume
 		e.resConCtx.groups.Resources.Memory = int64(resources.MemoryMB * 1024 * 1024)
+
+		// If the task may use more memory than it reserves, the reservation
+		// becomes the soft limit
+		if resources.MemoryMaxMB > resources.MemoryMB {
+			e.resConCtx.groups.Resources.Memory = int64(resources.MemoryMaxMB * 1024 * 1024)
+			e.resConCtx.groups.Resources.MemoryReservation = int64(resources.MemoryMB * 1024 * 1024)
+		}
+
 		// Disable swap to avoid issues on the machine
 		var memSwappiness int64 = 0
 		e.resConCtx.groups.Resources.MemorySwappiness = &memSwappiness
@@ -94,6 +132,13 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	// Set the relative CPU shares for this cgroup.
 	e.resConCtx.groups.Resources.CpuShares = int64(resources.CPU)
 
+	// Pin the task to its dedicated cores or confine it to the shared ones
+	if len(resources.CoreIDs) > 0 {
+		e.resConCtx.groups.Resources.CpusetCpus = structs.FormatCpuset(resources.CoreIDs)
+	} else if e.ctx != nil {
+		e.resConCtx.groups.Resources.CpusetCpus = e.ctx.Cpuset
+	}
+
 	if resources.IOPS != 0 {
 		// Validate it is in an acceptable range.
 		if resources.IOPS < 10 || resources.IOPS > 1000 {
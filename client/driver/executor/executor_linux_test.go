@@ -190,3 +190,57 @@ func TestExecutor_ClientCleanup(t *testing.T) {
 		t.Fatalf("Expected size: %v, actual: %v", finfo.Size(), finfo1.Size())
 	}
 }
+
+func TestExecutor_ConfigureCgroups_MemoryMax(t *testing.T) {
+	e := NewExecutor(testLogger()).(*UniversalExecutor)
+	resources := mock.Alloc().Job.TaskGroups[0].Tasks[0].Resources
+	resources.MemoryMB = 256
+
+	// Without a larger maximum the reservation is the hard limit
+	if err := e.configureCgroups(resources); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if mem := e.resConCtx.groups.Resources.Memory; mem != 256*1024*1024 {
+		t.Fatalf("bad memory limit: %d", mem)
+	}
+	if res := e.resConCtx.groups.Resources.MemoryReservation; res != 0 {
+		t.Fatalf("bad memory reservation: %d", res)
+	}
+
+	resources.MemoryMaxMB = 1024
+	if err := e.configureCgroups(resources); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if mem := e.resConCtx.groups.Resources.Memory; mem != 1024*1024*1024 {
+		t.Fatalf("bad memory limit: %d", mem)
+	}
+	if res := e.resConCtx.groups.Resources.MemoryReservation; res != 256*1024*1024 {
+		t.Fatalf("bad memory reservation: %d", res)
+	}
+}
+
+func TestExecutor_ConfigureCgroups_Cores(t *testing.T) {
+	e := NewExecutor(testLogger()).(*UniversalExecutor)
+	resources := mock.Alloc().Job.TaskGroups[0].Tasks[0].Resources
+	resources.CoreIDs = []uint16{2, 3}
+
+	if err := e.configureCgroups(resources); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if cpus := e.resConCtx.groups.Resources.CpusetCpus; cpus != "2,3" {
+		t.Fatalf("bad cpuset: %q", cpus)
+	}
+}
+
+func TestExecutor_ConfigureCgroups_SharedCores(t *testing.T) {
+	e := NewExecutor(testLogger()).(*UniversalExecutor)
+	e.ctx = &ExecutorContext{Cpuset: "0,1"}
+	resources := mock.Alloc().Job.TaskGroups[0].Tasks[0].Resources
+
+	if err := e.configureCgroups(resources); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if cpus := e.resConCtx.groups.Resources.CpusetCpus; cpus != "0,1" {
+		t.Fatalf("bad cpuset: %q", cpus)
+	}
+}
@@ -1,8 +1,12 @@
 package driver
 
 import (
+	"context"
 	"encoding/gob"
+	"io"
+	"io/ioutil"
 	"log"
+	"net"
 	"net/rpc"
 	"os"
 	"syscall"
@@ -10,6 +14,7 @@ import (
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad/client/driver/executor"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -26,6 +31,7 @@ func init() {
 
 type ExecutorRPC struct {
 	client *rpc.Client
+	broker *plugin.MuxBroker
 	logger *log.Logger
 }
 
@@ -45,6 +51,19 @@ type ExecCmdReturn struct {
 	Code   int
 }
 
+// ExecStreamingArgs wraps a command executed interactively. The input, output
+// and terminal sizes of the command are streamed over the connections with
+// the given broker ids. The command is stopped when the resize connection is
+// closed.
+type ExecStreamingArgs struct {
+	Command  []string
+	Tty      bool
+	StdinID  uint32
+	StdoutID uint32
+	StderrID uint32
+	ResizeID uint32
+}
+
 func (e *ExecutorRPC) LaunchCmd(cmd *executor.ExecCommand) (*executor.ProcessState, error) {
 	var ps *executor.ProcessState
 	err := e.client.Call("Plugin.LaunchCmd", LaunchCmdArgs{Cmd: cmd}, &ps)
@@ -83,6 +102,10 @@ func (e *ExecutorRPC) UpdateTask(task *structs.Task) error {
 	return e.client.Call("Plugin.UpdateTask", task, new(interface{}))
 }
 
+func (e *ExecutorRPC) UpdateCpuset(cpuset string) error {
+	return e.client.Call("Plugin.UpdateCpuset", cpuset, new(interface{}))
+}
+
 func (e *ExecutorRPC) DeregisterServices() error {
 	return e.client.Call("Plugin.DeregisterServices", new(interface{}), new(interface{}))
 }
@@ -117,8 +140,83 @@ func (e *ExecutorRPC) Exec(deadline time.Time, name string, args []string) ([]by
 	return resp.Output, resp.Code, err
 }
 
+func (e *ExecutorRPC) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	req := ExecStreamingArgs{
+		Command:  opts.Command,
+		Tty:      opts.Tty,
+		StdinID:  e.broker.NextId(),
+		StdoutID: e.broker.NextId(),
+		StderrID: e.broker.NextId(),
+		ResizeID: e.broker.NextId(),
+	}
+
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stderr == nil {
+		stderr = ioutil.Discard
+	}
+	doneCh := make(chan struct{})
+	outCh := make(chan struct{}, 2)
+	go e.acceptStream(req.StdinID, func(conn net.Conn) {
+		if opts.Stdin != nil {
+			io.Copy(conn, opts.Stdin)
+		}
+	})
+	go func() {
+		e.acceptStream(req.StdoutID, func(conn net.Conn) { io.Copy(stdout, conn) })
+		outCh <- struct{}{}
+	}()
+	go func() {
+		e.acceptStream(req.StderrID, func(conn net.Conn) { io.Copy(stderr, conn) })
+		outCh <- struct{}{}
+	}()
+	go e.acceptStream(req.ResizeID, func(conn net.Conn) {
+		enc := gob.NewEncoder(conn)
+		for {
+			select {
+			case size, ok := <-opts.ResizeCh:
+				if !ok {
+					opts.ResizeCh = nil
+					continue
+				}
+				if err := enc.Encode(size); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-doneCh:
+				return
+			}
+		}
+	})
+
+	var code int
+	err := e.client.Call("Plugin.ExecStreaming", req, &code)
+	close(doneCh)
+	if err != nil {
+		return 0, err
+	}
+
+	// Wait for the remaining output of the command
+	<-outCh
+	<-outCh
+	return code, nil
+}
+
+// acceptStream accepts the connection with the given broker id and calls f
+// with it. The connection is closed once f returns.
+func (e *ExecutorRPC) acceptStream(id uint32, f func(conn net.Conn)) {
+	conn, err := e.broker.Accept(id)
+	if err != nil {
+		e.logger.Printf("[ERR] driver: failed to accept exec stream: %v", err)
+		return
+	}
+	defer conn.Close()
+	f(conn)
+}
+
 type ExecutorRPCServer struct {
 	Impl   executor.Executor
+	broker *plugin.MuxBroker
 	logger *log.Logger
 }
 
@@ -166,6 +264,10 @@ func (e *ExecutorRPCServer) UpdateTask(args *structs.Task, resp *interface{}) er
 	return e.Impl.UpdateTask(args)
 }
 
+func (e *ExecutorRPCServer) UpdateCpuset(cpuset string, resp *interface{}) error {
+	return e.Impl.UpdateCpuset(cpuset)
+}
+
 func (e *ExecutorRPCServer) DeregisterServices(args interface{}, resp *interface{}) error {
 	// In 0.6 this is a noop. Goes away in 0.7.
 	return nil
@@ -201,18 +303,67 @@ func (e *ExecutorRPCServer) Exec(args ExecCmdArgs, result *ExecCmdReturn) error
 	return err
 }
 
+func (e *ExecutorRPCServer) ExecStreaming(args ExecStreamingArgs, code *int) error {
+	var conns []net.Conn
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for _, id := range []uint32{args.StdinID, args.StdoutID, args.StderrID, args.ResizeID} {
+		conn, err := e.broker.Dial(id)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+	}
+
+	// Stop the command once the client closes the resize connection
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resizeCh := make(chan dstructs.TerminalSize)
+	go func() {
+		defer cancel()
+		dec := gob.NewDecoder(conns[3])
+		for {
+			var size dstructs.TerminalSize
+			if err := dec.Decode(&size); err != nil {
+				return
+			}
+			select {
+			case resizeCh <- size:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	opts := &dstructs.ExecStreamingOptions{
+		Command:  args.Command,
+		Tty:      args.Tty,
+		Stdin:    conns[0],
+		Stdout:   conns[1],
+		Stderr:   conns[2],
+		ResizeCh: resizeCh,
+	}
+	c, err := e.Impl.ExecStreaming(ctx, opts)
+	*code = c
+	return err
+}
+
 type ExecutorPlugin struct {
 	logger *log.Logger
 	Impl   *ExecutorRPCServer
 }
 
-func (p *ExecutorPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+func (p *ExecutorPlugin) Server(b *plugin.MuxBroker) (interface{}, error) {
 	if p.Impl == nil {
 		p.Impl = &ExecutorRPCServer{Impl: executor.NewExecutor(p.logger), logger: p.logger}
 	}
+	p.Impl.broker = b
 	return p.Impl, nil
 }
 
 func (p *ExecutorPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
-	return &ExecutorRPC{client: c, logger: p.logger}, nil
+	return &ExecutorRPC{client: c, broker: b, logger: p.logger}, nil
 }
@@ -0,0 +1,522 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad/client/config"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// externalDriverPeriod is the period at which external drivers are
+	// fingerprinted when their plugin can't be launched.
+	externalDriverPeriod = 30 * time.Second
+
+	// externalDriverLogFile is the name of the file in the task directory
+	// the task's driver plugin logs to.
+	externalDriverLogFile = "driver-plugin.out"
+)
+
+// DriverPluginManager manages the plugin processes the client launches for
+// the calls of external drivers that aren't tied to a running task, such as
+// fingerprinting. A process is launched per plugin binary.
+type DriverPluginManager struct {
+	// clients are the clients of the plugin processes keyed by the path of
+	// the plugin binary
+	clients map[string]*plugin.Client
+	lock    sync.Mutex
+}
+
+// NewDriverPluginManager returns a manager without running plugin processes.
+func NewDriverPluginManager() *DriverPluginManager {
+	return &DriverPluginManager{
+		clients: make(map[string]*plugin.Client),
+	}
+}
+
+// dispense returns the driver of the plugin's process, launching the process
+// if it isn't running.
+func (m *DriverPluginManager) dispense(p *config.DriverPlugin, conf *config.Config) (*DriverRPC, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	c, ok := m.clients[p.Path]
+	if !ok || c.Exited() {
+		pluginConfig := newDriverPluginClientConfig(p, conf)
+		pluginConfig.Managed = true
+		pluginConfig.SyncStderr = conf.LogOutput
+		c = plugin.NewClient(pluginConfig)
+		m.clients[p.Path] = c
+	}
+
+	d, err := dispenseDriver(c)
+	if err != nil {
+		c.Kill()
+		delete(m.clients, p.Path)
+		return nil, err
+	}
+	return d, nil
+}
+
+// Shutdown kills the plugin processes of the manager. The plugin processes of
+// running tasks aren't managed and are left running so that the tasks can be
+// recovered when the client restarts.
+func (m *DriverPluginManager) Shutdown() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for path, c := range m.clients {
+		c.Kill()
+		delete(m.clients, path)
+	}
+}
+
+// newDriverPluginClientConfig returns the configuration to launch a process
+// of the plugin.
+func newDriverPluginClientConfig(p *config.DriverPlugin, conf *config.Config) *plugin.ClientConfig {
+	return &plugin.ClientConfig{
+		HandshakeConfig: DriverHandshakeConfig,
+		Plugins:         map[string]plugin.Plugin{"driver": new(DriverPlugin)},
+		Cmd:             exec.Command(p.Path),
+		MinPort:         conf.ClientMinPort,
+		MaxPort:         conf.ClientMaxPort,
+	}
+}
+
+// dispenseDriver returns the driver of the plugin client.
+func dispenseDriver(c *plugin.Client) (*DriverRPC, error) {
+	rpcClient, err := c.Client()
+	if err != nil {
+		return nil, fmt.Errorf("error creating rpc client for driver plugin: %v", err)
+	}
+	raw, err := rpcClient.Dispense("driver")
+	if err != nil {
+		return nil, fmt.Errorf("unable to dispense the driver plugin: %v", err)
+	}
+	return raw.(*DriverRPC), nil
+}
+
+// DiscoverDriverPlugins returns the driver plugins in the directory, keyed by
+// driver name. Driver plugins are the executables whose name is the driver
+// name prefixed with DriverPluginPrefix. The configs are the configuration of
+// the plugins keyed by driver name.
+func DiscoverDriverPlugins(dir string, configs map[string]map[string]interface{}) (map[string]*config.DriverPlugin, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read plugin directory %q: %v", dir, err)
+	}
+
+	plugins := make(map[string]*config.DriverPlugin)
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasPrefix(name, DriverPluginPrefix) {
+			continue
+		}
+
+		// Resolve symlinks to check whether the target is an executable
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			if !strings.HasSuffix(name, ".exe") {
+				continue
+			}
+			name = strings.TrimSuffix(name, ".exe")
+		} else if info.Mode()&0111 == 0 {
+			continue
+		}
+
+		name = strings.TrimPrefix(name, DriverPluginPrefix)
+		if name == "" {
+			continue
+		}
+		plugins[name] = &config.DriverPlugin{
+			Name:   name,
+			Path:   path,
+			Config: configs[name],
+		}
+	}
+	return plugins, nil
+}
+
+// ExternalDriver is a driver provided by an external plugin. Calls that
+// aren't tied to a running task are served by a plugin process shared by all
+// tasks of the driver. Each task is started by a plugin process of its own
+// that outlives the client so that the task can be recovered when the client
+// restarts.
+type ExternalDriver struct {
+	DriverContext
+
+	plugin *config.DriverPlugin
+}
+
+// externalDriverID is the handle ID of tasks of external drivers.
+type externalDriverID struct {
+	// Reattach is the configuration to reattach to the task's plugin
+	// process.
+	Reattach *PluginReattachConfig
+
+	// HandleID is the handle ID returned by the plugin's driver.
+	HandleID string
+}
+
+// NewExternalDriver returns the driver provided by the plugin.
+func NewExternalDriver(p *config.DriverPlugin, ctx *DriverContext) Driver {
+	return &ExternalDriver{
+		DriverContext: *ctx,
+		plugin:        p,
+	}
+}
+
+// pluginContext returns the context the plugin creates its driver with.
+func (d *ExternalDriver) pluginContext(logFile string) *DriverPluginContext {
+	ctx := &DriverPluginContext{
+		TaskName:     d.taskName,
+		AllocID:      d.allocID,
+		Node:         d.node,
+		PluginConfig: d.plugin.Config,
+		LogFile:      logFile,
+	}
+	if d.config != nil {
+		ctx.Options = d.config.Options
+		ctx.AllocDir = d.config.AllocDir
+		ctx.StateDir = d.config.StateDir
+		ctx.ClientMinPort = d.config.ClientMinPort
+		ctx.ClientMaxPort = d.config.ClientMaxPort
+		ctx.LogLevel = d.config.LogLevel
+	}
+	return ctx
+}
+
+// control returns the driver of the plugin process shared by the tasks of
+// the driver.
+func (d *ExternalDriver) control() (*DriverRPC, error) {
+	if d.driverPlugins == nil {
+		return nil, fmt.Errorf("no driver plugin manager to launch driver plugin %q", d.plugin.Name)
+	}
+	return d.driverPlugins.dispense(d.plugin, d.config)
+}
+
+func (d *ExternalDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	attr := fmt.Sprintf("driver.%s", d.plugin.Name)
+	rpc, err := d.control()
+	if err != nil {
+		d.logger.Printf("[WARN] driver.%s: failed to launch driver plugin %q: %v", d.plugin.Name, d.plugin.Path, err)
+		resp.RemoveAttribute(attr)
+		resp.Detected = false
+		return nil
+	}
+
+	// Drivers fingerprint the node of the request
+	ctx := d.pluginContext("")
+	ctx.Node = req.Node
+	remote, err := rpc.Fingerprint(ctx)
+	if err != nil {
+		return err
+	}
+	for k, v := range remote.Attributes {
+		resp.AddAttribute(k, v)
+	}
+	for k, v := range remote.Links {
+		resp.AddLink(k, v)
+	}
+	resp.Resources = remote.Resources
+	resp.Detected = remote.Detected
+	return nil
+}
+
+func (d *ExternalDriver) Periodic() (bool, time.Duration) {
+	rpc, err := d.control()
+	if err != nil {
+		// Keep trying to launch the plugin
+		return true, externalDriverPeriod
+	}
+	periodic, period, err := rpc.Periodic(d.pluginContext(""))
+	if err != nil {
+		return true, externalDriverPeriod
+	}
+	return periodic, period
+}
+
+func (d *ExternalDriver) HealthCheck(req *cstructs.HealthCheckRequest, resp *cstructs.HealthCheckResponse) error {
+	rpc, err := d.control()
+	if err != nil {
+		resp.AddDriverInfo(d.plugin.Name, &structs.DriverInfo{
+			Healthy:           false,
+			HealthDescription: fmt.Sprintf("Failed to launch driver plugin: %v", err),
+			UpdateTime:        time.Now(),
+		})
+		return nil
+	}
+	remote, err := rpc.HealthCheck(d.pluginContext(""))
+	if err != nil {
+		return err
+	}
+	*resp = *remote
+	return nil
+}
+
+func (d *ExternalDriver) GetHealthCheckInterval(req *cstructs.HealthCheckIntervalRequest, resp *cstructs.HealthCheckIntervalResponse) error {
+	rpc, err := d.control()
+	if err != nil {
+		return nil
+	}
+	remote, err := rpc.GetHealthCheckInterval(d.pluginContext(""))
+	if err != nil {
+		return err
+	}
+	*resp = *remote
+	return nil
+}
+
+func (d *ExternalDriver) Prestart(ctx *ExecContext, task *structs.Task) (*PrestartResponse, error) {
+	rpc, err := d.control()
+	if err != nil {
+		return nil, err
+	}
+	args := &DriverPluginTaskArgs{
+		Ctx:     d.pluginContext(""),
+		ExecCtx: ctx,
+		Task:    task,
+	}
+	return rpc.Prestart(args)
+}
+
+func (d *ExternalDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse, error) {
+	pluginConfig := newDriverPluginClientConfig(d.plugin, d.config)
+
+	// Isolate the plugin process so that it doesn't receive the signals
+	// sent to the client and survives its restarts.
+	isolateCommand(pluginConfig.Cmd)
+
+	pluginClient := plugin.NewClient(pluginConfig)
+	rpc, err := dispenseDriver(pluginClient)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+
+	args := &DriverPluginTaskArgs{
+		Ctx:     d.pluginContext(filepath.Join(ctx.TaskDir.Dir, externalDriverLogFile)),
+		ExecCtx: ctx,
+		Task:    task,
+	}
+	reply, err := rpc.Start(args)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+
+	h := newExternalDriverHandle(d, rpc, pluginClient, reply.HandleID)
+	return &StartResponse{Handle: h, Network: reply.Network}, nil
+}
+
+func (d *ExternalDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	id := &externalDriverID{}
+	if err := json.Unmarshal([]byte(handleID), id); err != nil {
+		return nil, fmt.Errorf("Failed to parse handle %q: %v", handleID, err)
+	}
+
+	args := &DriverPluginTaskArgs{
+		Ctx:      d.pluginContext(filepath.Join(ctx.TaskDir.Dir, externalDriverLogFile)),
+		ExecCtx:  ctx,
+		HandleID: id.HandleID,
+	}
+
+	// Reattach to the task's plugin process
+	if id.Reattach != nil {
+		pluginConfig := &plugin.ClientConfig{
+			HandshakeConfig: DriverHandshakeConfig,
+			Plugins:         map[string]plugin.Plugin{"driver": new(DriverPlugin)},
+			Reattach:        id.Reattach.PluginConfig(),
+		}
+		pluginClient := plugin.NewClient(pluginConfig)
+		rpc, err := dispenseDriver(pluginClient)
+		if err == nil {
+			var innerID string
+			if innerID, err = rpc.Open(args); err == nil {
+				return newExternalDriverHandle(d, rpc, pluginClient, innerID), nil
+			}
+			pluginClient.Kill()
+		}
+		d.logger.Printf("[WARN] driver.%s: failed to reattach to driver plugin, launching a new plugin: %v", d.plugin.Name, err)
+	}
+
+	// The plugin process is gone, so launch a new one for the plugin's
+	// driver to recover the task from its handle ID.
+	pluginConfig := newDriverPluginClientConfig(d.plugin, d.config)
+	isolateCommand(pluginConfig.Cmd)
+	pluginClient := plugin.NewClient(pluginConfig)
+	rpc, err := dispenseDriver(pluginClient)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+	innerID, err := rpc.Open(args)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+	return newExternalDriverHandle(d, rpc, pluginClient, innerID), nil
+}
+
+func (d *ExternalDriver) Cleanup(ctx *ExecContext, res *CreatedResources) error {
+	rpc, err := d.control()
+	if err != nil {
+		return structs.NewRecoverableError(err, true)
+	}
+	args := &DriverPluginCleanupArgs{
+		Ctx:       d.pluginContext(""),
+		ExecCtx:   ctx,
+		Resources: res,
+	}
+	return rpc.Cleanup(args)
+}
+
+func (d *ExternalDriver) Validate(config map[string]interface{}) error {
+	rpc, err := d.control()
+	if err != nil {
+		return err
+	}
+	args := &DriverPluginValidateArgs{
+		Ctx:    d.pluginContext(""),
+		Config: config,
+	}
+	return rpc.Validate(args)
+}
+
+func (d *ExternalDriver) Abilities() DriverAbilities {
+	rpc, err := d.control()
+	if err != nil {
+		return DriverAbilities{}
+	}
+	abilities, err := rpc.Abilities(d.pluginContext(""))
+	if err != nil {
+		d.logger.Printf("[WARN] driver.%s: failed to get abilities of driver plugin: %v", d.plugin.Name, err)
+	}
+	return abilities
+}
+
+func (d *ExternalDriver) FSIsolation() cstructs.FSIsolation {
+	rpc, err := d.control()
+	if err != nil {
+		return cstructs.FSIsolationNone
+	}
+	isolation, err := rpc.FSIsolation(d.pluginContext(""))
+	if err != nil {
+		d.logger.Printf("[WARN] driver.%s: failed to get filesystem isolation of driver plugin: %v", d.plugin.Name, err)
+		return cstructs.FSIsolationNone
+	}
+	return isolation
+}
+
+// externalDriverHandle is the handle of a task of an external driver. It
+// forwards the calls to the task's plugin process.
+type externalDriverHandle struct {
+	driver       *DriverRPC
+	pluginClient *plugin.Client
+	name         string
+	handleID     string
+	logger       *log.Logger
+
+	waitCh chan *dstructs.WaitResult
+	doneCh chan struct{}
+}
+
+func newExternalDriverHandle(d *ExternalDriver, rpc *DriverRPC, pluginClient *plugin.Client, handleID string) *externalDriverHandle {
+	h := &externalDriverHandle{
+		driver:       rpc,
+		pluginClient: pluginClient,
+		name:         d.plugin.Name,
+		handleID:     handleID,
+		logger:       d.logger,
+		waitCh:       make(chan *dstructs.WaitResult, 1),
+		doneCh:       make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+func (h *externalDriverHandle) ID() string {
+	id := &externalDriverID{
+		Reattach: NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		HandleID: h.handleID,
+	}
+	data, err := json.Marshal(id)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.%s: failed to marshal ID to JSON: %s", h.name, err)
+	}
+	return string(data)
+}
+
+func (h *externalDriverHandle) WaitCh() chan *dstructs.WaitResult {
+	return h.waitCh
+}
+
+func (h *externalDriverHandle) Update(task *structs.Task) error {
+	return h.driver.Update(h.handleID, task)
+}
+
+func (h *externalDriverHandle) Kill() error {
+	select {
+	case <-h.doneCh:
+		return nil
+	default:
+	}
+	return h.driver.Kill(h.handleID)
+}
+
+func (h *externalDriverHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.driver.Stats(h.handleID)
+}
+
+func (h *externalDriverHandle) Signal(s os.Signal) error {
+	sig, ok := s.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", s)
+	}
+	return h.driver.Signal(h.handleID, int(sig))
+}
+
+func (h *externalDriverHandle) Exec(ctx context.Context, cmd string, args []string) ([]byte, int, error) {
+	deadline, _ := ctx.Deadline()
+	return h.driver.Exec(h.handleID, deadline, cmd, args)
+}
+
+func (h *externalDriverHandle) run() {
+	res, err := h.driver.Wait(h.handleID)
+	if err != nil {
+		res = dstructs.NewWaitResult(-1, 0, fmt.Errorf("lost connection to driver plugin: %v", err))
+	}
+	close(h.doneCh)
+
+	// The task's plugin process is no longer needed
+	h.pluginClient.Kill()
+
+	h.waitCh <- res
+	close(h.waitCh)
+}
@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverDriverPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are discovered by extension on windows")
+	}
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-plugins")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]os.FileMode{
+		"nomad-driver-foo":    0755,
+		"nomad-driver-noexec": 0644,
+		"nomad-driver-":       0755,
+		"other-binary":        0755,
+	}
+	for name, mode := range files {
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode))
+	}
+	require.NoError(os.Mkdir(filepath.Join(dir, "nomad-driver-dir"), 0755))
+
+	configs := map[string]map[string]interface{}{
+		"foo": {"key": "value"},
+	}
+	plugins, err := DiscoverDriverPlugins(dir, configs)
+	require.NoError(err)
+	require.Len(plugins, 1)
+	require.Equal("foo", plugins["foo"].Name)
+	require.Equal(filepath.Join(dir, "nomad-driver-foo"), plugins["foo"].Path)
+	require.Equal(configs["foo"], plugins["foo"].Config)
+
+	// A missing directory has no plugins
+	plugins, err = DiscoverDriverPlugins(filepath.Join(dir, "missing"), nil)
+	require.NoError(err)
+	require.Empty(plugins)
+}
+
+func TestExternalDriver_NoPluginManager(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	p := &config.DriverPlugin{Name: "foo", Path: "/nonexistent/nomad-driver-foo"}
+	d := NewExternalDriver(p, NewDriverContext("", "", config.DefaultConfig(), nil, testLogger(), nil, nil))
+
+	// The driver isn't detected without a manager to launch its plugin
+	var resp cstructs.FingerprintResponse
+	require.NoError(d.Fingerprint(&cstructs.FingerprintRequest{}, &resp))
+	require.False(resp.Detected)
+	require.Error(d.Validate(nil))
+}
@@ -106,8 +106,9 @@ func (d *JavaDriver) Validate(config map[string]interface{}) error {
 
 func (d *JavaDriver) Abilities() DriverAbilities {
 	return DriverAbilities{
-		SendSignals: true,
-		Exec:        true,
+		SendSignals:      true,
+		Exec:             true,
+		NetworkIsolation: true,
 	}
 }
 
@@ -255,6 +256,7 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		Task:    task,
 		TaskDir: ctx.TaskDir.Dir,
 		LogDir:  ctx.TaskDir.LogDir,
+		Cpuset:  ctx.Cpuset,
 	}
 	if err := execIntf.SetContext(executorCtx); err != nil {
 		pluginClient.Kill()
@@ -272,12 +274,13 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 	}
 
 	execCmd := &executor.ExecCommand{
-		Cmd:            absPath,
-		Args:           args,
-		FSIsolation:    true,
-		ResourceLimits: true,
-		User:           getExecutorUser(task),
-		TaskKillSignal: taskKillSignal,
+		Cmd:              absPath,
+		Args:             args,
+		FSIsolation:      true,
+		ResourceLimits:   true,
+		User:             getExecutorUser(task),
+		TaskKillSignal:   taskKillSignal,
+		NetworkIsolation: ctx.NetworkIsolation,
 	}
 	ps, err := execIntf.LaunchCmd(execCmd)
 	if err != nil {
@@ -404,6 +407,14 @@ func (h *javaHandle) Exec(ctx context.Context, cmd string, args []string) ([]byt
 	return h.executor.Exec(deadline, cmd, args)
 }
 
+func (h *javaHandle) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	return h.executor.ExecStreaming(ctx, opts)
+}
+
+func (h *javaHandle) UpdateCpuset(cpuset string) error {
+	return h.executor.UpdateCpuset(cpuset)
+}
+
 func (h *javaHandle) Signal(s os.Signal) error {
 	return h.executor.Signal(s)
 }
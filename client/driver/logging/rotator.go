@@ -2,7 +2,9 @@ package logging
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -12,11 +14,19 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 const (
 	bufSize  = 32768
 	flushDur = 100 * time.Millisecond
+
+	// compressedSuffix is the suffix of the compressed rotated files
+	compressedSuffix = ".gz"
+
+	// tmpSuffix is the suffix of the files being compressed
+	tmpSuffix = ".tmp"
 )
 
 // FileRotator writes bytes to a rotated set of files
@@ -24,15 +34,21 @@ type FileRotator struct {
 	MaxFiles int   // MaxFiles is the maximum number of rotated files allowed in a path
 	FileSize int64 // FileSize is the size a rotated file is allowed to grow
 
-	path             string // path is the path on the file system where the rotated set of files are opened
-	baseFileName     string // baseFileName is the base file name of the rotated files
-	logFileIdx       int    // logFileIdx is the current index of the rotated files
-	oldestLogFileIdx int    // oldestLogFileIdx is the index of the oldest log file in a path
+	RotationInterval time.Duration // RotationInterval is the interval files are rotated at, aligned to the wall clock, if set
+	Compress         bool          // Compress gzips the rotated files
+	MaxTotalBytes    int64         // MaxTotalBytes is the maximum size of the rotated set of files, if set
+
+	path         string // path is the path on the file system where the rotated set of files are opened
+	baseFileName string // baseFileName is the base file name of the rotated files
+	logFileIdx   int    // logFileIdx is the current index of the rotated files
 
-	currentFile *os.File // currentFile is the file that is currently getting written
-	currentWr   int64    // currentWr is the number of bytes written to the current file
-	bufw        *bufio.Writer
-	bufLock     sync.Mutex
+	currentFile   *os.File  // currentFile is the file that is currently getting written
+	currentWr     int64     // currentWr is the number of bytes written to the current file
+	currentOpened time.Time // currentOpened is when the current file started being written
+	bufw          *bufio.Writer
+	bufLock       sync.Mutex
+
+	shipper io.Writer // shipper is written what is written to the files, if set
 
 	flushTicker *time.Ticker
 	logger      *log.Logger
@@ -66,20 +82,55 @@ func NewFileRotator(path string, baseFile string, maxFiles int,
 	return rotator, nil
 }
 
+// SetLogConfig sets the rotation policy of the rotator from the log config of
+// a task. The total size of the log files of the task is split evenly between
+// its stdout and stderr rotators.
+func (f *FileRotator) SetLogConfig(logConfig *structs.LogConfig) {
+	f.MaxFiles = logConfig.MaxFiles
+	f.FileSize = int64(logConfig.MaxFileSizeMB * 1024 * 1024)
+	f.RotationInterval = logConfig.RotationInterval
+	f.Compress = logConfig.Compress
+	f.MaxTotalBytes = int64(logConfig.MaxTotalSizeMB*1024*1024) / 2
+
+	// Apply the policy to the existing files
+	f.triggerPurge()
+}
+
+// SetShipper sets a writer that is written what is written to the files, for
+// example the writer of a LogShipper. It must be called before writing.
+func (f *FileRotator) SetShipper(w io.Writer) {
+	f.shipper = w
+}
+
 // Write writes a byte array to a file and rotates the file if it's size becomes
-// equal to the maximum size the user has defined.
+// equal to the maximum size the user has defined. The bytes written are then
+// written to the shipper, if any.
 func (f *FileRotator) Write(p []byte) (n int, err error) {
+	n, err = f.write(p)
+	if f.shipper != nil && n > 0 {
+		f.shipper.Write(p[:n])
+	}
+	return
+}
+
+// write writes a byte array to the rotated files
+func (f *FileRotator) write(p []byte) (n int, err error) {
 	n = 0
 	var nw int
 
+	// Rotate the current file if it has been written for the rotation
+	// interval
+	if f.rotationDue(time.Now()) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
 	for n < len(p) {
 		// Check if we still have space in the current file, otherwise close and
 		// open the next file
 		if f.currentWr >= f.FileSize {
-			f.flushBuffer()
-			f.currentFile.Close()
-			if err := f.nextFile(); err != nil {
-				f.logger.Printf("[ERROR] driver.rotator: error creating next file: %v", err)
+			if err := f.rotate(); err != nil {
 				return 0, err
 			}
 		}
@@ -111,8 +162,28 @@ func (f *FileRotator) Write(p []byte) (n int, err error) {
 	return
 }
 
-// nextFile opens the next file and purges older files if the number of rotated
-// files is larger than the maximum files configured by the user
+// rotationDue returns whether the current file has been written for the
+// rotation interval
+func (f *FileRotator) rotationDue(now time.Time) bool {
+	if f.RotationInterval <= 0 || f.currentWr == 0 {
+		return false
+	}
+	return !now.Before(f.currentOpened.Truncate(f.RotationInterval).Add(f.RotationInterval))
+}
+
+// rotate closes the current file and opens the next one
+func (f *FileRotator) rotate() error {
+	f.flushBuffer()
+	f.currentFile.Close()
+	if err := f.nextFile(); err != nil {
+		f.logger.Printf("[ERROR] driver.rotator: error creating next file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// nextFile opens the next file and purges older files if the rotated files
+// exceed the limits configured by the user
 func (f *FileRotator) nextFile() error {
 	nextFileIdx := f.logFileIdx
 	for {
@@ -123,22 +194,50 @@ func (f *FileRotator) nextFile() error {
 				continue
 			}
 		}
+		if _, err := os.Stat(logFileName + compressedSuffix); err == nil {
+			continue
+		}
 		f.logFileIdx = nextFileIdx
 		if err := f.createFile(); err != nil {
 			return err
 		}
 		break
 	}
-	// Purge old files if we have more files than MaxFiles
+
+	// Compress the rotated file and purge old files
+	f.triggerPurge()
+	return nil
+}
+
+// triggerPurge triggers compressing and purging the rotated files
+func (f *FileRotator) triggerPurge() {
 	f.closedLock.Lock()
 	defer f.closedLock.Unlock()
-	if f.logFileIdx-f.oldestLogFileIdx >= f.MaxFiles && !f.closed {
+	if !f.closed {
 		select {
 		case f.purgeCh <- struct{}{}:
 		default:
 		}
 	}
-	return nil
+}
+
+// parseIndex returns the index of a rotated file from its name and whether
+// the file is compressed. It returns false if the name isn't the name of a
+// rotated file.
+func (f *FileRotator) parseIndex(name string) (int, bool, bool) {
+	prefix := fmt.Sprintf("%s.", f.baseFileName)
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false, false
+	}
+
+	idxStr := strings.TrimPrefix(name, prefix)
+	compressed := strings.HasSuffix(idxStr, compressedSuffix)
+	idxStr = strings.TrimSuffix(idxStr, compressedSuffix)
+	n, err := strconv.Atoi(idxStr)
+	if err != nil {
+		return 0, false, false
+	}
+	return n, compressed, true
 }
 
 // lastFile finds out the rotated file with the largest index in a path.
@@ -148,20 +247,21 @@ func (f *FileRotator) lastFile() error {
 		return err
 	}
 
-	prefix := fmt.Sprintf("%s.", f.baseFileName)
 	for _, fi := range finfos {
 		if fi.IsDir() {
 			continue
 		}
-		if strings.HasPrefix(fi.Name(), prefix) {
-			fileIdx := strings.TrimPrefix(fi.Name(), prefix)
-			n, err := strconv.Atoi(fileIdx)
-			if err != nil {
-				continue
-			}
-			if n > f.logFileIdx {
-				f.logFileIdx = n
-			}
+		n, compressed, ok := f.parseIndex(fi.Name())
+		if !ok {
+			continue
+		}
+
+		// Compressed files are never written again
+		if compressed {
+			n++
+		}
+		if n > f.logFileIdx {
+			f.logFileIdx = n
 		}
 	}
 	if err := f.createFile(); err != nil {
@@ -183,6 +283,13 @@ func (f *FileRotator) createFile() error {
 		return err
 	}
 	f.currentWr = fi.Size()
+
+	// An existing file started being written at the latest when it was last
+	// modified
+	f.currentOpened = time.Now()
+	if f.currentWr != 0 {
+		f.currentOpened = fi.ModTime()
+	}
 	f.createOrResetBuffer()
 	return nil
 }
@@ -211,55 +318,181 @@ func (f *FileRotator) Close() {
 	}
 }
 
-// purgeOldFiles removes older files and keeps only the last N files rotated for
-// a file
+// rotatedFile is a file of the rotated set of files
+type rotatedFile struct {
+	idx        int
+	compressed bool
+	size       int64
+}
+
+// purgeOldFiles compresses the rotated files if enabled and removes older
+// files to keep only the last N files rotated for a file, within the maximum
+// total size if set. The file currently written is never compressed nor
+// removed.
 func (f *FileRotator) purgeOldFiles() {
 	for {
 		select {
 		case <-f.purgeCh:
-			var fIndexes []int
-			files, err := ioutil.ReadDir(f.path)
+			files, err := f.rotatedFiles()
 			if err != nil {
 				f.logger.Printf("[ERROR] driver.rotator: error getting directory listing: %v", err)
 				return
 			}
-			// Inserting all the rotated files in a slice
-			for _, fi := range files {
-				if strings.HasPrefix(fi.Name(), f.baseFileName) {
-					fileIdx := strings.TrimPrefix(fi.Name(), fmt.Sprintf("%s.", f.baseFileName))
-					n, err := strconv.Atoi(fileIdx)
+			if len(files) == 0 {
+				continue
+			}
+
+			if f.Compress {
+				for _, file := range files[:len(files)-1] {
+					if file.compressed {
+						continue
+					}
+					size, err := f.compressFile(file.idx)
 					if err != nil {
-						f.logger.Printf("[ERROR] driver.rotator: error extracting file index: %v", err)
+						f.logger.Printf("[ERROR] driver.rotator: error compressing file: %v", err)
 						continue
 					}
-					fIndexes = append(fIndexes, n)
+					file.compressed = true
+					file.size = size
 				}
 			}
 
-			// Not continuing to delete files if the number of files is not more
-			// than MaxFiles
-			if len(fIndexes) <= f.MaxFiles {
-				continue
+			// The current file is accounted for at its maximum size as it
+			// is still being written
+			if current := files[len(files)-1]; current.size < f.FileSize {
+				current.size = f.FileSize
+			}
+
+			var total int64
+			for _, file := range files {
+				total += file.size
 			}
 
-			// Sorting the file indexes so that we can purge the older files and keep
-			// only the number of files as configured by the user
-			sort.Sort(sort.IntSlice(fIndexes))
-			toDelete := fIndexes[0 : len(fIndexes)-f.MaxFiles]
-			for _, fIndex := range toDelete {
-				fname := filepath.Join(f.path, fmt.Sprintf("%s.%d", f.baseFileName, fIndex))
-				err := os.RemoveAll(fname)
-				if err != nil {
+			// Remove the oldest files until the limits are met
+			remove := 0
+			for ; remove < len(files)-1; remove++ {
+				tooMany := len(files)-remove > f.MaxFiles
+				tooLarge := f.MaxTotalBytes > 0 && total > f.MaxTotalBytes
+				if !tooMany && !tooLarge {
+					break
+				}
+				total -= files[remove].size
+			}
+
+			for _, file := range files[:remove] {
+				if err := os.RemoveAll(f.fileName(file)); err != nil {
 					f.logger.Printf("[ERROR] driver.rotator: error removing file: %v", err)
 				}
 			}
-			f.oldestLogFileIdx = fIndexes[0]
 		case <-f.doneCh:
 			return
 		}
 	}
 }
 
+// rotatedFiles returns the rotated files sorted by index. It removes the
+// leftovers of interrupted compressions.
+func (f *FileRotator) rotatedFiles() ([]*rotatedFile, error) {
+	finfos, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	byIdx := make(map[int]*rotatedFile)
+	for _, fi := range finfos {
+		if fi.IsDir() {
+			continue
+		}
+
+		name := fi.Name()
+		if strings.HasPrefix(name, f.baseFileName+".") && strings.HasSuffix(name, compressedSuffix+tmpSuffix) {
+			os.Remove(filepath.Join(f.path, name))
+			continue
+		}
+
+		idx, compressed, ok := f.parseIndex(name)
+		if !ok {
+			continue
+		}
+
+		file := &rotatedFile{idx: idx, compressed: compressed, size: fi.Size()}
+		if existing, ok := byIdx[idx]; ok {
+			// The compression was interrupted before removing the original
+			// file so remove it now and keep the compressed file
+			original, gzipped := existing, file
+			if !compressed {
+				original, gzipped = file, existing
+			}
+			os.Remove(f.fileName(original))
+			file = gzipped
+		}
+		byIdx[idx] = file
+	}
+
+	files := make([]*rotatedFile, 0, len(byIdx))
+	for _, file := range byIdx {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].idx < files[j].idx })
+	return files, nil
+}
+
+// fileName returns the path of a rotated file
+func (f *FileRotator) fileName(file *rotatedFile) string {
+	name := filepath.Join(f.path, fmt.Sprintf("%s.%d", f.baseFileName, file.idx))
+	if file.compressed {
+		name += compressedSuffix
+	}
+	return name
+}
+
+// compressFile gzips the rotated file with the given index and returns the
+// size of the compressed file. The original file is removed once the
+// compressed file is complete.
+func (f *FileRotator) compressFile(idx int) (int64, error) {
+	src := f.fileName(&rotatedFile{idx: idx})
+	dst := src + compressedSuffix
+	tmp := dst + tmpSuffix
+
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return 0, err
+	}
+
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Remove(src); err != nil {
+		return 0, err
+	}
+
+	fi, err := os.Stat(dst)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
 // flushBuffer flushes the buffer
 func (f *FileRotator) flushBuffer() error {
 	f.bufLock.Lock()
@@ -1,12 +1,14 @@
 package logging
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -289,3 +291,222 @@ func TestFileRotator_PurgeOldFiles(t *testing.T) {
 		t.Fatalf("%v", lastErr)
 	})
 }
+
+func TestFileRotator_OpenLastFile_Compressed(t *testing.T) {
+	t.Parallel()
+	var path string
+	var err error
+	if path, err = ioutil.TempDir("", pathPrefix); err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	for _, name := range []string{"redis.stdout.0.gz", "redis.stdout.1.gz"} {
+		if _, err := os.Create(filepath.Join(path, name)); err != nil {
+			t.Fatalf("test setup failure: %v", err)
+		}
+	}
+
+	fr, err := NewFileRotator(path, baseFileName, 10, 10, logger)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer fr.Close()
+
+	// Compressed files are never written to again
+	expected := filepath.Join(path, "redis.stdout.2")
+	if fr.currentFile.Name() != expected {
+		t.Fatalf("expected current file: %v, got: %v", expected, fr.currentFile.Name())
+	}
+}
+
+func TestFileRotator_InterruptedCompression(t *testing.T) {
+	t.Parallel()
+	var path string
+	var err error
+	if path, err = ioutil.TempDir("", pathPrefix); err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	// Both the original and the compressed file of index 0 are left behind
+	for _, name := range []string{"redis.stdout.0", "redis.stdout.0.gz", "redis.stdout.1"} {
+		if _, err := os.Create(filepath.Join(path, name)); err != nil {
+			t.Fatalf("test setup failure: %v", err)
+		}
+	}
+
+	fr, err := NewFileRotator(path, baseFileName, 10, 10, logger)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer fr.Close()
+
+	files, err := fr.rotatedFiles()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(files) != 2 || files[0].idx != 0 || !files[0].compressed || files[1].idx != 1 || files[1].compressed {
+		for _, file := range files {
+			t.Logf("file: %+v", file)
+		}
+		t.Fatalf("bad files")
+	}
+
+	// The original file is removed and the compressed file is kept
+	if _, err := os.Stat(filepath.Join(path, "redis.stdout.0")); !os.IsNotExist(err) {
+		t.Fatalf("expected the original file to be removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "redis.stdout.0.gz")); err != nil {
+		t.Fatalf("expected the compressed file to be kept: %v", err)
+	}
+}
+
+func TestFileRotator_RotationInterval(t *testing.T) {
+	t.Parallel()
+	var path string
+	var err error
+	if path, err = ioutil.TempDir("", pathPrefix); err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	fr, err := NewFileRotator(path, baseFileName, 10, 1024, logger)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer fr.Close()
+	fr.RotationInterval = time.Hour
+
+	if _, err := fr.Write([]byte("abc")); err != nil {
+		t.Fatalf("got error while writing: %v", err)
+	}
+	if fr.logFileIdx != 0 {
+		t.Fatalf("expected no rotation; got index %d", fr.logFileIdx)
+	}
+
+	// Pretend the current file was opened during the previous interval
+	fr.currentOpened = time.Now().Add(-time.Hour)
+	if _, err := fr.Write([]byte("def")); err != nil {
+		t.Fatalf("got error while writing: %v", err)
+	}
+	if fr.logFileIdx != 1 {
+		t.Fatalf("expected rotation; got index %d", fr.logFileIdx)
+	}
+	fr.flushBuffer()
+
+	for name, expected := range map[string]string{"redis.stdout.0": "abc", "redis.stdout.1": "def"} {
+		buf, err := ioutil.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(buf) != expected {
+			t.Fatalf("expected %q in %s; got %q", expected, name, string(buf))
+		}
+	}
+}
+
+func TestFileRotator_Compress(t *testing.T) {
+	t.Parallel()
+	var path string
+	var err error
+	if path, err = ioutil.TempDir("", pathPrefix); err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	fr, err := NewFileRotator(path, baseFileName, 10, 5, logger)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer fr.Close()
+	fr.Compress = true
+
+	if _, err := fr.Write([]byte("abcdefghijk")); err != nil {
+		t.Fatalf("got error while writing: %v", err)
+	}
+
+	// All the files but the current one are compressed
+	expected := []string{"redis.stdout.0.gz", "redis.stdout.1.gz", "redis.stdout.2"}
+	var lastErr error
+	testutil.WaitForResult(func() (bool, error) {
+		f, err := ioutil.ReadDir(path)
+		if err != nil {
+			lastErr = fmt.Errorf("test error: %v", err)
+			return false, nil
+		}
+
+		var names []string
+		for _, fi := range f {
+			names = append(names, fi.Name())
+		}
+		if fmt.Sprint(names) != fmt.Sprint(expected) {
+			lastErr = fmt.Errorf("expected files %v, got: %v", expected, names)
+			return false, nil
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("%v", lastErr)
+	})
+
+	f, err := os.Open(filepath.Join(path, "redis.stdout.1.gz"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	buf, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(buf) != "fghij" {
+		t.Fatalf("expected %q; got %q", "fghij", string(buf))
+	}
+}
+
+func TestFileRotator_MaxTotalBytes(t *testing.T) {
+	t.Parallel()
+	var path string
+	var err error
+	if path, err = ioutil.TempDir("", pathPrefix); err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	fr, err := NewFileRotator(path, baseFileName, 10, 4, logger)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer fr.Close()
+	fr.MaxTotalBytes = 10
+
+	if _, err := fr.Write([]byte("abcdefghijklmnop")); err != nil {
+		t.Fatalf("got error while writing: %v", err)
+	}
+
+	// Only the newest files fitting in the total size are kept
+	expected := []string{"redis.stdout.2", "redis.stdout.3"}
+	var lastErr error
+	testutil.WaitForResult(func() (bool, error) {
+		f, err := ioutil.ReadDir(path)
+		if err != nil {
+			lastErr = fmt.Errorf("test error: %v", err)
+			return false, nil
+		}
+
+		var names []string
+		for _, fi := range f {
+			names = append(names, fi.Name())
+		}
+		if fmt.Sprint(names) != fmt.Sprint(expected) {
+			lastErr = fmt.Errorf("expected files %v, got: %v", expected, names)
+			return false, nil
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("%v", lastErr)
+	})
+}
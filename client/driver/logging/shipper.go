@@ -0,0 +1,412 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// maxLineSize is the size after which a line without a newline is
+	// shipped as is
+	maxLineSize = 64 * 1024
+
+	// sinkRetryBase and sinkRetryMax bound the backoff between two attempts
+	// to send a batch of lines to a failing sink
+	sinkRetryBase = 500 * time.Millisecond
+	sinkRetryMax  = 30 * time.Second
+)
+
+// BuiltinLogSinks are the sinks log lines can be shipped to, by the type of
+// log shipper they implement.
+var BuiltinLogSinks = map[string]LogSinkFactory{
+	structs.LogShipperTypeTCP:  newNetSink,
+	structs.LogShipperTypeUDP:  newNetSink,
+	structs.LogShipperTypeFile: newFileSink,
+	structs.LogShipperTypeHTTP: newHTTPSink,
+}
+
+// LogLine is a line of the logs of a task as shipped to the sinks
+type LogLine struct {
+	Timestamp time.Time `json:"timestamp"`
+	Stream    string    `json:"stream"`
+	Message   string    `json:"message"`
+	AllocID   string    `json:"alloc_id"`
+	JobID     string    `json:"job_id"`
+	TaskGroup string    `json:"task_group"`
+	Task      string    `json:"task"`
+	Namespace string    `json:"namespace"`
+}
+
+// LogMeta is the metadata log lines are tagged with
+type LogMeta struct {
+	AllocID   string
+	JobID     string
+	TaskGroup string
+	Task      string
+	Namespace string
+}
+
+// NewLogMeta returns the metadata of the task with the given environment
+func NewLogMeta(taskEnv *env.TaskEnv) LogMeta {
+	if taskEnv == nil {
+		return LogMeta{}
+	}
+	return LogMeta{
+		AllocID:   taskEnv.EnvMap[env.AllocID],
+		JobID:     taskEnv.EnvMap[env.JobID],
+		TaskGroup: taskEnv.EnvMap[env.GroupName],
+		Task:      taskEnv.EnvMap[env.TaskName],
+		Namespace: taskEnv.EnvMap[env.Namespace],
+	}
+}
+
+// LogSinkContext is the context a log sink is created with
+type LogSinkContext struct {
+	// Config is the configuration of the log shipper
+	Config *structs.LogShipper
+
+	// LogConfig is the log configuration of the task
+	LogConfig *structs.LogConfig
+
+	// LogDir is the log directory of the task
+	LogDir string
+
+	Logger *log.Logger
+}
+
+// LogSinkFactory returns a new log sink
+type LogSinkFactory func(ctx *LogSinkContext) (LogSink, error)
+
+// LogSink is the interface implemented by the sinks log lines are shipped to
+type LogSink interface {
+	// Send sends a batch of lines. It is called again with the same lines
+	// when it returns an error and must not retain the slice.
+	Send(lines []*LogLine) error
+
+	// Close closes the sink
+	Close() error
+}
+
+// LogShipper ships the lines written to its writers to a set of sinks.
+//
+// Lines are buffered for each sink and sent in batches. Writes block once the
+// buffer of a sink is full, applying backpressure to the task, unless the sink
+// is failing in which case the lines that don't fit in the buffer are
+// dropped.
+type LogShipper struct {
+	meta   LogMeta
+	sinks  []*sinkShipper
+	logger *log.Logger
+
+	writers     []*lineWriter
+	writersLock sync.Mutex
+}
+
+// NewLogShipper returns a log shipper shipping lines tagged with the given
+// metadata to the sinks of the given log config.
+func NewLogShipper(logConfig *structs.LogConfig, meta LogMeta, logDir string, logger *log.Logger) (*LogShipper, error) {
+	s := &LogShipper{
+		meta:   meta,
+		logger: logger,
+	}
+
+	for _, config := range logConfig.Shippers {
+		factory, ok := BuiltinLogSinks[config.Type]
+		if !ok {
+			s.Close()
+			return nil, fmt.Errorf("unknown log shipper type %q", config.Type)
+		}
+
+		sink, err := factory(&LogSinkContext{
+			Config:    config,
+			LogConfig: logConfig,
+			LogDir:    logDir,
+			Logger:    logger,
+		})
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("failed to create %q log shipper: %v", config.Type, err)
+		}
+
+		ss := newSinkShipper(config, sink, logger)
+		go ss.run()
+		s.sinks = append(s.sinks, ss)
+	}
+
+	return s, nil
+}
+
+// Writer returns a writer shipping the lines written to it as part of the
+// given stream, for example stdout or stderr.
+func (s *LogShipper) Writer(stream string) io.Writer {
+	w := &lineWriter{
+		shipper: s,
+		stream:  stream,
+	}
+
+	s.writersLock.Lock()
+	s.writers = append(s.writers, w)
+	s.writersLock.Unlock()
+	return w
+}
+
+// ship tags a line and queues it to be sent to every sink
+func (s *LogShipper) ship(stream string, message []byte) {
+	line := &LogLine{
+		Timestamp: time.Now().UTC(),
+		Stream:    stream,
+		Message:   string(message),
+		AllocID:   s.meta.AllocID,
+		JobID:     s.meta.JobID,
+		TaskGroup: s.meta.TaskGroup,
+		Task:      s.meta.Task,
+		Namespace: s.meta.Namespace,
+	}
+
+	for _, sink := range s.sinks {
+		sink.enqueue(line)
+	}
+}
+
+// Close ships the partial lines of the writers, flushes the buffered lines
+// and closes the sinks.
+func (s *LogShipper) Close() {
+	s.writersLock.Lock()
+	for _, w := range s.writers {
+		w.flush()
+	}
+	s.writersLock.Unlock()
+
+	for _, sink := range s.sinks {
+		sink.shutdown()
+	}
+}
+
+// lineWriter splits what is written to it into lines and ships them
+type lineWriter struct {
+	shipper *LogShipper
+	stream  string
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.shipper.ship(w.stream, bytes.TrimSuffix(w.buf[start:start+i], []byte{'\r'}))
+		start += i + 1
+	}
+
+	// Keep the partial line unless it is too long
+	rest := w.buf[start:]
+	if len(rest) >= maxLineSize {
+		w.shipper.ship(w.stream, rest)
+		rest = nil
+	}
+	w.buf = w.buf[:copy(w.buf, rest)]
+	return len(p), nil
+}
+
+// flush ships the partial line, if any
+func (w *lineWriter) flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.buf) != 0 {
+		w.shipper.ship(w.stream, w.buf)
+		w.buf = w.buf[:0]
+	}
+}
+
+// sinkShipper buffers the lines shipped to a sink and sends them in batches
+type sinkShipper struct {
+	// dropped counts the lines dropped while the sink is failing. It is
+	// first to be 64-bit aligned for atomic operations.
+	dropped int64
+
+	config *structs.LogShipper
+	sink   LogSink
+	logger *log.Logger
+
+	linesCh chan *LogLine
+
+	// failCh is closed while the sink is failing
+	failing  bool
+	failCh   chan struct{}
+	failLock sync.Mutex
+
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
+	doneCh       chan struct{}
+}
+
+func newSinkShipper(config *structs.LogShipper, sink LogSink, logger *log.Logger) *sinkShipper {
+	return &sinkShipper{
+		config:     config,
+		sink:       sink,
+		logger:     logger,
+		linesCh:    make(chan *LogLine, config.BufferSize),
+		failCh:     make(chan struct{}),
+		shutdownCh: make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+}
+
+// enqueue buffers a line, blocking while the buffer is full unless the sink
+// is failing.
+func (s *sinkShipper) enqueue(line *LogLine) {
+	select {
+	case s.linesCh <- line:
+		return
+	case <-s.shutdownCh:
+		return
+	case <-s.failingCh():
+	}
+
+	// Drop the line rather than blocking on a failing sink
+	select {
+	case s.linesCh <- line:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+// failingCh returns a channel that is closed while the sink is failing
+func (s *sinkShipper) failingCh() <-chan struct{} {
+	s.failLock.Lock()
+	defer s.failLock.Unlock()
+	return s.failCh
+}
+
+// setFailing sets whether the sink is failing and returns whether it changed
+func (s *sinkShipper) setFailing(failing bool) bool {
+	s.failLock.Lock()
+	defer s.failLock.Unlock()
+
+	if s.failing == failing {
+		return false
+	}
+
+	s.failing = failing
+	if failing {
+		close(s.failCh)
+	} else {
+		s.failCh = make(chan struct{})
+	}
+	return true
+}
+
+// run sends the buffered lines once a batch is full or the flush interval is
+// reached, until the shipper is shutdown.
+func (s *sinkShipper) run() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*LogLine, 0, s.config.BatchSize)
+	for {
+		select {
+		case line := <-s.linesCh:
+			batch = append(batch, line)
+			if len(batch) < s.config.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-s.shutdownCh:
+			s.flush(batch)
+			return
+		}
+
+		batch = s.send(batch)
+	}
+}
+
+// send sends a batch of lines, retrying with a backoff until it succeeds or
+// the shipper is shutdown. It returns the emptied batch.
+func (s *sinkShipper) send(batch []*LogLine) []*LogLine {
+	backoff := sinkRetryBase
+	for {
+		err := s.sink.Send(batch)
+		if err == nil {
+			if s.setFailing(false) {
+				s.logger.Printf("[INFO] driver.log_shipper: %q sink %q recovered; %d lines were dropped",
+					s.config.Type, s.config.Address, atomic.SwapInt64(&s.dropped, 0))
+			}
+			return batch[:0]
+		}
+
+		if s.setFailing(true) {
+			s.logger.Printf("[WARN] driver.log_shipper: failed to send lines to %q sink %q: %v",
+				s.config.Type, s.config.Address, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.shutdownCh:
+			return batch[:0]
+		}
+
+		backoff *= 2
+		if backoff > sinkRetryMax {
+			backoff = sinkRetryMax
+		}
+	}
+}
+
+// flush sends the batch and the buffered lines without retrying, giving up
+// on the first error
+func (s *sinkShipper) flush(batch []*LogLine) {
+	for {
+		select {
+		case line := <-s.linesCh:
+			batch = append(batch, line)
+			if len(batch) < s.config.BatchSize {
+				continue
+			}
+		default:
+		}
+
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.sink.Send(batch); err != nil {
+			s.logger.Printf("[WARN] driver.log_shipper: failed to flush lines to %q sink %q: %v",
+				s.config.Type, s.config.Address, err)
+			return
+		}
+		batch = batch[:0]
+	}
+}
+
+// shutdown flushes the buffered lines and closes the sink
+func (s *sinkShipper) shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdownCh)
+		<-s.doneCh
+		if err := s.sink.Close(); err != nil {
+			s.logger.Printf("[WARN] driver.log_shipper: failed to close %q sink %q: %v",
+				s.config.Type, s.config.Address, err)
+		}
+	})
+}
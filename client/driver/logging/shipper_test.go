@@ -0,0 +1,395 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+var testLogMeta = LogMeta{
+	AllocID:   "alloc",
+	JobID:     "job",
+	TaskGroup: "group",
+	Task:      "task",
+	Namespace: "default",
+}
+
+func testLogConfig(shippers ...*structs.LogShipper) *structs.LogConfig {
+	c := structs.DefaultLogConfig()
+	for _, s := range shippers {
+		s.BufferSize = 16
+		s.BatchSize = 4
+		s.FlushInterval = 10 * time.Millisecond
+	}
+	c.Shippers = shippers
+	return c
+}
+
+func checkLine(t *testing.T, line *LogLine, stream, message string) {
+	if line.Stream != stream || line.Message != message {
+		t.Fatalf("expected %s line %q; got %s line %q", stream, message, line.Stream, line.Message)
+	}
+	if line.AllocID != "alloc" || line.JobID != "job" || line.TaskGroup != "group" ||
+		line.Task != "task" || line.Namespace != "default" {
+		t.Fatalf("line not tagged with the metadata: %#v", line)
+	}
+	if line.Timestamp.IsZero() {
+		t.Fatalf("line has no timestamp")
+	}
+}
+
+func TestLogShipper_TCP(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	linesCh := make(chan *LogLine, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			var line LogLine
+			if err := json.Unmarshal(s.Bytes(), &line); err != nil {
+				t.Errorf("invalid line %q: %v", s.Text(), err)
+				return
+			}
+			linesCh <- &line
+		}
+	}()
+
+	config := testLogConfig(&structs.LogShipper{Type: "tcp", Address: l.Addr().String()})
+	shipper, err := NewLogShipper(config, testLogMeta, "", logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer shipper.Close()
+
+	stdout := shipper.Writer("stdout")
+	stderr := shipper.Writer("stderr")
+	fmt.Fprint(stdout, "hello\nwor")
+	fmt.Fprint(stdout, "ld\r\n")
+	fmt.Fprintln(stderr, "oops")
+
+	expected := []struct{ stream, message string }{
+		{"stdout", "hello"},
+		{"stdout", "world"},
+		{"stderr", "oops"},
+	}
+	for _, e := range expected {
+		select {
+		case line := <-linesCh:
+			checkLine(t, line, e.stream, e.message)
+		case <-time.After(time.Duration(testutil.TestMultiplier()*5) * time.Second):
+			t.Fatalf("timeout waiting for %q", e.message)
+		}
+	}
+}
+
+func TestLogShipper_UDP(t *testing.T) {
+	t.Parallel()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer conn.Close()
+
+	config := testLogConfig(&structs.LogShipper{Type: "udp", Address: conn.LocalAddr().String()})
+	shipper, err := NewLogShipper(config, testLogMeta, "", logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer shipper.Close()
+
+	fmt.Fprintln(shipper.Writer("stdout"), "hello")
+
+	conn.SetReadDeadline(time.Now().Add(time.Duration(testutil.TestMultiplier()*5) * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var line LogLine
+	if err := json.Unmarshal(buf[:n], &line); err != nil {
+		t.Fatalf("invalid datagram %q: %v", buf[:n], err)
+	}
+	checkLine(t, &line, "stdout", "hello")
+}
+
+func TestLogShipper_HTTP(t *testing.T) {
+	t.Parallel()
+	var lock sync.Mutex
+	var batches [][]*LogLine
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		var batch []*LogLine
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("invalid batch: %v", err)
+		}
+
+		lock.Lock()
+		batches = append(batches, batch)
+		lock.Unlock()
+	}))
+	defer ts.Close()
+
+	config := testLogConfig(&structs.LogShipper{Type: "http", Address: ts.URL})
+	shipper, err := NewLogShipper(config, testLogMeta, "", logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	w := shipper.Writer("stdout")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+
+	// Closing flushes the buffered lines
+	shipper.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	var lines []*LogLine
+	for _, batch := range batches {
+		if len(batch) > 4 {
+			t.Fatalf("expected batches of at most 4 lines; got %d", len(batch))
+		}
+		lines = append(lines, batch...)
+	}
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 lines; got %d", len(lines))
+	}
+	for i, line := range lines {
+		checkLine(t, line, "stdout", fmt.Sprintf("line %d", i))
+	}
+}
+
+func TestLogShipper_File(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "logshipper")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testLogConfig(&structs.LogShipper{Type: "file", Address: "journal/task.journal"})
+	shipper, err := NewLogShipper(config, testLogMeta, dir, logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	fmt.Fprintln(shipper.Writer("stderr"), "oops")
+
+	// The partial line is shipped on close
+	fmt.Fprint(shipper.Writer("stdout"), "bye")
+	shipper.Close()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "journal", "task.journal.0"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	entries := strings.Split(strings.TrimSuffix(string(data), "\n\n"), "\n\n")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries; got %d:\n%s", len(entries), data)
+	}
+
+	for i, e := range []struct{ message, priority string }{{"oops", "3"}, {"bye", "6"}} {
+		fields := make(map[string]string)
+		for _, f := range strings.Split(entries[i], "\n") {
+			parts := strings.SplitN(f, "=", 2)
+			if len(parts) != 2 {
+				t.Fatalf("invalid field %q", f)
+			}
+			fields[parts[0]] = parts[1]
+		}
+
+		if fields["MESSAGE"] != e.message || fields["PRIORITY"] != e.priority {
+			t.Fatalf("unexpected entry %d: %v", i, fields)
+		}
+		if fields["NOMAD_ALLOC_ID"] != "alloc" || fields["NOMAD_JOB_ID"] != "job" ||
+			fields["NOMAD_NAMESPACE"] != "default" || fields["SYSLOG_IDENTIFIER"] != "task" {
+			t.Fatalf("entry %d not tagged with the metadata: %v", i, fields)
+		}
+		if fields["__REALTIME_TIMESTAMP"] == "" {
+			t.Fatalf("entry %d has no timestamp", i)
+		}
+	}
+}
+
+func TestLogShipper_FailingSinkDropsLines(t *testing.T) {
+	t.Parallel()
+
+	// Reserve a port nothing listens on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	config := testLogConfig(&structs.LogShipper{Type: "tcp", Address: addr})
+	shipper, err := NewLogShipper(config, testLogMeta, "", logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer shipper.Close()
+
+	// Writing more lines than buffered must not block once the sink is
+	// failing
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		w := shipper.Writer("stdout")
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+		}
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Duration(testutil.TestMultiplier()*10) * time.Second):
+		t.Fatalf("writes blocked on a failing sink")
+	}
+}
+
+func TestLogShipper_Backpressure(t *testing.T) {
+	// Not parallel as it registers a sink
+	releaseCh := make(chan struct{})
+	var lock sync.Mutex
+	var lines int
+	sink := &testSink{sendFn: func(batch []*LogLine) error {
+		<-releaseCh
+		lock.Lock()
+		lines += len(batch)
+		lock.Unlock()
+		return nil
+	}}
+	BuiltinLogSinks["test_backpressure"] = func(*LogSinkContext) (LogSink, error) { return sink, nil }
+	defer delete(BuiltinLogSinks, "test_backpressure")
+
+	config := testLogConfig(&structs.LogShipper{Type: "test_backpressure"})
+	shipper, err := NewLogShipper(config, testLogMeta, "", logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		w := shipper.Writer("stdout")
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(w, "line %d\n", i)
+		}
+	}()
+
+	// Writes block while the sink is slow
+	select {
+	case <-doneCh:
+		t.Fatalf("writes didn't block on a slow sink")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(releaseCh)
+	select {
+	case <-doneCh:
+	case <-time.After(time.Duration(testutil.TestMultiplier()*5) * time.Second):
+		t.Fatalf("writes still blocked")
+	}
+	shipper.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if lines != 100 {
+		t.Fatalf("expected 100 lines to be shipped; got %d", lines)
+	}
+}
+
+func TestLogShipper_UnknownType(t *testing.T) {
+	t.Parallel()
+	config := testLogConfig(&structs.LogShipper{Type: "foo", Address: "bar"})
+	if _, err := NewLogShipper(config, testLogMeta, "", logger); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestFileRotator_Shipper(t *testing.T) {
+	// Not parallel as it registers a sink
+	path, err := ioutil.TempDir("", pathPrefix)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	var lines []string
+	sink := &testSink{sendFn: func(batch []*LogLine) error {
+		for _, line := range batch {
+			lines = append(lines, line.Message)
+		}
+		return nil
+	}}
+	BuiltinLogSinks["test"] = func(*LogSinkContext) (LogSink, error) { return sink, nil }
+	defer delete(BuiltinLogSinks, "test")
+
+	shipper, err := NewLogShipper(testLogConfig(&structs.LogShipper{Type: "test"}), testLogMeta, path, logger)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	f, err := NewFileRotator(path, baseFileName, 10, 10, logger)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	f.SetShipper(shipper.Writer("stdout"))
+
+	// The lines are both rotated and shipped
+	if _, err := f.Write([]byte("abcdefgh\nij\n")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	f.Close()
+	shipper.Close()
+
+	if len(lines) != 2 || lines[0] != "abcdefgh" || lines[1] != "ij" {
+		t.Fatalf("unexpected shipped lines: %v", lines)
+	}
+	if _, err := os.Stat(filepath.Join(path, "redis.stdout.1")); err != nil {
+		t.Fatalf("expected the file to be rotated: %v", err)
+	}
+}
+
+type testSink struct {
+	sendFn func([]*LogLine) error
+}
+
+func (s *testSink) Send(lines []*LogLine) error {
+	return s.sendFn(lines)
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
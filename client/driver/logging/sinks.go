@@ -0,0 +1,203 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// sinkDialTimeout is the timeout to connect to network sinks
+	sinkDialTimeout = 5 * time.Second
+
+	// sinkWriteTimeout is the timeout to send a batch to network sinks
+	sinkWriteTimeout = 10 * time.Second
+)
+
+// netSink ships lines as line-delimited JSON over TCP or as a JSON datagram
+// per line over UDP.
+type netSink struct {
+	network string
+	address string
+	conn    net.Conn
+}
+
+func newNetSink(ctx *LogSinkContext) (LogSink, error) {
+	return &netSink{
+		network: ctx.Config.Type,
+		address: ctx.Config.Address,
+	}, nil
+}
+
+func (s *netSink) Send(lines []*LogLine) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, sinkDialTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if err := s.write(lines); err != nil {
+		// Reconnect on the next attempt
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *netSink) write(lines []*LogLine) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(sinkWriteTimeout)); err != nil {
+		return err
+	}
+
+	// Datagrams are written line by line while streams are buffered
+	var w io.Writer = s.conn
+	var bw *bufio.Writer
+	if s.network == structs.LogShipperTypeTCP {
+		bw = bufio.NewWriter(s.conn)
+		w = bw
+	}
+
+	for _, line := range lines {
+		buf, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if bw != nil {
+		return bw.Flush()
+	}
+	return nil
+}
+
+func (s *netSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// fileSink writes lines to a rotated file in the log directory of the task
+// using the journald export format.
+type fileSink struct {
+	rotator *FileRotator
+}
+
+func newFileSink(ctx *LogSinkContext) (LogSink, error) {
+	path := filepath.Join(ctx.LogDir, ctx.Config.Address)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, err
+	}
+
+	fileSize := int64(ctx.LogConfig.MaxFileSizeMB * 1024 * 1024)
+	rotator, err := NewFileRotator(filepath.Dir(path), filepath.Base(path),
+		ctx.LogConfig.MaxFiles, fileSize, ctx.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is rotated like the logs of the task but has the total size to
+	// itself
+	rotator.RotationInterval = ctx.LogConfig.RotationInterval
+	rotator.Compress = ctx.LogConfig.Compress
+	rotator.MaxTotalBytes = int64(ctx.LogConfig.MaxTotalSizeMB * 1024 * 1024)
+	return &fileSink{rotator: rotator}, nil
+}
+
+func (s *fileSink) Send(lines []*LogLine) error {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		writeJournalEntry(&buf, line)
+	}
+	_, err := s.rotator.Write(buf.Bytes())
+	return err
+}
+
+func (s *fileSink) Close() error {
+	s.rotator.Close()
+	return nil
+}
+
+// writeJournalEntry writes a line as an entry of the journald export format
+func writeJournalEntry(buf *bytes.Buffer, line *LogLine) {
+	// Use the syslog priorities journald gives to the streams of services
+	priority := "6"
+	if line.Stream == "stderr" {
+		priority = "3"
+	}
+
+	fields := [][2]string{
+		{"__REALTIME_TIMESTAMP", strconv.FormatInt(line.Timestamp.UnixNano()/int64(time.Microsecond), 10)},
+		{"PRIORITY", priority},
+		{"SYSLOG_IDENTIFIER", line.Task},
+		{"MESSAGE", line.Message},
+		{"NOMAD_STREAM", line.Stream},
+		{"NOMAD_ALLOC_ID", line.AllocID},
+		{"NOMAD_JOB_ID", line.JobID},
+		{"NOMAD_GROUP_NAME", line.TaskGroup},
+		{"NOMAD_TASK_NAME", line.Task},
+		{"NOMAD_NAMESPACE", line.Namespace},
+	}
+	for _, f := range fields {
+		buf.WriteString(f[0])
+		buf.WriteByte('=')
+		buf.WriteString(f[1])
+		buf.WriteByte('\n')
+	}
+
+	// Entries are separated by an empty line
+	buf.WriteByte('\n')
+}
+
+// httpSink POSTs batches of lines as a JSON array
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPSink(ctx *LogSinkContext) (LogSink, error) {
+	return &httpSink{
+		url:    ctx.Config.Address,
+		client: &http.Client{Timeout: sinkWriteTimeout},
+	}, nil
+}
+
+func (s *httpSink) Send(lines []*LogLine) error {
+	buf, err := json.Marshal(lines)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 	syslog "github.com/RackSec/srslog"
 	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver/env"
 	cstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -29,6 +30,10 @@ type LogCollectorContext struct {
 	// LogConfig provides configuration related to log rotation
 	LogConfig *structs.LogConfig
 
+	// TaskEnv is the environment of the task used to tag the shipped log
+	// lines
+	TaskEnv *env.TaskEnv
+
 	// PortUpperBound is the upper bound of the ports that we can use to start
 	// the syslog server
 	PortUpperBound uint
@@ -60,6 +65,7 @@ type SyslogCollector struct {
 
 	lro        *FileRotator
 	lre        *FileRotator
+	shipper    *LogShipper
 	server     *SyslogServer
 	syslogChan chan *SyslogMessage
 	taskDir    string
@@ -98,6 +104,7 @@ func (s *SyslogCollector) LaunchCollector(ctx *LogCollectorContext) (*SyslogColl
 	if err != nil {
 		return nil, err
 	}
+	lro.SetLogConfig(ctx.LogConfig)
 	s.lro = lro
 
 	lre, err := NewFileRotator(logdir, fmt.Sprintf("%v.stderr", ctx.TaskName),
@@ -105,8 +112,20 @@ func (s *SyslogCollector) LaunchCollector(ctx *LogCollectorContext) (*SyslogColl
 	if err != nil {
 		return nil, err
 	}
+	lre.SetLogConfig(ctx.LogConfig)
 	s.lre = lre
 
+	// Ship what is written to the log files if the task requests it
+	if len(ctx.LogConfig.Shippers) != 0 {
+		shipper, err := NewLogShipper(ctx.LogConfig, NewLogMeta(ctx.TaskEnv), logdir, s.logger)
+		if err != nil {
+			return nil, fmt.Errorf("error creating log shipper for %q: %v", ctx.TaskName, err)
+		}
+		s.shipper = shipper
+		lro.SetShipper(shipper.Writer("stdout"))
+		lre.SetShipper(shipper.Writer("stderr"))
+	}
+
 	go s.collectLogs(lre, lro)
 	syslogAddr := fmt.Sprintf("%s://%s", l.Addr().Network(), l.Addr().String())
 	return &SyslogCollectorState{Addr: syslogAddr}, nil
@@ -131,6 +150,9 @@ func (s *SyslogCollector) Exit() error {
 	s.server.Shutdown()
 	s.lre.Close()
 	s.lro.Close()
+	if s.shipper != nil {
+		s.shipper.Close()
+	}
 	return nil
 }
 
@@ -140,14 +162,12 @@ func (s *SyslogCollector) UpdateLogConfig(logConfig *structs.LogConfig) error {
 	if s.lro == nil {
 		return fmt.Errorf("log rotator for stdout doesn't exist")
 	}
-	s.lro.MaxFiles = logConfig.MaxFiles
-	s.lro.FileSize = int64(logConfig.MaxFileSizeMB * 1024 * 1024)
+	s.lro.SetLogConfig(logConfig)
 
 	if s.lre == nil {
 		return fmt.Errorf("log rotator for stderr doesn't exist")
 	}
-	s.lre.MaxFiles = logConfig.MaxFiles
-	s.lre.FileSize = int64(logConfig.MaxFileSizeMB * 1024 * 1024)
+	s.lre.SetLogConfig(logConfig)
 	return nil
 }
 
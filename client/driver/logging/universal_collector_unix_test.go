@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestSyslogCollector_Shipper(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "collector")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := &LogCollectorContext{
+		TaskName: "web",
+		AllocDir: &allocdir.AllocDir{
+			TaskDirs: map[string]*allocdir.TaskDir{
+				"web": {Dir: dir, LogDir: dir},
+			},
+		},
+		LogConfig: testLogConfig(&structs.LogShipper{Type: "file", Address: "web.journal"}),
+		TaskEnv:   env.NewTaskEnv(map[string]string{env.AllocID: "alloc", env.TaskName: "web"}, nil),
+	}
+
+	c := NewSyslogCollector(logger)
+	state, err := c.LaunchCollector(ctx)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	conn, err := net.Dial("unix", strings.TrimPrefix(state.Addr, "unix://"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer conn.Close()
+
+	// Docker sends the lines with the syslog priority of the stream
+	fmt.Fprintln(conn, "<30>Jul  6 15:13:11 docker/9648c64f5037[16200]: hello")
+	fmt.Fprintln(conn, "<27>Jul  6 15:13:11 docker/9648c64f5037[16200]: oops")
+
+	testutil.WaitForResult(func() (bool, error) {
+		for _, name := range []string{"web.stdout.0", "web.stderr.0"} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return false, err
+			}
+			if len(data) == 0 {
+				return false, fmt.Errorf("%s is empty", name)
+			}
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The shipper is flushed on exit
+	c.Exit()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "web.journal.0"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, expected := range []string{
+		"MESSAGE=hello\nNOMAD_STREAM=stdout\nNOMAD_ALLOC_ID=alloc\n",
+		"MESSAGE=oops\nNOMAD_STREAM=stderr\nNOMAD_ALLOC_ID=alloc\n",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Fatalf("expected %q in shipped lines:\n%s", expected, data)
+		}
+	}
+}
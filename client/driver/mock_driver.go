@@ -121,8 +121,9 @@ func NewMockDriver(ctx *DriverContext) Driver {
 
 func (d *MockDriver) Abilities() DriverAbilities {
 	return DriverAbilities{
-		SendSignals: false,
-		Exec:        true,
+		SendSignals:      false,
+		Exec:             true,
+		NetworkIsolation: true,
 	}
 }
 
@@ -366,6 +367,26 @@ func (h *mockDriverHandle) Exec(ctx context.Context, cmd string, args []string)
 	return []byte(fmt.Sprintf("Exec(%q, %q)", cmd, args)), 0, nil
 }
 
+// ExecStreaming writes the command to stdout and then copies stdin to stdout
+// until stdin is closed. The command "exit <code>" exits with the code
+// instead.
+func (h *mockDriverHandle) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	h.logger.Printf("[DEBUG] driver.mock: ExecStreaming(%q, %v)", opts.Command, opts.Tty)
+	if len(opts.Command) == 2 && opts.Command[0] == "exit" {
+		return strconv.Atoi(opts.Command[1])
+	}
+
+	if _, err := fmt.Fprintf(opts.Stdout, "ExecStreaming(%q)\n", opts.Command); err != nil {
+		return 0, err
+	}
+	if opts.Stdin != nil {
+		if _, err := io.Copy(opts.Stdout, opts.Stdin); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
 // TODO Implement when we need it.
 func (h *mockDriverHandle) Update(task *structs.Task) error {
 	h.killTimeout = task.KillTimeout
@@ -449,8 +470,23 @@ func (h *mockDriverHandle) handleLogging() {
 		h.logger.Printf("[ERR] mock_driver: failed to setup file rotator: %v", err)
 		return
 	}
+	lro.SetLogConfig(h.task.LogConfig)
 	defer lro.Close()
 
+	// Ship the logs if requested
+	if len(h.task.LogConfig.Shippers) != 0 {
+		shipper, err := logging.NewLogShipper(h.task.LogConfig, logging.NewLogMeta(h.ctx.TaskEnv),
+			h.ctx.TaskDir.LogDir, h.logger)
+		if err != nil {
+			h.exitErr = err
+			close(h.doneCh)
+			h.logger.Printf("[ERR] mock_driver: failed to setup log shipper: %v", err)
+			return
+		}
+		defer shipper.Close()
+		lro.SetShipper(shipper.Writer("stdout"))
+	}
+
 	// Do initial write to stdout.
 	if _, err := io.WriteString(lro, h.stdoutString); err != nil {
 		h.exitErr = err
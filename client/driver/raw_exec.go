@@ -82,8 +82,9 @@ func (d *RawExecDriver) Validate(config map[string]interface{}) error {
 
 func (d *RawExecDriver) Abilities() DriverAbilities {
 	return DriverAbilities{
-		SendSignals: true,
-		Exec:        true,
+		SendSignals:      true,
+		Exec:             true,
+		NetworkIsolation: true,
 	}
 }
 
@@ -150,10 +151,11 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespo
 	}
 
 	execCmd := &executor.ExecCommand{
-		Cmd:            command,
-		Args:           driverConfig.Args,
-		User:           task.User,
-		TaskKillSignal: taskKillSignal,
+		Cmd:              command,
+		Args:             driverConfig.Args,
+		User:             task.User,
+		TaskKillSignal:   taskKillSignal,
+		NetworkIsolation: ctx.NetworkIsolation,
 	}
 	ps, err := exec.LaunchCmd(execCmd)
 	if err != nil {
@@ -263,6 +265,10 @@ func (h *rawExecHandle) Exec(ctx context.Context, cmd string, args []string) ([]
 	return executor.ExecScript(ctx, h.taskDir.Dir, h.taskEnv, nil, cmd, args)
 }
 
+func (h *rawExecHandle) ExecStreaming(ctx context.Context, opts *dstructs.ExecStreamingOptions) (int, error) {
+	return executor.ExecStreaming(ctx, h.taskDir.Dir, h.taskEnv, nil, opts)
+}
+
 func (h *rawExecHandle) Signal(s os.Signal) error {
 	return h.executor.Signal(s)
 }
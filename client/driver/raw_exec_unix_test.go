@@ -3,6 +3,8 @@
 package driver
 
 import (
+	"bytes"
+	"context"
 	"io/ioutil"
 	"path/filepath"
 	"runtime"
@@ -11,9 +13,11 @@ import (
 	"testing"
 	"time"
 
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	"github.com/hashicorp/nomad/helper/testtask"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRawExecDriver_User(t *testing.T) {
@@ -130,3 +134,63 @@ done
 		t.Fatalf("Command outputted %v; want %v", act, exp)
 	}
 }
+
+func TestRawExecDriver_ExecStreaming(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	task := &structs.Task{
+		Name:   "sleep",
+		Driver: "raw_exec",
+		Config: map[string]interface{}{
+			"command": testtask.Path(),
+			"args":    []string{"sleep", "45s"},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: basicResources,
+	}
+	testtask.SetTaskEnv(task)
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewRawExecDriver(ctx.DriverCtx)
+
+	_, err := d.Prestart(ctx.ExecCtx, task)
+	require.NoError(err)
+	resp, err := d.Start(ctx.ExecCtx, task)
+	require.NoError(err)
+	defer resp.Handle.Kill()
+
+	executor, ok := resp.Handle.(StreamingExecutor)
+	require.True(ok)
+
+	// Without a terminal the output streams are separate
+	var stdout, stderr bytes.Buffer
+	code, err := executor.ExecStreaming(context.Background(), &dstructs.ExecStreamingOptions{
+		Command: []string{"/bin/sh", "-c", "cat; echo failed >&2; exit 3"},
+		Stdin:   strings.NewReader("hello\n"),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
+	require.NoError(err)
+	require.Equal(3, code)
+	require.Equal("hello\n", stdout.String())
+	require.Equal("failed\n", stderr.String())
+
+	// With a terminal the command runs with the requested size
+	stdout.Reset()
+	resizeCh := make(chan dstructs.TerminalSize, 1)
+	resizeCh <- dstructs.TerminalSize{Height: 40, Width: 120}
+	code, err = executor.ExecStreaming(context.Background(), &dstructs.ExecStreamingOptions{
+		Command:  []string{"/bin/sh", "-c", "sleep 1; tty; stty size"},
+		Tty:      true,
+		Stdout:   &stdout,
+		Stderr:   &stderr,
+		ResizeCh: resizeCh,
+	})
+	require.NoError(err)
+	require.Zero(code)
+	require.Contains(stdout.String(), "/dev/")
+	require.Contains(stdout.String(), "40 120")
+}
@@ -1,3 +1,4 @@
+//go:build linux
 // +build linux
 
 package driver
@@ -22,7 +23,6 @@ import (
 	"time"
 
 	appcschema "github.com/appc/spec/schema"
-	rktv1 "github.com/rkt/rkt/api/v1"
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/go-version"
@@ -119,8 +119,23 @@ type rktPID struct {
 	MaxKillTimeout time.Duration
 }
 
+// rktPod is the subset of the pod status printed by `rkt status --format=json`
+// that the driver uses.
+type rktPod struct {
+	UUID     string          `json:"name"`
+	State    string          `json:"state"`
+	Networks []rktPodNetwork `json:"networks,omitempty"`
+}
+
+// rktPodNetwork describes a network the pod is attached to.
+type rktPodNetwork struct {
+	NetName string `json:"netName"`
+	IfName  string `json:"ifName"`
+	IP      net.IP `json:"ip"`
+}
+
 // Retrieve pod status for the pod with the given UUID.
-func rktGetStatus(uuid string, logger *log.Logger) (*rktv1.Pod, error) {
+func rktGetStatus(uuid string, logger *log.Logger) (*rktPod, error) {
 	statusArgs := []string{
 		"status",
 		"--format=json",
@@ -140,7 +155,7 @@ func rktGetStatus(uuid string, logger *log.Logger) (*rktv1.Pod, error) {
 		logger.Printf("[DEBUG] driver.rkt: status error output for UUID %s: %q", uuid, elide(errBuf))
 		return nil, fmt.Errorf("%s. stderr: %q", err, elide(errBuf))
 	}
-	var status rktv1.Pod
+	var status rktPod
 	if err := json.Unmarshal(outBuf.Bytes(), &status); err != nil {
 		return nil, err
 	}
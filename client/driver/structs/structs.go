@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"io"
 	"time"
 )
 
@@ -65,3 +66,32 @@ type ExecutorConfig struct {
 	// LogLevel is the level of the logs to putout
 	LogLevel string
 }
+
+// ExecStreamingOptions are the options of a command executed interactively
+// inside a task.
+type ExecStreamingOptions struct {
+	// Command is the command and its arguments.
+	Command []string
+
+	// Tty allocates a pseudo terminal for the command. The output of the
+	// command is written to Stdout only.
+	Tty bool
+
+	// Stdin is the input of the command. It may be nil if the command has no
+	// input.
+	Stdin io.Reader
+
+	// Stdout and Stderr receive the output of the command.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ResizeCh receives the new sizes of the terminal. It is only used when
+	// a pseudo terminal is allocated.
+	ResizeCh <-chan TerminalSize
+}
+
+// TerminalSize is the size of a terminal in characters.
+type TerminalSize struct {
+	Height uint16
+	Width  uint16
+}
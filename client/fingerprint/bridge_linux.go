@@ -0,0 +1,66 @@
+package fingerprint
+
+import (
+	"log"
+	"os"
+	"os/exec"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// bridgeModulePath exists when the bridge kernel module is loaded or
+	// built in
+	bridgeModulePath = "/sys/module/bridge"
+)
+
+// BridgeFingerprint is used to fingerprint whether allocations can be run in
+// the bridge network mode: the client must be root, the bridge module must be
+// available and iproute2 and iptables installed.
+type BridgeFingerprint struct {
+	StaticFingerprinter
+	logger *log.Logger
+
+	// modulePath, euid and lookPath can be overridden by tests
+	modulePath string
+	euid       func() int
+	lookPath   func(string) (string, error)
+}
+
+// NewBridgeFingerprint is used to create a bridge fingerprint
+func NewBridgeFingerprint(logger *log.Logger) Fingerprint {
+	f := &BridgeFingerprint{
+		logger:     logger,
+		modulePath: bridgeModulePath,
+		euid:       os.Geteuid,
+		lookPath:   exec.LookPath,
+	}
+	return f
+}
+
+func (f *BridgeFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	attr := structs.NodeNetworkModeAttrPrefix + structs.NetworkModeBridge
+	if f.euid() != 0 {
+		resp.RemoveAttribute(attr)
+		return nil
+	}
+
+	if _, err := os.Stat(f.modulePath); err != nil {
+		f.logger.Printf("[DEBUG] fingerprint.bridge: bridge kernel module unavailable: %v", err)
+		resp.RemoveAttribute(attr)
+		return nil
+	}
+
+	for _, bin := range []string{"ip", "iptables"} {
+		if _, err := f.lookPath(bin); err != nil {
+			f.logger.Printf("[DEBUG] fingerprint.bridge: %s is not installed", bin)
+			resp.RemoveAttribute(attr)
+			return nil
+		}
+	}
+
+	resp.AddAttribute(attr, "1")
+	resp.Detected = true
+	return nil
+}
@@ -0,0 +1,66 @@
+package fingerprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridgeFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomadtest-bridge")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		Name     string
+		Root     bool
+		Module   bool
+		Missing  string
+		Detected bool
+	}{
+		{Name: "available", Root: true, Module: true, Detected: true},
+		{Name: "not root", Module: true},
+		{Name: "no module", Root: true},
+		{Name: "no iptables", Root: true, Module: true, Missing: "iptables"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			f := NewBridgeFingerprint(testLogger()).(*BridgeFingerprint)
+			f.modulePath = filepath.Join(dir, "missing")
+			if c.Module {
+				f.modulePath = dir
+			}
+			f.euid = func() int {
+				if c.Root {
+					return 0
+				}
+				return 1000
+			}
+			f.lookPath = func(bin string) (string, error) {
+				if bin == c.Missing {
+					return "", fmt.Errorf("not found")
+				}
+				return "/usr/sbin/" + bin, nil
+			}
+
+			var resp cstructs.FingerprintResponse
+			err := f.Fingerprint(&cstructs.FingerprintRequest{Config: config.DefaultConfig()}, &resp)
+			require.NoError(t, err)
+			require.Equal(t, c.Detected, resp.Detected)
+			if c.Detected {
+				require.Equal(t, "1", resp.Attributes["network.mode.bridge"])
+			} else {
+				v, ok := resp.Attributes["network.mode.bridge"]
+				require.True(t, ok)
+				require.Empty(t, v)
+			}
+		})
+	}
+}
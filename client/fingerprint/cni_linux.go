@@ -0,0 +1,100 @@
+package fingerprint
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containernetworking/cni/libcni"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// CNIFingerprint is used to fingerprint the CNI networks allocations can be
+// attached to: the client must be root and the networks are configured in
+// its CNI configuration directory.
+type CNIFingerprint struct {
+	logger *log.Logger
+
+	// networks are the networks found by the last fingerprint
+	networks map[string]struct{}
+
+	// euid can be overridden by tests
+	euid func() int
+}
+
+// NewCNIFingerprint is used to create a CNI fingerprint
+func NewCNIFingerprint(logger *log.Logger) Fingerprint {
+	f := &CNIFingerprint{
+		logger: logger,
+		euid:   os.Geteuid,
+	}
+	return f
+}
+
+func (f *CNIFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	networks := make(map[string]struct{})
+	if f.euid() == 0 {
+		var err error
+		networks, err = cniNetworks(req.Config.CNIConfigDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name := range f.networks {
+		if _, ok := networks[name]; !ok {
+			resp.RemoveAttribute(cniNetworkAttr(name))
+		}
+	}
+	for name := range networks {
+		resp.AddAttribute(cniNetworkAttr(name), "1")
+	}
+
+	f.networks = networks
+	resp.Detected = len(networks) != 0
+	return nil
+}
+
+// Periodic determines the interval at which the CNI configuration directory
+// is read again, picking up the networks that were added or removed.
+func (f *CNIFingerprint) Periodic() (bool, time.Duration) {
+	return true, 15 * time.Second
+}
+
+// cniNetworkAttr returns the node attribute of the network mode of a CNI
+// network
+func cniNetworkAttr(name string) string {
+	return structs.NodeNetworkModeAttrPrefix + structs.NetworkModeCNIPrefix + name
+}
+
+// cniNetworks returns the names of the networks configured in the CNI
+// configuration directory.
+func cniNetworks(dir string) (map[string]struct{}, error) {
+	files, err := libcni.ConfFiles(dir, []string{".conf", ".conflist", ".json"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CNI configurations in %q: %v", dir, err)
+	}
+
+	networks := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		var name string
+		if filepath.Ext(file) == ".conflist" {
+			list, err := libcni.ConfListFromFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load CNI configuration %q: %v", file, err)
+			}
+			name = list.Name
+		} else {
+			conf, err := libcni.ConfFromFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load CNI configuration %q: %v", file, err)
+			}
+			name = conf.Network.Name
+		}
+		networks[name] = struct{}{}
+	}
+	return networks, nil
+}
@@ -0,0 +1,56 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCNIFingerprint(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomadtest-cni")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	conf := `{"cniVersion": "0.3.1", "name": "mynet", "type": "bridge"}`
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "10-mynet.conf"), []byte(conf), 0644))
+	conflist := `{"cniVersion": "0.4.0", "name": "other", "plugins": [{"type": "macvlan"}, {"type": "portmap"}]}`
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "20-other.conflist"), []byte(conflist), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a network"), 0644))
+
+	cfg := config.DefaultConfig()
+	cfg.CNIConfigDir = dir
+	f := NewCNIFingerprint(testLogger()).(*CNIFingerprint)
+	f.euid = func() int { return 0 }
+
+	var resp cstructs.FingerprintResponse
+	require.NoError(f.Fingerprint(&cstructs.FingerprintRequest{Config: cfg}, &resp))
+	require.True(resp.Detected)
+	require.Equal("1", resp.Attributes["network.mode.cni/mynet"])
+	require.Equal("1", resp.Attributes["network.mode.cni/other"])
+
+	// Networks that were removed are removed from the node
+	require.NoError(os.Remove(filepath.Join(dir, "20-other.conflist")))
+	resp = cstructs.FingerprintResponse{}
+	require.NoError(f.Fingerprint(&cstructs.FingerprintRequest{Config: cfg}, &resp))
+	require.True(resp.Detected)
+	require.Equal("1", resp.Attributes["network.mode.cni/mynet"])
+	v, ok := resp.Attributes["network.mode.cni/other"]
+	require.True(ok)
+	require.Empty(v)
+
+	// Only root can create network namespaces
+	f.euid = func() int { return 1000 }
+	resp = cstructs.FingerprintResponse{}
+	require.NoError(f.Fingerprint(&cstructs.FingerprintRequest{Config: cfg}, &resp))
+	require.False(resp.Detected)
+	v, ok = resp.Attributes["network.mode.cni/mynet"]
+	require.True(ok)
+	require.Empty(v)
+}
@@ -3,6 +3,7 @@ package fingerprint
 import (
 	"fmt"
 	"log"
+	"runtime"
 
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/helper/stats"
@@ -23,9 +24,31 @@ func NewCPUFingerprint(logger *log.Logger) Fingerprint {
 
 func (f *CPUFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
 	cfg := req.Config
+
+	// Detect the cores that can be dedicated to tasks
+	cores, err := platformCoreIDs()
+	if err != nil {
+		f.logger.Printf("[WARN] fingerprint.cpu: failed to detect usable cores: %v", err)
+		cores = defaultCoreIDs()
+	}
+	reservable := len(cores)
+	if req.Node != nil && req.Node.Reserved != nil {
+		reserved := make(map[uint16]struct{}, len(req.Node.Reserved.CoreIDs))
+		for _, id := range req.Node.Reserved.CoreIDs {
+			reserved[id] = struct{}{}
+		}
+		for _, id := range cores {
+			if _, ok := reserved[id]; ok {
+				reservable--
+			}
+		}
+	}
+	resp.AddAttribute("cpu.reservablecores", fmt.Sprintf("%d", reservable))
+
 	setResourcesCPU := func(totalCompute int) {
 		resp.Resources = &structs.Resources{
-			CPU: totalCompute,
+			CPU:     totalCompute,
+			CoreIDs: cores,
 		}
 	}
 
@@ -72,3 +95,12 @@ func (f *CPUFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cst
 
 	return nil
 }
+
+// defaultCoreIDs returns the IDs of all the cores of the host.
+func defaultCoreIDs() []uint16 {
+	cores := make([]uint16, runtime.NumCPU())
+	for i := range cores {
+		cores[i] = uint16(i)
+	}
+	return cores
+}
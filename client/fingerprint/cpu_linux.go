@@ -0,0 +1,30 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+var (
+	// cpusetPaths are the files listing the cores usable by Nomad, in order
+	// of preference. The cores of the cpuset cgroup exclude those isolated
+	// from the processes of the host.
+	cpusetPaths = []string{
+		"/sys/fs/cgroup/cpuset/cpuset.effective_cpus",
+		"/sys/fs/cgroup/cpuset/cpuset.cpus",
+		"/sys/devices/system/cpu/online",
+	}
+)
+
+// platformCoreIDs returns the IDs of the cores usable on the host.
+func platformCoreIDs() ([]uint16, error) {
+	for _, path := range cpusetPaths {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		return structs.ParseCpuset(string(raw))
+	}
+	return defaultCoreIDs(), nil
+}
@@ -1,6 +1,7 @@
 package fingerprint
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/hashicorp/nomad/client/config"
@@ -47,6 +48,30 @@ func TestCPUFingerprint(t *testing.T) {
 	if response.Resources == nil || response.Resources.CPU == 0 {
 		t.Fatalf("Expected to find CPU Resources")
 	}
+	if len(response.Resources.CoreIDs) == 0 {
+		t.Fatalf("Expected to find usable cores")
+	}
+}
+
+func TestCPUFingerprint_ReservedCores(t *testing.T) {
+	f := NewCPUFingerprint(testLogger())
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+		Reserved: &structs.Resources{
+			CoreIDs: []uint16{0},
+		},
+	}
+
+	request := &cstructs.FingerprintRequest{Config: &config.Config{}, Node: node}
+	var response cstructs.FingerprintResponse
+	if err := f.Fingerprint(request, &response); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The reserved cores are still part of the node's resources but aren't
+	// reservable by tasks
+	cores := response.Resources.CoreIDs
+	assertNodeAttributeEquals(t, response.Attributes, "cpu.reservablecores", fmt.Sprintf("%d", len(cores)-1))
 }
 
 // TestCPUFingerprint_OverrideCompute asserts that setting cpu_total_compute in
@@ -0,0 +1,97 @@
+package fingerprint
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// devicesPeriod is the interval at which the devices are fingerprinted
+	// to detect devices that were added or became unhealthy.
+	devicesPeriod = 1 * time.Minute
+)
+
+// DeviceDetector detects a class of devices available on the host, such as
+// USB devices or SR-IOV virtual functions.
+type DeviceDetector interface {
+	// Name is the name of the detector, used when logging.
+	Name() string
+
+	// Detect returns the devices found on the host. Instances of the same
+	// vendor, type and name should be grouped into a single device.
+	Detect() ([]*structs.DeviceResource, error)
+}
+
+// DevicesFingerprint is used to fingerprint the devices available on the node
+// so that tasks can request them.
+type DevicesFingerprint struct {
+	logger    *log.Logger
+	detectors []DeviceDetector
+}
+
+// NewDevicesFingerprint is used to create a devices fingerprint using the
+// device detectors of the platform.
+func NewDevicesFingerprint(logger *log.Logger) Fingerprint {
+	return NewDevicesFingerprintWithDetectors(logger, platformDeviceDetectors())
+}
+
+// NewDevicesFingerprintWithDetectors is used to create a devices fingerprint
+// that uses the given device detectors.
+func NewDevicesFingerprintWithDetectors(logger *log.Logger, detectors []DeviceDetector) Fingerprint {
+	f := &DevicesFingerprint{
+		logger:    logger,
+		detectors: detectors,
+	}
+	return f
+}
+
+func (f *DevicesFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	var devices []*structs.DeviceResource
+	for _, d := range f.detectors {
+		found, err := d.Detect()
+		if err != nil {
+			f.logger.Printf("[WARN] fingerprint.devices: failed to detect %s devices: %v", d.Name(), err)
+			continue
+		}
+		devices = append(devices, found...)
+	}
+
+	// Remove the devices that are no longer found from the node
+	for attr := range req.Node.Attributes {
+		if strings.HasPrefix(attr, "device.") && strings.HasSuffix(attr, ".count") {
+			resp.RemoveAttribute(attr)
+		}
+	}
+
+	// Sort the devices so that the node only changes when they do
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID() < devices[j].ID()
+	})
+	for _, d := range devices {
+		sort.Slice(d.Instances, func(i, j int) bool {
+			return d.Instances[i].ID < d.Instances[j].ID
+		})
+		resp.AddAttribute(fmt.Sprintf("device.%s.count", d.ID()), fmt.Sprintf("%d", len(d.Instances)))
+	}
+
+	// The device list is always set, even when empty, so that devices that
+	// were removed from the host are no longer advertised
+	if devices == nil {
+		devices = []*structs.DeviceResource{}
+	}
+	resp.Resources = &structs.Resources{
+		Devices: devices,
+	}
+	resp.Detected = len(devices) != 0
+	return nil
+}
+
+func (f *DevicesFingerprint) Periodic() (bool, time.Duration) {
+	return true, devicesPeriod
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package fingerprint
+
+// platformDeviceDetectors returns the device detectors available on the
+// platform. Devices are only detected on Linux.
+func platformDeviceDetectors() []DeviceDetector {
+	return nil
+}
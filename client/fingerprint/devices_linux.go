@@ -0,0 +1,162 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// usbDevicesPath is the sysfs directory listing the USB devices.
+	usbDevicesPath = "/sys/bus/usb/devices"
+
+	// pciDevicesPath is the sysfs directory listing the PCI devices.
+	pciDevicesPath = "/sys/bus/pci/devices"
+
+	// usbHubClass is the USB device class of hubs, which aren't fingerprinted.
+	usbHubClass = "09"
+
+	// pciNetworkClassPrefix is the prefix of the PCI class of network
+	// controllers.
+	pciNetworkClassPrefix = "0x02"
+)
+
+// platformDeviceDetectors returns the device detectors available on Linux.
+func platformDeviceDetectors() []DeviceDetector {
+	return []DeviceDetector{
+		&usbDeviceDetector{root: usbDevicesPath},
+		&sriovDeviceDetector{root: pciDevicesPath},
+	}
+}
+
+// usbDeviceDetector detects USB devices using sysfs. Devices are identified
+// by their hex vendor and product IDs, such as "046d/usb/c52b", and each
+// instance by its bus path.
+type usbDeviceDetector struct {
+	root string
+}
+
+func (d *usbDeviceDetector) Name() string {
+	return "usb"
+}
+
+func (d *usbDeviceDetector) Detect() ([]*structs.DeviceResource, error) {
+	entries, err := ioutil.ReadDir(d.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	grouped := newDeviceGroups()
+	for _, e := range entries {
+		dir := filepath.Join(d.root, e.Name())
+
+		// Interfaces of devices don't have vendor and product IDs
+		vendor := readSysfsValue(dir, "idVendor")
+		product := readSysfsValue(dir, "idProduct")
+		if vendor == "" || product == "" {
+			continue
+		}
+		if readSysfsValue(dir, "bDeviceClass") == usbHubClass {
+			continue
+		}
+
+		dev := grouped.add(vendor, "usb", product, e.Name())
+		if dev.Attributes == nil {
+			dev.Attributes = map[string]string{
+				"manufacturer": readSysfsValue(dir, "manufacturer"),
+				"product":      readSysfsValue(dir, "product"),
+			}
+		}
+	}
+	return grouped.devices, nil
+}
+
+// sriovDeviceDetector detects SR-IOV virtual functions using sysfs. Virtual
+// functions of network controllers have the "nic" type, others the "vf" type.
+// Devices are identified by their hex PCI vendor and device IDs, such as
+// "8086/nic/154c", and each instance by its PCI address.
+type sriovDeviceDetector struct {
+	root string
+}
+
+func (d *sriovDeviceDetector) Name() string {
+	return "sriov"
+}
+
+func (d *sriovDeviceDetector) Detect() ([]*structs.DeviceResource, error) {
+	entries, err := ioutil.ReadDir(d.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	grouped := newDeviceGroups()
+	for _, e := range entries {
+		dir := filepath.Join(d.root, e.Name())
+
+		// Only virtual functions link to their physical function
+		if _, err := os.Lstat(filepath.Join(dir, "physfn")); err != nil {
+			continue
+		}
+
+		vendor := strings.TrimPrefix(readSysfsValue(dir, "vendor"), "0x")
+		device := strings.TrimPrefix(readSysfsValue(dir, "device"), "0x")
+		if vendor == "" || device == "" {
+			continue
+		}
+
+		typ := "vf"
+		if strings.HasPrefix(readSysfsValue(dir, "class"), pciNetworkClassPrefix) {
+			typ = "nic"
+		}
+		grouped.add(vendor, typ, device, e.Name())
+	}
+	return grouped.devices, nil
+}
+
+// deviceGroups groups device instances by their vendor, type and name.
+type deviceGroups struct {
+	devices []*structs.DeviceResource
+	byID    map[string]*structs.DeviceResource
+}
+
+func newDeviceGroups() *deviceGroups {
+	return &deviceGroups{
+		byID: make(map[string]*structs.DeviceResource),
+	}
+}
+
+// add adds a healthy instance of the device, returning the device.
+func (g *deviceGroups) add(vendor, typ, name, instanceID string) *structs.DeviceResource {
+	dev := &structs.DeviceResource{Vendor: vendor, Type: typ, Name: name}
+	if existing, ok := g.byID[dev.ID()]; ok {
+		dev = existing
+	} else {
+		g.byID[dev.ID()] = dev
+		g.devices = append(g.devices, dev)
+	}
+
+	dev.Instances = append(dev.Instances, &structs.DeviceInstance{
+		ID:      instanceID,
+		Healthy: true,
+	})
+	return dev
+}
+
+// readSysfsValue returns the trimmed contents of the sysfs attribute file or
+// an empty string if it can't be read.
+func readSysfsValue(dir, name string) string {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,105 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeSysfsDevice creates a fake sysfs device directory with the given
+// attribute files.
+func writeSysfsDevice(t *testing.T, root, name string, attrs map[string]string) string {
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for k, v := range attrs {
+		if err := ioutil.WriteFile(filepath.Join(dir, k), []byte(v+"\n"), 0644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestUSBDeviceDetector(t *testing.T) {
+	require := require.New(t)
+	root, err := ioutil.TempDir("", "nomad-usb")
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	dongle := map[string]string{
+		"idVendor":     "046d",
+		"idProduct":    "c52b",
+		"bDeviceClass": "00",
+		"manufacturer": "Logitech",
+		"product":      "USB Receiver",
+	}
+	writeSysfsDevice(t, root, "1-1.2", dongle)
+	writeSysfsDevice(t, root, "1-1.3", dongle)
+	writeSysfsDevice(t, root, "usb1", map[string]string{
+		"idVendor":     "1d6b",
+		"idProduct":    "0002",
+		"bDeviceClass": usbHubClass,
+	})
+	writeSysfsDevice(t, root, "1-1.2:1.0", map[string]string{
+		"bInterfaceClass": "03",
+	})
+
+	d := &usbDeviceDetector{root: root}
+	devices, err := d.Detect()
+	require.NoError(err)
+	require.Len(devices, 1)
+	require.Equal("046d/usb/c52b", devices[0].ID())
+	require.Equal([]string{"1-1.2", "1-1.3"}, devices[0].InstanceIDs())
+	require.Equal("Logitech", devices[0].Attributes["manufacturer"])
+}
+
+func TestSRIOVDeviceDetector(t *testing.T) {
+	require := require.New(t)
+	root, err := ioutil.TempDir("", "nomad-pci")
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	pf := writeSysfsDevice(t, root, "0000:03:00.0", map[string]string{
+		"vendor": "0x8086",
+		"device": "0x1572",
+		"class":  "0x020000",
+	})
+	for _, name := range []string{"0000:03:02.0", "0000:03:02.1"} {
+		vf := writeSysfsDevice(t, root, name, map[string]string{
+			"vendor": "0x8086",
+			"device": "0x154c",
+			"class":  "0x020000",
+		})
+		require.NoError(os.Symlink(pf, filepath.Join(vf, "physfn")))
+	}
+	vf := writeSysfsDevice(t, root, "0000:04:01.0", map[string]string{
+		"vendor": "0x8086",
+		"device": "0x0435",
+		"class":  "0x0b4000",
+	})
+	require.NoError(os.Symlink(pf, filepath.Join(vf, "physfn")))
+
+	d := &sriovDeviceDetector{root: root}
+	devices, err := d.Detect()
+	require.NoError(err)
+	require.Len(devices, 2)
+	require.Equal("8086/nic/154c", devices[0].ID())
+	require.Equal([]string{"0000:03:02.0", "0000:03:02.1"}, devices[0].InstanceIDs())
+	require.Equal("8086/vf/0435", devices[1].ID())
+}
+
+func TestDeviceDetectors_MissingSysfs(t *testing.T) {
+	require := require.New(t)
+	for _, d := range []DeviceDetector{
+		&usbDeviceDetector{root: "/nonexistent/usb"},
+		&sriovDeviceDetector{root: "/nonexistent/pci"},
+	} {
+		devices, err := d.Detect()
+		require.NoError(err)
+		require.Empty(devices)
+	}
+}
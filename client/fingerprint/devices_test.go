@@ -0,0 +1,113 @@
+package fingerprint
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDeviceDetector is a device detector returning a fixed set of devices.
+type fakeDeviceDetector struct {
+	name    string
+	devices []*structs.DeviceResource
+	err     error
+}
+
+func (d *fakeDeviceDetector) Name() string {
+	return d.name
+}
+
+func (d *fakeDeviceDetector) Detect() ([]*structs.DeviceResource, error) {
+	return d.devices, d.err
+}
+
+func TestDevicesFingerprint(t *testing.T) {
+	require := require.New(t)
+	detectors := []DeviceDetector{
+		&fakeDeviceDetector{
+			name: "fake",
+			devices: []*structs.DeviceResource{
+				{
+					Vendor: "8086",
+					Type:   "nic",
+					Name:   "154c",
+					Instances: []*structs.DeviceInstance{
+						{ID: "0000:03:02.1", Healthy: true},
+						{ID: "0000:03:02.0", Healthy: true},
+					},
+				},
+				{
+					Vendor: "046d",
+					Type:   "usb",
+					Name:   "c52b",
+					Instances: []*structs.DeviceInstance{
+						{ID: "1-1.2", Healthy: true},
+					},
+				},
+			},
+		},
+		&fakeDeviceDetector{
+			name: "broken",
+			err:  fmt.Errorf("broken"),
+		},
+	}
+	f := NewDevicesFingerprintWithDetectors(testLogger(), detectors)
+
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+	request := &cstructs.FingerprintRequest{Config: &config.Config{}, Node: node}
+	var response cstructs.FingerprintResponse
+	require.NoError(f.Fingerprint(request, &response))
+	require.True(response.Detected)
+
+	assertNodeAttributeEquals(t, response.Attributes, "device.046d/usb/c52b.count", "1")
+	assertNodeAttributeEquals(t, response.Attributes, "device.8086/nic/154c.count", "2")
+
+	// Devices and their instances are sorted
+	require.NotNil(response.Resources)
+	devices := response.Resources.Devices
+	require.Len(devices, 2)
+	require.Equal("046d/usb/c52b", devices[0].ID())
+	require.Equal("8086/nic/154c", devices[1].ID())
+	require.Equal([]string{"0000:03:02.0", "0000:03:02.1"}, devices[1].InstanceIDs())
+}
+
+func TestDevicesFingerprint_NoDevices(t *testing.T) {
+	require := require.New(t)
+	f := NewDevicesFingerprintWithDetectors(testLogger(), []DeviceDetector{&fakeDeviceDetector{name: "fake"}})
+
+	// The node advertises a device that was removed from the host
+	node := &structs.Node{
+		Attributes: map[string]string{
+			"device.046d/usb/c52b.count": "1",
+		},
+		Resources: &structs.Resources{
+			Devices: []*structs.DeviceResource{
+				{
+					Vendor:    "046d",
+					Type:      "usb",
+					Name:      "c52b",
+					Instances: []*structs.DeviceInstance{{ID: "1-1.2", Healthy: true}},
+				},
+			},
+		},
+	}
+	request := &cstructs.FingerprintRequest{Config: &config.Config{}, Node: node}
+	var response cstructs.FingerprintResponse
+	require.NoError(f.Fingerprint(request, &response))
+	require.False(response.Detected)
+
+	// The device list is set and empty so the device is removed
+	require.NotNil(response.Resources)
+	require.NotNil(response.Resources.Devices)
+	require.Empty(response.Resources.Devices)
+	assertNodeAttributeEquals(t, response.Attributes, "device.046d/usb/c52b.count", "")
+
+	node.Resources.Merge(response.Resources)
+	require.Empty(node.Resources.Devices)
+}
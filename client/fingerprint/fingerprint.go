@@ -29,16 +29,18 @@ var (
 	// hostFingerprinters contains the host fingerprints which are available for a
 	// given platform.
 	hostFingerprinters = map[string]Factory{
-		"arch":    NewArchFingerprint,
-		"consul":  NewConsulFingerprint,
-		"cpu":     NewCPUFingerprint,
-		"host":    NewHostFingerprint,
-		"memory":  NewMemoryFingerprint,
-		"network": NewNetworkFingerprint,
-		"nomad":   NewNomadFingerprint,
-		"signal":  NewSignalFingerprint,
-		"storage": NewStorageFingerprint,
-		"vault":   NewVaultFingerprint,
+		"arch":        NewArchFingerprint,
+		"consul":      NewConsulFingerprint,
+		"cpu":         NewCPUFingerprint,
+		"devices":     NewDevicesFingerprint,
+		"host":        NewHostFingerprint,
+		"host_volume": NewHostVolumeFingerprint,
+		"memory":      NewMemoryFingerprint,
+		"network":     NewNetworkFingerprint,
+		"nomad":       NewNomadFingerprint,
+		"signal":      NewSignalFingerprint,
+		"storage":     NewStorageFingerprint,
+		"vault":       NewVaultFingerprint,
 	}
 
 	// envFingerprinters contains the fingerprints that are environment specific.
@@ -1,5 +1,7 @@
 package fingerprint
 
 func initPlatformFingerprints(fps map[string]Factory) {
+	fps["bridge"] = NewBridgeFingerprint
 	fps["cgroup"] = NewCGroupFingerprint
+	fps["cni"] = NewCNIFingerprint
 }
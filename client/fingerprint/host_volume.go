@@ -0,0 +1,50 @@
+package fingerprint
+
+import (
+	"log"
+	"os"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// HostVolumeFingerprint is used to fingerprint the host volumes configured by
+// the operator so that the scheduler only places tasks mounting them on nodes
+// providing them.
+type HostVolumeFingerprint struct {
+	StaticFingerprinter
+	logger *log.Logger
+}
+
+// NewHostVolumeFingerprint is used to create a host volume fingerprint
+func NewHostVolumeFingerprint(logger *log.Logger) Fingerprint {
+	f := &HostVolumeFingerprint{logger: logger}
+	return f
+}
+
+func (f *HostVolumeFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	if req.Config == nil || len(req.Config.HostVolumes) == 0 {
+		return nil
+	}
+
+	volumes := make(map[string]*structs.ClientHostVolumeConfig, len(req.Config.HostVolumes))
+	for name, volume := range req.Config.HostVolumes {
+		// Skip the volumes whose directory is missing rather than failing the
+		// tasks mounting them
+		fi, err := os.Stat(volume.Path)
+		if err != nil {
+			f.logger.Printf("[WARN] fingerprint.host_volume: skipping host volume %q: %v", name, err)
+			continue
+		}
+		if !fi.IsDir() {
+			f.logger.Printf("[WARN] fingerprint.host_volume: skipping host volume %q: %q is not a directory", name, volume.Path)
+			continue
+		}
+
+		volumes[name] = volume.Copy()
+	}
+
+	resp.HostVolumes = volumes
+	resp.Detected = true
+	return nil
+}
@@ -0,0 +1,66 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostVolumeFingerprint(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomadtest-hostvolume")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	require.NoError(ioutil.WriteFile(file, []byte("foo"), 0644))
+
+	conf := config.DefaultConfig()
+	conf.HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"data": {
+			Name:     "data",
+			Path:     dir,
+			ReadOnly: true,
+		},
+		"missing": {
+			Name: "missing",
+			Path: filepath.Join(dir, "missing"),
+		},
+		"file": {
+			Name: "file",
+			Path: file,
+		},
+	}
+
+	fp := NewHostVolumeFingerprint(testLogger())
+	request := &cstructs.FingerprintRequest{Config: conf, Node: &structs.Node{}}
+	var response cstructs.FingerprintResponse
+	require.NoError(fp.Fingerprint(request, &response))
+	require.True(response.Detected)
+
+	// Only the existing directories are volumes of the node
+	expected := map[string]*structs.ClientHostVolumeConfig{
+		"data": {
+			Name:     "data",
+			Path:     dir,
+			ReadOnly: true,
+		},
+	}
+	require.Equal(expected, response.HostVolumes)
+}
+
+func TestHostVolumeFingerprint_None(t *testing.T) {
+	fp := NewHostVolumeFingerprint(testLogger())
+	request := &cstructs.FingerprintRequest{Config: config.DefaultConfig(), Node: &structs.Node{}}
+	var response cstructs.FingerprintResponse
+	require.NoError(t, fp.Fingerprint(request, &response))
+	require.False(t, response.Detected)
+	require.Nil(t, response.HostVolumes)
+}
@@ -28,7 +28,11 @@ type FingerprintManager struct {
 	// updateNodeFromDriver is a callback to the client to update the state of a
 	// specific driver for the node
 	updateNodeFromDriver func(string, *structs.DriverInfo, *structs.DriverInfo) *structs.Node
-	logger               *log.Logger
+
+	// driverPlugins manages the plugin processes of the external drivers
+	driverPlugins *driver.DriverPluginManager
+
+	logger *log.Logger
 }
 
 // NewFingerprintManager is a constructor that creates and returns an instance
@@ -38,11 +42,13 @@ func NewFingerprintManager(getConfig func() *config.Config,
 	shutdownCh chan struct{},
 	updateNodeAttributes func(*cstructs.FingerprintResponse) *structs.Node,
 	updateNodeFromDriver func(string, *structs.DriverInfo, *structs.DriverInfo) *structs.Node,
+	driverPlugins *driver.DriverPluginManager,
 	logger *log.Logger) *FingerprintManager {
 	return &FingerprintManager{
 		getConfig:            getConfig,
 		updateNodeAttributes: updateNodeAttributes,
 		updateNodeFromDriver: updateNodeFromDriver,
+		driverPlugins:        driverPlugins,
 		node:                 node,
 		shutdownCh:           shutdownCh,
 		logger:               logger,
@@ -104,7 +110,7 @@ func (fp *FingerprintManager) Run() error {
 	var availDrivers []string
 	var skippedDrivers []string
 
-	for name := range driver.BuiltinDrivers {
+	for _, name := range driver.DriverNames(cfg) {
 		// Skip fingerprinting drivers that are not in the whitelist if it is
 		// enabled.
 		if _, ok := whitelistDrivers[name]; whitelistDriversEnabled && !ok {
@@ -167,7 +173,7 @@ func (fm *FingerprintManager) setupFingerprinters(fingerprints []string) error {
 // supported
 func (fm *FingerprintManager) setupDrivers(drivers []string) error {
 	var availDrivers []string
-	driverCtx := driver.NewDriverContext("", "", fm.getConfig(), fm.node, fm.logger, nil)
+	driverCtx := driver.NewDriverContext("", "", fm.getConfig(), fm.node, fm.logger, nil, fm.driverPlugins)
 	for _, name := range drivers {
 
 		d, err := driver.NewDriver(name, driverCtx)
@@ -27,6 +27,7 @@ func TestFingerprintManager_Run_MockDriver(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testLogger(),
 	)
 
@@ -55,6 +56,7 @@ func TestFingerprintManager_Run_ResourcesFingerprint(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -82,6 +84,7 @@ func TestFingerprintManager_Fingerprint_Run(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -115,6 +118,7 @@ func TestFingerprintManager_Fingerprint_Periodic(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -177,6 +181,7 @@ func TestFingerprintManager_HealthCheck_Driver(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -277,6 +282,7 @@ func TestFingerprintManager_HealthCheck_Periodic(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -373,6 +379,7 @@ func TestFimgerprintManager_Run_InWhitelist(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -403,6 +410,7 @@ func TestFingerprintManager_Run_InBlacklist(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -435,6 +443,7 @@ func TestFingerprintManager_Run_Combination(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -468,6 +477,7 @@ func TestFingerprintManager_Run_WhitelistDrivers(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -498,6 +508,7 @@ func TestFingerprintManager_Run_AllDriversBlacklisted(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -532,6 +543,7 @@ func TestFingerprintManager_Run_DriversWhiteListBlacklistCombination(t *testing.
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -565,6 +577,7 @@ func TestFingerprintManager_Run_DriversInBlacklist(t *testing.T) {
 		testClient.shutdownCh,
 		testClient.updateNodeFromFingerprint,
 		testClient.updateNodeFromDriver,
+		testClient.driverPlugins,
 		testClient.logger,
 	)
 
@@ -2,9 +2,12 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
@@ -65,6 +68,12 @@ const (
 	// and end of a file.
 	OriginStart = "start"
 	OriginEnd   = "end"
+
+	// compressedLogSuffix is the suffix of the rotated log files compressed
+	// by the log rotator, and tmpLogSuffix the suffix of the files being
+	// compressed
+	compressedLogSuffix = ".gz"
+	tmpLogSuffix        = ".tmp"
 )
 
 // FileSystem endpoint is used for accessing the logs and filesystem of
@@ -512,6 +521,14 @@ func (f *FileSystem) logsImpl(ctx context.Context, follow, plain bool, offset in
 			return fmt.Errorf("failed to list entries: %v", err)
 		}
 
+		// Offsets into compressed log files are in uncompressed bytes
+		if err := setUncompressedSizes(fs, logPath, entries); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read compressed log file size: %v", err)
+		}
+
 		// If we are not following logs, determine the max index for the logs we are
 		// interested in so we can stop there.
 		maxIndex := int64(math.MaxInt64)
@@ -543,7 +560,12 @@ func (f *FileSystem) logsImpl(ctx context.Context, follow, plain bool, offset in
 		}
 
 		p := filepath.Join(logPath, logEntry.Name)
-		err = f.streamFile(ctx, openOffset, p, 0, fs, framer, eofCancelCh)
+		if strings.HasSuffix(logEntry.Name, compressedLogSuffix) {
+			// Compressed files are never written to again
+			err = f.streamCompressedFile(ctx, openOffset, p, fs, framer)
+		} else {
+			err = f.streamFile(ctx, openOffset, p, 0, fs, framer, eofCancelCh)
+		}
 
 		// Check if the context is cancelled
 		select {
@@ -719,6 +741,81 @@ OUTER:
 	}
 }
 
+// streamCompressedFile streams the uncompressed content of a compressed log
+// file from the given uncompressed offset until its end. If the connection is
+// broken an EPIPE error is returned
+func (f *FileSystem) streamCompressedFile(ctx context.Context, offset int64, path string,
+	fs allocdir.AllocDirFS, framer *sframer.StreamFramer) error {
+
+	file, err := fs.ReadAt(path, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Skip to the offset
+	if _, err := io.CopyN(ioutil.Discard, reader, offset); err != nil && err != io.EOF {
+		return err
+	}
+
+	data := make([]byte, streamFrameSize)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		n, readErr := io.ReadFull(reader, data)
+		offset += int64(n)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return readErr
+		}
+
+		if n != 0 {
+			if err := framer.Send(path, "", data[:n], offset); err != nil {
+				return parseFramerErr(err)
+			}
+		}
+
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
+// setUncompressedSizes sets the size of the compressed log file entries to
+// the size of their uncompressed content, read from the gzip trailer.
+func setUncompressedSizes(fs allocdir.AllocDirFS, logPath string, entries []*cstructs.AllocFileInfo) error {
+	for _, entry := range entries {
+		if entry.IsDir || !strings.HasSuffix(entry.Name, compressedLogSuffix) || entry.Size < 4 {
+			continue
+		}
+
+		file, err := fs.ReadAt(filepath.Join(logPath, entry.Name), entry.Size-4)
+		if err != nil {
+			return err
+		}
+
+		// The trailer holds the uncompressed size modulo 2^32 which is
+		// enough for rotated log files
+		var size uint32
+		err = binary.Read(file, binary.LittleEndian, &size)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		entry.Size = int64(size)
+	}
+	return nil
+}
+
 // blockUntilNextLog returns a channel that will have data sent when the next
 // log index or anything greater is created.
 func blockUntilNextLog(ctx context.Context, fs allocdir.AllocDirFS, logPath, task, logType string, nextIndex int64) chan error {
@@ -791,10 +888,11 @@ func (a indexTupleArray) Less(i, j int) bool { return a[i].idx < a[j].idx }
 func (a indexTupleArray) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
 // logIndexes takes a set of entries and returns a indexTupleArray of
-// the desired log file entries. If the indexes could not be determined, an
-// error is returned.
+// the desired log file entries, compressed or not. If the indexes could not be
+// determined, an error is returned.
 func logIndexes(entries []*cstructs.AllocFileInfo, task, logType string) (indexTupleArray, error) {
 	var indexes []indexTuple
+	positions := make(map[int64]int)
 	prefix := fmt.Sprintf("%s.%s.", task, logType)
 	for _, entry := range entries {
 		if entry.IsDir {
@@ -807,12 +905,28 @@ func logIndexes(entries []*cstructs.AllocFileInfo, task, logType string) (indexT
 			continue
 		}
 
+		// Skip the files being compressed
+		if strings.HasSuffix(idxStr, tmpLogSuffix) {
+			continue
+		}
+		compressed := strings.HasSuffix(idxStr, compressedLogSuffix)
+		idxStr = strings.TrimSuffix(idxStr, compressedLogSuffix)
+
 		// Convert to an int
 		idx, err := strconv.Atoi(idxStr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert %q to a log index: %v", idxStr, err)
 		}
 
+		// Prefer the original file while it is being compressed
+		if pos, ok := positions[int64(idx)]; ok {
+			if !compressed {
+				indexes[pos].entry = entry
+			}
+			continue
+		}
+
+		positions[int64(idx)] = len(indexes)
 		indexes = append(indexes, indexTuple{idx: int64(idx), entry: entry})
 	}
 
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -1952,6 +1954,118 @@ func TestFS_logsImpl_NoFollow(t *testing.T) {
 	}
 }
 
+func TestFS_logIndexes_Compressed(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	entries := []*cstructs.AllocFileInfo{
+		{Name: "foo.stdout.0.gz"},
+		{Name: "foo.stdout.1.gz.tmp"},
+		{Name: "foo.stdout.1"},
+		{Name: "foo.stdout.2.gz"},
+		{Name: "foo.stdout.2"},
+		{Name: "foo.stdout.3"},
+		{Name: "foo.stderr.0.gz"},
+	}
+
+	indexes, err := logIndexes(entries, "foo", "stdout")
+	require.Nil(err)
+
+	var names []string
+	for _, index := range indexes {
+		names = append(names, index.entry.Name)
+	}
+
+	// The original file is preferred while it is being compressed
+	require.Equal([]string{"foo.stdout.0.gz", "foo.stdout.1", "foo.stdout.2", "foo.stdout.3"}, names)
+}
+
+func TestFS_logsImpl_Compressed(t *testing.T) {
+	t.Parallel()
+
+	c := TestClient(t, nil)
+	defer c.Shutdown()
+
+	// Get a temp alloc dir and create the log dir
+	ad := tempAllocDir(t)
+	defer os.RemoveAll(ad.AllocDir)
+
+	logDir := filepath.Join(ad.SharedDir, allocdir.LogDirName)
+	if err := os.MkdirAll(logDir, 0777); err != nil {
+		t.Fatalf("Failed to make log dir: %v", err)
+	}
+
+	// Create a series of log files in the temp dir, all but the last one
+	// being compressed
+	task := "foo"
+	logType := "stdout"
+	contents := []string{"0123", "4567", "89"}
+	for i, content := range contents {
+		logFile := fmt.Sprintf("%s.%s.%d", task, logType, i)
+		data := []byte(content)
+		if i != len(contents)-1 {
+			logFile += ".gz"
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write(data)
+			gz.Close()
+			data = buf.Bytes()
+		}
+		if err := ioutil.WriteFile(filepath.Join(logDir, logFile), data, 0666); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	// Read from an offset from the end landing in the first compressed file
+	expected := []byte("3456789")
+
+	// Start the reader
+	resultCh := make(chan struct{})
+	frames := make(chan *sframer.StreamFrame, 4)
+	var received []byte
+	go func() {
+		for {
+			frame, ok := <-frames
+			if !ok {
+				return
+			}
+
+			if frame.IsHeartbeat() {
+				continue
+			}
+
+			received = append(received, frame.Data...)
+			if reflect.DeepEqual(received, expected) {
+				close(resultCh)
+				return
+			}
+		}
+	}()
+
+	// Start streaming logs
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.endpoints.FileSystem.logsImpl(
+			context.Background(), false, false, int64(len(expected)),
+			OriginEnd, task, logType, ad, frames)
+	}()
+
+	select {
+	case <-resultCh:
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("logs() failed: %v", err)
+		}
+		select {
+		case <-resultCh:
+		case <-time.After(10 * time.Duration(testutil.TestMultiplier()) * streamBatchWindow):
+			t.Fatalf("did not receive data: got %q", string(received))
+		}
+	case <-time.After(10 * time.Duration(testutil.TestMultiplier()) * streamBatchWindow):
+		t.Fatalf("did not receive data: got %q", string(received))
+	}
+}
+
 func TestFS_logsImpl_Follow(t *testing.T) {
 	t.Parallel()
 
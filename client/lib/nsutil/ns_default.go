@@ -0,0 +1,28 @@
+// +build !linux
+
+package nsutil
+
+import "fmt"
+
+// NetNSRunDir is the directory network namespaces are mounted in.
+const NetNSRunDir = ""
+
+// NewNS is not supported outside of Linux.
+func NewNS(name string) (string, error) {
+	return "", fmt.Errorf("network namespaces are only supported on Linux")
+}
+
+// UnmountNS is not supported outside of Linux.
+func UnmountNS(nsPath string) error {
+	return fmt.Errorf("network namespaces are only supported on Linux")
+}
+
+// IsNS always returns false outside of Linux.
+func IsNS(nsPath string) bool {
+	return false
+}
+
+// WithNS is not supported outside of Linux.
+func WithNS(nsPath string, f func() error) error {
+	return fmt.Errorf("network namespaces are only supported on Linux")
+}
@@ -0,0 +1,125 @@
+package nsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// NetNSRunDir is the directory network namespaces are mounted in. It is
+	// shared with iproute2 so the namespaces are listed by "ip netns".
+	NetNSRunDir = "/var/run/netns"
+
+	// nsfsMagic and procSuperMagic are the types of the file systems of
+	// namespace files
+	nsfsMagic      = 0x6e736673
+	procSuperMagic = 0x9fa0
+)
+
+// NewNS creates a network namespace and mounts it in NetNSRunDir under the
+// given name, keeping it alive without any process in it. It returns the path
+// of the namespace.
+func NewNS(name string) (string, error) {
+	if err := os.MkdirAll(NetNSRunDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %q: %v", NetNSRunDir, err)
+	}
+
+	nsPath := filepath.Join(NetNSRunDir, name)
+	f, err := os.OpenFile(nsPath, os.O_RDONLY|os.O_CREATE|os.O_EXCL, 0444)
+	if err != nil {
+		return "", fmt.Errorf("failed to create namespace file: %v", err)
+	}
+	f.Close()
+
+	// The namespace is created by a thread of its own that is never unlocked
+	// so the runtime terminates it instead of scheduling other goroutines in
+	// the namespace.
+	errCh := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+
+		if err := unix.Unshare(unix.CLONE_NEWNET); err != nil {
+			errCh <- fmt.Errorf("failed to create network namespace: %v", err)
+			return
+		}
+
+		threadNSPath := fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid())
+		if err := unix.Mount(threadNSPath, nsPath, "none", unix.MS_BIND, ""); err != nil {
+			errCh <- fmt.Errorf("failed to mount network namespace: %v", err)
+			return
+		}
+		errCh <- nil
+	}()
+
+	if err := <-errCh; err != nil {
+		os.Remove(nsPath)
+		return "", err
+	}
+	return nsPath, nil
+}
+
+// UnmountNS unmounts and removes a network namespace created by NewNS. The
+// namespace is destroyed once no process is left in it.
+func UnmountNS(nsPath string) error {
+	if _, err := os.Stat(nsPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := unix.Unmount(nsPath, unix.MNT_DETACH); err != nil && err != unix.EINVAL {
+		return fmt.Errorf("failed to unmount network namespace %q: %v", nsPath, err)
+	}
+	if err := os.Remove(nsPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove network namespace %q: %v", nsPath, err)
+	}
+	return nil
+}
+
+// IsNS returns whether the path is a namespace, either mounted or in /proc.
+func IsNS(nsPath string) bool {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(nsPath, &stat); err != nil {
+		return false
+	}
+
+	// Namespaces in /proc are on procfs until they are bind mounted
+	return stat.Type == nsfsMagic || stat.Type == procSuperMagic
+}
+
+// WithNS runs f with the calling goroutine locked to a thread in the network
+// namespace at the given path. The processes started by f are created in the
+// namespace.
+func WithNS(nsPath string, f func() error) error {
+	ns, err := os.Open(nsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open network namespace %q: %v", nsPath, err)
+	}
+	defer ns.Close()
+
+	runtime.LockOSThread()
+
+	current, err := os.Open(fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid()))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("failed to open current network namespace: %v", err)
+	}
+	defer current.Close()
+
+	if err := unix.Setns(int(ns.Fd()), unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("failed to enter network namespace %q: %v", nsPath, err)
+	}
+
+	ferr := f()
+
+	// The thread is only unlocked once it is back in the namespace it came
+	// from. Otherwise it stays locked and is terminated with the goroutine.
+	if err := unix.Setns(int(current.Fd()), unix.CLONE_NEWNET); err != nil {
+		return fmt.Errorf("failed to leave network namespace %q: %v", nsPath, err)
+	}
+	runtime.UnlockOSThread()
+	return ferr
+}
@@ -0,0 +1,63 @@
+package nsutil
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNS(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Must be root to create network namespaces")
+	}
+	ipPath, err := exec.LookPath("ip")
+	if err != nil {
+		t.Skip("ip is not installed")
+	}
+	require := require.New(t)
+
+	nsPath, err := NewNS("nomad-test-" + uuid.Generate()[:8])
+	require.NoError(err)
+	defer UnmountNS(nsPath)
+	require.True(IsNS(nsPath))
+
+	// Processes started in the namespace only see its loopback interface
+	var out []byte
+	err = WithNS(nsPath, func() error {
+		var err error
+		out, err = exec.Command(ipPath, "-o", "link", "show").Output()
+		return err
+	})
+	require.NoError(err)
+	require.Equal(1, strings.Count(string(out), "\n"), string(out))
+	require.Contains(string(out), "lo:")
+
+	// The calling thread is back in the namespace of the client
+	hostOut, err := exec.Command(ipPath, "-o", "link", "show").Output()
+	require.NoError(err)
+	require.NotEqual(string(out), string(hostOut))
+
+	require.NoError(UnmountNS(nsPath))
+	require.False(IsNS(nsPath))
+	_, err = os.Stat(nsPath)
+	require.True(os.IsNotExist(err))
+
+	// Unmounting is idempotent
+	require.NoError(UnmountNS(nsPath))
+}
+
+func TestIsNS(t *testing.T) {
+	require.True(t, IsNS("/proc/self/ns/net"))
+
+	f, err := ioutil.TempFile("", "nsutil")
+	require.NoError(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+	require.False(t, IsNS(f.Name()))
+	require.False(t, IsNS("/nonexistent"))
+}
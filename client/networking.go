@@ -0,0 +1,112 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/lib/nsutil"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// networkConfigurator configures the network namespace of an allocation for
+// the network mode of its task group.
+type networkConfigurator interface {
+	// Setup configures the network namespace and returns the network of the
+	// tasks in it. It is called again with the same namespace when the client
+	// restarts and must be idempotent.
+	Setup(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) (*cstructs.DriverNetwork, error)
+
+	// Teardown removes the configuration of the network namespace. It is
+	// idempotent.
+	Teardown(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) error
+}
+
+// newNetworkConfigurator returns the configurator of the given network mode.
+func newNetworkConfigurator(mode string, config *config.Config, logger *log.Logger) (networkConfigurator, error) {
+	switch {
+	case mode == structs.NetworkModeBridge:
+		return newBridgeNetworkConfigurator(config, logger)
+	case strings.HasPrefix(mode, structs.NetworkModeCNIPrefix):
+		return newCNINetworkConfigurator(strings.TrimPrefix(mode, structs.NetworkModeCNIPrefix), config, logger)
+	default:
+		return nil, fmt.Errorf("unsupported network mode %q", mode)
+	}
+}
+
+// allocPort is a port of the group network of an allocation
+type allocPort struct {
+	Label    string
+	HostIP   string
+	HostPort int
+
+	// To is the port the host port is mapped to in the network namespace
+	To int
+}
+
+// allocPorts returns the ports of the group network of the allocation.
+func allocPorts(alloc *structs.Allocation) []allocPort {
+	if alloc.SharedResources == nil {
+		return nil
+	}
+
+	var ports []allocPort
+	for _, n := range alloc.SharedResources.Networks {
+		for _, p := range append(append([]structs.Port{}, n.ReservedPorts...), n.DynamicPorts...) {
+			to := p.To
+			if to == 0 {
+				to = p.Value
+			}
+			ports = append(ports, allocPort{
+				Label:    p.Label,
+				HostIP:   n.IP,
+				HostPort: p.Value,
+				To:       to,
+			})
+		}
+	}
+	return ports
+}
+
+// allocDriverNetwork returns the network of the tasks in the network namespace
+// of the allocation, which has the given address.
+func allocDriverNetwork(alloc *structs.Allocation, ip string) *cstructs.DriverNetwork {
+	ports := allocPorts(alloc)
+	portMap := make(map[string]int, len(ports))
+	for _, p := range ports {
+		portMap[p.Label] = p.To
+	}
+	return &cstructs.DriverNetwork{
+		IP:      ip,
+		PortMap: portMap,
+	}
+}
+
+// netnsNetworkManager creates the network namespaces of the allocations whose
+// tasks don't use a driver managing them. The namespaces are mounted under
+// the ID of the allocation.
+type netnsNetworkManager struct{}
+
+func (netnsNetworkManager) CreateNetwork(allocID string) (*cstructs.NetworkIsolationSpec, error) {
+	path := filepath.Join(nsutil.NetNSRunDir, allocID)
+	if nsutil.IsNS(path) {
+		return &cstructs.NetworkIsolationSpec{Path: path}, nil
+	}
+
+	// Remove what is left of a namespace that failed to be created
+	os.Remove(path)
+
+	path, err := nsutil.NewNS(allocID)
+	if err != nil {
+		return nil, err
+	}
+	return &cstructs.NetworkIsolationSpec{Path: path}, nil
+}
+
+func (netnsNetworkManager) DestroyNetwork(allocID string, spec *cstructs.NetworkIsolationSpec) error {
+	return nsutil.UnmountNS(spec.Path)
+}
@@ -0,0 +1,27 @@
+// +build !linux
+
+package client
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// bridgeNetworkConfigurator is only supported on Linux
+type bridgeNetworkConfigurator struct{}
+
+func newBridgeNetworkConfigurator(config *config.Config, logger *log.Logger) (*bridgeNetworkConfigurator, error) {
+	return nil, fmt.Errorf("the bridge network mode is only supported on Linux")
+}
+
+func (b *bridgeNetworkConfigurator) Setup(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) (*cstructs.DriverNetwork, error) {
+	return nil, fmt.Errorf("the bridge network mode is only supported on Linux")
+}
+
+func (b *bridgeNetworkConfigurator) Teardown(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) error {
+	return nil
+}
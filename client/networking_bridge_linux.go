@@ -0,0 +1,364 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/lib/nsutil"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// bridgePortMapChain is the nat chain holding the rules forwarding the
+	// ports of the host to the allocations
+	bridgePortMapChain = "NOMAD-PORTMAP"
+
+	// bridgeAllocIfName is the name of the interface of the allocations
+	bridgeAllocIfName = "eth0"
+)
+
+// bridgeLock serializes the configuration of the bridge and the allocation
+// of addresses across allocations
+var bridgeLock sync.Mutex
+
+// bridgeNetworkConfigurator attaches the network namespace of allocations to
+// a bridge of the host through a veth pair. Each allocation gets an address
+// of the subnet of the bridge, traffic leaving the host is masqueraded and
+// the ports of the group network are forwarded from the host to the
+// allocation.
+type bridgeNetworkConfigurator struct {
+	bridgeName string
+	subnet     *net.IPNet
+	gateway    net.IP
+
+	// ipamDir holds a file per allocated address containing the ID of the
+	// allocation it's allocated to
+	ipamDir string
+
+	logger *log.Logger
+}
+
+func newBridgeNetworkConfigurator(config *config.Config, logger *log.Logger) (*bridgeNetworkConfigurator, error) {
+	ip, subnet, err := net.ParseCIDR(config.BridgeNetworkSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bridge network subnet %q: %v", config.BridgeNetworkSubnet, err)
+	}
+	if ip.To4() == nil {
+		return nil, fmt.Errorf("bridge network subnet %q isn't an IPv4 subnet", config.BridgeNetworkSubnet)
+	}
+	if ones, _ := subnet.Mask.Size(); ones > 30 {
+		return nil, fmt.Errorf("bridge network subnet %q is too small", config.BridgeNetworkSubnet)
+	}
+
+	return &bridgeNetworkConfigurator{
+		bridgeName: config.BridgeNetworkName,
+		subnet:     subnet,
+		gateway:    ipAdd(subnet.IP, 1),
+		ipamDir:    filepath.Join(config.StateDir, "network", config.BridgeNetworkName),
+		logger:     logger,
+	}, nil
+}
+
+func (b *bridgeNetworkConfigurator) Setup(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) (*cstructs.DriverNetwork, error) {
+	bridgeLock.Lock()
+	defer bridgeLock.Unlock()
+
+	if err := b.ensureBridge(); err != nil {
+		return nil, fmt.Errorf("failed to configure bridge %q: %v", b.bridgeName, err)
+	}
+
+	ip, err := b.allocateIP(alloc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate an address: %v", err)
+	}
+
+	// The veth pair only exists while the network namespace does, so it is
+	// only created when the namespace is new
+	veth := bridgeVethName(alloc.ID)
+	if !linkExists(veth) {
+		if err := b.attach(spec, veth, ip); err != nil {
+			runIP("link", "del", veth)
+			return nil, fmt.Errorf("failed to attach the allocation to bridge %q: %v", b.bridgeName, err)
+		}
+	}
+
+	for _, rule := range b.portMapRules(alloc, ip) {
+		if err := iptablesEnsureRule(false, "nat", bridgePortMapChain, rule...); err != nil {
+			return nil, fmt.Errorf("failed to forward ports: %v", err)
+		}
+	}
+	return allocDriverNetwork(alloc, ip.String()), nil
+}
+
+func (b *bridgeNetworkConfigurator) Teardown(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) error {
+	bridgeLock.Lock()
+	defer bridgeLock.Unlock()
+
+	ip, err := b.lookupIP(alloc.ID)
+	if err != nil {
+		return err
+	}
+	if ip == nil {
+		return nil
+	}
+
+	for _, rule := range b.portMapRules(alloc, ip) {
+		if err := iptablesDeleteRule("nat", bridgePortMapChain, rule...); err != nil {
+			return fmt.Errorf("failed to remove forwarded ports: %v", err)
+		}
+	}
+
+	// Deleting the end of the pair in the host deletes the pair
+	if veth := bridgeVethName(alloc.ID); linkExists(veth) {
+		if err := runIP("link", "del", veth); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(filepath.Join(b.ipamDir, ip.String()))
+}
+
+// ensureBridge creates the bridge and the firewall rules shared by the
+// allocations if they don't exist.
+func (b *bridgeNetworkConfigurator) ensureBridge() error {
+	if !linkExists(b.bridgeName) {
+		if err := runIP("link", "add", "name", b.bridgeName, "type", "bridge"); err != nil {
+			return err
+		}
+	}
+
+	ones, _ := b.subnet.Mask.Size()
+	gateway := fmt.Sprintf("%s/%d", b.gateway, ones)
+	out, err := exec.Command("ip", "-o", "addr", "show", "dev", b.bridgeName).Output()
+	if err != nil {
+		return fmt.Errorf("failed to list addresses: %v", err)
+	}
+	if !strings.Contains(string(out), " "+gateway+" ") {
+		if err := runIP("addr", "add", gateway, "dev", b.bridgeName); err != nil {
+			return err
+		}
+	}
+	if err := runIP("link", "set", b.bridgeName, "up"); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile("/proc/sys/net/ipv4/ip_forward", []byte("1"), 0644); err != nil {
+		return fmt.Errorf("failed to enable IP forwarding: %v", err)
+	}
+
+	if err := iptablesEnsureChain("nat", bridgePortMapChain); err != nil {
+		return err
+	}
+	for _, chain := range []string{"PREROUTING", "OUTPUT"} {
+		if err := iptablesEnsureRule(false, "nat", chain,
+			"-m", "addrtype", "--dst-type", "LOCAL", "-j", bridgePortMapChain); err != nil {
+			return err
+		}
+	}
+	if err := iptablesEnsureRule(false, "nat", "POSTROUTING",
+		"-s", b.subnet.String(), "!", "-o", b.bridgeName, "-j", "MASQUERADE"); err != nil {
+		return err
+	}
+
+	// The forwarding rules are inserted first so they aren't shadowed by the
+	// rules of other tools, such as docker
+	for _, dir := range []string{"-i", "-o"} {
+		if err := iptablesEnsureRule(true, "filter", "FORWARD", dir, b.bridgeName, "-j", "ACCEPT"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attach creates the veth pair of the allocation, its end in the network
+// namespace being configured with the address of the allocation and a route
+// through the bridge.
+func (b *bridgeNetworkConfigurator) attach(spec *cstructs.NetworkIsolationSpec, veth string, ip net.IP) error {
+	// The namespace is given by path as the namespace of the client process
+	// isn't necessarily the one of its threads
+	if err := runIP("link", "add", veth, "type", "veth", "peer", "name", bridgeAllocIfName, "netns", spec.Path); err != nil {
+		return err
+	}
+	if err := runIP("link", "set", veth, "master", b.bridgeName); err != nil {
+		return err
+	}
+	if err := runIP("link", "set", veth, "up"); err != nil {
+		return err
+	}
+
+	ones, _ := b.subnet.Mask.Size()
+	return nsutil.WithNS(spec.Path, func() error {
+		cmds := [][]string{
+			{"link", "set", "lo", "up"},
+			{"addr", "add", fmt.Sprintf("%s/%d", ip, ones), "dev", bridgeAllocIfName},
+			{"link", "set", bridgeAllocIfName, "up"},
+			{"route", "add", "default", "via", b.gateway.String()},
+		}
+		for _, args := range cmds {
+			if err := runIP(args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// portMapRules returns the rules forwarding the ports of the group network
+// of the allocation to its address, to the port they are mapped to if any.
+func (b *bridgeNetworkConfigurator) portMapRules(alloc *structs.Allocation, ip net.IP) [][]string {
+	var rules [][]string
+	for _, port := range allocPorts(alloc) {
+		for _, proto := range []string{"tcp", "udp"} {
+			var rule []string
+			if port.HostIP != "" {
+				rule = append(rule, "-d", port.HostIP+"/32")
+			}
+			rule = append(rule,
+				"-p", proto, "--dport", strconv.Itoa(port.HostPort),
+				"-m", "comment", "--comment", "nomad alloc "+alloc.ID,
+				"-j", "DNAT", "--to-destination", fmt.Sprintf("%s:%d", ip, port.To))
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// allocateIP returns the address allocated to the allocation, allocating the
+// first free address of the subnet if there is none.
+func (b *bridgeNetworkConfigurator) allocateIP(allocID string) (net.IP, error) {
+	if ip, err := b.lookupIP(allocID); err != nil || ip != nil {
+		return ip, err
+	}
+
+	if err := os.MkdirAll(b.ipamDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// The network and broadcast addresses and the gateway are skipped
+	ones, bits := b.subnet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+	for i := uint32(2); i < size-1; i++ {
+		ip := ipAdd(b.subnet.IP, i)
+		f, err := os.OpenFile(filepath.Join(b.ipamDir, ip.String()), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = f.WriteString(allocID)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("no address left in subnet %s", b.subnet)
+}
+
+// lookupIP returns the address allocated to the allocation or nil if there is
+// none.
+func (b *bridgeNetworkConfigurator) lookupIP(allocID string) (net.IP, error) {
+	files, err := ioutil.ReadDir(b.ipamDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		id, err := ioutil.ReadFile(filepath.Join(b.ipamDir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if string(id) == allocID {
+			return net.ParseIP(f.Name()).To4(), nil
+		}
+	}
+	return nil, nil
+}
+
+// bridgeVethName returns the name of the end of the veth pair of the
+// allocation in the host. Interface names are limited to 15 characters.
+func bridgeVethName(allocID string) string {
+	id := strings.Replace(allocID, "-", "", -1)
+	if len(id) > 11 {
+		id = id[:11]
+	}
+	return "veth" + id
+}
+
+// ipAdd returns the IPv4 address n addresses after ip
+func ipAdd(ip net.IP, n uint32) net.IP {
+	out := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(out, binary.BigEndian.Uint32(ip.To4())+n)
+	return out
+}
+
+// linkExists returns whether the network interface exists
+func linkExists(name string) bool {
+	return exec.Command("ip", "link", "show", "dev", name).Run() == nil
+}
+
+// runIP runs the ip command of iproute2
+func runIP(args ...string) error {
+	if out, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("ip %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// runIPTables runs iptables, waiting for the lock of the rules
+func runIPTables(args ...string) error {
+	args = append([]string{"-w"}, args...)
+	if out, err := exec.Command("iptables", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("iptables %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// iptablesEnsureChain creates the chain if it doesn't exist
+func iptablesEnsureChain(table, chain string) error {
+	if runIPTables("-t", table, "-n", "-L", chain) == nil {
+		return nil
+	}
+	return runIPTables("-t", table, "-N", chain)
+}
+
+// iptablesEnsureRule appends the rule to the chain, or inserts it first, if
+// it doesn't exist
+func iptablesEnsureRule(insert bool, table, chain string, rule ...string) error {
+	if runIPTables(append([]string{"-t", table, "-C", chain}, rule...)...) == nil {
+		return nil
+	}
+
+	op := "-A"
+	if insert {
+		op = "-I"
+	}
+	return runIPTables(append([]string{"-t", table, op, chain}, rule...)...)
+}
+
+// iptablesDeleteRule deletes the rule from the chain if it exists
+func iptablesDeleteRule(table, chain string, rule ...string) error {
+	if runIPTables(append([]string{"-t", table, "-C", chain}, rule...)...) != nil {
+		return nil
+	}
+	return runIPTables(append([]string{"-t", table, "-D", chain}, rule...)...)
+}
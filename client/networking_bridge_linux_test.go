@@ -0,0 +1,113 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/helper/testlog"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func testBridgeNetworkConfigurator(t *testing.T, subnet string) (*bridgeNetworkConfigurator, func()) {
+	dir, err := ioutil.TempDir("", "nomadtest-bridge")
+	require.NoError(t, err)
+
+	conf := config.DefaultConfig()
+	conf.StateDir = dir
+	conf.BridgeNetworkSubnet = subnet
+	b, err := newBridgeNetworkConfigurator(conf, testlog.Logger(t))
+	require.NoError(t, err)
+	return b, func() { os.RemoveAll(dir) }
+}
+
+func TestBridgeNetworkConfigurator_Config(t *testing.T) {
+	t.Parallel()
+
+	b, cleanup := testBridgeNetworkConfigurator(t, "10.10.0.0/20")
+	defer cleanup()
+	require.Equal(t, "nomad", b.bridgeName)
+	require.Equal(t, "10.10.0.1", b.gateway.String())
+
+	conf := config.DefaultConfig()
+	for _, subnet := range []string{"10.10.0.0", "fd00::/64", "10.10.0.0/31"} {
+		conf.BridgeNetworkSubnet = subnet
+		_, err := newBridgeNetworkConfigurator(conf, testlog.Logger(t))
+		require.Error(t, err, subnet)
+	}
+}
+
+func TestBridgeNetworkConfigurator_IPAM(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// A /29 has 5 addresses for allocations once the network and broadcast
+	// addresses and the gateway are excluded
+	b, cleanup := testBridgeNetworkConfigurator(t, "10.10.0.0/29")
+	defer cleanup()
+
+	ip, err := b.lookupIP("alloc1")
+	require.NoError(err)
+	require.Nil(ip)
+
+	ip, err = b.allocateIP("alloc1")
+	require.NoError(err)
+	require.Equal("10.10.0.2", ip.String())
+
+	// Allocating is idempotent
+	ip, err = b.allocateIP("alloc1")
+	require.NoError(err)
+	require.Equal("10.10.0.2", ip.String())
+
+	for i, id := range []string{"alloc2", "alloc3", "alloc4", "alloc5"} {
+		ip, err = b.allocateIP(id)
+		require.NoError(err)
+		require.Equal(ipAdd(b.subnet.IP, uint32(i+3)), ip)
+	}
+
+	_, err = b.allocateIP("alloc6")
+	require.Error(err)
+	require.Contains(err.Error(), "no address left")
+
+	ip, err = b.lookupIP("alloc4")
+	require.NoError(err)
+	require.Equal("10.10.0.5", ip.String())
+}
+
+func TestBridgeNetworkConfigurator_PortMapRules(t *testing.T) {
+	t.Parallel()
+
+	b, cleanup := testBridgeNetworkConfigurator(t, "10.10.0.0/20")
+	defer cleanup()
+
+	alloc := mock.Alloc()
+	alloc.SharedResources.Networks = []*structs.NetworkResource{
+		{
+			Mode:          structs.NetworkModeBridge,
+			IP:            "192.168.0.100",
+			ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+			DynamicPorts:  []structs.Port{{Label: "http", Value: 23456, To: 8080}},
+		},
+	}
+
+	comment := "nomad alloc " + alloc.ID
+	expected := [][]string{
+		{"-d", "192.168.0.100/32", "-p", "tcp", "--dport", "9000", "-m", "comment", "--comment", comment, "-j", "DNAT", "--to-destination", "10.10.0.2:9000"},
+		{"-d", "192.168.0.100/32", "-p", "udp", "--dport", "9000", "-m", "comment", "--comment", comment, "-j", "DNAT", "--to-destination", "10.10.0.2:9000"},
+		{"-d", "192.168.0.100/32", "-p", "tcp", "--dport", "23456", "-m", "comment", "--comment", comment, "-j", "DNAT", "--to-destination", "10.10.0.2:8080"},
+		{"-d", "192.168.0.100/32", "-p", "udp", "--dport", "23456", "-m", "comment", "--comment", comment, "-j", "DNAT", "--to-destination", "10.10.0.2:8080"},
+	}
+	require.Equal(t, expected, b.portMapRules(alloc, ipAdd(b.subnet.IP, 2)))
+}
+
+func TestBridgeVethName(t *testing.T) {
+	t.Parallel()
+
+	name := bridgeVethName("6b6e3f5a-2a35-4bba-b2a0-7b3c8d0ef4c1")
+	require.Equal(t, "veth6b6e3f5a2a3", name)
+	require.Len(t, name, 15)
+	require.Equal(t, "vethabc", bridgeVethName("abc"))
+}
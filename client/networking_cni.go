@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/libcni"
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+	cnicurrent "github.com/containernetworking/cni/pkg/types/100"
+	cniversion "github.com/containernetworking/cni/pkg/version"
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// cniAllocIfName is the name of the interface CNI plugins create in the
+	// network namespace of the allocation
+	cniAllocIfName = "eth0"
+
+	// cniPortMappingsCapability is the capability of the plugins forwarding
+	// ports of the host, like the portmap plugin
+	cniPortMappingsCapability = "portMappings"
+)
+
+// cniNetworkConfigurator attaches the network namespace of allocations to a
+// CNI network by running its plugins through libcni.
+type cniNetworkConfigurator struct {
+	// name is the name of the CNI network
+	name      string
+	configDir string
+
+	cni    *libcni.CNIConfig
+	logger *log.Logger
+}
+
+// cniPortMapping is the format of the port mappings passed to the plugins
+// with the portMappings capability
+type cniPortMapping struct {
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"`
+	HostIP        string `json:"hostIP,omitempty"`
+}
+
+func newCNINetworkConfigurator(name string, config *config.Config, logger *log.Logger) (*cniNetworkConfigurator, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing the name of the CNI network")
+	}
+
+	// The results of the plugins are cached with the state of the client
+	cacheDir := filepath.Join(config.StateDir, "cni")
+	return &cniNetworkConfigurator{
+		name:      name,
+		configDir: config.CNIConfigDir,
+		cni:       libcni.NewCNIConfigWithCacheDir(filepath.SplitList(config.CNIPath), cacheDir, nil),
+		logger:    logger,
+	}, nil
+}
+
+func (c *cniNetworkConfigurator) Setup(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) (*cstructs.DriverNetwork, error) {
+	list, err := libcni.LoadConfList(c.configDir, c.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CNI network %q: %v", c.name, err)
+	}
+	rt := c.runtimeConf(alloc, spec)
+
+	// The result of adding the namespace to the network is cached by libcni,
+	// so the network is only added again when the namespace was recreated
+	result, err := c.cachedResult(list, rt)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result, err = c.cni.AddNetworkList(context.Background(), list, rt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add the allocation to CNI network %q: %v", c.name, err)
+		}
+	}
+
+	ip, err := cniResultIP(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result of CNI network %q: %v", c.name, err)
+	}
+	return allocDriverNetwork(alloc, ip.String()), nil
+}
+
+func (c *cniNetworkConfigurator) Teardown(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) error {
+	list, err := libcni.LoadConfList(c.configDir, c.name)
+	if err != nil {
+		return fmt.Errorf("failed to load CNI network %q: %v", c.name, err)
+	}
+
+	if err := c.cni.DelNetworkList(context.Background(), list, c.runtimeConf(alloc, spec)); err != nil {
+		return fmt.Errorf("failed to remove the allocation from CNI network %q: %v", c.name, err)
+	}
+	return nil
+}
+
+// cachedResult returns the cached result of adding the namespace to the
+// network, or nil if it isn't attached to it. Networks supporting the CHECK
+// command are checked so a namespace that was recreated is attached again.
+func (c *cniNetworkConfigurator) cachedResult(list *libcni.NetworkConfigList, rt *libcni.RuntimeConf) (cnitypes.Result, error) {
+	result, err := c.cni.GetNetworkListCachedResult(list, rt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cached result of CNI network %q: %v", c.name, err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	if ok, err := cniversion.GreaterThanOrEqualTo(list.CNIVersion, "0.4.0"); err != nil || !ok {
+		return result, nil
+	}
+	if err := c.cni.CheckNetworkList(context.Background(), list, rt); err != nil {
+		c.logger.Printf("[DEBUG] client: attaching alloc %q to CNI network %q again: %v", rt.ContainerID, c.name, err)
+		if err := c.cni.DelNetworkList(context.Background(), list, rt); err != nil {
+			return nil, fmt.Errorf("failed to remove the allocation from CNI network %q: %v", c.name, err)
+		}
+		return nil, nil
+	}
+	return result, nil
+}
+
+// runtimeConf returns the arguments of the CNI plugins for the network
+// namespace of the allocation.
+func (c *cniNetworkConfigurator) runtimeConf(alloc *structs.Allocation, spec *cstructs.NetworkIsolationSpec) *libcni.RuntimeConf {
+	var portMappings []cniPortMapping
+	for _, port := range allocPorts(alloc) {
+		for _, proto := range []string{"tcp", "udp"} {
+			portMappings = append(portMappings, cniPortMapping{
+				HostPort:      port.HostPort,
+				ContainerPort: port.To,
+				Protocol:      proto,
+				HostIP:        port.HostIP,
+			})
+		}
+	}
+
+	rt := &libcni.RuntimeConf{
+		ContainerID: alloc.ID,
+		NetNS:       spec.Path,
+		IfName:      cniAllocIfName,
+		Args: [][2]string{
+			{"IgnoreUnknown", "true"},
+		},
+	}
+	if len(portMappings) != 0 {
+		rt.CapabilityArgs = map[string]interface{}{
+			cniPortMappingsCapability: portMappings,
+		}
+	}
+	return rt
+}
+
+// cniResultIP returns the address of the allocation in the result of a CNI
+// network, preferring IPv4 addresses. Addresses of interfaces outside of the
+// network namespace are ignored.
+func cniResultIP(result cnitypes.Result) (net.IP, error) {
+	res, err := cnicurrent.NewResultFromResult(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var ip net.IP
+	for _, ipc := range res.IPs {
+		if ipc.Interface != nil && *ipc.Interface >= 0 && *ipc.Interface < len(res.Interfaces) &&
+			res.Interfaces[*ipc.Interface].Sandbox == "" {
+			continue
+		}
+
+		if ipc.Address.IP.To4() != nil {
+			return ipc.Address.IP, nil
+		}
+		if ip == nil {
+			ip = ipc.Address.IP
+		}
+	}
+
+	if ip == nil {
+		return nil, fmt.Errorf("no address assigned to the allocation")
+	}
+	return ip, nil
+}
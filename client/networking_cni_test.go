@@ -0,0 +1,111 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/testlog"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// testCNIPlugin is a CNI plugin logging its commands and their configuration
+// that assigns a fixed address in the namespace
+const testCNIPlugin = `#!/bin/sh
+echo "$CNI_COMMAND $CNI_NETNS $(cat)" >> "$(dirname "$0")/plugin.log"
+if [ "$CNI_COMMAND" = "ADD" ]; then
+	cat <<RESULT
+{
+	"cniVersion": "1.0.0",
+	"interfaces": [{"name": "veth0"}, {"name": "eth0", "sandbox": "$CNI_NETNS"}],
+	"ips": [
+		{"address": "10.1.2.1/24", "interface": 0},
+		{"address": "fd00::3/64", "interface": 1},
+		{"address": "10.1.2.3/24", "interface": 1}
+	]
+}
+RESULT
+fi
+`
+
+func TestCNINetworkConfigurator(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomadtest-cni")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	conf := config.DefaultConfig()
+	conf.StateDir = filepath.Join(dir, "state")
+	conf.CNIPath = filepath.Join(dir, "missing") + string(filepath.ListSeparator) + dir
+	conf.CNIConfigDir = dir
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "fake"), []byte(testCNIPlugin), 0755))
+	netConf := `{
+		"cniVersion": "1.0.0",
+		"name": "mynet",
+		"plugins": [{"type": "fake", "capabilities": {"portMappings": true}}]
+	}`
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "mynet.conflist"), []byte(netConf), 0644))
+
+	_, err = newNetworkConfigurator(structs.NetworkModeCNIPrefix, conf, testlog.Logger(t))
+	require.Error(err)
+	c, err := newNetworkConfigurator("cni/mynet", conf, testlog.Logger(t))
+	require.NoError(err)
+
+	alloc := mock.Alloc()
+	alloc.SharedResources.Networks = []*structs.NetworkResource{
+		{
+			Mode:         "cni/mynet",
+			IP:           "192.168.0.100",
+			DynamicPorts: []structs.Port{{Label: "http", Value: 23456, To: 8080}},
+		},
+	}
+	spec := &cstructs.NetworkIsolationSpec{Mode: "cni/mynet", Path: "/var/run/netns/" + alloc.ID}
+
+	// The address of the namespace is the address of the tasks
+	network, err := c.Setup(alloc, spec)
+	require.NoError(err)
+	require.Equal("10.1.2.3", network.IP)
+	require.Equal(map[string]int{"http": 8080}, network.PortMap)
+
+	// The namespace is only added to the network once
+	network, err = c.Setup(alloc, spec)
+	require.NoError(err)
+	require.Equal("10.1.2.3", network.IP)
+
+	require.NoError(c.Teardown(alloc, spec))
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, "plugin.log"))
+	require.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Len(lines, 3)
+	require.True(strings.HasPrefix(lines[0], "ADD "+spec.Path))
+	require.Contains(lines[0], `{"hostPort":23456,"containerPort":8080,"protocol":"tcp","hostIP":"192.168.0.100"}`)
+	require.True(strings.HasPrefix(lines[1], "CHECK "+spec.Path))
+	require.True(strings.HasPrefix(lines[2], "DEL "+spec.Path))
+}
+
+func TestCNINetworkConfigurator_MissingNetwork(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomadtest-cni")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	conf := config.DefaultConfig()
+	conf.StateDir = dir
+	conf.CNIConfigDir = dir
+	c, err := newNetworkConfigurator("cni/mynet", conf, testlog.Logger(t))
+	require.NoError(err)
+
+	alloc := mock.Alloc()
+	_, err = c.Setup(alloc, &cstructs.NetworkIsolationSpec{Path: "/var/run/netns/" + alloc.ID})
+	require.Error(err)
+	require.Contains(err.Error(), `failed to load CNI network "mynet"`)
+}
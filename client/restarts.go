@@ -20,11 +20,17 @@ const (
 	ReasonDelay               = "Exceeded allowed attempts, applying a delay"
 )
 
-func newRestartTracker(policy *structs.RestartPolicy, jobType string) *RestartTracker {
+func newRestartTracker(policy *structs.RestartPolicy, jobType string, lifecycle *structs.TaskLifecycleConfig) *RestartTracker {
 	onSuccess := true
 	if jobType == structs.JobTypeBatch {
 		onSuccess = false
 	}
+
+	// Lifecycle tasks that aren't sidecars run to completion so they aren't
+	// restarted when they exit successfully
+	if lifecycle != nil && !lifecycle.Sidecar {
+		onSuccess = false
+	}
 	return &RestartTracker{
 		startTime: time.Now(),
 		onSuccess: onSuccess,
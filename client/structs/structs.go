@@ -165,6 +165,60 @@ type AllocStatsResponse struct {
 	structs.QueryMeta
 }
 
+// AllocExecRequest is the initial request for executing a command inside
+// a task of an allocation. It is followed by a stream of
+// ExecStreamingInput messages.
+type AllocExecRequest struct {
+	// AllocID is the allocation to execute the command in
+	AllocID string
+
+	// Task is the task to execute the command in
+	Task string
+
+	// Tty indicates whether the command should run with a pseudo-terminal
+	Tty bool
+
+	// Cmd is the command and its arguments
+	Cmd []string
+
+	structs.QueryOptions
+}
+
+// ExecStreamingIOOperation is a write to or the close of a stream of an
+// exec session.
+type ExecStreamingIOOperation struct {
+	Data  []byte `json:"data,omitempty"`
+	Close bool   `json:"close,omitempty"`
+}
+
+// TerminalSize is the size of the terminal of an exec session.
+type TerminalSize struct {
+	Height int `json:"height,omitempty"`
+	Width  int `json:"width,omitempty"`
+}
+
+// ExecStreamingInput is a message sent to an exec session carrying stdin
+// or a terminal resize.
+type ExecStreamingInput struct {
+	Stdin   *ExecStreamingIOOperation `json:"stdin,omitempty"`
+	TTYSize *TerminalSize             `json:"tty_size,omitempty"`
+}
+
+// ExecStreamingExitResult is the result of a command of an exec session.
+type ExecStreamingExitResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+// ExecStreamingOutput is a message sent from an exec session carrying
+// output or the exit result of the command.
+type ExecStreamingOutput struct {
+	Stdout *ExecStreamingIOOperation `json:"stdout,omitempty"`
+	Stderr *ExecStreamingIOOperation `json:"stderr,omitempty"`
+
+	Exited bool                     `json:"exited,omitempty"`
+	Result *ExecStreamingExitResult `json:"result,omitempty"`
+}
+
 // MemoryStats holds memory usage related stats
 type MemoryStats struct {
 	RSS            uint64
@@ -290,6 +344,58 @@ func (f FSIsolation) String() string {
 	}
 }
 
+// MountConfig is a directory of the host mounted into a task by its driver.
+type MountConfig struct {
+	// HostPath is the path of the directory on the host
+	HostPath string
+
+	// TaskPath is the path the directory is mounted at in the task
+	TaskPath string
+
+	// Readonly mounts the directory read only
+	Readonly bool
+}
+
+func (m *MountConfig) Copy() *MountConfig {
+	if m == nil {
+		return nil
+	}
+
+	nm := new(MountConfig)
+	*nm = *m
+	return nm
+}
+
+// NetworkIsolationSpec is the network namespace the tasks of an allocation
+// share when its task group network isn't in host mode.
+type NetworkIsolationSpec struct {
+	// Mode is the network mode of the task group
+	Mode string
+
+	// Path is the path of the network namespace
+	Path string
+
+	// Labels are details of the network namespace set by the driver that
+	// created it, such as the ID of the container holding it
+	Labels map[string]string
+}
+
+func (n *NetworkIsolationSpec) Copy() *NetworkIsolationSpec {
+	if n == nil {
+		return nil
+	}
+
+	nn := new(NetworkIsolationSpec)
+	*nn = *n
+	if n.Labels != nil {
+		nn.Labels = make(map[string]string, len(n.Labels))
+		for k, v := range n.Labels {
+			nn.Labels[k] = v
+		}
+	}
+	return nn
+}
+
 // DriverNetwork is the network created by driver's (eg Docker's bridge
 // network) during Prestart.
 type DriverNetwork struct {
@@ -358,6 +464,10 @@ type FingerprintResponse struct {
 	Links      map[string]string
 	Resources  *structs.Resources
 
+	// HostVolumes are the host volumes available to tasks, replacing those
+	// of the node when set
+	HostVolumes map[string]*structs.ClientHostVolumeConfig
+
 	// Detected is a boolean indicating whether the fingerprinter detected
 	// if the resource was available
 	Detected bool
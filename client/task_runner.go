@@ -2,11 +2,11 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -16,14 +16,11 @@ import (
 
 	metrics "github.com/armon/go-metrics"
 	"github.com/boltdb/bolt"
-	"github.com/golang/snappy"
-	"github.com/hashicorp/consul-template/signals"
 	"github.com/hashicorp/go-multierror"
 	version "github.com/hashicorp/go-version"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
-	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/ugorji/go/codec"
@@ -109,43 +106,52 @@ type TaskRunner struct {
 	driverNet     *cstructs.DriverNetwork
 	driverNetLock sync.Mutex
 
+	// volumeMounts are the volumes mounted into the task, passed to drivers
+	// with image isolation
+	volumeMounts     []*cstructs.MountConfig
+	volumeMountsLock sync.Mutex
+
+	// networkIsolation is the network namespace of the allocation the task
+	// is started in, nil in the host network mode, and allocNetwork the
+	// network of the tasks in it
+	networkIsolation     *cstructs.NetworkIsolationSpec
+	allocNetwork         *cstructs.DriverNetwork
+	networkIsolationLock sync.Mutex
+
 	// updateCh is used to receive updated versions of the allocation
 	updateCh chan *structs.Allocation
 
 	handle     driver.DriverHandle
 	handleLock sync.Mutex
 
-	// artifactsDownloaded tracks whether the tasks artifacts have been
-	// downloaded
-	//
-	// Must acquire persistLock when accessing
-	artifactsDownloaded bool
-
-	// taskDirBuilt tracks whether the task has built its directory.
-	//
-	// Must acquire persistLock when accessing
-	taskDirBuilt bool
-
 	// createdResources are all the resources created by the task driver
 	// across all attempts to start the task.
 	// Simple gets and sets should use {get,set}CreatedResources
 	createdResources     *driver.CreatedResources
 	createdResourcesLock sync.Mutex
 
-	// payloadRendered tracks whether the payload has been rendered to disk
-	payloadRendered bool
-
 	// vaultFuture is the means to wait for and get a Vault token
 	vaultFuture *tokenFuture
 
-	// recoveredVaultToken is the token that was recovered through a restore
-	recoveredVaultToken string
-
 	// vaultClient is used to retrieve and renew any needed Vault token
 	vaultClient vaultclient.VaultClient
 
-	// templateManager is used to manage any consul-templates this task may have
-	templateManager *TaskTemplateManager
+	// cpusets confines the tasks without dedicated cores to the shared cores
+	cpusets *cpusetManager
+
+	// driverPlugins manages the plugin processes of the external drivers
+	driverPlugins *driver.DriverPluginManager
+
+	// hooks are run throughout the lifecycle of the task, in order
+	hooks []TaskHook
+
+	// hookStates is the persisted state of the hooks by name
+	hookStates     map[string]*hookState
+	hookStatesLock sync.Mutex
+
+	// ctx is cancelled when the task runner exits
+	ctx       context.Context
+	ctxCancel context.CancelFunc
 
 	// startCh is used to trigger the start of the task
 	startCh chan struct{}
@@ -186,13 +192,17 @@ type TaskRunner struct {
 
 // taskRunnerState is used to snapshot the state of the task runner
 type taskRunnerState struct {
-	Version            string
-	HandleID           string
+	Version  string
+	HandleID string
+
+	// COMPAT: Remove in 0.10. ArtifactDownloaded, TaskDirBuilt and
+	// PayloadRendered are persisted as the state of their hooks.
 	ArtifactDownloaded bool
 	TaskDirBuilt       bool
 	PayloadRendered    bool
-	CreatedResources   *driver.CreatedResources
-	DriverNetwork      *cstructs.DriverNetwork
+
+	CreatedResources *driver.CreatedResources
+	DriverNetwork    *cstructs.DriverNetwork
 }
 
 func (s *taskRunnerState) Hash() []byte {
@@ -229,7 +239,8 @@ type SignalEvent struct {
 func NewTaskRunner(logger *log.Logger, config *config.Config,
 	stateDB *bolt.DB, updater TaskStateUpdater, taskDir *allocdir.TaskDir,
 	alloc *structs.Allocation, task *structs.Task,
-	vaultClient vaultclient.VaultClient, consulClient ConsulServiceAPI) *TaskRunner {
+	vaultClient vaultclient.VaultClient, consulClient ConsulServiceAPI,
+	cpusets *cpusetManager, driverPlugins *driver.DriverPluginManager) *TaskRunner {
 
 	// Merge in the task resources
 	task.Resources = alloc.TaskResources[task.Name]
@@ -240,7 +251,7 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 		logger.Printf("[ERR] client: alloc %q for missing task group %q", alloc.ID, alloc.TaskGroup)
 		return nil
 	}
-	restartTracker := newRestartTracker(tg.RestartPolicy, alloc.Job.Type)
+	restartTracker := newRestartTracker(tg.RestartPolicy, alloc.Job.Type, task.Lifecycle)
 
 	// Initialize the environment builder
 	envBuilder := env.NewBuilder(config.Node, alloc, task, config.Region)
@@ -259,6 +270,8 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 		consul:           consulClient,
 		vaultClient:      vaultClient,
 		vaultFuture:      NewTokenFuture().Set(""),
+		cpusets:          cpusets,
+		driverPlugins:    driverPlugins,
 		updateCh:         make(chan *structs.Allocation, 64),
 		destroyCh:        make(chan struct{}),
 		waitCh:           make(chan struct{}),
@@ -266,7 +279,10 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 		unblockCh:        make(chan struct{}),
 		restartCh:        make(chan *taskRestartEvent),
 		signalCh:         make(chan SignalEvent),
+		hookStates:       make(map[string]*hookState),
 	}
+	tc.ctx, tc.ctxCancel = context.WithCancel(context.Background())
+	tc.initHooks()
 
 	tc.baseLabels = []metrics.Label{
 		{
@@ -356,7 +372,7 @@ func (r *TaskRunner) RestoreState() (string, error) {
 			if err := getObject(bkt, taskRunnerStateAllKey, &snap); err != nil {
 				return fmt.Errorf("failed to read task runner state: %v", err)
 			}
-			return nil
+			return r.restoreHookStates(bkt)
 		})
 		if err != nil {
 			return "", err
@@ -365,27 +381,22 @@ func (r *TaskRunner) RestoreState() (string, error) {
 	}
 
 	// Restore fields from the snapshot
-	r.artifactsDownloaded = snap.ArtifactDownloaded
-	r.taskDirBuilt = snap.TaskDirBuilt
-	r.payloadRendered = snap.PayloadRendered
 	r.setCreatedResources(snap.CreatedResources)
 	r.driverNet = snap.DriverNetwork
 
-	if r.task.Vault != nil {
-		// Read the token from the secret directory
-		tokenPath := filepath.Join(r.taskDir.SecretsDir, vaultTokenFile)
-		data, err := ioutil.ReadFile(tokenPath)
-		if err != nil {
-			if !os.IsNotExist(err) {
-				return "", fmt.Errorf("failed to read token for task %q in alloc %q: %v", r.task.Name, r.alloc.ID, err)
-			}
-
-			// Token file doesn't exist
-		} else {
-			// Store the recovered token
-			r.recoveredVaultToken = string(data)
-		}
+	// COMPAT: Remove in 0.10. Restore the state of the hooks from the fields
+	// persisted before the hooks persisted their own state.
+	r.hookStatesLock.Lock()
+	if _, ok := r.hookStates["task_dir"]; !ok && snap.TaskDirBuilt {
+		r.hookStates["task_dir"] = &hookState{Data: map[string]string{taskDirBuiltKey: "true"}}
 	}
+	if _, ok := r.hookStates["artifacts"]; !ok && snap.ArtifactDownloaded {
+		r.hookStates["artifacts"] = &hookState{PrestartDone: true}
+	}
+	if _, ok := r.hookStates["dispatch_payload"]; !ok && snap.PayloadRendered {
+		r.hookStates["dispatch_payload"] = &hookState{PrestartDone: true}
+	}
+	r.hookStatesLock.Unlock()
 
 	// Restore the driver
 	restartReason := ""
@@ -413,11 +424,11 @@ func (r *TaskRunner) RestoreState() (string, error) {
 			restartReason = pre06ScriptCheckReason
 		}
 
-		if err := r.registerServices(d, handle, r.driverNet); err != nil {
+		if err := r.poststart(d, handle, r.driverNet); err != nil {
 			// Don't hard fail here as there's a chance this task
 			// registered with Consul properly when it initial
 			// started.
-			r.logger.Printf("[WARN] client: failed to register services and checks with consul for task %q in alloc %q: %v",
+			r.logger.Printf("[WARN] client: failed to run poststart hooks for task %q in alloc %q: %v",
 				r.task.Name, r.alloc.ID, err)
 		}
 
@@ -477,11 +488,8 @@ func (r *TaskRunner) SaveState() error {
 	r.persistLock.Lock()
 	defer r.persistLock.Unlock()
 	snap := taskRunnerState{
-		Version:            r.config.Version.VersionNumber(),
-		ArtifactDownloaded: r.artifactsDownloaded,
-		TaskDirBuilt:       r.taskDirBuilt,
-		PayloadRendered:    r.payloadRendered,
-		CreatedResources:   r.getCreatedResources(),
+		Version:          r.config.Version.VersionNumber(),
+		CreatedResources: r.getCreatedResources(),
 	}
 
 	r.handleLock.Lock()
@@ -563,7 +571,7 @@ func (r *TaskRunner) createDriver() (driver.Driver, error) {
 		r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskDriverMessage).SetDriverMessage(msg), false)
 	}
 
-	driverCtx := driver.NewDriverContext(r.task.Name, r.alloc.ID, r.config, r.config.Node, r.logger, eventEmitter)
+	driverCtx := driver.NewDriverContext(r.task.Name, r.alloc.ID, r.config, r.config.Node, r.logger, eventEmitter, r.driverPlugins)
 	d, err := driver.NewDriver(r.task.Driver, driverCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver '%s' for alloc %s: %v",
@@ -576,50 +584,25 @@ func (r *TaskRunner) createDriver() (driver.Driver, error) {
 // Run is a long running routine used to manage the task
 func (r *TaskRunner) Run() {
 	defer close(r.waitCh)
+	defer r.ctxCancel()
 	r.logger.Printf("[DEBUG] client: starting task context for '%s' (alloc '%s')",
 		r.task.Name, r.alloc.ID)
 
-	if err := r.validateTask(); err != nil {
-		r.setState(
-			structs.TaskStateDead,
-			structs.NewTaskEvent(structs.TaskFailedValidation).SetValidationError(err).SetFailsTask(),
-			false)
-		return
-	}
-
-	// Create a temporary driver so that we can determine the FSIsolation
-	// required. run->startTask will create a new driver after environment
-	// has been setup (env vars, templates, artifacts, secrets, etc).
-	tmpDrv, err := r.createDriver()
-	if err != nil {
-		e := fmt.Errorf("failed to create driver of task %q for alloc %q: %v", r.task.Name, r.alloc.ID, err)
-		r.setState(
-			structs.TaskStateDead,
-			structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(e).SetFailsTask(),
-			false)
+	// The task may have been destroyed before it was started, for example
+	// because a task it was waiting on failed
+	if destroyed, event := r.isDestroyed(); destroyed {
+		r.setState(structs.TaskStateDead, event, false)
 		return
 	}
 
-	// Build base task directory structure regardless of FS isolation abilities.
-	// This needs to happen before we start the Vault manager and call prestart
-	// as both those can write to the task directories
-	if err := r.buildTaskDir(tmpDrv.FSIsolation()); err != nil {
-		e := fmt.Errorf("failed to build task directory for %q: %v", r.task.Name, err)
+	if err := r.validateTask(); err != nil {
 		r.setState(
 			structs.TaskStateDead,
-			structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(e).SetFailsTask(),
+			structs.NewTaskEvent(structs.TaskFailedValidation).SetValidationError(err).SetFailsTask(),
 			false)
 		return
 	}
 
-	// If there is no Vault policy leave the static future created in
-	// NewTaskRunner
-	if r.task.Vault != nil {
-		// Start the go-routine to get a Vault token
-		r.vaultFuture.Clear()
-		go r.vaultManager(r.recoveredVaultToken)
-	}
-
 	// Start the run loop
 	r.run()
 
@@ -731,353 +714,20 @@ func (f *tokenFuture) Get() string {
 	return f.token
 }
 
-// vaultManager should be called in a go-routine and manages the derivation,
-// renewal and handling of errors with the Vault token. The optional parameter
-// allows setting the initial Vault token. This is useful when the Vault token
-// is recovered off disk.
-func (r *TaskRunner) vaultManager(token string) {
-	// Helper for stopping token renewal
-	stopRenewal := func() {
-		if err := r.vaultClient.StopRenewToken(r.vaultFuture.Get()); err != nil {
-			r.logger.Printf("[WARN] client: failed to stop token renewal for task %v in alloc %q: %v", r.task.Name, r.alloc.ID, err)
-		}
-	}
-
-	// updatedToken lets us store state between loops. If true, a new token
-	// has been retrieved and we need to apply the Vault change mode
-	var updatedToken bool
-
-OUTER:
-	for {
-		// Check if we should exit
-		select {
-		case <-r.waitCh:
-			stopRenewal()
-			return
-		default:
-		}
-
-		// Clear the token
-		r.vaultFuture.Clear()
-
-		// Check if there already is a token which can be the case for
-		// restoring the TaskRunner
-		if token == "" {
-			// Get a token
-			var exit bool
-			token, exit = r.deriveVaultToken()
-			if exit {
-				// Exit the manager
-				return
-			}
-
-			// Write the token to disk
-			if err := r.writeToken(token); err != nil {
-				e := fmt.Errorf("failed to write Vault token to disk")
-				r.logger.Printf("[ERR] client: %v for task %v on alloc %q: %v", e, r.task.Name, r.alloc.ID, err)
-				r.Kill("vault", e.Error(), true)
-				return
-			}
-		}
-
-		// Start the renewal process
-		renewCh, err := r.vaultClient.RenewToken(token, 30)
-
-		// An error returned means the token is not being renewed
-		if err != nil {
-			r.logger.Printf("[ERR] client: failed to start renewal of Vault token for task %v on alloc %q: %v", r.task.Name, r.alloc.ID, err)
-			token = ""
-			goto OUTER
-		}
-
-		// The Vault token is valid now, so set it
-		r.vaultFuture.Set(token)
-
-		if updatedToken {
-			switch r.task.Vault.ChangeMode {
-			case structs.VaultChangeModeSignal:
-				s, err := signals.Parse(r.task.Vault.ChangeSignal)
-				if err != nil {
-					e := fmt.Errorf("failed to parse signal: %v", err)
-					r.logger.Printf("[ERR] client: %v", err)
-					r.Kill("vault", e.Error(), true)
-					return
-				}
-
-				if err := r.Signal("vault", "new Vault token acquired", s); err != nil {
-					r.logger.Printf("[ERR] client: failed to send signal to task %v for alloc %q: %v", r.task.Name, r.alloc.ID, err)
-					r.Kill("vault", fmt.Sprintf("failed to send signal to task: %v", err), true)
-					return
-				}
-			case structs.VaultChangeModeRestart:
-				const noFailure = false
-				r.Restart("vault", "new Vault token acquired", noFailure)
-			case structs.VaultChangeModeNoop:
-				fallthrough
-			default:
-				r.logger.Printf("[ERR] client: Invalid Vault change mode: %q", r.task.Vault.ChangeMode)
-			}
-
-			// We have handled it
-			updatedToken = false
-
-			// Call the handler
-			r.updatedTokenHandler()
-		}
-
-		// Start watching for renewal errors
-		select {
-		case err := <-renewCh:
-			// Clear the token
-			token = ""
-			r.logger.Printf("[ERR] client: failed to renew Vault token for task %v on alloc %q: %v", r.task.Name, r.alloc.ID, err)
-			stopRenewal()
-
-			// Check if we have to do anything
-			if r.task.Vault.ChangeMode != structs.VaultChangeModeNoop {
-				updatedToken = true
-			}
-		case <-r.waitCh:
-			stopRenewal()
-			return
-		}
-	}
-}
-
-// deriveVaultToken derives the Vault token using exponential backoffs. It
-// returns the Vault token and whether the manager should exit.
-func (r *TaskRunner) deriveVaultToken() (token string, exit bool) {
-	attempts := 0
-	for {
-		tokens, err := r.vaultClient.DeriveToken(r.alloc, []string{r.task.Name})
-		if err == nil {
-			return tokens[r.task.Name], false
-		}
-
-		// Check if this is a server side error
-		if structs.IsServerSide(err) {
-			r.logger.Printf("[ERR] client: failed to derive Vault token for task %v on alloc %q: %v",
-				r.task.Name, r.alloc.ID, err)
-			r.Kill("vault", fmt.Sprintf("server error deriving vault token: %v", err), true)
-			return "", true
-		}
-		// Check if we can't recover from the error
-		if !structs.IsRecoverable(err) {
-			r.logger.Printf("[ERR] client: failed to derive Vault token for task %v on alloc %q: %v",
-				r.task.Name, r.alloc.ID, err)
-			r.Kill("vault", fmt.Sprintf("failed to derive token: %v", err), true)
-			return "", true
-		}
-
-		// Handle the retry case
-		backoff := (1 << (2 * uint64(attempts))) * vaultBackoffBaseline
-		if backoff > vaultBackoffLimit {
-			backoff = vaultBackoffLimit
-		}
-		r.logger.Printf("[ERR] client: failed to derive Vault token for task %v on alloc %q: %v; retrying in %v",
-			r.task.Name, r.alloc.ID, err, backoff)
-
-		attempts++
-
-		// Wait till retrying
-		select {
-		case <-r.waitCh:
-			return "", true
-		case <-time.After(backoff):
-		}
-	}
-}
-
-// writeToken writes the given token to disk
-func (r *TaskRunner) writeToken(token string) error {
-	tokenPath := filepath.Join(r.taskDir.SecretsDir, vaultTokenFile)
-	if err := ioutil.WriteFile(tokenPath, []byte(token), 0777); err != nil {
-		return fmt.Errorf("failed to save Vault tokens to secret dir for task %q in alloc %q: %v", r.task.Name, r.alloc.ID, err)
-	}
-
-	return nil
-}
-
-// updatedTokenHandler is called when a new Vault token is retrieved. Things
-// that rely on the token should be updated here.
-func (r *TaskRunner) updatedTokenHandler() {
-
+// updatedVaultToken is called when a new Vault token is retrieved. Things
+// that rely on the token are updated through the update hooks.
+func (r *TaskRunner) updatedVaultToken(token string) {
 	// Update the tasks environment
-	r.envBuilder.SetVaultToken(r.vaultFuture.Get(), r.task.Vault.Env)
-
-	if r.templateManager != nil {
-		r.templateManager.Stop()
-
-		// Create a new templateManager
-		var err error
-		r.templateManager, err = NewTaskTemplateManager(&TaskTemplateManagerConfig{
-			Hooks:                r,
-			Templates:            r.task.Templates,
-			ClientConfig:         r.config,
-			VaultToken:           r.vaultFuture.Get(),
-			TaskDir:              r.taskDir.Dir,
-			EnvBuilder:           r.envBuilder,
-			MaxTemplateEventRate: DefaultMaxTemplateEventRate,
-		})
+	r.envBuilder.SetVaultToken(token, r.task.Vault.Env)
 
-		if err != nil {
-			err := fmt.Errorf("failed to build task's template manager: %v", err)
-			r.setState(structs.TaskStateDead,
-				structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
-				false)
-			r.logger.Printf("[ERR] client: alloc %q, task %q %v", r.alloc.ID, r.task.Name, err)
-			r.Kill("vault", err.Error(), true)
-			return
-		}
-	}
-}
-
-// prestart handles life-cycle tasks that occur before the task has started.
-// Since it's run asynchronously with the main Run() loop the alloc & task are
-// passed in to avoid racing with updates.
-func (r *TaskRunner) prestart(alloc *structs.Allocation, task *structs.Task, resultCh chan bool) {
-	if task.Vault != nil {
-		// Wait for the token
-		r.logger.Printf("[DEBUG] client: waiting for Vault token for task %v in alloc %q", task.Name, alloc.ID)
-		tokenCh := r.vaultFuture.Wait()
-		select {
-		case <-tokenCh:
-		case <-r.waitCh:
-			resultCh <- false
-			return
-		}
-		r.logger.Printf("[DEBUG] client: retrieved Vault token for task %v in alloc %q", task.Name, alloc.ID)
-		r.envBuilder.SetVaultToken(r.vaultFuture.Get(), task.Vault.Env)
-	}
-
-	// If the job is a dispatch job and there is a payload write it to disk
-	requirePayload := len(alloc.Job.Payload) != 0 &&
-		(r.task.DispatchPayload != nil && r.task.DispatchPayload.File != "")
-	if !r.payloadRendered && requirePayload {
-		renderTo := filepath.Join(r.taskDir.LocalDir, task.DispatchPayload.File)
-		decoded, err := snappy.Decode(nil, alloc.Job.Payload)
-		if err != nil {
-			r.setState(
-				structs.TaskStateDead,
-				structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
-				false)
-			resultCh <- false
-			return
-		}
-
-		if err := os.MkdirAll(filepath.Dir(renderTo), 07777); err != nil {
-			r.setState(
-				structs.TaskStateDead,
-				structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
-				false)
-			resultCh <- false
-			return
-		}
-
-		if err := ioutil.WriteFile(renderTo, decoded, 0777); err != nil {
-			r.setState(
-				structs.TaskStateDead,
-				structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
-				false)
-			resultCh <- false
-			return
-		}
-
-		r.payloadRendered = true
-	}
-
-	for {
-		r.persistLock.Lock()
-		downloaded := r.artifactsDownloaded
-		r.persistLock.Unlock()
-
-		// Download the task's artifacts
-		if !downloaded && len(task.Artifacts) > 0 {
-			r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskDownloadingArtifacts), false)
-			taskEnv := r.envBuilder.Build()
-			for _, artifact := range task.Artifacts {
-				if err := getter.GetArtifact(taskEnv, artifact, r.taskDir.Dir); err != nil {
-					wrapped := fmt.Errorf("failed to download artifact %q: %v", artifact.GetterSource, err)
-					r.logger.Printf("[DEBUG] client: %v", wrapped)
-					r.setState(structs.TaskStatePending,
-						structs.NewTaskEvent(structs.TaskArtifactDownloadFailed).SetDownloadError(wrapped), false)
-					r.restartTracker.SetStartError(structs.WrapRecoverable(wrapped.Error(), err))
-					goto RESTART
-				}
-			}
-
-			r.persistLock.Lock()
-			r.artifactsDownloaded = true
-			r.persistLock.Unlock()
-		}
-
-		// We don't have to wait for any template
-		if len(task.Templates) == 0 {
-			// Send the start signal
-			select {
-			case r.startCh <- struct{}{}:
-			default:
-			}
-
-			resultCh <- true
-			return
-		}
-
-		// Build the template manager
-		if r.templateManager == nil {
-			var err error
-			r.templateManager, err = NewTaskTemplateManager(&TaskTemplateManagerConfig{
-				Hooks:                r,
-				Templates:            r.task.Templates,
-				ClientConfig:         r.config,
-				VaultToken:           r.vaultFuture.Get(),
-				TaskDir:              r.taskDir.Dir,
-				EnvBuilder:           r.envBuilder,
-				MaxTemplateEventRate: DefaultMaxTemplateEventRate,
-			})
-			if err != nil {
-				err := fmt.Errorf("failed to build task's template manager: %v", err)
-				r.setState(structs.TaskStateDead, structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(), false)
-				r.logger.Printf("[ERR] client: alloc %q, task %q %v", alloc.ID, task.Name, err)
-				resultCh <- false
-				return
-			}
-		}
-
-		// Block for consul-template
-		// TODO Hooks should register themselves as blocking and then we can
-		// periodically enumerate what we are still blocked on
-		select {
-		case <-r.unblockCh:
-			// Send the start signal
-			select {
-			case r.startCh <- struct{}{}:
-			default:
-			}
-
-			resultCh <- true
-			return
-		case <-r.waitCh:
-			// The run loop has exited so exit too
-			resultCh <- false
-			return
-		}
-
-	RESTART:
-		restart := r.shouldRestart()
-		if !restart {
-			resultCh <- false
-			return
-		}
+	if err := r.updateHooks(r.alloc, r.task); err != nil {
+		r.logger.Printf("[ERR] client: alloc %q, task %q failed to handle new Vault token: %v", r.alloc.ID, r.task.Name, err)
 	}
 }
 
 // postrun is used to do any cleanup that is necessary after exiting the runloop
 func (r *TaskRunner) postrun() {
-	// Stop the template manager
-	if r.templateManager != nil {
-		r.templateManager.Stop()
-	}
+	r.stop()
 }
 
 // run is the main run loop that handles starting the application, destroying
@@ -1153,6 +803,7 @@ func (r *TaskRunner) run() {
 				// Log whether the task was successful or not.
 				r.restartTracker.SetWaitResult(waitRes)
 				r.setState("", r.waitErrorToEvent(waitRes), true)
+				r.exited()
 				if !waitRes.Successful() {
 					r.logger.Printf("[INFO] client: task %q for alloc %q failed: %v", r.task.Name, r.alloc.ID, waitRes)
 				} else {
@@ -1202,6 +853,7 @@ func (r *TaskRunner) run() {
 				if handleWaitCh != nil {
 					<-handleWaitCh
 				}
+				r.exited()
 
 				r.restartTracker.SetRestartTriggered(restartEvent.failure)
 				break WAIT
@@ -1216,17 +868,9 @@ func (r *TaskRunner) run() {
 					return
 				}
 
-				// Remove from consul before killing the task so that traffic
-				// can be rerouted
-				interpTask := interpolateServices(r.envBuilder.Build(), r.task)
-				r.consul.RemoveTask(r.alloc.ID, interpTask)
-
-				// Delay actually killing the task if configured. See #244
-				if r.task.ShutdownDelay > 0 {
-					r.logger.Printf("[DEBUG] client: delaying shutdown of alloc %q task %q for %q",
-						r.alloc.ID, r.task.Name, r.task.ShutdownDelay)
-					<-time.After(r.task.ShutdownDelay)
-				}
+				// Run the pre-kill hooks, for example to remove the task
+				// from Consul so that traffic can be rerouted
+				r.preKill()
 
 				// Store the task event that provides context on the task
 				// destroy. The Killed event is set from the alloc_runner and
@@ -1245,6 +889,7 @@ func (r *TaskRunner) run() {
 
 				// Wait for handler to exit before calling cleanup
 				<-handleWaitCh
+				r.exited()
 				r.cleanup()
 
 				r.setState(structs.TaskStateDead, nil, false)
@@ -1270,13 +915,8 @@ func (r *TaskRunner) run() {
 	}
 }
 
-// cleanup removes Consul entries and calls Driver.Cleanup when a task is
-// stopping. Errors are logged.
+// cleanup calls Driver.Cleanup when a task is stopping. Errors are logged.
 func (r *TaskRunner) cleanup() {
-	// Remove from Consul
-	interpTask := interpolateServices(r.envBuilder.Build(), r.task)
-	r.consul.RemoveTask(r.alloc.ID, interpTask)
-
 	drv, err := r.createDriver()
 	if err != nil {
 		r.logger.Printf("[ERR] client: error creating driver to cleanup resources: %v", err)
@@ -1337,10 +977,6 @@ func (r *TaskRunner) shouldRestart() bool {
 		return false
 	}
 
-	// Unregister from Consul while waiting to restart.
-	interpTask := interpolateServices(r.envBuilder.Build(), r.task)
-	r.consul.RemoveTask(r.alloc.ID, interpTask)
-
 	// Sleep but watch for destroy events.
 	select {
 	case <-time.After(when):
@@ -1413,8 +1049,18 @@ func (r *TaskRunner) startTask() error {
 			r.task.Name, r.alloc.ID, err)
 	}
 
+	// Tasks can only share the network namespace of the allocation if their
+	// driver can start them in it
+	netIsolation, allocNetwork := r.getNetworkIsolation()
+	if netIsolation != nil && !drv.Abilities().NetworkIsolation {
+		return structs.NewRecoverableError(fmt.Errorf("driver %q doesn't support the %q network mode",
+			r.task.Driver, netIsolation.Mode), false)
+	}
+
 	// Run prestart
 	ctx := driver.NewExecContext(r.taskDir, r.envBuilder.Build())
+	ctx.Mounts = r.getVolumeMounts()
+	ctx.NetworkIsolation = netIsolation
 	presp, err := drv.Prestart(ctx, r.task)
 
 	// Merge newly created resources into previously created resources
@@ -1436,6 +1082,11 @@ func (r *TaskRunner) startTask() error {
 
 	// Create a new context for Start since the environment may have been updated.
 	ctx = driver.NewExecContext(r.taskDir, r.envBuilder.Build())
+	ctx.Mounts = r.getVolumeMounts()
+	ctx.NetworkIsolation = netIsolation
+	if r.cpusets != nil && len(taskCores(r.task)) == 0 {
+		ctx.Cpuset = r.cpusets.Shared()
+	}
 
 	// Start the job
 	sresp, err := drv.Start(ctx, r.task)
@@ -1447,6 +1098,12 @@ func (r *TaskRunner) startTask() error {
 
 	}
 
+	// Tasks in the network namespace of the allocation are reached at its
+	// address unless their driver detected one
+	if allocNetwork != nil && (sresp.Network == nil || sresp.Network.IP == "") {
+		sresp.Network = allocNetwork.Copy()
+	}
+
 	// Log driver network information
 	if sresp.Network != nil && sresp.Network.IP != "" {
 		if sresp.Network.AutoAdvertise {
@@ -1465,10 +1122,10 @@ func (r *TaskRunner) startTask() error {
 	// Update environment with the network defined by the driver's Start method.
 	r.envBuilder.SetDriverNetwork(sresp.Network)
 
-	if err := r.registerServices(drv, sresp.Handle, sresp.Network); err != nil {
-		// All IO is done asynchronously, so errors from registering
-		// services are hard failures.
-		r.logger.Printf("[ERR] client: failed to register services and checks for task %q alloc %q: %v", r.task.Name, r.alloc.ID, err)
+	if err := r.poststart(drv, sresp.Handle, sresp.Network); err != nil {
+		// All IO is done asynchronously, so errors from the poststart
+		// hooks such as registering services are hard failures.
+		r.logger.Printf("[ERR] client: failed to run poststart hooks for task %q alloc %q: %v", r.task.Name, r.alloc.ID, err)
 
 		// Kill the started task
 		if destroyed, err := r.handleDestroy(sresp.Handle); !destroyed {
@@ -1490,17 +1147,6 @@ func (r *TaskRunner) startTask() error {
 	return nil
 }
 
-// registerServices and checks with Consul.
-func (r *TaskRunner) registerServices(d driver.Driver, h driver.DriverHandle, n *cstructs.DriverNetwork) error {
-	var exec driver.ScriptExecutor
-	if d.Abilities().Exec {
-		// Allow set the script executor if the driver supports it
-		exec = h
-	}
-	interpolatedTask := interpolateServices(r.envBuilder.Build(), r.task)
-	return r.consul.RegisterTask(r.alloc.ID, interpolatedTask, r, exec, n)
-}
-
 // interpolateServices interpolates tags in a service and checks with values from the
 // task's environment.
 func interpolateServices(taskEnv *env.TaskEnv, task *structs.Task) *structs.Task {
@@ -1535,40 +1181,6 @@ func interpolateServices(taskEnv *env.TaskEnv, task *structs.Task) *structs.Task
 	return taskCopy
 }
 
-// buildTaskDir creates the task directory before driver.Prestart. It is safe
-// to call multiple times as its state is persisted.
-func (r *TaskRunner) buildTaskDir(fsi cstructs.FSIsolation) error {
-	r.persistLock.Lock()
-	built := r.taskDirBuilt
-	r.persistLock.Unlock()
-
-	// We do not set the state again since this only occurs during restoration
-	// and the task dir is already built. The reason we call Build again is to
-	// ensure that the task dir invariants are still held.
-	if !built {
-		r.setState(structs.TaskStatePending,
-			structs.NewTaskEvent(structs.TaskSetup).SetMessage(structs.TaskBuildingTaskDir),
-			false)
-	}
-
-	chroot := config.DefaultChrootEnv
-	if len(r.config.ChrootEnv) > 0 {
-		chroot = r.config.ChrootEnv
-	}
-	if err := r.taskDir.Build(built, chroot, fsi); err != nil {
-		return err
-	}
-
-	// Mark task dir as successfully built
-	r.persistLock.Lock()
-	r.taskDirBuilt = true
-	r.persistLock.Unlock()
-
-	// Set path and host related env vars
-	driver.SetEnvvars(r.envBuilder, fsi, r.taskDir, r.config)
-	return nil
-}
-
 // collectResourceUsageStats starts collecting resource usage stats of a Task.
 // Collection ends when the passed channel is closed
 func (r *TaskRunner) collectResourceUsageStats(stopCollection <-chan struct{}) {
@@ -1653,37 +1265,24 @@ func (r *TaskRunner) handleUpdate(update *structs.Allocation) error {
 	// Merge in the task resources
 	updatedTask.Resources = update.TaskResources[updatedTask.Name]
 
-	// Interpolate the old task with the old env before updating the env as
-	// updating services in Consul need both the old and new interpolations
-	// to find differences.
-	oldInterpolatedTask := interpolateServices(r.envBuilder.Build(), r.task)
-
-	// Now it's safe to update the environment
+	// Update the environment
 	r.envBuilder.UpdateTask(update, updatedTask)
 
 	var mErr multierror.Error
 	r.handleLock.Lock()
 	if r.handle != nil {
-		drv, err := r.createDriver()
-		if err != nil {
-			// Something has really gone wrong; don't continue
-			r.handleLock.Unlock()
-			return fmt.Errorf("error accessing driver when updating task %q: %v", r.task.Name, err)
-		}
-
 		// Update will update resources and store the new kill timeout.
 		if err := r.handle.Update(updatedTask); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("updating task resources failed: %v", err))
 		}
-
-		// Update services in Consul
-		newInterpolatedTask := interpolateServices(r.envBuilder.Build(), updatedTask)
-		if err := r.updateServices(drv, r.handle, oldInterpolatedTask, newInterpolatedTask); err != nil {
-			mErr.Errors = append(mErr.Errors, fmt.Errorf("error updating services and checks in Consul: %v", err))
-		}
 	}
 	r.handleLock.Unlock()
 
+	// Run the update hooks, for example to update services in Consul
+	if err := r.updateHooks(update, updatedTask); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+
 	// Update the restart policy.
 	if r.restartTracker != nil {
 		r.restartTracker.SetPolicy(tg.RestartPolicy)
@@ -1695,19 +1294,6 @@ func (r *TaskRunner) handleUpdate(update *structs.Allocation) error {
 	return mErr.ErrorOrNil()
 }
 
-// updateServices and checks with Consul. Tasks must be interpolated!
-func (r *TaskRunner) updateServices(d driver.Driver, h driver.ScriptExecutor, oldTask, newTask *structs.Task) error {
-	var exec driver.ScriptExecutor
-	if d.Abilities().Exec {
-		// Allow set the script executor if the driver supports it
-		exec = h
-	}
-	r.driverNetLock.Lock()
-	net := r.driverNet.Copy()
-	r.driverNetLock.Unlock()
-	return r.consul.UpdateTask(r.alloc.ID, oldTask, newTask, r, exec, net)
-}
-
 // handleDestroy kills the task handle. In the case that killing fails,
 // handleDestroy will retry with an exponential backoff and will give up at a
 // given limit. It returns whether the task was destroyed and the error
@@ -1760,6 +1346,7 @@ func (r *TaskRunner) Signal(source, reason string, s os.Signal) error {
 	select {
 	case r.signalCh <- se:
 	case <-r.waitCh:
+		return fmt.Errorf("task %q is not running", r.task.Name)
 	}
 
 	return <-resCh
@@ -1832,6 +1419,14 @@ func (r *TaskRunner) Destroy(event *structs.TaskEvent) {
 	close(r.destroyCh)
 }
 
+// isDestroyed returns whether the task has been destroyed and the event
+// provided when it was.
+func (r *TaskRunner) isDestroyed() (bool, *structs.TaskEvent) {
+	r.destroyLock.Lock()
+	defer r.destroyLock.Unlock()
+	return r.destroy, r.destroyEvent
+}
+
 // getCreatedResources returns the resources created by drivers. It will never
 // return nil.
 func (r *TaskRunner) getCreatedResources() *driver.CreatedResources {
@@ -1856,6 +1451,37 @@ func (r *TaskRunner) setCreatedResources(cr *driver.CreatedResources) {
 	r.createdResourcesLock.Unlock()
 }
 
+// getVolumeMounts returns the volumes mounted into the task
+func (r *TaskRunner) getVolumeMounts() []*cstructs.MountConfig {
+	r.volumeMountsLock.Lock()
+	defer r.volumeMountsLock.Unlock()
+	return r.volumeMounts
+}
+
+// setVolumeMounts sets the volumes mounted into the task
+func (r *TaskRunner) setVolumeMounts(mounts []*cstructs.MountConfig) {
+	r.volumeMountsLock.Lock()
+	defer r.volumeMountsLock.Unlock()
+	r.volumeMounts = mounts
+}
+
+// getNetworkIsolation returns the network namespace the task is started in
+// and the network of the tasks in it
+func (r *TaskRunner) getNetworkIsolation() (*cstructs.NetworkIsolationSpec, *cstructs.DriverNetwork) {
+	r.networkIsolationLock.Lock()
+	defer r.networkIsolationLock.Unlock()
+	return r.networkIsolation, r.allocNetwork
+}
+
+// setNetworkIsolation sets the network namespace the task is started in and
+// the network of the tasks in it
+func (r *TaskRunner) setNetworkIsolation(spec *cstructs.NetworkIsolationSpec, network *cstructs.DriverNetwork) {
+	r.networkIsolationLock.Lock()
+	defer r.networkIsolationLock.Unlock()
+	r.networkIsolation = spec
+	r.allocNetwork = network
+}
+
 func (r *TaskRunner) setGaugeForMemory(ru *cstructs.TaskResourceUsage) {
 	if !r.config.DisableTaggedMetrics {
 		metrics.SetGaugeWithLabels([]string{"client", "allocs", "memory", "rss"},
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/nomad/client/getter"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// artifactHook downloads the artifacts of the task.
+type artifactHook struct {
+	runner *TaskRunner
+}
+
+func newArtifactHook(runner *TaskRunner) *artifactHook {
+	return &artifactHook{runner: runner}
+}
+
+func (h *artifactHook) Name() string {
+	return "artifacts"
+}
+
+// Prestart downloads the artifacts of the task. Once they have all been
+// downloaded they aren't downloaded again. Download failures are handled by
+// the restart policy.
+func (h *artifactHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	if len(req.Task.Artifacts) == 0 {
+		resp.Done = true
+		return nil
+	}
+
+	r := h.runner
+	r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskDownloadingArtifacts), false)
+	for _, artifact := range req.Task.Artifacts {
+		if err := getter.GetArtifact(req.TaskEnv, artifact, req.TaskDir.Dir); err != nil {
+			wrapped := fmt.Errorf("failed to download artifact %q: %v", artifact.GetterSource, err)
+			r.logger.Printf("[DEBUG] client: %v", wrapped)
+			r.setState(structs.TaskStatePending,
+				structs.NewTaskEvent(structs.TaskArtifactDownloadFailed).SetDownloadError(wrapped), false)
+			return structs.WrapRecoverable(wrapped.Error(), err)
+		}
+	}
+
+	resp.Done = true
+	return nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// cpusetHook removes the cores dedicated to the task from the cores shared by
+// the other tasks while it runs, or confines the task to the shared cores if
+// it has no dedicated cores.
+type cpusetHook struct {
+	runner *TaskRunner
+}
+
+func newCpusetHook(runner *TaskRunner) *cpusetHook {
+	return &cpusetHook{runner: runner}
+}
+
+func (h *cpusetHook) Name() string {
+	return "cpuset"
+}
+
+// Prestart removes the dedicated cores of the task from the shared cores
+// before it starts.
+func (h *cpusetHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	if cores := taskCores(req.Task); len(cores) > 0 {
+		h.runner.cpusets.AddPinned(h.key(), cores)
+	}
+	return nil
+}
+
+// Poststart confines the started or restored task to the shared cores if it
+// has no dedicated cores.
+func (h *cpusetHook) Poststart(ctx context.Context, req *TaskPoststartRequest) error {
+	if cores := taskCores(req.Task); len(cores) > 0 {
+		// Prestart isn't run for restored tasks
+		h.runner.cpusets.AddPinned(h.key(), cores)
+		return nil
+	}
+
+	if handle, ok := req.DriverHandle.(driver.CpusetUpdater); ok {
+		h.runner.cpusets.AddShared(h.key(), handle)
+	}
+	return nil
+}
+
+// Exited returns the dedicated cores of the task to the shared cores.
+func (h *cpusetHook) Exited(ctx context.Context) error {
+	h.runner.cpusets.Remove(h.key())
+	return nil
+}
+
+// Stop ensures the dedicated cores of the task are returned.
+func (h *cpusetHook) Stop(ctx context.Context) error {
+	h.runner.cpusets.Remove(h.key())
+	return nil
+}
+
+func (h *cpusetHook) key() string {
+	r := h.runner
+	return cpusetKey(r.alloc.ID, r.task.Name)
+}
+
+// taskCores returns the cores dedicated to the task.
+func taskCores(task *structs.Task) []uint16 {
+	if task.Resources == nil {
+		return nil
+	}
+	return task.Resources.CoreIDs
+}
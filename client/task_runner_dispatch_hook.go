@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/snappy"
+)
+
+// dispatchHook writes the payload of a dispatched job to the task directory.
+type dispatchHook struct {
+	runner *TaskRunner
+}
+
+func newDispatchHook(runner *TaskRunner) *dispatchHook {
+	return &dispatchHook{runner: runner}
+}
+
+func (h *dispatchHook) Name() string {
+	return "dispatch_payload"
+}
+
+// Prestart writes the payload to disk once if the job is a dispatch job and
+// the task requires it.
+func (h *dispatchHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	requirePayload := len(req.Alloc.Job.Payload) != 0 &&
+		(req.Task.DispatchPayload != nil && req.Task.DispatchPayload.File != "")
+	if !requirePayload {
+		resp.Done = true
+		return nil
+	}
+
+	renderTo := filepath.Join(req.TaskDir.LocalDir, req.Task.DispatchPayload.File)
+	decoded, err := snappy.Decode(nil, req.Alloc.Job.Payload)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(renderTo), 07777); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(renderTo, decoded, 0777); err != nil {
+		return err
+	}
+
+	resp.Done = true
+	return nil
+}
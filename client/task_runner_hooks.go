@@ -0,0 +1,445 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+)
+
+// TaskHook is the interface implemented by the hooks run by the TaskRunner
+// throughout the lifecycle of a task. A hook implements any of the
+// TaskPrestartHook, TaskPoststartHook, TaskUpdateHook, TaskExitedHook,
+// TaskPreKillHook and TaskStopHook interfaces to be called at that point of
+// the lifecycle. Hooks are called in the order they are registered.
+type TaskHook interface {
+	// Name returns the name of the hook. It is used to persist the state of
+	// the hook and must be unique within a task runner.
+	Name() string
+}
+
+// TaskPrestartRequest is the request passed to the prestart hooks.
+type TaskPrestartRequest struct {
+	// Alloc and Task are the allocation and task being started
+	Alloc *structs.Allocation
+	Task  *structs.Task
+
+	// TaskDir is the directory of the task
+	TaskDir *allocdir.TaskDir
+
+	// TaskEnv is the environment of the task as built by the previous hooks
+	TaskEnv *env.TaskEnv
+
+	// VaultToken is the Vault token of the task, if any
+	VaultToken string
+
+	// HookData is the data returned by the hook the last time it was run,
+	// including before the client restarted.
+	HookData map[string]string
+}
+
+// TaskPrestartResponse is the response returned by the prestart hooks.
+type TaskPrestartResponse struct {
+	// HookData is persisted and passed back to the hook the next time it is
+	// run.
+	HookData map[string]string
+
+	// Done marks the hook as having completed. It won't be run again when
+	// the task is restarted or restored.
+	Done bool
+}
+
+// TaskPrestartHook is called before the task is started, every time it is
+// started. Returning an error prevents the task from starting: a recoverable
+// error is handled by the restart policy while any other error fails the
+// task.
+type TaskPrestartHook interface {
+	TaskHook
+
+	// Prestart is called before the task is started. The context is
+	// cancelled when the task runner exits.
+	Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error
+}
+
+// TaskPoststartRequest is the request passed to the poststart hooks.
+type TaskPoststartRequest struct {
+	// Task is the task that was started
+	Task *structs.Task
+
+	// TaskEnv is the environment the task was started with
+	TaskEnv *env.TaskEnv
+
+	// DriverHandle is the handle of the started task
+	DriverHandle driver.DriverHandle
+
+	// DriverExec is used to execute commands in the task. It is nil if the
+	// driver doesn't support it.
+	DriverExec driver.ScriptExecutor
+
+	// DriverNetwork is the network of the task set by the driver, if any
+	DriverNetwork *cstructs.DriverNetwork
+}
+
+// TaskPoststartHook is called once the task has started or when it is
+// restored with a running handle. Returning an error kills the task and
+// restarts it according to the restart policy.
+type TaskPoststartHook interface {
+	TaskHook
+
+	// Poststart is called after the task has started.
+	Poststart(ctx context.Context, req *TaskPoststartRequest) error
+}
+
+// TaskUpdateRequest is the request passed to the update hooks.
+type TaskUpdateRequest struct {
+	// Alloc and Task are the updated allocation and task
+	Alloc *structs.Allocation
+	Task  *structs.Task
+
+	// TaskEnv is the updated environment of the task
+	TaskEnv *env.TaskEnv
+
+	// VaultToken is the current Vault token of the task, if any
+	VaultToken string
+}
+
+// TaskUpdateHook is called when the allocation of the task is updated or
+// when a new Vault token is acquired.
+type TaskUpdateHook interface {
+	TaskHook
+
+	// Update is called with the updated task.
+	Update(ctx context.Context, req *TaskUpdateRequest) error
+}
+
+// TaskExitedHook is called every time the task exits, whether it is going to
+// be restarted or not.
+type TaskExitedHook interface {
+	TaskHook
+
+	// Exited is called after the task has exited.
+	Exited(ctx context.Context) error
+}
+
+// TaskPreKillHook is called before a running task is killed because it is
+// being destroyed.
+type TaskPreKillHook interface {
+	TaskHook
+
+	// PreKill is called before the task is killed.
+	PreKill(ctx context.Context) error
+}
+
+// TaskStopHook is called once the task runner won't run the task anymore.
+type TaskStopHook interface {
+	TaskHook
+
+	// Stop is called when the task runner exits.
+	Stop(ctx context.Context) error
+}
+
+// hookState is the state of a task or alloc runner hook persisted in the
+// state database.
+type hookState struct {
+	// PrestartDone is set once the prestart or prerun hook has completed
+	PrestartDone bool
+
+	// Data is the data returned by the prestart or prerun hook
+	Data map[string]string
+}
+
+// hookStateKey returns the key the state of the named hook is persisted at in
+// the task or allocation bucket.
+func hookStateKey(name string) []byte {
+	return []byte("hook-" + name)
+}
+
+// initHooks registers the hooks of the task runner in the order they are
+// run.
+func (r *TaskRunner) initHooks() {
+	r.hooks = []TaskHook{
+		newTaskDirHook(r),
+	}
+
+	if len(r.task.VolumeMounts) != 0 {
+		r.hooks = append(r.hooks, newVolumeHook(r))
+	}
+
+	r.hooks = append(r.hooks, newLogsHook(r))
+
+	if r.task.Vault != nil {
+		r.hooks = append(r.hooks, newVaultHook(r))
+	}
+
+	r.hooks = append(r.hooks,
+		newDispatchHook(r),
+		newArtifactHook(r),
+	)
+
+	if len(r.task.Templates) != 0 {
+		r.hooks = append(r.hooks, newTemplateHook(r))
+	}
+
+	if r.cpusets != nil {
+		r.hooks = append(r.hooks, newCpusetHook(r))
+	}
+
+	r.hooks = append(r.hooks, newServiceHook(r))
+}
+
+// getHookState returns a copy of the persisted state of the named hook or nil
+// if there is none.
+func (r *TaskRunner) getHookState(name string) *hookState {
+	r.hookStatesLock.Lock()
+	defer r.hookStatesLock.Unlock()
+
+	state, ok := r.hookStates[name]
+	if !ok {
+		return nil
+	}
+	c := *state
+	return &c
+}
+
+// setHookState stores the state of the named hook and persists it if it has
+// changed.
+func (r *TaskRunner) setHookState(name string, state *hookState) error {
+	r.hookStatesLock.Lock()
+	if old, ok := r.hookStates[name]; ok && reflect.DeepEqual(old, state) {
+		r.hookStatesLock.Unlock()
+		return nil
+	}
+	r.hookStates[name] = state
+	r.hookStatesLock.Unlock()
+
+	r.destroyLock.Lock()
+	defer r.destroyLock.Unlock()
+	if r.destroy {
+		// Don't save state if already destroyed
+		return nil
+	}
+
+	r.persistLock.Lock()
+	defer r.persistLock.Unlock()
+	return r.stateDB.Batch(func(tx *bolt.Tx) error {
+		taskBkt, err := getTaskBucket(tx, r.alloc.ID, r.task.Name)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve allocation bucket: %v", err)
+		}
+
+		if err := putObject(taskBkt, hookStateKey(name), state); err != nil {
+			return fmt.Errorf("failed to write state of hook %q: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// restoreHookStates reads the persisted state of the hooks from the task
+// bucket.
+func (r *TaskRunner) restoreHookStates(bkt *bolt.Bucket) error {
+	r.hookStatesLock.Lock()
+	defer r.hookStatesLock.Unlock()
+
+	for _, hook := range r.hooks {
+		key := hookStateKey(hook.Name())
+		if bkt.Get(key) == nil {
+			continue
+		}
+
+		var state hookState
+		if err := getObject(bkt, key, &state); err != nil {
+			return fmt.Errorf("failed to read state of hook %q: %v", hook.Name(), err)
+		}
+		r.hookStates[hook.Name()] = &state
+	}
+	return nil
+}
+
+// prestart runs the prestart hooks of the task. Since it's run asynchronously
+// with the main Run() loop the alloc & task are passed in to avoid racing with
+// updates.
+func (r *TaskRunner) prestart(alloc *structs.Allocation, task *structs.Task, resultCh chan bool) {
+	for {
+		err := r.runPrestartHooks(alloc, task)
+		if err == nil {
+			// Send the start signal
+			select {
+			case r.startCh <- struct{}{}:
+			default:
+			}
+
+			resultCh <- true
+			return
+		}
+
+		// The run loop has exited so exit too
+		if r.ctx.Err() != nil {
+			resultCh <- false
+			return
+		}
+
+		// Errors that aren't recoverable errors fail the task while the
+		// others are handled by the restart policy
+		if _, ok := err.(structs.Recoverable); !ok {
+			r.setState(structs.TaskStateDead,
+				structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
+				false)
+			resultCh <- false
+			return
+		}
+
+		r.restartTracker.SetStartError(err)
+		if restart := r.shouldRestart(); !restart {
+			resultCh <- false
+			return
+		}
+	}
+}
+
+// runPrestartHooks runs the prestart hooks that haven't completed in order
+// and returns the first error.
+func (r *TaskRunner) runPrestartHooks(alloc *structs.Allocation, task *structs.Task) error {
+	for _, hook := range r.hooks {
+		pre, ok := hook.(TaskPrestartHook)
+		if !ok {
+			continue
+		}
+
+		name := pre.Name()
+		state := r.getHookState(name)
+		if state != nil && state.PrestartDone {
+			continue
+		}
+
+		req := &TaskPrestartRequest{
+			Alloc:      alloc,
+			Task:       task,
+			TaskDir:    r.taskDir,
+			TaskEnv:    r.envBuilder.Build(),
+			VaultToken: r.vaultFuture.Get(),
+		}
+		if state != nil {
+			req.HookData = state.Data
+		}
+
+		var resp TaskPrestartResponse
+		if err := pre.Prestart(r.ctx, req, &resp); err != nil {
+			r.logger.Printf("[ERR] client: prestart hook %q failed for task %q in alloc %q: %v",
+				name, task.Name, alloc.ID, err)
+			return err
+		}
+
+		state = &hookState{
+			PrestartDone: resp.Done,
+			Data:         resp.HookData,
+		}
+		if err := r.setHookState(name, state); err != nil {
+			r.logger.Printf("[WARN] client: failed to persist state of hook %q for task %q in alloc %q: %v",
+				name, task.Name, alloc.ID, err)
+		}
+	}
+	return nil
+}
+
+// poststart runs the poststart hooks of the task with the driver and handle
+// of the started task.
+func (r *TaskRunner) poststart(d driver.Driver, h driver.DriverHandle, n *cstructs.DriverNetwork) error {
+	req := &TaskPoststartRequest{
+		Task:          r.task,
+		TaskEnv:       r.envBuilder.Build(),
+		DriverHandle:  h,
+		DriverNetwork: n,
+	}
+	if d.Abilities().Exec {
+		// Allow set the script executor if the driver supports it
+		req.DriverExec = h
+	}
+
+	for _, hook := range r.hooks {
+		post, ok := hook.(TaskPoststartHook)
+		if !ok {
+			continue
+		}
+
+		if err := post.Poststart(r.ctx, req); err != nil {
+			return fmt.Errorf("poststart hook %q failed: %v", post.Name(), err)
+		}
+	}
+	return nil
+}
+
+// updateHooks runs the update hooks of the task and returns any errors.
+func (r *TaskRunner) updateHooks(alloc *structs.Allocation, task *structs.Task) error {
+	req := &TaskUpdateRequest{
+		Alloc:      alloc,
+		Task:       task,
+		TaskEnv:    r.envBuilder.Build(),
+		VaultToken: r.vaultFuture.Get(),
+	}
+
+	var mErr multierror.Error
+	for _, hook := range r.hooks {
+		upd, ok := hook.(TaskUpdateHook)
+		if !ok {
+			continue
+		}
+
+		if err := upd.Update(r.ctx, req); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("update hook %q failed: %v", upd.Name(), err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// exited runs the exited hooks of the task. Errors are logged.
+func (r *TaskRunner) exited() {
+	for _, hook := range r.hooks {
+		ex, ok := hook.(TaskExitedHook)
+		if !ok {
+			continue
+		}
+
+		if err := ex.Exited(r.ctx); err != nil {
+			r.logger.Printf("[WARN] client: exited hook %q failed for task %q in alloc %q: %v",
+				ex.Name(), r.task.Name, r.alloc.ID, err)
+		}
+	}
+}
+
+// preKill runs the pre-kill hooks of the task. Errors are logged.
+func (r *TaskRunner) preKill() {
+	for _, hook := range r.hooks {
+		pk, ok := hook.(TaskPreKillHook)
+		if !ok {
+			continue
+		}
+
+		if err := pk.PreKill(r.ctx); err != nil {
+			r.logger.Printf("[WARN] client: pre-kill hook %q failed for task %q in alloc %q: %v",
+				pk.Name(), r.task.Name, r.alloc.ID, err)
+		}
+	}
+}
+
+// stop runs the stop hooks of the task. Errors are logged.
+func (r *TaskRunner) stop() {
+	for _, hook := range r.hooks {
+		st, ok := hook.(TaskStopHook)
+		if !ok {
+			continue
+		}
+
+		if err := st.Stop(r.ctx); err != nil {
+			r.logger.Printf("[WARN] client: stop hook %q failed for task %q in alloc %q: %v",
+				st.Name(), r.task.Name, r.alloc.ID, err)
+		}
+	}
+}
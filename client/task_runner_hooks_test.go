@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// testPrestartHook is a prestart hook recording how many times it ran.
+type testPrestartHook struct {
+	calls int
+	lock  sync.Mutex
+}
+
+func (h *testPrestartHook) Name() string {
+	return "test"
+}
+
+func (h *testPrestartHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.calls++
+	resp.HookData = map[string]string{"foo": "bar"}
+	resp.Done = true
+	return nil
+}
+
+func (h *testPrestartHook) Calls() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.calls
+}
+
+func TestTaskRunner_Hooks_Order(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Vault = &structs.Vault{Policies: []string{"default"}}
+	task.Templates = []*structs.Template{
+		{
+			EmbeddedTmpl: "hello",
+			DestPath:     "local/test",
+			ChangeMode:   structs.TemplateChangeModeNoop,
+		},
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	defer ctx.Cleanup()
+
+	var names []string
+	for _, hook := range ctx.tr.hooks {
+		names = append(names, hook.Name())
+	}
+
+	expected := []string{"task_dir", "logs", "vault", "dispatch_payload", "artifacts", "template", "consul_services"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected hooks %v; got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected hooks %v; got %v", expected, names)
+		}
+	}
+}
+
+func TestTaskRunner_Hooks_SaveRestore(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"exit_code": "0",
+		"run_for":   "5s",
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	hook := &testPrestartHook{}
+	ctx.tr.hooks = append(ctx.tr.hooks, hook)
+	ctx.tr.MarkReceived()
+	go ctx.tr.Run()
+	defer ctx.Cleanup()
+
+	testWaitForTaskToStart(t, ctx)
+
+	if calls := hook.Calls(); calls != 1 {
+		t.Fatalf("expected prestart to be called once; got %d", calls)
+	}
+
+	// The task dir hook is never done as it must run on every start
+	if state := ctx.tr.getHookState("task_dir"); state == nil || state.PrestartDone || state.Data[taskDirBuiltKey] != "true" {
+		t.Fatalf("unexpected task dir hook state: %#v", state)
+	}
+
+	if err := ctx.tr.SaveState(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a new task runner restoring the state of the hooks
+	task2 := &structs.Task{Name: ctx.tr.task.Name, Driver: ctx.tr.task.Driver}
+	tr2 := NewTaskRunner(ctx.tr.logger, ctx.tr.config, ctx.tr.stateDB, ctx.upd.Update,
+		ctx.tr.taskDir, ctx.tr.alloc, task2, ctx.tr.vaultClient, ctx.tr.consul, nil, nil)
+	tr2.restartTracker = noRestartsTracker()
+	hook2 := &testPrestartHook{}
+	tr2.hooks = append(tr2.hooks, hook2)
+	if _, err := tr2.RestoreState(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	state := tr2.getHookState("test")
+	if state == nil || !state.PrestartDone || state.Data["foo"] != "bar" {
+		t.Fatalf("unexpected restored hook state: %#v", state)
+	}
+
+	go tr2.Run()
+	defer tr2.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+
+	select {
+	case <-tr2.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*15) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	// The completed hook must not have been run again
+	if calls := hook2.Calls(); calls != 0 {
+		t.Fatalf("expected prestart not to be called; got %d", calls)
+	}
+}
+
+func TestTaskRunner_VolumeHook(t *testing.T) {
+	t.Parallel()
+	hostDir, err := ioutil.TempDir("", "nomadtest-hostvolume")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(hostDir)
+	hostFile := filepath.Join(hostDir, "file")
+	if err := ioutil.WriteFile(hostFile, []byte("foo"), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	alloc := mock.Alloc()
+	alloc.Job.TaskGroups[0].Volumes = map[string]*structs.VolumeRequest{
+		"data": {
+			Name:   "data",
+			Type:   structs.VolumeTypeHost,
+			Source: "shared-data",
+		},
+	}
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"exit_code": "0",
+		"run_for":   "5s",
+	}
+	task.VolumeMounts = []*structs.VolumeMount{
+		{
+			Volume:      "data",
+			Destination: "/data",
+		},
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	ctx.tr.config.HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"shared-data": {
+			Name: "shared-data",
+			Path: hostDir,
+		},
+	}
+	ctx.tr.MarkReceived()
+	go ctx.tr.Run()
+
+	testWaitForTaskToStart(t, ctx)
+
+	// The volume is mounted in the task directory
+	path := filepath.Join(ctx.tr.taskDir.Dir, "data")
+	if _, err := ioutil.ReadFile(filepath.Join(path, "file")); err != nil {
+		t.Fatalf("expected the host file in the volume: %v", err)
+	}
+	mounts := ctx.tr.getVolumeMounts()
+	if len(mounts) != 1 || mounts[0].HostPath != hostDir || mounts[0].TaskPath != path || mounts[0].Readonly {
+		t.Fatalf("unexpected mounts: %#v", mounts)
+	}
+
+	// Destroying the alloc dir must leave the host directory intact
+	ctx.Cleanup()
+	if _, err := os.Stat(hostFile); err != nil {
+		t.Fatalf("expected the host file to be intact: %v", err)
+	}
+}
+
+func TestTaskRunner_VolumeHook_MissingHostVolume(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	alloc.Job.TaskGroups[0].Volumes = map[string]*structs.VolumeRequest{
+		"data": {
+			Name:   "data",
+			Type:   structs.VolumeTypeHost,
+			Source: "shared-data",
+		},
+	}
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"exit_code": "0",
+		"run_for":   "5s",
+	}
+	task.VolumeMounts = []*structs.VolumeMount{
+		{
+			Volume:      "data",
+			Destination: "/data",
+		},
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	ctx.tr.MarkReceived()
+	go ctx.tr.Run()
+	defer ctx.Cleanup()
+
+	select {
+	case <-ctx.tr.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*15) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	// The task fails to be set up
+	var found bool
+	for _, e := range ctx.upd.events {
+		if e.Type == structs.TaskSetupFailure && e.FailsTask {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a setup failure; got %#v", ctx.upd.events)
+	}
+}
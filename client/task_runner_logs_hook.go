@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// logsHook prepares the logging of the task's stdout and stderr, which the
+// drivers write to the shared log directory of the allocation.
+type logsHook struct {
+	runner *TaskRunner
+}
+
+func newLogsHook(runner *TaskRunner) *logsHook {
+	return &logsHook{runner: runner}
+}
+
+func (h *logsHook) Name() string {
+	return "logs"
+}
+
+// Prestart ensures the log directory exists, as it may have been removed
+// since the allocation directory was built.
+func (h *logsHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	if err := os.MkdirAll(req.TaskDir.LogDir, 0777); err != nil {
+		return fmt.Errorf("failed to create log directory for task %q: %v", req.Task.Name, err)
+	}
+	return nil
+}
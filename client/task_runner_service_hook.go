@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+)
+
+// serviceHook registers the services and checks of the task with Consul while
+// it runs.
+type serviceHook struct {
+	runner *TaskRunner
+
+	// registered marks whether the services of the task are registered
+	registered bool
+
+	// task is the interpolated task the services were registered with
+	task *structs.Task
+
+	// exec and net are the script executor and network of the running task
+	exec driver.ScriptExecutor
+	net  *cstructs.DriverNetwork
+
+	lock sync.Mutex
+}
+
+func newServiceHook(runner *TaskRunner) *serviceHook {
+	return &serviceHook{runner: runner}
+}
+
+func (h *serviceHook) Name() string {
+	return "consul_services"
+}
+
+// Poststart registers the services and checks of the started task.
+func (h *serviceHook) Poststart(ctx context.Context, req *TaskPoststartRequest) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	r := h.runner
+	h.task = withGroupNetworks(r.alloc, interpolateServices(req.TaskEnv, req.Task))
+	h.exec = req.DriverExec
+	h.net = req.DriverNetwork
+	if err := r.consul.RegisterTask(r.alloc.ID, h.task, r, h.exec, h.net); err != nil {
+		// Remove anything that may have been registered
+		r.consul.RemoveTask(r.alloc.ID, h.task)
+		return err
+	}
+	h.registered = true
+	return nil
+}
+
+// Update updates the services and checks of the running task in Consul.
+func (h *serviceHook) Update(ctx context.Context, req *TaskUpdateRequest) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.registered {
+		return nil
+	}
+
+	r := h.runner
+	task := withGroupNetworks(r.alloc, interpolateServices(req.TaskEnv, req.Task))
+	if err := r.consul.UpdateTask(r.alloc.ID, h.task, task, r, h.exec, h.net); err != nil {
+		return err
+	}
+	h.task = task
+	return nil
+}
+
+// Exited deregisters the services of the task so no traffic is routed to it
+// while it is restarted or stopped.
+func (h *serviceHook) Exited(ctx context.Context) error {
+	h.deregister()
+	return nil
+}
+
+// PreKill deregisters the services before the task is killed so traffic can
+// be rerouted, and delays the kill by the shutdown delay of the task.
+func (h *serviceHook) PreKill(ctx context.Context) error {
+	h.deregister()
+
+	// Delay actually killing the task if configured. See #244
+	r := h.runner
+	if r.task.ShutdownDelay > 0 {
+		r.logger.Printf("[DEBUG] client: delaying shutdown of alloc %q task %q for %q",
+			r.alloc.ID, r.task.Name, r.task.ShutdownDelay)
+		<-time.After(r.task.ShutdownDelay)
+	}
+	return nil
+}
+
+// Stop ensures the services of the task are deregistered.
+func (h *serviceHook) Stop(ctx context.Context) error {
+	h.deregister()
+	return nil
+}
+
+// deregister removes the services of the task from Consul if they are
+// registered.
+func (h *serviceHook) deregister() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.registered {
+		return
+	}
+
+	r := h.runner
+	r.consul.RemoveTask(r.alloc.ID, h.task)
+	h.registered = false
+}
+
+// withGroupNetworks adds the group network of the allocation to the networks
+// of the task copy so its services can be registered on the ports of the
+// group network.
+func withGroupNetworks(alloc *structs.Allocation, task *structs.Task) *structs.Task {
+	if alloc.SharedResources == nil || len(alloc.SharedResources.Networks) == 0 {
+		return task
+	}
+	if task.Resources == nil {
+		task.Resources = &structs.Resources{}
+	}
+	for _, n := range alloc.SharedResources.Networks {
+		task.Resources.Networks = append(task.Resources.Networks, n.Copy())
+	}
+	return task
+}
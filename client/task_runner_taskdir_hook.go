@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// taskDirBuiltKey is the hook data key marking the task directory as
+	// built
+	taskDirBuiltKey = "built"
+)
+
+// taskDirHook builds the task directory before the other hooks write to it
+// and sets the path related environment variables of the task.
+type taskDirHook struct {
+	runner *TaskRunner
+}
+
+func newTaskDirHook(runner *TaskRunner) *taskDirHook {
+	return &taskDirHook{runner: runner}
+}
+
+func (h *taskDirHook) Name() string {
+	return "task_dir"
+}
+
+// Prestart builds the task directory. It is run every time the task starts to
+// ensure the task dir invariants are still held and to set the environment.
+func (h *taskDirHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	r := h.runner
+
+	// Create a temporary driver so that we can determine the FSIsolation
+	// required. startTask will create a new driver after environment has
+	// been setup (env vars, templates, artifacts, secrets, etc).
+	tmpDrv, err := r.createDriver()
+	if err != nil {
+		return fmt.Errorf("failed to create driver of task %q for alloc %q: %v", req.Task.Name, req.Alloc.ID, err)
+	}
+	fsi := tmpDrv.FSIsolation()
+
+	// We do not set the state again when the task dir was already built
+	// since this only occurs during restarts and restoration.
+	built := req.HookData[taskDirBuiltKey] == "true"
+	if !built {
+		r.setState(structs.TaskStatePending,
+			structs.NewTaskEvent(structs.TaskSetup).SetMessage(structs.TaskBuildingTaskDir),
+			false)
+	}
+
+	chroot := config.DefaultChrootEnv
+	if len(r.config.ChrootEnv) > 0 {
+		chroot = r.config.ChrootEnv
+	}
+	if err := req.TaskDir.Build(built, chroot, fsi); err != nil {
+		return fmt.Errorf("failed to build task directory for %q: %v", req.Task.Name, err)
+	}
+
+	// Set path and host related env vars
+	driver.SetEnvvars(r.envBuilder, fsi, req.TaskDir, r.config)
+
+	resp.HookData = map[string]string{taskDirBuiltKey: "true"}
+	return nil
+}
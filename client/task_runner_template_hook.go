@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// templateHook renders the consul-templates of the task and blocks the start
+// of the task until they have been rendered.
+type templateHook struct {
+	runner *TaskRunner
+
+	// manager is used to manage the consul-templates of the task
+	manager *TaskTemplateManager
+
+	// vaultToken is the Vault token the manager was created with
+	vaultToken string
+
+	managerLock sync.Mutex
+}
+
+func newTemplateHook(runner *TaskRunner) *templateHook {
+	return &templateHook{runner: runner}
+}
+
+func (h *templateHook) Name() string {
+	return "template"
+}
+
+// Prestart creates the template manager the first time it is called and
+// blocks until the templates are rendered.
+func (h *templateHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	h.managerLock.Lock()
+	if h.manager == nil {
+		if err := h.newManager(req.Task, req.VaultToken); err != nil {
+			h.managerLock.Unlock()
+			return err
+		}
+	}
+	h.managerLock.Unlock()
+
+	// Block for consul-template
+	select {
+	case <-h.runner.unblockCh:
+		return nil
+	case <-ctx.Done():
+		// The run loop has exited so exit too
+		return ctx.Err()
+	}
+}
+
+// Update rebuilds the template manager when a new Vault token has been
+// acquired so the templates are rendered with it.
+func (h *templateHook) Update(ctx context.Context, req *TaskUpdateRequest) error {
+	h.managerLock.Lock()
+	defer h.managerLock.Unlock()
+
+	if h.manager == nil || req.VaultToken == h.vaultToken {
+		return nil
+	}
+
+	h.manager.Stop()
+	if err := h.newManager(req.Task, req.VaultToken); err != nil {
+		r := h.runner
+		r.setState(structs.TaskStateDead,
+			structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
+			false)
+		r.Kill("vault", err.Error(), true)
+		return err
+	}
+	return nil
+}
+
+// Stop stops the template manager.
+func (h *templateHook) Stop(ctx context.Context) error {
+	h.managerLock.Lock()
+	defer h.managerLock.Unlock()
+
+	if h.manager != nil {
+		h.manager.Stop()
+	}
+	return nil
+}
+
+// getManager returns the current template manager.
+func (h *templateHook) getManager() *TaskTemplateManager {
+	h.managerLock.Lock()
+	defer h.managerLock.Unlock()
+	return h.manager
+}
+
+// newManager creates the template manager with the given Vault token. The
+// manager lock must be held.
+func (h *templateHook) newManager(task *structs.Task, vaultToken string) error {
+	r := h.runner
+	manager, err := NewTaskTemplateManager(&TaskTemplateManagerConfig{
+		Hooks:                r,
+		Templates:            task.Templates,
+		ClientConfig:         r.config,
+		VaultToken:           vaultToken,
+		TaskDir:              r.taskDir.Dir,
+		EnvBuilder:           r.envBuilder,
+		MaxTemplateEventRate: DefaultMaxTemplateEventRate,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build task's template manager: %v", err)
+	}
+
+	h.manager = manager
+	h.vaultToken = vaultToken
+	return nil
+}
@@ -1,9 +1,12 @@
 package client
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -19,6 +22,7 @@ import (
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/logging"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/hashicorp/nomad/command/agent/consul"
@@ -42,7 +46,7 @@ func prefixedTestLogger(prefix string) *log.Logger {
 // Returns a tracker that never restarts.
 func noRestartsTracker() *RestartTracker {
 	policy := &structs.RestartPolicy{Attempts: 0, Mode: structs.RestartPolicyModeFail}
-	return newRestartTracker(policy, structs.JobTypeBatch)
+	return newRestartTracker(policy, structs.JobTypeBatch, nil)
 }
 
 type MockTaskStateUpdater struct {
@@ -145,7 +149,7 @@ func testTaskRunnerFromAlloc(t *testing.T, restarts bool, alloc *structs.Allocat
 	cclient := consul.NewMockAgent()
 	serviceClient := consul.NewServiceClient(cclient, logger)
 	go serviceClient.Run()
-	tr := NewTaskRunner(logger, conf, db, upd.Update, taskDir, alloc, task, vclient, serviceClient)
+	tr := NewTaskRunner(logger, conf, db, upd.Update, taskDir, alloc, task, vclient, serviceClient, nil, nil)
 	if !restarts {
 		tr.restartTracker = noRestartsTracker()
 	}
@@ -450,7 +454,7 @@ func TestTaskRunner_SaveRestoreState(t *testing.T) {
 	// Create a new task runner
 	task2 := &structs.Task{Name: ctx.tr.task.Name, Driver: ctx.tr.task.Driver, Vault: ctx.tr.task.Vault}
 	tr2 := NewTaskRunner(ctx.tr.logger, ctx.tr.config, ctx.tr.stateDB, ctx.upd.Update,
-		ctx.tr.taskDir, ctx.tr.alloc, task2, ctx.tr.vaultClient, ctx.tr.consul)
+		ctx.tr.taskDir, ctx.tr.alloc, task2, ctx.tr.vaultClient, ctx.tr.consul, nil, nil)
 	tr2.restartTracker = noRestartsTracker()
 	if _, err := tr2.RestoreState(); err != nil {
 		t.Fatalf("err: %v", err)
@@ -471,6 +475,69 @@ func TestTaskRunner_SaveRestoreState(t *testing.T) {
 	}
 }
 
+func TestTaskRunner_LogShipper(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	linesCh := make(chan *logging.LogLine, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			var line logging.LogLine
+			if err := json.Unmarshal(s.Bytes(), &line); err != nil {
+				return
+			}
+			linesCh <- &line
+		}
+	}()
+
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"exit_code":     "0",
+		"run_for":       "1s",
+		"stdout_string": "hello\n",
+	}
+	task.LogConfig.Shippers = []*structs.LogShipper{
+		{
+			Type:          structs.LogShipperTypeTCP,
+			Address:       l.Addr().String(),
+			BufferSize:    10,
+			BatchSize:     1,
+			FlushInterval: 10 * time.Millisecond,
+		},
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	ctx.tr.MarkReceived()
+	go ctx.tr.Run()
+	defer ctx.Cleanup()
+
+	select {
+	case line := <-linesCh:
+		if line.Message != "hello" || line.Stream != "stdout" {
+			t.Fatalf("unexpected line: %#v", line)
+		}
+		if line.AllocID != alloc.ID || line.JobID != alloc.JobID || line.TaskGroup != alloc.TaskGroup ||
+			line.Task != task.Name || line.Namespace != alloc.Namespace {
+			t.Fatalf("line not tagged with the task metadata: %#v", line)
+		}
+	case <-time.After(time.Duration(testutil.TestMultiplier()*10) * time.Second):
+		t.Fatalf("timeout waiting for the shipped line")
+	}
+}
+
 func TestTaskRunner_Download_List(t *testing.T) {
 	t.Parallel()
 	ts := httptest.NewServer(http.FileServer(http.Dir(filepath.Dir("."))))
@@ -1266,6 +1333,17 @@ func TestTaskRunner_Template_Artifact(t *testing.T) {
 	}
 }
 
+// templateManagerOf returns the template manager of the task runner's
+// template hook.
+func templateManagerOf(tr *TaskRunner) *TaskTemplateManager {
+	for _, h := range tr.hooks {
+		if th, ok := h.(*templateHook); ok {
+			return th.getManager()
+		}
+	}
+	return nil
+}
+
 func TestTaskRunner_Template_NewVaultToken(t *testing.T) {
 	t.Parallel()
 	alloc := mock.Alloc()
@@ -1307,7 +1385,7 @@ func TestTaskRunner_Template_NewVaultToken(t *testing.T) {
 		t.Fatalf("no renewal channel")
 	}
 
-	originalManager := ctx.tr.templateManager
+	originalManager := templateManagerOf(ctx.tr)
 
 	renewalCh <- fmt.Errorf("Test killing")
 	close(renewalCh)
@@ -1319,7 +1397,7 @@ func TestTaskRunner_Template_NewVaultToken(t *testing.T) {
 			return false, fmt.Errorf("No new Vault token")
 		}
 
-		if originalManager == ctx.tr.templateManager {
+		if originalManager == templateManagerOf(ctx.tr) {
 			return false, fmt.Errorf("Template manager not ctx.updated")
 		}
 
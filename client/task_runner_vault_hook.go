@@ -0,0 +1,248 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul-template/signals"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// vaultHook derives the Vault token of the task, writes it to the secrets
+// directory and renews it for as long as the task runner runs, applying the
+// change mode of the task when the token has to be replaced.
+type vaultHook struct {
+	runner *TaskRunner
+
+	// started marks whether the Vault manager has been started
+	started     bool
+	startedLock sync.Mutex
+}
+
+func newVaultHook(runner *TaskRunner) *vaultHook {
+	return &vaultHook{runner: runner}
+}
+
+func (h *vaultHook) Name() string {
+	return "vault"
+}
+
+// Prestart starts the Vault manager the first time it is called and waits for
+// a valid token. A token recovered from the secrets directory, for example
+// after the client restarted, is used before deriving a new one.
+func (h *vaultHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	r := h.runner
+
+	h.startedLock.Lock()
+	if !h.started {
+		// Read the token from the secret directory
+		var token string
+		tokenPath := filepath.Join(req.TaskDir.SecretsDir, vaultTokenFile)
+		data, err := ioutil.ReadFile(tokenPath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				h.startedLock.Unlock()
+				return fmt.Errorf("failed to read token for task %q in alloc %q: %v", req.Task.Name, req.Alloc.ID, err)
+			}
+
+			// Token file doesn't exist
+		} else {
+			// Store the recovered token
+			token = string(data)
+		}
+
+		// Start the go-routine to get a Vault token
+		r.vaultFuture.Clear()
+		go h.vaultManager(ctx, token)
+		h.started = true
+	}
+	h.startedLock.Unlock()
+
+	// Wait for the token
+	r.logger.Printf("[DEBUG] client: waiting for Vault token for task %v in alloc %q", req.Task.Name, req.Alloc.ID)
+	tokenCh := r.vaultFuture.Wait()
+	select {
+	case <-tokenCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	r.logger.Printf("[DEBUG] client: retrieved Vault token for task %v in alloc %q", req.Task.Name, req.Alloc.ID)
+	r.envBuilder.SetVaultToken(r.vaultFuture.Get(), req.Task.Vault.Env)
+	return nil
+}
+
+// vaultManager should be called in a go-routine and manages the derivation,
+// renewal and handling of errors with the Vault token. The optional parameter
+// allows setting the initial Vault token. This is useful when the Vault token
+// is recovered off disk.
+func (h *vaultHook) vaultManager(ctx context.Context, token string) {
+	r := h.runner
+
+	// Helper for stopping token renewal
+	stopRenewal := func() {
+		if err := r.vaultClient.StopRenewToken(r.vaultFuture.Get()); err != nil {
+			r.logger.Printf("[WARN] client: failed to stop token renewal for task %v in alloc %q: %v", r.task.Name, r.alloc.ID, err)
+		}
+	}
+
+	// updatedToken lets us store state between loops. If true, a new token
+	// has been retrieved and we need to apply the Vault change mode
+	var updatedToken bool
+
+OUTER:
+	for {
+		// Check if we should exit
+		select {
+		case <-ctx.Done():
+			stopRenewal()
+			return
+		default:
+		}
+
+		// Clear the token
+		r.vaultFuture.Clear()
+
+		// Check if there already is a token which can be the case for
+		// restoring the TaskRunner
+		if token == "" {
+			// Get a token
+			var exit bool
+			token, exit = h.deriveVaultToken(ctx)
+			if exit {
+				// Exit the manager
+				return
+			}
+
+			// Write the token to disk
+			if err := h.writeToken(token); err != nil {
+				e := fmt.Errorf("failed to write Vault token to disk")
+				r.logger.Printf("[ERR] client: %v for task %v on alloc %q: %v", e, r.task.Name, r.alloc.ID, err)
+				r.Kill("vault", e.Error(), true)
+				return
+			}
+		}
+
+		// Start the renewal process
+		renewCh, err := r.vaultClient.RenewToken(token, 30)
+
+		// An error returned means the token is not being renewed
+		if err != nil {
+			r.logger.Printf("[ERR] client: failed to start renewal of Vault token for task %v on alloc %q: %v", r.task.Name, r.alloc.ID, err)
+			token = ""
+			goto OUTER
+		}
+
+		// The Vault token is valid now, so set it
+		r.vaultFuture.Set(token)
+
+		if updatedToken {
+			switch r.task.Vault.ChangeMode {
+			case structs.VaultChangeModeSignal:
+				s, err := signals.Parse(r.task.Vault.ChangeSignal)
+				if err != nil {
+					e := fmt.Errorf("failed to parse signal: %v", err)
+					r.logger.Printf("[ERR] client: %v", err)
+					r.Kill("vault", e.Error(), true)
+					return
+				}
+
+				if err := r.Signal("vault", "new Vault token acquired", s); err != nil {
+					r.logger.Printf("[ERR] client: failed to send signal to task %v for alloc %q: %v", r.task.Name, r.alloc.ID, err)
+					r.Kill("vault", fmt.Sprintf("failed to send signal to task: %v", err), true)
+					return
+				}
+			case structs.VaultChangeModeRestart:
+				const noFailure = false
+				r.Restart("vault", "new Vault token acquired", noFailure)
+			case structs.VaultChangeModeNoop:
+				fallthrough
+			default:
+				r.logger.Printf("[ERR] client: Invalid Vault change mode: %q", r.task.Vault.ChangeMode)
+			}
+
+			// We have handled it
+			updatedToken = false
+
+			// Call the handler
+			r.updatedVaultToken(token)
+		}
+
+		// Start watching for renewal errors
+		select {
+		case err := <-renewCh:
+			// Clear the token
+			token = ""
+			r.logger.Printf("[ERR] client: failed to renew Vault token for task %v on alloc %q: %v", r.task.Name, r.alloc.ID, err)
+			stopRenewal()
+
+			// Check if we have to do anything
+			if r.task.Vault.ChangeMode != structs.VaultChangeModeNoop {
+				updatedToken = true
+			}
+		case <-ctx.Done():
+			stopRenewal()
+			return
+		}
+	}
+}
+
+// deriveVaultToken derives the Vault token using exponential backoffs. It
+// returns the Vault token and whether the manager should exit.
+func (h *vaultHook) deriveVaultToken(ctx context.Context) (token string, exit bool) {
+	r := h.runner
+	attempts := 0
+	for {
+		tokens, err := r.vaultClient.DeriveToken(r.alloc, []string{r.task.Name})
+		if err == nil {
+			return tokens[r.task.Name], false
+		}
+
+		// Check if this is a server side error
+		if structs.IsServerSide(err) {
+			r.logger.Printf("[ERR] client: failed to derive Vault token for task %v on alloc %q: %v",
+				r.task.Name, r.alloc.ID, err)
+			r.Kill("vault", fmt.Sprintf("server error deriving vault token: %v", err), true)
+			return "", true
+		}
+		// Check if we can't recover from the error
+		if !structs.IsRecoverable(err) {
+			r.logger.Printf("[ERR] client: failed to derive Vault token for task %v on alloc %q: %v",
+				r.task.Name, r.alloc.ID, err)
+			r.Kill("vault", fmt.Sprintf("failed to derive token: %v", err), true)
+			return "", true
+		}
+
+		// Handle the retry case
+		backoff := (1 << (2 * uint64(attempts))) * vaultBackoffBaseline
+		if backoff > vaultBackoffLimit {
+			backoff = vaultBackoffLimit
+		}
+		r.logger.Printf("[ERR] client: failed to derive Vault token for task %v on alloc %q: %v; retrying in %v",
+			r.task.Name, r.alloc.ID, err, backoff)
+
+		attempts++
+
+		// Wait till retrying
+		select {
+		case <-ctx.Done():
+			return "", true
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// writeToken writes the given token to disk
+func (h *vaultHook) writeToken(token string) error {
+	r := h.runner
+	tokenPath := filepath.Join(r.taskDir.SecretsDir, vaultTokenFile)
+	if err := ioutil.WriteFile(tokenPath, []byte(token), 0777); err != nil {
+		return fmt.Errorf("failed to save Vault tokens to secret dir for task %q in alloc %q: %v", r.task.Name, r.alloc.ID, err)
+	}
+
+	return nil
+}
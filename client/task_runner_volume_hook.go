@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// volumeHook mounts the host volumes of the task group into the task. Volumes
+// are mounted into the task directory unless the driver isolates the task in
+// an image, in which case the driver mounts them.
+type volumeHook struct {
+	runner *TaskRunner
+}
+
+func newVolumeHook(runner *TaskRunner) *volumeHook {
+	return &volumeHook{runner: runner}
+}
+
+func (h *volumeHook) Name() string {
+	return "volumes"
+}
+
+// Prestart mounts the volumes. It is run every time the task starts as the
+// driver mounts the volumes of tasks with image isolation on every start.
+func (h *volumeHook) Prestart(ctx context.Context, req *TaskPrestartRequest, resp *TaskPrestartResponse) error {
+	r := h.runner
+
+	tg := req.Alloc.Job.LookupTaskGroup(req.Alloc.TaskGroup)
+	if tg == nil {
+		return fmt.Errorf("task group %q not found in job", req.Alloc.TaskGroup)
+	}
+
+	drv, err := r.createDriver()
+	if err != nil {
+		return fmt.Errorf("failed to create driver of task %q for alloc %q: %v", req.Task.Name, req.Alloc.ID, err)
+	}
+	image := drv.FSIsolation() == cstructs.FSIsolationImage
+	if image && !drv.Abilities().MountVolumes {
+		return fmt.Errorf("driver %q doesn't support volume mounts", req.Task.Driver)
+	}
+
+	mounts := make([]*cstructs.MountConfig, 0, len(req.Task.VolumeMounts))
+	for _, m := range req.Task.VolumeMounts {
+		request, ok := tg.Volumes[m.Volume]
+		if !ok || request == nil {
+			return fmt.Errorf("volume %q is not defined by the task group", m.Volume)
+		}
+		if request.Type != structs.VolumeTypeHost {
+			return fmt.Errorf("volume %q has unsupported type %q", m.Volume, request.Type)
+		}
+
+		volume, ok := r.config.HostVolumes[request.Source]
+		if !ok || volume == nil {
+			return fmt.Errorf("host volume %q of volume %q is not available on the client", request.Source, m.Volume)
+		}
+
+		// The volume is read only if any of the operator, the task group or
+		// the task asks for it
+		mount := &cstructs.MountConfig{
+			HostPath: volume.Path,
+			TaskPath: filepath.Join("/", m.Destination),
+			Readonly: volume.ReadOnly || request.ReadOnly || m.ReadOnly,
+		}
+
+		if !image {
+			path, err := req.TaskDir.MountVolume(mount.HostPath, m.Destination, mount.Readonly)
+			if err != nil {
+				return err
+			}
+			mount.TaskPath = path
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	r.setVolumeMounts(mounts)
+	return nil
+}
@@ -280,6 +280,12 @@ func convertServerConfig(agentConfig *Config, logOutput io.Writer) (*nomad.Confi
 	if maxHPS := agentConfig.Server.MaxHeartbeatsPerSecond; maxHPS != 0 {
 		conf.MaxHeartbeatsPerSecond = maxHPS
 	}
+	if size := agentConfig.Server.EventBufferSize; size != 0 {
+		conf.EventBufferSize = size
+	}
+	conf.EvalFairQueueing = agentConfig.Server.EvalFairQueueing
+	conf.EvalNamespaceWeights = agentConfig.Server.EvalNamespaceWeights
+	conf.EvalMaxReadyPerJob = agentConfig.Server.EvalMaxReadyPerJob
 
 	if *agentConfig.Consul.AutoAdvertise && agentConfig.Consul.ServerServiceName == "" {
 		return nil, fmt.Errorf("server_service_name must be set when auto_advertise is enabled")
@@ -333,11 +339,41 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	if a.config.Client.AllocDir != "" {
 		conf.AllocDir = a.config.Client.AllocDir
 	}
+	if a.config.DataDir != "" {
+		conf.PluginDir = filepath.Join(a.config.DataDir, "plugins")
+	}
+	if a.config.Client.PluginDir != "" {
+		conf.PluginDir = a.config.Client.PluginDir
+	}
+	if len(a.config.Client.Plugins) > 0 {
+		conf.PluginConfigs = make(map[string]map[string]interface{}, len(a.config.Client.Plugins))
+		for name, p := range a.config.Client.Plugins {
+			conf.PluginConfigs[name] = p.Config
+		}
+	}
 	conf.Servers = a.config.Client.Servers
 	if a.config.Client.NetworkInterface != "" {
 		conf.NetworkInterface = a.config.Client.NetworkInterface
 	}
 	conf.ChrootEnv = a.config.Client.ChrootEnv
+	if len(a.config.Client.HostVolumes) > 0 {
+		conf.HostVolumes = make(map[string]*structs.ClientHostVolumeConfig, len(a.config.Client.HostVolumes))
+		for _, v := range a.config.Client.HostVolumes {
+			conf.HostVolumes[v.Name] = v.Copy()
+		}
+	}
+	if a.config.Client.BridgeNetworkName != "" {
+		conf.BridgeNetworkName = a.config.Client.BridgeNetworkName
+	}
+	if a.config.Client.BridgeNetworkSubnet != "" {
+		conf.BridgeNetworkSubnet = a.config.Client.BridgeNetworkSubnet
+	}
+	if a.config.Client.CNIPath != "" {
+		conf.CNIPath = a.config.Client.CNIPath
+	}
+	if a.config.Client.CNIConfigDir != "" {
+		conf.CNIConfigDir = a.config.Client.CNIConfigDir
+	}
 	conf.Options = a.config.Client.Options
 	// Logging deprecation messages about consul related configuration in client
 	// options
@@ -393,6 +429,7 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	r.MemoryMB = a.config.Client.Reserved.MemoryMB
 	r.DiskMB = a.config.Client.Reserved.DiskMB
 	r.IOPS = a.config.Client.Reserved.IOPS
+	r.CoreIDs = a.config.Client.Reserved.ParsedCores
 	conf.GloballyReservedPorts = a.config.Client.Reserved.ParsedReservedPorts
 
 	conf.Version = a.config.Version
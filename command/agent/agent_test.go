@@ -192,6 +192,20 @@ func TestAgent_ServerConfig(t *testing.T) {
 		t.Fatalf("expect 11, got: %v", max)
 	}
 
+	conf.Server.EventBufferSize = 250
+	out, err = a.serverConfig()
+	if size := out.EventBufferSize; size != 250 {
+		t.Fatalf("expect 250, got: %v", size)
+	}
+
+	conf.Server.EvalFairQueueing = true
+	conf.Server.EvalNamespaceWeights = map[string]int{"batch": 2}
+	conf.Server.EvalMaxReadyPerJob = 10
+	out, err = a.serverConfig()
+	if !out.EvalFairQueueing || out.EvalNamespaceWeights["batch"] != 2 || out.EvalMaxReadyPerJob != 10 {
+		t.Fatalf("bad fair queueing config: %v %v %v", out.EvalFairQueueing, out.EvalNamespaceWeights, out.EvalMaxReadyPerJob)
+	}
+
 	// Defaults to the global bind addr
 	conf.Addresses.RPC = ""
 	conf.Addresses.Serf = ""
@@ -1,13 +1,21 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/snappy"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/nomad/api"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
 )
 
 const (
@@ -41,7 +49,29 @@ func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request)
 }
 
 func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
-	allocID := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
+	reqSuffix := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
+
+	// tokenize the suffix of the path to get the alloc id and find the action
+	// invoked on the alloc id
+	tokens := strings.Split(reqSuffix, "/")
+	if len(tokens) > 2 || len(tokens) < 1 {
+		return nil, CodedError(404, resourceNotFoundErr)
+	}
+	allocID := tokens[0]
+
+	if len(tokens) == 1 {
+		return s.allocGet(allocID, resp, req)
+	}
+
+	switch tokens[1] {
+	case "stop":
+		return s.allocStop(allocID, resp, req)
+	}
+
+	return nil, CodedError(404, resourceNotFoundErr)
+}
+
+func (s *HTTPServer) allocGet(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if req.Method != "GET" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
@@ -78,6 +108,28 @@ func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Re
 	return alloc, nil
 }
 
+func (s *HTTPServer) allocStop(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if !(req.Method == "POST" || req.Method == "PUT") {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.AllocStopRequest{
+		AllocID: allocID,
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.AllocStopResponse
+	if err := s.agent.RPC("Alloc.Stop", &args, &out); err != nil {
+		if structs.IsErrUnknownAllocation(err) {
+			return nil, CodedError(404, err.Error())
+		}
+		return nil, err
+	}
+
+	setIndex(resp, out.Index)
+	return &out, nil
+}
+
 func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 
 	reqSuffix := strings.TrimPrefix(req.URL.Path, "/v1/client/allocation/")
@@ -100,6 +152,12 @@ func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Requ
 		return s.allocSnapshot(allocID, resp, req)
 	case "gc":
 		return s.allocGC(allocID, resp, req)
+	case "restart":
+		return s.allocRestart(allocID, resp, req)
+	case "signal":
+		return s.allocSignal(allocID, resp, req)
+	case "exec":
+		return s.allocExec(allocID, resp, req)
 	}
 
 	return nil, CodedError(404, resourceNotFoundErr)
@@ -172,6 +230,106 @@ func (s *HTTPServer) allocGC(allocID string, resp http.ResponseWriter, req *http
 	return nil, rpcErr
 }
 
+func (s *HTTPServer) allocRestart(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if !(req.Method == "POST" || req.Method == "PUT") {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	// Build the request and parse the ACL token
+	args := structs.AllocRestartRequest{
+		AllocID:  allocID,
+		TaskName: req.URL.Query().Get("task"),
+	}
+	if req.Body != nil {
+		var body api.AllocRestartRequest
+		if err := decodeBody(req, &body); err != nil && err != io.EOF {
+			return nil, CodedError(400, err.Error())
+		}
+		if body.TaskName != "" {
+			args.TaskName = body.TaskName
+		}
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	// Determine the handler to use
+	useLocalClient, useClientRPC, useServerRPC := s.rpcHandlerForAlloc(allocID)
+
+	// Make the RPC
+	var reply structs.GenericResponse
+	var rpcErr error
+	if useLocalClient {
+		rpcErr = s.agent.Client().ClientRPC("Allocations.Restart", &args, &reply)
+	} else if useClientRPC {
+		rpcErr = s.agent.Client().RPC("ClientAllocations.Restart", &args, &reply)
+	} else if useServerRPC {
+		rpcErr = s.agent.Server().RPC("ClientAllocations.Restart", &args, &reply)
+	} else {
+		rpcErr = CodedError(400, "No local Node and node_id not provided")
+	}
+
+	if rpcErr != nil {
+		if structs.IsErrNoNodeConn(rpcErr) || structs.IsErrUnknownAllocation(rpcErr) {
+			rpcErr = CodedError(404, rpcErr.Error())
+		}
+	}
+
+	return reply, rpcErr
+}
+
+func (s *HTTPServer) allocSignal(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if !(req.Method == "POST" || req.Method == "PUT") {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	// Build the request and parse the ACL token
+	query := req.URL.Query()
+	args := structs.AllocSignalRequest{
+		AllocID: allocID,
+		Task:    query.Get("task"),
+		Signal:  query.Get("signal"),
+	}
+	if req.Body != nil {
+		var body api.AllocSignalRequest
+		if err := decodeBody(req, &body); err != nil && err != io.EOF {
+			return nil, CodedError(400, err.Error())
+		}
+		if body.Task != "" {
+			args.Task = body.Task
+		}
+		if body.Signal != "" {
+			args.Signal = body.Signal
+		}
+	}
+	if args.Signal == "" {
+		return nil, CodedError(400, "missing signal")
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	// Determine the handler to use
+	useLocalClient, useClientRPC, useServerRPC := s.rpcHandlerForAlloc(allocID)
+
+	// Make the RPC
+	var reply structs.GenericResponse
+	var rpcErr error
+	if useLocalClient {
+		rpcErr = s.agent.Client().ClientRPC("Allocations.Signal", &args, &reply)
+	} else if useClientRPC {
+		rpcErr = s.agent.Client().RPC("ClientAllocations.Signal", &args, &reply)
+	} else if useServerRPC {
+		rpcErr = s.agent.Server().RPC("ClientAllocations.Signal", &args, &reply)
+	} else {
+		rpcErr = CodedError(400, "No local Node and node_id not provided")
+	}
+
+	if rpcErr != nil {
+		if structs.IsErrNoNodeConn(rpcErr) || structs.IsErrUnknownAllocation(rpcErr) {
+			rpcErr = CodedError(404, rpcErr.Error())
+		}
+	}
+
+	return reply, rpcErr
+}
+
 func (s *HTTPServer) allocSnapshot(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var secret string
 	s.parseToken(req, &secret)
@@ -223,3 +381,151 @@ func (s *HTTPServer) allocStats(allocID string, resp http.ResponseWriter, req *h
 
 	return reply.Stats, rpcErr
 }
+
+// execUpgrader upgrades exec requests to websocket connections
+var execUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+func (s *HTTPServer) allocExec(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Build the request and parse the ACL token
+	task := req.URL.Query().Get("task")
+	if task == "" {
+		return nil, CodedError(400, "must provide task name")
+	}
+
+	var command []string
+	if err := json.Unmarshal([]byte(req.URL.Query().Get("command")), &command); err != nil || len(command) == 0 {
+		return nil, CodedError(400, "command must be a non-empty JSON array of strings")
+	}
+
+	tty := false
+	if v := req.URL.Query().Get("tty"); v != "" {
+		var err error
+		if tty, err = strconv.ParseBool(v); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse tty: %v", err))
+		}
+	}
+
+	args := cstructs.AllocExecRequest{
+		AllocID: allocID,
+		Task:    task,
+		Tty:     tty,
+		Cmd:     command,
+	}
+	s.parse(resp, req, &args.QueryOptions.Region, &args.QueryOptions)
+
+	// The upgrader replies with an error itself if the upgrade fails
+	conn, err := execUpgrader.Upgrade(resp, req, nil)
+	if err != nil {
+		s.logger.Printf("[ERR] http: failed to upgrade exec connection: %v", err)
+		return nil, nil
+	}
+
+	s.execStreamImpl(conn, &args)
+	return nil, nil
+}
+
+// execStreamImpl bridges the websocket connection of an exec session to the
+// streaming RPC executing the command. Inputs received as JSON messages are
+// forwarded to the RPC and the JSON output frames of the RPC are sent as
+// messages. Errors are returned as the close code and reason of the
+// websocket.
+func (s *HTTPServer) execStreamImpl(ws *websocket.Conn, args *cstructs.AllocExecRequest) {
+	defer ws.Close()
+
+	// Get the correct handler
+	localClient, remoteClient, localServer := s.rpcHandlerForAlloc(args.AllocID)
+	var handler structs.StreamingRpcHandler
+	var handlerErr error
+	if localClient {
+		handler, handlerErr = s.agent.Client().StreamingRpcHandler("Allocations.Exec")
+	} else if remoteClient {
+		handler, handlerErr = s.agent.Client().RemoteStreamingRpcHandler("Allocations.Exec")
+	} else if localServer {
+		handler, handlerErr = s.agent.Server().StreamingRpcHandler("Allocations.Exec")
+	} else {
+		handlerErr = fmt.Errorf("No local Node and node_id not provided")
+	}
+
+	if handlerErr != nil {
+		writeExecClose(ws, websocket.CloseInternalServerErr, handlerErr.Error())
+		return
+	}
+
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+	decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+
+	go handler(p2)
+
+	// Send the request
+	if err := encoder.Encode(args); err != nil {
+		writeExecClose(ws, websocket.CloseInternalServerErr, err.Error())
+		return
+	}
+
+	// Forward the inputs until the remote side closes the connection
+	go func() {
+		defer p1.Close()
+		for {
+			var input cstructs.ExecStreamingInput
+			if err := ws.ReadJSON(&input); err != nil {
+				return
+			}
+			if err := encoder.Encode(&input); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Forward the output frames until the command exits
+	for {
+		var res cstructs.StreamErrWrapper
+		if err := decoder.Decode(&res); err != nil {
+			writeExecClose(ws, websocket.CloseNormalClosure, "")
+			return
+		}
+
+		if err := res.Error; err != nil {
+			writeExecClose(ws, execCloseCode(err), err.Error())
+			return
+		}
+
+		if err := ws.WriteMessage(websocket.TextMessage, res.Payload); err != nil {
+			return
+		}
+	}
+}
+
+// execCloseCode returns the websocket close code of an error of an exec
+// session. Errors with an HTTP status code are closed with 4000 plus the
+// status code.
+func execCloseCode(err *cstructs.RpcError) int {
+	if err.Code != nil {
+		return 4000 + int(*err.Code)
+	}
+
+	switch {
+	case strings.HasSuffix(err.Error(), structs.ErrPermissionDenied.Error()),
+		strings.HasSuffix(err.Error(), structs.ErrTokenNotFound.Error()):
+		return 4403
+	case structs.IsErrUnknownAllocation(err), structs.IsErrNoNodeConn(err):
+		return 4404
+	}
+	return websocket.CloseInternalServerErr
+}
+
+// writeExecClose closes the websocket of an exec session with the given code
+// and reason.
+func writeExecClose(ws *websocket.Conn, code int, reason string) {
+	// The reason must fit in a control frame
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+
+	msg := websocket.FormatCloseMessage(code, reason)
+	ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+}
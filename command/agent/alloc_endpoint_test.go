@@ -7,12 +7,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/golang/snappy"
+	"github.com/gorilla/websocket"
 	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/helper/uuid"
@@ -260,6 +262,53 @@ func TestHTTP_AllocQuery_Payload(t *testing.T) {
 	})
 }
 
+func TestHTTP_AllocStop(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		alloc := mock.Alloc()
+		require.Nil(state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)))
+		require.Nil(state.UpsertAllocs(1000, []*structs.Allocation{alloc}))
+
+		// Reading is not allowed
+		{
+			req, err := http.NewRequest("GET", "/v1/allocation/"+alloc.ID+"/stop", nil)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.AllocSpecificRequest(respW, req)
+			require.NotNil(err)
+			require.Equal(405, err.(HTTPCodedError).Code())
+		}
+
+		// An unknown allocation is not found
+		{
+			req, err := http.NewRequest("PUT", "/v1/allocation/"+uuid.Generate()+"/stop", nil)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.AllocSpecificRequest(respW, req)
+			require.NotNil(err)
+			require.Equal(404, err.(HTTPCodedError).Code())
+		}
+
+		// Stop the allocation
+		req, err := http.NewRequest("PUT", "/v1/allocation/"+alloc.ID+"/stop", nil)
+		require.Nil(err)
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.AllocSpecificRequest(respW, req)
+		require.Nil(err)
+		require.NotEmpty(respW.HeaderMap.Get("X-Nomad-Index"))
+
+		out := obj.(*structs.AllocStopResponse)
+		require.NotEmpty(out.EvalID)
+
+		a, err := state.AllocByID(nil, alloc.ID)
+		require.Nil(err)
+		require.True(a.DesiredTransition.ShouldMigrate())
+	})
+}
+
 func TestHTTP_AllocStats(t *testing.T) {
 	t.Parallel()
 	require := require.New(t)
@@ -663,6 +712,83 @@ func TestHTTP_AllocGC_ACL(t *testing.T) {
 	})
 }
 
+func TestHTTP_AllocRestart(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	path := fmt.Sprintf("/v1/client/allocation/%s/restart", uuid.Generate())
+	httpTest(t, nil, func(s *TestAgent) {
+		// Reading is not allowed
+		{
+			req, err := http.NewRequest("GET", path, nil)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.NotNil(err)
+			require.Equal(405, err.(HTTPCodedError).Code())
+		}
+
+		// Local node, local resp
+		{
+			body := encodeReq(map[string]string{"TaskName": "web"})
+			req, err := http.NewRequest("PUT", path, body)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.True(structs.IsErrUnknownAllocation(err), "unexpected err: %v", err)
+		}
+
+		// Local node, server resp
+		{
+			srv := s.server
+			s.server = nil
+
+			req, err := http.NewRequest("PUT", path, nil)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.True(structs.IsErrUnknownAllocation(err), "unexpected err: %v", err)
+
+			s.server = srv
+		}
+	})
+}
+
+func TestHTTP_AllocSignal(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	path := fmt.Sprintf("/v1/client/allocation/%s/signal", uuid.Generate())
+	httpTest(t, nil, func(s *TestAgent) {
+		// A signal is required
+		{
+			req, err := http.NewRequest("PUT", path, nil)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.NotNil(err)
+			require.Equal(400, err.(HTTPCodedError).Code())
+		}
+
+		// Signal in the body
+		{
+			body := encodeReq(map[string]string{"Signal": "SIGUSR1", "Task": "web"})
+			req, err := http.NewRequest("PUT", path, body)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.True(structs.IsErrUnknownAllocation(err), "unexpected err: %v", err)
+		}
+
+		// Signal in the query parameters
+		{
+			req, err := http.NewRequest("PUT", path+"?signal=SIGUSR1", nil)
+			require.Nil(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.True(structs.IsErrUnknownAllocation(err), "unexpected err: %v", err)
+		}
+	})
+}
+
 func TestHTTP_AllocAllGC(t *testing.T) {
 	t.Parallel()
 	require := require.New(t)
@@ -776,3 +902,43 @@ func TestHTTP_AllocAllGC_ACL(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_AllocExec(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		allocID := uuid.Generate()
+
+		// Invalid arguments are rejected before upgrading the connection
+		{
+			path := fmt.Sprintf("/v1/client/allocation/%s/exec?task=web&command=sh", allocID)
+			req, err := http.NewRequest("GET", path, nil)
+			require.NoError(err)
+
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientAllocRequest(respW, req)
+			require.Error(err)
+			codedErr, ok := err.(HTTPCodedError)
+			require.True(ok)
+			require.Equal(400, codedErr.Code())
+		}
+
+		// Errors of the session are returned as close codes
+		{
+			q := url.Values{}
+			q.Set("task", "web")
+			q.Set("command", `["/bin/sh"]`)
+			u := fmt.Sprintf("ws://%s/v1/client/allocation/%s/exec?%s", s.Server.Addr, allocID, q.Encode())
+
+			conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+			require.NoError(err)
+			defer conn.Close()
+
+			_, _, err = conn.ReadMessage()
+			closeErr, ok := err.(*websocket.CloseError)
+			require.True(ok, "unexpected error: %v", err)
+			require.Equal(4404, closeErr.Code)
+			require.Contains(closeErr.Text, structs.ErrUnknownAllocationPrefix)
+		}
+	})
+}
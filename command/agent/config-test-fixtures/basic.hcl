@@ -46,6 +46,7 @@ client {
 		disk = 10
 		iops = 10
 		reserved_ports = "1,100,10-12"
+		cores = "0-1"
 	}
 	client_min_port = 1000
 	client_max_port = 2000
@@ -60,6 +61,23 @@ client {
     gc_inode_usage_threshold = 91
     gc_max_allocs = 50
     no_host_uuid = false
+    plugin_dir = "/tmp/nomad-plugins"
+    plugin "sandbox" {
+        config {
+            image_dir = "/var/sandbox"
+        }
+    }
+    host_volume "certs" {
+        path = "/etc/ssl/certs"
+        read_only = true
+    }
+    host_volume "data" {
+        path = "/srv/data"
+    }
+    bridge_network_name = "nomad0"
+    bridge_network_subnet = "10.10.0.0/20"
+    cni_path = "/opt/cni/bin:/usr/libexec/cni"
+    cni_config_dir = "/etc/cni/net.d"
 }
 server {
 	enabled = true
@@ -77,6 +95,12 @@ server {
 	heartbeat_grace   = "30s"
 	min_heartbeat_ttl = "33s"
 	max_heartbeats_per_second = 11.0
+	event_buffer_size = 250
+	eval_fair_queueing = true
+	eval_namespace_weights {
+		batch = 3
+	}
+	eval_max_ready_per_job = 20
 	retry_join = [ "1.1.1.1", "2.2.2.2" ]
 	start_join = [ "1.1.1.1", "2.2.2.2" ]
 	retry_max = 3
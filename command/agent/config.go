@@ -19,6 +19,7 @@ import (
 	client "github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/version"
 )
@@ -217,6 +218,40 @@ type ClientConfig struct {
 	// NoHostUUID disables using the host's UUID and will force generation of a
 	// random UUID.
 	NoHostUUID *bool `mapstructure:"no_host_uuid"`
+
+	// PluginDir is the directory in which external driver plugins are
+	// discovered. Defaults to the plugins directory of the data dir.
+	PluginDir string `mapstructure:"plugin_dir"`
+
+	// Plugins is the configuration of the external plugins, keyed by
+	// plugin name.
+	Plugins map[string]*PluginConfig `mapstructure:"-"`
+
+	// HostVolumes are the directories of the host made available to tasks
+	// as volumes.
+	HostVolumes []*structs.ClientHostVolumeConfig `mapstructure:"-"`
+
+	// BridgeNetworkName is the name of the bridge allocations in the bridge
+	// network mode are attached to.
+	BridgeNetworkName string `mapstructure:"bridge_network_name"`
+
+	// BridgeNetworkSubnet is the subnet the addresses of the allocations in
+	// the bridge network mode are allocated from.
+	BridgeNetworkSubnet string `mapstructure:"bridge_network_subnet"`
+
+	// CNIPath is the list of directories, separated like the PATH environment
+	// variable, the CNI plugins are searched in.
+	CNIPath string `mapstructure:"cni_path"`
+
+	// CNIConfigDir is the directory the CNI network configurations are loaded
+	// from.
+	CNIConfigDir string `mapstructure:"cni_config_dir"`
+}
+
+// PluginConfig is the configuration of an external plugin.
+type PluginConfig struct {
+	// Config is passed to the plugin.
+	Config map[string]interface{} `mapstructure:"config"`
 }
 
 // ACLConfig is configuration specific to the ACL system
@@ -308,6 +343,23 @@ type ServerConfig struct {
 	// to meet the target rate.
 	MaxHeartbeatsPerSecond float64 `mapstructure:"max_heartbeats_per_second"`
 
+	// EventBufferSize is the number of Raft indexes whose events are buffered
+	// for the subscribers of the event stream resuming it from an index.
+	EventBufferSize int `mapstructure:"event_buffer_size"`
+
+	// EvalFairQueueing makes the eval broker dequeue the ready evaluations
+	// with a weighted round-robin across namespaces rather than purely by
+	// priority.
+	EvalFairQueueing bool `mapstructure:"eval_fair_queueing"`
+
+	// EvalNamespaceWeights are the weights of the namespaces in the fair
+	// queueing round-robin. Namespaces without a weight have a weight of one.
+	EvalNamespaceWeights map[string]int `mapstructure:"eval_namespace_weights"`
+
+	// EvalMaxReadyPerJob caps the number of ready evaluations of a job when
+	// fair queueing is enabled. Zero disables the cap.
+	EvalMaxReadyPerJob int `mapstructure:"eval_max_ready_per_job"`
+
 	// StartJoin is a list of addresses to attempt to join when the
 	// agent starts. If Serf is unable to communicate with any of these
 	// addresses, then the agent will error and exit.
@@ -473,18 +525,27 @@ type AdvertiseAddrs struct {
 }
 
 type Resources struct {
-	CPU                 int    `mapstructure:"cpu"`
-	MemoryMB            int    `mapstructure:"memory"`
-	DiskMB              int    `mapstructure:"disk"`
-	IOPS                int    `mapstructure:"iops"`
-	ReservedPorts       string `mapstructure:"reserved_ports"`
-	ParsedReservedPorts []int  `mapstructure:"-"`
+	CPU                 int      `mapstructure:"cpu"`
+	MemoryMB            int      `mapstructure:"memory"`
+	DiskMB              int      `mapstructure:"disk"`
+	IOPS                int      `mapstructure:"iops"`
+	ReservedPorts       string   `mapstructure:"reserved_ports"`
+	ParsedReservedPorts []int    `mapstructure:"-"`
+	Cores               string   `mapstructure:"cores"`
+	ParsedCores         []uint16 `mapstructure:"-"`
 }
 
-// ParseReserved expands the ReservedPorts string into a slice of port numbers.
-// The supported syntax is comma separated integers or ranges separated by
-// hyphens. For example, "80,120-150,160"
+// ParseReserved expands the ReservedPorts string into a slice of port numbers
+// and the Cores string into a slice of core IDs. The supported syntax is comma
+// separated integers or ranges separated by hyphens. For example,
+// "80,120-150,160"
 func (r *Resources) ParseReserved() error {
+	cores, err := structs.ParseCpuset(r.Cores)
+	if err != nil {
+		return fmt.Errorf("failed to parse reserved cores: %v", err)
+	}
+	r.ParsedCores = cores
+
 	parts := strings.Split(r.ReservedPorts, ",")
 
 	// Hot path the empty case
@@ -1033,6 +1094,23 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	if b.MaxHeartbeatsPerSecond != 0.0 {
 		result.MaxHeartbeatsPerSecond = b.MaxHeartbeatsPerSecond
 	}
+	if b.EventBufferSize != 0 {
+		result.EventBufferSize = b.EventBufferSize
+	}
+	if b.EvalFairQueueing {
+		result.EvalFairQueueing = true
+	}
+	if b.EvalNamespaceWeights != nil {
+		if result.EvalNamespaceWeights == nil {
+			result.EvalNamespaceWeights = make(map[string]int)
+		}
+		for namespace, weight := range b.EvalNamespaceWeights {
+			result.EvalNamespaceWeights[namespace] = weight
+		}
+	}
+	if b.EvalMaxReadyPerJob != 0 {
+		result.EvalMaxReadyPerJob = b.EvalMaxReadyPerJob
+	}
 	if b.RetryMaxAttempts != 0 {
 		result.RetryMaxAttempts = b.RetryMaxAttempts
 	}
@@ -1134,6 +1212,21 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	if b.NoHostUUID != nil {
 		result.NoHostUUID = b.NoHostUUID
 	}
+	if b.PluginDir != "" {
+		result.PluginDir = b.PluginDir
+	}
+	if b.BridgeNetworkName != "" {
+		result.BridgeNetworkName = b.BridgeNetworkName
+	}
+	if b.BridgeNetworkSubnet != "" {
+		result.BridgeNetworkSubnet = b.BridgeNetworkSubnet
+	}
+	if b.CNIPath != "" {
+		result.CNIPath = b.CNIPath
+	}
+	if b.CNIConfigDir != "" {
+		result.CNIConfigDir = b.CNIConfigDir
+	}
 
 	// Add the servers
 	result.Servers = append(result.Servers, b.Servers...)
@@ -1162,6 +1255,23 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 		result.ChrootEnv[k] = v
 	}
 
+	// Add the plugin configs, replacing those of the same plugin
+	if len(b.Plugins) > 0 {
+		plugins := make(map[string]*PluginConfig, len(result.Plugins)+len(b.Plugins))
+		for k, v := range result.Plugins {
+			plugins[k] = v
+		}
+		for k, v := range b.Plugins {
+			plugins[k] = v
+		}
+		result.Plugins = plugins
+	}
+
+	// Add the host volumes, replacing those of the same name
+	if len(b.HostVolumes) > 0 {
+		result.HostVolumes = structs.HostVolumeSliceMerge(result.HostVolumes, b.HostVolumes)
+	}
+
 	return &result
 }
 
@@ -1322,6 +1432,12 @@ func (r *Resources) Merge(b *Resources) *Resources {
 	if len(b.ParsedReservedPorts) != 0 {
 		result.ParsedReservedPorts = b.ParsedReservedPorts
 	}
+	if b.Cores != "" {
+		result.Cores = b.Cores
+	}
+	if len(b.ParsedCores) != 0 {
+		result.ParsedCores = b.ParsedCores
+	}
 	return &result
 }
 
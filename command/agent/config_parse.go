@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/mitchellh/mapstructure"
 )
@@ -369,6 +370,13 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		"gc_parallel_destroys",
 		"gc_max_allocs",
 		"no_host_uuid",
+		"plugin_dir",
+		"plugin",
+		"host_volume",
+		"bridge_network_name",
+		"bridge_network_subnet",
+		"cni_path",
+		"cni_config_dir",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return err
@@ -384,6 +392,8 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 	delete(m, "chroot_env")
 	delete(m, "reserved")
 	delete(m, "stats")
+	delete(m, "plugin")
+	delete(m, "host_volume")
 
 	var config ClientConfig
 	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
@@ -447,10 +457,116 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse plugin configs
+	if o := listVal.Filter("plugin"); len(o.Items) > 0 {
+		if err := parsePlugins(&config.Plugins, o); err != nil {
+			return multierror.Prefix(err, "plugin ->")
+		}
+	}
+
+	// Parse host volumes
+	if o := listVal.Filter("host_volume"); len(o.Items) > 0 {
+		if err := parseHostVolumes(&config.HostVolumes, o); err != nil {
+			return multierror.Prefix(err, "host_volume ->")
+		}
+	}
+
 	*result = &config
 	return nil
 }
 
+func parseHostVolumes(result *[]*structs.ClientHostVolumeConfig, list *ast.ObjectList) error {
+	seen := make(map[string]struct{}, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return fmt.Errorf("host_volume block must have exactly one label")
+		}
+		name := item.Keys[0].Token.Value().(string)
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("host_volume %q defined more than once", name)
+		}
+		seen[name] = struct{}{}
+
+		// Value should be an object
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("host_volume %q: should be an object", name)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"path",
+			"read_only",
+		}
+		if err := helper.CheckHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("host_volume %q:", name))
+		}
+
+		volume := &structs.ClientHostVolumeConfig{}
+		if err := hcl.DecodeObject(volume, listVal); err != nil {
+			return err
+		}
+		volume.Name = name
+		if err := volume.Validate(); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("host_volume %q:", name))
+		}
+		*result = append(*result, volume)
+	}
+
+	return nil
+}
+
+func parsePlugins(result *map[string]*PluginConfig, list *ast.ObjectList) error {
+	plugins := make(map[string]*PluginConfig, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return fmt.Errorf("plugin block must have exactly one label")
+		}
+		name := item.Keys[0].Token.Value().(string)
+		if _, ok := plugins[name]; ok {
+			return fmt.Errorf("plugin %q defined more than once", name)
+		}
+
+		// Value should be an object
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("plugin %q: should be an object", name)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"config",
+		}
+		if err := helper.CheckHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("plugin %q:", name))
+		}
+
+		// Parse out config fields. These are in HCL as a list so we need to
+		// iterate over them and merge them.
+		plugin := &PluginConfig{}
+		if configO := listVal.Filter("config"); len(configO.Items) > 0 {
+			plugin.Config = make(map[string]interface{})
+			for _, o := range configO.Elem().Items {
+				var m map[string]interface{}
+				if err := hcl.DecodeObject(&m, o.Val); err != nil {
+					return err
+				}
+				for k, v := range m {
+					plugin.Config[k] = v
+				}
+			}
+		}
+		plugins[name] = plugin
+	}
+
+	*result = plugins
+	return nil
+}
+
 func parseReserved(result **Resources, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -475,6 +591,7 @@ func parseReserved(result **Resources, list *ast.ObjectList) error {
 		"disk",
 		"iops",
 		"reserved_ports",
+		"cores",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return err
@@ -530,6 +647,10 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		"heartbeat_grace",
 		"min_heartbeat_ttl",
 		"max_heartbeats_per_second",
+		"event_buffer_size",
+		"eval_fair_queueing",
+		"eval_namespace_weights",
+		"eval_max_ready_per_job",
 		"start_join",
 		"retry_join",
 		"retry_max",
@@ -550,6 +671,8 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		return err
 	}
 
+	delete(m, "eval_namespace_weights")
+
 	var config ServerConfig
 	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
@@ -563,6 +686,25 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		return err
 	}
 
+	// Parse out eval_namespace_weights fields. These are in HCL as a list so
+	// we need to iterate over them and merge them.
+	if weightsO := listVal.Filter("eval_namespace_weights"); len(weightsO.Items) > 0 {
+		for _, o := range weightsO.Elem().Items {
+			var m map[string]interface{}
+			if err := hcl.DecodeObject(&m, o.Val); err != nil {
+				return err
+			}
+			if err := mapstructure.WeakDecode(m, &config.EvalNamespaceWeights); err != nil {
+				return err
+			}
+		}
+		for namespace, weight := range config.EvalNamespaceWeights {
+			if weight < 1 {
+				return fmt.Errorf("eval_namespace_weights: weight of namespace %q must be greater than zero", namespace)
+			}
+		}
+	}
+
 	if config.UpgradeVersion != "" {
 		if _, err := version.NewVersion(config.UpgradeVersion); err != nil {
 			return fmt.Errorf("error parsing upgrade_version: %v", err)
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/stretchr/testify/require"
 )
@@ -73,6 +74,8 @@ func TestConfig_Parse(t *testing.T) {
 						IOPS:                10,
 						ReservedPorts:       "1,100,10-12",
 						ParsedReservedPorts: []int{1, 10, 11, 12, 100},
+						Cores:               "0-1",
+						ParsedCores:         []uint16{0, 1},
 					},
 					GCInterval:            6 * time.Second,
 					GCParallelDestroys:    6,
@@ -80,6 +83,29 @@ func TestConfig_Parse(t *testing.T) {
 					GCInodeUsageThreshold: 91,
 					GCMaxAllocs:           50,
 					NoHostUUID:            helper.BoolToPtr(false),
+					PluginDir:             "/tmp/nomad-plugins",
+					Plugins: map[string]*PluginConfig{
+						"sandbox": {
+							Config: map[string]interface{}{
+								"image_dir": "/var/sandbox",
+							},
+						},
+					},
+					HostVolumes: []*structs.ClientHostVolumeConfig{
+						{
+							Name:     "certs",
+							Path:     "/etc/ssl/certs",
+							ReadOnly: true,
+						},
+						{
+							Name: "data",
+							Path: "/srv/data",
+						},
+					},
+					BridgeNetworkName:   "nomad0",
+					BridgeNetworkSubnet: "10.10.0.0/20",
+					CNIPath:             "/opt/cni/bin:/usr/libexec/cni",
+					CNIConfigDir:        "/etc/cni/net.d",
 				},
 				Server: &ServerConfig{
 					Enabled:                true,
@@ -97,6 +123,10 @@ func TestConfig_Parse(t *testing.T) {
 					HeartbeatGrace:         30 * time.Second,
 					MinHeartbeatTTL:        33 * time.Second,
 					MaxHeartbeatsPerSecond: 11.0,
+					EventBufferSize:        250,
+					EvalFairQueueing:       true,
+					EvalNamespaceWeights:   map[string]int{"batch": 3},
+					EvalMaxReadyPerJob:     20,
 					RetryJoin:              []string{"1.1.1.1", "2.2.2.2"},
 					StartJoin:              []string{"1.1.1.1", "2.2.2.2"},
 					RetryInterval:          "15s",
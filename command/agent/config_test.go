@@ -261,6 +261,10 @@ func TestConfig_Merge(t *testing.T) {
 			HeartbeatGrace:         2 * time.Minute,
 			MinHeartbeatTTL:        2 * time.Minute,
 			MaxHeartbeatsPerSecond: 200.0,
+			EventBufferSize:        500,
+			EvalFairQueueing:       true,
+			EvalNamespaceWeights:   map[string]int{"batch": 2},
+			EvalMaxReadyPerJob:     10,
 			RejoinAfterLeave:       true,
 			StartJoin:              []string{"1.1.1.1"},
 			RetryJoin:              []string{"1.1.1.1"},
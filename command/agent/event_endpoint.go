@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/pkg/ioutils"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+// EventStream streams the events of the changes applied to the state of the
+// cluster as newline delimited JSON. The events are filtered by the topic
+// query parameters, in the form Topic or Topic:Key, and resumed after the
+// index query parameter.
+func (s *HTTPServer) EventStream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.EventStreamRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	// The index the stream is resumed from is the index query parameter
+	args.Index = args.MinQueryIndex
+	args.MinQueryIndex = 0
+
+	topics, err := parseEventTopics(req.URL.Query()["topic"])
+	if err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	args.Topics = topics
+
+	// Stream from the local server or forward to a server
+	var handler structs.StreamingRpcHandler
+	var handlerErr error
+	if srv := s.agent.Server(); srv != nil {
+		handler, handlerErr = srv.StreamingRpcHandler("Event.Stream")
+	} else {
+		handler, handlerErr = s.agent.Client().RemoteStreamingRpcHandler("Event.Stream")
+	}
+	if handlerErr != nil {
+		return nil, CodedError(500, handlerErr.Error())
+	}
+
+	p1, p2 := net.Pipe()
+	decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+
+	// Create a goroutine that closes the pipe if the connection closes.
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		<-ctx.Done()
+		p1.Close()
+	}()
+
+	// Create an output that gets flushed on every write
+	resp.Header().Set("Content-Type", "application/x-ndjson")
+	output := ioutils.NewWriteFlusher(resp)
+
+	// Create a channel that decodes the results. It is buffered so the pipe
+	// is closed as soon as the stream fails.
+	errCh := make(chan error, 1)
+	go func() {
+		defer cancel()
+
+		// Send the request
+		if err := encoder.Encode(args); err != nil {
+			errCh <- CodedError(500, err.Error())
+			return
+		}
+
+		for {
+			var res cstructs.StreamErrWrapper
+			if err := decoder.Decode(&res); err != nil {
+				errCh <- CodedError(500, err.Error())
+				return
+			}
+
+			if err := res.Error; err != nil {
+				if err.Code != nil {
+					errCh <- CodedError(int(*err.Code), err.Error())
+				} else {
+					errCh <- err
+				}
+				return
+			}
+
+			if _, err := io.Copy(output, bytes.NewBuffer(res.Payload)); err != nil {
+				errCh <- CodedError(500, err.Error())
+				return
+			}
+		}
+	}()
+
+	handler(p2)
+	cancel()
+	streamErr := <-errCh
+	if streamErr != nil &&
+		(streamErr == io.EOF ||
+			strings.Contains(streamErr.Error(), "closed") ||
+			strings.Contains(streamErr.Error(), "EOF")) {
+		streamErr = nil
+	}
+	return nil, streamErr
+}
+
+// parseEventTopics parses the topic query parameters, in the form Topic or
+// Topic:Key, into the topics of an event stream request. A topic without a
+// key matches all the events of the topic.
+func parseEventTopics(params []string) (map[structs.Topic][]string, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	topics := make(map[structs.Topic][]string, len(params))
+	for _, param := range params {
+		parts := strings.SplitN(param, ":", 2)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("invalid topic %q", param)
+		}
+
+		topic, key := structs.Topic(parts[0]), structs.EventKeyAll
+		if len(parts) == 2 && parts[1] != "" {
+			key = parts[1]
+		}
+		topics[topic] = append(topics[topic], key)
+	}
+	return topics, nil
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP_EventStream(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		node := mock.Node()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequest("GET", s.HTTPAddr()+"/v1/event/stream?topic=Node:"+node.ID, nil)
+		require.NoError(err)
+		resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		require.NoError(err)
+		defer resp.Body.Close()
+		require.Equal(200, resp.StatusCode)
+		require.Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		// Register the node and another one
+		for _, n := range []*structs.Node{mock.Node(), node} {
+			args := structs.NodeRegisterRequest{
+				Node:         n,
+				WriteRequest: structs.WriteRequest{Region: "global"},
+			}
+			var regResp structs.NodeUpdateResponse
+			require.NoError(s.Agent.RPC("Node.Register", &args, &regResp))
+		}
+
+		// Only the events of the node are streamed
+		var events struct {
+			Index  uint64
+			Events []struct {
+				Topic string
+				Type  string
+				Key   string
+			}
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		for events.Index == 0 {
+			require.True(scanner.Scan(), "no events: %v", scanner.Err())
+			require.NoError(json.Unmarshal(scanner.Bytes(), &events))
+		}
+		require.Len(events.Events, 1)
+		require.Equal("Node", events.Events[0].Topic)
+		require.Equal(structs.TypeNodeRegistration, events.Events[0].Type)
+		require.Equal(node.ID, events.Events[0].Key)
+	})
+}
+
+func TestHTTP_EventStream_ResumeUnavailable(t *testing.T) {
+	t.Parallel()
+	httpTest(t, func(c *Config) {
+		c.Server.EventBufferSize = 1
+	}, func(s *TestAgent) {
+		// Register nodes so the events of the first ones are dropped
+		for i := 0; i < 3; i++ {
+			args := structs.NodeRegisterRequest{
+				Node:         mock.Node(),
+				WriteRequest: structs.WriteRequest{Region: "global"},
+			}
+			var regResp structs.NodeUpdateResponse
+			require.NoError(t, s.Agent.RPC("Node.Register", &args, &regResp))
+		}
+
+		req, err := http.NewRequest("GET", "/v1/event/stream?index=1", nil)
+		require.NoError(t, err)
+		_, err = s.Server.EventStream(httptest.NewRecorder(), req)
+		require.Error(t, err)
+		codedErr, ok := err.(HTTPCodedError)
+		require.True(t, ok)
+		require.Equal(t, 400, codedErr.Code())
+		require.Contains(t, err.Error(), "no longer available")
+	})
+}
+
+func TestEventStream_ParseTopics(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	topics, err := parseEventTopics(nil)
+	require.NoError(err)
+	require.Nil(topics)
+
+	topics, err = parseEventTopics([]string{"Job:web", "Job:db", "Node", "Allocation:"})
+	require.NoError(err)
+	require.Equal(map[structs.Topic][]string{
+		structs.TopicJob:        {"web", "db"},
+		structs.TopicNode:       {"*"},
+		structs.TopicAllocation: {"*"},
+	}, topics)
+
+	_, err = parseEventTopics([]string{":web"})
+	require.Error(err)
+}
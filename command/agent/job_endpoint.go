@@ -572,6 +572,20 @@ func ApiJobToStructJob(job *api.Job) *structs.Job {
 		}
 	}
 
+	if l := len(job.Affinities); l != 0 {
+		j.Affinities = make([]*structs.Affinity, l)
+		for i, a := range job.Affinities {
+			j.Affinities[i] = ApiAffinityToStructs(a)
+		}
+	}
+
+	if l := len(job.Spreads); l != 0 {
+		j.Spreads = make([]*structs.Spread, l)
+		for i, apiSpread := range job.Spreads {
+			j.Spreads[i] = ApiSpreadToStructs(apiSpread)
+		}
+	}
+
 	// COMPAT: Remove in 0.7.0. Update has been pushed into the task groups
 	if job.Update != nil {
 		j.Update = structs.UpdateStrategy{}
@@ -587,8 +601,11 @@ func ApiJobToStructJob(job *api.Job) *structs.Job {
 	if job.Periodic != nil {
 		j.Periodic = &structs.PeriodicConfig{
 			Enabled:         *job.Periodic.Enabled,
+			Specs:           job.Periodic.Specs,
 			SpecType:        *job.Periodic.SpecType,
 			ProhibitOverlap: *job.Periodic.ProhibitOverlap,
+			CatchUp:         *job.Periodic.CatchUp,
+			CatchUpWindow:   *job.Periodic.CatchUpWindow,
 			TimeZone:        *job.Periodic.TimeZone,
 		}
 
@@ -631,6 +648,20 @@ func ApiTgToStructsTG(taskGroup *api.TaskGroup, tg *structs.TaskGroup) {
 		}
 	}
 
+	if l := len(taskGroup.Affinities); l != 0 {
+		tg.Affinities = make([]*structs.Affinity, l)
+		for k, affinity := range taskGroup.Affinities {
+			tg.Affinities[k] = ApiAffinityToStructs(affinity)
+		}
+	}
+
+	if l := len(taskGroup.Spreads); l != 0 {
+		tg.Spreads = make([]*structs.Spread, l)
+		for k, spread := range taskGroup.Spreads {
+			tg.Spreads[k] = ApiSpreadToStructs(spread)
+		}
+	}
+
 	tg.RestartPolicy = &structs.RestartPolicy{
 		Attempts: *taskGroup.RestartPolicy.Attempts,
 		Interval: *taskGroup.RestartPolicy.Interval,
@@ -664,15 +695,31 @@ func ApiTgToStructsTG(taskGroup *api.TaskGroup, tg *structs.TaskGroup) {
 		Migrate: *taskGroup.EphemeralDisk.Migrate,
 	}
 
+	if l := len(taskGroup.Volumes); l != 0 {
+		tg.Volumes = make(map[string]*structs.VolumeRequest, l)
+		for name, v := range taskGroup.Volumes {
+			tg.Volumes[name] = &structs.VolumeRequest{
+				Name:     v.Name,
+				Type:     v.Type,
+				Source:   v.Source,
+				ReadOnly: v.ReadOnly,
+			}
+		}
+	}
+
+	tg.Networks = ApiNetworkResourcesToStructs(taskGroup.Networks)
+
 	if taskGroup.Update != nil {
 		tg.Update = &structs.UpdateStrategy{
-			Stagger:         *taskGroup.Update.Stagger,
-			MaxParallel:     *taskGroup.Update.MaxParallel,
-			HealthCheck:     *taskGroup.Update.HealthCheck,
-			MinHealthyTime:  *taskGroup.Update.MinHealthyTime,
-			HealthyDeadline: *taskGroup.Update.HealthyDeadline,
-			AutoRevert:      *taskGroup.Update.AutoRevert,
-			Canary:          *taskGroup.Update.Canary,
+			Stagger:          *taskGroup.Update.Stagger,
+			MaxParallel:      *taskGroup.Update.MaxParallel,
+			HealthCheck:      *taskGroup.Update.HealthCheck,
+			MinHealthyTime:   *taskGroup.Update.MinHealthyTime,
+			HealthyDeadline:  *taskGroup.Update.HealthyDeadline,
+			ProgressDeadline: *taskGroup.Update.ProgressDeadline,
+			AutoRevert:       *taskGroup.Update.AutoRevert,
+			AutoPromote:      *taskGroup.Update.AutoPromote,
+			Canary:           *taskGroup.Update.Canary,
 		}
 	}
 
@@ -709,6 +756,13 @@ func ApiTaskToStructsTask(apiTask *api.Task, structsTask *structs.Task) {
 		}
 	}
 
+	if l := len(apiTask.Affinities); l != 0 {
+		structsTask.Affinities = make([]*structs.Affinity, l)
+		for i, a := range apiTask.Affinities {
+			structsTask.Affinities[i] = ApiAffinityToStructs(a)
+		}
+	}
+
 	if l := len(apiTask.Services); l != 0 {
 		structsTask.Services = make([]*structs.Service, l)
 		for i, service := range apiTask.Services {
@@ -756,40 +810,46 @@ func ApiTaskToStructsTask(apiTask *api.Task, structsTask *structs.Task) {
 		IOPS:     *apiTask.Resources.IOPS,
 	}
 
-	if l := len(apiTask.Resources.Networks); l != 0 {
-		structsTask.Resources.Networks = make([]*structs.NetworkResource, l)
-		for i, nw := range apiTask.Resources.Networks {
-			structsTask.Resources.Networks[i] = &structs.NetworkResource{
-				CIDR:  nw.CIDR,
-				IP:    nw.IP,
-				MBits: *nw.MBits,
-			}
+	if apiTask.Resources.MemoryMaxMB != nil {
+		structsTask.Resources.MemoryMaxMB = *apiTask.Resources.MemoryMaxMB
+	}
+	if apiTask.Resources.Cores != nil {
+		structsTask.Resources.Cores = *apiTask.Resources.Cores
+	}
 
-			if l := len(nw.DynamicPorts); l != 0 {
-				structsTask.Resources.Networks[i].DynamicPorts = make([]structs.Port, l)
-				for j, dp := range nw.DynamicPorts {
-					structsTask.Resources.Networks[i].DynamicPorts[j] = structs.Port{
-						Label: dp.Label,
-						Value: dp.Value,
-					}
-				}
-			}
+	structsTask.Resources.Networks = ApiNetworkResourcesToStructs(apiTask.Resources.Networks)
 
-			if l := len(nw.ReservedPorts); l != 0 {
-				structsTask.Resources.Networks[i].ReservedPorts = make([]structs.Port, l)
-				for j, rp := range nw.ReservedPorts {
-					structsTask.Resources.Networks[i].ReservedPorts[j] = structs.Port{
-						Label: rp.Label,
-						Value: rp.Value,
-					}
-				}
+	if l := len(apiTask.Resources.Devices); l != 0 {
+		structsTask.Resources.Devices = make([]*structs.DeviceResource, l)
+		for i, d := range apiTask.Resources.Devices {
+			structsTask.Resources.Devices[i] = &structs.DeviceResource{
+				Vendor: d.Vendor,
+				Type:   d.Type,
+				Name:   d.Name,
+				Count:  *d.Count,
 			}
 		}
 	}
 
 	structsTask.LogConfig = &structs.LogConfig{
-		MaxFiles:      *apiTask.LogConfig.MaxFiles,
-		MaxFileSizeMB: *apiTask.LogConfig.MaxFileSizeMB,
+		MaxFiles:         *apiTask.LogConfig.MaxFiles,
+		MaxFileSizeMB:    *apiTask.LogConfig.MaxFileSizeMB,
+		RotationInterval: *apiTask.LogConfig.RotationInterval,
+		Compress:         *apiTask.LogConfig.Compress,
+		MaxTotalSizeMB:   *apiTask.LogConfig.MaxTotalSizeMB,
+	}
+
+	if l := len(apiTask.LogConfig.Shippers); l != 0 {
+		structsTask.LogConfig.Shippers = make([]*structs.LogShipper, l)
+		for k, s := range apiTask.LogConfig.Shippers {
+			structsTask.LogConfig.Shippers[k] = &structs.LogShipper{
+				Type:          *s.Type,
+				Address:       *s.Address,
+				BufferSize:    *s.BufferSize,
+				BatchSize:     *s.BatchSize,
+				FlushInterval: *s.FlushInterval,
+			}
+		}
 	}
 
 	if l := len(apiTask.Artifacts); l != 0 {
@@ -837,6 +897,65 @@ func ApiTaskToStructsTask(apiTask *api.Task, structsTask *structs.Task) {
 			File: apiTask.DispatchPayload.File,
 		}
 	}
+
+	if l := len(apiTask.VolumeMounts); l != 0 {
+		structsTask.VolumeMounts = make([]*structs.VolumeMount, l)
+		for i, m := range apiTask.VolumeMounts {
+			structsTask.VolumeMounts[i] = &structs.VolumeMount{
+				Volume:      m.Volume,
+				Destination: m.Destination,
+				ReadOnly:    m.ReadOnly,
+			}
+		}
+	}
+
+	if apiTask.Lifecycle != nil {
+		structsTask.Lifecycle = &structs.TaskLifecycleConfig{
+			Hook:    apiTask.Lifecycle.Hook,
+			Sidecar: apiTask.Lifecycle.Sidecar,
+		}
+	}
+}
+
+// ApiNetworkResourcesToStructs converts the networks requested by a task or a
+// task group.
+func ApiNetworkResourcesToStructs(in []*api.NetworkResource) []*structs.NetworkResource {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out := make([]*structs.NetworkResource, len(in))
+	for i, nw := range in {
+		out[i] = &structs.NetworkResource{
+			Mode:  nw.Mode,
+			CIDR:  nw.CIDR,
+			IP:    nw.IP,
+			MBits: *nw.MBits,
+		}
+
+		if l := len(nw.DynamicPorts); l != 0 {
+			out[i].DynamicPorts = make([]structs.Port, l)
+			for j, dp := range nw.DynamicPorts {
+				out[i].DynamicPorts[j] = structs.Port{
+					Label: dp.Label,
+					Value: dp.Value,
+					To:    dp.To,
+				}
+			}
+		}
+
+		if l := len(nw.ReservedPorts); l != 0 {
+			out[i].ReservedPorts = make([]structs.Port, l)
+			for j, rp := range nw.ReservedPorts {
+				out[i].ReservedPorts[j] = structs.Port{
+					Label: rp.Label,
+					Value: rp.Value,
+					To:    rp.To,
+				}
+			}
+		}
+	}
+	return out
 }
 
 func ApiConstraintToStructs(c1 *api.Constraint, c2 *structs.Constraint) {
@@ -844,3 +963,28 @@ func ApiConstraintToStructs(c1 *api.Constraint, c2 *structs.Constraint) {
 	c2.RTarget = c1.RTarget
 	c2.Operand = c1.Operand
 }
+
+func ApiAffinityToStructs(a1 *api.Affinity) *structs.Affinity {
+	return &structs.Affinity{
+		LTarget: a1.LTarget,
+		Operand: a1.Operand,
+		RTarget: a1.RTarget,
+		Weight:  *a1.Weight,
+	}
+}
+
+func ApiSpreadToStructs(a1 *api.Spread) *structs.Spread {
+	ret := &structs.Spread{}
+	ret.Attribute = a1.Attribute
+	ret.Weight = *a1.Weight
+	if a1.SpreadTarget != nil {
+		ret.SpreadTarget = make([]*structs.SpreadTarget, len(a1.SpreadTarget))
+		for i, st := range a1.SpreadTarget {
+			ret.SpreadTarget[i] = &structs.SpreadTarget{
+				Value:   st.Value,
+				Percent: st.Percent,
+			}
+		}
+	}
+	return ret
+}
@@ -1128,6 +1128,26 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 				Operand: "c",
 			},
 		},
+		Affinities: []*api.Affinity{
+			{
+				LTarget: "a",
+				RTarget: "b",
+				Operand: "c",
+				Weight:  helper.IntToPtr(50),
+			},
+		},
+		Spreads: []*api.Spread{
+			{
+				Attribute: "${meta.rack}",
+				Weight:    helper.IntToPtr(100),
+				SpreadTarget: []*api.SpreadTarget{
+					{
+						Value:   "r1",
+						Percent: 50,
+					},
+				},
+			},
+		},
 		Update: &api.UpdateStrategy{
 			Stagger:         helper.TimeToPtr(1 * time.Second),
 			MaxParallel:     helper.IntToPtr(5),
@@ -1140,8 +1160,11 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 		Periodic: &api.PeriodicConfig{
 			Enabled:         helper.BoolToPtr(true),
 			Spec:            helper.StringToPtr("spec"),
+			Specs:           []string{"other spec"},
 			SpecType:        helper.StringToPtr("cron"),
 			ProhibitOverlap: helper.BoolToPtr(true),
+			CatchUp:         helper.BoolToPtr(true),
+			CatchUpWindow:   helper.TimeToPtr(2 * time.Hour),
 			TimeZone:        helper.StringToPtr("test zone"),
 		},
 		ParameterizedJob: &api.ParameterizedJobConfig{
@@ -1189,11 +1212,28 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 					Sticky:  helper.BoolToPtr(true),
 					Migrate: helper.BoolToPtr(true),
 				},
+				Volumes: map[string]*api.VolumeRequest{
+					"data": {
+						Name:     "data",
+						Type:     "host",
+						Source:   "shared-data",
+						ReadOnly: true,
+					},
+				},
+				Networks: []*api.NetworkResource{
+					{
+						Mode:         "bridge",
+						MBits:        helper.IntToPtr(10),
+						DynamicPorts: []api.Port{{Label: "http", To: 8080}},
+					},
+				},
 				Update: &api.UpdateStrategy{
-					HealthCheck:     helper.StringToPtr(structs.UpdateStrategyHealthCheck_Checks),
-					MinHealthyTime:  helper.TimeToPtr(2 * time.Minute),
-					HealthyDeadline: helper.TimeToPtr(5 * time.Minute),
-					AutoRevert:      helper.BoolToPtr(true),
+					HealthCheck:      helper.StringToPtr(structs.UpdateStrategyHealthCheck_Checks),
+					MinHealthyTime:   helper.TimeToPtr(2 * time.Minute),
+					HealthyDeadline:  helper.TimeToPtr(5 * time.Minute),
+					ProgressDeadline: helper.TimeToPtr(7 * time.Minute),
+					AutoRevert:       helper.BoolToPtr(true),
+					AutoPromote:      helper.BoolToPtr(true),
 				},
 
 				Meta: map[string]string{
@@ -1260,8 +1300,10 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 							},
 						},
 						Resources: &api.Resources{
-							CPU:      helper.IntToPtr(100),
-							MemoryMB: helper.IntToPtr(10),
+							CPU:         helper.IntToPtr(100),
+							MemoryMB:    helper.IntToPtr(10),
+							MemoryMaxMB: helper.IntToPtr(20),
+							Cores:       helper.IntToPtr(1),
 							Networks: []*api.NetworkResource{
 								{
 									IP:    "10.10.11.1",
@@ -1280,6 +1322,14 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 									},
 								},
 							},
+							Devices: []*api.DeviceResource{
+								{
+									Vendor: "046d",
+									Type:   "usb",
+									Name:   "c52b",
+									Count:  helper.Uint64ToPtr(2),
+								},
+							},
 						},
 						Meta: map[string]string{
 							"lol": "code",
@@ -1287,8 +1337,20 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						KillTimeout: helper.TimeToPtr(10 * time.Second),
 						KillSignal:  "SIGQUIT",
 						LogConfig: &api.LogConfig{
-							MaxFiles:      helper.IntToPtr(10),
-							MaxFileSizeMB: helper.IntToPtr(100),
+							MaxFiles:         helper.IntToPtr(10),
+							MaxFileSizeMB:    helper.IntToPtr(100),
+							RotationInterval: helper.TimeToPtr(time.Hour),
+							Compress:         helper.BoolToPtr(true),
+							MaxTotalSizeMB:   helper.IntToPtr(1000),
+							Shippers: []*api.LogShipper{
+								{
+									Type:          helper.StringToPtr("tcp"),
+									Address:       helper.StringToPtr("127.0.0.1:5140"),
+									BufferSize:    helper.IntToPtr(1024),
+									BatchSize:     helper.IntToPtr(100),
+									FlushInterval: helper.TimeToPtr(time.Second),
+								},
+							},
 						},
 						Artifacts: []*api.TaskArtifact{
 							{
@@ -1324,6 +1386,17 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						DispatchPayload: &api.DispatchPayloadConfig{
 							File: "fileA",
 						},
+						Lifecycle: &api.TaskLifecycle{
+							Hook:    "prestart",
+							Sidecar: true,
+						},
+						VolumeMounts: []*api.VolumeMount{
+							{
+								Volume:      "data",
+								Destination: "/srv/data",
+								ReadOnly:    true,
+							},
+						},
 					},
 				},
 			},
@@ -1355,6 +1428,26 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 				Operand: "c",
 			},
 		},
+		Affinities: []*structs.Affinity{
+			{
+				LTarget: "a",
+				RTarget: "b",
+				Operand: "c",
+				Weight:  50,
+			},
+		},
+		Spreads: []*structs.Spread{
+			{
+				Attribute: "${meta.rack}",
+				Weight:    100,
+				SpreadTarget: []*structs.SpreadTarget{
+					{
+						Value:   "r1",
+						Percent: 50,
+					},
+				},
+			},
+		},
 		Update: structs.UpdateStrategy{
 			Stagger:     1 * time.Second,
 			MaxParallel: 5,
@@ -1362,8 +1455,11 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 		Periodic: &structs.PeriodicConfig{
 			Enabled:         true,
 			Spec:            "spec",
+			Specs:           []string{"other spec"},
 			SpecType:        "cron",
 			ProhibitOverlap: true,
+			CatchUp:         true,
+			CatchUpWindow:   2 * time.Hour,
 			TimeZone:        "test zone",
 		},
 		ParameterizedJob: &structs.ParameterizedJobConfig{
@@ -1411,14 +1507,31 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 					Sticky:  true,
 					Migrate: true,
 				},
+				Volumes: map[string]*structs.VolumeRequest{
+					"data": {
+						Name:     "data",
+						Type:     structs.VolumeTypeHost,
+						Source:   "shared-data",
+						ReadOnly: true,
+					},
+				},
+				Networks: []*structs.NetworkResource{
+					{
+						Mode:         structs.NetworkModeBridge,
+						MBits:        10,
+						DynamicPorts: []structs.Port{{Label: "http", To: 8080}},
+					},
+				},
 				Update: &structs.UpdateStrategy{
-					Stagger:         1 * time.Second,
-					MaxParallel:     5,
-					HealthCheck:     structs.UpdateStrategyHealthCheck_Checks,
-					MinHealthyTime:  2 * time.Minute,
-					HealthyDeadline: 5 * time.Minute,
-					AutoRevert:      true,
-					Canary:          1,
+					Stagger:          1 * time.Second,
+					MaxParallel:      5,
+					HealthCheck:      structs.UpdateStrategyHealthCheck_Checks,
+					MinHealthyTime:   2 * time.Minute,
+					HealthyDeadline:  5 * time.Minute,
+					ProgressDeadline: 7 * time.Minute,
+					AutoRevert:       true,
+					AutoPromote:      true,
+					Canary:           1,
 				},
 				Meta: map[string]string{
 					"key": "value",
@@ -1482,8 +1595,10 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 							},
 						},
 						Resources: &structs.Resources{
-							CPU:      100,
-							MemoryMB: 10,
+							CPU:         100,
+							MemoryMB:    10,
+							MemoryMaxMB: 20,
+							Cores:       1,
 							Networks: []*structs.NetworkResource{
 								{
 									IP:    "10.10.11.1",
@@ -1502,6 +1617,14 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 									},
 								},
 							},
+							Devices: []*structs.DeviceResource{
+								{
+									Vendor: "046d",
+									Type:   "usb",
+									Name:   "c52b",
+									Count:  2,
+								},
+							},
 						},
 						Meta: map[string]string{
 							"lol": "code",
@@ -1509,8 +1632,20 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						KillTimeout: 10 * time.Second,
 						KillSignal:  "SIGQUIT",
 						LogConfig: &structs.LogConfig{
-							MaxFiles:      10,
-							MaxFileSizeMB: 100,
+							MaxFiles:         10,
+							MaxFileSizeMB:    100,
+							RotationInterval: time.Hour,
+							Compress:         true,
+							MaxTotalSizeMB:   1000,
+							Shippers: []*structs.LogShipper{
+								{
+									Type:          "tcp",
+									Address:       "127.0.0.1:5140",
+									BufferSize:    1024,
+									BatchSize:     100,
+									FlushInterval: time.Second,
+								},
+							},
 						},
 						Artifacts: []*structs.TaskArtifact{
 							{
@@ -1546,6 +1681,17 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						DispatchPayload: &structs.DispatchPayloadConfig{
 							File: "fileA",
 						},
+						Lifecycle: &structs.TaskLifecycleConfig{
+							Hook:    structs.TaskLifecycleHookPrestart,
+							Sidecar: true,
+						},
+						VolumeMounts: []*structs.VolumeMount{
+							{
+								Volume:      "data",
+								Destination: "/srv/data",
+								ReadOnly:    true,
+							},
+						},
 					},
 				},
 			},
@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"context"
+	"io"
+	"net"
 	"net/http"
 	"strings"
 
@@ -10,8 +13,16 @@ import (
 
 	"github.com/hashicorp/consul/agent/consul/autopilot"
 	"github.com/hashicorp/nomad/api"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/raft"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// snapshotFrameSize is the maximum size of the snapshot data sent in a
+	// single frame when restoring a snapshot
+	snapshotFrameSize = 64 * 1024
 )
 
 func (s *HTTPServer) OperatorRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -164,6 +175,161 @@ func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, re
 	}
 }
 
+// OperatorSchedulerConfiguration is used to inspect and update the scheduler
+// configuration.
+func (s *HTTPServer) OperatorSchedulerConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Switch on the method
+	switch req.Method {
+	case "GET":
+		var args structs.GenericRequest
+		if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+			return nil, nil
+		}
+
+		var reply structs.SchedulerConfigurationResponse
+		if err := s.agent.RPC("Operator.SchedulerGetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+		setMeta(resp, &reply.QueryMeta)
+
+		config := reply.SchedulerConfig
+		out := api.SchedulerConfigurationResponse{
+			SchedulerConfig: &api.SchedulerConfiguration{
+				SchedulerAlgorithm:            string(config.SchedulerAlgorithm),
+				MinNodeLimit:                  config.MinNodeLimit,
+				MaxSkippedNodes:               config.MaxSkippedNodes,
+				ServiceJobAntiAffinityPenalty: config.ServiceJobAntiAffinityPenalty,
+				BatchJobAntiAffinityPenalty:   config.BatchJobAntiAffinityPenalty,
+				MaxServiceScheduleAttempts:    config.MaxServiceScheduleAttempts,
+				MaxBatchScheduleAttempts:      config.MaxBatchScheduleAttempts,
+				DisableBlockedEvals:           config.DisableBlockedEvals,
+				MemoryOversubscriptionEnabled: config.MemoryOversubscriptionEnabled,
+				CreateIndex:                   config.CreateIndex,
+				ModifyIndex:                   config.ModifyIndex,
+			},
+			QueryMeta: api.QueryMeta{
+				LastIndex:   reply.Index,
+				LastContact: reply.LastContact,
+				KnownLeader: reply.KnownLeader,
+			},
+		}
+		return out, nil
+
+	case "PUT", "POST":
+		var args structs.SchedulerSetConfigRequest
+		s.parseWriteRequest(req, &args.WriteRequest)
+
+		var conf api.SchedulerConfiguration
+		if err := decodeBody(req, &conf); err != nil {
+			return nil, CodedError(http.StatusBadRequest, fmt.Sprintf("Error parsing scheduler config: %v", err))
+		}
+
+		args.Config = structs.SchedulerConfiguration{
+			SchedulerAlgorithm:            structs.SchedulerAlgorithm(conf.SchedulerAlgorithm),
+			MinNodeLimit:                  conf.MinNodeLimit,
+			MaxSkippedNodes:               conf.MaxSkippedNodes,
+			ServiceJobAntiAffinityPenalty: conf.ServiceJobAntiAffinityPenalty,
+			BatchJobAntiAffinityPenalty:   conf.BatchJobAntiAffinityPenalty,
+			MaxServiceScheduleAttempts:    conf.MaxServiceScheduleAttempts,
+			MaxBatchScheduleAttempts:      conf.MaxBatchScheduleAttempts,
+			DisableBlockedEvals:           conf.DisableBlockedEvals,
+			MemoryOversubscriptionEnabled: conf.MemoryOversubscriptionEnabled,
+		}
+		if err := args.Config.Validate(); err != nil {
+			return nil, CodedError(http.StatusBadRequest, err.Error())
+		}
+
+		// Check for cas value
+		params := req.URL.Query()
+		if _, ok := params["cas"]; ok {
+			casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
+			if err != nil {
+				return nil, CodedError(http.StatusBadRequest, fmt.Sprintf("Error parsing cas value: %v", err))
+			}
+			args.Config.ModifyIndex = casVal
+			args.CAS = true
+		}
+
+		var reply structs.SchedulerSetConfigurationResponse
+		if err := s.agent.RPC("Operator.SchedulerSetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+		setIndex(resp, reply.Index)
+
+		out := api.SchedulerSetConfigurationResponse{
+			Updated: reply.Updated,
+			WriteMeta: api.WriteMeta{
+				LastIndex: reply.Index,
+			},
+		}
+		return out, nil
+
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// OperatorSchedulerQueue is used to inspect the queues of the eval broker.
+func (s *HTTPServer) OperatorSchedulerQueue(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.GenericRequest
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var out structs.SchedulerQueueResponse
+	if err := s.agent.RPC("Operator.SchedulerQueue", &args, &out); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &out.QueryMeta)
+	return out, nil
+}
+
+// OperatorSchedulerSimulate is used to simulate how the schedulers would react
+// to hypothetical changes to the nodes and jobs of the cluster.
+func (s *HTTPServer) OperatorSchedulerSimulate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var sim api.SchedulerSimulateRequest
+	if err := decodeBody(req, &sim); err != nil {
+		return nil, CodedError(http.StatusBadRequest, fmt.Sprintf("Error parsing simulation request: %v", err))
+	}
+
+	args := structs.SchedulerSimulateRequest{
+		RemoveNodeIDs: sim.RemoveNodeIDs,
+		DrainNodeIDs:  sim.DrainNodeIDs,
+	}
+	for _, nodes := range sim.AddNodes {
+		if nodes == nil {
+			return nil, CodedError(http.StatusBadRequest, "Nodes to add must be specified")
+		}
+		args.AddNodes = append(args.AddNodes, &structs.SimulatedNodes{
+			Count:          nodes.Count,
+			NodeClass:      nodes.NodeClass,
+			TemplateNodeID: nodes.TemplateNodeID,
+		})
+	}
+	for _, job := range sim.Jobs {
+		if job == nil || job.ID == nil {
+			return nil, CodedError(http.StatusBadRequest, "Job must have a valid ID")
+		}
+		args.Jobs = append(args.Jobs, ApiJobToStructJob(job))
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.SchedulerSimulateResponse
+	if err := s.agent.RPC("Operator.SchedulerSimulate", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 // OperatorServerHealth is used to get the health of the servers in the given Region.
 func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if req.Method != "GET" {
@@ -208,3 +374,173 @@ func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Re
 
 	return out, nil
 }
+
+// OperatorSnapshotRequest is used to save a snapshot of the state of the
+// cluster or to restore the state from a snapshot.
+func (s *HTTPServer) OperatorSnapshotRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		return s.operatorSnapshotSave(resp, req)
+	case "PUT", "POST":
+		return s.operatorSnapshotRestore(resp, req)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// snapshotStreamingRpcHandler returns the handler of a snapshot streaming
+// RPC, which is the local server or a remote server.
+func (s *HTTPServer) snapshotStreamingRpcHandler(method string) (structs.StreamingRpcHandler, error) {
+	if srv := s.agent.Server(); srv != nil {
+		return srv.StreamingRpcHandler(method)
+	}
+	return s.agent.Client().RemoteStreamingRpcHandler(method)
+}
+
+// operatorSnapshotSave streams a snapshot of the state of the cluster in the
+// response body.
+func (s *HTTPServer) operatorSnapshotSave(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.SnapshotSaveRequest
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	handler, err := s.snapshotStreamingRpcHandler("Operator.SnapshotSave")
+	if err != nil {
+		return nil, CodedError(500, err.Error())
+	}
+
+	p1, p2 := net.Pipe()
+	decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+
+	// Create a goroutine that closes the pipe if the connection closes.
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		<-ctx.Done()
+		p1.Close()
+	}()
+
+	// Create a channel that decodes the results
+	errCh := make(chan error, 1)
+	go func() {
+		defer cancel()
+
+		// Send the request
+		if err := encoder.Encode(args); err != nil {
+			errCh <- CodedError(500, err.Error())
+			return
+		}
+
+		wroteHeader := false
+		for {
+			var res cstructs.StreamErrWrapper
+			if err := decoder.Decode(&res); err != nil {
+				errCh <- CodedError(500, err.Error())
+				return
+			}
+
+			if err := res.Error; err != nil {
+				if err.Code != nil {
+					errCh <- CodedError(int(*err.Code), err.Error())
+				} else {
+					errCh <- err
+				}
+				return
+			}
+
+			if !wroteHeader {
+				resp.Header().Set("Content-Type", "application/x-gzip")
+				wroteHeader = true
+			}
+			if _, err := resp.Write(res.Payload); err != nil {
+				errCh <- CodedError(500, err.Error())
+				return
+			}
+		}
+	}()
+
+	handler(p2)
+	cancel()
+	streamErr := <-errCh
+	if streamErr != nil &&
+		(streamErr == io.EOF ||
+			strings.Contains(streamErr.Error(), "closed") ||
+			strings.Contains(streamErr.Error(), "EOF")) {
+		streamErr = nil
+	}
+	return nil, streamErr
+}
+
+// operatorSnapshotRestore restores the state of the cluster from the
+// snapshot in the request body.
+func (s *HTTPServer) operatorSnapshotRestore(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.SnapshotRestoreRequest
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	handler, err := s.snapshotStreamingRpcHandler("Operator.SnapshotRestore")
+	if err != nil {
+		return nil, CodedError(500, err.Error())
+	}
+
+	p1, p2 := net.Pipe()
+	decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+
+	// Create a goroutine that closes the pipe if the connection closes.
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		<-ctx.Done()
+		p1.Close()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer cancel()
+
+		// Send the request
+		if err := encoder.Encode(args); err != nil {
+			errCh <- CodedError(500, err.Error())
+			return
+		}
+
+		// Send the snapshot while waiting for the response, the end of the
+		// snapshot is marked by an EOF error
+		go func() {
+			buf := make([]byte, snapshotFrameSize)
+			for {
+				n, err := req.Body.Read(buf)
+				if n > 0 {
+					if err := encoder.Encode(&cstructs.StreamErrWrapper{Payload: buf[:n]}); err != nil {
+						return
+					}
+				}
+				if err != nil {
+					encoder.Encode(&cstructs.StreamErrWrapper{Error: cstructs.NewRpcError(err, nil)})
+					return
+				}
+			}
+		}()
+
+		var res cstructs.StreamErrWrapper
+		if err := decoder.Decode(&res); err != nil {
+			errCh <- CodedError(500, err.Error())
+			return
+		}
+
+		if err := res.Error; err != nil {
+			if err.Code != nil {
+				errCh <- CodedError(int(*err.Code), err.Error())
+			} else {
+				errCh <- err
+			}
+			return
+		}
+
+		errCh <- nil
+	}()
+
+	handler(p2)
+	cancel()
+	return nil, <-errCh
+}
@@ -11,8 +11,10 @@ import (
 
 	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/snapshot"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHTTP_OperatorRaftConfiguration(t *testing.T) {
@@ -257,3 +259,186 @@ func TestOperator_ServerHealth_Unhealthy(t *testing.T) {
 		})
 	})
 }
+
+func TestOperator_SchedulerGetConfiguration(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		require := require.New(t)
+		req, _ := http.NewRequest("GET", "/v1/operator/scheduler/configuration", nil)
+		resp := httptest.NewRecorder()
+		obj, err := s.Server.OperatorSchedulerConfiguration(resp, req)
+		require.Nil(err)
+		require.Equal(200, resp.Code)
+
+		out, ok := obj.(api.SchedulerConfigurationResponse)
+		require.True(ok)
+		require.Equal(api.SchedulerAlgorithmBinpack, out.SchedulerConfig.SchedulerAlgorithm)
+	})
+}
+
+func TestOperator_SchedulerQueue(t *testing.T) {
+	t.Parallel()
+	httpTest(t, func(c *Config) {
+		c.Server.EvalFairQueueing = true
+	}, func(s *TestAgent) {
+		require := require.New(t)
+		req, _ := http.NewRequest("GET", "/v1/operator/scheduler/queue", nil)
+		resp := httptest.NewRecorder()
+		obj, err := s.Server.OperatorSchedulerQueue(resp, req)
+		require.Nil(err)
+		require.Equal(200, resp.Code)
+
+		out, ok := obj.(structs.SchedulerQueueResponse)
+		require.True(ok)
+		require.True(out.FairQueueing)
+	})
+}
+
+func TestOperator_SchedulerSetConfiguration(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		require := require.New(t)
+		body := bytes.NewBuffer([]byte(`{"SchedulerAlgorithm": "spread"}`))
+		req, _ := http.NewRequest("PUT", "/v1/operator/scheduler/configuration", body)
+		resp := httptest.NewRecorder()
+		_, err := s.Server.OperatorSchedulerConfiguration(resp, req)
+		require.Nil(err)
+		require.Equal(200, resp.Code)
+		require.NotEmpty(resp.Header().Get("X-Nomad-Index"))
+
+		args := structs.GenericRequest{
+			QueryOptions: structs.QueryOptions{
+				Region: s.Config.Region,
+			},
+		}
+
+		var reply structs.SchedulerConfigurationResponse
+		require.Nil(s.RPC("Operator.SchedulerGetConfiguration", &args, &reply))
+		require.Equal(structs.SchedulerAlgorithmSpread, reply.SchedulerConfig.SchedulerAlgorithm)
+
+		// Unknown algorithms are rejected
+		body = bytes.NewBuffer([]byte(`{"SchedulerAlgorithm": "random"}`))
+		req, _ = http.NewRequest("PUT", "/v1/operator/scheduler/configuration", body)
+		resp = httptest.NewRecorder()
+		_, err = s.Server.OperatorSchedulerConfiguration(resp, req)
+		require.NotNil(err)
+		require.Contains(err.Error(), "invalid scheduler algorithm")
+	})
+}
+
+func TestOperator_SchedulerCASConfiguration(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		require := require.New(t)
+		body := bytes.NewBuffer([]byte(`{"MinNodeLimit": 4}`))
+		req, _ := http.NewRequest("PUT", "/v1/operator/scheduler/configuration", body)
+		resp := httptest.NewRecorder()
+		_, err := s.Server.OperatorSchedulerConfiguration(resp, req)
+		require.Nil(err)
+		require.Equal(200, resp.Code)
+
+		args := structs.GenericRequest{
+			QueryOptions: structs.QueryOptions{
+				Region: s.Config.Region,
+			},
+		}
+
+		var reply structs.SchedulerConfigurationResponse
+		require.Nil(s.RPC("Operator.SchedulerGetConfiguration", &args, &reply))
+		require.Equal(4, *reply.SchedulerConfig.MinNodeLimit)
+
+		// Create a CAS request, bad index
+		{
+			buf := bytes.NewBuffer([]byte(`{"MinNodeLimit": 8}`))
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/v1/operator/scheduler/configuration?cas=%d", reply.SchedulerConfig.ModifyIndex-1), buf)
+			resp := httptest.NewRecorder()
+			obj, err := s.Server.OperatorSchedulerConfiguration(resp, req)
+			require.Nil(err)
+			require.False(obj.(api.SchedulerSetConfigurationResponse).Updated)
+		}
+
+		// Create a CAS request, good index
+		{
+			buf := bytes.NewBuffer([]byte(`{"MinNodeLimit": 8}`))
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/v1/operator/scheduler/configuration?cas=%d", reply.SchedulerConfig.ModifyIndex), buf)
+			resp := httptest.NewRecorder()
+			obj, err := s.Server.OperatorSchedulerConfiguration(resp, req)
+			require.Nil(err)
+			require.True(obj.(api.SchedulerSetConfigurationResponse).Updated)
+		}
+
+		// Verify the update
+		require.Nil(s.RPC("Operator.SchedulerGetConfiguration", &args, &reply))
+		require.Equal(8, *reply.SchedulerConfig.MinNodeLimit)
+	})
+}
+
+func TestOperator_SchedulerSimulate(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		require := require.New(t)
+		body := bytes.NewBuffer([]byte(`{}`))
+		req, _ := http.NewRequest("PUT", "/v1/operator/scheduler/simulate", body)
+		resp := httptest.NewRecorder()
+		obj, err := s.Server.OperatorSchedulerSimulate(resp, req)
+		require.Nil(err)
+		require.Equal(200, resp.Code)
+		require.NotEmpty(resp.Header().Get("X-Nomad-Index"))
+
+		out, ok := obj.(structs.SchedulerSimulateResponse)
+		require.True(ok)
+		require.Empty(out.Jobs)
+
+		// Unknown nodes are rejected
+		body = bytes.NewBuffer([]byte(`{"DrainNodeIDs": ["ffffffff-0000"]}`))
+		req, _ = http.NewRequest("PUT", "/v1/operator/scheduler/simulate", body)
+		resp = httptest.NewRecorder()
+		_, err = s.Server.OperatorSchedulerSimulate(resp, req)
+		require.NotNil(err)
+		require.Contains(err.Error(), "no node with prefix")
+
+		// Only writes are allowed
+		req, _ = http.NewRequest("GET", "/v1/operator/scheduler/simulate", nil)
+		resp = httptest.NewRecorder()
+		_, err = s.Server.OperatorSchedulerSimulate(resp, req)
+		require.NotNil(err)
+	})
+}
+
+func TestOperator_SnapshotSaveRestore(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		// Save a snapshot
+		req, err := http.NewRequest("GET", "/v1/operator/snapshot", nil)
+		require.NoError(err)
+		resp := httptest.NewRecorder()
+		_, err = s.Server.OperatorSnapshotRequest(resp, req)
+		require.NoError(err)
+		require.Equal(200, resp.Code)
+		require.Equal("application/x-gzip", resp.Header().Get("Content-Type"))
+
+		snap := resp.Body.Bytes()
+		meta, err := snapshot.Verify(bytes.NewReader(snap))
+		require.NoError(err)
+		require.NotZero(meta.Index)
+
+		// Restore it
+		req, err = http.NewRequest("PUT", "/v1/operator/snapshot", bytes.NewReader(snap))
+		require.NoError(err)
+		resp = httptest.NewRecorder()
+		_, err = s.Server.OperatorSnapshotRequest(resp, req)
+		require.NoError(err)
+		require.Equal(200, resp.Code)
+
+		// A corrupted snapshot is rejected
+		req, err = http.NewRequest("PUT", "/v1/operator/snapshot", bytes.NewReader(snap[:len(snap)/2]))
+		require.NoError(err)
+		resp = httptest.NewRecorder()
+		_, err = s.Server.OperatorSnapshotRequest(resp, req)
+		require.Error(err)
+		codedErr, ok := err.(HTTPCodedError)
+		require.True(ok)
+		require.Equal(400, codedErr.Code())
+	})
+}
@@ -0,0 +1,276 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/term"
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/posener/complete"
+)
+
+type AllocExecCommand struct {
+	Meta
+
+	// Stdin, Stdout and Stderr are the streams of the command. They default
+	// to the streams of the process.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (l *AllocExecCommand) Help() string {
+	helpText := `
+Usage: nomad alloc exec [options] <allocation> <command> [<args>...]
+
+  Runs a command inside a running task of the given allocation and streams its
+  output. The exit code of the command is returned.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Exec Specific Options:
+
+  -task <task-name>
+    Sets the task to execute the command in. It may be omitted if the
+    allocation has a single task.
+
+  -job
+    Use a random allocation from the specified job ID.
+
+  -i
+    Pass stdin to the command.
+
+  -t
+    Allocate a pseudo-terminal for the command. The terminal of the CLI is put
+    in raw mode and its size is synchronized with the pseudo-terminal.
+
+  -verbose
+    Show full information.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+func (l *AllocExecCommand) Synopsis() string {
+	return "Execute commands in a task"
+}
+
+func (l *AllocExecCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(l.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-task":    complete.PredictAnything,
+			"-job":     complete.PredictAnything,
+			"-i":       complete.PredictNothing,
+			"-t":       complete.PredictNothing,
+			"-verbose": complete.PredictNothing,
+		})
+}
+
+func (l *AllocExecCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := l.Meta.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Allocs, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Allocs]
+	})
+}
+
+func (l *AllocExecCommand) Run(args []string) int {
+	var verbose, job, stdinOpt, ttyOpt bool
+	var task string
+
+	flags := l.Meta.FlagSet("alloc exec", FlagSetClient)
+	flags.Usage = func() { l.Ui.Output(l.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&job, "job", false, "")
+	flags.BoolVar(&stdinOpt, "i", false, "")
+	flags.BoolVar(&ttyOpt, "t", false, "")
+	flags.StringVar(&task, "task", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	args = flags.Args()
+
+	if len(args) < 2 {
+		if job {
+			l.Ui.Error("Job ID and command required. See help:\n")
+		} else {
+			l.Ui.Error("Allocation ID and command required. See help:\n")
+		}
+
+		l.Ui.Error(l.Help())
+		return 1
+	}
+	command := args[1:]
+
+	client, err := l.Meta.Client()
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Error initializing client: %v", err))
+		return 1
+	}
+
+	// If -job is specified, use random allocation, otherwise use provided allocation
+	allocID := args[0]
+	if job {
+		allocID, err = getRandomJobAlloc(client, args[0])
+		if err != nil {
+			l.Ui.Error(fmt.Sprintf("Error fetching allocations: %v", err))
+			return 1
+		}
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+	// Query the allocation info
+	if len(allocID) == 1 {
+		l.Ui.Error(fmt.Sprintf("Alloc ID must contain at least two characters."))
+		return 1
+	}
+
+	allocID = sanitizeUUIDPrefix(allocID)
+	allocs, _, err := client.Allocations().PrefixList(allocID)
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Error querying allocation: %v", err))
+		return 1
+	}
+	if len(allocs) == 0 {
+		l.Ui.Error(fmt.Sprintf("No allocation(s) with prefix or id %q found", allocID))
+		return 1
+	}
+	if len(allocs) > 1 {
+		// Format the allocs
+		out := formatAllocListStubs(allocs, verbose, length)
+		l.Ui.Error(fmt.Sprintf("Prefix matched multiple allocations\n\n%s", out))
+		return 1
+	}
+	// Prefix lookup matched a single allocation
+	alloc, _, err := client.Allocations().Info(allocs[0].ID, nil)
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+		return 1
+	}
+
+	if task == "" {
+		// Try to determine the tasks name from the allocation
+		var tasks []*api.Task
+		for _, tg := range alloc.Job.TaskGroups {
+			if *tg.Name == alloc.TaskGroup {
+				if len(tg.Tasks) == 1 {
+					task = tg.Tasks[0].Name
+					break
+				}
+
+				tasks = tg.Tasks
+				break
+			}
+		}
+
+		if task == "" {
+			l.Ui.Error(fmt.Sprintf("Allocation %q is running the following tasks:", limit(alloc.ID, length)))
+			for _, t := range tasks {
+				l.Ui.Error(fmt.Sprintf("  * %s", t.Name))
+			}
+			l.Ui.Error("\nPlease specify the task with -task.")
+			return 1
+		}
+	}
+
+	exitCode, err := l.execImpl(client, alloc, task, ttyOpt, stdinOpt, command)
+	if err != nil {
+		l.Ui.Error(fmt.Sprintf("Failed to exec into task: %v", err))
+		return 1
+	}
+
+	return exitCode
+}
+
+// execImpl executes the command in the task, wiring up the streams of the
+// CLI and putting the terminal in raw mode if a pseudo-terminal is used.
+func (l *AllocExecCommand) execImpl(client *api.Client, alloc *api.Allocation, task string, tty, stdinOpt bool,
+	command []string) (int, error) {
+
+	stdin, stdout, stderr := l.Stdin, l.Stdout, l.Stderr
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	if !stdinOpt {
+		stdin = nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sizeCh chan api.TerminalSize
+	if tty {
+		inFd, isTerm := term.GetFdInfo(stdin)
+		if stdin != nil && isTerm {
+			state, err := term.SetRawTerminal(inFd)
+			if err != nil {
+				return -1, fmt.Errorf("failed to put terminal in raw mode: %v", err)
+			}
+			defer term.RestoreTerminal(inFd, state)
+		}
+
+		if outFd, isTerm := term.GetFdInfo(stdout); isTerm {
+			sizeCh = make(chan api.TerminalSize, 1)
+			go watchTerminalSize(ctx, outFd, sizeCh)
+		}
+	} else {
+		// Without a terminal interrupts stop the command rather than being
+		// passed to it
+		signalCh := make(chan os.Signal, 1)
+		signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signalCh)
+		go func() {
+			select {
+			case <-signalCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return client.Allocations().Exec(ctx, alloc, task, tty, command, stdin, stdout, stderr, sizeCh, nil)
+}
+
+// terminalSize returns the size of the terminal.
+func terminalSize(fd uintptr) (api.TerminalSize, error) {
+	ws, err := term.GetWinsize(fd)
+	if err != nil {
+		return api.TerminalSize{}, err
+	}
+	return api.TerminalSize{Height: int(ws.Height), Width: int(ws.Width)}, nil
+}
+
+// offerTerminalSize sends the size to the channel, replacing a size that has
+// not been sent yet.
+func offerTerminalSize(sizeCh chan api.TerminalSize, size api.TerminalSize) {
+	select {
+	case <-sizeCh:
+	default:
+	}
+	sizeCh <- size
+}
@@ -0,0 +1,32 @@
+// +build !windows
+
+package command
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// watchTerminalSize sends the size of the terminal when started and whenever
+// the terminal is resized until the context is done.
+func watchTerminalSize(ctx context.Context, fd uintptr, sizeCh chan api.TerminalSize) {
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGWINCH)
+	defer signal.Stop(signalCh)
+
+	for {
+		if size, err := terminalSize(fd); err == nil {
+			offerTerminalSize(sizeCh, size)
+		}
+
+		select {
+		case <-signalCh:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// +build windows
+
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// terminalSizePollInterval is the interval at which the size of the terminal
+// is checked as Windows does not signal resizes.
+const terminalSizePollInterval = time.Second
+
+// watchTerminalSize sends the size of the terminal when started and whenever
+// the terminal is resized until the context is done.
+func watchTerminalSize(ctx context.Context, fd uintptr, sizeCh chan api.TerminalSize) {
+	ticker := time.NewTicker(terminalSizePollInterval)
+	defer ticker.Stop()
+
+	var last api.TerminalSize
+	for {
+		if size, err := terminalSize(fd); err == nil && size != last {
+			last = size
+			offerTerminalSize(sizeCh, size)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocRestartCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &AllocRestartCommand{}
+}
+
+func TestAllocRestartCommand_Fails(t *testing.T) {
+	t.Parallel()
+	srv, _, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	cases := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			name:        "misuse",
+			args:        []string{"some-alloc", "task", "extra"},
+			expectedErr: "This command takes one or two arguments",
+		},
+		{
+			name:        "connection failure",
+			args:        []string{"-address=nope", "26470238-5CF2-438F-8772-DC67CFB0705C"},
+			expectedErr: "Error querying allocation",
+		},
+		{
+			name:        "missing alloc",
+			args:        []string{"-address=" + url, "26470238-5CF2-438F-8772-DC67CFB0705C"},
+			expectedErr: "No allocation(s) with prefix or id",
+		},
+		{
+			name:        "too few characters",
+			args:        []string{"-address=" + url, "2"},
+			expectedErr: "must contain at least two characters.",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ui := new(cli.MockUi)
+			cmd := &AllocRestartCommand{Meta: Meta{Ui: ui}}
+
+			code := cmd.Run(c.args)
+			require.Equal(t, 1, code)
+			out := ui.ErrorWriter.String()
+			require.True(t, strings.Contains(out, c.expectedErr), "unexpected output: %s", out)
+		})
+	}
+}
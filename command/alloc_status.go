@@ -286,6 +286,18 @@ func formatAllocBasicInfo(alloc *api.Allocation, client *api.Client, uuidLength
 		basic = append(basic,
 			fmt.Sprintf("Replacement Alloc ID|%s", limit(alloc.NextAllocation, uuidLength)))
 	}
+	if alloc.PreemptedByAllocation != "" {
+		basic = append(basic,
+			fmt.Sprintf("Preempted By Alloc ID|%s", limit(alloc.PreemptedByAllocation, uuidLength)))
+	}
+	if len(alloc.PreemptedAllocations) > 0 {
+		preempted := make([]string, len(alloc.PreemptedAllocations))
+		for i, id := range alloc.PreemptedAllocations {
+			preempted[i] = limit(id, uuidLength)
+		}
+		basic = append(basic,
+			fmt.Sprintf("Preempted Alloc IDs|%s", strings.Join(preempted, ",")))
+	}
 	if alloc.FollowupEvalID != "" {
 		nextEvalTime := futureEvalTimePretty(alloc.FollowupEvalID, client)
 		if nextEvalTime != "" {
@@ -472,6 +484,8 @@ func buildDisplayMessage(event *api.TaskEvent) string {
 		desc = event.DriverMessage
 	case api.TaskLeaderDead:
 		desc = "Leader Task in Group dead"
+	case api.TaskMainDead:
+		desc = "Main Tasks in Group dead"
 	default:
 		desc = event.Message
 	}
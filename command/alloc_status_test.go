@@ -224,6 +224,56 @@ func TestAllocStatusCommand_RescheduleInfo(t *testing.T) {
 	require.Regexp(regexp.MustCompile(".*Reschedule Attempts\\s*=\\s*1/2"), out)
 }
 
+func TestAllocStatusCommand_PreemptionInfo(t *testing.T) {
+	t.Parallel()
+	srv, client, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Wait for a node to be ready
+	testutil.WaitForResult(func() (bool, error) {
+		nodes, _, err := client.Nodes().List(nil)
+		if err != nil {
+			return false, err
+		}
+		for _, node := range nodes {
+			if node.Status == structs.NodeStatusReady {
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("no ready nodes")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	ui := new(cli.MockUi)
+	cmd := &AllocStatusCommand{Meta: Meta{Ui: ui}}
+	require := require.New(t)
+	state := srv.Agent.Server().State()
+
+	// Create an allocation that preempted another one
+	a := mock.Alloc()
+	a.Metrics = &structs.AllocMetric{}
+	preempted := mock.Alloc()
+	preempted.Metrics = &structs.AllocMetric{}
+	preempted.DesiredStatus = structs.AllocDesiredStatusEvict
+	preempted.PreemptedByAllocation = a.ID
+	a.PreemptedAllocations = []string{preempted.ID}
+	require.Nil(state.UpsertAllocs(1000, []*structs.Allocation{a, preempted}))
+
+	if code := cmd.Run([]string{"-address=" + url, a.ID}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	out := ui.OutputWriter.String()
+	require.Regexp(regexp.MustCompile(".*Preempted Alloc IDs\\s*=\\s*"+limit(preempted.ID, shortId)), out)
+
+	ui.OutputWriter.Reset()
+	if code := cmd.Run([]string{"-address=" + url, preempted.ID}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d", code)
+	}
+	out = ui.OutputWriter.String()
+	require.Regexp(regexp.MustCompile(".*Preempted By Alloc ID\\s*=\\s*"+limit(a.ID, shortId)), out)
+}
+
 func TestAllocStatusCommand_AutocompleteArgs(t *testing.T) {
 	assert := assert.New(t)
 	t.Parallel()
@@ -192,16 +192,22 @@ func formatDeployment(d *api.Deployment, uuidLength int) string {
 
 func formatDeploymentGroups(d *api.Deployment, uuidLength int) string {
 	// Detect if we need to add these columns
-	canaries, autorevert := false, false
+	canaries, autorevert, autopromote, deadline := false, false, false, false
 	tgNames := make([]string, 0, len(d.TaskGroups))
 	for name, state := range d.TaskGroups {
 		tgNames = append(tgNames, name)
 		if state.AutoRevert {
 			autorevert = true
 		}
+		if state.AutoPromote {
+			autopromote = true
+		}
 		if state.DesiredCanaries > 0 {
 			canaries = true
 		}
+		if state.ProgressDeadline != 0 {
+			deadline = true
+		}
 	}
 
 	// Sort the task group names to get a reliable ordering
@@ -212,6 +218,9 @@ func formatDeploymentGroups(d *api.Deployment, uuidLength int) string {
 	if autorevert {
 		rowString += "Auto Revert|"
 	}
+	if autopromote {
+		rowString += "Auto Promote|"
+	}
 	if canaries {
 		rowString += "Promoted|"
 	}
@@ -220,6 +229,9 @@ func formatDeploymentGroups(d *api.Deployment, uuidLength int) string {
 		rowString += "Canaries|"
 	}
 	rowString += "Placed|Healthy|Unhealthy"
+	if deadline {
+		rowString += "|Progress Deadline"
+	}
 
 	rows := make([]string, len(d.TaskGroups)+1)
 	rows[0] = rowString
@@ -230,6 +242,9 @@ func formatDeploymentGroups(d *api.Deployment, uuidLength int) string {
 		if autorevert {
 			row += fmt.Sprintf("%v|", state.AutoRevert)
 		}
+		if autopromote {
+			row += fmt.Sprintf("%v|", state.AutoPromote)
+		}
 		if canaries {
 			if state.DesiredCanaries > 0 {
 				row += fmt.Sprintf("%v|", state.Promoted)
@@ -242,6 +257,13 @@ func formatDeploymentGroups(d *api.Deployment, uuidLength int) string {
 			row += fmt.Sprintf("%d|", state.DesiredCanaries)
 		}
 		row += fmt.Sprintf("%d|%d|%d", state.PlacedAllocs, state.HealthyAllocs, state.UnhealthyAllocs)
+		if deadline {
+			if state.RequireProgressBy.IsZero() {
+				row += fmt.Sprintf("|%v", "N/A")
+			} else {
+				row += fmt.Sprintf("|%v", formatTime(state.RequireProgressBy))
+			}
+		}
 		rows[i] = row
 		i++
 	}
@@ -71,7 +71,8 @@ Plan Options:
     Sets the flag to force override any soft mandatory Sentinel policies.
 
   -verbose
-    Increase diff verbosity.
+    Increase diff verbosity and show the score breakdown of the top ranked
+    nodes for each task group.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -162,7 +163,7 @@ func (c *JobPlanCommand) Run(args []string) int {
 
 	// Print the scheduler dry-run output
 	c.Ui.Output(c.Colorize().Color("[bold]Scheduler dry-run:[reset]"))
-	c.Ui.Output(c.Colorize().Color(formatDryRun(resp, job)))
+	c.Ui.Output(c.Colorize().Color(formatDryRun(resp, job, verbose)))
 	c.Ui.Output("")
 
 	// Print any warnings if there are any
@@ -198,8 +199,10 @@ func formatJobModifyIndex(jobModifyIndex uint64, jobName string) string {
 	return out
 }
 
-// formatDryRun produces a string explaining the results of the dry run.
-func formatDryRun(resp *api.JobPlanResponse, job *api.Job) string {
+// formatDryRun produces a string explaining the results of the dry run. If
+// verbose is set, the scores of the nodes considered for placements are
+// included.
+func formatDryRun(resp *api.JobPlanResponse, job *api.Job, verbose bool) string {
 	var rolling *api.Evaluation
 	for _, eval := range resp.CreatedEvals {
 		if eval.TriggeredBy == "rolling-update" {
@@ -226,13 +229,31 @@ func formatDryRun(resp *api.JobPlanResponse, job *api.Job) string {
 				noun += "s"
 			}
 			out += fmt.Sprintf("%s[yellow]Task Group %q (failed to place %d %s):\n[reset]", strings.Repeat(" ", 2), tg, metrics.CoalescedFailures+1, noun)
-			out += fmt.Sprintf("[yellow]%s[reset]\n\n", formatAllocMetrics(metrics, false, strings.Repeat(" ", 4)))
+			out += fmt.Sprintf("[yellow]%s[reset]\n\n", formatAllocMetrics(metrics, verbose, strings.Repeat(" ", 4)))
 		}
 		if rolling == nil {
 			out = strings.TrimSuffix(out, "\n")
 		}
 	}
 
+	if verbose && len(resp.PlacementMetrics) > 0 {
+		out += "[bold]- Placement scores of the top ranked nodes:[reset]\n"
+		sorted := sortedTaskGroupFromMetrics(resp.PlacementMetrics)
+		for _, tg := range sorted {
+			metrics := resp.PlacementMetrics[tg]
+			if len(metrics.ScoreMetaData) == 0 {
+				continue
+			}
+			out += fmt.Sprintf("%sTask Group %q:\n", strings.Repeat(" ", 2), tg)
+			out += fmt.Sprintf("%s\n\n", formatScoreMetaData(metrics.ScoreMetaData, strings.Repeat(" ", 4)))
+		}
+	}
+
+	if resp.Annotations != nil && len(resp.Annotations.PreemptedAllocs) > 0 {
+		out += "[bold][yellow]- WARNING: The following allocations of lower priority jobs will be preempted:[reset]\n"
+		out += fmt.Sprintf("[yellow]%s[reset]\n", formatPreemptedAllocs(resp.Annotations.PreemptedAllocs, strings.Repeat(" ", 2)))
+	}
+
 	if rolling != nil {
 		out += fmt.Sprintf("[green]- Rolling update, next evaluation will be in %s.\n", rolling.Wait)
 	}
@@ -252,6 +273,26 @@ func formatDryRun(resp *api.JobPlanResponse, job *api.Job) string {
 	return out
 }
 
+// formatPreemptedAllocs produces a table of the allocations that would be
+// preempted, with each line indented by the given prefix.
+func formatPreemptedAllocs(allocs []*api.AllocationListStub, prefix string) string {
+	rows := make([]string, len(allocs)+1)
+	rows[0] = "Alloc ID|Job ID|Task Group|Node ID"
+	for i, alloc := range allocs {
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s",
+			limit(alloc.ID, shortId),
+			alloc.JobID,
+			alloc.TaskGroup,
+			limit(alloc.NodeID, shortId))
+	}
+
+	lines := strings.Split(formatList(rows), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
 // formatJobDiff produces an annotated diff of the job. If verbose mode is
 // set, added or deleted task groups and tasks are expanded.
 func formatJobDiff(job *api.JobDiff, verbose bool) string {
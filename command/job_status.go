@@ -24,8 +24,13 @@ type JobStatusCommand struct {
 	evals     bool
 	allAllocs bool
 	verbose   bool
+	launches  int
 }
 
+// defaultPeriodicLaunches is the number of next launches of a periodic job
+// displayed by default.
+const defaultPeriodicLaunches = 5
+
 func (c *JobStatusCommand) Help() string {
 	helpText := `
 Usage: nomad status [options] <job>
@@ -50,6 +55,9 @@ Status Options:
     Display all allocations matching the job ID, including those from an older
     instance of the job.
 
+  -periodic-launches
+    Number of next launch times to display for a periodic job. Defaults to 5.
+
   -verbose
     Display full information.
 `
@@ -63,10 +71,11 @@ func (c *JobStatusCommand) Synopsis() string {
 func (c *JobStatusCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-all-allocs": complete.PredictNothing,
-			"-evals":      complete.PredictNothing,
-			"-short":      complete.PredictNothing,
-			"-verbose":    complete.PredictNothing,
+			"-all-allocs":        complete.PredictNothing,
+			"-evals":             complete.PredictNothing,
+			"-periodic-launches": complete.PredictAnything,
+			"-short":             complete.PredictNothing,
+			"-verbose":           complete.PredictNothing,
 		})
 }
 
@@ -94,6 +103,7 @@ func (c *JobStatusCommand) Run(args []string) int {
 	flags.BoolVar(&c.evals, "evals", false, "")
 	flags.BoolVar(&c.allAllocs, "all-allocs", false, "")
 	flags.BoolVar(&c.verbose, "verbose", false, "")
+	flags.IntVar(&c.launches, "periodic-launches", defaultPeriodicLaunches, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -226,6 +236,9 @@ func (c *JobStatusCommand) outputPeriodicInfo(client *api.Client, job *api.Job)
 		return err
 	}
 
+	// Output the next launches of the job
+	c.outputNextPeriodicLaunches(job)
+
 	// Generate the prefix that matches launched jobs from the periodic job.
 	prefix := fmt.Sprintf("%s%s", *job.ID, structs.PeriodicLaunchSuffix)
 	children, _, err := client.Jobs().PrefixList(prefix)
@@ -257,6 +270,36 @@ func (c *JobStatusCommand) outputPeriodicInfo(client *api.Client, job *api.Job)
 	return nil
 }
 
+// outputNextPeriodicLaunches prints the next launch times of the passed
+// periodic job across all of its specs.
+func (c *JobStatusCommand) outputNextPeriodicLaunches(job *api.Job) {
+	if *job.Stop || c.launches <= 0 {
+		return
+	}
+
+	location, err := job.Periodic.GetLocation()
+	if err != nil {
+		return
+	}
+
+	now := time.Now().In(location)
+	launches := job.Periodic.NextN(now, c.launches)
+	if len(launches) == 0 {
+		return
+	}
+
+	out := make([]string, len(launches)+1)
+	out[0] = "Launch Time|From Now"
+	for i, launch := range launches {
+		out[i+1] = fmt.Sprintf("%s|%s",
+			formatTime(launch),
+			formatTimeDifference(now, launch, time.Second))
+	}
+
+	c.Ui.Output(c.Colorize().Color("\n[bold]Next Periodic Launches[reset]"))
+	c.Ui.Output(formatList(out))
+}
+
 // outputParameterizedInfo prints information about a parameterized job. If a
 // request fails, an error is returned.
 func (c *JobStatusCommand) outputParameterizedInfo(client *api.Client, job *api.Job) error {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/command/agent"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
@@ -208,6 +209,37 @@ func TestJobStatusCommand_Fails(t *testing.T) {
 	}
 }
 
+func TestJobStatusCommand_NextPeriodicLaunches(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &JobStatusCommand{Meta: Meta{Ui: ui}, launches: 3}
+
+	// A job launching every minute and at every round hour
+	job := &api.Job{
+		ID: helper.StringToPtr("foo"),
+		Periodic: &api.PeriodicConfig{
+			Spec:  helper.StringToPtr("* * * * *"),
+			Specs: []string{"0 * * * *"},
+		},
+	}
+	job.Canonicalize()
+
+	cmd.outputNextPeriodicLaunches(job)
+	out := ui.OutputWriter.String()
+	require.Contains(t, out, "Next Periodic Launches")
+	require.Contains(t, out, "Launch Time")
+
+	// The title, the header and the three launches are displayed
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 5)
+	ui.OutputWriter.Reset()
+
+	// Nothing is displayed for a stopped job
+	job.Stop = helper.BoolToPtr(true)
+	cmd.outputNextPeriodicLaunches(job)
+	require.Empty(t, ui.OutputWriter.String())
+}
+
 func TestJobStatusCommand_AutocompleteArgs(t *testing.T) {
 	assert := assert.New(t)
 	t.Parallel()
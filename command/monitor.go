@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -373,11 +374,53 @@ func formatAllocMetrics(metrics *api.AllocationMetric, scores bool, prefix strin
 
 	// Print scores
 	if scores {
-		for name, score := range metrics.Scores {
-			out += fmt.Sprintf("%s* Score %q = %f\n", prefix, name, score)
+		if len(metrics.ScoreMetaData) > 0 {
+			out += fmt.Sprintf("%s\n", formatScoreMetaData(metrics.ScoreMetaData, prefix))
+		} else {
+			for name, score := range metrics.Scores {
+				out += fmt.Sprintf("%s* Score %q = %f\n", prefix, name, score)
+			}
 		}
 	}
 
 	out = strings.TrimSuffix(out, "\n")
 	return out
 }
+
+// formatScoreMetaData produces a table of the normalized score breakdown of
+// the top scoring nodes, with each line indented by the given prefix.
+func formatScoreMetaData(scores []*api.NodeScoreMeta, prefix string) string {
+	// Collect the names of all the scorers as the columns
+	names := make(map[string]struct{})
+	for _, meta := range scores {
+		for name := range meta.Scores {
+			names[name] = struct{}{}
+		}
+	}
+	scorers := make([]string, 0, len(names))
+	for name := range names {
+		scorers = append(scorers, name)
+	}
+	sort.Strings(scorers)
+
+	rows := make([]string, len(scores)+1)
+	rows[0] = strings.Join(append(append([]string{"Node"}, scorers...), "final score"), "|")
+	for i, meta := range scores {
+		row := []string{limit(meta.NodeID, shortId)}
+		for _, name := range scorers {
+			if score, ok := meta.Scores[name]; ok {
+				row = append(row, fmt.Sprintf("%.3f", score))
+			} else {
+				row = append(row, "0")
+			}
+		}
+		row = append(row, fmt.Sprintf("%.3f", meta.NormScore))
+		rows[i+1] = strings.Join(row, "|")
+	}
+
+	lines := strings.Split(formatList(rows), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -5,8 +5,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMonitor_Update_Eval(t *testing.T) {
@@ -285,3 +287,38 @@ func TestMonitor_MonitorWithPrefix(t *testing.T) {
 	}
 
 }
+
+func TestMonitor_FormatAllocMetrics_Scores(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	metrics := &api.AllocationMetric{
+		NodesEvaluated: 2,
+		Scores: map[string]float64{
+			"node1.binpack": 9,
+		},
+		ScoreMetaData: []*api.NodeScoreMeta{
+			{
+				NodeID:    "e0ba7ae2-6b5e-9e0e-1b1c-1c0fd6b4d9a1",
+				Scores:    map[string]float64{"binpack": 0.5, "node-affinity": 0.25},
+				NormScore: 0.75,
+			},
+			{
+				NodeID:    "8d3c1d45-6b44-4c51-a1d4-2d8e1b6f0a2e",
+				Scores:    map[string]float64{"binpack": 0.4},
+				NormScore: 0.4,
+			},
+		},
+	}
+
+	// Scores are only shown when asked for
+	require.Empty(formatAllocMetrics(metrics, false, ""))
+
+	out := formatAllocMetrics(metrics, true, "  ")
+	lines := strings.Split(out, "\n")
+	require.Len(lines, 3)
+	require.Equal([]string{"Node", "binpack", "node-affinity", "final", "score"}, strings.Fields(lines[0]))
+	require.Equal([]string{"e0ba7ae2", "0.500", "0.250", "0.750"}, strings.Fields(lines[1]))
+	require.Equal([]string{"8d3c1d45", "0.400", "0", "0.400"}, strings.Fields(lines[2]))
+	require.True(strings.HasPrefix(lines[1], "  "))
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorSchedulerCommand struct {
+	Meta
+}
+
+func (c *OperatorSchedulerCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *OperatorSchedulerCommand) Synopsis() string {
+	return "Provides tools for managing the schedulers"
+}
+
+func (c *OperatorSchedulerCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler <subcommand> [options]
+
+  This command groups subcommands for interacting with the cluster wide
+  configuration of Nomad's schedulers.
+
+  Display the current scheduler configuration:
+
+      $ nomad operator scheduler get-config
+
+  Switch the schedulers to spread allocations across nodes:
+
+      $ nomad operator scheduler set-config -scheduler-algorithm=spread
+
+  Simulate draining a node and registering a job:
+
+      $ nomad operator scheduler simulate -drain-node=f7476465 example.nomad
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type OperatorSchedulerGetCommand struct {
+	Meta
+}
+
+func (c *OperatorSchedulerGetCommand) AutocompleteFlags() complete.Flags {
+	return c.Meta.AutocompleteFlags(FlagSetClient)
+}
+
+func (c *OperatorSchedulerGetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorSchedulerGetCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("scheduler", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration.
+	resp, _, err := client.Operator().SchedulerGetConfiguration(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying scheduler configuration: %s", err))
+		return 1
+	}
+	config := resp.SchedulerConfig
+	c.Ui.Output(fmt.Sprintf("SchedulerAlgorithm = %v", config.SchedulerAlgorithm))
+	c.Ui.Output(fmt.Sprintf("MinNodeLimit = %v", *config.MinNodeLimit))
+	c.Ui.Output(fmt.Sprintf("MaxSkippedNodes = %v", *config.MaxSkippedNodes))
+	c.Ui.Output(fmt.Sprintf("ServiceJobAntiAffinityPenalty = %v", *config.ServiceJobAntiAffinityPenalty))
+	c.Ui.Output(fmt.Sprintf("BatchJobAntiAffinityPenalty = %v", *config.BatchJobAntiAffinityPenalty))
+	c.Ui.Output(fmt.Sprintf("MaxServiceScheduleAttempts = %v", *config.MaxServiceScheduleAttempts))
+	c.Ui.Output(fmt.Sprintf("MaxBatchScheduleAttempts = %v", *config.MaxBatchScheduleAttempts))
+	c.Ui.Output(fmt.Sprintf("DisableBlockedEvals = %v", config.DisableBlockedEvals))
+	c.Ui.Output(fmt.Sprintf("MemoryOversubscriptionEnabled = %v", config.MemoryOversubscriptionEnabled))
+
+	return 0
+}
+
+func (c *OperatorSchedulerGetCommand) Synopsis() string {
+	return "Display the current scheduler configuration"
+}
+
+func (c *OperatorSchedulerGetCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler get-config [options]
+
+  Displays the current scheduler configuration.
+
+General Options:
+
+  ` + generalOptionsUsage()
+
+	return strings.TrimSpace(helpText)
+}
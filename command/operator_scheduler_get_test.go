@@ -0,0 +1,31 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorSchedulerGetConfigCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorSchedulerGetCommand{}
+}
+
+func TestOperatorSchedulerGetConfigCommand(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s, _, addr := testServer(t, false, nil)
+	defer s.Shutdown()
+
+	ui := new(cli.MockUi)
+	c := &OperatorSchedulerGetCommand{Meta: Meta{Ui: ui}}
+
+	code := c.Run([]string{"-address=" + addr})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	output := ui.OutputWriter.String()
+	require.Contains(output, "SchedulerAlgorithm = binpack")
+	require.Contains(output, "MinNodeLimit = 2")
+	require.Contains(output, "DisableBlockedEvals = false")
+	require.Contains(output, "MemoryOversubscriptionEnabled = false")
+}
@@ -0,0 +1,210 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/nomad/api"
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
+	"github.com/posener/complete"
+)
+
+type OperatorSchedulerSetCommand struct {
+	Meta
+}
+
+func (c *OperatorSchedulerSetCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-scheduler-algorithm": complete.PredictSet(
+				api.SchedulerAlgorithmBinpack,
+				api.SchedulerAlgorithmSpread,
+			),
+			"-min-node-limit":                    complete.PredictAnything,
+			"-max-skipped-nodes":                 complete.PredictAnything,
+			"-service-job-anti-affinity-penalty": complete.PredictAnything,
+			"-batch-job-anti-affinity-penalty":   complete.PredictAnything,
+			"-max-service-schedule-attempts":     complete.PredictAnything,
+			"-max-batch-schedule-attempts":       complete.PredictAnything,
+			"-disable-blocked-evals":             complete.PredictNothing,
+			"-memory-oversubscription":           complete.PredictNothing,
+		})
+}
+
+func (c *OperatorSchedulerSetCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorSchedulerSetCommand) Run(args []string) int {
+	var algorithm flags.StringValue
+	var disableBlockedEvals flags.BoolValue
+	var memoryOversubscription flags.BoolValue
+	var minNodeLimit, maxSkippedNodes, maxServiceAttempts, maxBatchAttempts *int
+	var servicePenalty, batchPenalty *float64
+
+	intVar := func(onto **int) flaghelper.FuncVar {
+		return func(s string) error {
+			v, err := strconv.ParseUint(s, 10, 0)
+			if err != nil {
+				return err
+			}
+			i := int(v)
+			*onto = &i
+			return nil
+		}
+	}
+	floatVar := func(onto **float64) flaghelper.FuncVar {
+		return func(s string) error {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			*onto = &v
+			return nil
+		}
+	}
+
+	f := c.Meta.FlagSet("scheduler", FlagSetClient)
+	f.Usage = func() { c.Ui.Output(c.Help()) }
+	f.Var(&algorithm, "scheduler-algorithm", "")
+	f.Var(intVar(&minNodeLimit), "min-node-limit", "")
+	f.Var(intVar(&maxSkippedNodes), "max-skipped-nodes", "")
+	f.Var(floatVar(&servicePenalty), "service-job-anti-affinity-penalty", "")
+	f.Var(floatVar(&batchPenalty), "batch-job-anti-affinity-penalty", "")
+	f.Var(intVar(&maxServiceAttempts), "max-service-schedule-attempts", "")
+	f.Var(intVar(&maxBatchAttempts), "max-batch-schedule-attempts", "")
+	f.Var(&disableBlockedEvals, "disable-blocked-evals", "")
+	f.Var(&memoryOversubscription, "memory-oversubscription", "")
+
+	if err := f.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration.
+	operator := client.Operator()
+	resp, _, err := operator.SchedulerGetConfiguration(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying for scheduler configuration: %s", err))
+		return 1
+	}
+	conf := resp.SchedulerConfig
+
+	// Update the config values based on the set flags.
+	algorithm.Merge(&conf.SchedulerAlgorithm)
+	switch conf.SchedulerAlgorithm {
+	case api.SchedulerAlgorithmBinpack, api.SchedulerAlgorithmSpread:
+	default:
+		c.Ui.Error(fmt.Sprintf("Invalid scheduler algorithm %q, must be one of %q or %q",
+			conf.SchedulerAlgorithm, api.SchedulerAlgorithmBinpack, api.SchedulerAlgorithmSpread))
+		return 1
+	}
+
+	if minNodeLimit != nil {
+		conf.MinNodeLimit = minNodeLimit
+	}
+	if maxSkippedNodes != nil {
+		conf.MaxSkippedNodes = maxSkippedNodes
+	}
+	if servicePenalty != nil {
+		conf.ServiceJobAntiAffinityPenalty = servicePenalty
+	}
+	if batchPenalty != nil {
+		conf.BatchJobAntiAffinityPenalty = batchPenalty
+	}
+	if maxServiceAttempts != nil {
+		conf.MaxServiceScheduleAttempts = maxServiceAttempts
+	}
+	if maxBatchAttempts != nil {
+		conf.MaxBatchScheduleAttempts = maxBatchAttempts
+	}
+	disableBlockedEvals.Merge(&conf.DisableBlockedEvals)
+	memoryOversubscription.Merge(&conf.MemoryOversubscriptionEnabled)
+
+	// Check-and-set the new configuration.
+	result, _, err := operator.SchedulerCASConfiguration(conf, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error setting scheduler configuration: %s", err))
+		return 1
+	}
+	if result.Updated {
+		c.Ui.Output("Scheduler configuration updated!")
+		return 0
+	}
+	c.Ui.Output("Scheduler configuration could not be atomically updated, please try again")
+	return 1
+}
+
+func (c *OperatorSchedulerSetCommand) Synopsis() string {
+	return "Modify the current scheduler configuration"
+}
+
+func (c *OperatorSchedulerSetCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler set-config [options]
+
+  Modifies the current scheduler configuration. The configuration is
+  read by the schedulers at the start of each evaluation. Options that
+  are not given keep their current value.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Set Config Options:
+
+  -scheduler-algorithm=[binpack|spread]
+     Specifies the algorithm used to score the fit of allocations on
+     nodes. "binpack" places allocations on as few nodes as possible,
+     while "spread" places them on the least utilized nodes.
+
+  -min-node-limit=<count>
+     The minimum number of feasible nodes the service and batch schedulers
+     score before picking the best one. Must be positive. Defaults to 2.
+
+  -max-skipped-nodes=<count>
+     The number of low scoring nodes, such as nodes already running the
+     job, the service and batch schedulers may pass over in search of a
+     better node. 0 disables skipping. Defaults to 3.
+
+  -service-job-anti-affinity-penalty=<score>
+     The penalty applied to the score of a node for each allocation of the
+     same service job it already runs. 0 disables the penalty. Defaults
+     to 20.
+
+  -batch-job-anti-affinity-penalty=<score>
+     The penalty applied to the score of a node for each allocation of the
+     same batch job it already runs. 0 disables the penalty. Defaults
+     to 10.
+
+  -max-service-schedule-attempts=<count>
+     The number of times the service scheduler attempts to schedule an
+     evaluation without making progress before giving up. Must be
+     positive. Defaults to 5.
+
+  -max-batch-schedule-attempts=<count>
+     The number of times the batch scheduler attempts to schedule an
+     evaluation without making progress before giving up. Must be
+     positive. Defaults to 2.
+
+  -disable-blocked-evals=[true|false]
+     Stops the schedulers from creating blocked evaluations for allocations
+     that can't be placed. The placements are then only retried the next
+     time the job is evaluated.
+
+  -memory-oversubscription=[true|false]
+     Allows tasks to set a "memory_max" limit above the memory reserved for
+     them. When disabled, "memory_max" is ignored and the reserved memory
+     is the hard limit of tasks.
+`
+	return strings.TrimSpace(helpText)
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorSchedulerSetConfigCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorSchedulerSetCommand{}
+}
+
+func TestOperatorSchedulerSetConfigCommand(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s, _, addr := testServer(t, false, nil)
+	defer s.Shutdown()
+
+	ui := new(cli.MockUi)
+	c := &OperatorSchedulerSetCommand{Meta: Meta{Ui: ui}}
+
+	// Unknown algorithms are rejected
+	code := c.Run([]string{"-address=" + addr, "-scheduler-algorithm=random"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "Invalid scheduler algorithm")
+
+	code = c.Run([]string{"-address=" + addr, "-scheduler-algorithm=spread"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Scheduler configuration updated")
+
+	client, err := c.Client()
+	require.Nil(err)
+	resp, _, err := client.Operator().SchedulerGetConfiguration(nil)
+	require.Nil(err)
+	require.Equal(api.SchedulerAlgorithmSpread, resp.SchedulerConfig.SchedulerAlgorithm)
+
+	// Tunables are set and the unset options are kept
+	ui.OutputWriter.Reset()
+	code = c.Run([]string{"-address=" + addr,
+		"-min-node-limit=4",
+		"-service-job-anti-affinity-penalty=12.5",
+		"-max-skipped-nodes=0",
+		"-disable-blocked-evals",
+		"-memory-oversubscription=true",
+	})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Scheduler configuration updated")
+
+	resp, _, err = client.Operator().SchedulerGetConfiguration(nil)
+	require.Nil(err)
+	conf := resp.SchedulerConfig
+	require.Equal(api.SchedulerAlgorithmSpread, conf.SchedulerAlgorithm)
+	require.Equal(4, *conf.MinNodeLimit)
+	require.Zero(*conf.MaxSkippedNodes)
+	require.Equal(12.5, *conf.ServiceJobAntiAffinityPenalty)
+	require.Equal(10.0, *conf.BatchJobAntiAffinityPenalty)
+	require.True(conf.DisableBlockedEvals)
+	require.True(conf.MemoryOversubscriptionEnabled)
+}
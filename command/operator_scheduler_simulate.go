@@ -0,0 +1,289 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
+	"github.com/posener/complete"
+)
+
+type OperatorSchedulerSimulateCommand struct {
+	Meta
+	JobGetter
+}
+
+func (c *OperatorSchedulerSimulateCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler simulate [options] [<path>...]
+
+  Simulates how the schedulers would react to hypothetical changes to the
+  cluster, such as draining, removing or adding nodes and registering the
+  jobs at the given paths. The schedulers are run against a copy of the
+  cluster state and nothing is committed.
+
+  The jobs given and the jobs whose allocations are affected by the changes
+  are listed along with the allocations that could not be placed, followed by
+  the utilization of the nodes after the simulation.
+
+  The exit code indicates the result of the simulation:
+    0: All allocations were placed.
+    1: An error occurred running the simulation.
+    2: Some allocations could not be placed.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Simulate Options:
+
+  -drain-node=<node-id>
+    Drains the node, migrating its allocations to other nodes. May be
+    given multiple times.
+
+  -remove-node=<node-id>
+    Removes the node from the cluster. Its allocations are treated as lost.
+    May be given multiple times.
+
+  -add-nodes=<class>:<count>
+    Adds count nodes of the node class. The nodes are copies of an existing
+    node of the class. May be given multiple times.
+
+  -clone-node=<node-id>:<count>
+    Adds count copies of the node. May be given multiple times.
+
+  -verbose
+    Display full information, including the metrics of failed placements.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSchedulerSimulateCommand) Synopsis() string {
+	return "Simulate the schedulers against hypothetical cluster changes"
+}
+
+func (c *OperatorSchedulerSimulateCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-drain-node":  nodePredictor(c.Meta),
+			"-remove-node": nodePredictor(c.Meta),
+			"-add-nodes":   complete.PredictAnything,
+			"-clone-node":  complete.PredictAnything,
+			"-verbose":     complete.PredictNothing,
+		})
+}
+
+func (c *OperatorSchedulerSimulateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(complete.PredictFiles("*.nomad"), complete.PredictFiles("*.hcl"))
+}
+
+// nodePredictor predicts the IDs of the nodes of the cluster.
+func nodePredictor(m Meta) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := m.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Nodes, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Nodes]
+	})
+}
+
+func (c *OperatorSchedulerSimulateCommand) Run(args []string) int {
+	var drainNodes, removeNodes, addNodes, cloneNodes flaghelper.StringFlag
+	var verbose bool
+
+	flags := c.Meta.FlagSet("scheduler simulate", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.Var(&drainNodes, "drain-node", "")
+	flags.Var(&removeNodes, "remove-node", "")
+	flags.Var(&addNodes, "add-nodes", "")
+	flags.Var(&cloneNodes, "clone-node", "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	req := &api.SchedulerSimulateRequest{
+		DrainNodeIDs:  drainNodes,
+		RemoveNodeIDs: removeNodes,
+	}
+	for _, spec := range addNodes {
+		class, count, err := parseSimulatedNodes(spec)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid -add-nodes value: %v", err))
+			return 1
+		}
+		req.AddNodes = append(req.AddNodes, &api.SimulatedNodes{Count: count, NodeClass: class})
+	}
+	for _, spec := range cloneNodes {
+		nodeID, count, err := parseSimulatedNodes(spec)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid -clone-node value: %v", err))
+			return 1
+		}
+		req.AddNodes = append(req.AddNodes, &api.SimulatedNodes{Count: count, TemplateNodeID: nodeID})
+	}
+
+	for _, path := range flags.Args() {
+		job, err := c.JobGetter.ApiJob(path)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting job struct: %s", err))
+			return 1
+		}
+		req.Jobs = append(req.Jobs, job)
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	resp, _, err := client.Operator().SchedulerSimulate(req, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error running simulation: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color("[bold]Jobs[reset]"))
+	c.Ui.Output(formatSimulatedJobs(resp.Jobs))
+
+	failed := false
+	for _, job := range resp.Jobs {
+		if len(job.FailedTGAllocs) == 0 {
+			continue
+		}
+		if !failed {
+			c.Ui.Output(c.Colorize().Color("\n[bold][yellow]Placement Failures[reset]"))
+			failed = true
+		}
+		for _, tg := range sortedTaskGroupFromMetrics(job.FailedTGAllocs) {
+			metrics := job.FailedTGAllocs[tg]
+			noun := "allocation"
+			if metrics.CoalescedFailures > 0 {
+				noun += "s"
+			}
+			out := fmt.Sprintf("[yellow]Job %q, Task Group %q (failed to place %d %s):\n[reset]",
+				job.JobID, tg, metrics.CoalescedFailures+1, noun)
+			if verbose {
+				out += fmt.Sprintf("[yellow]%s[reset]", formatAllocMetrics(metrics, false, strings.Repeat(" ", 2)))
+			}
+			c.Ui.Output(c.Colorize().Color(strings.TrimSuffix(out, "\n")))
+		}
+	}
+
+	c.Ui.Output(c.Colorize().Color("\n[bold]Node Utilization[reset]"))
+	c.Ui.Output(formatSimulatedNodes(resp.Nodes, length))
+
+	if failed {
+		return 2
+	}
+	return 0
+}
+
+// parseSimulatedNodes parses a value of the form "<name>:<count>".
+func parseSimulatedNodes(spec string) (string, int, error) {
+	idx := strings.LastIndex(spec, ":")
+	if idx <= 0 {
+		return "", 0, fmt.Errorf("%q must be of the form <name>:<count>", spec)
+	}
+	count, err := strconv.Atoi(spec[idx+1:])
+	if err != nil || count <= 0 {
+		return "", 0, fmt.Errorf("%q has an invalid count", spec)
+	}
+	return spec[:idx], count, nil
+}
+
+// formatSimulatedJobs formats the changes the schedulers made to the jobs of
+// a simulation.
+func formatSimulatedJobs(jobs []*api.SimulatedJobResult) string {
+	if len(jobs) == 0 {
+		return "No jobs affected"
+	}
+
+	out := make([]string, len(jobs)+1)
+	out[0] = "ID|Namespace|Place|Migrate|Stop|In-Place|Destructive|Failed"
+	for i, job := range jobs {
+		var total api.DesiredUpdates
+		for _, u := range job.DesiredTGUpdates {
+			total.Place += u.Place
+			total.Migrate += u.Migrate
+			total.Stop += u.Stop
+			total.InPlaceUpdate += u.InPlaceUpdate
+			total.DestructiveUpdate += u.DestructiveUpdate
+		}
+		failed := 0
+		for _, metrics := range job.FailedTGAllocs {
+			failed += metrics.CoalescedFailures + 1
+		}
+		out[i+1] = fmt.Sprintf("%s|%s|%d|%d|%d|%d|%d|%d",
+			job.JobID, job.Namespace, total.Place, total.Migrate, total.Stop,
+			total.InPlaceUpdate, total.DestructiveUpdate, failed)
+	}
+	return formatList(out)
+}
+
+// formatSimulatedNodes formats the utilization of the nodes of a simulation.
+func formatSimulatedNodes(nodes []*api.SimulatedNodeUtilization, length int) string {
+	if len(nodes) == 0 {
+		return "No nodes"
+	}
+
+	sorted := make([]*api.SimulatedNodeUtilization, len(nodes))
+	copy(sorted, nodes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	out := make([]string, len(sorted)+1)
+	out[0] = "ID|DC|Name|Class|Change|Allocs|CPU (MHz)|Memory (MiB)|Disk (MiB)"
+	for i, n := range sorted {
+		change := n.Change
+		if change == "" {
+			change = "<none>"
+		}
+		class := n.NodeClass
+		if class == "" {
+			class = "<none>"
+		}
+		out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s|%d|%s|%s|%s",
+			limit(n.ID, length), n.Datacenter, n.Name, class, change, n.Allocs,
+			formatSimulatedUsage(n.Used, n.Capacity, func(r *api.Resources) *int { return r.CPU }),
+			formatSimulatedUsage(n.Used, n.Capacity, func(r *api.Resources) *int { return r.MemoryMB }),
+			formatSimulatedUsage(n.Used, n.Capacity, func(r *api.Resources) *int { return r.DiskMB }))
+	}
+	return formatList(out)
+}
+
+// formatSimulatedUsage formats the used and available amount of a resource of
+// a node.
+func formatSimulatedUsage(used, capacity *api.Resources, get func(*api.Resources) *int) string {
+	var u, c int
+	if used != nil && get(used) != nil {
+		u = *get(used)
+	}
+	if capacity != nil && get(capacity) != nil {
+		c = *get(capacity)
+	}
+	if c == 0 {
+		return fmt.Sprintf("%d/%d", u, c)
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", u, c, u*100/c)
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperatorSchedulerSimulateCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorSchedulerSimulateCommand{}
+}
+
+func TestOperatorSchedulerSimulateCommand_Fails(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s, _, addr := testServer(t, false, nil)
+	defer s.Shutdown()
+
+	ui := new(cli.MockUi)
+	c := &OperatorSchedulerSimulateCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on invalid node groups
+	code := c.Run([]string{"-address=" + addr, "-add-nodes=large"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "Invalid -add-nodes value")
+	ui.ErrorWriter.Reset()
+
+	code = c.Run([]string{"-address=" + addr, "-clone-node=f7476465:0"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "invalid count")
+	ui.ErrorWriter.Reset()
+
+	// Fails on unknown nodes
+	code = c.Run([]string{"-address=" + addr, "-drain-node=f7476465"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "no node with prefix")
+	ui.ErrorWriter.Reset()
+
+	// Fails on missing job files
+	code = c.Run([]string{"-address=" + addr, "/unicorns/leprechauns"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "Error getting job struct")
+}
+
+func TestOperatorSchedulerSimulateCommand_Nodes(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s, client, addr := testServer(t, true, nil)
+	defer s.Shutdown()
+
+	// Wait for a node to appear
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		nodes, _, err := client.Nodes().List(nil)
+		if err != nil {
+			return false, err
+		}
+		if len(nodes) == 0 {
+			return false, fmt.Errorf("missing node")
+		}
+		nodeID = nodes[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	ui := new(cli.MockUi)
+	c := &OperatorSchedulerSimulateCommand{Meta: Meta{Ui: ui}}
+
+	code := c.Run([]string{"-address=" + addr, "-verbose",
+		"-clone-node=" + nodeID[:8] + ":2", "-drain-node=" + nodeID})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	out := ui.OutputWriter.String()
+	require.Contains(out, "No jobs affected")
+	require.Contains(out, nodeID)
+	require.Contains(out, "drained")
+
+	resp, _, err := client.Operator().SchedulerSimulate(&api.SchedulerSimulateRequest{
+		AddNodes: []*api.SimulatedNodes{{Count: 2, TemplateNodeID: nodeID}},
+	}, nil)
+	require.Nil(err)
+	require.Len(resp.Nodes, 3)
+
+	// Nothing was committed
+	nodes, _, err := client.Nodes().List(nil)
+	require.Nil(err)
+	require.Len(nodes, 1)
+	require.False(nodes[0].Drain)
+}
+
+func TestFormatSimulatedJobs(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	out := formatSimulatedJobs([]*api.SimulatedJobResult{
+		{
+			Namespace: "default",
+			JobID:     "example",
+			DesiredTGUpdates: map[string]*api.DesiredUpdates{
+				"cache": {Place: 1, Migrate: 2},
+				"web":   {Place: 2, Stop: 1},
+			},
+			FailedTGAllocs: map[string]*api.AllocationMetric{
+				"web": {CoalescedFailures: 1},
+			},
+		},
+	})
+	require.Contains(out, "example  default    3      2        1     0         0            2")
+
+	out = formatSimulatedNodes([]*api.SimulatedNodeUtilization{
+		{
+			ID:       "f7476465-4d6e-c0de-26d0-e383c49be941",
+			Name:     "node1",
+			Change:   "added",
+			Allocs:   1,
+			Capacity: &api.Resources{CPU: helper.IntToPtr(4000), MemoryMB: helper.IntToPtr(8192)},
+			Used:     &api.Resources{CPU: helper.IntToPtr(1000), MemoryMB: helper.IntToPtr(2048)},
+		},
+	}, shortId)
+	require.Contains(out, "f7476465")
+	require.Contains(out, "1000/4000 (25%)")
+	require.Contains(out, "2048/8192 (25%)")
+	require.Contains(out, "0/0")
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorSnapshotCommand struct {
+	Meta
+}
+
+func (c *OperatorSnapshotCommand) Help() string {
+	helpText := `
+Usage: nomad operator snapshot <subcommand> [options]
+
+  This command groups subcommands for saving and restoring the state of the
+  Nomad servers for disaster recovery. Snapshots are point-in-time archives of
+  the Raft state, holding the jobs, allocations, evaluations, deployments,
+  nodes and ACLs of the cluster.
+
+  Save a snapshot of the current state:
+
+      $ nomad operator snapshot save backup.snap
+
+  Inspect a snapshot:
+
+      $ nomad operator snapshot inspect backup.snap
+
+  Restore the state from a snapshot:
+
+      $ nomad operator snapshot restore backup.snap
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSnapshotCommand) Synopsis() string {
+	return "Saves and restores snapshots of the state of the cluster"
+}
+
+func (c *OperatorSnapshotCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
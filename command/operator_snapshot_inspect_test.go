@@ -0,0 +1,54 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperator_Snapshot_Inspect_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorSnapshotInspectCommand{}
+}
+
+func TestOperator_Snapshot_Inspect(t *testing.T) {
+	t.Parallel()
+	s, _, addr := testServer(t, false, nil)
+	defer s.Shutdown()
+
+	dir, err := ioutil.TempDir("", "nomad-snapshot")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "backup.snap")
+
+	ui := new(cli.MockUi)
+	save := &OperatorSnapshotSaveCommand{Meta: Meta{Ui: ui}}
+	if code := save.Run([]string{"-address=" + addr, file}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	ui = new(cli.MockUi)
+	c := &OperatorSnapshotInspectCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{file}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	for _, key := range []string{"ID", "Size", "Index", "Term", "Version"} {
+		if !strings.Contains(out, key) {
+			t.Fatalf("expected %q in output: %s", key, out)
+		}
+	}
+
+	// Fails on a missing file
+	ui = new(cli.MockUi)
+	c = &OperatorSnapshotInspectCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{filepath.Join(dir, "nope.snap")}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+}
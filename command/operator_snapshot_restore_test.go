@@ -0,0 +1,57 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperator_Snapshot_Restore_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorSnapshotRestoreCommand{}
+}
+
+func TestOperator_Snapshot_Restore(t *testing.T) {
+	t.Parallel()
+	s, _, addr := testServer(t, false, nil)
+	defer s.Shutdown()
+
+	dir, err := ioutil.TempDir("", "nomad-snapshot")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "backup.snap")
+
+	ui := new(cli.MockUi)
+	save := &OperatorSnapshotSaveCommand{Meta: Meta{Ui: ui}}
+	if code := save.Run([]string{"-address=" + addr, file}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	ui = new(cli.MockUi)
+	c := &OperatorSnapshotRestoreCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{"-address=" + addr, file}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Restored snapshot") {
+		t.Fatalf("bad: %s", out)
+	}
+
+	// A corrupted snapshot is rejected before being sent
+	if err := ioutil.WriteFile(file, []byte("corrupted"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ui = new(cli.MockUi)
+	c = &OperatorSnapshotRestoreCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{"-address=" + addr, file}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error verifying snapshot") {
+		t.Fatalf("bad: %s", out)
+	}
+}
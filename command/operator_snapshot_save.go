@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/snapshot"
+	"github.com/hashicorp/raft"
+	"github.com/posener/complete"
+)
+
+type OperatorSnapshotSaveCommand struct {
+	Meta
+}
+
+func (c *OperatorSnapshotSaveCommand) Help() string {
+	helpText := `
+Usage: nomad operator snapshot save [options] <file>
+
+  Retrieves an atomic, point-in-time snapshot of the state of the Nomad
+  servers and saves it to the given file. The snapshot is verified once
+  written. If ACLs are enabled, a management token is required.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Snapshot Save Options:
+
+  -stale
+    Allow the snapshot to be taken by any server instead of the leader. This
+    can be used to save a snapshot when the cluster has no leader, at the
+    risk of it not holding the latest changes.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSnapshotSaveCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-stale": complete.PredictNothing,
+		})
+}
+
+func (c *OperatorSnapshotSaveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *OperatorSnapshotSaveCommand) Synopsis() string {
+	return "Saves a snapshot of the state of the cluster"
+}
+
+func (c *OperatorSnapshotSaveCommand) Run(args []string) int {
+	var stale bool
+
+	flags := c.Meta.FlagSet("snapshot save", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stale, "stale", false, "")
+	if err := flags.Parse(args); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	file := args[0]
+
+	// Set up a client.
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Take the snapshot.
+	snap, err := client.Operator().SnapshotSave(&api.QueryOptions{AllowStale: stale})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error saving snapshot: %s", err))
+		return 1
+	}
+	defer snap.Close()
+
+	// Save the snapshot to a temporary file first so an existing file isn't
+	// replaced by a snapshot that fails the verification.
+	tmpFile := file + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating snapshot file: %s", err))
+		return 1
+	}
+	if _, err := io.Copy(f, snap); err != nil {
+		f.Close()
+		os.Remove(tmpFile)
+		c.Ui.Error(fmt.Sprintf("Error writing snapshot file: %s", err))
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		c.Ui.Error(fmt.Sprintf("Error closing snapshot file: %s", err))
+		return 1
+	}
+
+	// Verify the snapshot.
+	meta, err := verifySnapshotFile(tmpFile)
+	if err != nil {
+		os.Remove(tmpFile)
+		c.Ui.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
+		return 1
+	}
+
+	if err := os.Rename(tmpFile, file); err != nil {
+		os.Remove(tmpFile)
+		c.Ui.Error(fmt.Sprintf("Error renaming snapshot file: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Saved and verified snapshot to index %d", meta.Index))
+	return 0
+}
+
+// verifySnapshotFile verifies the integrity of a snapshot file and returns
+// its metadata.
+func verifySnapshotFile(file string) (*raft.SnapshotMeta, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return snapshot.Verify(f)
+}
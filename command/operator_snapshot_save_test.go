@@ -0,0 +1,69 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperator_Snapshot_Save_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorSnapshotSaveCommand{}
+}
+
+func TestOperator_Snapshot_Save(t *testing.T) {
+	t.Parallel()
+	s, _, addr := testServer(t, false, nil)
+	defer s.Shutdown()
+
+	dir, err := ioutil.TempDir("", "nomad-snapshot")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "backup.snap")
+
+	ui := new(cli.MockUi)
+	c := &OperatorSnapshotSaveCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{"-address=" + addr, file}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Saved and verified snapshot") {
+		t.Fatalf("bad: %s", out)
+	}
+
+	// The snapshot file is valid
+	if _, err := verifySnapshotFile(file); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file not removed: %v", err)
+	}
+}
+
+func TestOperator_Snapshot_Save_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	c := &OperatorSnapshotSaveCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := c.Run([]string{}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, c.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := c.Run([]string{"-address=nope", "backup.snap"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error saving snapshot") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
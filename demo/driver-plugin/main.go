@@ -0,0 +1,238 @@
+// nomad-driver-example is an example of an external task driver plugin. Its
+// tasks write a message to their stdout log and then run for a duration.
+//
+// Build the plugin into the plugin directory of a client to use it:
+//
+//	go build -o <plugin_dir>/nomad-driver-example ./demo/driver-plugin
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/nomad/client/driver"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/client/fingerprint"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/fields"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// exampleDriverAttr is the key populated in Node Attributes to indicate
+	// the presence of the driver.
+	exampleDriverAttr = "driver.example"
+)
+
+func main() {
+	driver.ServeDriverPlugin(NewExampleDriver)
+}
+
+// ExampleDriver is the driver served by the plugin.
+type ExampleDriver struct {
+	driver.DriverContext
+	fingerprint.StaticFingerprinter
+}
+
+// ExampleDriverConfig is the task configuration of the driver.
+type ExampleDriverConfig struct {
+	// Message is written to the task's stdout log.
+	Message string `mapstructure:"message"`
+
+	// Duration is how long the task runs for. The task runs until it is
+	// killed if no duration is given.
+	Duration string `mapstructure:"duration"`
+}
+
+// exampleHandleID is the handle ID of the tasks of the driver. It holds the
+// state of the task so that the task can be recovered by a new plugin
+// process.
+type exampleHandleID struct {
+	TaskName  string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// exampleHandle is the handle of a task of the driver.
+type exampleHandle struct {
+	id     exampleHandleID
+	logger *log.Logger
+	waitCh chan *dstructs.WaitResult
+
+	killCh   chan struct{}
+	killOnce sync.Once
+}
+
+// NewExampleDriver is used to create a new example driver
+func NewExampleDriver(ctx *driver.DriverContext) driver.Driver {
+	return &ExampleDriver{DriverContext: *ctx}
+}
+
+// Validate is used to validate the driver configuration
+func (d *ExampleDriver) Validate(config map[string]interface{}) error {
+	fd := &fields.FieldData{
+		Raw: config,
+		Schema: map[string]*fields.FieldSchema{
+			"message": {
+				Type:     fields.TypeString,
+				Required: true,
+			},
+			"duration": {
+				Type: fields.TypeString,
+			},
+		},
+	}
+
+	if err := fd.Validate(); err != nil {
+		return err
+	}
+
+	if raw, ok := config["duration"]; ok {
+		if _, err := time.ParseDuration(fmt.Sprintf("%v", raw)); err != nil {
+			return fmt.Errorf("invalid duration: %v", err)
+		}
+	}
+	return nil
+}
+
+func (d *ExampleDriver) Abilities() driver.DriverAbilities {
+	return driver.DriverAbilities{}
+}
+
+func (d *ExampleDriver) FSIsolation() cstructs.FSIsolation {
+	return cstructs.FSIsolationNone
+}
+
+func (d *ExampleDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	resp.AddAttribute(exampleDriverAttr, "1")
+	resp.Detected = true
+	return nil
+}
+
+func (d *ExampleDriver) Prestart(*driver.ExecContext, *structs.Task) (*driver.PrestartResponse, error) {
+	return nil, nil
+}
+
+func (d *ExampleDriver) Start(ctx *driver.ExecContext, task *structs.Task) (*driver.StartResponse, error) {
+	var driverConfig ExampleDriverConfig
+	if err := mapstructure.WeakDecode(task.Config, &driverConfig); err != nil {
+		return nil, err
+	}
+
+	id := exampleHandleID{
+		TaskName:  task.Name,
+		StartedAt: time.Now(),
+	}
+	if driverConfig.Duration != "" {
+		dur, err := time.ParseDuration(driverConfig.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %v", err)
+		}
+		id.Duration = dur
+	}
+
+	// Write the message to the task's stdout log
+	prefix, _ := d.PluginConfig()["prefix"].(string)
+	stdout := filepath.Join(ctx.TaskDir.LogDir, fmt.Sprintf("%s.stdout.0", task.Name))
+	f, err := os.OpenFile(stdout, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout log: %v", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s%s\n", prefix, driverConfig.Message); err != nil {
+		return nil, fmt.Errorf("failed to write message: %v", err)
+	}
+
+	d.Logger().Printf("[INFO] driver.example: started task %q", task.Name)
+	return &driver.StartResponse{Handle: d.newHandle(id)}, nil
+}
+
+func (d *ExampleDriver) Open(ctx *driver.ExecContext, handleID string) (driver.DriverHandle, error) {
+	var id exampleHandleID
+	if err := json.Unmarshal([]byte(handleID), &id); err != nil {
+		return nil, fmt.Errorf("Failed to parse handle %q: %v", handleID, err)
+	}
+
+	d.Logger().Printf("[INFO] driver.example: recovered task %q", id.TaskName)
+	return d.newHandle(id), nil
+}
+
+func (d *ExampleDriver) Cleanup(*driver.ExecContext, *driver.CreatedResources) error {
+	return nil
+}
+
+func (d *ExampleDriver) newHandle(id exampleHandleID) *exampleHandle {
+	h := &exampleHandle{
+		id:     id,
+		logger: d.Logger(),
+		waitCh: make(chan *dstructs.WaitResult, 1),
+		killCh: make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+func (h *exampleHandle) ID() string {
+	data, err := json.Marshal(h.id)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.example: failed to marshal ID to JSON: %s", err)
+	}
+	return string(data)
+}
+
+func (h *exampleHandle) WaitCh() chan *dstructs.WaitResult {
+	return h.waitCh
+}
+
+func (h *exampleHandle) Update(task *structs.Task) error {
+	return nil
+}
+
+func (h *exampleHandle) Kill() error {
+	h.killOnce.Do(func() { close(h.killCh) })
+	return nil
+}
+
+func (h *exampleHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return &cstructs.TaskResourceUsage{
+		ResourceUsage: &cstructs.ResourceUsage{
+			MemoryStats: &cstructs.MemoryStats{},
+			CpuStats:    &cstructs.CpuStats{},
+		},
+		Timestamp: time.Now().UTC().UnixNano(),
+	}, nil
+}
+
+func (h *exampleHandle) Signal(s os.Signal) error {
+	return nil
+}
+
+func (h *exampleHandle) Exec(ctx context.Context, cmd string, args []string) ([]byte, int, error) {
+	return nil, 0, fmt.Errorf("exec not supported by the example driver")
+}
+
+func (h *exampleHandle) run() {
+	var timeout <-chan time.Time
+	if h.id.Duration > 0 {
+		remaining := h.id.Duration - time.Since(h.id.StartedAt)
+		timeout = time.After(remaining)
+	}
+
+	res := dstructs.NewWaitResult(0, 0, nil)
+	select {
+	case <-timeout:
+	case <-h.killCh:
+		res = dstructs.NewWaitResult(0, int(syscall.SIGTERM), nil)
+	}
+
+	h.waitCh <- res
+	close(h.waitCh)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/client/driver/env"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/testlog"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// servePluginEnv is set in the environment of the plugin processes launched
+// by the tests so that the test binary serves the plugin.
+const servePluginEnv = "NOMAD_TEST_SERVE_EXAMPLE_DRIVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(servePluginEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+
+	os.Setenv(servePluginEnv, "1")
+	os.Exit(m.Run())
+}
+
+// testPluginDir returns a plugin directory containing the test binary as the
+// example driver plugin.
+func testPluginDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "nomad-plugins")
+	require.NoError(t, err)
+
+	bin, err := os.Executable()
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink(bin, filepath.Join(dir, driver.DriverPluginPrefix+"example")))
+	return dir
+}
+
+type testContext struct {
+	config  *config.Config
+	node    *structs.Node
+	alloc   *structs.Allocation
+	task    *structs.Task
+	execCtx *driver.ExecContext
+	logger  *log.Logger
+	plugins *driver.DriverPluginManager
+}
+
+func (c *testContext) newDriver(t *testing.T) driver.Driver {
+	dctx := driver.NewDriverContext(c.task.Name, c.alloc.ID, c.config, c.node, c.logger, nil, c.plugins)
+	d, err := driver.NewDriver("example", dctx)
+	require.NoError(t, err)
+	return d
+}
+
+func newTestContext(t *testing.T, pluginDir string, task *structs.Task) (*testContext, func()) {
+	plugins, err := driver.DiscoverDriverPlugins(pluginDir, map[string]map[string]interface{}{
+		"example": {"prefix": "example: "},
+	})
+	require.NoError(t, err)
+	require.Contains(t, plugins, "example")
+
+	tmp, err := ioutil.TempDir("", "nomad-example")
+	require.NoError(t, err)
+
+	logger := testlog.Logger(t)
+	conf := config.DefaultConfig()
+	conf.Node = mock.Node()
+	conf.StateDir = filepath.Join(tmp, "state")
+	conf.AllocDir = filepath.Join(tmp, "alloc")
+	conf.DriverPlugins = plugins
+
+	alloc := mock.Alloc()
+	allocDir := allocdir.NewAllocDir(logger, filepath.Join(conf.AllocDir, alloc.ID))
+	require.NoError(t, allocDir.Build())
+	taskDir := allocDir.NewTaskDir(task.Name)
+	require.NoError(t, taskDir.Build(false, nil, cstructs.FSIsolationNone))
+
+	c := &testContext{
+		config:  conf,
+		node:    conf.Node,
+		alloc:   alloc,
+		task:    task,
+		execCtx: driver.NewExecContext(taskDir, env.NewTaskEnv(nil, nil)),
+		logger:  logger,
+		plugins: driver.NewDriverPluginManager(),
+	}
+	cleanup := func() {
+		c.plugins.Shutdown()
+		os.RemoveAll(tmp)
+	}
+	return c, cleanup
+}
+
+func testTask(duration string) *structs.Task {
+	return &structs.Task{
+		Name:   "web",
+		Driver: "example",
+		Config: map[string]interface{}{
+			"message":  "hello world",
+			"duration": duration,
+		},
+		LogConfig: structs.DefaultLogConfig(),
+		Resources: structs.DefaultResources(),
+	}
+}
+
+func TestExampleDriver_Fingerprint(t *testing.T) {
+	pluginDir := testPluginDir(t)
+	defer os.RemoveAll(pluginDir)
+	ctx, cleanup := newTestContext(t, pluginDir, testTask("1s"))
+	defer cleanup()
+
+	d := ctx.newDriver(t)
+	req := &cstructs.FingerprintRequest{Config: ctx.config, Node: ctx.node}
+	var resp cstructs.FingerprintResponse
+	require.NoError(t, d.Fingerprint(req, &resp))
+	require.True(t, resp.Detected)
+	require.Equal(t, "1", resp.Attributes["driver.example"])
+
+	require.NoError(t, d.Validate(ctx.task.Config))
+	require.Error(t, d.Validate(map[string]interface{}{"duration": "1s"}))
+	require.Error(t, d.Validate(map[string]interface{}{"message": "hi", "duration": "soon"}))
+}
+
+func TestExampleDriver_StartWait(t *testing.T) {
+	pluginDir := testPluginDir(t)
+	defer os.RemoveAll(pluginDir)
+	ctx, cleanup := newTestContext(t, pluginDir, testTask("100ms"))
+	defer cleanup()
+
+	d := ctx.newDriver(t)
+	resp, err := d.Start(ctx.execCtx, ctx.task)
+	require.NoError(t, err)
+
+	select {
+	case res := <-resp.Handle.WaitCh():
+		require.True(t, res.Successful(), "task failed: %v", res)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for task to exit")
+	}
+
+	stdout := filepath.Join(ctx.execCtx.TaskDir.LogDir, "web.stdout.0")
+	out, err := ioutil.ReadFile(stdout)
+	require.NoError(t, err)
+	require.Equal(t, "example: hello world\n", string(out))
+}
+
+func TestExampleDriver_Reattach(t *testing.T) {
+	pluginDir := testPluginDir(t)
+	defer os.RemoveAll(pluginDir)
+	ctx, cleanup := newTestContext(t, pluginDir, testTask(""))
+	defer cleanup()
+
+	resp, err := ctx.newDriver(t).Start(ctx.execCtx, ctx.task)
+	require.NoError(t, err)
+	handleID := resp.Handle.ID()
+
+	// Reopen the task with a new driver as a restarted client would
+	h, err := ctx.newDriver(t).Open(ctx.execCtx, handleID)
+	require.NoError(t, err)
+	require.Equal(t, handleID, h.ID())
+
+	// Kill the task's plugin process; the task is recovered by a new one
+	var id struct {
+		Reattach struct {
+			Pid int
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(handleID), &id))
+	proc, err := os.FindProcess(id.Reattach.Pid)
+	require.NoError(t, err)
+	require.NoError(t, proc.Kill())
+
+	select {
+	case res := <-h.WaitCh():
+		require.Error(t, res.Err)
+		require.True(t, strings.Contains(res.Err.Error(), "driver plugin"), "unexpected error: %v", res.Err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for lost plugin")
+	}
+
+	h, err = ctx.newDriver(t).Open(ctx.execCtx, handleID)
+	require.NoError(t, err)
+	require.NotEqual(t, handleID, h.ID())
+
+	require.NoError(t, h.Kill())
+	select {
+	case res := <-h.WaitCh():
+		require.NoError(t, res.Err)
+		require.NotZero(t, res.Signal)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for task to be killed")
+	}
+}
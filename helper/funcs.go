@@ -62,6 +62,11 @@ func Uint64ToPtr(u uint64) *uint64 {
 	return &u
 }
 
+// Float64ToPtr returns the pointer to a float64
+func Float64ToPtr(f float64) *float64 {
+	return &f
+}
+
 // StringToPtr returns the pointer to a string
 func StringToPtr(str string) *string {
 	return &str
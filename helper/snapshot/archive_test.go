@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/raft"
+)
+
+func TestArchive(t *testing.T) {
+	// Create some fake snapshot data.
+	metadata := raft.SnapshotMeta{
+		Index: 2005,
+		Term:  2011,
+		Configuration: raft.Configuration{
+			Servers: []raft.Server{
+				{
+					Suffrage: raft.Voter,
+					ID:       raft.ServerID("hello"),
+					Address:  raft.ServerAddress("127.0.0.1:8300"),
+				},
+			},
+		},
+		Size: 1024,
+	}
+	var snap bytes.Buffer
+	var expected bytes.Buffer
+	both := io.MultiWriter(&snap, &expected)
+	if _, err := io.Copy(both, io.LimitReader(rand.Reader, 1024)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Write out the snapshot.
+	var archive bytes.Buffer
+	if err := write(&archive, &metadata, &snap); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Read the snapshot back.
+	var newMeta raft.SnapshotMeta
+	var newSnap bytes.Buffer
+	if err := read(&archive, &newMeta, &newSnap); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check the contents.
+	if !reflect.DeepEqual(newMeta, metadata) {
+		t.Fatalf("bad: %#v", newMeta)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, &newSnap); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected.Bytes()) {
+		t.Fatalf("snapshot contents didn't match")
+	}
+}
+
+func TestArchive_BadData(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Files map[string]string
+		Error string
+	}{
+		{
+			Name:  "missing sums",
+			Files: map[string]string{metaFile: "{}", stateFile: "data"},
+			Error: "missing its SHA256SUMS file",
+		},
+		{
+			Name: "bad sum",
+			Files: map[string]string{
+				metaFile:  "{}",
+				stateFile: "data",
+				sumsFile:  fmt.Sprintf("%x  %s\n%x  %s\n", make([]byte, 32), metaFile, make([]byte, 32), stateFile),
+			},
+			Error: "hash check failed",
+		},
+		{
+			Name:  "unexpected file",
+			Files: map[string]string{"nope.txt": "hello"},
+			Error: "unexpected file",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			var archive bytes.Buffer
+			tw := tar.NewWriter(&archive)
+			for name, contents := range c.Files {
+				if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(contents))}); err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if _, err := tw.Write([]byte(contents)); err != nil {
+					t.Fatalf("err: %v", err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			var metadata raft.SnapshotMeta
+			err := read(&archive, &metadata, &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), c.Error) {
+				t.Fatalf("expected error %q, got %v", c.Error, err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	metadata := raft.SnapshotMeta{Index: 10, Term: 2, Size: 4}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if err := write(gz, &metadata, strings.NewReader("data")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	verified, err := Verify(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if verified.Index != 10 || verified.Term != 2 || verified.Size != 4 {
+		t.Fatalf("bad: %#v", verified)
+	}
+
+	// A truncated archive fails the verification
+	if _, err := Verify(bytes.NewReader(compressed.Bytes()[:compressed.Len()/2])); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
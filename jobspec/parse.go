@@ -102,12 +102,14 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 	if err := hcl.DecodeObject(&m, obj.Val); err != nil {
 		return err
 	}
+	delete(m, "affinity")
 	delete(m, "constraint")
 	delete(m, "meta")
 	delete(m, "migrate")
 	delete(m, "parameterized")
 	delete(m, "periodic")
 	delete(m, "reschedule")
+	delete(m, "spread")
 	delete(m, "update")
 	delete(m, "vault")
 
@@ -131,6 +133,7 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 	// Check for invalid keys
 	valid := []string{
 		"all_at_once",
+		"affinity",
 		"constraint",
 		"datacenters",
 		"group",
@@ -144,6 +147,7 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 		"priority",
 		"region",
 		"reschedule",
+		"spread",
 		"task",
 		"type",
 		"update",
@@ -161,6 +165,20 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse affinities
+	if o := listVal.Filter("affinity"); len(o.Items) > 0 {
+		if err := parseAffinities(&result.Affinities, o); err != nil {
+			return multierror.Prefix(err, "affinity ->")
+		}
+	}
+
+	// Parse spread
+	if o := listVal.Filter("spread"); len(o.Items) > 0 {
+		if err := parseSpread(&result.Spreads, o); err != nil {
+			return multierror.Prefix(err, "spread ->")
+		}
+	}
+
 	// If we have an update strategy, then parse that
 	if o := listVal.Filter("update"); len(o.Items) > 0 {
 		if err := parseUpdate(&result.Update, o); err != nil {
@@ -287,6 +305,7 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 		valid := []string{
 			"count",
 			"constraint",
+			"affinity",
 			"restart",
 			"meta",
 			"task",
@@ -295,6 +314,9 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 			"reschedule",
 			"vault",
 			"migrate",
+			"spread",
+			"volume",
+			"network",
 		}
 		if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
@@ -305,6 +327,7 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 			return err
 		}
 		delete(m, "constraint")
+		delete(m, "affinity")
 		delete(m, "meta")
 		delete(m, "task")
 		delete(m, "restart")
@@ -312,6 +335,9 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 		delete(m, "update")
 		delete(m, "vault")
 		delete(m, "migrate")
+		delete(m, "spread")
+		delete(m, "volume")
+		delete(m, "network")
 
 		// Build the group with the basic decode
 		var g api.TaskGroup
@@ -327,6 +353,20 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse affinities
+		if o := listVal.Filter("affinity"); len(o.Items) > 0 {
+			if err := parseAffinities(&g.Affinities, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', affinity ->", n))
+			}
+		}
+
+		// Parse spread
+		if o := listVal.Filter("spread"); len(o.Items) > 0 {
+			if err := parseSpread(&g.Spreads, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', spread ->", n))
+			}
+		}
+
 		// Parse restart policy
 		if o := listVal.Filter("restart"); len(o.Items) > 0 {
 			if err := parseRestartPolicy(&g.RestartPolicy, o); err != nil {
@@ -362,6 +402,22 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse volumes
+		if o := listVal.Filter("volume"); len(o.Items) > 0 {
+			if err := parseVolumes(&g.Volumes, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', volume ->", n))
+			}
+		}
+
+		// Parse the network shared by the tasks
+		if o := listVal.Filter("network"); len(o.Items) > 0 {
+			r, err := parseNetwork(o, true)
+			if err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', network ->", n))
+			}
+			g.Networks = []*api.NetworkResource{r}
+		}
+
 		// Parse out meta fields. These are in HCL as a list so we need
 		// to iterate over them and merge them.
 		if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -576,6 +632,160 @@ func parseConstraints(result *[]*api.Constraint, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseAffinities(result *[]*api.Affinity, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"attribute",
+			"operator",
+			"regexp",
+			"set_contains",
+			"value",
+			"version",
+			"weight",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		m["LTarget"] = m["attribute"]
+		m["RTarget"] = m["value"]
+		m["Operand"] = m["operator"]
+
+		// If "version" is provided, set the operand
+		// to "version" and the value to the "RTarget"
+		if affinity, ok := m[structs.ConstraintVersion]; ok {
+			m["Operand"] = structs.ConstraintVersion
+			m["RTarget"] = affinity
+		}
+
+		// If "regexp" is provided, set the operand
+		// to "regexp" and the value to the "RTarget"
+		if affinity, ok := m[structs.ConstraintRegex]; ok {
+			m["Operand"] = structs.ConstraintRegex
+			m["RTarget"] = affinity
+		}
+
+		// If "set_contains" is provided, set the operand
+		// to "set_contains" and the value to the "RTarget"
+		if affinity, ok := m[structs.ConstraintSetContains]; ok {
+			m["Operand"] = structs.ConstraintSetContains
+			m["RTarget"] = affinity
+		}
+
+		// Build the affinity
+		var a api.Affinity
+		if err := mapstructure.WeakDecode(m, &a); err != nil {
+			return err
+		}
+		if a.Operand == "" {
+			a.Operand = "="
+		}
+
+		*result = append(*result, &a)
+	}
+
+	return nil
+}
+
+func parseSpread(result *[]*api.Spread, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"attribute",
+			"weight",
+			"target",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		// We need this later
+		var listVal *ast.ObjectList
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("spread should be an object")
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "target")
+
+		// Build spread
+		var s api.Spread
+		if err := mapstructure.WeakDecode(m, &s); err != nil {
+			return err
+		}
+
+		// Parse spread targets
+		if o := listVal.Filter("target"); len(o.Items) > 0 {
+			if err := parseSpreadTarget(&s.SpreadTarget, o); err != nil {
+				return multierror.Prefix(err, "target ->")
+			}
+		}
+
+		*result = append(*result, &s)
+	}
+
+	return nil
+}
+
+func parseSpreadTarget(result *[]*api.SpreadTarget, list *ast.ObjectList) error {
+	seen := make(map[string]struct{})
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return fmt.Errorf("target should have exactly one value")
+		}
+		n := item.Keys[0].Token.Value().(string)
+
+		// Make sure we haven't already found this
+		if _, ok := seen[n]; ok {
+			return fmt.Errorf("target '%s' defined more than once", n)
+		}
+		seen[n] = struct{}{}
+
+		// We need this later
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("target '%s': should be an object", n)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"percent",
+		}
+		if err := helper.CheckHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		// Build the spread target
+		var target api.SpreadTarget
+		target.Value = n
+		if err := mapstructure.WeakDecode(m, &target); err != nil {
+			return err
+		}
+
+		*result = append(*result, &target)
+	}
+
+	return nil
+}
+
 func parseEphemeralDisk(result **api.EphemeralDisk, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -654,6 +864,7 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 		// Check for invalid keys
 		valid := []string{
 			"artifact",
+			"affinity",
 			"config",
 			"constraint",
 			"dispatch_payload",
@@ -661,6 +872,7 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			"env",
 			"kill_timeout",
 			"leader",
+			"lifecycle",
 			"logs",
 			"meta",
 			"resources",
@@ -670,6 +882,7 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			"user",
 			"vault",
 			"kill_signal",
+			"volume_mount",
 		}
 		if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
@@ -680,16 +893,19 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			return err
 		}
 		delete(m, "artifact")
+		delete(m, "affinity")
 		delete(m, "config")
 		delete(m, "constraint")
 		delete(m, "dispatch_payload")
 		delete(m, "env")
+		delete(m, "lifecycle")
 		delete(m, "logs")
 		delete(m, "meta")
 		delete(m, "resources")
 		delete(m, "service")
 		delete(m, "template")
 		delete(m, "vault")
+		delete(m, "volume_mount")
 
 		// Build the task
 		var t api.Task
@@ -751,6 +967,14 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			}
 		}
 
+		// Parse affinities
+		if o := listVal.Filter("affinity"); len(o.Items) > 0 {
+			if err := parseAffinities(&t.Affinities, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf(
+					"'%s', affinity ->", n))
+			}
+		}
+
 		// Parse out meta fields. These are in HCL as a list so we need
 		// to iterate over them and merge them.
 		if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -787,6 +1011,10 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			valid := []string{
 				"max_files",
 				"max_file_size",
+				"rotation_interval",
+				"compress",
+				"max_total_size",
+				"shipper",
 			}
 			if err := helper.CheckHCLKeys(logsBlock.Val, valid); err != nil {
 				return multierror.Prefix(err, fmt.Sprintf("'%s', logs ->", n))
@@ -795,12 +1023,30 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			if err := hcl.DecodeObject(&m, logsBlock.Val); err != nil {
 				return err
 			}
+			delete(m, "shipper")
 
 			var log api.LogConfig
-			if err := mapstructure.WeakDecode(m, &log); err != nil {
+			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+				WeaklyTypedInput: true,
+				Result:           &log,
+			})
+			if err != nil {
+				return err
+			}
+			if err := dec.Decode(m); err != nil {
 				return err
 			}
 
+			// Parse the log shippers
+			if ot, ok := logsBlock.Val.(*ast.ObjectType); ok {
+				if o := ot.List.Filter("shipper"); len(o.Items) > 0 {
+					if err := parseLogShippers(&log.Shippers, o); err != nil {
+						return multierror.Prefix(err, fmt.Sprintf("'%s', logs, shipper ->", n))
+					}
+				}
+			}
+
 			t.LogConfig = &log
 		}
 
@@ -858,12 +1104,110 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			}
 		}
 
+		// If we have a lifecycle block parse that
+		if o := listVal.Filter("lifecycle"); len(o.Items) > 0 {
+			if len(o.Items) > 1 {
+				return fmt.Errorf("only one lifecycle block is allowed in a task. Number of lifecycle blocks found: %d", len(o.Items))
+			}
+			var m map[string]interface{}
+			lifecycleBlock := o.Items[0]
+
+			// Check for invalid keys
+			valid := []string{
+				"hook",
+				"sidecar",
+			}
+			if err := helper.CheckHCLKeys(lifecycleBlock.Val, valid); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', lifecycle ->", n))
+			}
+
+			if err := hcl.DecodeObject(&m, lifecycleBlock.Val); err != nil {
+				return err
+			}
+
+			t.Lifecycle = &api.TaskLifecycle{}
+			if err := mapstructure.WeakDecode(m, t.Lifecycle); err != nil {
+				return err
+			}
+		}
+
+		// Parse volume mounts
+		if o := listVal.Filter("volume_mount"); len(o.Items) > 0 {
+			if err := parseVolumeMounts(&t.VolumeMounts, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', volume_mount ->", n))
+			}
+		}
+
 		*result = append(*result, &t)
 	}
 
 	return nil
 }
 
+func parseVolumes(result *map[string]*api.VolumeRequest, list *ast.ObjectList) error {
+	list = list.Children()
+	volumes := make(map[string]*api.VolumeRequest, len(list.Items))
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		// Make sure we haven't already found this
+		if _, ok := volumes[n]; ok {
+			return fmt.Errorf("volume '%s' defined more than once", n)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"type",
+			"source",
+			"read_only",
+		}
+		if err := helper.CheckHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		v := &api.VolumeRequest{Name: n}
+		if err := mapstructure.WeakDecode(m, v); err != nil {
+			return err
+		}
+		volumes[n] = v
+	}
+
+	*result = volumes
+	return nil
+}
+
+func parseVolumeMounts(result *[]*api.VolumeMount, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"volume",
+			"destination",
+			"read_only",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var v api.VolumeMount
+		if err := mapstructure.WeakDecode(m, &v); err != nil {
+			return err
+		}
+		*result = append(*result, &v)
+	}
+
+	return nil
+}
+
 func parseArtifacts(result *[]*api.TaskArtifact, list *ast.ObjectList) error {
 	for _, o := range list.Elem().Items {
 		// Check for invalid keys
@@ -931,6 +1275,44 @@ func parseArtifactOption(result map[string]string, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseLogShippers(result *[]*api.LogShipper, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"type",
+			"address",
+			"buffer_size",
+			"batch_size",
+			"flush_interval",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var shipper api.LogShipper
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           &shipper,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+
+		*result = append(*result, &shipper)
+	}
+
+	return nil
+}
+
 func parseTemplates(result *[]*api.Template, list *ast.ObjectList) error {
 	for _, o := range list.Elem().Items {
 		// Check for invalid keys
@@ -1199,7 +1581,10 @@ func parseResources(result *api.Resources, list *ast.ObjectList) error {
 		"iops",
 		"disk",
 		"memory",
+		"memory_max",
+		"cores",
 		"network",
+		"device",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return multierror.Prefix(err, "resources ->")
@@ -1210,6 +1595,7 @@ func parseResources(result *api.Resources, list *ast.ObjectList) error {
 		return err
 	}
 	delete(m, "network")
+	delete(m, "device")
 
 	if err := mapstructure.WeakDecode(m, result); err != nil {
 		return err
@@ -1217,54 +1603,102 @@ func parseResources(result *api.Resources, list *ast.ObjectList) error {
 
 	// Parse the network resources
 	if o := listVal.Filter("network"); len(o.Items) > 0 {
-		if len(o.Items) > 1 {
-			return fmt.Errorf("only one 'network' resource allowed")
+		r, err := parseNetwork(o, false)
+		if err != nil {
+			return multierror.Prefix(err, "resources, network ->")
+		}
+		result.Networks = []*api.NetworkResource{r}
+	}
+
+	// Parse the device resources
+	if o := listVal.Filter("device"); len(o.Items) > 0 {
+		if err := parseDevices(result, o); err != nil {
+			return multierror.Prefix(err, "resources, device ->")
+		}
+	}
+
+	return nil
+}
+
+func parseDevices(result *api.Resources, list *ast.ObjectList) error {
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			return fmt.Errorf("devices must be named")
+		}
+		id := item.Keys[0].Token.Value().(string)
+		dev, err := structs.ParseDeviceID(id)
+		if err != nil {
+			return err
 		}
 
 		// Check for invalid keys
 		valid := []string{
-			"mbits",
-			"port",
+			"count",
 		}
-		if err := helper.CheckHCLKeys(o.Items[0].Val, valid); err != nil {
-			return multierror.Prefix(err, "resources, network ->")
+		if err := helper.CheckHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%q ->", id))
 		}
 
-		var r api.NetworkResource
 		var m map[string]interface{}
-		if err := hcl.DecodeObject(&m, o.Items[0].Val); err != nil {
-			return err
-		}
-		if err := mapstructure.WeakDecode(m, &r); err != nil {
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
 			return err
 		}
 
-		var networkObj *ast.ObjectList
-		if ot, ok := o.Items[0].Val.(*ast.ObjectType); ok {
-			networkObj = ot.List
-		} else {
-			return fmt.Errorf("resource: should be an object")
+		r := api.DeviceResource{
+			Vendor: dev.Vendor,
+			Type:   dev.Type,
+			Name:   dev.Name,
 		}
-		if err := parsePorts(networkObj, &r); err != nil {
-			return multierror.Prefix(err, "resources, network, ports ->")
+		if err := mapstructure.WeakDecode(m, &r); err != nil {
+			return err
 		}
-
-		result.Networks = []*api.NetworkResource{&r}
+		result.Devices = append(result.Devices, &r)
 	}
-
 	return nil
 }
 
-func parsePorts(networkObj *ast.ObjectList, nw *api.NetworkResource) error {
+// parseNetwork parses a network stanza. The networks of task groups have a
+// mode and may map their ports.
+func parseNetwork(o *ast.ObjectList, group bool) (*api.NetworkResource, error) {
+	if len(o.Items) > 1 {
+		return nil, fmt.Errorf("only one 'network' resource allowed")
+	}
+
 	// Check for invalid keys
 	valid := []string{
 		"mbits",
 		"port",
 	}
-	if err := helper.CheckHCLKeys(networkObj, valid); err != nil {
-		return err
+	if group {
+		valid = append(valid, "mode")
+	}
+	if err := helper.CheckHCLKeys(o.Items[0].Val, valid); err != nil {
+		return nil, err
 	}
 
+	var r api.NetworkResource
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Items[0].Val); err != nil {
+		return nil, err
+	}
+	delete(m, "port")
+	if err := mapstructure.WeakDecode(m, &r); err != nil {
+		return nil, err
+	}
+
+	var networkObj *ast.ObjectList
+	if ot, ok := o.Items[0].Val.(*ast.ObjectType); ok {
+		networkObj = ot.List
+	} else {
+		return nil, fmt.Errorf("should be an object")
+	}
+	if err := parsePorts(networkObj, &r, group); err != nil {
+		return nil, multierror.Prefix(err, "ports ->")
+	}
+	return &r, nil
+}
+
+func parsePorts(networkObj *ast.ObjectList, nw *api.NetworkResource, mapping bool) error {
 	portsObjList := networkObj.Filter("port")
 	knownPortLabels := make(map[string]bool)
 	for _, port := range portsObjList.Items {
@@ -1284,6 +1718,9 @@ func parsePorts(networkObj *ast.ObjectList, nw *api.NetworkResource) error {
 		if err := hcl.DecodeObject(&p, port.Val); err != nil {
 			return err
 		}
+		if _, ok := p["to"]; ok && !mapping {
+			return fmt.Errorf("port %q: only the ports of group networks can be mapped", label)
+		}
 		if err := mapstructure.WeakDecode(p, &res); err != nil {
 			return err
 		}
@@ -1320,7 +1757,9 @@ func parseUpdate(result **api.UpdateStrategy, list *ast.ObjectList) error {
 		"health_check",
 		"min_healthy_time",
 		"healthy_deadline",
+		"progress_deadline",
 		"auto_revert",
+		"auto_promote",
 		"canary",
 	}
 	if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
@@ -1392,7 +1831,10 @@ func parsePeriodic(result **api.PeriodicConfig, list *ast.ObjectList) error {
 	valid := []string{
 		"enabled",
 		"cron",
+		"crons",
 		"prohibit_overlap",
+		"catchup",
+		"catchup_window",
 		"time_zone",
 	}
 	if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
@@ -1413,9 +1855,23 @@ func parsePeriodic(result **api.PeriodicConfig, list *ast.ObjectList) error {
 		m["Spec"] = cron
 	}
 
+	// If "crons" is provided, set the type to "cron" and store the specs.
+	if crons, ok := m["crons"]; ok {
+		m["SpecType"] = structs.PeriodicSpecCron
+		m["Specs"] = crons
+	}
+
 	// Build the constraint
 	var p api.PeriodicConfig
-	if err := mapstructure.WeakDecode(m, &p); err != nil {
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &p,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
 		return err
 	}
 	*result = &p
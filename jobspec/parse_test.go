@@ -103,12 +103,14 @@ func TestParse(t *testing.T) {
 							SizeMB: helper.IntToPtr(150),
 						},
 						Update: &api.UpdateStrategy{
-							MaxParallel:     helper.IntToPtr(3),
-							HealthCheck:     helper.StringToPtr("checks"),
-							MinHealthyTime:  helper.TimeToPtr(1 * time.Second),
-							HealthyDeadline: helper.TimeToPtr(1 * time.Minute),
-							AutoRevert:      helper.BoolToPtr(false),
-							Canary:          helper.IntToPtr(2),
+							MaxParallel:      helper.IntToPtr(3),
+							HealthCheck:      helper.StringToPtr("checks"),
+							MinHealthyTime:   helper.TimeToPtr(1 * time.Second),
+							HealthyDeadline:  helper.TimeToPtr(1 * time.Minute),
+							ProgressDeadline: helper.TimeToPtr(5 * time.Minute),
+							AutoRevert:       helper.BoolToPtr(false),
+							AutoPromote:      helper.BoolToPtr(true),
+							Canary:           helper.IntToPtr(2),
 						},
 						Migrate: &api.MigrateStrategy{
 							MaxParallel:     helper.IntToPtr(2),
@@ -223,9 +225,11 @@ func TestParse(t *testing.T) {
 									"image": "hashicorp/storagelocker",
 								},
 								Resources: &api.Resources{
-									CPU:      helper.IntToPtr(500),
-									MemoryMB: helper.IntToPtr(128),
-									IOPS:     helper.IntToPtr(30),
+									CPU:         helper.IntToPtr(500),
+									MemoryMB:    helper.IntToPtr(128),
+									MemoryMaxMB: helper.IntToPtr(256),
+									IOPS:        helper.IntToPtr(30),
+									Cores:       helper.IntToPtr(2),
 								},
 								Constraints: []*api.Constraint{
 									{
@@ -367,6 +371,21 @@ func TestParse(t *testing.T) {
 			false,
 		},
 
+		{
+			"periodic-crons.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("foo"),
+				Name: helper.StringToPtr("foo"),
+				Periodic: &api.PeriodicConfig{
+					SpecType:      helper.StringToPtr(api.PeriodicSpecCron),
+					Specs:         []string{"0 8 * * 1-5", "0 10 * * 0,6"},
+					CatchUp:       helper.BoolToPtr(true),
+					CatchUpWindow: helper.TimeToPtr(6 * time.Hour),
+				},
+			},
+			false,
+		},
+
 		{
 			"specify-job.hcl",
 			&api.Job{
@@ -593,6 +612,177 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"lifecycle-job.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("foo"),
+				Name: helper.StringToPtr("foo"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Tasks: []*api.Task{
+							{
+								Name:   "init",
+								Driver: "docker",
+								Lifecycle: &api.TaskLifecycle{
+									Hook: "prestart",
+								},
+							},
+							{
+								Name:   "main",
+								Driver: "docker",
+							},
+							{
+								Name:   "shipper",
+								Driver: "docker",
+								Lifecycle: &api.TaskLifecycle{
+									Hook:    "poststart",
+									Sidecar: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"log-shipper.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("foo"),
+				Name: helper.StringToPtr("foo"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Tasks: []*api.Task{
+							{
+								Name:   "web",
+								Driver: "docker",
+								LogConfig: &api.LogConfig{
+									MaxFiles:      helper.IntToPtr(5),
+									MaxFileSizeMB: helper.IntToPtr(20),
+									Shippers: []*api.LogShipper{
+										{
+											Type:    helper.StringToPtr("tcp"),
+											Address: helper.StringToPtr("127.0.0.1:5140"),
+										},
+										{
+											Type:          helper.StringToPtr("http"),
+											Address:       helper.StringToPtr("https://logs.example.com/ingest"),
+											BufferSize:    helper.IntToPtr(4096),
+											BatchSize:     helper.IntToPtr(500),
+											FlushInterval: helper.TimeToPtr(5 * time.Second),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"log-rotation.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("foo"),
+				Name: helper.StringToPtr("foo"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Tasks: []*api.Task{
+							{
+								Name:   "web",
+								Driver: "docker",
+								LogConfig: &api.LogConfig{
+									MaxFiles:         helper.IntToPtr(24),
+									MaxFileSizeMB:    helper.IntToPtr(10),
+									RotationInterval: helper.TimeToPtr(time.Hour),
+									Compress:         helper.BoolToPtr(true),
+									MaxTotalSizeMB:   helper.IntToPtr(100),
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"volumes.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("foo"),
+				Name: helper.StringToPtr("foo"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Volumes: map[string]*api.VolumeRequest{
+							"certs": {
+								Name:     "certs",
+								Type:     "host",
+								Source:   "ca-certificates",
+								ReadOnly: true,
+							},
+							"data": {
+								Name:   "data",
+								Type:   "host",
+								Source: "shared-data",
+							},
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "web",
+								Driver: "docker",
+								VolumeMounts: []*api.VolumeMount{
+									{
+										Volume:      "certs",
+										Destination: "/etc/ssl/certs",
+									},
+									{
+										Volume:      "data",
+										Destination: "/srv/data",
+										ReadOnly:    true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"group-network.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("foo"),
+				Name: helper.StringToPtr("foo"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Networks: []*api.NetworkResource{
+							{
+								Mode:          "bridge",
+								MBits:         helper.IntToPtr(50),
+								ReservedPorts: []api.Port{{Label: "admin", Value: 9000, To: 9090}},
+								DynamicPorts:  []api.Port{{Label: "http", To: 8080}},
+							},
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "web",
+								Driver: "exec",
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"task-network-port-to.hcl",
+			nil,
+			true,
+		},
 		{
 			"service-check-driver-address.hcl",
 			&api.Job{
@@ -779,6 +969,129 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"affinity-job.hcl",
+			&api.Job{
+				ID:          helper.StringToPtr("foo"),
+				Name:        helper.StringToPtr("foo"),
+				Datacenters: []string{"dc1"},
+				Affinities: []*api.Affinity{
+					{
+						LTarget: "${meta.team}",
+						RTarget: "mobile",
+						Operand: "=",
+					},
+				},
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Affinities: []*api.Affinity{
+							{
+								LTarget: "${node.class}",
+								RTarget: "ssd",
+								Operand: "=",
+								Weight:  helper.IntToPtr(80),
+							},
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "bar",
+								Driver: "raw_exec",
+								Affinities: []*api.Affinity{
+									{
+										LTarget: "${attr.kernel.version}",
+										RTarget: ">= 4.0",
+										Operand: structs.ConstraintVersion,
+										Weight:  helper.IntToPtr(-50),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"device-job.hcl",
+			&api.Job{
+				ID:          helper.StringToPtr("foo"),
+				Name:        helper.StringToPtr("foo"),
+				Datacenters: []string{"dc1"},
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("bar"),
+						Tasks: []*api.Task{
+							{
+								Name:   "bar",
+								Driver: "raw_exec",
+								Resources: &api.Resources{
+									Devices: []*api.DeviceResource{
+										{
+											Vendor: "046d",
+											Type:   "usb",
+											Name:   "c52b",
+											Count:  helper.Uint64ToPtr(2),
+										},
+										{
+											Type: "nic",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"bad-device.hcl",
+			nil,
+			true,
+		},
+		{
+			"spread-job.hcl",
+			&api.Job{
+				ID:          helper.StringToPtr("foo"),
+				Name:        helper.StringToPtr("foo"),
+				Datacenters: []string{"dc1", "dc2"},
+				Spreads: []*api.Spread{
+					{
+						Attribute: "${node.datacenter}",
+						Weight:    helper.IntToPtr(80),
+						SpreadTarget: []*api.SpreadTarget{
+							{
+								Value:   "dc1",
+								Percent: 70,
+							},
+							{
+								Value:   "dc2",
+								Percent: 30,
+							},
+						},
+					},
+				},
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name:  helper.StringToPtr("bar"),
+						Count: helper.IntToPtr(10),
+						Spreads: []*api.Spread{
+							{
+								Attribute: "${meta.rack}",
+							},
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "bar",
+								Driver: "raw_exec",
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,26 @@
+job "foo" {
+  datacenters = ["dc1"]
+
+  affinity {
+    attribute = "${meta.team}"
+    value     = "mobile"
+  }
+
+  group "bar" {
+    affinity {
+      attribute = "${node.class}"
+      value     = "ssd"
+      weight    = 80
+    }
+
+    task "bar" {
+      driver = "raw_exec"
+
+      affinity {
+        attribute = "${attr.kernel.version}"
+        version   = ">= 4.0"
+        weight    = -50
+      }
+    }
+  }
+}
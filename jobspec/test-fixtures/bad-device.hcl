@@ -0,0 +1,13 @@
+job "foo" {
+  group "bar" {
+    task "bar" {
+      driver = "raw_exec"
+
+      resources {
+        device "046d//c52b" {
+          count = 1
+        }
+      }
+    }
+  }
+}
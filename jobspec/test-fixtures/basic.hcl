@@ -63,7 +63,9 @@ job "binstore-storagelocker" {
         health_check = "checks"
         min_healthy_time = "1s"
         healthy_deadline = "1m"
+        progress_deadline = "5m"
         auto_revert = false
+        auto_promote = true
         canary = 2
     }
 
@@ -199,9 +201,11 @@ job "binstore-storagelocker" {
       }
 
       resources {
-        cpu    = 500
-        memory = 128
-        iops   = 30
+        cpu        = 500
+        memory     = 128
+        memory_max = 256
+        iops       = 30
+        cores      = 2
       }
 
       constraint {
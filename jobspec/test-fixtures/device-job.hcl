@@ -0,0 +1,17 @@
+job "foo" {
+  datacenters = ["dc1"]
+
+  group "bar" {
+    task "bar" {
+      driver = "raw_exec"
+
+      resources {
+        device "046d/usb/c52b" {
+          count = 2
+        }
+
+        device "nic" {}
+      }
+    }
+  }
+}
@@ -0,0 +1,21 @@
+job "foo" {
+  group "bar" {
+    network {
+      mode  = "bridge"
+      mbits = 50
+
+      port "http" {
+        to = 8080
+      }
+
+      port "admin" {
+        static = 9000
+        to     = 9090
+      }
+    }
+
+    task "web" {
+      driver = "exec"
+    }
+  }
+}
@@ -0,0 +1,24 @@
+job "foo" {
+  group "bar" {
+    task "init" {
+      driver = "docker"
+
+      lifecycle {
+        hook = "prestart"
+      }
+    }
+
+    task "main" {
+      driver = "docker"
+    }
+
+    task "shipper" {
+      driver = "docker"
+
+      lifecycle {
+        hook    = "poststart"
+        sidecar = true
+      }
+    }
+  }
+}
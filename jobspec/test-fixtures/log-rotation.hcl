@@ -0,0 +1,15 @@
+job "foo" {
+  group "bar" {
+    task "web" {
+      driver = "docker"
+
+      logs {
+        max_files         = 24
+        max_file_size     = 10
+        rotation_interval = "1h"
+        compress          = true
+        max_total_size    = 100
+      }
+    }
+  }
+}
@@ -0,0 +1,25 @@
+job "foo" {
+  group "bar" {
+    task "web" {
+      driver = "docker"
+
+      logs {
+        max_files     = 5
+        max_file_size = 20
+
+        shipper {
+          type    = "tcp"
+          address = "127.0.0.1:5140"
+        }
+
+        shipper {
+          type           = "http"
+          address        = "https://logs.example.com/ingest"
+          buffer_size    = 4096
+          batch_size     = 500
+          flush_interval = "5s"
+        }
+      }
+    }
+  }
+}
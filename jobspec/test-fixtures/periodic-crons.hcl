@@ -0,0 +1,7 @@
+job "foo" {
+    periodic {
+        crons = ["0 8 * * 1-5", "0 10 * * 0,6"]
+        catchup = true
+        catchup_window = "6h"
+    }
+}
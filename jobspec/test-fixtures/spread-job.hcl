@@ -0,0 +1,28 @@
+job "foo" {
+  datacenters = ["dc1", "dc2"]
+
+  spread {
+    attribute = "${node.datacenter}"
+    weight    = 80
+
+    target "dc1" {
+      percent = 70
+    }
+
+    target "dc2" {
+      percent = 30
+    }
+  }
+
+  group "bar" {
+    count = 10
+
+    spread {
+      attribute = "${meta.rack}"
+    }
+
+    task "bar" {
+      driver = "raw_exec"
+    }
+  }
+}
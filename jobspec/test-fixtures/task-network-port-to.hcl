@@ -0,0 +1,15 @@
+job "foo" {
+  group "bar" {
+    task "web" {
+      driver = "exec"
+
+      resources {
+        network {
+          port "http" {
+            to = 8080
+          }
+        }
+      }
+    }
+  }
+}
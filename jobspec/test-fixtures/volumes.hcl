@@ -0,0 +1,29 @@
+job "foo" {
+  group "bar" {
+    volume "certs" {
+      type      = "host"
+      source    = "ca-certificates"
+      read_only = true
+    }
+
+    volume "data" {
+      type   = "host"
+      source = "shared-data"
+    }
+
+    task "web" {
+      driver = "docker"
+
+      volume_mount {
+        volume      = "certs"
+        destination = "/etc/ssl/certs"
+      }
+
+      volume_mount {
+        volume      = "data"
+        destination = "/srv/data"
+        read_only   = true
+      }
+    }
+  }
+}
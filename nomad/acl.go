@@ -1,6 +1,7 @@
 package nomad
 
 import (
+	"sort"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
@@ -39,6 +40,28 @@ func (s *Server) ResolveToken(secretID string) (*acl.ACL, error) {
 // using a cache to avoid parsing and ACL construction when possible. It is split from resolveToken
 // to simplify testing.
 func resolveTokenFromSnapshotCache(snap *state.StateSnapshot, cache *lru.TwoQueueCache, secretID string) (*acl.ACL, error) {
+	token, policies, err := resolveTokenPolicies(snap, secretID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if this is a management token
+	if token.Type == structs.ACLManagementToken {
+		return acl.ManagementACL, nil
+	}
+
+	// Compile and cache the ACL object
+	aclObj, err := structs.CompileACLObject(cache, policies)
+	if err != nil {
+		return nil, err
+	}
+	return aclObj, nil
+}
+
+// resolveTokenPolicies is used to lookup the ACL token of the secret ID and
+// its policies from a snapshot of state. Policies that don't exist are
+// skipped.
+func resolveTokenPolicies(snap *state.StateSnapshot, secretID string) (*structs.ACLToken, []*structs.ACLPolicy, error) {
 	// Lookup the ACL Token
 	var token *structs.ACLToken
 	var err error
@@ -49,16 +72,16 @@ func resolveTokenFromSnapshotCache(snap *state.StateSnapshot, cache *lru.TwoQueu
 	} else {
 		token, err = snap.ACLTokenBySecretID(nil, secretID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if token == nil {
-			return nil, structs.ErrTokenNotFound
+			return nil, nil, structs.ErrTokenNotFound
 		}
 	}
 
-	// Check if this is a management token
+	// Management tokens have no policies
 	if token.Type == structs.ACLManagementToken {
-		return acl.ManagementACL, nil
+		return token, nil, nil
 	}
 
 	// Get all associated policies
@@ -66,21 +89,43 @@ func resolveTokenFromSnapshotCache(snap *state.StateSnapshot, cache *lru.TwoQueu
 	for _, policyName := range token.Policies {
 		policy, err := snap.ACLPolicyByName(nil, policyName)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if policy == nil {
 			// Ignore policies that don't exist, since they don't grant any more privilege
 			continue
 		}
-
-		// Save the policy and update the cache key
 		policies = append(policies, policy)
 	}
+	return token, policies, nil
+}
 
-	// Compile and cache the ACL object
-	aclObj, err := structs.CompileACLObject(cache, policies)
+// resolveTokenACLKey returns a key identifying the ACL object the secret ID
+// resolves to. The key changes when the type of the token, its policies or
+// their rules change, so it is used to detect changes in what a token is
+// allowed to do after it was resolved.
+func (s *Server) resolveTokenACLKey(secretID string) (string, error) {
+	if !s.config.ACLEnabled {
+		return "", nil
+	}
+	if leaderAcl := s.getLeaderAcl(); leaderAcl != "" && secretID == leaderAcl {
+		return structs.ACLManagementToken, nil
+	}
+
+	snap, err := s.fsm.State().Snapshot()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return aclObj, nil
+	token, policies, err := resolveTokenPolicies(snap, secretID)
+	if err != nil {
+		return "", err
+	}
+	if token.Type == structs.ACLManagementToken {
+		return structs.ACLManagementToken, nil
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return structs.ACLPolicyListHash(policies), nil
 }
@@ -567,3 +567,56 @@ func TestAllocEndpoint_UpdateDesiredTransition(t *testing.T) {
 	require.True(*out1.DesiredTransition.Migrate)
 	require.True(*out2.DesiredTransition.Migrate)
 }
+
+func TestAllocEndpoint_Stop(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s1, _ := TestACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the allocation
+	alloc := mock.Alloc()
+	state := s1.fsm.State()
+	require.Nil(state.UpsertJobSummary(998, mock.JobSummary(alloc.JobID)))
+	require.Nil(state.UpsertAllocs(999, []*structs.Allocation{alloc}))
+
+	req := &structs.AllocStopRequest{
+		AllocID:      alloc.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Try without permissions
+	var resp structs.AllocStopResponse
+	err := msgpackrpc.CallWithCodec(codec, "Alloc.Stop", req, &resp)
+	require.NotNil(err)
+	require.True(structs.IsErrPermissionDenied(err))
+
+	// Try with an unknown allocation
+	unknown := &structs.AllocStopRequest{
+		AllocID:      uuid.Generate(),
+		WriteRequest: structs.WriteRequest{Region: "global", AuthToken: s1.getLeaderAcl()},
+	}
+	err = msgpackrpc.CallWithCodec(codec, "Alloc.Stop", unknown, &resp)
+	require.True(structs.IsErrUnknownAllocation(err))
+
+	// Try with permissions
+	req.AuthToken = s1.getLeaderAcl()
+	var resp2 structs.AllocStopResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Alloc.Stop", req, &resp2))
+	require.NotZero(resp2.Index)
+	require.NotEmpty(resp2.EvalID)
+
+	// Look up the allocation and evaluation
+	out, err := state.AllocByID(nil, alloc.ID)
+	require.Nil(err)
+	require.True(out.DesiredTransition.ShouldMigrate())
+
+	eval, err := state.EvalByID(nil, resp2.EvalID)
+	require.Nil(err)
+	require.NotNil(eval)
+	require.Equal(structs.EvalTriggerAllocStop, eval.TriggeredBy)
+	require.Equal(alloc.JobID, eval.JobID)
+}
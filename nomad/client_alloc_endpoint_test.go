@@ -1,8 +1,12 @@
 package nomad
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/acl"
@@ -14,6 +18,7 @@ import (
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
 )
 
 func TestClientAllocations_GarbageCollectAll_Local(t *testing.T) {
@@ -490,6 +495,173 @@ func TestClientAllocations_GarbageCollect_Remote(t *testing.T) {
 	require.Nil(err)
 }
 
+func TestClientAllocations_Restart_Local_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// Start a server
+	s, root := TestACLServer(t, nil)
+	defer s.Shutdown()
+	codec := rpcClient(t, s)
+	testutil.WaitForLeader(t, s.RPC)
+
+	// Create a bad token
+	policyBad := mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob})
+	tokenBad := mock.CreatePolicyAndToken(t, s.State(), 1005, "invalid", policyBad)
+
+	// Create a token for another namespace
+	policyOther := mock.NamespacePolicy("other", "", []string{acl.NamespaceCapabilitySubmitJob})
+	tokenOther := mock.CreatePolicyAndToken(t, s.State(), 1007, "other", policyOther)
+
+	policyGood := mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilitySubmitJob})
+	tokenGood := mock.CreatePolicyAndToken(t, s.State(), 1009, "valid2", policyGood)
+
+	// Upsert an allocation on an unknown node
+	alloc := mock.Alloc()
+	require.Nil(s.State().UpsertAllocs(1010, []*structs.Allocation{alloc}))
+
+	cases := []struct {
+		Name          string
+		Token         string
+		Namespace     string
+		ExpectedError string
+	}{
+		{
+			Name:          "bad token",
+			Token:         tokenBad.SecretID,
+			Namespace:     structs.DefaultNamespace,
+			ExpectedError: structs.ErrPermissionDenied.Error(),
+		},
+		{
+			Name:          "other namespace token",
+			Token:         tokenOther.SecretID,
+			Namespace:     "other",
+			ExpectedError: structs.ErrPermissionDenied.Error(),
+		},
+		{
+			Name:          "good token",
+			Token:         tokenGood.SecretID,
+			Namespace:     structs.DefaultNamespace,
+			ExpectedError: "Unknown node",
+		},
+		{
+			Name:          "root token",
+			Token:         root.SecretID,
+			Namespace:     structs.DefaultNamespace,
+			ExpectedError: "Unknown node",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			req := &structs.AllocRestartRequest{
+				AllocID: alloc.ID,
+				WriteRequest: structs.WriteRequest{
+					AuthToken: c.Token,
+					Region:    "global",
+					Namespace: c.Namespace,
+				},
+			}
+
+			// Fetch the response
+			var resp structs.GenericResponse
+			err := msgpackrpc.CallWithCodec(codec, "ClientAllocations.Restart", req, &resp)
+			require.NotNil(err)
+			require.Contains(err.Error(), c.ExpectedError)
+		})
+	}
+}
+
+func TestClientAllocations_Signal_Local(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// Start a server and client
+	s := TestServer(t, nil)
+	defer s.Shutdown()
+	codec := rpcClient(t, s)
+	testutil.WaitForLeader(t, s.RPC)
+
+	c := client.TestClient(t, func(c *config.Config) {
+		c.Servers = []string{s.config.RPCAddr.String()}
+	})
+	defer c.Shutdown()
+
+	// Force an allocation onto the node
+	a := mock.Alloc()
+	a.NodeID = c.NodeID()
+	a.Job.TaskGroups[0].Count = 1
+	a.Job.TaskGroups[0].Tasks[0] = &structs.Task{
+		Name:   "web",
+		Driver: "mock_driver",
+		Config: map[string]interface{}{
+			"run_for": "20s",
+		},
+		LogConfig: structs.DefaultLogConfig(),
+		Resources: &structs.Resources{
+			CPU:      500,
+			MemoryMB: 256,
+		},
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		nodes := s.connectedNodes()
+		return len(nodes) == 1, nil
+	}, func(err error) {
+		t.Fatalf("should have a clients")
+	})
+
+	// Upsert the allocation
+	state := s.State()
+	require.Nil(state.UpsertNamespace(998, &structs.Namespace{Name: structs.DefaultNamespace}))
+	require.Nil(state.UpsertJob(999, a.Job))
+	require.Nil(state.UpsertAllocs(1003, []*structs.Allocation{a}))
+
+	// Wait for the client to run the allocation
+	testutil.WaitForResult(func() (bool, error) {
+		alloc, err := state.AllocByID(nil, a.ID)
+		if err != nil {
+			return false, err
+		}
+		if alloc == nil {
+			return false, fmt.Errorf("unknown alloc")
+		}
+		if alloc.ClientStatus != structs.AllocClientStatusRunning {
+			return false, fmt.Errorf("alloc client status: %v", alloc.ClientStatus)
+		}
+
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("Alloc on node %q not running: %v", c.NodeID(), err)
+	})
+
+	// Make the request without a signal
+	req := &structs.AllocSignalRequest{
+		AllocID:      a.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "ClientAllocations.Signal", req, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), "missing Signal")
+
+	// Signal the task
+	req.Signal = "SIGUSR1"
+	req.Task = "web"
+	var resp2 structs.GenericResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "ClientAllocations.Signal", req, &resp2))
+
+	// Restart the task
+	restartReq := &structs.AllocRestartRequest{
+		AllocID:      a.ID,
+		TaskName:     "web",
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp3 structs.GenericResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "ClientAllocations.Restart", restartReq, &resp3))
+}
+
 func TestClientAllocations_Stats_OldNode(t *testing.T) {
 	t.Parallel()
 	require := require.New(t)
@@ -760,3 +932,217 @@ func TestClientAllocations_Stats_Remote(t *testing.T) {
 	require.Nil(err)
 	require.NotNil(resp.Stats)
 }
+
+// execStreamingRpc runs an exec session against the server's streaming
+// handler. It sends the inputs after the request and returns the stdout and
+// exit code of the command or the error returned by the handler.
+func execStreamingRpc(t *testing.T, s *Server, req *cstructs.AllocExecRequest, inputs ...*cstructs.ExecStreamingInput) (string, int, error) {
+	handler, err := s.StreamingRpcHandler("Allocations.Exec")
+	require.Nil(t, err)
+
+	// Create a pipe
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+	defer p2.Close()
+
+	// Start the handler
+	go handler(p2)
+
+	// Send the request and the inputs
+	go func() {
+		encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+		if err := encoder.Encode(req); err != nil {
+			return
+		}
+		for _, input := range inputs {
+			if err := encoder.Encode(input); err != nil {
+				return
+			}
+		}
+	}()
+
+	resultCh := make(chan error, 1)
+	var stdout bytes.Buffer
+	exitCode := -1
+	go func() {
+		decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+		for {
+			var msg cstructs.StreamErrWrapper
+			if err := decoder.Decode(&msg); err != nil {
+				resultCh <- fmt.Errorf("error decoding: %v", err)
+				return
+			}
+			if msg.Error != nil {
+				resultCh <- msg.Error
+				return
+			}
+
+			var frame cstructs.ExecStreamingOutput
+			if err := codec.NewDecoderBytes(msg.Payload, structs.JsonHandle).Decode(&frame); err != nil {
+				resultCh <- fmt.Errorf("error decoding frame: %v", err)
+				return
+			}
+			if frame.Stdout != nil {
+				stdout.Write(frame.Stdout.Data)
+			}
+			if frame.Exited {
+				exitCode = frame.Result.ExitCode
+				resultCh <- nil
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-resultCh:
+		return stdout.String(), exitCode, err
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout")
+		return "", 0, nil
+	}
+}
+
+func TestClientAllocations_Exec_ACL(t *testing.T) {
+	t.Parallel()
+
+	// Start a server
+	s, root := TestACLServer(t, nil)
+	defer s.Shutdown()
+	testutil.WaitForLeader(t, s.RPC)
+
+	// Create a bad token
+	policyBad := mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadLogs})
+	tokenBad := mock.CreatePolicyAndToken(t, s.State(), 1005, "invalid", policyBad)
+
+	// Create a token for another namespace
+	policyOther := mock.NamespacePolicy("other", "", []string{acl.NamespaceCapabilityAllocExec})
+	tokenOther := mock.CreatePolicyAndToken(t, s.State(), 1007, "other", policyOther)
+
+	policyGood := mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityAllocExec})
+	tokenGood := mock.CreatePolicyAndToken(t, s.State(), 1009, "valid2", policyGood)
+
+	// Upsert an allocation on an unknown node
+	alloc := mock.Alloc()
+	require.Nil(t, s.State().UpsertAllocs(1010, []*structs.Allocation{alloc}))
+
+	cases := []struct {
+		Name          string
+		Token         string
+		Namespace     string
+		ExpectedError string
+	}{
+		{
+			Name:          "bad token",
+			Token:         tokenBad.SecretID,
+			Namespace:     structs.DefaultNamespace,
+			ExpectedError: structs.ErrPermissionDenied.Error(),
+		},
+		{
+			Name:          "other namespace token",
+			Token:         tokenOther.SecretID,
+			Namespace:     "other",
+			ExpectedError: structs.ErrPermissionDenied.Error(),
+		},
+		{
+			Name:          "good token",
+			Token:         tokenGood.SecretID,
+			Namespace:     structs.DefaultNamespace,
+			ExpectedError: "Unknown node",
+		},
+		{
+			Name:          "root token",
+			Token:         root.SecretID,
+			Namespace:     structs.DefaultNamespace,
+			ExpectedError: "Unknown node",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			req := &cstructs.AllocExecRequest{
+				AllocID: alloc.ID,
+				Task:    "web",
+				Cmd:     []string{"/bin/sh"},
+				QueryOptions: structs.QueryOptions{
+					Namespace: c.Namespace,
+					Region:    "global",
+					AuthToken: c.Token,
+				},
+			}
+
+			_, _, err := execStreamingRpc(t, s, req)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.ExpectedError)
+		})
+	}
+}
+
+func TestClientAllocations_Exec_Local(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// Start a server and client
+	s := TestServer(t, nil)
+	defer s.Shutdown()
+	testutil.WaitForLeader(t, s.RPC)
+
+	c := client.TestClient(t, func(c *config.Config) {
+		c.Servers = []string{s.config.RPCAddr.String()}
+	})
+	defer c.Shutdown()
+
+	// Force a running allocation onto the node
+	a := mock.Alloc()
+	a.NodeID = c.NodeID()
+	a.Job.TaskGroups[0].Count = 1
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"run_for": "20s",
+	}
+
+	// Wait for the client to connect
+	testutil.WaitForResult(func() (bool, error) {
+		nodes := s.connectedNodes()
+		return len(nodes) == 1, nil
+	}, func(err error) {
+		t.Fatalf("should have a clients")
+	})
+
+	// Upsert the allocation
+	state := s.State()
+	require.Nil(state.UpsertNamespace(998, &structs.Namespace{Name: structs.DefaultNamespace}))
+	require.Nil(state.UpsertJob(999, a.Job))
+	require.Nil(state.UpsertAllocs(1003, []*structs.Allocation{a}))
+
+	// Wait for the client to run the allocation
+	testutil.WaitForResult(func() (bool, error) {
+		alloc, err := state.AllocByID(nil, a.ID)
+		if err != nil {
+			return false, err
+		}
+		if alloc == nil {
+			return false, fmt.Errorf("unknown alloc")
+		}
+		if alloc.ClientStatus != structs.AllocClientStatusRunning {
+			return false, fmt.Errorf("alloc client status: %v", alloc.ClientStatus)
+		}
+
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("Alloc on node %q not running: %v", c.NodeID(), err)
+	})
+
+	// Make the request
+	req := &cstructs.AllocExecRequest{
+		AllocID:      a.ID,
+		Task:         task.Name,
+		Cmd:          []string{"cat"},
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	stdout, exitCode, err := execStreamingRpc(t, s, req,
+		&cstructs.ExecStreamingInput{Stdin: &cstructs.ExecStreamingIOOperation{Data: []byte("hello"), Close: true}})
+	require.NoError(err)
+	require.Zero(exitCode)
+	require.True(strings.HasSuffix(stdout, "hello"), "unexpected stdout: %q", stdout)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/memberlist"
 	"github.com/hashicorp/nomad/helper/tlsutil"
 	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/stream"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/scheduler"
@@ -215,6 +216,20 @@ type Config struct {
 	// additional delay is selected from this range randomly.
 	EvalFailedFollowupDelayRange time.Duration
 
+	// EvalFairQueueing makes the eval broker dequeue the ready evaluations
+	// with a weighted round-robin across namespaces rather than purely by
+	// priority, so a busy namespace can't starve the others.
+	EvalFairQueueing bool
+
+	// EvalNamespaceWeights are the weights of the namespaces in the fair
+	// queueing round-robin. Namespaces without a weight have a weight of one.
+	EvalNamespaceWeights map[string]int
+
+	// EvalMaxReadyPerJob caps the number of ready evaluations of a job when
+	// fair queueing is enabled. The evaluations of dispatched and periodic
+	// child jobs count against their parent job. Zero disables the cap.
+	EvalMaxReadyPerJob int
+
 	// MinHeartbeatTTL is the minimum time between heartbeats.
 	// This is used as a floor to prevent excessive updates.
 	MinHeartbeatTTL time.Duration
@@ -291,6 +306,10 @@ type Config struct {
 	// autopilot tasks, such as promoting eligible non-voters and removing
 	// dead servers.
 	AutopilotInterval time.Duration
+
+	// EventBufferSize is the number of Raft indexes whose events are buffered
+	// for the subscribers of the event stream resuming it from an index.
+	EventBufferSize int
 }
 
 // CheckVersion is used to check if the ProtocolVersion is valid
@@ -359,6 +378,7 @@ func DefaultConfig() *Config {
 		},
 		ServerHealthInterval: 2 * time.Second,
 		AutopilotInterval:    10 * time.Second,
+		EventBufferSize:      stream.DefaultEventBufferSize,
 	}
 
 	// Enable all known schedulers by default
@@ -163,9 +163,10 @@ func (w *deploymentWatcher) SetAllocHealth(
 	// Create the request
 	areq := &structs.ApplyDeploymentAllocHealthRequest{
 		DeploymentAllocHealthRequest: *req,
-		Eval:             w.getEval(),
-		DeploymentUpdate: u,
-		Job:              j,
+		Eval:                         w.getEval(),
+		DeploymentUpdate:             u,
+		Job:                          j,
+		Timestamp:                    time.Now(),
 	}
 
 	index, err := w.upsertDeploymentAllocHealth(areq)
@@ -313,14 +314,81 @@ func (w *deploymentWatcher) StopWatch() {
 }
 
 // watch is the long running watcher that takes actions upon allocation changes
+// and fails the deployment when it doesn't make progress before its deadline.
 func (w *deploymentWatcher) watch() {
+	// Arm the progress deadline of the deployment
+	var deadlineTimer *time.Timer
+	var deadlineCh <-chan time.Time
+	armDeadline := func() {
+		deadline, err := w.progressDeadline()
+		if err != nil {
+			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to determine progress deadline for deployment %q: %v", w.d.ID, err)
+			return
+		}
+		if deadline.IsZero() {
+			deadlineCh = nil
+			return
+		}
+
+		if deadlineTimer == nil {
+			deadlineTimer = time.NewTimer(time.Until(deadline))
+		} else {
+			if !deadlineTimer.Stop() {
+				select {
+				case <-deadlineTimer.C:
+				default:
+				}
+			}
+			deadlineTimer.Reset(time.Until(deadline))
+		}
+		deadlineCh = deadlineTimer.C
+	}
+	armDeadline()
+	defer func() {
+		if deadlineTimer != nil {
+			deadlineTimer.Stop()
+		}
+	}()
+
 	allocIndex := uint64(1)
+	allocsCh := w.getAllocsCh(allocIndex)
 	for {
-		// Block getting all allocations that are part of the deployment using
-		// the last evaluation index. This will have us block waiting for
-		// something to change past what the scheduler has evaluated.
-		allocs, index, err := w.getAllocs(allocIndex)
-		if err != nil {
+		var updates *allocUpdates
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-deadlineCh:
+			deadlineCh = nil
+
+			// The deadline may have been extended by allocations becoming
+			// healthy since the timer was armed.
+			deadline, err := w.progressDeadline()
+			if err != nil {
+				w.logger.Printf("[ERR] nomad.deployment_watcher: failed to determine progress deadline for deployment %q: %v", w.d.ID, err)
+				continue
+			}
+			if deadline.IsZero() {
+				continue
+			}
+			if time.Now().Before(deadline) {
+				armDeadline()
+				continue
+			}
+
+			// Fail the deployment, rolling back if any group auto-reverts
+			rollback := false
+			for _, state := range w.d.TaskGroups {
+				if state.AutoRevert {
+					rollback = true
+					break
+				}
+			}
+			w.failDeployment(structs.DeploymentStatusDescriptionProgressDeadline, rollback)
+			continue
+		case updates = <-allocsCh:
+		}
+
+		if err := updates.err; err != nil {
 			if err == context.Canceled || w.ctx.Err() == context.Canceled {
 				return
 			}
@@ -328,6 +396,7 @@ func (w *deploymentWatcher) watch() {
 			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to retrieve allocations for deployment %q: %v", w.d.ID, err)
 			return
 		}
+		allocs, index := updates.allocs, updates.index
 		allocIndex = index
 
 		// Get the latest evaluation index
@@ -371,41 +440,168 @@ func (w *deploymentWatcher) watch() {
 
 		// Change the deployments status to failed
 		if failDeployment {
-			// Default description
-			desc := structs.DeploymentStatusDescriptionFailedAllocations
-
-			// Rollback to the old job if necessary
-			var j *structs.Job
-			if rollback {
-				var err error
-				j, err = w.latestStableJob()
-				if err != nil {
-					w.logger.Printf("[ERR] nomad.deployment_watcher: failed to lookup latest stable job for %q: %v", w.d.JobID, err)
-				}
+			w.failDeployment(structs.DeploymentStatusDescriptionFailedAllocations, rollback)
+		} else {
+			// Promote the canaries once they are all healthy
+			promoted, err := w.autoPromoteDeployment(allocs)
+			if err != nil {
+				w.logger.Printf("[ERR] nomad.deployment_watcher: failed to auto promote deployment %q: %v", w.d.ID, err)
+			}
 
-				// Description should include that the job is being rolled back to
-				// version N
-				if j != nil {
-					j, desc = w.handleRollbackValidity(j, desc)
-				} else {
-					desc = structs.DeploymentStatusDescriptionNoRollbackTarget(desc)
-				}
+			// Create an eval to push the deployment along. The promotion
+			// already created one.
+			if createEval && !promoted {
+				w.createEvalBatched(index)
 			}
+		}
+
+		// Rearm the progress deadline in case it was extended
+		armDeadline()
+
+		allocsCh = w.getAllocsCh(allocIndex)
+	}
+}
 
-			// Update the status of the deployment to failed and create an
-			// evaluation.
-			e := w.getEval()
-			u := w.getDeploymentStatusUpdate(structs.DeploymentStatusFailed, desc)
-			if index, err := w.upsertDeploymentStatusUpdate(u, e, j); err != nil {
-				w.logger.Printf("[ERR] nomad.deployment_watcher: failed to update deployment %q status: %v", w.d.ID, err)
-			} else {
-				w.setLatestEval(index)
+// failDeployment marks the deployment as failed with the given description
+// and rolls the job back to the latest stable version if requested.
+func (w *deploymentWatcher) failDeployment(desc string, rollback bool) {
+	// Rollback to the old job if necessary
+	var j *structs.Job
+	if rollback {
+		var err error
+		j, err = w.latestStableJob()
+		if err != nil {
+			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to lookup latest stable job for %q: %v", w.d.JobID, err)
+		}
+
+		// Description should include that the job is being rolled back to
+		// version N
+		if j != nil {
+			j, desc = w.handleRollbackValidity(j, desc)
+		} else {
+			desc = structs.DeploymentStatusDescriptionNoRollbackTarget(desc)
+		}
+	}
+
+	// Update the status of the deployment to failed and create an
+	// evaluation.
+	e := w.getEval()
+	u := w.getDeploymentStatusUpdate(structs.DeploymentStatusFailed, desc)
+	if index, err := w.upsertDeploymentStatusUpdate(u, e, j); err != nil {
+		w.logger.Printf("[ERR] nomad.deployment_watcher: failed to update deployment %q status: %v", w.d.ID, err)
+	} else {
+		w.setLatestEval(index)
+	}
+}
+
+// autoPromoteDeployment promotes the canaries of the deployment when every
+// task group awaiting promotion auto-promotes and all their canaries are
+// healthy. It returns whether the deployment was promoted.
+func (w *deploymentWatcher) autoPromoteDeployment(allocs []*structs.AllocListStub) (bool, error) {
+	d, err := w.latestDeployment()
+	if err != nil || d == nil {
+		return false, err
+	}
+	if !d.RequiresPromotion() {
+		return false, nil
+	}
+
+	healthy := make(map[string]struct{}, len(allocs))
+	for _, alloc := range allocs {
+		if alloc.DeploymentStatus.IsHealthy() {
+			healthy[alloc.ID] = struct{}{}
+		}
+	}
+
+	for _, state := range d.TaskGroups {
+		if state.DesiredCanaries == 0 || state.Promoted {
+			continue
+		}
+
+		// The job version is promoted as a whole so every group must
+		// auto-promote
+		if !state.AutoPromote || len(state.PlacedCanaries) < state.DesiredCanaries {
+			return false, nil
+		}
+		for _, id := range state.PlacedCanaries {
+			if _, ok := healthy[id]; !ok {
+				return false, nil
 			}
-		} else if createEval {
-			// Create an eval to push the deployment along
-			w.createEvalBatched(index)
 		}
 	}
+
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+		Eval: w.getEval(),
+	}
+	index, err := w.upsertDeploymentPromotion(req)
+	if err != nil {
+		return false, err
+	}
+
+	w.setLatestEval(index)
+	return true, nil
+}
+
+// progressDeadline returns the earliest time by which a task group of the
+// deployment must make progress. Task groups whose allocations are all healthy
+// are ignored. The zero time is returned if there is no deadline.
+func (w *deploymentWatcher) progressDeadline() (time.Time, error) {
+	snap, err := w.state.Snapshot()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	d, err := snap.DeploymentByID(nil, w.d.ID)
+	if err != nil || d == nil || !d.Active() {
+		return time.Time{}, err
+	}
+
+	allocs, err := snap.AllocsByDeployment(nil, w.d.ID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Count the healthy running allocations of each group
+	healthy := make(map[string]int, len(d.TaskGroups))
+	for _, alloc := range allocs {
+		if alloc.TerminalStatus() || !alloc.DeploymentStatus.IsHealthy() {
+			continue
+		}
+		healthy[alloc.TaskGroup]++
+	}
+
+	var next time.Time
+	for name, state := range d.TaskGroups {
+		if state.RequireProgressBy.IsZero() {
+			continue
+		}
+
+		// The group is done unless it awaits promotion
+		awaitingPromotion := state.DesiredCanaries != 0 && !state.Promoted
+		if !awaitingPromotion && healthy[name] >= state.DesiredTotal {
+			continue
+		}
+
+		if next.IsZero() || state.RequireProgressBy.Before(next) {
+			next = state.RequireProgressBy
+		}
+	}
+
+	return next, nil
+}
+
+// latestDeployment returns the current version of the watched deployment
+func (w *deploymentWatcher) latestDeployment() (*structs.Deployment, error) {
+	snap, err := w.state.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return snap.DeploymentByID(nil, w.d.ID)
 }
 
 // latestStableJob returns the latest stable job. It may be nil if none exist
@@ -489,6 +685,30 @@ func (w *deploymentWatcher) getDeploymentStatusUpdate(status, desc string) *stru
 	}
 }
 
+// allocUpdates holds the result of a blocking query on the allocations of the
+// deployment.
+type allocUpdates struct {
+	allocs []*structs.AllocListStub
+	index  uint64
+	err    error
+}
+
+// getAllocsCh retrieves the allocations that are part of the deployment
+// blocking at the given index in the background and sends the result on the
+// returned channel.
+func (w *deploymentWatcher) getAllocsCh(index uint64) <-chan *allocUpdates {
+	out := make(chan *allocUpdates, 1)
+	go func() {
+		allocs, index, err := w.getAllocs(index)
+		out <- &allocUpdates{
+			allocs: allocs,
+			index:  index,
+			err:    err,
+		}
+	}()
+	return out
+}
+
 // getAllocs retrieves the allocations that are part of the deployment blocking
 // at the given index.
 func (w *deploymentWatcher) getAllocs(index uint64) ([]*structs.AllocListStub, uint64, error) {
@@ -991,3 +991,165 @@ func TestWatcher_BatchEvals(t *testing.T) {
 	testutil.WaitForResult(func() (bool, error) { return 2 == len(w.watchers), nil },
 		func(err error) { assert.Equal(2, len(w.watchers), "Should have 2 deployment") })
 }
+
+// Tests that a deployment making no progress fails at its progress deadline
+func TestDeploymentWatcher_ProgressDeadline(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	w, m := testDeploymentWatcher(t, 1000.0, 1*time.Millisecond)
+
+	// Create a job, alloc, and a deployment whose progress deadline is soon
+	j := mock.Job()
+	j.TaskGroups[0].Update = structs.DefaultUpdateStrategy.Copy()
+	j.TaskGroups[0].Update.ProgressDeadline = 500 * time.Millisecond
+	d := mock.Deployment()
+	d.JobID = j.ID
+	d.TaskGroups["web"].ProgressDeadline = 500 * time.Millisecond
+	d.TaskGroups["web"].RequireProgressBy = time.Now().Add(500 * time.Millisecond)
+	a := mock.Alloc()
+	a.DeploymentID = d.ID
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j), "UpsertJob")
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d), "UpsertDeployment")
+	assert.Nil(m.state.UpsertAllocs(m.nextIndex(), []*structs.Allocation{a}), "UpsertAllocs")
+
+	// Assert that we get a call to UpsertDeploymentStatusUpdate
+	c := &matchDeploymentStatusUpdateConfig{
+		DeploymentID:      d.ID,
+		Status:            structs.DeploymentStatusFailed,
+		StatusDescription: structs.DeploymentStatusDescriptionProgressDeadline,
+		Eval:              true,
+	}
+	m1 := matchDeploymentStatusUpdateRequest(c)
+	m.On("UpdateDeploymentStatus", mocker.MatchedBy(m1)).Return(nil)
+
+	w.SetEnabled(true, m.state)
+	testutil.WaitForResult(func() (bool, error) { return 1 == len(w.watchers), nil },
+		func(err error) { assert.Equal(1, len(w.watchers), "Should have 1 deployment") })
+
+	// Wait for the deployment to be failed
+	testutil.WaitForResult(func() (bool, error) {
+		ws := memdb.NewWatchSet()
+		out, err := m.state.DeploymentByID(ws, d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			return false, fmt.Errorf("got status %q; want %q", out.Status, structs.DeploymentStatusFailed)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatal(err)
+	})
+
+	m.AssertCalled(t, "UpdateDeploymentStatus", mocker.MatchedBy(m1))
+	testutil.WaitForResult(func() (bool, error) { return 0 == len(w.watchers), nil },
+		func(err error) { assert.Equal(0, len(w.watchers), "Should have no deployment") })
+}
+
+// Tests that a deployment whose allocations are all healthy doesn't fail at
+// its progress deadline
+func TestDeploymentWatcher_ProgressDeadline_Healthy(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	w, m := testDeploymentWatcher(t, 1000.0, 1*time.Millisecond)
+
+	// Create a job, a healthy alloc, and a deployment whose progress deadline
+	// is soon
+	j := mock.Job()
+	j.TaskGroups[0].Update = structs.DefaultUpdateStrategy.Copy()
+	d := mock.Deployment()
+	d.JobID = j.ID
+	d.TaskGroups["web"].DesiredTotal = 1
+	d.TaskGroups["web"].ProgressDeadline = 200 * time.Millisecond
+	d.TaskGroups["web"].RequireProgressBy = time.Now().Add(200 * time.Millisecond)
+	a := mock.Alloc()
+	a.DeploymentID = d.ID
+	a.DeploymentStatus = &structs.AllocDeploymentStatus{
+		Healthy: helper.BoolToPtr(true),
+	}
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j), "UpsertJob")
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d), "UpsertDeployment")
+	assert.Nil(m.state.UpsertAllocs(m.nextIndex(), []*structs.Allocation{a}), "UpsertAllocs")
+
+	w.SetEnabled(true, m.state)
+	testutil.WaitForResult(func() (bool, error) { return 1 == len(w.watchers), nil },
+		func(err error) { assert.Equal(1, len(w.watchers), "Should have 1 deployment") })
+
+	// Wait past the deadline and check the deployment is still running
+	time.Sleep(500 * time.Millisecond)
+	ws := memdb.NewWatchSet()
+	out, err := m.state.DeploymentByID(ws, d.ID)
+	assert.Nil(err, "DeploymentByID")
+	assert.Equal(structs.DeploymentStatusRunning, out.Status)
+	m.AssertNotCalled(t, "UpdateDeploymentStatus", mocker.Anything)
+}
+
+// Tests that a deployment is promoted once all its canaries are healthy when
+// its task groups auto promote
+func TestDeploymentWatcher_AutoPromote(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	w, m := testDeploymentWatcher(t, 1000.0, 1*time.Millisecond)
+
+	// Create a job, a canary alloc, and a deployment awaiting promotion
+	j := mock.Job()
+	j.TaskGroups[0].Update = structs.DefaultUpdateStrategy.Copy()
+	j.TaskGroups[0].Update.Canary = 1
+	j.TaskGroups[0].Update.AutoPromote = true
+	d := mock.Deployment()
+	d.JobID = j.ID
+	d.StatusDescription = structs.DeploymentStatusDescriptionRunningNeedsPromotion
+	a := mock.Alloc()
+	a.DeploymentID = d.ID
+	d.TaskGroups[a.TaskGroup].AutoPromote = true
+	d.TaskGroups[a.TaskGroup].DesiredCanaries = 1
+	d.TaskGroups[a.TaskGroup].PlacedCanaries = []string{a.ID}
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j), "UpsertJob")
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d), "UpsertDeployment")
+	assert.Nil(m.state.UpsertAllocs(m.nextIndex(), []*structs.Allocation{a}), "UpsertAllocs")
+
+	// Assert that we get a call to UpsertDeploymentPromotion
+	matchConfig := &matchDeploymentPromoteRequestConfig{
+		Promotion: &structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+		Eval: true,
+	}
+	matcher := matchDeploymentPromoteRequest(matchConfig)
+	m.On("UpdateDeploymentPromotion", mocker.MatchedBy(matcher)).Return(nil)
+
+	w.SetEnabled(true, m.state)
+	testutil.WaitForResult(func() (bool, error) { return 1 == len(w.watchers), nil },
+		func(err error) { assert.Equal(1, len(w.watchers), "Should have 1 deployment") })
+
+	// The canary isn't healthy yet so there is no promotion
+	m.AssertNotCalled(t, "UpdateDeploymentPromotion", mocker.Anything)
+
+	// Mark the canary healthy
+	req := &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentAllocHealthRequest: structs.DeploymentAllocHealthRequest{
+			DeploymentID:         d.ID,
+			HealthyAllocationIDs: []string{a.ID},
+		},
+		Timestamp: time.Now(),
+	}
+	assert.Nil(m.state.UpdateDeploymentAllocHealth(m.nextIndex(), req), "UpsertDeploymentAllocHealth")
+
+	// Wait for the deployment to be promoted
+	testutil.WaitForResult(func() (bool, error) {
+		ws := memdb.NewWatchSet()
+		out, err := m.state.DeploymentByID(ws, d.ID)
+		if err != nil {
+			return false, err
+		}
+		if !out.TaskGroups[a.TaskGroup].Promoted {
+			return false, fmt.Errorf("deployment not promoted")
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatal(err)
+	})
+
+	m.AssertCalled(t, "UpdateDeploymentPromotion", mocker.MatchedBy(matcher))
+}
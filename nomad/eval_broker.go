@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -41,6 +43,12 @@ var (
 // to only dequeue work they know how to handle. The broker is designed to be entirely
 // in-memory and is managed by the leader node.
 //
+// With fair queueing enabled, the broker instead dequeues with a weighted
+// round-robin across the namespaces with ready work, the highest priority work
+// of the namespace being dequeued first. It can also cap the number of ready
+// evaluations of a job so a job dispatching many children can't fill the ready
+// queues on its own.
+//
 // The broker must provide at-least-once delivery semantics. It relies on explicit
 // Ack/Nack messages to handle this. If a delivery is not Ack'd in a sufficient time
 // span, it will be assumed Nack'd.
@@ -62,8 +70,36 @@ type EvalBroker struct {
 	// blocked tracks the blocked evaluations by JobID in a priority queue
 	blocked map[structs.NamespacedID]PendingEvaluations
 
-	// ready tracks the ready jobs by scheduler in a priority queue
-	ready map[string]PendingEvaluations
+	// ready tracks the ready jobs by scheduler and namespace in a priority
+	// queue
+	ready map[string]map[string]PendingEvaluations
+
+	// readyTime tracks when the ready evaluations were made ready by ID
+	readyTime map[string]time.Time
+
+	// fairQueueing dequeues the ready evaluations with a weighted round-robin
+	// across namespaces rather than purely by priority
+	fairQueueing bool
+
+	// namespaceWeights are the weights of the namespaces in the round-robin.
+	// Namespaces without a weight have a weight of one.
+	namespaceWeights map[string]int
+
+	// namespaceCredits holds the current weights of the namespaces in the
+	// smooth weighted round-robin
+	namespaceCredits map[string]int
+
+	// maxReadyPerJob caps the number of ready evaluations of a job when fair
+	// queueing is enabled. Zero disables the cap.
+	maxReadyPerJob int
+
+	// readyJobs counts the ready evaluations of the jobs capped by
+	// maxReadyPerJob
+	readyJobs map[structs.NamespacedID]int
+
+	// throttled tracks the evaluations held back by maxReadyPerJob by job in
+	// a priority queue
+	throttled map[structs.NamespacedID]PendingEvaluations
 
 	// unack is a map of evalID to an un-acknowledged evaluation
 	unack map[string]*unackEval
@@ -135,7 +171,11 @@ func NewEvalBroker(timeout, initialNackDelay, subsequentNackDelay time.Duration,
 		evals:                make(map[string]int),
 		jobEvals:             make(map[structs.NamespacedID]string),
 		blocked:              make(map[structs.NamespacedID]PendingEvaluations),
-		ready:                make(map[string]PendingEvaluations),
+		ready:                make(map[string]map[string]PendingEvaluations),
+		readyTime:            make(map[string]time.Time),
+		namespaceCredits:     make(map[string]int),
+		readyJobs:            make(map[structs.NamespacedID]int),
+		throttled:            make(map[structs.NamespacedID]PendingEvaluations),
 		unack:                make(map[string]*unackEval),
 		waiting:              make(map[string]chan struct{}),
 		requeue:              make(map[string]*structs.Evaluation),
@@ -146,10 +186,32 @@ func NewEvalBroker(timeout, initialNackDelay, subsequentNackDelay time.Duration,
 		delayedEvalsUpdateCh: make(chan struct{}, 1),
 	}
 	b.stats.ByScheduler = make(map[string]*SchedulerStats)
+	b.stats.ByNamespace = make(map[string]*NamespaceStats)
 
 	return b, nil
 }
 
+// SetFairQueueing configures the fair queueing of the broker. The weights
+// are the weights of the namespaces in the round-robin, and maxReadyPerJob
+// caps the number of ready evaluations of a job, zero disabling the cap. The
+// evaluations of dispatched and periodic child jobs count against their
+// parent job. It must be called before the broker is enabled.
+func (b *EvalBroker) SetFairQueueing(enabled bool, weights map[string]int, maxReadyPerJob int) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.fairQueueing = enabled
+	b.namespaceWeights = weights
+	b.maxReadyPerJob = maxReadyPerJob
+}
+
+// FairQueueing returns whether fair queueing is enabled and the cap on the
+// ready evaluations of a job.
+func (b *EvalBroker) FairQueueing() (bool, int) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	return b.fairQueueing, b.maxReadyPerJob
+}
+
 // Enabled is used to check if the broker is enabled.
 func (b *EvalBroker) Enabled() bool {
 	b.l.RLock()
@@ -287,18 +349,47 @@ func (b *EvalBroker) enqueueLocked(eval *structs.Evaluation, queue string) {
 		return
 	}
 
-	// Find the pending by scheduler class
-	pending, ok := b.ready[queue]
+	// Hold the evaluation back if its job has reached its cap of ready
+	// evaluations
+	if b.capsJobs(queue) {
+		jobKey := fairJobKey(eval)
+		if b.readyJobs[jobKey] >= b.maxReadyPerJob {
+			throttled := b.throttled[jobKey]
+			heap.Push(&throttled, eval)
+			b.throttled[jobKey] = throttled
+			b.stats.TotalThrottled += 1
+			b.namespaceStats(eval.Namespace).Throttled += 1
+			return
+		}
+	}
+
+	b.readyLocked(eval, queue)
+}
+
+// readyLocked is used to push an evaluation onto the ready queue of the
+// scheduler with the lock held
+func (b *EvalBroker) readyLocked(eval *structs.Evaluation, queue string) {
+	// Find the pending by scheduler class and namespace
+	byNamespace, ok := b.ready[queue]
 	if !ok {
-		pending = make([]*structs.Evaluation, 0, 16)
+		byNamespace = make(map[string]PendingEvaluations)
+		b.ready[queue] = byNamespace
 		if _, ok := b.waiting[queue]; !ok {
 			b.waiting[queue] = make(chan struct{}, 1)
 		}
 	}
+	pending, ok := byNamespace[eval.Namespace]
+	if !ok {
+		pending = make([]*structs.Evaluation, 0, 16)
+	}
 
 	// Push onto the heap
 	heap.Push(&pending, eval)
-	b.ready[queue] = pending
+	byNamespace[eval.Namespace] = pending
+	b.readyTime[eval.ID] = time.Now()
+	if b.capsJobs(queue) {
+		b.readyJobs[fairJobKey(eval)] += 1
+	}
 
 	// Update the stats
 	b.stats.TotalReady += 1
@@ -308,6 +399,7 @@ func (b *EvalBroker) enqueueLocked(eval *structs.Evaluation, queue string) {
 		b.stats.ByScheduler[queue] = bySched
 	}
 	bySched.Ready += 1
+	b.namespaceStats(eval.Namespace).Ready += 1
 
 	// Unblock any blocked dequeues
 	select {
@@ -363,24 +455,35 @@ func (b *EvalBroker) scanForSchedulers(schedulers []string) (*structs.Evaluation
 		return nil, "", fmt.Errorf("eval broker disabled")
 	}
 
+	// With fair queueing, pick the namespace to dequeue from first
+	var namespace string
+	if b.fairQueueing {
+		var ok bool
+		namespace, ok = b.nextFairNamespace(schedulers)
+		if !ok {
+			return nil, "", nil
+		}
+	}
+
 	// Scan for eligible work
+	var eligible []*structs.Evaluation
 	var eligibleSched []string
 	var eligiblePriority int
 	for _, sched := range schedulers {
-		// Get the pending queue
-		pending, ok := b.ready[sched]
-		if !ok {
-			continue
-		}
-
 		// Peek at the next item
-		ready := pending.Peek()
+		var ready *structs.Evaluation
+		if b.fairQueueing {
+			ready = b.ready[sched][namespace].Peek()
+		} else {
+			ready = b.peekReady(sched)
+		}
 		if ready == nil {
 			continue
 		}
 
 		// Add to eligible if equal or greater priority
 		if len(eligibleSched) == 0 || ready.Priority > eligiblePriority {
+			eligible = []*structs.Evaluation{ready}
 			eligibleSched = []string{sched}
 			eligiblePriority = ready.Priority
 
@@ -388,6 +491,7 @@ func (b *EvalBroker) scanForSchedulers(schedulers []string) (*structs.Evaluation
 			continue
 
 		} else if eligiblePriority == ready.Priority {
+			eligible = append(eligible, ready)
 			eligibleSched = append(eligibleSched, sched)
 		}
 	}
@@ -400,24 +504,129 @@ func (b *EvalBroker) scanForSchedulers(schedulers []string) (*structs.Evaluation
 
 	case 1:
 		// Only a single task, dequeue
-		return b.dequeueForSched(eligibleSched[0])
+		return b.dequeueForSched(eligibleSched[0], eligible[0].Namespace)
 
 	default:
 		// Multiple tasks. We pick a random task so that we fairly
 		// distribute work.
 		offset := rand.Intn(n)
-		return b.dequeueForSched(eligibleSched[offset])
+		return b.dequeueForSched(eligibleSched[offset], eligible[offset].Namespace)
+	}
+}
+
+// peekReady returns the next ready evaluation of the scheduler across all
+// namespaces. This assumes locks are held.
+func (b *EvalBroker) peekReady(sched string) *structs.Evaluation {
+	var next *structs.Evaluation
+	for _, pending := range b.ready[sched] {
+		ready := pending.Peek()
+		if ready == nil {
+			continue
+		}
+		if next == nil || (PendingEvaluations{ready, next}).Less(0, 1) {
+			next = ready
+		}
 	}
+	return next
 }
 
-// dequeueForSched is used to dequeue the next work item for a given scheduler.
-// This assumes locks are held and that this scheduler has work
-func (b *EvalBroker) dequeueForSched(sched string) (*structs.Evaluation, string, error) {
+// nextFairNamespace picks the namespace to dequeue from among the namespaces
+// with ready work for the schedulers using a smooth weighted round-robin:
+// every namespace is credited its weight, and the namespace with the most
+// credits is picked and debited the total weight. It returns false if there
+// is no ready work. This assumes locks are held.
+func (b *EvalBroker) nextFairNamespace(schedulers []string) (string, bool) {
+	active := make(map[string]struct{})
+	for _, sched := range schedulers {
+		for namespace, pending := range b.ready[sched] {
+			if len(pending) != 0 {
+				active[namespace] = struct{}{}
+			}
+		}
+	}
+	if len(active) == 0 {
+		return "", false
+	}
+
+	// Sort the namespaces so ties are broken deterministically
+	namespaces := make([]string, 0, len(active))
+	for namespace := range active {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var picked string
+	total := 0
+	for i, namespace := range namespaces {
+		weight := b.namespaceWeight(namespace)
+		total += weight
+		b.namespaceCredits[namespace] += weight
+		if i == 0 || b.namespaceCredits[namespace] > b.namespaceCredits[picked] {
+			picked = namespace
+		}
+	}
+	b.namespaceCredits[picked] -= total
+	return picked, true
+}
+
+// namespaceWeight returns the weight of the namespace in the round-robin
+func (b *EvalBroker) namespaceWeight(namespace string) int {
+	if weight, ok := b.namespaceWeights[namespace]; ok && weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// capsJobs returns whether the ready evaluations of the queue are capped by
+// job
+func (b *EvalBroker) capsJobs(queue string) bool {
+	return b.fairQueueing && b.maxReadyPerJob > 0 && queue != failedQueue
+}
+
+// fairJobKey returns the job the evaluation counts against for the cap of
+// ready evaluations. The evaluations of dispatched and periodic child jobs
+// count against their parent job.
+func fairJobKey(eval *structs.Evaluation) structs.NamespacedID {
+	jobID := eval.JobID
+	for _, suffix := range []string{structs.DispatchLaunchSuffix, structs.PeriodicLaunchSuffix} {
+		if i := strings.LastIndex(jobID, suffix); i > 0 {
+			jobID = jobID[:i]
+			break
+		}
+	}
+	return structs.NamespacedID{
+		ID:        jobID,
+		Namespace: eval.Namespace,
+	}
+}
+
+// namespaceStats returns the stats of the namespace, creating them if needed.
+// This assumes locks are held.
+func (b *EvalBroker) namespaceStats(namespace string) *NamespaceStats {
+	byNamespace, ok := b.stats.ByNamespace[namespace]
+	if !ok {
+		byNamespace = &NamespaceStats{}
+		b.stats.ByNamespace[namespace] = byNamespace
+	}
+	return byNamespace
+}
+
+// dequeueForSched is used to dequeue the next work item of a namespace for a
+// given scheduler. This assumes locks are held and that this scheduler has
+// work for the namespace.
+func (b *EvalBroker) dequeueForSched(sched, namespace string) (*structs.Evaluation, string, error) {
 	// Get the pending queue
-	pending := b.ready[sched]
+	byNamespace := b.ready[sched]
+	pending := byNamespace[namespace]
 	raw := heap.Pop(&pending)
-	b.ready[sched] = pending
+	if len(pending) != 0 {
+		byNamespace[namespace] = pending
+	} else {
+		delete(byNamespace, namespace)
+		b.pruneNamespaceCredits(namespace)
+	}
 	eval := raw.(*structs.Evaluation)
+	delete(b.readyTime, eval.ID)
 
 	// Generate a UUID for the token
 	token := uuid.Generate()
@@ -443,10 +652,52 @@ func (b *EvalBroker) dequeueForSched(sched string) (*structs.Evaluation, string,
 	bySched := b.stats.ByScheduler[sched]
 	bySched.Ready -= 1
 	bySched.Unacked += 1
+	nsStats := b.namespaceStats(namespace)
+	nsStats.Ready -= 1
+	nsStats.Unacked += 1
+
+	// Free the slot of the job and make its next throttled evaluation ready
+	if b.capsJobs(sched) {
+		b.releaseThrottled(fairJobKey(eval))
+	}
 
 	return eval, token, nil
 }
 
+// pruneNamespaceCredits forgets the credits of the namespace once it has no
+// ready work left for any scheduler. This assumes locks are held.
+func (b *EvalBroker) pruneNamespaceCredits(namespace string) {
+	for _, byNamespace := range b.ready {
+		if len(byNamespace[namespace]) != 0 {
+			return
+		}
+	}
+	delete(b.namespaceCredits, namespace)
+}
+
+// releaseThrottled is used to free a ready evaluation slot of the job and
+// make its next throttled evaluation ready with the lock held
+func (b *EvalBroker) releaseThrottled(jobKey structs.NamespacedID) {
+	if b.readyJobs[jobKey] -= 1; b.readyJobs[jobKey] <= 0 {
+		delete(b.readyJobs, jobKey)
+	}
+
+	throttled := b.throttled[jobKey]
+	if len(throttled) == 0 {
+		return
+	}
+	raw := heap.Pop(&throttled)
+	if len(throttled) > 0 {
+		b.throttled[jobKey] = throttled
+	} else {
+		delete(b.throttled, jobKey)
+	}
+	eval := raw.(*structs.Evaluation)
+	b.stats.TotalThrottled -= 1
+	b.namespaceStats(eval.Namespace).Throttled -= 1
+	b.readyLocked(eval, eval.Type)
+}
+
 // waitForSchedulers is used to wait for work on any of the scheduler or until a timeout.
 // Returns if there is work waiting potentially.
 func (b *EvalBroker) waitForSchedulers(schedulers []string, timeoutCh <-chan time.Time) bool {
@@ -550,6 +801,7 @@ func (b *EvalBroker) Ack(evalID, token string) error {
 	}
 	bySched := b.stats.ByScheduler[queue]
 	bySched.Unacked -= 1
+	b.namespaceStats(unack.Eval.Namespace).Unacked -= 1
 
 	// Cleanup
 	delete(b.unack, evalID)
@@ -610,6 +862,7 @@ func (b *EvalBroker) Nack(evalID, token string) error {
 	b.stats.TotalUnacked -= 1
 	bySched := b.stats.ByScheduler[unack.Eval.Type]
 	bySched.Unacked -= 1
+	b.namespaceStats(unack.Eval.Namespace).Unacked -= 1
 
 	// Check if we've hit the delivery limit, and re-enqueue
 	// in the failedQueue
@@ -712,11 +965,17 @@ func (b *EvalBroker) flush() {
 	b.stats.TotalUnacked = 0
 	b.stats.TotalBlocked = 0
 	b.stats.TotalWaiting = 0
+	b.stats.TotalThrottled = 0
 	b.stats.ByScheduler = make(map[string]*SchedulerStats)
+	b.stats.ByNamespace = make(map[string]*NamespaceStats)
 	b.evals = make(map[string]int)
 	b.jobEvals = make(map[structs.NamespacedID]string)
 	b.blocked = make(map[structs.NamespacedID]PendingEvaluations)
-	b.ready = make(map[string]PendingEvaluations)
+	b.ready = make(map[string]map[string]PendingEvaluations)
+	b.readyTime = make(map[string]time.Time)
+	b.namespaceCredits = make(map[string]int)
+	b.readyJobs = make(map[structs.NamespacedID]int)
+	b.throttled = make(map[structs.NamespacedID]PendingEvaluations)
 	b.unack = make(map[string]*unackEval)
 	b.timeWait = make(map[string]*time.Timer)
 	b.delayHeap = lib.NewDelayHeap()
@@ -795,6 +1054,7 @@ func (b *EvalBroker) Stats() *BrokerStats {
 	// Allocate a new stats struct
 	stats := new(BrokerStats)
 	stats.ByScheduler = make(map[string]*SchedulerStats)
+	stats.ByNamespace = make(map[string]*NamespaceStats)
 
 	b.l.RLock()
 	defer b.l.RUnlock()
@@ -804,11 +1064,34 @@ func (b *EvalBroker) Stats() *BrokerStats {
 	stats.TotalUnacked = b.stats.TotalUnacked
 	stats.TotalBlocked = b.stats.TotalBlocked
 	stats.TotalWaiting = b.stats.TotalWaiting
+	stats.TotalThrottled = b.stats.TotalThrottled
 	for sched, subStat := range b.stats.ByScheduler {
 		subStatCopy := new(SchedulerStats)
 		*subStatCopy = *subStat
 		stats.ByScheduler[sched] = subStatCopy
 	}
+	for namespace, subStat := range b.stats.ByNamespace {
+		subStatCopy := new(NamespaceStats)
+		*subStatCopy = *subStat
+		subStatCopy.Weight = b.namespaceWeight(namespace)
+		stats.ByNamespace[namespace] = subStatCopy
+	}
+
+	// Find how long the oldest ready evaluation of each namespace has waited
+	now := time.Now()
+	for _, byNamespace := range b.ready {
+		for namespace, pending := range byNamespace {
+			subStat, ok := stats.ByNamespace[namespace]
+			if !ok {
+				continue
+			}
+			for _, eval := range pending {
+				if wait := now.Sub(b.readyTime[eval.ID]); wait > subStat.ReadyWait {
+					subStat.ReadyWait = wait
+				}
+			}
+		}
+	}
 	return stats
 }
 
@@ -822,10 +1105,17 @@ func (b *EvalBroker) EmitStats(period time.Duration, stopCh chan struct{}) {
 			metrics.SetGauge([]string{"nomad", "broker", "total_unacked"}, float32(stats.TotalUnacked))
 			metrics.SetGauge([]string{"nomad", "broker", "total_blocked"}, float32(stats.TotalBlocked))
 			metrics.SetGauge([]string{"nomad", "broker", "total_waiting"}, float32(stats.TotalWaiting))
+			metrics.SetGauge([]string{"nomad", "broker", "total_throttled"}, float32(stats.TotalThrottled))
 			for sched, schedStats := range stats.ByScheduler {
 				metrics.SetGauge([]string{"nomad", "broker", sched, "ready"}, float32(schedStats.Ready))
 				metrics.SetGauge([]string{"nomad", "broker", sched, "unacked"}, float32(schedStats.Unacked))
 			}
+			for namespace, nsStats := range stats.ByNamespace {
+				metrics.SetGauge([]string{"nomad", "broker", "namespace", namespace, "ready"}, float32(nsStats.Ready))
+				metrics.SetGauge([]string{"nomad", "broker", "namespace", namespace, "unacked"}, float32(nsStats.Unacked))
+				metrics.SetGauge([]string{"nomad", "broker", "namespace", namespace, "throttled"}, float32(nsStats.Throttled))
+				metrics.SetGauge([]string{"nomad", "broker", "namespace", namespace, "wait_time"}, float32(nsStats.ReadyWait.Seconds()*1000))
+			}
 
 		case <-stopCh:
 			return
@@ -835,11 +1125,13 @@ func (b *EvalBroker) EmitStats(period time.Duration, stopCh chan struct{}) {
 
 // BrokerStats returns all the stats about the broker
 type BrokerStats struct {
-	TotalReady   int
-	TotalUnacked int
-	TotalBlocked int
-	TotalWaiting int
-	ByScheduler  map[string]*SchedulerStats
+	TotalReady     int
+	TotalUnacked   int
+	TotalBlocked   int
+	TotalWaiting   int
+	TotalThrottled int
+	ByScheduler    map[string]*SchedulerStats
+	ByNamespace    map[string]*NamespaceStats
 }
 
 // SchedulerStats returns the stats per scheduler
@@ -848,6 +1140,21 @@ type SchedulerStats struct {
 	Unacked int
 }
 
+// NamespaceStats returns the stats per namespace
+type NamespaceStats struct {
+	Ready     int
+	Unacked   int
+	Throttled int
+
+	// Weight is the weight of the namespace in the fair queueing
+	// round-robin
+	Weight int
+
+	// ReadyWait is how long the oldest ready evaluation of the namespace
+	// has waited to be dequeued
+	ReadyWait time.Duration
+}
+
 // Len is for the sorting interface
 func (p PendingEvaluations) Len() int {
 	return len(p)
@@ -1384,3 +1384,121 @@ func TestEvalBroker_NamespacedJobs(t *testing.T) {
 	require.Equal(1, len(b.blocked))
 
 }
+
+// Test that fair queueing alternates between the namespaces with ready work
+// regardless of the priority of their evaluations
+func TestEvalBroker_FairQueueing(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	b := testBroker(t, 0)
+	b.SetFairQueueing(true, nil, 0)
+	b.SetEnabled(true)
+
+	for i := 0; i < 4; i++ {
+		eval := mock.Eval()
+		eval.Namespace = "busy"
+		eval.Priority = 80
+		b.Enqueue(eval)
+	}
+	for i := 0; i < 2; i++ {
+		eval := mock.Eval()
+		eval.Namespace = "quiet"
+		eval.Priority = 20
+		b.Enqueue(eval)
+	}
+
+	var namespaces []string
+	for i := 0; i < 6; i++ {
+		out, _, err := b.Dequeue(defaultSched, time.Second)
+		require.Nil(err)
+		require.NotNil(out)
+		namespaces = append(namespaces, out.Namespace)
+	}
+	require.Equal([]string{"busy", "quiet", "busy", "quiet", "busy", "busy"}, namespaces)
+}
+
+func TestEvalBroker_FairQueueing_Weights(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	b := testBroker(t, 0)
+	b.SetFairQueueing(true, map[string]int{"heavy": 2}, 0)
+	b.SetEnabled(true)
+
+	for i := 0; i < 6; i++ {
+		for _, namespace := range []string{"heavy", "light"} {
+			eval := mock.Eval()
+			eval.Namespace = namespace
+			b.Enqueue(eval)
+		}
+	}
+
+	var namespaces []string
+	for i := 0; i < 6; i++ {
+		out, _, err := b.Dequeue(defaultSched, time.Second)
+		require.Nil(err)
+		require.NotNil(out)
+		namespaces = append(namespaces, out.Namespace)
+	}
+	require.Equal([]string{"heavy", "light", "heavy", "heavy", "light", "heavy"}, namespaces)
+
+	stats := b.Stats()
+	require.Equal(2, stats.ByNamespace["heavy"].Weight)
+	require.Equal(1, stats.ByNamespace["light"].Weight)
+	require.Equal(2, stats.ByNamespace["heavy"].Ready)
+	require.Equal(4, stats.ByNamespace["light"].Ready)
+	require.Equal(4, stats.ByNamespace["heavy"].Unacked)
+	require.NotZero(stats.ByNamespace["light"].ReadyWait)
+}
+
+// Test that the evaluations of the dispatched children of a job count against
+// the cap of ready evaluations of the parent job
+func TestEvalBroker_FairQueueing_MaxReadyPerJob(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	b := testBroker(t, 0)
+	b.SetFairQueueing(true, nil, 2)
+	b.SetEnabled(true)
+
+	var children []*structs.Evaluation
+	for i := 0; i < 5; i++ {
+		eval := mock.Eval()
+		eval.JobID = structs.DispatchedID("parent", time.Now())
+		eval.Priority = 80
+		eval.CreateIndex = uint64(i + 1)
+		b.Enqueue(eval)
+		children = append(children, eval)
+	}
+	other := mock.Eval()
+	b.Enqueue(other)
+
+	stats := b.Stats()
+	require.Equal(3, stats.TotalReady)
+	require.Equal(3, stats.TotalThrottled)
+	require.Equal(3, stats.ByNamespace[other.Namespace].Throttled)
+
+	// Dequeuing a child makes the next throttled one ready
+	out, token, err := b.Dequeue(defaultSched, time.Second)
+	require.Nil(err)
+	require.Equal(children[0].ID, out.ID)
+	stats = b.Stats()
+	require.Equal(3, stats.TotalReady)
+	require.Equal(2, stats.TotalThrottled)
+	require.Nil(b.Ack(out.ID, token))
+
+	// All the evaluations are eventually dequeued
+	seen := map[string]struct{}{out.ID: {}}
+	for i := 0; i < 5; i++ {
+		out, token, err := b.Dequeue(defaultSched, time.Second)
+		require.Nil(err)
+		require.NotNil(out)
+		seen[out.ID] = struct{}{}
+		require.Nil(b.Ack(out.ID, token))
+	}
+	require.Len(seen, 6)
+	require.Contains(seen, other.ID)
+
+	stats = b.Stats()
+	require.Zero(stats.TotalReady)
+	require.Zero(stats.TotalThrottled)
+	require.Zero(stats.TotalUnacked)
+}
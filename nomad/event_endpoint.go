@@ -0,0 +1,210 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// eventStreamHeartbeatInterval is the interval at which an empty object
+	// is sent to the subscribers of the event stream when there are no
+	// events, detecting closed connections. The tokens of the subscribers
+	// are resolved again at the same interval.
+	eventStreamHeartbeatInterval = 10 * time.Second
+)
+
+// Event endpoint is used to stream the events of the changes applied to the
+// state of the cluster.
+type Event struct {
+	srv *Server
+
+	// heartbeatInterval is the interval at which heartbeats are sent to the
+	// subscribers and their tokens are resolved again
+	heartbeatInterval time.Duration
+}
+
+func (e *Event) register() {
+	e.srv.streamingRpcs.Register("Event.Stream", e.stream)
+}
+
+// handleStreamResultError is a helper for sending an error with a potential
+// error code. The transmission of the error is ignored if the error has been
+// generated by the closing of the underlying transport.
+func (e *Event) handleStreamResultError(err error, code *int64, encoder *codec.Encoder) {
+	// Nothing to do as the conn is closed
+	if err == io.EOF || strings.Contains(err.Error(), "closed") {
+		return
+	}
+
+	// Attempt to send the error
+	encoder.Encode(&cstructs.StreamErrWrapper{
+		Error: cstructs.NewRpcError(err, code),
+	})
+}
+
+// stream streams the events matching the request as newline delimited JSON.
+// Each line holds the events of a Raft index. An empty object is sent once
+// subscribed and as a heartbeat when there are no events.
+func (e *Event) stream(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	defer metrics.MeasureSince([]string{"nomad", "event", "stream"}, time.Now())
+
+	// Decode the arguments
+	var args structs.EventStreamRequest
+	decoder := codec.NewDecoder(conn, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(conn, structs.MsgpackHandle)
+
+	if err := decoder.Decode(&args); err != nil {
+		e.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+
+	// Potentially forward to a different region.
+	if region := args.RequestRegion(); region != "" && region != e.srv.Region() {
+		e.forwardRegionStreamingRpc(conn, encoder, &args)
+		return
+	}
+
+	// The key is looked up before resolving the token so that a change in
+	// between is detected when the token is resolved again
+	aclKey, err := e.srv.resolveTokenACLKey(args.AuthToken)
+	if err != nil {
+		e.handleStreamResultError(err, nil, encoder)
+		return
+	}
+	aclObj, err := e.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		e.handleStreamResultError(err, nil, encoder)
+		return
+	}
+
+	if args.Namespace == "" {
+		args.Namespace = structs.DefaultNamespace
+	}
+
+	sub, err := e.srv.eventBroker.Subscribe(&args, aclObj)
+	if err != nil {
+		e.handleStreamResultError(err, helper.Int64ToPtr(400), encoder)
+		return
+	}
+
+	// Stop streaming when the subscriber closes the connection or the server
+	// shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		cancel()
+	}()
+	go func() {
+		select {
+		case <-e.srv.shutdownCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Acknowledge the subscription
+	heartbeat := []byte("{}\n")
+	if err := encoder.Encode(&cstructs.StreamErrWrapper{Payload: heartbeat}); err != nil {
+		e.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+
+	nextResolve := time.Now().Add(e.heartbeatInterval)
+	for {
+		// Stop streaming once the token is deleted or what it is allowed to
+		// read changes, as the subscription filters with the token's ACL
+		if now := time.Now(); !now.Before(nextResolve) {
+			nextResolve = now.Add(e.heartbeatInterval)
+			key, err := e.srv.resolveTokenACLKey(args.AuthToken)
+			if err == nil && key != aclKey {
+				err = structs.ErrPermissionDenied
+			}
+			if err != nil {
+				code := helper.Int64ToPtr(403)
+				if err != structs.ErrTokenNotFound && err != structs.ErrPermissionDenied {
+					code = helper.Int64ToPtr(500)
+				}
+				e.handleStreamResultError(err, code, encoder)
+				return
+			}
+		}
+
+		nextCtx, nextCancel := context.WithTimeout(ctx, e.heartbeatInterval)
+		events, err := sub.Next(nextCtx)
+		nextCancel()
+
+		payload := heartbeat
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if err != context.DeadlineExceeded {
+				e.handleStreamResultError(err, helper.Int64ToPtr(400), encoder)
+				return
+			}
+		} else {
+			var buf bytes.Buffer
+			if err := codec.NewEncoder(&buf, structs.JsonHandle).Encode(events); err != nil {
+				e.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+				return
+			}
+			buf.WriteByte('\n')
+			payload = buf.Bytes()
+		}
+
+		if err := encoder.Encode(&cstructs.StreamErrWrapper{Payload: payload}); err != nil {
+			e.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+			return
+		}
+	}
+}
+
+// forwardRegionStreamingRpc is used to forward the event stream to a server
+// of a different region.
+func (e *Event) forwardRegionStreamingRpc(conn io.ReadWriteCloser, encoder *codec.Encoder, args *structs.EventStreamRequest) {
+	region := args.RequestRegion()
+
+	e.srv.peerLock.RLock()
+	servers := e.srv.peers[region]
+	var server *serverParts
+	if len(servers) != 0 {
+		server = servers[rand.Intn(len(servers))]
+	}
+	e.srv.peerLock.RUnlock()
+
+	if server == nil {
+		e.srv.logger.Printf("[WARN] nomad.rpc: RPC request for region '%s', no path found", region)
+		e.handleStreamResultError(structs.ErrNoRegionPath, nil, encoder)
+		return
+	}
+
+	// Get a connection to the server
+	srvConn, err := e.srv.streamingRpc(server, "Event.Stream")
+	if err != nil {
+		e.handleStreamResultError(err, nil, encoder)
+		return
+	}
+	defer srvConn.Close()
+
+	// Send the request.
+	outEncoder := codec.NewEncoder(srvConn, structs.MsgpackHandle)
+	if err := outEncoder.Encode(args); err != nil {
+		e.handleStreamResultError(err, nil, encoder)
+		return
+	}
+
+	structs.Bridge(conn, srvConn)
+}
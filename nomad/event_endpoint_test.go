@@ -0,0 +1,190 @@
+package nomad
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+// testEventStream starts streaming events from the server and returns the
+// channel of the received messages
+func testEventStream(t *testing.T, s *Server, req *structs.EventStreamRequest) <-chan *cstructs.StreamErrWrapper {
+	handler, err := s.StreamingRpcHandler("Event.Stream")
+	require.NoError(t, err)
+
+	p1, p2 := net.Pipe()
+	go handler(p2)
+
+	msgCh := make(chan *cstructs.StreamErrWrapper, 10)
+	go func() {
+		defer close(msgCh)
+		decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+		for {
+			var msg cstructs.StreamErrWrapper
+			if err := decoder.Decode(&msg); err != nil {
+				return
+			}
+			msgCh <- &msg
+		}
+	}()
+
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+	require.NoError(t, encoder.Encode(req))
+	return msgCh
+}
+
+func testEventStreamMsg(t *testing.T, msgCh <-chan *cstructs.StreamErrWrapper) *cstructs.StreamErrWrapper {
+	select {
+	case msg, ok := <-msgCh:
+		require.True(t, ok, "stream closed")
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for events")
+	}
+	return nil
+}
+
+func TestEvent_Stream_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s, _ := TestACLServer(t, nil)
+	defer s.Shutdown()
+	testutil.WaitForLeader(t, s.RPC)
+
+	// The token can read the jobs of the default namespace but not the nodes
+	policy := mock.NamespacePolicy(structs.DefaultNamespace, "read", nil)
+	token := mock.CreatePolicyAndToken(t, s.State(), 1001, "job-read", policy)
+
+	msgCh := testEventStream(t, s, &structs.EventStreamRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: token.SecretID,
+		},
+	})
+
+	// The subscription is acknowledged
+	msg := testEventStreamMsg(t, msgCh)
+	require.Nil(msg.Error)
+	require.Equal("{}\n", string(msg.Payload))
+
+	_, _, err := s.raftApply(structs.NodeRegisterRequestType, &structs.NodeRegisterRequest{Node: mock.Node()})
+	require.NoError(err)
+	eval := mock.Eval()
+	_, _, err = s.raftApply(structs.EvalUpdateRequestType, &structs.EvalUpdateRequest{
+		Evals: []*structs.Evaluation{eval},
+	})
+	require.NoError(err)
+
+	// Only the event of the evaluation is received
+	msg = testEventStreamMsg(t, msgCh)
+	require.Nil(msg.Error)
+	var events struct {
+		Events []struct {
+			Topic structs.Topic
+			Key   string
+		}
+	}
+	require.NoError(json.Unmarshal(msg.Payload, &events))
+	require.Len(events.Events, 1)
+	require.Equal(structs.TopicEvaluation, events.Events[0].Topic)
+	require.Equal(eval.ID, events.Events[0].Key)
+}
+
+func TestEvent_Stream_UnknownToken(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s, _ := TestACLServer(t, nil)
+	defer s.Shutdown()
+	testutil.WaitForLeader(t, s.RPC)
+
+	msgCh := testEventStream(t, s, &structs.EventStreamRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: uuid.Generate(),
+		},
+	})
+
+	msg := testEventStreamMsg(t, msgCh)
+	require.NotNil(msg.Error)
+	require.Contains(msg.Error.Error(), structs.ErrTokenNotFound.Error())
+}
+
+func TestEvent_Stream_TokenChanged(t *testing.T) {
+	t.Parallel()
+
+	policy := mock.NamespacePolicy(structs.DefaultNamespace, "read", nil)
+
+	cases := []struct {
+		name   string
+		change func(s *Server, token *structs.ACLToken) error
+		err    error
+	}{
+		{
+			name: "token deleted",
+			change: func(s *Server, token *structs.ACLToken) error {
+				return s.State().DeleteACLTokens(1010, []string{token.AccessorID})
+			},
+			err: structs.ErrTokenNotFound,
+		},
+		{
+			name: "policy changed",
+			change: func(s *Server, token *structs.ACLToken) error {
+				p := mock.ACLPolicy()
+				p.Name = token.Policies[0]
+				p.Rules = mock.NodePolicy("read")
+				return s.State().UpsertACLPolicies(1010, []*structs.ACLPolicy{p})
+			},
+			err: structs.ErrPermissionDenied,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			require := require.New(t)
+
+			s, _ := TestACLServer(t, nil)
+			defer s.Shutdown()
+			testutil.WaitForLeader(t, s.RPC)
+			s.staticEndpoints.Event.heartbeatInterval = 100 * time.Millisecond
+
+			token := mock.CreatePolicyAndToken(t, s.State(), 1001, "job-read", policy)
+			msgCh := testEventStream(t, s, &structs.EventStreamRequest{
+				QueryOptions: structs.QueryOptions{
+					Region:    "global",
+					AuthToken: token.SecretID,
+				},
+			})
+
+			// The stream is kept open while the token is unchanged
+			for i := 0; i < 3; i++ {
+				msg := testEventStreamMsg(t, msgCh)
+				require.Nil(msg.Error)
+			}
+
+			require.NoError(c.change(s, token))
+
+			for {
+				msg := testEventStreamMsg(t, msgCh)
+				if msg.Error == nil {
+					continue
+				}
+				require.Contains(msg.Error.Error(), c.err.Error())
+				require.EqualValues(403, *msg.Error.Code)
+				break
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/stream"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/scheduler"
 	"github.com/hashicorp/raft"
@@ -44,6 +45,7 @@ const (
 	DeploymentSnapshot
 	ACLPolicySnapshot
 	ACLTokenSnapshot
+	SchedulerConfigSnapshot
 )
 
 // LogApplier is the definition of a function that can apply a Raft log
@@ -67,6 +69,7 @@ type nomadFSM struct {
 	evalBroker         *EvalBroker
 	blockedEvals       *BlockedEvals
 	periodicDispatcher *PeriodicDispatch
+	eventBroker        *stream.EventBroker
 	logger             *log.Logger
 	state              *state.StateStore
 	timetable          *TimeTable
@@ -112,6 +115,10 @@ type FSMConfig struct {
 	// be added to.
 	Blocked *BlockedEvals
 
+	// EventBroker is the broker the events of the applied changes are
+	// published to. Events aren't published if it is nil.
+	EventBroker *stream.EventBroker
+
 	// LogOutput is the writer logs should be written to
 	LogOutput io.Writer
 
@@ -135,6 +142,7 @@ func NewFSM(config *FSMConfig) (*nomadFSM, error) {
 		evalBroker:          config.EvalBroker,
 		periodicDispatcher:  config.Periodic,
 		blockedEvals:        config.Blocked,
+		eventBroker:         config.EventBroker,
 		logger:              log.New(config.LogOutput, "", log.LstdFlags),
 		config:              config,
 		state:               state,
@@ -169,7 +177,7 @@ func (n *nomadFSM) TimeTable() *TimeTable {
 	return n.timetable
 }
 
-func (n *nomadFSM) Apply(log *raft.Log) interface{} {
+func (n *nomadFSM) Apply(log *raft.Log) (resp interface{}) {
 	buf := log.Data
 	msgType := structs.MessageType(buf[0])
 
@@ -185,6 +193,13 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		ignoreUnknown = true
 	}
 
+	// Publish the events of the change once it is applied
+	defer func() {
+		if _, ok := resp.(error); !ok {
+			n.publishEvents(msgType, buf[1:], log.Index)
+		}
+	}()
+
 	switch msgType {
 	case structs.NodeRegisterRequestType:
 		return n.applyUpsertNode(buf[1:], log.Index)
@@ -246,6 +261,8 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		return n.applyNodeEligibilityUpdate(buf[1:], log.Index)
 	case structs.BatchNodeUpdateDrainRequestType:
 		return n.applyBatchDrainUpdate(buf[1:], log.Index)
+	case structs.SchedulerConfigRequestType:
+		return n.applySchedulerConfigUpdate(buf[1:], log.Index)
 	}
 
 	// Check enterprise only message types.
@@ -582,6 +599,13 @@ func (n *nomadFSM) upsertEvals(index uint64, evals []*structs.Evaluation) error
 		return err
 	}
 
+	n.handleUpsertedEvals(evals)
+	return nil
+}
+
+// handleUpsertedEvals is a helper for taking action after upserting
+// evaluations.
+func (n *nomadFSM) handleUpsertedEvals(evals []*structs.Evaluation) {
 	for _, eval := range evals {
 		if eval.ShouldEnqueue() {
 			n.evalBroker.Enqueue(eval)
@@ -594,7 +618,6 @@ func (n *nomadFSM) upsertEvals(index uint64, evals []*structs.Evaluation) error
 			n.blockedEvals.Untrack(eval.JobID)
 		}
 	}
-	return nil
 }
 
 func (n *nomadFSM) applyDeleteEval(buf []byte, index uint64) interface{} {
@@ -809,6 +832,8 @@ func (n *nomadFSM) applyPlanResults(buf []byte, index uint64) interface{} {
 		return err
 	}
 
+	// Add evals for jobs that had allocations preempted
+	n.handleUpsertedEvals(req.PreemptionEvals)
 	return nil
 }
 
@@ -998,6 +1023,29 @@ func (n *nomadFSM) applyAutopilotUpdate(buf []byte, index uint64) interface{} {
 	return n.state.AutopilotSetConfig(index, &req.Config)
 }
 
+func (n *nomadFSM) applySchedulerConfigUpdate(buf []byte, index uint64) interface{} {
+	var req structs.SchedulerSetConfigRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_scheduler_config"}, time.Now())
+
+	if req.CAS {
+		act, err := n.state.SchedulerCASConfig(index, req.Config.ModifyIndex, &req.Config)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: SchedulerCASConfig failed: %v", err)
+			return err
+		}
+		return act
+	}
+
+	if err := n.state.SchedulerSetConfig(index, &req.Config); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: SchedulerSetConfig failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (n *nomadFSM) Snapshot() (raft.FSMSnapshot, error) {
 	// Create a new snapshot
 	snap, err := n.state.Snapshot()
@@ -1218,6 +1266,15 @@ func (n *nomadFSM) Restore(old io.ReadCloser) error {
 				return err
 			}
 
+		case SchedulerConfigSnapshot:
+			config := new(structs.SchedulerConfiguration)
+			if err := dec.Decode(config); err != nil {
+				return err
+			}
+			if err := restore.SchedulerConfigRestore(config); err != nil {
+				return err
+			}
+
 		default:
 			// Check if this is an enterprise only object being restored
 			restorer, ok := n.enterpriseRestorers[snapType]
@@ -1270,6 +1327,16 @@ func (n *nomadFSM) Restore(old io.ReadCloser) error {
 	// blocking queries won't see any changes and need to be woken up.
 	stateOld.Abandon()
 
+	// The events of the changes included in the snapshot are no longer
+	// available
+	if n.eventBroker != nil {
+		latestIndex, err := newState.LatestIndex()
+		if err != nil {
+			return fmt.Errorf("unable to query latest index: %v", err)
+		}
+		n.eventBroker.Reset(latestIndex)
+	}
+
 	return nil
 }
 
@@ -1442,6 +1509,10 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistSchedulerConfig(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	if err := s.persistEnterpriseTables(sink, encoder); err != nil {
 		sink.Cancel()
 		return err
@@ -1778,6 +1849,25 @@ func (s *nomadSnapshot) persistACLTokens(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistSchedulerConfig(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get the scheduler config
+	_, config, err := s.snap.SchedulerConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+
+	// Write out the scheduler config
+	sink.Write([]byte{byte(SchedulerConfigSnapshot)})
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Release is a no-op, as we just need to GC the pointer
 // to the state store snapshot. There is nothing to explicitly
 // cleanup.
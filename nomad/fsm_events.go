@@ -0,0 +1,334 @@
+package nomad
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// publishEvents publishes the events of a Raft log applied at the given index
+// to the event broker. The objects of the events are read back from the
+// state so they reflect the applied change.
+func (n *nomadFSM) publishEvents(msgType structs.MessageType, buf []byte, index uint64) {
+	if n.eventBroker == nil {
+		return
+	}
+
+	e := &fsmEvents{state: n.state, index: index}
+	if err := e.build(msgType, buf); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: failed to build the events of index %d: %v", index, err)
+		return
+	}
+	n.eventBroker.Publish(&structs.Events{Index: index, Events: e.events})
+}
+
+// fsmEvents builds the events of a Raft log
+type fsmEvents struct {
+	state  *state.StateStore
+	index  uint64
+	events []structs.Event
+}
+
+func (e *fsmEvents) build(msgType structs.MessageType, buf []byte) error {
+	switch msgType {
+	case structs.NodeRegisterRequestType:
+		var req structs.NodeRegisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.node(structs.TypeNodeRegistration, req.Node.ID)
+	case structs.NodeDeregisterRequestType:
+		var req structs.NodeDeregisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		e.add(structs.TopicNode, structs.TypeNodeDeregistration, req.NodeID, "", nil,
+			&structs.NodeStreamEvent{Node: &structs.Node{ID: req.NodeID}})
+	case structs.NodeUpdateStatusRequestType:
+		var req structs.NodeUpdateStatusRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.node(structs.TypeNodeStatusUpdate, req.NodeID)
+	case structs.NodeUpdateDrainRequestType:
+		var req structs.NodeUpdateDrainRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.node(structs.TypeNodeDrain, req.NodeID)
+	case structs.BatchNodeUpdateDrainRequestType:
+		var req structs.BatchNodeUpdateDrainRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		nodeIDs := make([]string, 0, len(req.Updates))
+		for nodeID := range req.Updates {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+		sort.Strings(nodeIDs)
+		for _, nodeID := range nodeIDs {
+			if err := e.node(structs.TypeNodeDrain, nodeID); err != nil {
+				return err
+			}
+		}
+	case structs.NodeUpdateEligibilityRequestType:
+		var req structs.NodeUpdateEligibilityRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.node(structs.TypeNodeEligibility, req.NodeID)
+	case structs.JobRegisterRequestType:
+		var req structs.JobRegisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.job(structs.TypeJobRegistered, req.Job.Namespace, req.Job.ID)
+	case structs.JobDeregisterRequestType:
+		var req structs.JobDeregisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.job(structs.TypeJobDeregistered, req.Namespace, req.JobID)
+	case structs.JobBatchDeregisterRequestType:
+		var req structs.JobBatchDeregisterRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		jobs := make([]structs.NamespacedID, 0, len(req.Jobs))
+		for jobNS := range req.Jobs {
+			jobs = append(jobs, jobNS)
+		}
+		sort.Slice(jobs, func(i, j int) bool {
+			return jobs[i].String() < jobs[j].String()
+		})
+		for _, jobNS := range jobs {
+			if err := e.job(structs.TypeJobDeregistered, jobNS.Namespace, jobNS.ID); err != nil {
+				return err
+			}
+		}
+		return e.evals(req.Evals)
+	case structs.EvalUpdateRequestType:
+		var req structs.EvalUpdateRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.evals(req.Evals)
+	case structs.AllocUpdateRequestType:
+		var req structs.AllocUpdateRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		return e.allocs(req.Alloc)
+	case structs.AllocClientUpdateRequestType:
+		var req structs.AllocUpdateRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		if err := e.allocs(req.Alloc); err != nil {
+			return err
+		}
+		return e.evals(req.Evals)
+	case structs.AllocUpdateDesiredTransitionRequestType:
+		var req structs.AllocUpdateDesiredTransitionRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		allocIDs := make([]string, 0, len(req.Allocs))
+		for allocID := range req.Allocs {
+			allocIDs = append(allocIDs, allocID)
+		}
+		sort.Strings(allocIDs)
+		if err := e.allocIDs(allocIDs); err != nil {
+			return err
+		}
+		return e.evals(req.Evals)
+	case structs.ApplyPlanResultsRequestType:
+		var req structs.ApplyPlanResultsRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		if err := e.allocs(req.Alloc); err != nil {
+			return err
+		}
+		if err := e.allocs(req.NodePreemptions); err != nil {
+			return err
+		}
+		if req.Deployment != nil {
+			if err := e.deployment(structs.TypeDeploymentStatusUpdate, req.Deployment.ID); err != nil {
+				return err
+			}
+		}
+		for _, update := range req.DeploymentUpdates {
+			if err := e.deployment(structs.TypeDeploymentStatusUpdate, update.DeploymentID); err != nil {
+				return err
+			}
+		}
+		return e.evals(req.PreemptionEvals)
+	case structs.DeploymentStatusUpdateRequestType:
+		var req structs.DeploymentStatusUpdateRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		if err := e.deployment(structs.TypeDeploymentStatusUpdate, req.DeploymentUpdate.DeploymentID); err != nil {
+			return err
+		}
+		return e.deploymentJobAndEval(req.Job, req.Eval)
+	case structs.DeploymentPromoteRequestType:
+		var req structs.ApplyDeploymentPromoteRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		if err := e.deployment(structs.TypeDeploymentPromotion, req.DeploymentID); err != nil {
+			return err
+		}
+		return e.deploymentJobAndEval(nil, req.Eval)
+	case structs.DeploymentAllocHealthRequestType:
+		var req structs.ApplyDeploymentAllocHealthRequest
+		if err := structs.Decode(buf, &req); err != nil {
+			return err
+		}
+		if err := e.deployment(structs.TypeDeploymentAllocHealth, req.DeploymentID); err != nil {
+			return err
+		}
+		if err := e.allocIDs(req.HealthyAllocationIDs); err != nil {
+			return err
+		}
+		if err := e.allocIDs(req.UnhealthyAllocationIDs); err != nil {
+			return err
+		}
+		return e.deploymentJobAndEval(req.Job, req.Eval)
+	}
+	return nil
+}
+
+func (e *fsmEvents) add(topic structs.Topic, eventType, key, namespace string, filterKeys []string, payload interface{}) {
+	e.events = append(e.events, structs.Event{
+		Topic:      topic,
+		Type:       eventType,
+		Key:        key,
+		Namespace:  namespace,
+		FilterKeys: filterKeys,
+		Index:      e.index,
+		Payload:    payload,
+	})
+}
+
+// node adds an event of the node, omitting its secret ID
+func (e *fsmEvents) node(eventType, nodeID string) error {
+	node, err := e.state.NodeByID(nil, nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to lookup node %q: %v", nodeID, err)
+	}
+	if node == nil {
+		return nil
+	}
+
+	node = node.Copy()
+	node.SecretID = ""
+	e.add(structs.TopicNode, eventType, node.ID, "", nil, &structs.NodeStreamEvent{Node: node})
+	return nil
+}
+
+// job adds an event of the job. The job of a purged job only has its ID and
+// namespace.
+func (e *fsmEvents) job(eventType, namespace, jobID string) error {
+	job, err := e.state.JobByID(nil, namespace, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to lookup job %q in namespace %q: %v", jobID, namespace, err)
+	}
+	if job == nil {
+		job = &structs.Job{ID: jobID, Namespace: namespace}
+	}
+
+	e.add(structs.TopicJob, eventType, job.ID, job.Namespace, nil, &structs.JobEvent{Job: job})
+	return nil
+}
+
+// evals adds the events of the evaluations
+func (e *fsmEvents) evals(evals []*structs.Evaluation) error {
+	for _, eval := range evals {
+		stored, err := e.state.EvalByID(nil, eval.ID)
+		if err != nil {
+			return fmt.Errorf("failed to lookup evaluation %q: %v", eval.ID, err)
+		}
+		if stored == nil {
+			continue
+		}
+
+		e.add(structs.TopicEvaluation, structs.TypeEvaluationUpdated, stored.ID, stored.Namespace,
+			filterKeys(stored.JobID, stored.DeploymentID, stored.NodeID),
+			&structs.EvaluationEvent{Evaluation: stored})
+	}
+	return nil
+}
+
+// allocs adds the events of the allocations
+func (e *fsmEvents) allocs(allocs []*structs.Allocation) error {
+	allocIDs := make([]string, 0, len(allocs))
+	for _, alloc := range allocs {
+		allocIDs = append(allocIDs, alloc.ID)
+	}
+	return e.allocIDs(allocIDs)
+}
+
+// allocIDs adds the events of the allocations, omitting their job
+func (e *fsmEvents) allocIDs(allocIDs []string) error {
+	for _, allocID := range allocIDs {
+		alloc, err := e.state.AllocByID(nil, allocID)
+		if err != nil {
+			return fmt.Errorf("failed to lookup allocation %q: %v", allocID, err)
+		}
+		if alloc == nil {
+			continue
+		}
+
+		alloc = alloc.CopySkipJob()
+		alloc.Job = nil
+		e.add(structs.TopicAllocation, structs.TypeAllocationUpdated, alloc.ID, alloc.Namespace,
+			filterKeys(alloc.JobID, alloc.DeploymentID, alloc.NodeID),
+			&structs.AllocationEvent{Allocation: alloc})
+	}
+	return nil
+}
+
+// deployment adds an event of the deployment
+func (e *fsmEvents) deployment(eventType, deploymentID string) error {
+	deployment, err := e.state.DeploymentByID(nil, deploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to lookup deployment %q: %v", deploymentID, err)
+	}
+	if deployment == nil {
+		return nil
+	}
+
+	e.add(structs.TopicDeployment, eventType, deployment.ID, deployment.Namespace,
+		filterKeys(deployment.JobID), &structs.DeploymentEvent{Deployment: deployment})
+	return nil
+}
+
+// deploymentJobAndEval adds the events of the job reverted and the evaluation
+// created by a deployment update
+func (e *fsmEvents) deploymentJobAndEval(job *structs.Job, eval *structs.Evaluation) error {
+	if job != nil {
+		if err := e.job(structs.TypeJobRegistered, job.Namespace, job.ID); err != nil {
+			return err
+		}
+	}
+	if eval != nil {
+		return e.evals([]*structs.Evaluation{eval})
+	}
+	return nil
+}
+
+// filterKeys returns the keys that are set
+func filterKeys(keys ...string) []string {
+	var set []string
+	for _, key := range keys {
+		if key != "" {
+			set = append(set, key)
+		}
+	}
+	return set
+}
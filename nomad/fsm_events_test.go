@@ -0,0 +1,136 @@
+package nomad
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/stream"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSM_PublishEvents(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	fsm := testFSM(t)
+	fsm.eventBroker = stream.NewEventBroker(10)
+
+	sub, err := fsm.eventBroker.Subscribe(&structs.EventStreamRequest{
+		QueryOptions: structs.QueryOptions{Namespace: structs.EventNamespaceAll},
+	}, nil)
+	require.NoError(err)
+
+	index := uint64(100)
+	apply := func(msgType structs.MessageType, req interface{}) *structs.Events {
+		index++
+		buf, err := structs.Encode(msgType, req)
+		require.NoError(err)
+		resp := fsm.Apply(&raft.Log{Index: index, Term: 1, Type: raft.LogCommand, Data: buf})
+		if err, ok := resp.(error); ok {
+			require.NoError(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		events, err := sub.Next(ctx)
+		require.NoError(err)
+		require.Equal(index, events.Index)
+		return events
+	}
+
+	// Registering a node
+	node := mock.Node()
+	events := apply(structs.NodeRegisterRequestType, &structs.NodeRegisterRequest{Node: node})
+	require.Len(events.Events, 1)
+	event := events.Events[0]
+	require.Equal(structs.TopicNode, event.Topic)
+	require.Equal(structs.TypeNodeRegistration, event.Type)
+	require.Equal(node.ID, event.Key)
+	payload := event.Payload.(*structs.NodeStreamEvent)
+	require.Equal(node.ID, payload.Node.ID)
+	require.Empty(payload.Node.SecretID)
+
+	// Draining it
+	events = apply(structs.NodeUpdateDrainRequestType, &structs.NodeUpdateDrainRequest{
+		NodeID:        node.ID,
+		DrainStrategy: &structs.DrainStrategy{DrainSpec: structs.DrainSpec{Deadline: time.Minute}},
+	})
+	require.Len(events.Events, 1)
+	require.Equal(structs.TypeNodeDrain, events.Events[0].Type)
+	require.NotNil(events.Events[0].Payload.(*structs.NodeStreamEvent).Node.DrainStrategy)
+
+	// Registering a job
+	job := mock.Job()
+	events = apply(structs.JobRegisterRequestType, &structs.JobRegisterRequest{Job: job})
+	require.Len(events.Events, 1)
+	event = events.Events[0]
+	require.Equal(structs.TopicJob, event.Topic)
+	require.Equal(structs.TypeJobRegistered, event.Type)
+	require.Equal(job.ID, event.Key)
+	require.Equal(job.Namespace, event.Namespace)
+	require.Equal(index, event.Payload.(*structs.JobEvent).Job.ModifyIndex)
+
+	// Creating its evaluation
+	eval := mock.Eval()
+	eval.JobID = job.ID
+	events = apply(structs.EvalUpdateRequestType, &structs.EvalUpdateRequest{Evals: []*structs.Evaluation{eval}})
+	require.Len(events.Events, 1)
+	event = events.Events[0]
+	require.Equal(structs.TypeEvaluationUpdated, event.Type)
+	require.Equal(eval.ID, event.Key)
+	require.Contains(event.FilterKeys, job.ID)
+
+	// Placing an allocation
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	events = apply(structs.AllocUpdateRequestType, &structs.AllocUpdateRequest{Alloc: []*structs.Allocation{alloc}})
+	require.Len(events.Events, 1)
+	event = events.Events[0]
+	require.Equal(structs.TopicAllocation, event.Topic)
+	require.Equal(structs.TypeAllocationUpdated, event.Type)
+	require.Equal(alloc.ID, event.Key)
+	require.Contains(event.FilterKeys, job.ID)
+	require.Contains(event.FilterKeys, node.ID)
+	require.Nil(event.Payload.(*structs.AllocationEvent).Allocation.Job)
+
+	// Updating the status of a deployment
+	d := mock.Deployment()
+	d.JobID = job.ID
+	require.NoError(fsm.State().UpsertDeployment(index+1, d))
+	index++
+	events = apply(structs.DeploymentStatusUpdateRequestType, &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: d.ID,
+			Status:       structs.DeploymentStatusPaused,
+		},
+	})
+	require.Len(events.Events, 1)
+	event = events.Events[0]
+	require.Equal(structs.TypeDeploymentStatusUpdate, event.Type)
+	require.Equal(structs.DeploymentStatusPaused, event.Payload.(*structs.DeploymentEvent).Deployment.Status)
+
+	// Failed changes don't publish events
+	buf, err := structs.Encode(structs.JobDeregisterRequestType, &structs.JobDeregisterRequest{
+		JobID:        "unknown",
+		WriteRequest: structs.WriteRequest{Namespace: structs.DefaultNamespace},
+	})
+	require.NoError(err)
+	index++
+	_, ok := fsm.Apply(&raft.Log{Index: index, Term: 1, Type: raft.LogCommand, Data: buf}).(error)
+	require.True(ok)
+
+	events = apply(structs.JobDeregisterRequestType, &structs.JobDeregisterRequest{
+		JobID:        job.ID,
+		Purge:        true,
+		WriteRequest: structs.WriteRequest{Namespace: job.Namespace},
+	})
+	require.Len(events.Events, 1)
+	require.Equal(structs.TypeJobDeregistered, events.Events[0].Type)
+	require.Equal(job.ID, events.Events[0].Payload.(*structs.JobEvent).Job.ID)
+}
@@ -2777,3 +2777,71 @@ func TestFSM_Autopilot(t *testing.T) {
 		t.Fatalf("bad: %v", config.CleanupDeadServers)
 	}
 }
+
+func TestFSM_SchedulerConfig(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	fsm := testFSM(t)
+
+	// Set the scheduler config using a request.
+	req := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfiguration{
+			SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+		},
+	}
+	buf, err := structs.Encode(structs.SchedulerConfigRequestType, req)
+	require.Nil(err)
+	resp := fsm.Apply(makeLog(buf))
+	require.Nil(resp)
+
+	// Verify the config is set directly in the state store.
+	_, config, err := fsm.state.SchedulerConfig()
+	require.Nil(err)
+	require.Equal(structs.SchedulerAlgorithmSpread, config.SchedulerAlgorithm)
+	require.EqualValues(1, config.ModifyIndex)
+
+	// Try to update the config using a stale CAS index.
+	req.CAS = true
+	req.Config.SchedulerAlgorithm = structs.SchedulerAlgorithmBinpack
+	req.Config.ModifyIndex = 0
+	buf, err = structs.Encode(structs.SchedulerConfigRequestType, req)
+	require.Nil(err)
+	resp = fsm.Apply(makeLog(buf))
+	require.Equal(false, resp)
+
+	_, config, err = fsm.state.SchedulerConfig()
+	require.Nil(err)
+	require.Equal(structs.SchedulerAlgorithmSpread, config.SchedulerAlgorithm)
+
+	// Update the config using the current CAS index.
+	req.Config.ModifyIndex = 1
+	buf, err = structs.Encode(structs.SchedulerConfigRequestType, req)
+	require.Nil(err)
+	resp = fsm.Apply(makeLog(buf))
+	require.Equal(true, resp)
+
+	_, config, err = fsm.state.SchedulerConfig()
+	require.Nil(err)
+	require.Equal(structs.SchedulerAlgorithmBinpack, config.SchedulerAlgorithm)
+}
+
+func TestFSM_SnapshotRestore_SchedulerConfig(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	config := &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}
+	require.Nil(state.SchedulerSetConfig(1000, config))
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	index, out, err := state2.SchedulerConfig()
+	require.Nil(err)
+	require.EqualValues(1000, index)
+	require.Equal(config, out)
+}
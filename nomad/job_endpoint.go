@@ -70,8 +70,11 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return err
 	}
 
+	// Warn about memory limits that won't be honored
+	memoryWarnings := j.memoryOversubscriptionWarnings(args.Job)
+
 	// Set the warning message
-	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
+	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings, memoryWarnings)
 
 	// Check job submission permissions
 	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
@@ -80,6 +83,10 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
 			return structs.ErrPermissionDenied
 		}
+		// Check the host volumes of the job can be mounted
+		if !allowHostVolumes(aclObj, args.RequestNamespace(), args.Job) {
+			return structs.ErrPermissionDenied
+		}
 		// Check if override is set and we do not have permissions
 		if args.PolicyOverride {
 			if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySentinelOverride) {
@@ -167,7 +174,7 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	}
 	if policyWarnings != nil {
 		reply.Warnings = structs.MergeMultierrorWarnings(warnings,
-			canonicalizeWarnings, policyWarnings)
+			canonicalizeWarnings, memoryWarnings, policyWarnings)
 	}
 
 	// Clear the Vault token
@@ -381,8 +388,11 @@ func (j *Job) Validate(args *structs.JobValidateRequest, reply *structs.JobValid
 		}
 	}
 
+	// Warn about memory limits that won't be honored
+	memoryWarnings := j.memoryOversubscriptionWarnings(args.Job)
+
 	// Set the warning message
-	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
+	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings, memoryWarnings)
 	reply.DriverConfigValidated = true
 	return nil
 }
@@ -1101,8 +1111,11 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		return err
 	}
 
+	// Warn about memory limits that won't be honored
+	memoryWarnings := j.memoryOversubscriptionWarnings(args.Job)
+
 	// Set the warning message
-	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
+	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings, memoryWarnings)
 
 	// Check job submission permissions, which we assume is the same for plan
 	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
@@ -1111,6 +1124,10 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
 			return structs.ErrPermissionDenied
 		}
+		// Check the host volumes of the job can be mounted
+		if !allowHostVolumes(aclObj, args.RequestNamespace(), args.Job) {
+			return structs.ErrPermissionDenied
+		}
 		// Check if override is set and we do not have permissions
 		if args.PolicyOverride {
 			if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySentinelOverride) {
@@ -1126,7 +1143,7 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	}
 	if policyWarnings != nil {
 		reply.Warnings = structs.MergeMultierrorWarnings(warnings,
-			canonicalizeWarnings, policyWarnings)
+			canonicalizeWarnings, memoryWarnings, policyWarnings)
 	}
 
 	// Acquire a snapshot of the state
@@ -1220,6 +1237,7 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	}
 
 	reply.FailedTGAllocs = updatedEval.FailedTGAllocs
+	reply.PlacementMetrics = planPlacementMetrics(planner.Plans[0])
 	reply.JobModifyIndex = index
 	reply.Annotations = annotations
 	reply.CreatedEvals = planner.CreateEvals
@@ -1227,6 +1245,56 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	return nil
 }
 
+// planPlacementMetrics returns the metrics of the first new allocation placed
+// by the plan for each task group, ordered by allocation name.
+func planPlacementMetrics(plan *structs.Plan) map[string]*structs.AllocMetric {
+	first := make(map[string]*structs.Allocation)
+	for _, allocs := range plan.NodeAllocation {
+		for _, alloc := range allocs {
+			// Skip in-place updates of existing allocations
+			if alloc.CreateIndex != 0 || alloc.Metrics == nil {
+				continue
+			}
+			if existing, ok := first[alloc.TaskGroup]; !ok || alloc.Name < existing.Name {
+				first[alloc.TaskGroup] = alloc
+			}
+		}
+	}
+
+	if len(first) == 0 {
+		return nil
+	}
+	metrics := make(map[string]*structs.AllocMetric, len(first))
+	for tg, alloc := range first {
+		metrics[tg] = alloc.Metrics
+	}
+	return metrics
+}
+
+// memoryOversubscriptionWarnings returns a warning for the tasks of the job
+// that set a memory limit above their reservation while memory
+// oversubscription is disabled in the scheduler configuration.
+func (j *Job) memoryOversubscriptionWarnings(job *structs.Job) error {
+	_, config, err := j.srv.fsm.State().SchedulerConfig()
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: failed to read scheduler configuration: %v", err)
+		return nil
+	}
+	if config != nil && config.MemoryOversubscriptionEnabled {
+		return nil
+	}
+
+	var mErr multierror.Error
+	for _, tg := range job.TaskGroups {
+		for _, task := range tg.Tasks {
+			if task.Resources != nil && task.Resources.MemoryMaxMB != 0 {
+				multierror.Append(&mErr, fmt.Errorf("Memory oversubscription is not enabled; the memory_max of task %q in group %q will be ignored", task.Name, tg.Name))
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
 // validateJob validates a Job and task drivers and returns an error if there is
 // a validation problem or if the Job is of a type a user is not allowed to
 // submit.
@@ -1500,3 +1568,19 @@ func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job)
 
 	return nil
 }
+
+// allowHostVolumes returns whether the host volumes requested by the job can
+// be mounted in the namespace
+func allowHostVolumes(aclObj *acl.ACL, namespace string, job *structs.Job) bool {
+	for _, tg := range job.TaskGroups {
+		for _, volume := range tg.Volumes {
+			if volume.Type != structs.VolumeTypeHost {
+				continue
+			}
+			if !aclObj.AllowHostVolume(namespace, volume.Source) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -141,6 +141,78 @@ func TestJobEndpoint_Register_ACL(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_ACL_HostVolumes(t *testing.T) {
+	t.Parallel()
+	s1, _ := TestACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+	if err := state.UpsertNamespace(900, &structs.Namespace{Name: structs.DefaultNamespace}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a job mounting a host volume
+	job := mock.Job()
+	job.TaskGroups[0].Volumes = map[string]*structs.VolumeRequest{
+		"data": {
+			Name:   "data",
+			Type:   structs.VolumeTypeHost,
+			Source: "shared-data",
+		},
+	}
+	job.TaskGroups[0].Tasks[0].VolumeMounts = []*structs.VolumeMount{
+		{
+			Volume:      "data",
+			Destination: "/data",
+		},
+	}
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Try with a token that can submit jobs but not mount volumes
+	submitToken := mock.CreatePolicyAndToken(t, state, 1001, "test-submit",
+		mock.NamespacePolicy(structs.DefaultNamespace, "write", nil))
+	req.AuthToken = submitToken.SecretID
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), structs.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied; got %v", err)
+	}
+
+	// Try with a token restricted to other volumes
+	otherToken := mock.CreatePolicyAndToken(t, state, 1003, "test-other",
+		`namespace "default" {
+			policy = "write"
+			capabilities = ["mount-volume"]
+			host_volumes = ["certs"]
+		}`)
+	req.AuthToken = otherToken.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), structs.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied; got %v", err)
+	}
+
+	// Try with a token allowed to mount the volume
+	mountToken := mock.CreatePolicyAndToken(t, state, 1005, "test-mount",
+		`namespace "default" {
+			policy = "write"
+			capabilities = ["mount-volume"]
+			host_volumes = ["shared-*"]
+		}`)
+	req.AuthToken = mountToken.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+}
+
 func TestJobEndpoint_Register_InvalidNamespace(t *testing.T) {
 	t.Parallel()
 	s1 := TestServer(t, func(c *Config) {
@@ -359,6 +431,43 @@ func TestJobEndpoint_Register_Existing(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_MemoryMax(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1 := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require.NoError(s1.fsm.State().UpsertNamespace(900, &structs.Namespace{Name: structs.DefaultNamespace}))
+
+	// Create the register request with a memory limit
+	job := mock.Job()
+	job.TaskGroups[0].Tasks[0].Resources.MemoryMaxMB = 1024
+	req := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+
+	// Warn while memory oversubscription is disabled
+	var resp structs.JobRegisterResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+	require.Contains(resp.Warnings, "Memory oversubscription is not enabled")
+
+	// Don't warn once it is enabled
+	config := structs.DefaultSchedulerConfiguration()
+	config.MemoryOversubscriptionEnabled = true
+	require.NoError(s1.fsm.State().SchedulerSetConfig(1000, config))
+
+	resp = structs.JobRegisterResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+	require.NotContains(resp.Warnings, "Memory oversubscription")
+}
+
 func TestJobEndpoint_Register_Periodic(t *testing.T) {
 	t.Parallel()
 	s1 := TestServer(t, func(c *Config) {
@@ -3673,6 +3782,47 @@ func TestJobEndpoint_Plan_NoDiff(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Plan_PlacementMetrics(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1 := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the job's namespace and a node for the allocations to be placed
+	// on
+	node := mock.Node()
+	state := s1.fsm.State()
+	require.Nil(state.UpsertNamespace(999, &structs.Namespace{Name: structs.DefaultNamespace}))
+	require.Nil(state.UpsertNode(1000, node))
+
+	// Create a plan request
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	planReq := &structs.JobPlanRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+
+	// Fetch the response
+	var planResp structs.JobPlanResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp))
+
+	// Check the score breakdown of the placement
+	require.Empty(planResp.FailedTGAllocs)
+	metrics := planResp.PlacementMetrics["web"]
+	require.NotNil(metrics)
+	require.Len(metrics.ScoreMetaData, 1)
+	require.Equal(node.ID, metrics.ScoreMetaData[0].NodeID)
+	require.Contains(metrics.ScoreMetaData[0].Scores, "binpack")
+}
+
 func TestJobEndpoint_ImplicitConstraints_Vault(t *testing.T) {
 	t.Parallel()
 	s1 := TestServer(t, func(c *Config) {
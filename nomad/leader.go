@@ -40,6 +40,10 @@ const (
 
 var minAutopilotVersion = version.Must(version.NewVersion("0.8.0"))
 
+// minSchedulerConfigVersion is the minimum version all servers must be on
+// before the scheduler configuration can be set.
+var minSchedulerConfigVersion = version.Must(version.NewVersion("0.8.1"))
+
 // monitorLeadership is used to monitor if we acquire or lose our role
 // as the leader in the Raft cluster. There is some work the leader is
 // expected to do, so we must react to changes
@@ -156,6 +160,14 @@ WAIT:
 			goto RECONCILE
 		case member := <-reconcileCh:
 			s.reconcileMember(member)
+		case errCh := <-s.reassertLeaderCh:
+			// The state store was restored from a snapshot, the leader
+			// state must be rebuilt from it
+			if !establishedLeader {
+				errCh <- fmt.Errorf("leadership has not been established")
+				continue
+			}
+			errCh <- s.restoreLeaderState()
 		}
 	}
 }
@@ -276,6 +288,44 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	return nil
 }
 
+// restoreLeaderState is used to rebuild the state the leader keeps in memory
+// after the state store was restored from a snapshot. The eval broker, the
+// blocked evals, the periodic dispatcher, the deployment watcher, the node
+// drainer and the heartbeat timers are flushed and restored from the new
+// state store.
+func (s *Server) restoreLeaderState() error {
+	defer metrics.MeasureSince([]string{"nomad", "leader", "restore_leader_state"}, time.Now())
+
+	// Flush and restore the pending and blocked evaluations
+	s.evalBroker.SetEnabled(false)
+	s.evalBroker.SetEnabled(true)
+	s.blockedEvals.SetEnabled(false)
+	s.blockedEvals.SetEnabled(true)
+	s.blockedEvals.SetTimetable(s.fsm.TimeTable())
+	if err := s.restoreEvals(); err != nil {
+		return err
+	}
+
+	// Flush and restore the periodic jobs
+	s.periodicDispatcher.SetEnabled(false)
+	s.periodicDispatcher.SetEnabled(true)
+	if err := s.restorePeriodicDispatcher(); err != nil {
+		return err
+	}
+
+	// Watch the deployments and drains of the new state store
+	s.deploymentWatcher.SetEnabled(false, nil)
+	s.deploymentWatcher.SetEnabled(true, s.State())
+	s.nodeDrainer.SetEnabled(false, nil)
+	s.nodeDrainer.SetEnabled(true, s.State())
+
+	// Reset the heartbeat timers of the restored nodes
+	if err := s.clearAllHeartbeatTimers(); err != nil {
+		return err
+	}
+	return s.initializeHeartbeatTimers()
+}
+
 // restoreEvals is used to restore pending evaluations into the eval broker and
 // blocked evaluations into the blocked eval tracker. The broker and blocked
 // eval tracker is maintained only by the leader, so it must be restored anytime
@@ -399,6 +449,14 @@ func (s *Server) restorePeriodicDispatcher() error {
 				job.ID, job.Namespace)
 		}
 
+		// Launch the runs missed since the last launch if the job catches up.
+		if job.Periodic.CatchUp {
+			if err := s.catchUpPeriodicJob(job, launch.Launch, now); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// nextLaunch is the next launch that should occur.
 		nextLaunch := job.Periodic.Next(launch.Launch.In(job.Periodic.GetLocation()))
 
@@ -421,6 +479,34 @@ func (s *Server) restorePeriodicDispatcher() error {
 	return nil
 }
 
+// catchUpPeriodicJob launches the runs of the periodic job that were missed
+// since its last launch, within its catch-up window. If the job prohibits
+// overlap only the latest missed run is launched.
+func (s *Server) catchUpPeriodicJob(job *structs.Job, lastLaunch, now time.Time) error {
+	from := lastLaunch
+	if windowStart := now.Add(-job.Periodic.GetCatchUpWindow()); from.Before(windowStart) {
+		from = windowStart
+	}
+
+	loc := job.Periodic.GetLocation()
+	missed := job.Periodic.Launches(from.In(loc), now.In(loc), structs.MaxPeriodicCatchUpLaunches)
+	if len(missed) == 0 {
+		return nil
+	}
+	if job.Periodic.ProhibitOverlap {
+		missed = missed[len(missed)-1:]
+	}
+
+	if _, err := s.periodicDispatcher.CatchUp(job.Namespace, job.ID, missed); err != nil {
+		msg := fmt.Sprintf("catch up of periodic job %q failed: %v", job.ID, err)
+		s.logger.Printf("[ERR] nomad.periodic: %s", msg)
+		return errors.New(msg)
+	}
+	s.logger.Printf("[DEBUG] nomad.periodic: caught up %d missed launches of periodic"+
+		" job %q during leadership establishment", len(missed), job.ID)
+	return nil
+}
+
 // schedulePeriodic is used to do periodic job dispatch while we are leader
 func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	evalGC := time.NewTicker(s.config.EvalGCInterval)
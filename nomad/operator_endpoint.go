@@ -2,12 +2,32 @@ package nomad
 
 import (
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
+	"strings"
+	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/agent/consul/autopilot"
+	"github.com/hashicorp/nomad/acl"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/snapshot"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// snapshotFrameSize is the maximum size of the snapshot data sent in a
+	// single frame of the snapshot streams
+	snapshotFrameSize = 64 * 1024
+
+	// snapshotRestoreLeaderTimeout is the time to wait for the leader to
+	// rebuild its state after a snapshot is restored
+	snapshotRestoreLeaderTimeout = time.Minute
 )
 
 // Operator endpoint is used to perform low-level operator tasks for Nomad.
@@ -15,6 +35,11 @@ type Operator struct {
 	srv *Server
 }
 
+func (op *Operator) register() {
+	op.srv.streamingRpcs.Register("Operator.SnapshotSave", op.snapshotSave)
+	op.srv.streamingRpcs.Register("Operator.SnapshotRestore", op.snapshotRestore)
+}
+
 // RaftGetConfiguration is used to retrieve the current Raft configuration.
 func (op *Operator) RaftGetConfiguration(args *structs.GenericRequest, reply *structs.RaftConfigurationResponse) error {
 	if done, err := op.srv.forward("Operator.RaftGetConfiguration", args, args, reply); done {
@@ -252,6 +277,197 @@ func (op *Operator) AutopilotSetConfiguration(args *structs.AutopilotSetConfigRe
 	return nil
 }
 
+// SchedulerGetConfiguration is used to retrieve the current scheduler
+// configuration. The default configuration is returned if it has never been
+// set.
+func (op *Operator) SchedulerGetConfiguration(args *structs.GenericRequest, reply *structs.SchedulerConfigurationResponse) error {
+	if done, err := op.srv.forward("Operator.SchedulerGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	rule, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.AllowOperatorRead() {
+		return structs.ErrPermissionDenied
+	}
+
+	state := op.srv.fsm.State()
+	index, config, err := state.SchedulerConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		config = structs.DefaultSchedulerConfiguration()
+	} else {
+		config = config.Copy()
+		config.Canonicalize()
+	}
+
+	reply.SchedulerConfig = config
+	reply.Index = index
+	op.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// SchedulerSetConfiguration is used to set the current scheduler configuration.
+func (op *Operator) SchedulerSetConfiguration(args *structs.SchedulerSetConfigRequest, reply *structs.SchedulerSetConfigurationResponse) error {
+	if done, err := op.srv.forward("Operator.SchedulerSetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator write access.
+	rule, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.AllowOperatorWrite() {
+		return structs.ErrPermissionDenied
+	}
+
+	if err := args.Config.Validate(); err != nil {
+		return fmt.Errorf("invalid scheduler configuration: %v", err)
+	}
+	args.Config.Canonicalize()
+
+	// All servers must understand the request before it can be applied
+	if !ServersMeetMinimumVersion(op.srv.Members(), minSchedulerConfigVersion) {
+		return fmt.Errorf("all servers should be running version %v or later to set the scheduler configuration", minSchedulerConfigVersion)
+	}
+
+	// Apply the update
+	resp, index, err := op.srv.raftApply(structs.SchedulerConfigRequestType, args)
+	if err != nil {
+		op.srv.logger.Printf("[ERR] nomad.operator: Apply failed: %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Check if the return type is a bool. It is only set for CAS updates.
+	reply.Updated = true
+	if respBool, ok := resp.(bool); ok {
+		reply.Updated = respBool
+	}
+	reply.Index = index
+	return nil
+}
+
+// SchedulerQueue is used to retrieve the state of the queues of the eval
+// broker.
+func (op *Operator) SchedulerQueue(args *structs.GenericRequest, reply *structs.SchedulerQueueResponse) error {
+	// This must be sent to the leader since only its eval broker is enabled,
+	// so we fix the args since we are re-using a structure where we don't
+	// support all the options.
+	args.AllowStale = false
+	if done, err := op.srv.forward("Operator.SchedulerQueue", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	rule, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.AllowOperatorRead() {
+		return structs.ErrPermissionDenied
+	}
+
+	stats := op.srv.evalBroker.Stats()
+	reply.FairQueueing, reply.MaxReadyPerJob = op.srv.evalBroker.FairQueueing()
+	reply.TotalReady = stats.TotalReady
+	reply.TotalUnacked = stats.TotalUnacked
+	reply.TotalBlocked = stats.TotalBlocked
+	reply.TotalWaiting = stats.TotalWaiting
+	reply.TotalThrottled = stats.TotalThrottled
+	reply.Namespaces = make(map[string]*structs.NamespaceQueueStats, len(stats.ByNamespace))
+	for namespace, nsStats := range stats.ByNamespace {
+		reply.Namespaces[namespace] = &structs.NamespaceQueueStats{
+			Weight:    nsStats.Weight,
+			Ready:     nsStats.Ready,
+			Unacked:   nsStats.Unacked,
+			Throttled: nsStats.Throttled,
+			ReadyWait: nsStats.ReadyWait,
+		}
+	}
+	op.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// SchedulerSimulate is used to simulate how the schedulers would react to
+// hypothetical changes to the nodes and jobs of the cluster. The changes and
+// the resulting plans are only applied to a snapshot of the state.
+func (op *Operator) SchedulerSimulate(args *structs.SchedulerSimulateRequest, reply *structs.SchedulerSimulateResponse) error {
+	if done, err := op.srv.forward("Operator.SchedulerSimulate", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access and permission to submit
+	// the jobs, which we assume is the same for simulating them.
+	rule, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.AllowOperatorRead() {
+		return structs.ErrPermissionDenied
+	}
+
+	for _, job := range args.Jobs {
+		if job == nil {
+			return fmt.Errorf("Job required for simulation")
+		}
+
+		// Initialize and validate the job as if it were registered
+		job.Canonicalize()
+		setImplicitConstraints(job)
+		if err, _ := validateJob(job); err != nil {
+			return fmt.Errorf("invalid job %q: %v", job.ID, err)
+		}
+
+		if rule != nil && !rule.AllowNsOp(job.Namespace, acl.NamespaceCapabilitySubmitJob) {
+			return structs.ErrPermissionDenied
+		}
+	}
+
+	snap, err := op.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	index, err := snap.LatestIndex()
+	if err != nil {
+		return err
+	}
+	sim, err := newSchedulerSimulation(op.srv.logger, snap)
+	if err != nil {
+		return err
+	}
+	resp, err := sim.Run(args)
+	if err != nil {
+		return err
+	}
+
+	// All the jobs of the cluster are evaluated since they compete for the
+	// nodes, but only the results of the given jobs and of the jobs the token
+	// can read are returned.
+	if rule != nil {
+		results := resp.Jobs[:len(args.Jobs)]
+		for _, result := range resp.Jobs[len(args.Jobs):] {
+			if rule.AllowNsOp(result.Namespace, acl.NamespaceCapabilityReadJob) {
+				results = append(results, result)
+			}
+		}
+		resp.Jobs = results
+	}
+
+	reply.Jobs = resp.Jobs
+	reply.Nodes = resp.Nodes
+	reply.Index = index
+	return nil
+}
+
 // ServerHealth is used to get the current health of the servers.
 func (op *Operator) ServerHealth(args *structs.GenericRequest, reply *autopilot.OperatorHealthReply) error {
 	// This must be sent to the leader, so we fix the args since we are
@@ -283,3 +499,231 @@ func (op *Operator) ServerHealth(args *structs.GenericRequest, reply *autopilot.
 
 	return nil
 }
+
+// handleStreamResultError is a helper for sending an error with a potential
+// error code. The transmission of the error is ignored if the error has been
+// generated by the closing of the underlying transport.
+func (op *Operator) handleStreamResultError(err error, code *int64, encoder *codec.Encoder) {
+	// Nothing to do as the conn is closed
+	if err == io.EOF || strings.Contains(err.Error(), "closed") {
+		return
+	}
+
+	// Attempt to send the error
+	encoder.Encode(&cstructs.StreamErrWrapper{
+		Error: cstructs.NewRpcError(err, code),
+	})
+}
+
+// forwardStreamingRpc is used to forward a streaming RPC to a server of the
+// region of the request or to the leader. It returns whether the RPC was
+// forwarded.
+func (op *Operator) forwardStreamingRpc(conn io.ReadWriteCloser, encoder *codec.Encoder,
+	method string, args interface{}, info structs.RPCInfo) bool {
+	var server *serverParts
+	if region := info.RequestRegion(); region != op.srv.Region() {
+		op.srv.peerLock.RLock()
+		servers := op.srv.peers[region]
+		if len(servers) != 0 {
+			server = servers[rand.Intn(len(servers))]
+		}
+		op.srv.peerLock.RUnlock()
+
+		if server == nil {
+			op.srv.logger.Printf("[WARN] nomad.rpc: RPC request for region '%s', no path found", region)
+			op.handleStreamResultError(structs.ErrNoRegionPath, nil, encoder)
+			return true
+		}
+	} else if info.IsRead() && info.AllowStaleRead() {
+		return false
+	} else {
+		isLeader, remoteServer := op.srv.getLeader()
+		if isLeader {
+			return false
+		}
+		if remoteServer == nil {
+			op.handleStreamResultError(structs.ErrNoLeader, nil, encoder)
+			return true
+		}
+		server = remoteServer
+	}
+
+	// Get a connection to the server
+	srvConn, err := op.srv.streamingRpc(server, method)
+	if err != nil {
+		op.handleStreamResultError(err, nil, encoder)
+		return true
+	}
+	defer srvConn.Close()
+
+	// Send the request.
+	info.SetForwarded()
+	outEncoder := codec.NewEncoder(srvConn, structs.MsgpackHandle)
+	if err := outEncoder.Encode(args); err != nil {
+		op.handleStreamResultError(err, nil, encoder)
+		return true
+	}
+
+	structs.Bridge(conn, srvConn)
+	return true
+}
+
+// snapshotSave streams a snapshot of the state of the cluster. The snapshot
+// is a gzipped archive holding the Raft snapshot and its checksummed
+// metadata, sent in the payloads of the stream.
+func (op *Operator) snapshotSave(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	defer metrics.MeasureSince([]string{"nomad", "operator", "snapshot_save"}, time.Now())
+
+	// Decode the arguments
+	var args structs.SnapshotSaveRequest
+	decoder := codec.NewDecoder(conn, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(conn, structs.MsgpackHandle)
+
+	if err := decoder.Decode(&args); err != nil {
+		op.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+
+	if op.forwardStreamingRpc(conn, encoder, "Operator.SnapshotSave", &args, &args) {
+		return
+	}
+
+	// Check management permissions
+	if aclObj, err := op.srv.ResolveToken(args.AuthToken); err != nil {
+		op.handleStreamResultError(err, nil, encoder)
+		return
+	} else if aclObj != nil && !aclObj.IsManagement() {
+		op.handleStreamResultError(structs.ErrPermissionDenied, nil, encoder)
+		return
+	}
+
+	snap, err := snapshot.New(op.srv.logger, op.srv.raft)
+	if err != nil {
+		op.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+	defer snap.Close()
+
+	buf := make([]byte, snapshotFrameSize)
+	for {
+		n, err := snap.Read(buf)
+		if n > 0 {
+			if err := encoder.Encode(&cstructs.StreamErrWrapper{Payload: buf[:n]}); err != nil {
+				op.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+				return
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			op.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+			return
+		}
+	}
+}
+
+// snapshotRestore restores the state of the cluster from a snapshot taken by
+// snapshotSave. The snapshot is sent in the payloads of the stream following
+// the request, the end of the snapshot being marked by an EOF error. Once the
+// state is restored and the leader rebuilt its state from it, an empty
+// message is sent back.
+func (op *Operator) snapshotRestore(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	defer metrics.MeasureSince([]string{"nomad", "operator", "snapshot_restore"}, time.Now())
+
+	// Decode the arguments
+	var args structs.SnapshotRestoreRequest
+	decoder := codec.NewDecoder(conn, structs.MsgpackHandle)
+	encoder := codec.NewEncoder(conn, structs.MsgpackHandle)
+
+	if err := decoder.Decode(&args); err != nil {
+		op.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+		return
+	}
+
+	if op.forwardStreamingRpc(conn, encoder, "Operator.SnapshotRestore", &args, &args) {
+		return
+	}
+
+	// Check management permissions
+	if aclObj, err := op.srv.ResolveToken(args.AuthToken); err != nil {
+		op.handleStreamResultError(err, nil, encoder)
+		return
+	} else if aclObj != nil && !aclObj.IsManagement() {
+		op.handleStreamResultError(structs.ErrPermissionDenied, nil, encoder)
+		return
+	}
+
+	// Restore the snapshot through the FSM
+	reader := decodeSnapshotStream(decoder)
+	defer reader.Close()
+	if err := snapshot.Restore(op.srv.logger, reader, op.srv.raft); err != nil {
+		op.handleStreamResultError(fmt.Errorf("failed to restore from snapshot: %v", err), helper.Int64ToPtr(400), encoder)
+		return
+	}
+
+	// Ask the leader loop to rebuild the leader state from the restored
+	// state store
+	errCh := make(chan error, 1)
+	timeoutCh := time.After(snapshotRestoreLeaderTimeout)
+	select {
+	case op.srv.reassertLeaderCh <- errCh:
+	case <-timeoutCh:
+		op.handleStreamResultError(fmt.Errorf("timed out waiting to rebuild the leader state"), helper.Int64ToPtr(500), encoder)
+		return
+	case <-op.srv.shutdownCh:
+		return
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			op.handleStreamResultError(fmt.Errorf("failed to rebuild the leader state: %v", err), helper.Int64ToPtr(500), encoder)
+			return
+		}
+	case <-timeoutCh:
+		op.handleStreamResultError(fmt.Errorf("timed out waiting to rebuild the leader state"), helper.Int64ToPtr(500), encoder)
+		return
+	case <-op.srv.shutdownCh:
+		return
+	}
+
+	// Acknowledge the restore
+	if err := encoder.Encode(&cstructs.StreamErrWrapper{}); err != nil {
+		op.handleStreamResultError(err, helper.Int64ToPtr(500), encoder)
+	}
+}
+
+// decodeSnapshotStream returns a reader of the snapshot sent in the payloads
+// of the stream. The reader returns EOF once the EOF error is received and
+// fails with any other error received.
+func decodeSnapshotStream(decoder *codec.Decoder) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			var frame cstructs.StreamErrWrapper
+			if err := decoder.Decode(&frame); err != nil {
+				pw.CloseWithError(fmt.Errorf("failed to decode snapshot stream: %v", err))
+				return
+			}
+
+			if len(frame.Payload) != 0 {
+				if _, err := pw.Write(frame.Payload); err != nil {
+					return
+				}
+			}
+
+			if err := frame.Error; err != nil {
+				if err.Message == io.EOF.Error() {
+					pw.Close()
+				} else {
+					pw.CloseWithError(err)
+				}
+				return
+			}
+		}
+	}()
+	return pr
+}
@@ -1,19 +1,28 @@
 package nomad
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/lib/freeport"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/acl"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/snapshot"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/hashicorp/raft"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
 )
 
 func TestOperator_RaftGetConfiguration(t *testing.T) {
@@ -333,3 +342,536 @@ func TestOperator_RaftRemovePeerByID_ACL(t *testing.T) {
 		assert.Nil(err)
 	}
 }
+
+func TestOperator_SchedulerGetConfiguration(t *testing.T) {
+	t.Parallel()
+	s1 := TestServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	arg := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+
+	// The default configuration is returned before it is set
+	var reply structs.SchedulerConfigurationResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerGetConfiguration", &arg, &reply))
+	require.Equal(structs.DefaultSchedulerConfiguration(), reply.SchedulerConfig)
+
+	// Set the configuration and read it back
+	require.Nil(s1.fsm.State().SchedulerSetConfig(1000, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}))
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerGetConfiguration", &arg, &reply))
+	require.Equal(structs.SchedulerAlgorithmSpread, reply.SchedulerConfig.SchedulerAlgorithm)
+	require.EqualValues(1000, reply.Index)
+}
+
+func TestOperator_SchedulerQueue(t *testing.T) {
+	t.Parallel()
+	s1 := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+		c.EvalFairQueueing = true
+		c.EvalNamespaceWeights = map[string]int{"default": 3}
+		c.EvalMaxReadyPerJob = 5
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	eval := mock.Eval()
+	s1.evalBroker.Enqueue(eval)
+
+	arg := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.SchedulerQueueResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerQueue", &arg, &reply))
+	require.True(reply.FairQueueing)
+	require.Equal(5, reply.MaxReadyPerJob)
+	require.Equal(1, reply.TotalReady)
+	require.Contains(reply.Namespaces, eval.Namespace)
+	require.Equal(3, reply.Namespaces[eval.Namespace].Weight)
+	require.Equal(1, reply.Namespaces[eval.Namespace].Ready)
+}
+
+func TestOperator_SchedulerSetConfiguration(t *testing.T) {
+	t.Parallel()
+	s1 := TestServer(t, func(c *Config) {
+		c.Build = "0.8.1+unittest"
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	arg := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfiguration{
+			SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+		},
+	}
+	arg.Region = s1.config.Region
+
+	var reply structs.SchedulerSetConfigurationResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply))
+	require.NotZero(reply.Index)
+	require.True(reply.Updated)
+
+	// Unset values are stored as their defaults
+	_, config, err := s1.fsm.State().SchedulerConfig()
+	require.Nil(err)
+	require.Equal(structs.SchedulerAlgorithmSpread, config.SchedulerAlgorithm)
+	require.Equal(structs.DefaultSchedulerMinNodeLimit, *config.MinNodeLimit)
+	require.Equal(structs.DefaultMaxServiceScheduleAttempts, *config.MaxServiceScheduleAttempts)
+
+	// A stale CAS index is not applied
+	arg.CAS = true
+	arg.Config.SchedulerAlgorithm = structs.SchedulerAlgorithmBinpack
+	arg.Config.ModifyIndex = config.ModifyIndex - 1
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply))
+	require.False(reply.Updated)
+
+	// The current CAS index is applied
+	arg.Config.ModifyIndex = config.ModifyIndex
+	arg.Config.MaxSkippedNodes = helper.IntToPtr(0)
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply))
+	require.True(reply.Updated)
+
+	_, config, err = s1.fsm.State().SchedulerConfig()
+	require.Nil(err)
+	require.Equal(structs.SchedulerAlgorithmBinpack, config.SchedulerAlgorithm)
+	require.Zero(*config.MaxSkippedNodes)
+
+	// Negative tunables are rejected
+	arg.CAS = false
+	arg.Config.MaxSkippedNodes = helper.IntToPtr(-1)
+	err = msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply)
+	require.NotNil(err)
+	require.Contains(err.Error(), "maximum skipped nodes")
+	arg.Config.MaxSkippedNodes = nil
+
+	// A zero minimum node limit is rejected rather than defaulted
+	arg.Config.MinNodeLimit = helper.IntToPtr(0)
+	err = msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply)
+	require.NotNil(err)
+	require.Contains(err.Error(), "minimum node limit")
+	arg.Config.MinNodeLimit = nil
+
+	// Invalid algorithms are rejected
+	arg.Config.SchedulerAlgorithm = "random"
+	err = msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply)
+	require.NotNil(err)
+	require.Contains(err.Error(), "invalid scheduler algorithm")
+}
+
+func TestOperator_SchedulerSetConfiguration_ACL(t *testing.T) {
+	t.Parallel()
+	s1, root := TestACLServer(t, func(c *Config) {
+		c.Build = "0.8.1+unittest"
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+	state := s1.fsm.State()
+
+	// Create ACL token
+	invalidToken := mock.CreatePolicyAndToken(t, state, 1001, "test-invalid", mock.NodePolicy(acl.PolicyWrite))
+
+	arg := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfiguration{
+			SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+		},
+	}
+	arg.Region = s1.config.Region
+
+	var reply structs.SchedulerSetConfigurationResponse
+
+	// Try with no token and expect permission denied
+	err := msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply)
+	require.NotNil(err)
+	require.Equal(structs.ErrPermissionDenied.Error(), err.Error())
+
+	// Try with an invalid token and expect permission denied
+	arg.AuthToken = invalidToken.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply)
+	require.NotNil(err)
+	require.Equal(structs.ErrPermissionDenied.Error(), err.Error())
+
+	// Try with a management token
+	arg.AuthToken = root.SecretID
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply))
+}
+
+func TestOperator_SchedulerSetConfiguration_MinVersion(t *testing.T) {
+	t.Parallel()
+	s1 := TestServer(t, func(c *Config) {
+		c.Build = "0.8.0+unittest"
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	arg := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfiguration{
+			SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+		},
+	}
+	arg.Region = s1.config.Region
+
+	// Servers that may not understand the configuration must be upgraded
+	var reply structs.SchedulerSetConfigurationResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", &arg, &reply)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "all servers should be running version")
+}
+
+func TestOperator_SchedulerSimulate(t *testing.T) {
+	t.Parallel()
+	s1 := TestServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+	state := s1.fsm.State()
+
+	// Create two nodes and a job with both of its allocations on the first
+	require.Nil(state.UpsertNamespace(999, &structs.Namespace{Name: structs.DefaultNamespace}))
+	node1, node2 := mock.Node(), mock.Node()
+	require.Nil(state.UpsertNode(1000, node1))
+	require.Nil(state.UpsertNode(1001, node2))
+
+	job := mock.Job()
+	job.TaskGroups[0].Count = 2
+	require.Nil(state.UpsertJob(1002, job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 2; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = node1.ID
+		alloc.Name = fmt.Sprintf("%s.web[%d]", job.Name, i)
+		allocs = append(allocs, alloc)
+	}
+	require.Nil(state.UpsertAllocs(1003, allocs))
+
+	// Draining the first node migrates the allocations to the second
+	arg := structs.SchedulerSimulateRequest{
+		DrainNodeIDs: []string{node1.ID[:8]},
+	}
+	arg.Region = s1.config.Region
+
+	var reply structs.SchedulerSimulateResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply))
+	require.Equal(uint64(1003), reply.Index)
+	require.Len(reply.Jobs, 1)
+	require.Equal(job.ID, reply.Jobs[0].JobID)
+	require.Equal(uint64(2), reply.Jobs[0].DesiredTGUpdates["web"].Migrate)
+	require.Empty(reply.Jobs[0].FailedTGAllocs)
+
+	require.Len(reply.Nodes, 2)
+	utilization := make(map[string]*structs.SimulatedNodeUtilization)
+	for _, n := range reply.Nodes {
+		utilization[n.ID] = n
+	}
+	require.Equal(structs.SimulatedNodeDrained, utilization[node1.ID].Change)
+	require.Equal(0, utilization[node1.ID].Allocs)
+	require.Equal("", utilization[node2.ID].Change)
+	require.Equal(2, utilization[node2.ID].Allocs)
+	require.Equal(2*job.TaskGroups[0].Tasks[0].Resources.MemoryMB+node2.Reserved.MemoryMB,
+		utilization[node2.ID].Used.MemoryMB)
+
+	// Nothing was committed
+	out, err := state.AllocsByNode(nil, node1.ID)
+	require.Nil(err)
+	require.Len(out, 2)
+	for _, alloc := range out {
+		require.Nil(alloc.DesiredTransition.Migrate)
+	}
+	out, err = state.AllocsByNode(nil, node2.ID)
+	require.Nil(err)
+	require.Empty(out)
+	n, err := state.NodeByID(nil, node1.ID)
+	require.Nil(err)
+	require.Nil(n.DrainStrategy)
+
+	// Added nodes are reported and a job that doesn't fit fails to place
+	big := mock.Job()
+	big.TaskGroups[0].Count = 1
+	big.TaskGroups[0].Tasks[0].Resources.MemoryMB = 100000
+	arg = structs.SchedulerSimulateRequest{
+		AddNodes:      []*structs.SimulatedNodes{{Count: 3, NodeClass: node1.NodeClass}},
+		RemoveNodeIDs: []string{node2.ID},
+		Jobs:          []*structs.Job{big},
+	}
+	arg.Region = s1.config.Region
+	reply = structs.SchedulerSimulateResponse{}
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply))
+	require.Len(reply.Jobs, 1)
+	require.Equal(big.ID, reply.Jobs[0].JobID)
+	require.Contains(reply.Jobs[0].FailedTGAllocs, "web")
+
+	changes := make(map[string]int)
+	for _, n := range reply.Nodes {
+		changes[n.Change]++
+	}
+	require.Equal(map[string]int{"": 1, structs.SimulatedNodeAdded: 3, structs.SimulatedNodeRemoved: 1}, changes)
+
+	jobOut, err := state.JobByID(nil, big.Namespace, big.ID)
+	require.Nil(err)
+	require.Nil(jobOut)
+
+	// Unknown nodes are rejected
+	arg = structs.SchedulerSimulateRequest{
+		DrainNodeIDs: []string{"ffffffff-0000"},
+	}
+	arg.Region = s1.config.Region
+	err = msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply)
+	require.NotNil(err)
+	require.Contains(err.Error(), "no node with prefix")
+}
+
+func TestOperator_SchedulerSimulate_ACL(t *testing.T) {
+	t.Parallel()
+	s1, root := TestACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+	state := s1.fsm.State()
+	require.Nil(state.UpsertNamespace(999, &structs.Namespace{Name: structs.DefaultNamespace}))
+
+	// Operator read access isn't enough to simulate jobs
+	readToken := mock.CreatePolicyAndToken(t, state, 1001, "test-read", mock.OperatorPolicy(acl.PolicyRead))
+
+	arg := structs.SchedulerSimulateRequest{
+		Jobs: []*structs.Job{mock.Job()},
+	}
+	arg.Region = s1.config.Region
+
+	// Try with no token and expect permission denied
+	var reply structs.SchedulerSimulateResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply)
+	require.NotNil(err)
+	require.Equal(err.Error(), structs.ErrPermissionDenied.Error())
+
+	arg.AuthToken = readToken.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply)
+	require.NotNil(err)
+	require.Equal(err.Error(), structs.ErrPermissionDenied.Error())
+
+	// Without jobs operator read access is enough
+	arg.Jobs = nil
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply))
+
+	// Try with root token, should succeed
+	arg.Jobs = []*structs.Job{mock.Job()}
+	arg.AuthToken = root.SecretID
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply))
+	require.Len(reply.Jobs, 1)
+
+	// Place a job on a node and drain it
+	node := mock.Node()
+	require.Nil(state.UpsertNode(1002, node))
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	require.Nil(state.UpsertJob(1003, job))
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	require.Nil(state.UpsertAllocs(1004, []*structs.Allocation{alloc}))
+
+	arg = structs.SchedulerSimulateRequest{
+		DrainNodeIDs: []string{node.ID},
+	}
+	arg.Region = s1.config.Region
+
+	// The results of jobs the token can't read are not returned
+	arg.AuthToken = readToken.SecretID
+	reply = structs.SchedulerSimulateResponse{}
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply))
+	require.Empty(reply.Jobs)
+
+	// The results of jobs the token can read are returned
+	jobToken := mock.CreatePolicyAndToken(t, state, 1005, "test-read-job",
+		mock.OperatorPolicy(acl.PolicyRead)+
+			mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+	arg.AuthToken = jobToken.SecretID
+	reply = structs.SchedulerSimulateResponse{}
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSimulate", &arg, &reply))
+	require.Len(reply.Jobs, 1)
+	require.Equal(job.ID, reply.Jobs[0].JobID)
+}
+
+// testOperatorSnapshotSave takes a snapshot through the Operator.SnapshotSave
+// streaming RPC, returning the snapshot or the error sent by the server.
+func testOperatorSnapshotSave(t *testing.T, s *Server, token string) ([]byte, *cstructs.RpcError) {
+	handler, err := s.StreamingRpcHandler("Operator.SnapshotSave")
+	require.NoError(t, err)
+
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+	go handler(p2)
+
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+	decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+	require.NoError(t, encoder.Encode(&structs.SnapshotSaveRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    s.config.Region,
+			AuthToken: token,
+		},
+	}))
+
+	var snap bytes.Buffer
+	for {
+		var frame cstructs.StreamErrWrapper
+		if err := decoder.Decode(&frame); err == io.EOF {
+			return snap.Bytes(), nil
+		} else {
+			require.NoError(t, err)
+		}
+		if frame.Error != nil {
+			return nil, frame.Error
+		}
+		snap.Write(frame.Payload)
+	}
+}
+
+// testOperatorSnapshotRestore restores a snapshot through the
+// Operator.SnapshotRestore streaming RPC, returning the error sent by the
+// server.
+func testOperatorSnapshotRestore(t *testing.T, s *Server, token string, snap []byte) *cstructs.RpcError {
+	handler, err := s.StreamingRpcHandler("Operator.SnapshotRestore")
+	require.NoError(t, err)
+
+	p1, p2 := net.Pipe()
+	defer p1.Close()
+	go handler(p2)
+
+	encoder := codec.NewEncoder(p1, structs.MsgpackHandle)
+	decoder := codec.NewDecoder(p1, structs.MsgpackHandle)
+	require.NoError(t, encoder.Encode(&structs.SnapshotRestoreRequest{
+		WriteRequest: structs.WriteRequest{
+			Region:    s.config.Region,
+			AuthToken: token,
+		},
+	}))
+
+	// Send the snapshot while waiting for the response
+	go func() {
+		for len(snap) > 0 {
+			n := 1024
+			if n > len(snap) {
+				n = len(snap)
+			}
+			if err := encoder.Encode(&cstructs.StreamErrWrapper{Payload: snap[:n]}); err != nil {
+				return
+			}
+			snap = snap[n:]
+		}
+		encoder.Encode(&cstructs.StreamErrWrapper{Error: cstructs.NewRpcError(io.EOF, nil)})
+	}()
+
+	var frame cstructs.StreamErrWrapper
+	require.NoError(t, decoder.Decode(&frame))
+	return frame.Error
+}
+
+func TestOperator_SnapshotSaveRestore(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1 := TestServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register a node and a pending evaluation
+	node := mock.Node()
+	_, _, err := s1.raftApply(structs.NodeRegisterRequestType, &structs.NodeRegisterRequest{Node: node})
+	require.NoError(err)
+	eval := mock.Eval()
+	_, _, err = s1.raftApply(structs.EvalUpdateRequestType, &structs.EvalUpdateRequest{
+		Evals: []*structs.Evaluation{eval},
+	})
+	require.NoError(err)
+
+	// Take a snapshot and check its integrity
+	snap, rpcErr := testOperatorSnapshotSave(t, s1, "")
+	require.Nil(rpcErr)
+	meta, err := snapshot.Verify(bytes.NewReader(snap))
+	require.NoError(err)
+	require.NotZero(meta.Index)
+
+	// Register another node and evaluation after the snapshot
+	node2 := mock.Node()
+	_, _, err = s1.raftApply(structs.NodeRegisterRequestType, &structs.NodeRegisterRequest{Node: node2})
+	require.NoError(err)
+	eval2 := mock.Eval()
+	_, _, err = s1.raftApply(structs.EvalUpdateRequestType, &structs.EvalUpdateRequest{
+		Evals: []*structs.Evaluation{eval2},
+	})
+	require.NoError(err)
+	require.Equal(2, s1.evalBroker.Stats().TotalReady)
+
+	// Restore the snapshot
+	require.Nil(testOperatorSnapshotRestore(t, s1, "", snap))
+
+	// The state is the one of the snapshot
+	state := s1.fsm.State()
+	out, err := state.NodeByID(nil, node.ID)
+	require.NoError(err)
+	require.NotNil(out)
+	out, err = state.NodeByID(nil, node2.ID)
+	require.NoError(err)
+	require.Nil(out)
+
+	// The eval broker was rebuilt from the restored state
+	require.Equal(1, s1.evalBroker.Stats().TotalReady)
+	_, ok := s1.evalBroker.Outstanding(eval.ID)
+	require.False(ok)
+	dequeued, _, err := s1.evalBroker.Dequeue([]string{eval.Type}, time.Second)
+	require.NoError(err)
+	require.NotNil(dequeued)
+	require.Equal(eval.ID, dequeued.ID)
+
+	// A corrupted snapshot is rejected
+	rpcErr = testOperatorSnapshotRestore(t, s1, "", snap[:len(snap)/2])
+	require.NotNil(rpcErr)
+	require.EqualValues(400, *rpcErr.Code)
+}
+
+func TestOperator_SnapshotSave_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, root := TestACLServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// An operator token isn't allowed to take snapshots
+	policy := mock.NodePolicy(acl.PolicyWrite) + mock.OperatorPolicy(acl.PolicyWrite)
+	token := mock.CreatePolicyAndToken(t, s1.State(), 1001, "operator", policy)
+
+	_, rpcErr := testOperatorSnapshotSave(t, s1, token.SecretID)
+	require.NotNil(rpcErr)
+	require.Contains(rpcErr.Error(), structs.ErrPermissionDenied.Error())
+
+	rpcErr = testOperatorSnapshotRestore(t, s1, token.SecretID, []byte("snapshot"))
+	require.NotNil(rpcErr)
+	require.Contains(rpcErr.Error(), structs.ErrPermissionDenied.Error())
+
+	// A management token is
+	snap, rpcErr := testOperatorSnapshotSave(t, s1, root.SecretID)
+	require.Nil(rpcErr)
+	_, err := snapshot.Verify(bytes.NewReader(snap))
+	require.NoError(err)
+}
@@ -307,6 +307,40 @@ func (p *PeriodicDispatch) ForceRun(namespace, jobID string) (*structs.Evaluatio
 	return p.createEval(job, time.Now().In(job.Periodic.GetLocation()))
 }
 
+// CatchUp causes the periodic job to be evaluated for each of the passed
+// launch times that were missed and returns the created evals.
+func (p *PeriodicDispatch) CatchUp(namespace, jobID string, launches []time.Time) ([]*structs.Evaluation, error) {
+	p.l.Lock()
+
+	// Do nothing if not enabled
+	if !p.enabled {
+		p.l.Unlock()
+		return nil, fmt.Errorf("periodic dispatch disabled")
+	}
+
+	tuple := structs.NamespacedID{
+		ID:        jobID,
+		Namespace: namespace,
+	}
+	job, tracked := p.tracked[tuple]
+	if !tracked {
+		p.l.Unlock()
+		return nil, fmt.Errorf("can't catch up non-tracked job %q (%s)", jobID, namespace)
+	}
+
+	p.l.Unlock()
+
+	evals := make([]*structs.Evaluation, 0, len(launches))
+	for _, launch := range launches {
+		eval, err := p.createEval(job, launch.In(job.Periodic.GetLocation()))
+		if err != nil {
+			return evals, err
+		}
+		evals = append(evals, eval)
+	}
+	return evals, nil
+}
+
 // shouldRun returns whether the long lived run function should run.
 func (p *PeriodicDispatch) shouldRun() bool {
 	p.l.RLock()
@@ -385,6 +385,51 @@ func TestPeriodicDispatch_ForceRun_Tracked(t *testing.T) {
 	}
 }
 
+func TestPeriodicDispatch_CatchUp_Untracked(t *testing.T) {
+	t.Parallel()
+	p, _ := testPeriodicDispatcher()
+
+	if _, err := p.CatchUp("ns", "foo", []time.Time{time.Now()}); err == nil {
+		t.Fatal("CatchUp of untracked job should fail")
+	}
+}
+
+func TestPeriodicDispatch_CatchUp_Tracked(t *testing.T) {
+	t.Parallel()
+	p, m := testPeriodicDispatcher()
+
+	// Create a job that missed two launches and won't be evaluated for a while.
+	now := time.Now().Round(1 * time.Second)
+	missed := []time.Time{now.Add(-2 * time.Minute), now.Add(-1 * time.Minute)}
+	job := testPeriodicJob(missed[0], missed[1], now.Add(10*time.Second))
+
+	// Add it.
+	if err := p.Add(job); err != nil {
+		t.Fatalf("Add failed %v", err)
+	}
+
+	// Catch up the missed launches
+	evals, err := p.CatchUp(job.Namespace, job.ID, missed)
+	if err != nil {
+		t.Fatalf("CatchUp failed %v", err)
+	}
+	if len(evals) != 2 {
+		t.Fatalf("CatchUp created an unexpected number of evals; got %d; want 2", len(evals))
+	}
+
+	// Check that the job was launched at the missed times.
+	launches, err := m.LaunchTimes(p, job.Namespace, job.ID)
+	if err != nil {
+		t.Fatalf("failed to get launch times for job %q: %v", job.ID, err)
+	}
+	sort.Slice(launches, func(i, j int) bool { return launches[i].Before(launches[j]) })
+	for i, launch := range launches {
+		if !launch.Equal(missed[i]) {
+			t.Fatalf("launch %d got %v; want %v", i, launch, missed[i])
+		}
+	}
+}
+
 func TestPeriodicDispatch_Run_DisallowOverlaps(t *testing.T) {
 	t.Parallel()
 	p, m := testPeriodicDispatcher()
@@ -9,8 +9,10 @@ import (
 	"github.com/armon/go-metrics"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/scheduler"
 	"github.com/hashicorp/raft"
 )
 
@@ -154,6 +156,45 @@ func (s *Server) applyPlan(plan *structs.Plan, result *structs.PlanResult, snap
 		alloc.ModifyTime = now
 	}
 
+	// Add the preempted allocations and create a follow up evaluation for
+	// each job that had allocations preempted so that they can be replaced.
+	preemptedJobIDs := make(map[structs.NamespacedID]struct{})
+	for _, preemptions := range result.NodePreemptions {
+		for _, preempted := range preemptions {
+			preempted.ModifyTime = now
+			req.NodePreemptions = append(req.NodePreemptions, preempted)
+
+			// Gather the job IDs of the preempted allocations
+			jobID := structs.NamespacedID{
+				ID:        preempted.JobID,
+				Namespace: preempted.Namespace,
+			}
+			if _, ok := preemptedJobIDs[jobID]; ok {
+				continue
+			}
+			preemptedJobIDs[jobID] = struct{}{}
+
+			job, err := s.fsm.State().JobByID(nil, preempted.Namespace, preempted.JobID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to lookup job %q of preempted allocation: %v", preempted.JobID, err)
+			}
+			if job == nil {
+				continue
+			}
+
+			eval := &structs.Evaluation{
+				ID:          uuid.Generate(),
+				Namespace:   job.Namespace,
+				TriggeredBy: structs.EvalTriggerPreemption,
+				JobID:       job.ID,
+				Type:        job.Type,
+				Priority:    job.Priority,
+				Status:      structs.EvalStatusPending,
+			}
+			req.PreemptionEvals = append(req.PreemptionEvals, eval)
+		}
+	}
+
 	// Dispatch the Raft transaction
 	future, err := s.raftApplyFuture(structs.ApplyPlanResultsRequestType, &req)
 	if err != nil {
@@ -230,6 +271,7 @@ func evaluatePlanPlacements(pool *EvaluatePool, snap *state.StateSnapshot, plan
 	result := &structs.PlanResult{
 		NodeUpdate:        make(map[string][]*structs.Allocation),
 		NodeAllocation:    make(map[string][]*structs.Allocation),
+		NodePreemptions:   make(map[string][]*structs.Allocation),
 		Deployment:        plan.Deployment.Copy(),
 		DeploymentUpdates: plan.DeploymentUpdates,
 	}
@@ -275,6 +317,7 @@ func evaluatePlanPlacements(pool *EvaluatePool, snap *state.StateSnapshot, plan
 			if plan.AllAtOnce {
 				result.NodeUpdate = nil
 				result.NodeAllocation = nil
+				result.NodePreemptions = nil
 				result.DeploymentUpdates = nil
 				result.Deployment = nil
 				return true
@@ -291,6 +334,23 @@ func evaluatePlanPlacements(pool *EvaluatePool, snap *state.StateSnapshot, plan
 		if nodeAlloc := plan.NodeAllocation[nodeID]; len(nodeAlloc) > 0 {
 			result.NodeAllocation[nodeID] = nodeAlloc
 		}
+		if nodePreemptions := plan.NodePreemptions[nodeID]; len(nodePreemptions) > 0 {
+			// Only preempt allocations that have not already stopped
+			var filtered []*structs.Allocation
+			for _, preempted := range nodePreemptions {
+				alloc, err := snap.AllocByID(nil, preempted.ID)
+				if err != nil {
+					mErr.Errors = append(mErr.Errors, err)
+					continue
+				}
+				if alloc != nil && !alloc.TerminalStatus() {
+					filtered = append(filtered, preempted)
+				}
+			}
+			if len(filtered) > 0 {
+				result.NodePreemptions[nodeID] = filtered
+			}
+		}
 		return
 	}
 
@@ -439,6 +499,17 @@ func evaluateNodePlan(snap *state.StateSnapshot, plan *structs.Plan, nodeID stri
 			remove = append(remove, alloc)
 		}
 	}
+
+	// Ensure the allocations being preempted are on this node and belong to
+	// jobs with a lower priority than the job of the plan.
+	if preemptions := plan.NodePreemptions[nodeID]; len(preemptions) > 0 {
+		if reason, err := validatePreemptions(snap, plan, nodeID, preemptions); err != nil {
+			return false, "", err
+		} else if reason != "" {
+			return false, reason, nil
+		}
+		remove = append(remove, preemptions...)
+	}
 	proposed := structs.RemoveAllocs(existingAlloc, remove)
 	proposed = append(proposed, plan.NodeAllocation[nodeID]...)
 
@@ -446,3 +517,32 @@ func evaluateNodePlan(snap *state.StateSnapshot, plan *structs.Plan, nodeID stri
 	fit, reason, _, err := structs.AllocsFit(node, proposed, nil)
 	return fit, reason, err
 }
+
+// validatePreemptions checks that the allocations a plan preempts on a node
+// may be preempted by it. Allocations that no longer exist or are already
+// terminal are ignored since they do not use any resources. A non-empty reason
+// is returned if the preemptions are invalid.
+func validatePreemptions(snap *state.StateSnapshot, plan *structs.Plan, nodeID string, preemptions []*structs.Allocation) (string, error) {
+	for _, preempted := range preemptions {
+		alloc, err := snap.AllocByID(nil, preempted.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get preempted allocation %q: %v", preempted.ID, err)
+		}
+		if alloc == nil || alloc.TerminalStatus() {
+			continue
+		}
+
+		if alloc.NodeID != nodeID {
+			return fmt.Sprintf("preempted allocation %q is not on node", alloc.ID), nil
+		}
+		if plan.Job != nil && alloc.Namespace == plan.Job.Namespace && alloc.JobID == plan.Job.ID {
+			return fmt.Sprintf("preempted allocation %q belongs to the job being placed", alloc.ID), nil
+		}
+		if alloc.Job != nil && alloc.Job.Priority+scheduler.PreemptionPriorityDelta > plan.Priority {
+			return fmt.Sprintf("preempted allocation %q has a job priority of %d which is not at least %d lower than %d",
+				alloc.ID, alloc.Job.Priority, scheduler.PreemptionPriorityDelta, plan.Priority), nil
+		}
+	}
+
+	return "", nil
+}
@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	memdb "github.com/hashicorp/go-memdb"
@@ -594,6 +595,121 @@ func TestPlanApply_EvalNodePlan_NodeFull_Evict(t *testing.T) {
 	}
 }
 
+func TestPlanApply_EvalNodePlan_NodeFull_Preemption(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	alloc.Job.Priority = 20
+	state := testStateStore(t)
+	node := mock.Node()
+	alloc.NodeID = node.ID
+	node.Resources = alloc.Resources
+	node.Reserved = nil
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	state.UpsertNode(1000, node)
+	state.UpsertAllocs(1001, []*structs.Allocation{alloc})
+	snap, _ := state.Snapshot()
+
+	alloc2 := mock.Alloc()
+	alloc2.Job.Priority = 90
+	plan := &structs.Plan{
+		Job:      alloc2.Job,
+		Priority: alloc2.Job.Priority,
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: {alloc2},
+		},
+	}
+	plan.AppendPreemptedAlloc(alloc, structs.AllocDesiredStatusEvict, alloc2.ID)
+
+	fit, reason, err := evaluateNodePlan(snap, plan, node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit {
+		t.Fatalf("bad: %v", reason)
+	}
+	if reason != "" {
+		t.Fatalf("bad")
+	}
+}
+
+func TestPlanApply_EvalNodePlan_NodeFull_PreemptionHigherPriority(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	alloc.Job.Priority = 90
+	state := testStateStore(t)
+	node := mock.Node()
+	alloc.NodeID = node.ID
+	node.Resources = alloc.Resources
+	node.Reserved = nil
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	state.UpsertNode(1000, node)
+	state.UpsertAllocs(1001, []*structs.Allocation{alloc})
+	snap, _ := state.Snapshot()
+
+	// The plan's job has a lower priority than the job of the allocation it
+	// attempts to preempt
+	alloc2 := mock.Alloc()
+	alloc2.Job.Priority = 50
+	plan := &structs.Plan{
+		Job:      alloc2.Job,
+		Priority: alloc2.Job.Priority,
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: {alloc2},
+		},
+	}
+	plan.AppendPreemptedAlloc(alloc, structs.AllocDesiredStatusEvict, alloc2.ID)
+
+	fit, reason, err := evaluateNodePlan(snap, plan, node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit {
+		t.Fatalf("bad")
+	}
+	if !strings.Contains(reason, "priority") {
+		t.Fatalf("bad: %v", reason)
+	}
+}
+
+func TestPlanApply_EvalNodePlan_NodeFull_PreemptionCloserPriority(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	alloc.Job.Priority = 45
+	state := testStateStore(t)
+	node := mock.Node()
+	alloc.NodeID = node.ID
+	node.Resources = alloc.Resources
+	node.Reserved = nil
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	state.UpsertNode(1000, node)
+	state.UpsertAllocs(1001, []*structs.Allocation{alloc})
+	snap, _ := state.Snapshot()
+
+	// The plan's job has a higher priority than the job of the allocation it
+	// attempts to preempt, but by less than the preemption delta
+	alloc2 := mock.Alloc()
+	alloc2.Job.Priority = 50
+	plan := &structs.Plan{
+		Job:      alloc2.Job,
+		Priority: alloc2.Job.Priority,
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: {alloc2},
+		},
+	}
+	plan.AppendPreemptedAlloc(alloc, structs.AllocDesiredStatusEvict, alloc2.ID)
+
+	fit, reason, err := evaluateNodePlan(snap, plan, node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit {
+		t.Fatalf("bad")
+	}
+	if !strings.Contains(reason, "priority") {
+		t.Fatalf("bad: %v", reason)
+	}
+}
+
 func TestPlanApply_EvalNodePlan_NodeFull_AllocEvict(t *testing.T) {
 	t.Parallel()
 	alloc := mock.Alloc()
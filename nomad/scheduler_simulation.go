@@ -0,0 +1,392 @@
+package nomad
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/scheduler"
+)
+
+// schedulerSimulation applies hypothetical changes to a snapshot of the state
+// and runs the schedulers against it. The plans of the schedulers are applied
+// to the snapshot only, so nothing is committed.
+type schedulerSimulation struct {
+	logger *log.Logger
+	snap   *state.StateSnapshot
+
+	// index is the index at which the next change is applied to the
+	// snapshot.
+	index uint64
+
+	// changed maps the IDs of the nodes the simulation changed to the change.
+	changed map[string]string
+
+	// removed are the nodes that were removed from the snapshot.
+	removed []*structs.Node
+}
+
+// newSchedulerSimulation returns a simulation running against the snapshot.
+func newSchedulerSimulation(logger *log.Logger, snap *state.StateSnapshot) (*schedulerSimulation, error) {
+	index, err := snap.LatestIndex()
+	if err != nil {
+		return nil, err
+	}
+	s := &schedulerSimulation{
+		logger:  logger,
+		snap:    snap,
+		index:   index + 1,
+		changed: make(map[string]string),
+	}
+	return s, nil
+}
+
+// nextIndex returns the index at which to apply the next change.
+func (s *schedulerSimulation) nextIndex() uint64 {
+	index := s.index
+	s.index++
+	return index
+}
+
+// Run applies the changes of the request and evaluates the jobs of the
+// request, followed by all other jobs of the cluster.
+func (s *schedulerSimulation) Run(args *structs.SchedulerSimulateRequest) (*structs.SchedulerSimulateResponse, error) {
+	// Resolve the nodes before changing any of them so that the prefixes
+	// refer to the nodes of the cluster.
+	removeIDs, err := s.resolveNodeIDs(args.RemoveNodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	drainIDs, err := s.resolveNodeIDs(args.DrainNodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range drainIDs {
+		for _, rid := range removeIDs {
+			if id == rid {
+				return nil, fmt.Errorf("node %q can't be both drained and removed", id)
+			}
+		}
+	}
+
+	for _, nodes := range args.AddNodes {
+		if err := s.addNodes(nodes); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range drainIDs {
+		if err := s.drainNode(id); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range removeIDs {
+		if err := s.removeNode(id); err != nil {
+			return nil, err
+		}
+	}
+	for _, job := range args.Jobs {
+		if err := s.snap.UpsertJob(s.nextIndex(), job); err != nil {
+			return nil, fmt.Errorf("failed to register job %q: %v", job.ID, err)
+		}
+	}
+
+	results, err := s.evaluateJobs(args.Jobs)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeUtilization()
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &structs.SchedulerSimulateResponse{
+		Jobs:  results,
+		Nodes: nodes,
+	}
+	return reply, nil
+}
+
+// resolveNodeIDs returns the IDs of the nodes matching the ID prefixes. Each
+// prefix must match exactly one node.
+func (s *schedulerSimulation) resolveNodeIDs(prefixes []string) ([]string, error) {
+	ids := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		id, err := s.resolveNodeID(prefix)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *schedulerSimulation) resolveNodeID(prefix string) (string, error) {
+	iter, err := s.snap.NodesByIDPrefix(nil, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		ids = append(ids, raw.(*structs.Node).ID)
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no node with prefix %q found", prefix)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("prefix %q matched multiple nodes", prefix)
+	}
+}
+
+// addNodes adds copies of the template node of the group to the snapshot.
+func (s *schedulerSimulation) addNodes(nodes *structs.SimulatedNodes) error {
+	if err := nodes.Validate(); err != nil {
+		return err
+	}
+
+	var template *structs.Node
+	if nodes.TemplateNodeID != "" {
+		id, err := s.resolveNodeID(nodes.TemplateNodeID)
+		if err != nil {
+			return err
+		}
+		template, err = s.snap.NodeByID(nil, id)
+		if err != nil {
+			return err
+		}
+	} else {
+		iter, err := s.snap.Nodes(nil)
+		if err != nil {
+			return err
+		}
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			node := raw.(*structs.Node)
+			if node.NodeClass == nodes.NodeClass {
+				template = node
+				break
+			}
+		}
+		if template == nil {
+			return fmt.Errorf("no node of class %q to use as a template", nodes.NodeClass)
+		}
+	}
+
+	for i := 0; i < nodes.Count; i++ {
+		node := template.Copy()
+		node.ID = uuid.Generate()
+		node.SecretID = uuid.Generate()
+		node.Name = fmt.Sprintf("simulated-%s", node.ID[:8])
+		if nodes.NodeClass != "" {
+			node.NodeClass = nodes.NodeClass
+		}
+		if err := node.ComputeClass(); err != nil {
+			return fmt.Errorf("failed to compute class of node: %v", err)
+		}
+		node.Status = structs.NodeStatusReady
+		node.SchedulingEligibility = structs.NodeSchedulingEligible
+		node.Drain = false
+		node.DrainStrategy = nil
+
+		if err := s.snap.UpsertNode(s.nextIndex(), node); err != nil {
+			return err
+		}
+		s.changed[node.ID] = structs.SimulatedNodeAdded
+	}
+	return nil
+}
+
+// drainNode marks the node as draining and its allocations for migration.
+func (s *schedulerSimulation) drainNode(id string) error {
+	if err := s.snap.UpdateNodeDrain(s.nextIndex(), id, &structs.DrainStrategy{}, false); err != nil {
+		return err
+	}
+
+	allocs, err := s.snap.AllocsByNodeTerminal(nil, id, false)
+	if err != nil {
+		return err
+	}
+	transitions := make(map[string]*structs.DesiredTransition, len(allocs))
+	for _, alloc := range allocs {
+		transitions[alloc.ID] = &structs.DesiredTransition{
+			Migrate: helper.BoolToPtr(true),
+		}
+	}
+	if err := s.snap.UpdateAllocsDesiredTransitions(s.nextIndex(), transitions, nil); err != nil {
+		return err
+	}
+
+	s.changed[id] = structs.SimulatedNodeDrained
+	return nil
+}
+
+// removeNode deletes the node so that its allocations are treated as lost.
+func (s *schedulerSimulation) removeNode(id string) error {
+	node, err := s.snap.NodeByID(nil, id)
+	if err != nil {
+		return err
+	}
+	if err := s.snap.DeleteNode(s.nextIndex(), id); err != nil {
+		return err
+	}
+
+	s.changed[id] = structs.SimulatedNodeRemoved
+	s.removed = append(s.removed, node)
+	return nil
+}
+
+// evaluateJobs evaluates the given jobs, followed by the other jobs of the
+// cluster in priority order. The results of the given jobs are always
+// returned while the results of the other jobs are only returned if the
+// scheduler changed their allocations.
+func (s *schedulerSimulation) evaluateJobs(given []*structs.Job) ([]*structs.SimulatedJobResult, error) {
+	type jobKey struct{ namespace, id string }
+	seen := make(map[jobKey]struct{}, len(given))
+	for _, job := range given {
+		seen[jobKey{job.Namespace, job.ID}] = struct{}{}
+	}
+
+	iter, err := s.snap.Jobs(nil)
+	if err != nil {
+		return nil, err
+	}
+	var others []*structs.Job
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		job := raw.(*structs.Job)
+		if _, ok := seen[jobKey{job.Namespace, job.ID}]; ok {
+			continue
+		}
+
+		// Skip the jobs that are never placed themselves
+		if job.Stopped() || job.IsPeriodic() || job.IsParameterized() {
+			continue
+		}
+		others = append(others, job)
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].Priority > others[j].Priority
+	})
+
+	planner := &scheduler.Harness{
+		State: &s.snap.StateStore,
+	}
+	planner.SetNextIndex(s.nextIndex())
+
+	var results []*structs.SimulatedJobResult
+	for _, job := range given {
+		result, err := s.evaluateJob(planner, job, structs.EvalTriggerJobRegister)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	for _, job := range others {
+		result, err := s.evaluateJob(planner, job, structs.EvalTriggerNodeUpdate)
+		if err != nil {
+			return nil, err
+		}
+		if result.Changed() {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// evaluateJob runs the scheduler of the job and applies its plan to the
+// snapshot.
+func (s *schedulerSimulation) evaluateJob(planner *scheduler.Harness, job *structs.Job, trigger string) (*structs.SimulatedJobResult, error) {
+	// Read back the job to pick up the indexes set when it was registered
+	job, err := s.snap.JobByID(nil, job.Namespace, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	eval := &structs.Evaluation{
+		ID:             uuid.Generate(),
+		Namespace:      job.Namespace,
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    trigger,
+		JobID:          job.ID,
+		JobModifyIndex: job.JobModifyIndex,
+		Status:         structs.EvalStatusPending,
+		AnnotatePlan:   true,
+	}
+	if err := s.snap.UpsertEvals(planner.NextIndex(), []*structs.Evaluation{eval}); err != nil {
+		return nil, err
+	}
+
+	plans, evals := len(planner.Plans), len(planner.Evals)
+	sched, err := scheduler.NewScheduler(eval.Type, s.logger, s.snap, planner)
+	if err != nil {
+		return nil, err
+	}
+	if err := sched.Process(eval); err != nil {
+		return nil, fmt.Errorf("failed to evaluate job %q: %v", job.ID, err)
+	}
+
+	result := &structs.SimulatedJobResult{
+		Namespace: job.Namespace,
+		JobID:     job.ID,
+	}
+	for _, plan := range planner.Plans[plans:] {
+		if plan.Annotations != nil {
+			result.DesiredTGUpdates = plan.Annotations.DesiredTGUpdates
+		}
+	}
+	for _, update := range planner.Evals[evals:] {
+		if update.ID == eval.ID {
+			result.FailedTGAllocs = update.FailedTGAllocs
+		}
+	}
+	return result, nil
+}
+
+// nodeUtilization returns the utilization of the nodes of the snapshot and
+// the nodes that were removed from it, ordered by ID.
+func (s *schedulerSimulation) nodeUtilization() ([]*structs.SimulatedNodeUtilization, error) {
+	iter, err := s.snap.Nodes(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.SimulatedNodeUtilization
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		node := raw.(*structs.Node)
+		allocs, err := s.snap.AllocsByNodeTerminal(nil, node.ID, false)
+		if err != nil {
+			return nil, err
+		}
+		_, _, used, err := structs.AllocsFit(node, allocs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute utilization of node %q: %v", node.ID, err)
+		}
+		out = append(out, simulatedNodeUtilization(node, s.changed[node.ID], len(allocs), used))
+	}
+	for _, node := range s.removed {
+		out = append(out, simulatedNodeUtilization(node, structs.SimulatedNodeRemoved, 0, nil))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func simulatedNodeUtilization(node *structs.Node, change string, allocs int, used *structs.Resources) *structs.SimulatedNodeUtilization {
+	return &structs.SimulatedNodeUtilization{
+		ID:         node.ID,
+		Name:       node.Name,
+		Datacenter: node.Datacenter,
+		NodeClass:  node.NodeClass,
+		Change:     change,
+		Allocs:     allocs,
+		Capacity:   node.Resources,
+		Used:       used,
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/hashicorp/nomad/nomad/deploymentwatcher"
 	"github.com/hashicorp/nomad/nomad/drainer"
 	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/stream"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/scheduler"
@@ -104,6 +105,10 @@ type Server struct {
 	raftInmem     *raft.InmemStore
 	raftTransport *raft.NetworkTransport
 
+	// reassertLeaderCh is used to ask the leader loop to rebuild the leader
+	// state after the state store was restored from a snapshot
+	reassertLeaderCh chan chan error
+
 	// autopilot is the Autopilot instance for this server.
 	autopilot *autopilot.Autopilot
 
@@ -183,6 +188,10 @@ type Server struct {
 	// periodicDispatcher is used to track and create evaluations for periodic jobs.
 	periodicDispatcher *PeriodicDispatch
 
+	// eventBroker buffers the events published by the FSM for the
+	// subscribers of the event stream.
+	eventBroker *stream.EventBroker
+
 	// planQueue is used to manage the submitted allocation
 	// plans that are waiting to be assessed by the leader
 	planQueue *PlanQueue
@@ -243,6 +252,9 @@ type endpoints struct {
 	ClientStats       *ClientStats
 	FileSystem        *FileSystem
 	ClientAllocations *ClientAllocations
+
+	// Event stream endpoint
+	Event *Event
 }
 
 // NewServer is used to construct a new Nomad server from the
@@ -262,6 +274,7 @@ func NewServer(config *Config, consulCatalog consul.CatalogAPI, logger *log.Logg
 	if err != nil {
 		return nil, err
 	}
+	evalBroker.SetFairQueueing(config.EvalFairQueueing, config.EvalNamespaceWeights, config.EvalMaxReadyPerJob)
 
 	// Create a new blocked eval tracker.
 	blockedEvals := NewBlockedEvals(evalBroker)
@@ -287,24 +300,26 @@ func NewServer(config *Config, consulCatalog consul.CatalogAPI, logger *log.Logg
 
 	// Create the server
 	s := &Server{
-		config:        config,
-		consulCatalog: consulCatalog,
-		connPool:      pool.NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
-		logger:        logger,
-		tlsWrap:       tlsWrap,
-		rpcServer:     rpc.NewServer(),
-		streamingRpcs: structs.NewStreamingRpcRegistry(),
-		nodeConns:     make(map[string]*nodeConnState),
-		peers:         make(map[string][]*serverParts),
-		localPeers:    make(map[raft.ServerAddress]*serverParts),
-		reconcileCh:   make(chan serf.Member, 32),
-		eventCh:       make(chan serf.Event, 256),
-		evalBroker:    evalBroker,
-		blockedEvals:  blockedEvals,
-		planQueue:     planQueue,
-		rpcTLS:        incomingTLS,
-		aclCache:      aclCache,
-		shutdownCh:    make(chan struct{}),
+		config:           config,
+		consulCatalog:    consulCatalog,
+		connPool:         pool.NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
+		logger:           logger,
+		tlsWrap:          tlsWrap,
+		rpcServer:        rpc.NewServer(),
+		streamingRpcs:    structs.NewStreamingRpcRegistry(),
+		nodeConns:        make(map[string]*nodeConnState),
+		peers:            make(map[string][]*serverParts),
+		localPeers:       make(map[raft.ServerAddress]*serverParts),
+		reassertLeaderCh: make(chan chan error),
+		reconcileCh:      make(chan serf.Member, 32),
+		eventCh:          make(chan serf.Event, 256),
+		evalBroker:       evalBroker,
+		blockedEvals:     blockedEvals,
+		eventBroker:      stream.NewEventBroker(config.EventBufferSize),
+		planQueue:        planQueue,
+		rpcTLS:           incomingTLS,
+		aclCache:         aclCache,
+		shutdownCh:       make(chan struct{}),
 	}
 
 	// Create the periodic dispatcher for launching periodic jobs.
@@ -998,6 +1013,7 @@ func (s *Server) setupRpcServer(server *rpc.Server, ctx *RPCContext) {
 		s.staticEndpoints.Node = &Node{srv: s} // Add but don't register
 		s.staticEndpoints.Deployment = &Deployment{srv: s}
 		s.staticEndpoints.Operator = &Operator{s}
+		s.staticEndpoints.Operator.register()
 		s.staticEndpoints.Periodic = &Periodic{s}
 		s.staticEndpoints.Plan = &Plan{s}
 		s.staticEndpoints.Region = &Region{s}
@@ -1009,10 +1025,13 @@ func (s *Server) setupRpcServer(server *rpc.Server, ctx *RPCContext) {
 		// Client endpoints
 		s.staticEndpoints.ClientStats = &ClientStats{s}
 		s.staticEndpoints.ClientAllocations = &ClientAllocations{s}
+		s.staticEndpoints.ClientAllocations.register()
 
 		// Streaming endpoints
 		s.staticEndpoints.FileSystem = &FileSystem{s}
 		s.staticEndpoints.FileSystem.register()
+		s.staticEndpoints.Event = &Event{srv: s, heartbeatInterval: eventStreamHeartbeatInterval}
+		s.staticEndpoints.Event.register()
 	}
 
 	// Register the static handlers
@@ -1053,11 +1072,12 @@ func (s *Server) setupRaft() error {
 
 	// Create the FSM
 	fsmConfig := &FSMConfig{
-		EvalBroker: s.evalBroker,
-		Periodic:   s.periodicDispatcher,
-		Blocked:    s.blockedEvals,
-		LogOutput:  s.config.LogOutput,
-		Region:     s.Region(),
+		EvalBroker:  s.evalBroker,
+		Periodic:    s.periodicDispatcher,
+		Blocked:     s.blockedEvals,
+		EventBroker: s.eventBroker,
+		LogOutput:   s.config.LogOutput,
+		Region:      s.Region(),
 	}
 	var err error
 	s.fsm, err = NewFSM(fsmConfig)
@@ -1090,7 +1110,9 @@ func (s *Server) setupRaft() error {
 		s.raftInmem = store
 		stable = store
 		log = store
-		snap = raft.NewDiscardSnapshotStore()
+
+		// Keep the snapshots in memory so they can be saved by operators
+		snap = raft.NewInmemSnapshotStore()
 
 	} else {
 		// Create the base raft path
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_SchedulerConfig(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// The configuration is unset initially
+	idx, config, err := s.SchedulerConfig()
+	require.Nil(err)
+	require.Zero(idx)
+	require.Nil(config)
+
+	expected := &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}
+	require.Nil(s.SchedulerSetConfig(5, expected))
+
+	idx, config, err = s.SchedulerConfig()
+	require.Nil(err)
+	require.EqualValues(5, idx)
+	require.Equal(expected, config)
+
+	// Updates keep the create index
+	require.Nil(s.SchedulerSetConfig(10, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmBinpack,
+	}))
+
+	idx, config, err = s.SchedulerConfig()
+	require.Nil(err)
+	require.EqualValues(10, idx)
+	require.Equal(structs.SchedulerAlgorithmBinpack, config.SchedulerAlgorithm)
+	require.EqualValues(5, config.CreateIndex)
+	require.EqualValues(10, config.ModifyIndex)
+}
+
+func TestStateStore_SchedulerCASConfig(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// A CAS index of zero succeeds when the configuration is unset
+	ok, err := s.SchedulerCASConfig(5, 0, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	})
+	require.Nil(err)
+	require.True(ok)
+
+	// A stale CAS index is rejected
+	ok, err = s.SchedulerCASConfig(6, 0, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmBinpack,
+	})
+	require.Nil(err)
+	require.False(ok)
+
+	_, config, err := s.SchedulerConfig()
+	require.Nil(err)
+	require.Equal(structs.SchedulerAlgorithmSpread, config.SchedulerAlgorithm)
+
+	// The current CAS index is accepted
+	ok, err = s.SchedulerCASConfig(7, config.ModifyIndex, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmBinpack,
+		MinNodeLimit:       helper.IntToPtr(4),
+	})
+	require.Nil(err)
+	require.True(ok)
+
+	idx, config, err := s.SchedulerConfig()
+	require.Nil(err)
+	require.EqualValues(7, idx)
+	require.Equal(structs.SchedulerAlgorithmBinpack, config.SchedulerAlgorithm)
+	require.Equal(4, *config.MinNodeLimit)
+	require.EqualValues(5, config.CreateIndex)
+}
@@ -192,11 +192,46 @@ func (s *StateStore) UpsertPlanResults(index uint64, results *structs.ApplyPlanR
 		alloc.Resources.Add(alloc.SharedResources)
 	}
 
+	// Mark the allocations preempted by the plan as evicted. Only the minimal
+	// set of fields is sent in the plan, so the existing allocation is copied
+	// and updated.
+	if len(results.NodePreemptions) > 0 {
+		preempted := make([]*structs.Allocation, 0, len(results.NodePreemptions))
+		for _, update := range results.NodePreemptions {
+			existing, err := txn.First("allocs", "id", update.ID)
+			if err != nil {
+				return fmt.Errorf("alloc lookup failed: %v", err)
+			}
+			if existing == nil {
+				continue
+			}
+
+			alloc := existing.(*structs.Allocation).Copy()
+			alloc.DesiredStatus = update.DesiredStatus
+			alloc.DesiredDescription = update.DesiredDescription
+			alloc.PreemptedByAllocation = update.PreemptedByAllocation
+			alloc.ModifyTime = update.ModifyTime
+			preempted = append(preempted, alloc)
+		}
+
+		if err := s.upsertAllocsImpl(index, preempted, txn); err != nil {
+			return err
+		}
+	}
+
 	// Upsert the allocations
 	if err := s.upsertAllocsImpl(index, results.Alloc, txn); err != nil {
 		return err
 	}
 
+	// Create the follow up evaluations for the jobs whose allocations were
+	// preempted
+	for _, eval := range results.PreemptionEvals {
+		if err := s.nestedUpsertEval(txn, index, eval); err != nil {
+			return err
+		}
+	}
+
 	// COMPAT: Nomad versions before 0.7.1 did not include the eval ID when
 	// applying the plan. Thus while we are upgrading, we ignore updating the
 	// modify index of evaluations from older plans.
@@ -2734,6 +2769,7 @@ func (s *StateStore) UpdateDeploymentAllocHealth(index uint64, req *structs.Appl
 				copy.DeploymentStatus = &structs.AllocDeploymentStatus{}
 			}
 			copy.DeploymentStatus.Healthy = helper.BoolToPtr(healthy)
+			copy.DeploymentStatus.Timestamp = req.Timestamp
 			copy.DeploymentStatus.ModifyIndex = index
 
 			if err := s.updateDeploymentWithAlloc(index, copy, old, txn); err != nil {
@@ -3283,6 +3319,13 @@ func (s *StateStore) updateDeploymentWithAlloc(index uint64, alloc, existing *st
 	state.HealthyAllocs += healthy
 	state.UnhealthyAllocs += unhealthy
 
+	// Extend the progress deadline when an allocation becomes healthy
+	if state.ProgressDeadline != 0 && healthy > 0 {
+		if deadline := alloc.DeploymentStatus.Timestamp.Add(state.ProgressDeadline); deadline.After(state.RequireProgressBy) {
+			state.RequireProgressBy = deadline
+		}
+	}
+
 	// Upsert the deployment
 	if err := s.upsertDeploymentImpl(index, deploymentCopy, txn); err != nil {
 		return err
@@ -3859,6 +3902,14 @@ func (r *StateRestore) ACLTokenRestore(token *structs.ACLToken) error {
 	return nil
 }
 
+// SchedulerConfigRestore is used to restore the scheduler configuration
+func (r *StateRestore) SchedulerConfigRestore(config *structs.SchedulerConfiguration) error {
+	if err := r.txn.Insert("scheduler_config", config); err != nil {
+		return fmt.Errorf("inserting scheduler config failed: %v", err)
+	}
+	return nil
+}
+
 // addEphemeralDiskToTaskGroups adds missing EphemeralDisk objects to TaskGroups
 func (r *StateRestore) addEphemeralDiskToTaskGroups(job *structs.Job) {
 	for _, tg := range job.TaskGroups {
@@ -140,6 +140,68 @@ func TestStateStore_UpsertPlanResults_AllocationsCreated_Denormalized(t *testing
 	assert.EqualValues(1000, evalOut.ModifyIndex)
 }
 
+// This test checks that allocations preempted by a plan are evicted and that
+// the follow up evaluations for their jobs are created
+func TestStateStore_UpsertPlanResults_PreemptedAllocs(t *testing.T) {
+	require := require.New(t)
+	state := testStateStore(t)
+
+	// Create the allocation that will be preempted
+	preemptedAlloc := mock.Alloc()
+	require.NoError(state.UpsertJob(997, preemptedAlloc.Job))
+	require.NoError(state.UpsertAllocs(998, []*structs.Allocation{preemptedAlloc}))
+
+	alloc := mock.Alloc()
+	job := alloc.Job
+	alloc.Job = nil
+	require.NoError(state.UpsertJob(999, job))
+
+	eval := mock.Eval()
+	eval.JobID = job.ID
+	require.NoError(state.UpsertEvals(1, []*structs.Evaluation{eval}))
+
+	// Create the eval for the job of the preempted allocation
+	preemptionEval := mock.Eval()
+	preemptionEval.JobID = preemptedAlloc.JobID
+	preemptionEval.TriggeredBy = structs.EvalTriggerPreemption
+
+	plan := &structs.Plan{}
+	plan.AppendPreemptedAlloc(preemptedAlloc, structs.AllocDesiredStatusEvict, alloc.ID)
+
+	// Create a plan result
+	res := structs.ApplyPlanResultsRequest{
+		AllocUpdateRequest: structs.AllocUpdateRequest{
+			Alloc: []*structs.Allocation{alloc},
+			Job:   job,
+		},
+		EvalID:          eval.ID,
+		NodePreemptions: plan.NodePreemptions[preemptedAlloc.NodeID],
+		PreemptionEvals: []*structs.Evaluation{preemptionEval},
+	}
+	require.NoError(state.UpsertPlanResults(1000, &res))
+
+	ws := memdb.NewWatchSet()
+
+	// Verify the preempted allocation was evicted
+	out, err := state.AllocByID(ws, preemptedAlloc.ID)
+	require.NoError(err)
+	require.Equal(structs.AllocDesiredStatusEvict, out.DesiredStatus)
+	require.Equal(alloc.ID, out.PreemptedByAllocation)
+	require.NotNil(out.Job)
+	require.EqualValues(1000, out.ModifyIndex)
+
+	// Verify the new allocation was created
+	out, err = state.AllocByID(ws, alloc.ID)
+	require.NoError(err)
+	require.NotNil(out)
+
+	// Verify the follow up eval was created
+	evalOut, err := state.EvalByID(ws, preemptionEval.ID)
+	require.NoError(err)
+	require.NotNil(evalOut)
+	require.EqualValues(1000, evalOut.CreateIndex)
+}
+
 // This test checks that the deployment is created and allocations count towards
 // the deployment
 func TestStateStore_UpsertPlanResults_Deployment(t *testing.T) {
@@ -5952,6 +6014,46 @@ func TestStateStore_UpsertDeploymentAllocHealth(t *testing.T) {
 	}
 }
 
+// Tests that marking an allocation healthy extends the progress deadline of
+// the deployment
+func TestStateStore_UpsertDeploymentAllocHealth_ProgressDeadline(t *testing.T) {
+	require := require.New(t)
+	state := testStateStore(t)
+
+	// Insert a deployment with a progress deadline
+	now := time.Now()
+	d := mock.Deployment()
+	d.TaskGroups["web"].ProgressDeadline = 5 * time.Minute
+	d.TaskGroups["web"].RequireProgressBy = now.Add(time.Minute)
+	require.Nil(state.UpsertDeployment(1, d))
+
+	// Insert an allocation
+	a := mock.Alloc()
+	a.DeploymentID = d.ID
+	require.Nil(state.UpsertAllocs(2, []*structs.Allocation{a}))
+
+	// Set health against the deployment
+	timestamp := now.Add(2 * time.Minute)
+	req := &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentAllocHealthRequest: structs.DeploymentAllocHealthRequest{
+			DeploymentID:         d.ID,
+			HealthyAllocationIDs: []string{a.ID},
+		},
+		Timestamp: timestamp,
+	}
+	require.Nil(state.UpdateDeploymentAllocHealth(3, req))
+
+	// Check the deadline was extended from the health timestamp
+	ws := memdb.NewWatchSet()
+	dout, err := state.DeploymentByID(ws, d.ID)
+	require.Nil(err)
+	require.Equal(timestamp.Add(5*time.Minute), dout.TaskGroups["web"].RequireProgressBy)
+
+	aout, err := state.AllocByID(ws, a.ID)
+	require.Nil(err)
+	require.Equal(timestamp, aout.DeploymentStatus.Timestamp)
+}
+
 func TestStateStore_UpsertVaultAccessors(t *testing.T) {
 	state := testStateStore(t)
 	a := mock.VaultAccessor()
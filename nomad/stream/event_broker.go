@@ -0,0 +1,223 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// DefaultEventBufferSize is the default number of Raft indexes whose
+	// events are buffered for subscribers resuming the stream
+	DefaultEventBufferSize = 100
+)
+
+// EventBroker buffers the events published by the FSM for the latest Raft
+// indexes and delivers them to the subscribers of the event stream.
+type EventBroker struct {
+	// size is the maximum number of indexes whose events are buffered
+	size int
+
+	// buffer holds the events of the buffered indexes in increasing index
+	// order
+	buffer []*structs.Events
+
+	// floor is the highest index whose events may no longer be buffered,
+	// subscribers can only resume the stream from later indexes
+	floor uint64
+
+	// lastIndex is the index of the last published events
+	lastIndex uint64
+
+	// notifyCh is closed and replaced when events are published or the
+	// broker is reset
+	notifyCh chan struct{}
+
+	l sync.Mutex
+}
+
+// NewEventBroker returns an event broker buffering the events of up to size
+// indexes.
+func NewEventBroker(size int) *EventBroker {
+	if size <= 0 {
+		size = DefaultEventBufferSize
+	}
+	return &EventBroker{
+		size:     size,
+		notifyCh: make(chan struct{}),
+	}
+}
+
+// Publish buffers the events of an index and notifies the subscribers. The
+// events of the oldest index are dropped when the buffer is full.
+func (b *EventBroker) Publish(events *structs.Events) {
+	if events == nil || len(events.Events) == 0 {
+		return
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	// Raft indexes are applied in order, ignore events that are already
+	// buffered
+	if events.Index <= b.lastIndex {
+		return
+	}
+
+	b.buffer = append(b.buffer, events)
+	if len(b.buffer) > b.size {
+		b.floor = b.buffer[0].Index
+		b.buffer[0] = nil
+		b.buffer = b.buffer[1:]
+	}
+	b.lastIndex = events.Index
+	b.notify()
+}
+
+// Reset drops the buffered events. It is used when the state is restored
+// from a snapshot at the given index: the events of earlier indexes are no
+// longer available.
+func (b *EventBroker) Reset(index uint64) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.buffer = nil
+	b.floor = index
+	b.lastIndex = index
+	b.notify()
+}
+
+// notify wakes up the subscribers waiting for events. The lock must be held.
+func (b *EventBroker) notify() {
+	close(b.notifyCh)
+	b.notifyCh = make(chan struct{})
+}
+
+// Subscribe returns a subscription to the events matching the request that
+// are allowed by the ACL object, which may be nil if ACLs are disabled.
+func (b *EventBroker) Subscribe(req *structs.EventStreamRequest, aclObj *acl.ACL) (*Subscription, error) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	index := req.Index
+	if index == 0 {
+		index = b.lastIndex
+	} else if index < b.floor {
+		return nil, indexUnavailableErr(index, b.floor)
+	}
+
+	return &Subscription{
+		broker: b,
+		req:    req,
+		aclObj: aclObj,
+		index:  index,
+	}, nil
+}
+
+// Subscription is a subscription to the event stream
+type Subscription struct {
+	broker *EventBroker
+	req    *structs.EventStreamRequest
+	aclObj *acl.ACL
+
+	// index is the last index whose events were sent to the subscriber
+	index uint64
+}
+
+// Next blocks until events matching the subscription are published after
+// the last index it returned and returns them. An error is returned if the
+// context is done or if the events the subscriber is waiting for were
+// dropped from the buffer.
+func (s *Subscription) Next(ctx context.Context) (*structs.Events, error) {
+	for {
+		events, notifyCh, err := s.next()
+		if err != nil || events != nil {
+			return events, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-notifyCh:
+		}
+	}
+}
+
+// next returns the next matching events in the buffer, or the channel
+// notifying the subscriber of new events when there are none.
+func (s *Subscription) next() (*structs.Events, <-chan struct{}, error) {
+	b := s.broker
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if s.index < b.floor {
+		return nil, nil, indexUnavailableErr(s.index, b.floor)
+	}
+
+	i := sort.Search(len(b.buffer), func(i int) bool {
+		return b.buffer[i].Index > s.index
+	})
+	for _, events := range b.buffer[i:] {
+		s.index = events.Index
+
+		var matching []structs.Event
+		for _, event := range events.Events {
+			if s.matches(&event) {
+				matching = append(matching, event)
+			}
+		}
+		if len(matching) != 0 {
+			return &structs.Events{Index: events.Index, Events: matching}, nil, nil
+		}
+	}
+
+	return nil, b.notifyCh, nil
+}
+
+// matches returns whether the event matches the topics and namespace of the
+// subscription and is allowed by its ACL object.
+func (s *Subscription) matches(event *structs.Event) bool {
+	if len(s.req.Topics) != 0 {
+		if !matchesKeys(event, s.req.Topics[event.Topic]) &&
+			!matchesKeys(event, s.req.Topics[structs.TopicAll]) {
+			return false
+		}
+	}
+
+	if event.Namespace != "" && s.req.Namespace != structs.EventNamespaceAll &&
+		event.Namespace != s.req.Namespace {
+		return false
+	}
+
+	if s.aclObj == nil {
+		return true
+	}
+	if event.Topic == structs.TopicNode {
+		return s.aclObj.AllowNodeRead()
+	}
+	return s.aclObj.AllowNsOp(event.Namespace, acl.NamespaceCapabilityReadJob)
+}
+
+// matchesKeys returns whether the key or one of the filter keys of the event
+// is one of the keys.
+func matchesKeys(event *structs.Event, keys []string) bool {
+	for _, key := range keys {
+		if key == structs.EventKeyAll || key == event.Key {
+			return true
+		}
+		for _, filterKey := range event.FilterKeys {
+			if key == filterKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func indexUnavailableErr(index, floor uint64) error {
+	return fmt.Errorf("events after index %d are no longer available, the stream can be resumed from index %d", index, floor)
+}
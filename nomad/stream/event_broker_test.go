@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvents(index uint64, events ...structs.Event) *structs.Events {
+	for i := range events {
+		events[i].Index = index
+	}
+	return &structs.Events{Index: index, Events: events}
+}
+
+func testNext(t *testing.T, sub *Subscription) *structs.Events {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := sub.Next(ctx)
+	require.NoError(t, err)
+	return events
+}
+
+func TestEventBroker_Subscribe(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	b := NewEventBroker(10)
+	b.Publish(testEvents(5, structs.Event{Topic: structs.TopicJob, Key: "old", Namespace: "default"}))
+
+	// Subscribing at index zero only returns new events
+	sub, err := b.Subscribe(&structs.EventStreamRequest{
+		QueryOptions: structs.QueryOptions{Namespace: "default"},
+	}, nil)
+	require.NoError(err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Publish(testEvents(6, structs.Event{Topic: structs.TopicJob, Key: "new", Namespace: "default"}))
+	}()
+
+	events := testNext(t, sub)
+	require.Equal(uint64(6), events.Index)
+	require.Len(events.Events, 1)
+	require.Equal("new", events.Events[0].Key)
+}
+
+func TestEventBroker_Resume(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	b := NewEventBroker(2)
+	for i := uint64(1); i <= 3; i++ {
+		b.Publish(testEvents(i*10, structs.Event{Topic: structs.TopicNode, Key: "node"}))
+	}
+
+	// The events of index 10 were dropped
+	_, err := b.Subscribe(&structs.EventStreamRequest{Index: 5}, nil)
+	require.Error(err)
+	require.Contains(err.Error(), "no longer available")
+
+	sub, err := b.Subscribe(&structs.EventStreamRequest{Index: 10}, nil)
+	require.NoError(err)
+	require.Equal(uint64(20), testNext(t, sub).Index)
+	require.Equal(uint64(30), testNext(t, sub).Index)
+
+	// A subscriber falling behind the buffer gets an error
+	sub, err = b.Subscribe(&structs.EventStreamRequest{Index: 20}, nil)
+	require.NoError(err)
+	b.Publish(testEvents(40, structs.Event{Topic: structs.TopicNode, Key: "node"}))
+	b.Publish(testEvents(50, structs.Event{Topic: structs.TopicNode, Key: "node"}))
+	_, err = sub.Next(context.Background())
+	require.Error(err)
+
+	// Resetting the broker drops the buffered events
+	b.Reset(100)
+	_, err = b.Subscribe(&structs.EventStreamRequest{Index: 50}, nil)
+	require.Error(err)
+}
+
+func TestEventBroker_Filter(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	b := NewEventBroker(10)
+	b.Publish(testEvents(2,
+		structs.Event{Topic: structs.TopicJob, Key: "web", Namespace: "default"},
+		structs.Event{Topic: structs.TopicJob, Key: "web", Namespace: "other"},
+		structs.Event{Topic: structs.TopicJob, Key: "db", Namespace: "default"},
+		structs.Event{Topic: structs.TopicAllocation, Key: "a1", Namespace: "default", FilterKeys: []string{"web"}},
+		structs.Event{Topic: structs.TopicAllocation, Key: "a2", Namespace: "default", FilterKeys: []string{"db"}},
+		structs.Event{Topic: structs.TopicNode, Key: "n1"},
+	))
+
+	cases := []struct {
+		name      string
+		topics    map[structs.Topic][]string
+		namespace string
+		keys      []string
+	}{
+		{
+			name:      "all",
+			namespace: "default",
+			keys:      []string{"web", "db", "a1", "a2", "n1"},
+		},
+		{
+			name:      "all namespaces",
+			namespace: "*",
+			keys:      []string{"web", "web", "db", "a1", "a2", "n1"},
+		},
+		{
+			name:      "topic",
+			topics:    map[structs.Topic][]string{structs.TopicJob: {"*"}},
+			namespace: "default",
+			keys:      []string{"web", "db"},
+		},
+		{
+			name:      "filter key",
+			topics:    map[structs.Topic][]string{structs.TopicAllocation: {"web"}},
+			namespace: "default",
+			keys:      []string{"a1"},
+		},
+		{
+			name:      "all topics",
+			topics:    map[structs.Topic][]string{structs.TopicAll: {"web"}},
+			namespace: "other",
+			keys:      []string{"web"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sub, err := b.Subscribe(&structs.EventStreamRequest{
+				Topics:       c.topics,
+				Index:        1,
+				QueryOptions: structs.QueryOptions{Namespace: c.namespace},
+			}, nil)
+			require.NoError(err)
+
+			var keys []string
+			for _, event := range testNext(t, sub).Events {
+				keys = append(keys, event.Key)
+			}
+			require.Equal(c.keys, keys)
+		})
+	}
+}
+
+func TestEventBroker_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	b := NewEventBroker(10)
+	b.Publish(testEvents(2,
+		structs.Event{Topic: structs.TopicJob, Key: "web", Namespace: "default"},
+		structs.Event{Topic: structs.TopicJob, Key: "web", Namespace: "other"},
+		structs.Event{Topic: structs.TopicNode, Key: "n1"},
+	))
+
+	policy, err := acl.Parse(mock.NamespacePolicy("other", "read", nil))
+	require.NoError(err)
+	aclObj, err := acl.NewACL(false, []*acl.Policy{policy})
+	require.NoError(err)
+
+	sub, err := b.Subscribe(&structs.EventStreamRequest{
+		Index:        1,
+		QueryOptions: structs.QueryOptions{Namespace: "*"},
+	}, aclObj)
+	require.NoError(err)
+
+	events := testNext(t, sub)
+	require.Len(events.Events, 1)
+	require.Equal("other", events.Events[0].Namespace)
+}
@@ -0,0 +1,145 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCpuset parses a list of core IDs in the cpuset format used by cgroups,
+// comma separated IDs or ranges of IDs separated by hyphens such as "0-3,6".
+// The returned IDs are sorted and unique.
+func ParseCpuset(s string) ([]uint16, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	seen := make(map[uint16]struct{})
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.Split(part, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid core range %q", part)
+		}
+
+		start, err := strconv.ParseUint(bounds[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid core %q: %v", part, err)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.ParseUint(bounds[1], 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid core %q: %v", part, err)
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid core range %q: end is less than start", part)
+			}
+		}
+
+		for i := start; i <= end; i++ {
+			seen[uint16(i)] = struct{}{}
+		}
+	}
+
+	cores := make([]uint16, 0, len(seen))
+	for id := range seen {
+		cores = append(cores, id)
+	}
+	sort.Slice(cores, func(i, j int) bool { return cores[i] < cores[j] })
+	return cores, nil
+}
+
+// FormatCpuset formats core IDs as a comma separated list, as accepted by the
+// cpuset cgroup.
+func FormatCpuset(cores []uint16) string {
+	parts := make([]string, len(cores))
+	for i, id := range cores {
+		parts[i] = strconv.Itoa(int(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+// CoreAccounter is used to account for the CPU cores dedicated to tasks on a
+// node given the allocations placed on it.
+type CoreAccounter struct {
+	// Cores are the cores of the node that can be dedicated to tasks, in
+	// ascending order. Cores reserved by the node are excluded.
+	Cores []uint16
+
+	// Used is the number of tasks using each core.
+	Used map[uint16]int
+}
+
+// NewCoreAccounter returns a core accounter for the cores of the node.
+func NewCoreAccounter(node *Node) *CoreAccounter {
+	c := &CoreAccounter{
+		Used: make(map[uint16]int),
+	}
+	if node.Resources == nil {
+		return c
+	}
+
+	reserved := make(map[uint16]struct{})
+	if node.Reserved != nil {
+		for _, id := range node.Reserved.CoreIDs {
+			reserved[id] = struct{}{}
+		}
+	}
+	for _, id := range node.Resources.CoreIDs {
+		if _, ok := reserved[id]; ok {
+			continue
+		}
+		c.Cores = append(c.Cores, id)
+		c.Used[id] = 0
+	}
+	sort.Slice(c.Cores, func(i, j int) bool { return c.Cores[i] < c.Cores[j] })
+	return c
+}
+
+// AddAllocs is used to add the cores dedicated to the tasks of the
+// allocations. Returns true if a core is used more than once or can't be
+// dedicated on the node.
+func (c *CoreAccounter) AddAllocs(allocs []*Allocation) (collide bool) {
+	for _, alloc := range allocs {
+		for _, task := range alloc.TaskResources {
+			if c.AddReserved(task.CoreIDs) {
+				collide = true
+			}
+		}
+	}
+	return
+}
+
+// AddReserved is used to add dedicated cores. Returns true if a core is used
+// more than once or can't be dedicated on the node.
+func (c *CoreAccounter) AddReserved(cores []uint16) (collide bool) {
+	for _, id := range cores {
+		count, ok := c.Used[id]
+		if !ok || count != 0 {
+			collide = true
+		}
+		c.Used[id] = count + 1
+	}
+	return
+}
+
+// AssignCores is used to assign the given number of unused cores, in
+// ascending order of their IDs.
+func (c *CoreAccounter) AssignCores(count int) ([]uint16, error) {
+	cores := make([]uint16, 0, count)
+	for _, id := range c.Cores {
+		if len(cores) == count {
+			break
+		}
+		if c.Used[id] == 0 {
+			cores = append(cores, id)
+		}
+	}
+	if len(cores) < count {
+		return nil, fmt.Errorf("not enough cores available")
+	}
+	return cores, nil
+}
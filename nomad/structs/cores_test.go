@@ -0,0 +1,74 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCpuset(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected []uint16
+		Err      bool
+	}{
+		{"", nil, false},
+		{"3", []uint16{3}, false},
+		{"0-3", []uint16{0, 1, 2, 3}, false},
+		{"6, 0-2,1\n", []uint16{0, 1, 2, 6}, false},
+		{"3-1", nil, true},
+		{"a", nil, true},
+		{"1-2-3", nil, true},
+		{"70000", nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Input, func(t *testing.T) {
+			require := require.New(t)
+			cores, err := ParseCpuset(c.Input)
+			if c.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(c.Expected, cores)
+		})
+	}
+}
+
+func TestFormatCpuset(t *testing.T) {
+	require.Equal(t, "0,2,3", FormatCpuset([]uint16{0, 2, 3}))
+	require.Equal(t, "", FormatCpuset(nil))
+}
+
+func TestCoreAccounter(t *testing.T) {
+	require := require.New(t)
+	node := &Node{
+		Resources: &Resources{
+			CoreIDs: []uint16{3, 2, 1, 0},
+		},
+		Reserved: &Resources{
+			CoreIDs: []uint16{0},
+		},
+	}
+	c := NewCoreAccounter(node)
+	require.Equal([]uint16{1, 2, 3}, c.Cores)
+
+	// Cores are assigned in order
+	cores, err := c.AssignCores(2)
+	require.NoError(err)
+	require.Equal([]uint16{1, 2}, cores)
+	require.False(c.AddReserved(cores))
+
+	_, err = c.AssignCores(2)
+	require.EqualError(err, "not enough cores available")
+
+	// Reusing cores or using reserved cores collides
+	alloc := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {CoreIDs: []uint16{2}},
+		},
+	}
+	require.True(c.AddAllocs([]*Allocation{alloc}))
+	require.True(NewCoreAccounter(node).AddReserved([]uint16{0}))
+}
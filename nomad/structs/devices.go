@@ -0,0 +1,238 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
+)
+
+// DeviceResource is used to represent devices such as FPGAs, NICs and other
+// accelerators. Like the NetworkResource, it has three uses: on nodes it lists
+// the instances of a device that are available, in the resources of a task it
+// is a request for a number of instances and in the resources of an
+// allocation it lists the instances that were assigned to the task.
+type DeviceResource struct {
+	// Vendor, Type and Name identify the device. A request only has to set
+	// the fields it cares about; the unset fields match any device.
+	Vendor string
+	Type   string
+	Name   string
+
+	// Count is the number of instances a task requests.
+	Count uint64
+
+	// Instances are the instances of the device available on a node or
+	// assigned to a task.
+	Instances []*DeviceInstance
+
+	// Attributes are fingerprinted attributes of the device.
+	Attributes map[string]string
+}
+
+// DeviceInstance is a single instance of a device.
+type DeviceInstance struct {
+	// ID is the node unique identifier of the instance.
+	ID string
+
+	// Healthy is whether the instance can be assigned to tasks.
+	Healthy bool
+}
+
+// ParseDeviceID parses a device identifier of the form "type",
+// "vendor/type" or "vendor/type/name" into a device request.
+func ParseDeviceID(id string) (*DeviceResource, error) {
+	parts := strings.Split(id, "/")
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid device %q: must be of the form type, vendor/type or vendor/type/name", id)
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		return &DeviceResource{Type: parts[0]}, nil
+	case 2:
+		return &DeviceResource{Vendor: parts[0], Type: parts[1]}, nil
+	case 3:
+		return &DeviceResource{Vendor: parts[0], Type: parts[1], Name: parts[2]}, nil
+	default:
+		return nil, fmt.Errorf("invalid device %q: must be of the form type, vendor/type or vendor/type/name", id)
+	}
+}
+
+// ID returns the identifier of the device, joining the set of vendor, type
+// and name.
+func (d *DeviceResource) ID() string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{d.Vendor, d.Type, d.Name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Matches returns whether the device satisfies the identifying fields of the
+// requested device.
+func (d *DeviceResource) Matches(ask *DeviceResource) bool {
+	if ask.Vendor != "" && ask.Vendor != d.Vendor {
+		return false
+	}
+	if ask.Type != "" && ask.Type != d.Type {
+		return false
+	}
+	if ask.Name != "" && ask.Name != d.Name {
+		return false
+	}
+	return true
+}
+
+// InstanceIDs returns the IDs of the device's instances.
+func (d *DeviceResource) InstanceIDs() []string {
+	ids := make([]string, len(d.Instances))
+	for i, inst := range d.Instances {
+		ids[i] = inst.ID
+	}
+	return ids
+}
+
+// Validate checks that a device requested by a task is valid.
+func (d *DeviceResource) Validate() error {
+	var mErr multierror.Error
+	if d.Type == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("device type must be set"))
+	}
+	if d.Name != "" && d.Vendor == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("device vendor must be set when the name is"))
+	}
+	if d.Count == 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("device count must be greater than zero"))
+	}
+	if len(d.Instances) != 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("device instances can't be requested"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a deep copy of the device resource.
+func (d *DeviceResource) Copy() *DeviceResource {
+	if d == nil {
+		return nil
+	}
+	nd := new(DeviceResource)
+	*nd = *d
+	if d.Instances != nil {
+		nd.Instances = make([]*DeviceInstance, len(d.Instances))
+		for i, inst := range d.Instances {
+			ni := *inst
+			nd.Instances[i] = &ni
+		}
+	}
+	nd.Attributes = helper.CopyMapStringString(d.Attributes)
+	return nd
+}
+
+// DeviceAccounter is used to account for the device instances that are used
+// on a node given the allocations placed on it. It is the device counterpart
+// of the NetworkIndex.
+type DeviceAccounter struct {
+	// Devices are the devices available on the node.
+	Devices []*DeviceResource
+
+	// Used is the number of users of each instance, keyed by the device's
+	// ID and the instance's ID.
+	Used map[string]map[string]int
+}
+
+// NewDeviceAccounter returns a device accounter for the devices of the node.
+func NewDeviceAccounter(node *Node) *DeviceAccounter {
+	d := &DeviceAccounter{
+		Used: make(map[string]map[string]int),
+	}
+	if node.Resources == nil {
+		return d
+	}
+
+	d.Devices = node.Resources.Devices
+	for _, dev := range d.Devices {
+		used := make(map[string]int, len(dev.Instances))
+		for _, inst := range dev.Instances {
+			used[inst.ID] = 0
+		}
+		d.Used[dev.ID()] = used
+	}
+	return d
+}
+
+// AddAllocs is used to add the devices assigned to the tasks of the
+// allocations. Returns true if an instance is used more than once or doesn't
+// exist on the node.
+func (d *DeviceAccounter) AddAllocs(allocs []*Allocation) (collide bool) {
+	for _, alloc := range allocs {
+		for _, task := range alloc.TaskResources {
+			for _, dev := range task.Devices {
+				if d.AddReserved(dev) {
+					collide = true
+				}
+			}
+		}
+	}
+	return
+}
+
+// AddReserved is used to add the instances of an assigned device. Returns
+// true if an instance is used more than once or doesn't exist on the node.
+func (d *DeviceAccounter) AddReserved(dev *DeviceResource) (collide bool) {
+	used, ok := d.Used[dev.ID()]
+	if !ok {
+		return len(dev.Instances) != 0
+	}
+
+	for _, inst := range dev.Instances {
+		count, ok := used[inst.ID]
+		if !ok || count != 0 {
+			collide = true
+		}
+		used[inst.ID] = count + 1
+	}
+	return
+}
+
+// AssignDevice is used to assign healthy, unused instances of a device
+// matching the request. All of the instances are taken from a single device
+// of the node.
+func (d *DeviceAccounter) AssignDevice(ask *DeviceResource) (*DeviceResource, error) {
+	matched := false
+	for _, dev := range d.Devices {
+		if !dev.Matches(ask) {
+			continue
+		}
+		matched = true
+
+		used := d.Used[dev.ID()]
+		var free []*DeviceInstance
+		for _, inst := range dev.Instances {
+			if inst.Healthy && used[inst.ID] == 0 {
+				free = append(free, inst)
+			}
+		}
+		if uint64(len(free)) < ask.Count {
+			continue
+		}
+
+		offer := dev.Copy()
+		offer.Count = ask.Count
+		offer.Instances = make([]*DeviceInstance, 0, ask.Count)
+		for _, inst := range free[:ask.Count] {
+			offer.Instances = append(offer.Instances, &DeviceInstance{ID: inst.ID, Healthy: inst.Healthy})
+		}
+		return offer, nil
+	}
+
+	if !matched {
+		return nil, fmt.Errorf("no devices match request %q", ask.ID())
+	}
+	return nil, fmt.Errorf("not enough %q instances available", ask.ID())
+}
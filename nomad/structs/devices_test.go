@@ -0,0 +1,134 @@
+package structs
+
+import (
+	"testing"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+func testDeviceNode() *Node {
+	return &Node{
+		Resources: &Resources{
+			Devices: []*DeviceResource{
+				{
+					Vendor: "046d",
+					Type:   "usb",
+					Name:   "c52b",
+					Instances: []*DeviceInstance{
+						{ID: "1-1.2", Healthy: true},
+						{ID: "1-1.3", Healthy: true},
+						{ID: "1-1.4", Healthy: false},
+					},
+				},
+				{
+					Vendor: "8086",
+					Type:   "nic",
+					Name:   "154c",
+					Instances: []*DeviceInstance{
+						{ID: "0000:03:02.0", Healthy: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParseDeviceID(t *testing.T) {
+	cases := []struct {
+		ID       string
+		Expected *DeviceResource
+		Err      bool
+	}{
+		{"usb", &DeviceResource{Type: "usb"}, false},
+		{"046d/usb", &DeviceResource{Vendor: "046d", Type: "usb"}, false},
+		{"046d/usb/c52b", &DeviceResource{Vendor: "046d", Type: "usb", Name: "c52b"}, false},
+		{"", nil, true},
+		{"046d//c52b", nil, true},
+		{"a/b/c/d", nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.ID, func(t *testing.T) {
+			require := require.New(t)
+			dev, err := ParseDeviceID(c.ID)
+			if c.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(c.Expected, dev)
+			require.Equal(c.ID, dev.ID())
+		})
+	}
+}
+
+func TestDeviceResource_Validate(t *testing.T) {
+	require := require.New(t)
+
+	d := &DeviceResource{Vendor: "046d", Type: "usb", Name: "c52b", Count: 1}
+	require.NoError(d.Validate())
+
+	d = &DeviceResource{Name: "c52b", Instances: []*DeviceInstance{{ID: "1-1.2"}}}
+	err := d.Validate()
+	require.Error(err)
+	mErr := err.(*multierror.Error)
+	require.Len(mErr.Errors, 4)
+}
+
+func TestDeviceAccounter_AssignDevice(t *testing.T) {
+	require := require.New(t)
+	d := NewDeviceAccounter(testDeviceNode())
+
+	// Unhealthy instances aren't assigned
+	offer, err := d.AssignDevice(&DeviceResource{Type: "usb", Count: 2})
+	require.NoError(err)
+	require.Equal("046d/usb/c52b", offer.ID())
+	require.Equal(uint64(2), offer.Count)
+	require.Equal([]string{"1-1.2", "1-1.3"}, offer.InstanceIDs())
+
+	// The assigned instances are no longer available
+	require.False(d.AddReserved(offer))
+	_, err = d.AssignDevice(&DeviceResource{Type: "usb", Count: 1})
+	require.EqualError(err, `not enough "usb" instances available`)
+
+	// Other devices are still available
+	offer, err = d.AssignDevice(&DeviceResource{Vendor: "8086", Type: "nic", Count: 1})
+	require.NoError(err)
+	require.Equal([]string{"0000:03:02.0"}, offer.InstanceIDs())
+
+	_, err = d.AssignDevice(&DeviceResource{Type: "gpu", Count: 1})
+	require.EqualError(err, `no devices match request "gpu"`)
+}
+
+func TestDeviceAccounter_AddAllocs_Collision(t *testing.T) {
+	require := require.New(t)
+	d := NewDeviceAccounter(testDeviceNode())
+
+	assigned := &DeviceResource{
+		Vendor:    "046d",
+		Type:      "usb",
+		Name:      "c52b",
+		Count:     1,
+		Instances: []*DeviceInstance{{ID: "1-1.2", Healthy: true}},
+	}
+	a1 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {Devices: []*DeviceResource{assigned}},
+		},
+	}
+	require.False(d.AddAllocs([]*Allocation{a1}))
+
+	a2 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {Devices: []*DeviceResource{assigned.Copy()}},
+		},
+	}
+	require.True(d.AddAllocs([]*Allocation{a2}))
+
+	// Instances that don't exist on the node collide
+	d = NewDeviceAccounter(testDeviceNode())
+	unknown := assigned.Copy()
+	unknown.Instances[0].ID = "9-9"
+	require.True(d.AddReserved(unknown))
+}
@@ -105,6 +105,22 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		diff.Objects = append(diff.Objects, conDiff...)
 	}
 
+	// Affinities diff
+	affinitiesDiff := primitiveObjectSetDiff(
+		interfaceSlice(j.Affinities),
+		interfaceSlice(other.Affinities),
+		[]string{"str"},
+		"Affinity",
+		contextual)
+	if affinitiesDiff != nil {
+		diff.Objects = append(diff.Objects, affinitiesDiff...)
+	}
+
+	// Spreads diff
+	if spreadDiff := spreadDiffs(j.Spreads, other.Spreads, contextual); spreadDiff != nil {
+		diff.Objects = append(diff.Objects, spreadDiff...)
+	}
+
 	// Task groups diff
 	tgs, err := taskGroupDiffs(j.TaskGroups, other.TaskGroups, contextual)
 	if err != nil {
@@ -113,7 +129,7 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 	diff.TaskGroups = tgs
 
 	// Periodic diff
-	if pDiff := primitiveObjectDiff(j.Periodic, other.Periodic, nil, "Periodic", contextual); pDiff != nil {
+	if pDiff := periodicConfigDiff(j.Periodic, other.Periodic, contextual); pDiff != nil {
 		diff.Objects = append(diff.Objects, pDiff)
 	}
 
@@ -228,6 +244,22 @@ func (tg *TaskGroup) Diff(other *TaskGroup, contextual bool) (*TaskGroupDiff, er
 		diff.Objects = append(diff.Objects, conDiff...)
 	}
 
+	// Affinities diff
+	affinitiesDiff := primitiveObjectSetDiff(
+		interfaceSlice(tg.Affinities),
+		interfaceSlice(other.Affinities),
+		[]string{"str"},
+		"Affinity",
+		contextual)
+	if affinitiesDiff != nil {
+		diff.Objects = append(diff.Objects, affinitiesDiff...)
+	}
+
+	// Spreads diff
+	if spreadDiff := spreadDiffs(tg.Spreads, other.Spreads, contextual); spreadDiff != nil {
+		diff.Objects = append(diff.Objects, spreadDiff...)
+	}
+
 	// Restart policy diff
 	rDiff := primitiveObjectDiff(tg.RestartPolicy, other.RestartPolicy, nil, "RestartPolicy", contextual)
 	if rDiff != nil {
@@ -252,6 +284,22 @@ func (tg *TaskGroup) Diff(other *TaskGroup, contextual bool) (*TaskGroupDiff, er
 		diff.Objects = append(diff.Objects, uDiff)
 	}
 
+	// Volumes diff
+	volumesDiff := primitiveObjectSetDiff(
+		volumeRequestSlice(tg.Volumes),
+		volumeRequestSlice(other.Volumes),
+		nil,
+		"Volume",
+		contextual)
+	if volumesDiff != nil {
+		diff.Objects = append(diff.Objects, volumesDiff...)
+	}
+
+	// Networks diff
+	if nDiffs := networkResourceDiffs(tg.Networks, other.Networks, contextual); nDiffs != nil {
+		diff.Objects = append(diff.Objects, nDiffs...)
+	}
+
 	// Tasks diff
 	tasks, err := taskDiffs(tg.Tasks, other.Tasks, contextual)
 	if err != nil {
@@ -387,6 +435,17 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, conDiff...)
 	}
 
+	// Affinities diff
+	affinitiesDiff := primitiveObjectSetDiff(
+		interfaceSlice(t.Affinities),
+		interfaceSlice(other.Affinities),
+		[]string{"str"},
+		"Affinity",
+		contextual)
+	if affinitiesDiff != nil {
+		diff.Objects = append(diff.Objects, affinitiesDiff...)
+	}
+
 	// Config diff
 	if cDiff := configDiff(t.Config, other.Config, contextual); cDiff != nil {
 		diff.Objects = append(diff.Objects, cDiff)
@@ -398,8 +457,7 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 	}
 
 	// LogConfig diff
-	lDiff := primitiveObjectDiff(t.LogConfig, other.LogConfig, nil, "LogConfig", contextual)
-	if lDiff != nil {
+	if lDiff := logConfigDiff(t.LogConfig, other.LogConfig, contextual); lDiff != nil {
 		diff.Objects = append(diff.Objects, lDiff)
 	}
 
@@ -409,6 +467,12 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, dDiff)
 	}
 
+	// Lifecycle diff
+	lcDiff := primitiveObjectDiff(t.Lifecycle, other.Lifecycle, nil, "Lifecycle", contextual)
+	if lcDiff != nil {
+		diff.Objects = append(diff.Objects, lcDiff)
+	}
+
 	// Artifacts diff
 	diffs := primitiveObjectSetDiff(
 		interfaceSlice(t.Artifacts),
@@ -420,6 +484,17 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, diffs...)
 	}
 
+	// Volume mounts diff
+	mountsDiff := primitiveObjectSetDiff(
+		interfaceSlice(t.VolumeMounts),
+		interfaceSlice(other.VolumeMounts),
+		nil,
+		"VolumeMount",
+		contextual)
+	if mountsDiff != nil {
+		diff.Objects = append(diff.Objects, mountsDiff...)
+	}
+
 	// Services diff
 	if sDiffs := serviceDiffs(t.Services, other.Services, contextual); sDiffs != nil {
 		diff.Objects = append(diff.Objects, sDiffs...)
@@ -508,6 +583,93 @@ func (t TaskDiffs) Len() int           { return len(t) }
 func (t TaskDiffs) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
 func (t TaskDiffs) Less(i, j int) bool { return t[i].Name < t[j].Name }
 
+// spreadDiff returns the diff of two spread objects. If contextual diff is
+// enabled, all fields will be returned, even if no diff occurred.
+func spreadDiff(old, new *Spread, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Spread"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+	filter := []string{"str"}
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &Spread{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(new, filter, true)
+	} else if new == nil {
+		new = &Spread{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(old, filter, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(old, filter, true)
+		newPrimitiveFlat = flatmap.Flatten(new, filter, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Spread targets diff
+	targetDiffs := primitiveObjectSetDiff(
+		interfaceSlice(old.SpreadTarget),
+		interfaceSlice(new.SpreadTarget),
+		filter,
+		"SpreadTarget",
+		contextual)
+	if targetDiffs != nil {
+		diff.Objects = append(diff.Objects, targetDiffs...)
+	}
+
+	// The spreads may only differ by their memoized string
+	if diff.Type == DiffTypeEdited && len(diff.Objects) == 0 {
+		edited := false
+		for _, f := range diff.Fields {
+			if f.Type != DiffTypeNone {
+				edited = true
+				break
+			}
+		}
+		if !edited {
+			return nil
+		}
+	}
+
+	return diff
+}
+
+// spreadDiffs diffs a set of spreads keyed by their attribute. If contextual
+// diff is enabled, unchanged fields within the spreads will be returned.
+func spreadDiffs(old, new []*Spread, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*Spread, len(old))
+	newMap := make(map[string]*Spread, len(new))
+	for _, o := range old {
+		oldMap[o.Attribute] = o
+	}
+	for _, n := range new {
+		newMap[n.Attribute] = n
+	}
+
+	var diffs []*ObjectDiff
+	for attribute, oldSpread := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := spreadDiff(oldSpread, newMap[attribute], contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for attribute, newSpread := range newMap {
+		// Diff the added
+		if old, ok := oldMap[attribute]; !ok {
+			if diff := spreadDiff(old, newSpread, contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
 // serviceDiff returns the diff of two service objects. If contextual diff is
 // enabled, all fields will be returned, even if no diff occurred.
 func serviceDiff(old, new *Service, contextual bool) *ObjectDiff {
@@ -697,6 +859,37 @@ func serviceCheckDiffs(old, new []*ServiceCheck, contextual bool) []*ObjectDiff
 	return diffs
 }
 
+// logConfigDiff returns the diff of two log configs, including the diff of
+// their log shippers. If contextual diff is enabled, all fields will be
+// returned, even if no diff occurred.
+func logConfigDiff(old, new *LogConfig, contextual bool) *ObjectDiff {
+	diff := primitiveObjectDiff(old, new, nil, "LogConfig", contextual)
+
+	var oldShippers, newShippers []*LogShipper
+	if old != nil {
+		oldShippers = old.Shippers
+	}
+	if new != nil {
+		newShippers = new.Shippers
+	}
+
+	sDiffs := primitiveObjectSetDiff(
+		interfaceSlice(oldShippers),
+		interfaceSlice(newShippers),
+		nil,
+		"LogShipper",
+		contextual)
+	if sDiffs == nil {
+		return diff
+	}
+
+	if diff == nil {
+		diff = &ObjectDiff{Type: DiffTypeEdited, Name: "LogConfig"}
+	}
+	diff.Objects = append(diff.Objects, sDiffs...)
+	return diff
+}
+
 // vaultDiff returns the diff of two vault objects. If contextual diff is
 // enabled, all fields will be returned, even if no diff occurred.
 func vaultDiff(old, new *Vault, contextual bool) *ObjectDiff {
@@ -733,6 +926,39 @@ func vaultDiff(old, new *Vault, contextual bool) *ObjectDiff {
 // parameterizedJobDiff returns the diff of two parameterized job objects. If
 // contextual diff is enabled, all fields will be returned, even if no diff
 // occurred.
+// periodicConfigDiff returns the diff of two periodic configs. If contextual
+// diff is enabled, all fields will be returned, even if no diff occurred.
+func periodicConfigDiff(old, new *PeriodicConfig, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Periodic"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &PeriodicConfig{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	} else if new == nil {
+		new = &PeriodicConfig{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Specs diff
+	if specsDiff := stringSetDiff(old.Specs, new.Specs, "Specs", contextual); specsDiff != nil {
+		diff.Objects = append(diff.Objects, specsDiff)
+	}
+
+	return diff
+}
+
 func parameterizedJobDiff(old, new *ParameterizedJobConfig, contextual bool) *ObjectDiff {
 	diff := &ObjectDiff{Type: DiffTypeNone, Name: "ParameterizedJob"}
 	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
@@ -798,6 +1024,16 @@ func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
 		diff.Objects = append(diff.Objects, nDiffs...)
 	}
 
+	// Requested devices diff
+	if dDiffs := primitiveObjectSetDiff(
+		interfaceSlice(r.Devices),
+		interfaceSlice(other.Devices),
+		nil,
+		"Device",
+		contextual); dDiffs != nil {
+		diff.Objects = append(diff.Objects, dDiffs...)
+	}
+
 	return diff
 }
 
@@ -1271,3 +1507,19 @@ func interfaceSlice(slice interface{}) []interface{} {
 
 	return ret
 }
+
+// volumeRequestSlice returns the volume requests of a task group sorted by
+// name for diffing
+func volumeRequestSlice(volumes map[string]*VolumeRequest) []interface{} {
+	names := make([]string, 0, len(volumes))
+	for name := range volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := make([]interface{}, len(names))
+	for i, name := range names {
+		ret[i] = volumes[name]
+	}
+	return ret
+}
@@ -439,6 +439,18 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeAdded,
 						Name: "Periodic",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "CatchUp",
+								Old:  "",
+								New:  "false",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "CatchUpWindow",
+								Old:  "",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeAdded,
 								Name: "Enabled",
@@ -493,6 +505,18 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeDeleted,
 						Name: "Periodic",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "CatchUp",
+								Old:  "false",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "CatchUpWindow",
+								Old:  "0",
+								New:  "",
+							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "Enabled",
@@ -543,8 +567,11 @@ func TestJobDiff(t *testing.T) {
 				Periodic: &PeriodicConfig{
 					Enabled:         true,
 					Spec:            "* * * * * *",
+					Specs:           []string{"0 0 * * 6"},
 					SpecType:        "cron",
 					ProhibitOverlap: true,
+					CatchUp:         true,
+					CatchUpWindow:   time.Hour,
 					TimeZone:        "America/Los_Angeles",
 				},
 			},
@@ -555,6 +582,18 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "Periodic",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "CatchUp",
+								Old:  "false",
+								New:  "true",
+							},
+							{
+								Type: DiffTypeEdited,
+								Name: "CatchUpWindow",
+								Old:  "0",
+								New:  "3600000000000",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "Enabled",
@@ -586,6 +625,20 @@ func TestJobDiff(t *testing.T) {
 								New:  "America/Los_Angeles",
 							},
 						},
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Specs",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Specs",
+										Old:  "",
+										New:  "0 0 * * 6",
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -618,6 +671,18 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "Periodic",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeNone,
+								Name: "CatchUp",
+								Old:  "false",
+								New:  "false",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "CatchUpWindow",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "Enabled",
@@ -741,6 +806,78 @@ func TestJobDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Spreads edited
+			Old: &Job{
+				Spreads: []*Spread{
+					{
+						Attribute: "${node.datacenter}",
+						Weight:    50,
+						SpreadTarget: []*SpreadTarget{
+							{
+								Value:   "dc1",
+								Percent: 50,
+							},
+						},
+					},
+				},
+			},
+			New: &Job{
+				Spreads: []*Spread{
+					{
+						Attribute: "${node.datacenter}",
+						Weight:    80,
+						SpreadTarget: []*SpreadTarget{
+							{
+								Value:   "dc1",
+								Percent: 50,
+							},
+							{
+								Value:   "dc2",
+								Percent: 30,
+							},
+						},
+					},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Spread",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Weight",
+								Old:  "50",
+								New:  "80",
+							},
+						},
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "SpreadTarget",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Percent",
+										Old:  "",
+										New:  "30",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "Value",
+										Old:  "",
+										New:  "dc2",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Task groups edited
 			Old: &Job{
@@ -1757,6 +1894,12 @@ func TestTaskGroupDiff(t *testing.T) {
 						Type: DiffTypeDeleted,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "AutoPromote",
+								Old:  "false",
+								New:  "",
+							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "AutoRevert",
@@ -1787,6 +1930,12 @@ func TestTaskGroupDiff(t *testing.T) {
 								Old:  "0",
 								New:  "",
 							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "ProgressDeadline",
+								Old:  "0",
+								New:  "",
+							},
 						},
 					},
 				},
@@ -1807,6 +1956,12 @@ func TestTaskGroupDiff(t *testing.T) {
 						Type: DiffTypeAdded,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "AutoPromote",
+								Old:  "",
+								New:  "false",
+							},
 							{
 								Type: DiffTypeAdded,
 								Name: "AutoRevert",
@@ -1837,6 +1992,12 @@ func TestTaskGroupDiff(t *testing.T) {
 								Old:  "",
 								New:  "0",
 							},
+							{
+								Type: DiffTypeAdded,
+								Name: "ProgressDeadline",
+								Old:  "",
+								New:  "0",
+							},
 						},
 					},
 				},
@@ -1846,22 +2007,25 @@ func TestTaskGroupDiff(t *testing.T) {
 			// Update strategy edited
 			Old: &TaskGroup{
 				Update: &UpdateStrategy{
-					MaxParallel:     5,
-					HealthCheck:     "foo",
-					MinHealthyTime:  1 * time.Second,
-					HealthyDeadline: 30 * time.Second,
-					AutoRevert:      true,
-					Canary:          2,
+					MaxParallel:      5,
+					HealthCheck:      "foo",
+					MinHealthyTime:   1 * time.Second,
+					HealthyDeadline:  30 * time.Second,
+					ProgressDeadline: 1 * time.Minute,
+					AutoRevert:       true,
+					Canary:           2,
 				},
 			},
 			New: &TaskGroup{
 				Update: &UpdateStrategy{
-					MaxParallel:     7,
-					HealthCheck:     "bar",
-					MinHealthyTime:  2 * time.Second,
-					HealthyDeadline: 31 * time.Second,
-					AutoRevert:      false,
-					Canary:          1,
+					MaxParallel:      7,
+					HealthCheck:      "bar",
+					MinHealthyTime:   2 * time.Second,
+					HealthyDeadline:  31 * time.Second,
+					ProgressDeadline: 2 * time.Minute,
+					AutoRevert:       false,
+					AutoPromote:      true,
+					Canary:           1,
 				},
 			},
 			Expected: &TaskGroupDiff{
@@ -1871,6 +2035,12 @@ func TestTaskGroupDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "AutoPromote",
+								Old:  "false",
+								New:  "true",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "AutoRevert",
@@ -1907,6 +2077,12 @@ func TestTaskGroupDiff(t *testing.T) {
 								Old:  "1000000000",
 								New:  "2000000000",
 							},
+							{
+								Type: DiffTypeEdited,
+								Name: "ProgressDeadline",
+								Old:  "60000000000",
+								New:  "120000000000",
+							},
 						},
 					},
 				},
@@ -1917,22 +2093,24 @@ func TestTaskGroupDiff(t *testing.T) {
 			Contextual: true,
 			Old: &TaskGroup{
 				Update: &UpdateStrategy{
-					MaxParallel:     5,
-					HealthCheck:     "foo",
-					MinHealthyTime:  1 * time.Second,
-					HealthyDeadline: 30 * time.Second,
-					AutoRevert:      true,
-					Canary:          2,
+					MaxParallel:      5,
+					HealthCheck:      "foo",
+					MinHealthyTime:   1 * time.Second,
+					HealthyDeadline:  30 * time.Second,
+					ProgressDeadline: 1 * time.Minute,
+					AutoRevert:       true,
+					Canary:           2,
 				},
 			},
 			New: &TaskGroup{
 				Update: &UpdateStrategy{
-					MaxParallel:     7,
-					HealthCheck:     "foo",
-					MinHealthyTime:  1 * time.Second,
-					HealthyDeadline: 30 * time.Second,
-					AutoRevert:      true,
-					Canary:          2,
+					MaxParallel:      7,
+					HealthCheck:      "foo",
+					MinHealthyTime:   1 * time.Second,
+					HealthyDeadline:  30 * time.Second,
+					ProgressDeadline: 1 * time.Minute,
+					AutoRevert:       true,
+					Canary:           2,
 				},
 			},
 			Expected: &TaskGroupDiff{
@@ -1942,6 +2120,12 @@ func TestTaskGroupDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeNone,
+								Name: "AutoPromote",
+								Old:  "false",
+								New:  "false",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "AutoRevert",
@@ -1978,6 +2162,12 @@ func TestTaskGroupDiff(t *testing.T) {
 								Old:  "1000000000",
 								New:  "1000000000",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "ProgressDeadline",
+								Old:  "60000000000",
+								New:  "60000000000",
+							},
 						},
 					},
 				},
@@ -2586,6 +2776,12 @@ func TestTaskDiff(t *testing.T) {
 						Type: DiffTypeAdded,
 						Name: "LogConfig",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Compress",
+								Old:  "",
+								New:  "false",
+							},
 							{
 								Type: DiffTypeAdded,
 								Name: "MaxFileSizeMB",
@@ -2598,6 +2794,18 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "",
 								New:  "1",
 							},
+							{
+								Type: DiffTypeAdded,
+								Name: "MaxTotalSizeMB",
+								Old:  "",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "RotationInterval",
+								Old:  "",
+								New:  "0",
+							},
 						},
 					},
 				},
@@ -2619,6 +2827,12 @@ func TestTaskDiff(t *testing.T) {
 						Type: DiffTypeDeleted,
 						Name: "LogConfig",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "Compress",
+								Old:  "false",
+								New:  "",
+							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "MaxFileSizeMB",
@@ -2631,6 +2845,18 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "1",
 								New:  "",
 							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "MaxTotalSizeMB",
+								Old:  "0",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "RotationInterval",
+								Old:  "0",
+								New:  "",
+							},
 						},
 					},
 				},
@@ -2696,6 +2922,12 @@ func TestTaskDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "LogConfig",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeNone,
+								Name: "Compress",
+								Old:  "false",
+								New:  "false",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "MaxFileSizeMB",
@@ -2708,6 +2940,89 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "1",
 								New:  "1",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "MaxTotalSizeMB",
+								Old:  "0",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "RotationInterval",
+								Old:  "0",
+								New:  "0",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "LogConfig shipper added",
+			Old: &Task{
+				LogConfig: &LogConfig{
+					MaxFiles:      1,
+					MaxFileSizeMB: 10,
+				},
+			},
+			New: &Task{
+				LogConfig: &LogConfig{
+					MaxFiles:      1,
+					MaxFileSizeMB: 10,
+					Shippers: []*LogShipper{
+						{
+							Type:          LogShipperTypeTCP,
+							Address:       "127.0.0.1:5140",
+							BufferSize:    10,
+							BatchSize:     1,
+							FlushInterval: time.Second,
+						},
+					},
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "LogConfig",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "LogShipper",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Address",
+										Old:  "",
+										New:  "127.0.0.1:5140",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "BatchSize",
+										Old:  "",
+										New:  "1",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "BufferSize",
+										Old:  "",
+										New:  "10",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "FlushInterval",
+										Old:  "",
+										New:  "1000000000",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "Type",
+										Old:  "",
+										New:  "tcp",
+									},
+								},
+							},
 						},
 					},
 				},
@@ -2905,6 +3220,12 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "100",
 								New:  "200",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "Cores",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "DiskMB",
@@ -2923,6 +3244,12 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "100",
 								New:  "100",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "MemoryMaxMB",
+								Old:  "0",
+								New:  "0",
+							},
 						},
 					},
 				},
@@ -3005,6 +3332,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "",
 												New:  "foo",
 											},
+											{
+												Type: DiffTypeAdded,
+												Name: "To",
+												Old:  "",
+												New:  "0",
+											},
 											{
 												Type: DiffTypeAdded,
 												Name: "Value",
@@ -3023,6 +3356,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "",
 												New:  "baz",
 											},
+											{
+												Type: DiffTypeAdded,
+												Name: "To",
+												Old:  "",
+												New:  "0",
+											},
 										},
 									},
 								},
@@ -3049,6 +3388,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "foo",
 												New:  "",
 											},
+											{
+												Type: DiffTypeDeleted,
+												Name: "To",
+												Old:  "0",
+												New:  "",
+											},
 											{
 												Type: DiffTypeDeleted,
 												Name: "Value",
@@ -3067,6 +3412,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "bar",
 												New:  "",
 											},
+											{
+												Type: DiffTypeDeleted,
+												Name: "To",
+												Old:  "0",
+												New:  "",
+											},
 										},
 									},
 								},
@@ -3274,6 +3625,12 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "boom_port",
 								New:  "boom_port",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "boom.To",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "boom.Value",
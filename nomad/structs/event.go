@@ -0,0 +1,112 @@
+package structs
+
+// Topic is the kind of objects the events of the event stream are about
+type Topic string
+
+const (
+	// TopicAll subscribes to the events of every topic
+	TopicAll        Topic = "*"
+	TopicJob        Topic = "Job"
+	TopicAllocation Topic = "Allocation"
+	TopicDeployment Topic = "Deployment"
+	TopicEvaluation Topic = "Evaluation"
+	TopicNode       Topic = "Node"
+)
+
+const (
+	// EventKeyAll matches the events of every key of a topic
+	EventKeyAll = "*"
+
+	// EventNamespaceAll matches the events of every namespace
+	EventNamespaceAll = "*"
+)
+
+// The types of events published to the event stream
+const (
+	TypeJobRegistered          = "JobRegistered"
+	TypeJobDeregistered        = "JobDeregistered"
+	TypeAllocationUpdated      = "AllocationUpdated"
+	TypeDeploymentStatusUpdate = "DeploymentStatusUpdate"
+	TypeDeploymentPromotion    = "DeploymentPromotion"
+	TypeDeploymentAllocHealth  = "DeploymentAllocHealth"
+	TypeEvaluationUpdated      = "EvaluationUpdated"
+	TypeNodeRegistration       = "NodeRegistration"
+	TypeNodeDeregistration     = "NodeDeregistration"
+	TypeNodeStatusUpdate       = "NodeStatusUpdate"
+	TypeNodeDrain              = "NodeDrain"
+	TypeNodeEligibility        = "NodeEligibility"
+)
+
+// Event is a change of the state of the cluster applied at a Raft index
+type Event struct {
+	// Topic is the kind of object the event is about
+	Topic Topic
+
+	// Type is the type of the event, such as JobRegistered
+	Type string
+
+	// Key is the ID of the object the event is about
+	Key string
+
+	// Namespace is the namespace of the object, it is empty for objects that
+	// aren't namespaced such as nodes
+	Namespace string
+
+	// FilterKeys are other keys the event can be matched with when
+	// subscribing, such as the job ID of an allocation
+	FilterKeys []string
+
+	// Index is the Raft index the change was applied at
+	Index uint64
+
+	// Payload is the object the event is about, wrapped in a JobEvent,
+	// AllocationEvent, DeploymentEvent, EvaluationEvent or NodeStreamEvent
+	Payload interface{}
+}
+
+// Events are the events published for a Raft index
+type Events struct {
+	Index  uint64
+	Events []Event
+}
+
+// JobEvent is the payload of the events of the Job topic
+type JobEvent struct {
+	Job *Job
+}
+
+// AllocationEvent is the payload of the events of the Allocation topic. The
+// job of the allocation is omitted.
+type AllocationEvent struct {
+	Allocation *Allocation
+}
+
+// DeploymentEvent is the payload of the events of the Deployment topic
+type DeploymentEvent struct {
+	Deployment *Deployment
+}
+
+// EvaluationEvent is the payload of the events of the Evaluation topic
+type EvaluationEvent struct {
+	Evaluation *Evaluation
+}
+
+// NodeStreamEvent is the payload of the events of the Node topic. The secret
+// ID of the node is omitted.
+type NodeStreamEvent struct {
+	Node *Node
+}
+
+// EventStreamRequest is used to subscribe to the event stream
+type EventStreamRequest struct {
+	// Topics maps the topics to subscribe to to the keys of the events to
+	// receive, EventKeyAll receiving every event of the topic. All the events
+	// are received when it is empty.
+	Topics map[Topic][]string
+
+	// Index is the Raft index to resume the stream from: the events of the
+	// later indexes are sent. Only new events are sent when it is zero.
+	Index uint64
+
+	QueryOptions
+}
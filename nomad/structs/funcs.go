@@ -154,14 +154,26 @@ func AllocsFit(node *Node, allocs []*Allocation, netIdx *NetworkIndex) (bool, st
 		return false, "bandwidth exceeded", used, nil
 	}
 
+	// Check that the assigned device instances exist and are only used once
+	devAcct := NewDeviceAccounter(node)
+	for _, d := range used.Devices {
+		if devAcct.AddReserved(d) {
+			return false, "device oversubscribed", used, nil
+		}
+	}
+
+	// Check that the dedicated cores can be dedicated and are only used once
+	if NewCoreAccounter(node).AddAllocs(allocs) {
+		return false, "cores oversubscribed", used, nil
+	}
+
 	// Allocations fit!
 	return true, "", used, nil
 }
 
-// ScoreFit is used to score the fit based on the Google work published here:
-// http://www.columbia.edu/~cs2035/courses/ieor4405.S13/datacenter_scheduling.ppt
-// This is equivalent to their BestFit v3
-func ScoreFit(node *Node, util *Resources) float64 {
+// computeFreePercentage returns the percentage of the node's CPU and memory
+// that is free given the utilization.
+func computeFreePercentage(node *Node, util *Resources) (freePctCpu, freePctRam float64) {
 	// Determine the node availability
 	nodeCpu := float64(node.Resources.CPU)
 	if node.Reserved != nil {
@@ -173,8 +185,16 @@ func ScoreFit(node *Node, util *Resources) float64 {
 	}
 
 	// Compute the free percentage
-	freePctCpu := 1 - (float64(util.CPU) / nodeCpu)
-	freePctRam := 1 - (float64(util.MemoryMB) / nodeMem)
+	freePctCpu = 1 - (float64(util.CPU) / nodeCpu)
+	freePctRam = 1 - (float64(util.MemoryMB) / nodeMem)
+	return freePctCpu, freePctRam
+}
+
+// ScoreFit is used to score the fit based on the Google work published here:
+// http://www.columbia.edu/~cs2035/courses/ieor4405.S13/datacenter_scheduling.ppt
+// This is equivalent to their BestFit v3
+func ScoreFit(node *Node, util *Resources) float64 {
+	freePctCpu, freePctRam := computeFreePercentage(node, util)
 
 	// Total will be "maximized" the smaller the value is.
 	// At 100% utilization, the total is 2, while at 0% util it is 20.
@@ -195,6 +215,25 @@ func ScoreFit(node *Node, util *Resources) float64 {
 	return score
 }
 
+// ScoreFitSpread is the inverse of ScoreFit. It favors nodes with the most
+// free resources, spreading allocations across nodes instead of packing them.
+func ScoreFitSpread(node *Node, util *Resources) float64 {
+	freePctCpu, freePctRam := computeFreePercentage(node, util)
+
+	// Total is 2 at 100% utilization and 20 at 0% utilization. Anchor on
+	// the floor so that an idle node scores 18.
+	total := math.Pow(10, freePctCpu) + math.Pow(10, freePctRam)
+	score := total - 2.0
+
+	// Bound the score, just in case
+	if score > 18.0 {
+		score = 18.0
+	} else if score < 0 {
+		score = 0
+	}
+	return score
+}
+
 func CopySliceConstraints(s []*Constraint) []*Constraint {
 	l := len(s)
 	if l == 0 {
@@ -208,6 +247,45 @@ func CopySliceConstraints(s []*Constraint) []*Constraint {
 	return c
 }
 
+func CopySliceAffinities(s []*Affinity) []*Affinity {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	c := make([]*Affinity, l)
+	for i, v := range s {
+		c[i] = v.Copy()
+	}
+	return c
+}
+
+func CopySliceSpreads(s []*Spread) []*Spread {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	c := make([]*Spread, l)
+	for i, v := range s {
+		c[i] = v.Copy()
+	}
+	return c
+}
+
+func CopySliceSpreadTarget(s []*SpreadTarget) []*SpreadTarget {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	c := make([]*SpreadTarget, l)
+	for i, v := range s {
+		c[i] = v.Copy()
+	}
+	return c
+}
+
 // VaultPoliciesSet takes the structure returned by VaultPolicies and returns
 // the set of required policies
 func VaultPoliciesSet(policies map[string]map[string]*Vault) []string {
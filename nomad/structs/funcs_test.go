@@ -106,7 +106,7 @@ func TestAllocsFit_PortsOvercommitted(t *testing.T) {
 						Device:        "eth0",
 						IP:            "10.0.0.1",
 						MBits:         50,
-						ReservedPorts: []Port{{"main", 8000}},
+						ReservedPorts: []Port{{"main", 8000, 0}},
 					},
 				},
 			},
@@ -157,7 +157,7 @@ func TestAllocsFit(t *testing.T) {
 					Device:        "eth0",
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"main", 80}},
+					ReservedPorts: []Port{{"main", 80, 0}},
 				},
 			},
 		},
@@ -174,7 +174,7 @@ func TestAllocsFit(t *testing.T) {
 					Device:        "eth0",
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"main", 8000}},
+					ReservedPorts: []Port{{"main", 8000, 0}},
 				},
 			},
 		},
@@ -216,6 +216,81 @@ func TestAllocsFit(t *testing.T) {
 
 }
 
+func TestAllocsFit_DevicesOversubscribed(t *testing.T) {
+	n := testDeviceNode()
+	assigned := &DeviceResource{
+		Vendor:    "046d",
+		Type:      "usb",
+		Name:      "c52b",
+		Count:     1,
+		Instances: []*DeviceInstance{{ID: "1-1.2", Healthy: true}},
+	}
+	a1 := &Allocation{
+		Resources: &Resources{
+			Devices: []*DeviceResource{assigned},
+		},
+	}
+
+	// Should fit one allocation
+	fit, _, used, err := AllocsFit(n, []*Allocation{a1}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit {
+		t.Fatalf("Bad")
+	}
+	if len(used.Devices) != 1 {
+		t.Fatalf("bad: %#v", used)
+	}
+
+	// Should not fit a second allocation using the same instance
+	fit, dim, _, err := AllocsFit(n, []*Allocation{a1, a1.Copy()}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit || dim != "device oversubscribed" {
+		t.Fatalf("Bad: %v %q", fit, dim)
+	}
+}
+
+func TestAllocsFit_CoresOversubscribed(t *testing.T) {
+	n := &Node{
+		Resources: &Resources{
+			CPU:      4000,
+			MemoryMB: 4096,
+			CoreIDs:  []uint16{0, 1, 2, 3},
+		},
+	}
+	a1 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {
+				CPU:      2000,
+				MemoryMB: 256,
+				Cores:    2,
+				CoreIDs:  []uint16{0, 1},
+			},
+		},
+	}
+
+	// Should fit one allocation
+	fit, _, _, err := AllocsFit(n, []*Allocation{a1}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit {
+		t.Fatalf("Bad")
+	}
+
+	// Should not fit a second allocation using the same cores
+	fit, dim, _, err := AllocsFit(n, []*Allocation{a1, a1.Copy()}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit || dim != "cores oversubscribed" {
+		t.Fatalf("Bad: %v %q", fit, dim)
+	}
+}
+
 func TestScoreFit(t *testing.T) {
 	node := &Node{}
 	node.Resources = &Resources{
@@ -258,6 +333,46 @@ func TestScoreFit(t *testing.T) {
 	}
 }
 
+func TestScoreFitSpread(t *testing.T) {
+	node := &Node{}
+	node.Resources = &Resources{
+		CPU:      4096,
+		MemoryMB: 8192,
+	}
+	node.Reserved = &Resources{
+		CPU:      2048,
+		MemoryMB: 4096,
+	}
+
+	// A fully utilized node is the worst fit
+	util := &Resources{
+		CPU:      2048,
+		MemoryMB: 4096,
+	}
+	if score := ScoreFitSpread(node, util); score != 0.0 {
+		t.Fatalf("bad: %v", score)
+	}
+
+	// An idle node is the best fit
+	util = &Resources{
+		CPU:      0,
+		MemoryMB: 0,
+	}
+	if score := ScoreFitSpread(node, util); score != 18.0 {
+		t.Fatalf("bad: %v", score)
+	}
+
+	// The scores of a half utilized node are the inverse of each other
+	util = &Resources{
+		CPU:      1024,
+		MemoryMB: 2048,
+	}
+	spread, binpack := ScoreFitSpread(node, util), ScoreFit(node, util)
+	if spread < 2.0 || spread > 8.0 || spread+binpack != 18.0 {
+		t.Fatalf("bad: %v %v", spread, binpack)
+	}
+}
+
 func TestACLPolicyListHash(t *testing.T) {
 	h1 := ACLPolicyListHash(nil)
 	assert.NotEqual(t, "", h1)
@@ -93,6 +93,15 @@ func (idx *NetworkIndex) SetNode(node *Node) (collide bool) {
 // true if there is a collision
 func (idx *NetworkIndex) AddAllocs(allocs []*Allocation) (collide bool) {
 	for _, alloc := range allocs {
+		// Add the group network shared by the tasks
+		if alloc.SharedResources != nil {
+			for _, n := range alloc.SharedResources.Networks {
+				if idx.AddReserved(n) {
+					collide = true
+				}
+			}
+		}
+
 		for _, task := range alloc.TaskResources {
 			if len(task.Networks) == 0 {
 				continue
@@ -202,6 +211,7 @@ func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResour
 
 		// Create the offer
 		offer := &NetworkResource{
+			Mode:          ask.Mode,
 			Device:        n.Device,
 			IP:            ipStr,
 			MBits:         ask.MBits,
@@ -1,8 +1,11 @@
 package structs
 
 import (
+	"fmt"
 	"time"
 
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/raft"
 )
 
@@ -119,3 +122,398 @@ type AutopilotConfig struct {
 	CreateIndex uint64
 	ModifyIndex uint64
 }
+
+// SchedulerAlgorithm is the algorithm the schedulers use to score the fit of
+// allocations on nodes.
+type SchedulerAlgorithm string
+
+const (
+	// SchedulerAlgorithmBinpack packs allocations tightly onto as few nodes
+	// as possible.
+	SchedulerAlgorithmBinpack SchedulerAlgorithm = "binpack"
+
+	// SchedulerAlgorithmSpread spreads allocations across as many nodes as
+	// possible.
+	SchedulerAlgorithmSpread SchedulerAlgorithm = "spread"
+)
+
+const (
+	// DefaultSchedulerMinNodeLimit is the default minimum number of nodes the
+	// service and batch schedulers score before making a placement.
+	DefaultSchedulerMinNodeLimit = 2
+
+	// DefaultSchedulerMaxSkippedNodes is the default number of low scoring
+	// nodes the service and batch schedulers may skip over.
+	DefaultSchedulerMaxSkippedNodes = 3
+
+	// DefaultServiceJobAntiAffinityPenalty is the default penalty applied to
+	// the score for placing an alloc on a node that already has an alloc for
+	// the same service job.
+	DefaultServiceJobAntiAffinityPenalty = 20.0
+
+	// DefaultBatchJobAntiAffinityPenalty is the same as the
+	// DefaultServiceJobAntiAffinityPenalty but for batch type jobs.
+	DefaultBatchJobAntiAffinityPenalty = 10.0
+
+	// DefaultMaxServiceScheduleAttempts is the default number of times the
+	// service scheduler attempts to schedule an evaluation before giving up.
+	DefaultMaxServiceScheduleAttempts = 5
+
+	// DefaultMaxBatchScheduleAttempts is the default number of times the
+	// batch scheduler attempts to schedule an evaluation before giving up.
+	DefaultMaxBatchScheduleAttempts = 2
+)
+
+// SchedulerConfiguration is the cluster wide configuration of the
+// schedulers. It is stored in Raft and set by operators. Unset values take
+// their defaults when the configuration is canonicalized while values set to
+// zero are kept.
+type SchedulerConfiguration struct {
+	// SchedulerAlgorithm is the algorithm used to score the fit of
+	// allocations on nodes. It defaults to binpack.
+	SchedulerAlgorithm SchedulerAlgorithm
+
+	// MinNodeLimit is the minimum number of feasible nodes the service and
+	// batch schedulers score before picking the best one. Task groups with
+	// affinities or spreads always score every feasible node.
+	MinNodeLimit *int
+
+	// MaxSkippedNodes is the number of nodes scoring below the skip
+	// threshold, such as nodes already running the job, that the service and
+	// batch schedulers may pass over in search of a better node.
+	MaxSkippedNodes *int
+
+	// ServiceJobAntiAffinityPenalty is the penalty applied to the score for
+	// placing an alloc on a node that already has an alloc for the same
+	// service job.
+	ServiceJobAntiAffinityPenalty *float64
+
+	// BatchJobAntiAffinityPenalty is the same as the
+	// ServiceJobAntiAffinityPenalty but for batch type jobs.
+	BatchJobAntiAffinityPenalty *float64
+
+	// MaxServiceScheduleAttempts is the number of times the service scheduler
+	// attempts to schedule an evaluation without making progress before it
+	// gives up.
+	MaxServiceScheduleAttempts *int
+
+	// MaxBatchScheduleAttempts is the same as the MaxServiceScheduleAttempts
+	// but for batch type jobs.
+	MaxBatchScheduleAttempts *int
+
+	// DisableBlockedEvals stops the schedulers from creating blocked
+	// evaluations for allocations that can't be placed. The failed placements
+	// are then only retried the next time the job is evaluated.
+	DisableBlockedEvals bool
+
+	// MemoryOversubscriptionEnabled allows tasks to set a MemoryMaxMB above
+	// the memory reserved for them by the scheduler. When disabled the
+	// MemoryMaxMB of tasks is ignored and MemoryMB is their hard limit.
+	MemoryOversubscriptionEnabled bool
+
+	// CreateIndex/ModifyIndex store the create/modify indexes of this configuration.
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// DefaultSchedulerConfiguration returns the scheduler configuration used
+// when an operator has not set one.
+func DefaultSchedulerConfiguration() *SchedulerConfiguration {
+	c := &SchedulerConfiguration{}
+	c.Canonicalize()
+	return c
+}
+
+// Copy returns a copy of the scheduler configuration.
+func (s *SchedulerConfiguration) Copy() *SchedulerConfiguration {
+	if s == nil {
+		return nil
+	}
+	c := new(SchedulerConfiguration)
+	*c = *s
+	if s.MinNodeLimit != nil {
+		c.MinNodeLimit = helper.IntToPtr(*s.MinNodeLimit)
+	}
+	if s.MaxSkippedNodes != nil {
+		c.MaxSkippedNodes = helper.IntToPtr(*s.MaxSkippedNodes)
+	}
+	if s.ServiceJobAntiAffinityPenalty != nil {
+		c.ServiceJobAntiAffinityPenalty = helper.Float64ToPtr(*s.ServiceJobAntiAffinityPenalty)
+	}
+	if s.BatchJobAntiAffinityPenalty != nil {
+		c.BatchJobAntiAffinityPenalty = helper.Float64ToPtr(*s.BatchJobAntiAffinityPenalty)
+	}
+	if s.MaxServiceScheduleAttempts != nil {
+		c.MaxServiceScheduleAttempts = helper.IntToPtr(*s.MaxServiceScheduleAttempts)
+	}
+	if s.MaxBatchScheduleAttempts != nil {
+		c.MaxBatchScheduleAttempts = helper.IntToPtr(*s.MaxBatchScheduleAttempts)
+	}
+	return c
+}
+
+// Canonicalize sets the unset values of the configuration to their defaults.
+func (s *SchedulerConfiguration) Canonicalize() {
+	if s.SchedulerAlgorithm == "" {
+		s.SchedulerAlgorithm = SchedulerAlgorithmBinpack
+	}
+	if s.MinNodeLimit == nil {
+		s.MinNodeLimit = helper.IntToPtr(DefaultSchedulerMinNodeLimit)
+	}
+	if s.MaxSkippedNodes == nil {
+		s.MaxSkippedNodes = helper.IntToPtr(DefaultSchedulerMaxSkippedNodes)
+	}
+	if s.ServiceJobAntiAffinityPenalty == nil {
+		s.ServiceJobAntiAffinityPenalty = helper.Float64ToPtr(DefaultServiceJobAntiAffinityPenalty)
+	}
+	if s.BatchJobAntiAffinityPenalty == nil {
+		s.BatchJobAntiAffinityPenalty = helper.Float64ToPtr(DefaultBatchJobAntiAffinityPenalty)
+	}
+	if s.MaxServiceScheduleAttempts == nil {
+		s.MaxServiceScheduleAttempts = helper.IntToPtr(DefaultMaxServiceScheduleAttempts)
+	}
+	if s.MaxBatchScheduleAttempts == nil {
+		s.MaxBatchScheduleAttempts = helper.IntToPtr(DefaultMaxBatchScheduleAttempts)
+	}
+}
+
+// EffectiveSchedulerAlgorithm returns the scheduler algorithm to use, taking
+// into account the default when it is unset.
+func (s *SchedulerConfiguration) EffectiveSchedulerAlgorithm() SchedulerAlgorithm {
+	if s == nil || s.SchedulerAlgorithm == "" {
+		return SchedulerAlgorithmBinpack
+	}
+	return s.SchedulerAlgorithm
+}
+
+// Validate returns an error if the scheduler configuration is invalid.
+func (s *SchedulerConfiguration) Validate() error {
+	var mErr multierror.Error
+	switch s.SchedulerAlgorithm {
+	case "", SchedulerAlgorithmBinpack, SchedulerAlgorithmSpread:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid scheduler algorithm %q", s.SchedulerAlgorithm))
+	}
+	if s.MinNodeLimit != nil && *s.MinNodeLimit < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum node limit must be positive: %d", *s.MinNodeLimit))
+	}
+	if s.MaxSkippedNodes != nil && *s.MaxSkippedNodes < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("maximum skipped nodes must not be negative: %d", *s.MaxSkippedNodes))
+	}
+	if s.ServiceJobAntiAffinityPenalty != nil && *s.ServiceJobAntiAffinityPenalty < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("service job anti-affinity penalty must not be negative: %v", *s.ServiceJobAntiAffinityPenalty))
+	}
+	if s.BatchJobAntiAffinityPenalty != nil && *s.BatchJobAntiAffinityPenalty < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("batch job anti-affinity penalty must not be negative: %v", *s.BatchJobAntiAffinityPenalty))
+	}
+	if s.MaxServiceScheduleAttempts != nil && *s.MaxServiceScheduleAttempts < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("maximum service schedule attempts must be positive: %d", *s.MaxServiceScheduleAttempts))
+	}
+	if s.MaxBatchScheduleAttempts != nil && *s.MaxBatchScheduleAttempts < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("maximum batch schedule attempts must be positive: %d", *s.MaxBatchScheduleAttempts))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// SchedulerConfigurationResponse is the response object that wraps the
+// scheduler configuration.
+type SchedulerConfigurationResponse struct {
+	// SchedulerConfig contains the scheduler configuration, or nil if it has
+	// never been set.
+	SchedulerConfig *SchedulerConfiguration
+
+	QueryMeta
+}
+
+// SchedulerSetConfigRequest is used by the Operator endpoint to update the
+// scheduler configuration of the cluster.
+type SchedulerSetConfigRequest struct {
+	// Config is the new scheduler configuration to use.
+	Config SchedulerConfiguration
+
+	// CAS controls whether to use check-and-set semantics for this request.
+	CAS bool
+
+	// WriteRequest holds the ACL token to go along with this request.
+	WriteRequest
+}
+
+// SchedulerSetConfigurationResponse is the response object returned when
+// setting the scheduler configuration.
+type SchedulerSetConfigurationResponse struct {
+	// Updated is false if a check-and-set update was rejected because the
+	// configuration had been modified since it was read.
+	Updated bool
+
+	WriteMeta
+}
+
+// SchedulerSimulateRequest is used by the Operator endpoint to simulate how
+// the schedulers would react to hypothetical changes to the cluster, such as
+// adding, removing or draining nodes and registering jobs. Nothing is
+// committed to the state.
+type SchedulerSimulateRequest struct {
+	// AddNodes are the groups of nodes to add to the cluster.
+	AddNodes []*SimulatedNodes
+
+	// RemoveNodeIDs are the IDs or ID prefixes of the nodes to remove from
+	// the cluster. Their allocations are treated as lost.
+	RemoveNodeIDs []string
+
+	// DrainNodeIDs are the IDs or ID prefixes of the nodes to drain. Their
+	// allocations are migrated to other nodes.
+	DrainNodeIDs []string
+
+	// Jobs are the jobs to register or update.
+	Jobs []*Job
+
+	// WriteRequest holds the ACL token to go along with this request.
+	WriteRequest
+}
+
+// SimulatedNodes describes a group of nodes to add when simulating the
+// scheduler. The nodes are copies of a template node of the cluster.
+type SimulatedNodes struct {
+	// Count is the number of nodes to add.
+	Count int
+
+	// NodeClass is the class of the added nodes. If no template node is
+	// given, any node of the class is used as the template.
+	NodeClass string
+
+	// TemplateNodeID is the ID or ID prefix of the node to copy.
+	TemplateNodeID string
+}
+
+// Validate returns an error if the group of nodes to add is invalid.
+func (s *SimulatedNodes) Validate() error {
+	if s.Count <= 0 {
+		return fmt.Errorf("node count must be greater than zero: %d", s.Count)
+	}
+	if s.NodeClass == "" && s.TemplateNodeID == "" {
+		return fmt.Errorf("node class or template node must be set")
+	}
+	return nil
+}
+
+// SchedulerSimulateResponse is the response object returned when simulating
+// the scheduler.
+type SchedulerSimulateResponse struct {
+	// Jobs are the results of the jobs that were given or whose allocations
+	// the changes to the cluster affected.
+	Jobs []*SimulatedJobResult
+
+	// Nodes is the utilization of the nodes after the simulation.
+	Nodes []*SimulatedNodeUtilization
+
+	WriteMeta
+}
+
+// SimulatedJobResult is the outcome of evaluating a job in a simulation.
+type SimulatedJobResult struct {
+	Namespace string
+	JobID     string
+
+	// DesiredTGUpdates is the changes the scheduler made to each task group.
+	DesiredTGUpdates map[string]*DesiredUpdates
+
+	// FailedTGAllocs is the metrics of the task groups that could not be
+	// placed.
+	FailedTGAllocs map[string]*AllocMetric
+}
+
+// Changed returns whether the scheduler placed, stopped or updated any
+// allocation of the job or failed to place one.
+func (r *SimulatedJobResult) Changed() bool {
+	if len(r.FailedTGAllocs) != 0 {
+		return true
+	}
+	for _, u := range r.DesiredTGUpdates {
+		if u.Place+u.Migrate+u.Stop+u.InPlaceUpdate+u.DestructiveUpdate+u.Canary+u.Preemptions != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// SimulatedNodeAdded, SimulatedNodeDrained and SimulatedNodeRemoved
+	// mark the nodes changed by a simulation.
+	SimulatedNodeAdded   = "added"
+	SimulatedNodeDrained = "drained"
+	SimulatedNodeRemoved = "removed"
+)
+
+// SimulatedNodeUtilization is the utilization of a node after a simulation.
+type SimulatedNodeUtilization struct {
+	ID         string
+	Name       string
+	Datacenter string
+	NodeClass  string
+
+	// Change is how the simulation changed the node, if at all.
+	Change string
+
+	// Allocs is the number of running allocations on the node.
+	Allocs int
+
+	// Capacity is the resources of the node and Used the resources reserved
+	// on the node or used by its allocations.
+	Capacity *Resources
+	Used     *Resources
+}
+
+// SchedulerQueueResponse is the response object returned when querying the
+// queues of the leader's evaluation broker.
+type SchedulerQueueResponse struct {
+	// FairQueueing is whether the ready evaluations are dequeued with a
+	// weighted round-robin across namespaces rather than purely by priority.
+	FairQueueing bool
+
+	// MaxReadyPerJob is the cap on the number of ready evaluations of a job
+	// when fair queueing is enabled. Zero means no cap.
+	MaxReadyPerJob int
+
+	TotalReady     int
+	TotalUnacked   int
+	TotalBlocked   int
+	TotalWaiting   int
+	TotalThrottled int
+
+	// Namespaces is the state of the queues of each namespace.
+	Namespaces map[string]*NamespaceQueueStats
+
+	QueryMeta
+}
+
+// NamespaceQueueStats is the state of the evaluation broker queues of a
+// namespace.
+type NamespaceQueueStats struct {
+	// Weight is the weight of the namespace in the fair queueing round-robin.
+	Weight int
+
+	// Ready, Unacked and Throttled are the number of evaluations of the
+	// namespace ready to be dequeued, dequeued but not yet acknowledged, and
+	// held back by the cap on the ready evaluations of a job.
+	Ready     int
+	Unacked   int
+	Throttled int
+
+	// ReadyWait is how long the oldest ready evaluation of the namespace has
+	// waited to be dequeued.
+	ReadyWait time.Duration
+}
+
+// SnapshotSaveRequest is used by the Operator endpoint to take a snapshot of
+// the state of the cluster. The snapshot is taken by the leader unless stale
+// reads are allowed.
+type SnapshotSaveRequest struct {
+	QueryOptions
+}
+
+// SnapshotRestoreRequest is used by the Operator endpoint to restore the
+// state of the cluster from a snapshot. The snapshot is streamed after the
+// request.
+type SnapshotRestoreRequest struct {
+	WriteRequest
+}
@@ -81,6 +81,7 @@ const (
 	AllocUpdateDesiredTransitionRequestType
 	NodeUpdateEligibilityRequestType
 	BatchNodeUpdateDrainRequestType
+	SchedulerConfigRequestType
 )
 
 const (
@@ -609,6 +610,14 @@ type ApplyPlanResultsRequest struct {
 	// because the job is stopped or the update block is removed.
 	DeploymentUpdates []*DeploymentStatusUpdate
 
+	// NodePreemptions is a slice of allocations from other lower priority
+	// jobs that are preempted to make room for the allocations in this plan.
+	NodePreemptions []*Allocation
+
+	// PreemptionEvals is a slice of follow up evals for the jobs whose
+	// allocations have been preempted so that they can be rescheduled.
+	PreemptionEvals []*Evaluation
+
 	// EvalID is the eval ID of the plan being applied. The modify index of the
 	// evaluation is updated as part of applying the plan to ensure that subsequent
 	// scheduling events for the same job will wait for the index that last produced
@@ -660,6 +669,37 @@ type AllocSpecificRequest struct {
 	QueryOptions
 }
 
+// AllocStopRequest is used to stop and reschedule a running allocation.
+type AllocStopRequest struct {
+	AllocID string
+
+	WriteRequest
+}
+
+// AllocRestartRequest is used to restart the tasks of a specific allocation.
+type AllocRestartRequest struct {
+	AllocID string
+
+	// TaskName is the task to restart. All tasks are restarted if it is
+	// empty.
+	TaskName string
+
+	WriteRequest
+}
+
+// AllocSignalRequest is used to signal the tasks of a specific allocation.
+type AllocSignalRequest struct {
+	AllocID string
+
+	// Task is the task to signal. All tasks are signaled if it is empty.
+	Task string
+
+	// Signal is the name of the signal to send
+	Signal string
+
+	WriteRequest
+}
+
 // AllocsGetRequest is used to query a set of allocations
 type AllocsGetRequest struct {
 	AllocIDs []string
@@ -788,6 +828,9 @@ type DeploymentAllocHealthRequest struct {
 type ApplyDeploymentAllocHealthRequest struct {
 	DeploymentAllocHealthRequest
 
+	// Timestamp is the time at which the health of the allocations was set
+	Timestamp time.Time
+
 	// An optional field to update the status of a deployment
 	DeploymentUpdate *DeploymentStatusUpdate
 
@@ -1024,6 +1067,10 @@ type JobPlanResponse struct {
 	// FailedTGAllocs is the placement failures per task group.
 	FailedTGAllocs map[string]*AllocMetric
 
+	// PlacementMetrics is the metrics of the first new allocation placed
+	// for each task group.
+	PlacementMetrics map[string]*AllocMetric
+
 	// JobModifyIndex is the modification index of the job. The value can be
 	// used when running `nomad run` to ensure that the Job wasn’t modified
 	// since the last plan. If the job is being created, the value is zero.
@@ -1054,6 +1101,14 @@ type SingleAllocResponse struct {
 	QueryMeta
 }
 
+// AllocStopResponse is the response to an AllocStopRequest
+type AllocStopResponse struct {
+	// EvalID is the id of the follow up evaluation for the rescheduled alloc.
+	EvalID string
+
+	WriteMeta
+}
+
 // AllocsGetResponse is used to return a set of allocations
 type AllocsGetResponse struct {
 	Allocs []*Allocation
@@ -1420,6 +1475,10 @@ type Node struct {
 	// Drivers is a map of driver names to current driver information
 	Drivers map[string]*DriverInfo
 
+	// HostVolumes is the set of host directories the operator made available
+	// to the tasks of the node, by name
+	HostVolumes map[string]*ClientHostVolumeConfig
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -1461,6 +1520,7 @@ func (n *Node) Copy() *Node {
 	nn.Meta = helper.CopyMapStringString(nn.Meta)
 	nn.Events = copyNodeEvents(n.Events)
 	nn.DrainStrategy = nn.DrainStrategy.Copy()
+	nn.HostVolumes = CopyMapStringClientHostVolumeConfig(nn.HostVolumes)
 	return nn
 }
 
@@ -1555,6 +1615,23 @@ type Resources struct {
 	DiskMB   int
 	IOPS     int
 	Networks Networks
+	Devices  []*DeviceResource
+
+	// MemoryMaxMB is the memory limit of the task, when it may use more
+	// memory than the MemoryMB reserved by the scheduler. It is only
+	// honored when memory oversubscription is enabled in the scheduler
+	// configuration.
+	MemoryMaxMB int
+
+	// Cores is the number of CPU cores dedicated to the task. The scheduler
+	// sets the CPU of the task to the compute of its cores.
+	Cores int
+
+	// CoreIDs are the IDs of CPU cores. On nodes they are the cores that can
+	// be dedicated to tasks, in the reserved resources of nodes the cores
+	// that can't and in the resources of allocations the cores dedicated to
+	// the task.
+	CoreIDs []uint16
 }
 
 const (
@@ -1599,6 +1676,15 @@ func (r *Resources) Merge(other *Resources) {
 	if other.MemoryMB != 0 {
 		r.MemoryMB = other.MemoryMB
 	}
+	if other.MemoryMaxMB != 0 {
+		r.MemoryMaxMB = other.MemoryMaxMB
+	}
+	if other.Cores != 0 {
+		r.Cores = other.Cores
+	}
+	if len(other.CoreIDs) != 0 {
+		r.CoreIDs = other.CoreIDs
+	}
 	if other.DiskMB != 0 {
 		r.DiskMB = other.DiskMB
 	}
@@ -1608,6 +1694,10 @@ func (r *Resources) Merge(other *Resources) {
 	if len(other.Networks) != 0 {
 		r.Networks = other.Networks
 	}
+	// An empty but non-nil device list clears the devices
+	if other.Devices != nil {
+		r.Devices = other.Devices
+	}
 }
 
 func (r *Resources) Canonicalize() {
@@ -1620,6 +1710,14 @@ func (r *Resources) Canonicalize() {
 	for _, n := range r.Networks {
 		n.Canonicalize()
 	}
+
+	if len(r.Devices) == 0 {
+		r.Devices = nil
+	}
+
+	if len(r.CoreIDs) == 0 {
+		r.CoreIDs = nil
+	}
 }
 
 // MeetsMinResources returns an error if the resources specified are less than
@@ -1634,6 +1732,12 @@ func (r *Resources) MeetsMinResources() error {
 	if r.MemoryMB < minResources.MemoryMB {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum MemoryMB value is %d; got %d", minResources.MemoryMB, r.MemoryMB))
 	}
+	if r.Cores < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Cores value must not be negative; got %d", r.Cores))
+	}
+	if r.MemoryMaxMB != 0 && r.MemoryMaxMB < r.MemoryMB {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("MemoryMaxMB value (%d) must be greater than or equal to MemoryMB (%d)", r.MemoryMaxMB, r.MemoryMB))
+	}
 	if r.IOPS < minResources.IOPS {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum IOPS value is %d; got %d", minResources.IOPS, r.IOPS))
 	}
@@ -1642,6 +1746,11 @@ func (r *Resources) MeetsMinResources() error {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("network resource at index %d failed: %v", i, err))
 		}
 	}
+	for i, d := range r.Devices {
+		if err := d.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("device resource at index %d failed: %v", i, err))
+		}
+	}
 
 	return mErr.ErrorOrNil()
 }
@@ -1660,6 +1769,16 @@ func (r *Resources) Copy() *Resources {
 			newR.Networks[i] = r.Networks[i].Copy()
 		}
 	}
+	if r.Devices != nil {
+		newR.Devices = make([]*DeviceResource, len(r.Devices))
+		for i, d := range r.Devices {
+			newR.Devices[i] = d.Copy()
+		}
+	}
+	if r.CoreIDs != nil {
+		newR.CoreIDs = make([]uint16, len(r.CoreIDs))
+		copy(newR.CoreIDs, r.CoreIDs)
+	}
 	return newR
 }
 
@@ -1674,8 +1793,8 @@ func (r *Resources) NetIndex(n *NetworkResource) int {
 }
 
 // Superset checks if one set of resources is a superset
-// of another. This ignores network resources, and the NetworkIndex
-// should be used for that.
+// of another. This ignores network, device and core resources, and the
+// NetworkIndex, DeviceAccounter and CoreAccounter should be used for those.
 func (r *Resources) Superset(other *Resources) (bool, string) {
 	if r.CPU < other.CPU {
 		return false, "cpu"
@@ -1712,6 +1831,13 @@ func (r *Resources) Add(delta *Resources) error {
 			r.Networks[idx].Add(n)
 		}
 	}
+
+	for _, d := range delta.Devices {
+		r.Devices = append(r.Devices, d.Copy())
+	}
+
+	r.Cores += delta.Cores
+	r.CoreIDs = append(r.CoreIDs, delta.CoreIDs...)
 	return nil
 }
 
@@ -1722,11 +1848,38 @@ func (r *Resources) GoString() string {
 type Port struct {
 	Label string
 	Value int
+
+	// To is the port inside the network namespace of the allocation the
+	// host port is mapped to. It defaults to the host port and is only used
+	// by group networks that aren't in host mode.
+	To int
 }
 
+const (
+	// NetworkModeHost is the mode of networks using the network namespace of
+	// the host. Task networks are always in host mode.
+	NetworkModeHost = "host"
+
+	// NetworkModeBridge is the mode of group networks giving the allocation
+	// its own network namespace attached to a bridge on the host, with the
+	// ports mapped from the host.
+	NetworkModeBridge = "bridge"
+
+	// NetworkModeCNIPrefix is the prefix of the mode of group networks giving
+	// the allocation its own network namespace configured by the CNI network
+	// named after the prefix.
+	NetworkModeCNIPrefix = "cni/"
+
+	// NodeNetworkModeAttrPrefix is the prefix of the node attributes
+	// fingerprinted for the network modes other than host the client
+	// supports.
+	NodeNetworkModeAttrPrefix = "network.mode."
+)
+
 // NetworkResource is used to represent available network
 // resources
 type NetworkResource struct {
+	Mode          string // Mode of the network, only set for group networks
 	Device        string // Name of the device
 	CIDR          string // CIDR block of addresses
 	IP            string // Host IP address
@@ -1736,6 +1889,10 @@ type NetworkResource struct {
 }
 
 func (nr *NetworkResource) Equals(other *NetworkResource) bool {
+	if nr.Mode != other.Mode {
+		return false
+	}
+
 	if nr.Device != other.Device {
 		return false
 	}
@@ -1832,6 +1989,40 @@ func (n *NetworkResource) GoString() string {
 	return fmt.Sprintf("*%#v", *n)
 }
 
+// validateGroupNetwork validates a network requested by a task group.
+func (n *NetworkResource) validateGroupNetwork() error {
+	var mErr multierror.Error
+	switch n.Mode {
+	case "", NetworkModeHost, NetworkModeBridge:
+	case NetworkModeCNIPrefix:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("network mode %q is missing the name of the CNI network", n.Mode))
+	default:
+		if !strings.HasPrefix(n.Mode, NetworkModeCNIPrefix) {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("unsupported network mode %q", n.Mode))
+		}
+	}
+	if n.MBits < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("MBits value must not be negative; got %d", n.MBits))
+	}
+
+	labels := make(map[string]struct{}, len(n.ReservedPorts)+len(n.DynamicPorts))
+	for _, ports := range [][]Port{n.ReservedPorts, n.DynamicPorts} {
+		for _, port := range ports {
+			if _, ok := labels[port.Label]; ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("port label %q is duplicate", port.Label))
+			}
+			labels[port.Label] = struct{}{}
+
+			if port.To < 0 || port.To >= maxValidPort {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("port %q is mapped to invalid port %d", port.Label, port.To))
+			} else if port.To != 0 && (n.Mode == "" || n.Mode == NetworkModeHost) {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("port %q can't be mapped in host mode", port.Label))
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
 // PortLabels returns a map of port labels to their assigned host ports.
 func (n *NetworkResource) PortLabels() map[string]int {
 	num := len(n.ReservedPorts) + len(n.DynamicPorts)
@@ -1931,6 +2122,14 @@ type Job struct {
 	// all the task groups and tasks.
 	Constraints []*Constraint
 
+	// Affinities can be specified at the job level to express
+	// scheduling preferences that apply to all groups and tasks
+	Affinities []*Affinity
+
+	// Spreads can be specified at the job level to express spreading
+	// allocations across a desired attribute, such as datacenter
+	Spreads []*Spread
+
 	// TaskGroups are the collections of task groups that this job needs
 	// to run. Each task group is an atomic unit of scheduling and placement.
 	TaskGroups []*TaskGroup
@@ -2027,6 +2226,8 @@ func (j *Job) Copy() *Job {
 	*nj = *j
 	nj.Datacenters = helper.CopySliceString(nj.Datacenters)
 	nj.Constraints = CopySliceConstraints(nj.Constraints)
+	nj.Affinities = CopySliceAffinities(nj.Affinities)
+	nj.Spreads = CopySliceSpreads(nj.Spreads)
 
 	if j.TaskGroups != nil {
 		tgs := make([]*TaskGroup, len(nj.TaskGroups))
@@ -2082,6 +2283,33 @@ func (j *Job) Validate() error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+	if j.Type == JobTypeSystem {
+		if len(j.Affinities) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("System jobs may not have an affinity stanza"))
+		}
+		if len(j.Spreads) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("System jobs may not have a spread stanza"))
+		}
+	} else {
+		for idx, affinity := range j.Affinities {
+			if err := affinity.Validate(); err != nil {
+				outer := fmt.Errorf("Affinity %d validation failed: %s", idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+		}
+		spreads := make(map[string]int)
+		for idx, spread := range j.Spreads {
+			if err := spread.Validate(); err != nil {
+				outer := fmt.Errorf("Spread %d validation failed: %s", idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+			if existing, ok := spreads[spread.Attribute]; ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread %d redefines attribute %q from spread %d", idx+1, spread.Attribute, existing+1))
+			} else if spread.Attribute != "" {
+				spreads[spread.Attribute] = idx
+			}
+		}
+	}
 
 	// Check for duplicate task groups
 	taskGroups := make(map[string]int)
@@ -2455,13 +2683,15 @@ var (
 	// DefaultUpdateStrategy provides a baseline that can be used to upgrade
 	// jobs with the old policy or for populating field defaults.
 	DefaultUpdateStrategy = &UpdateStrategy{
-		Stagger:         30 * time.Second,
-		MaxParallel:     1,
-		HealthCheck:     UpdateStrategyHealthCheck_Checks,
-		MinHealthyTime:  10 * time.Second,
-		HealthyDeadline: 5 * time.Minute,
-		AutoRevert:      false,
-		Canary:          0,
+		Stagger:          30 * time.Second,
+		MaxParallel:      1,
+		HealthCheck:      UpdateStrategyHealthCheck_Checks,
+		MinHealthyTime:   10 * time.Second,
+		HealthyDeadline:  5 * time.Minute,
+		ProgressDeadline: 0,
+		AutoRevert:       false,
+		AutoPromote:      false,
+		Canary:           0,
 	}
 )
 
@@ -2488,11 +2718,21 @@ type UpdateStrategy struct {
 	// period doesn't count against the MinHealthyTime.
 	HealthyDeadline time.Duration
 
+	// ProgressDeadline is the time in which an allocation must be marked as
+	// healthy, starting from the creation of the deployment or the last
+	// allocation marked healthy, before the deployment is automatically
+	// failed. A zero value disables the deadline.
+	ProgressDeadline time.Duration
+
 	// AutoRevert declares that if a deployment fails because of unhealthy
 	// allocations, there should be an attempt to auto-revert the job to a
 	// stable version.
 	AutoRevert bool
 
+	// AutoPromote declares that the deployment should be promoted once all
+	// the canaries are healthy.
+	AutoPromote bool
+
 	// Canary is the number of canaries to deploy when a change to the task
 	// group is detected.
 	Canary int
@@ -2535,6 +2775,12 @@ func (u *UpdateStrategy) Validate() error {
 	if u.MinHealthyTime >= u.HealthyDeadline {
 		multierror.Append(&mErr, fmt.Errorf("Minimum healthy time must be less than healthy deadline: %v > %v", u.MinHealthyTime, u.HealthyDeadline))
 	}
+	if u.ProgressDeadline < 0 {
+		multierror.Append(&mErr, fmt.Errorf("Progress deadline must be zero or greater: %v", u.ProgressDeadline))
+	}
+	if u.ProgressDeadline != 0 && u.HealthyDeadline >= u.ProgressDeadline {
+		multierror.Append(&mErr, fmt.Errorf("Healthy deadline must be less than progress deadline: %v > %v", u.HealthyDeadline, u.ProgressDeadline))
+	}
 	if u.Stagger <= 0 {
 		multierror.Append(&mErr, fmt.Errorf("Stagger must be greater than zero: %v", u.Stagger))
 	}
@@ -2555,6 +2801,19 @@ const (
 	// PeriodicSpecTest is only used by unit tests. It is a sorted, comma
 	// separated list of unix timestamps at which to launch.
 	PeriodicSpecTest = "_internal_test"
+
+	// DefaultPeriodicCatchUpWindow is the window missed launches are caught
+	// up within when the periodic config doesn't specify one.
+	DefaultPeriodicCatchUpWindow = 24 * time.Hour
+
+	// MaxPeriodicCatchUpWindow is the largest window missed launches can be
+	// caught up within. It bounds the launch times computed when catching up.
+	MaxPeriodicCatchUpWindow = 7 * DefaultPeriodicCatchUpWindow
+
+	// MaxPeriodicCatchUpLaunches is the maximum number of missed launches of
+	// a periodic job that are caught up at once. The most recent ones are
+	// kept.
+	MaxPeriodicCatchUpLaunches = 100
 )
 
 // Periodic defines the interval a job should be run at.
@@ -2566,12 +2825,25 @@ type PeriodicConfig struct {
 	// on the SpecType.
 	Spec string
 
+	// Specs are additional specs the job should be run as, the job is launched
+	// at the earliest next launch of all the specs. They are parsed based on
+	// the SpecType.
+	Specs []string
+
 	// SpecType defines the format of the spec.
 	SpecType string
 
 	// ProhibitOverlap enforces that spawned jobs do not run in parallel.
 	ProhibitOverlap bool
 
+	// CatchUp launches the runs missed while there was no leader to launch
+	// them when leadership is established.
+	CatchUp bool
+
+	// CatchUpWindow bounds how far back missed runs are caught up. If zero,
+	// DefaultPeriodicCatchUpWindow is used.
+	CatchUpWindow time.Duration
+
 	// TimeZone is the user specified string that determines the time zone to
 	// launch against. The time zones must be specified from IANA Time Zone
 	// database, such as "America/New_York".
@@ -2589,18 +2861,41 @@ func (p *PeriodicConfig) Copy() *PeriodicConfig {
 	}
 	np := new(PeriodicConfig)
 	*np = *p
+	np.Specs = helper.CopySliceString(p.Specs)
 	return np
 }
 
+// AllSpecs returns the Spec and the additional Specs of the periodic config.
+func (p *PeriodicConfig) AllSpecs() []string {
+	specs := make([]string, 0, len(p.Specs)+1)
+	if p.Spec != "" {
+		specs = append(specs, p.Spec)
+	}
+	return append(specs, p.Specs...)
+}
+
 func (p *PeriodicConfig) Validate() error {
 	if !p.Enabled {
 		return nil
 	}
 
 	var mErr multierror.Error
-	if p.Spec == "" {
+	specs := p.AllSpecs()
+	if len(specs) == 0 {
 		multierror.Append(&mErr, fmt.Errorf("Must specify a spec"))
 	}
+	for _, spec := range p.Specs {
+		if spec == "" {
+			multierror.Append(&mErr, fmt.Errorf("Specs can't be empty"))
+			break
+		}
+	}
+
+	if p.CatchUpWindow < 0 {
+		multierror.Append(&mErr, fmt.Errorf("Catch-up window must be positive: %v", p.CatchUpWindow))
+	} else if p.CatchUpWindow > MaxPeriodicCatchUpWindow {
+		multierror.Append(&mErr, fmt.Errorf("Catch-up window must be at most %v: %v", MaxPeriodicCatchUpWindow, p.CatchUpWindow))
+	}
 
 	// Check if we got a valid time zone
 	if p.TimeZone != "" {
@@ -2611,9 +2906,11 @@ func (p *PeriodicConfig) Validate() error {
 
 	switch p.SpecType {
 	case PeriodicSpecCron:
-		// Validate the cron spec
-		if _, err := cronexpr.Parse(p.Spec); err != nil {
-			multierror.Append(&mErr, fmt.Errorf("Invalid cron spec %q: %v", p.Spec, err))
+		// Validate the cron specs
+		for _, spec := range specs {
+			if _, err := cronexpr.Parse(spec); err != nil {
+				multierror.Append(&mErr, fmt.Errorf("Invalid cron spec %q: %v", spec, err))
+			}
 		}
 	case PeriodicSpecTest:
 		// No-op
@@ -2634,42 +2931,109 @@ func (p *PeriodicConfig) Canonicalize() {
 	p.location = l
 }
 
-// Next returns the closest time instant matching the spec that is after the
-// passed time. If no matching instance exists, the zero value of time.Time is
-// returned. The `time.Location` of the returned value matches that of the
-// passed time.
+// Next returns the closest time instant matching any of the specs that is
+// after the passed time. If no matching instance exists, the zero value of
+// time.Time is returned. The `time.Location` of the returned value matches
+// that of the passed time.
 func (p *PeriodicConfig) Next(fromTime time.Time) time.Time {
-	switch p.SpecType {
-	case PeriodicSpecCron:
-		if e, err := cronexpr.Parse(p.Spec); err == nil {
-			return e.Next(fromTime)
-		}
-	case PeriodicSpecTest:
-		split := strings.Split(p.Spec, ",")
-		if len(split) == 1 && split[0] == "" {
-			return time.Time{}
+	return nextPeriodicLaunch(p.schedules(), fromTime)
+}
+
+// Launches returns the launch times after the from time and up to and
+// including the to time, in increasing order. At most limit launch times are
+// returned, keeping the latest ones.
+func (p *PeriodicConfig) Launches(from, to time.Time, limit int) []time.Time {
+	// Parse the specs once rather than for each launch
+	schedules := p.schedules()
+
+	var launches []time.Time
+	for next := nextPeriodicLaunch(schedules, from); !next.IsZero() && !next.After(to); next = nextPeriodicLaunch(schedules, next) {
+		launches = append(launches, next)
+		if len(launches) > limit {
+			launches = launches[1:]
 		}
+	}
+	return launches
+}
 
-		// Parse the times
-		times := make([]time.Time, len(split))
-		for i, s := range split {
-			unix, err := strconv.Atoi(s)
-			if err != nil {
-				return time.Time{}
-			}
+// GetCatchUpWindow returns the window missed launches should be caught up
+// within.
+func (p *PeriodicConfig) GetCatchUpWindow() time.Duration {
+	if p.CatchUpWindow == 0 {
+		return DefaultPeriodicCatchUpWindow
+	}
+	return p.CatchUpWindow
+}
+
+// periodicSchedule is a parsed periodic spec.
+type periodicSchedule interface {
+	// Next returns the closest time instant matching the spec that is after
+	// the passed time, or the zero value of time.Time if there is none.
+	Next(fromTime time.Time) time.Time
+}
+
+// periodicTestSchedule is a parsed PeriodicSpecTest spec.
+type periodicTestSchedule []time.Time
 
-			times[i] = time.Unix(int64(unix), 0)
+func (s periodicTestSchedule) Next(fromTime time.Time) time.Time {
+	for _, next := range s {
+		if fromTime.Before(next) {
+			return next
 		}
+	}
+	return time.Time{}
+}
 
-		// Find the next match
-		for _, next := range times {
-			if fromTime.Before(next) {
-				return next
+// schedules parses the specs of the periodic config. Invalid specs are
+// skipped.
+func (p *PeriodicConfig) schedules() []periodicSchedule {
+	var schedules []periodicSchedule
+	for _, spec := range p.AllSpecs() {
+		switch p.SpecType {
+		case PeriodicSpecCron:
+			if e, err := cronexpr.Parse(spec); err == nil {
+				schedules = append(schedules, e)
+			}
+		case PeriodicSpecTest:
+			split := strings.Split(spec, ",")
+			if len(split) == 1 && split[0] == "" {
+				continue
+			}
+
+			// Parse the times
+			times := make(periodicTestSchedule, len(split))
+			valid := true
+			for i, s := range split {
+				unix, err := strconv.Atoi(s)
+				if err != nil {
+					valid = false
+					break
+				}
+				times[i] = time.Unix(int64(unix), 0)
+			}
+			if valid {
+				schedules = append(schedules, times)
 			}
 		}
 	}
+	return schedules
+}
 
-	return time.Time{}
+// nextPeriodicLaunch returns the closest time instant matching any of the
+// schedules that is after the passed time. If no matching instance exists,
+// the zero value of time.Time is returned.
+func nextPeriodicLaunch(schedules []periodicSchedule, fromTime time.Time) time.Time {
+	var next time.Time
+	for _, schedule := range schedules {
+		n := schedule.Next(fromTime)
+		if n.IsZero() {
+			continue
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+	return next
 }
 
 // GetLocation returns the location to use for determining the time zone to run
@@ -2790,6 +3154,57 @@ func (d *DispatchPayloadConfig) Validate() error {
 	return nil
 }
 
+const (
+	// TaskLifecycleHookPrestart runs the task before the main tasks of the
+	// group are started.
+	TaskLifecycleHookPrestart = "prestart"
+
+	// TaskLifecycleHookPoststart runs the task once the main tasks of the
+	// group have started.
+	TaskLifecycleHookPoststart = "poststart"
+
+	// TaskLifecycleHookPoststop runs the task once the main tasks of the group
+	// have stopped.
+	TaskLifecycleHookPoststop = "poststop"
+)
+
+// TaskLifecycleConfig configures when a task is run relative to the main tasks
+// of its group.
+type TaskLifecycleConfig struct {
+	// Hook is the point in the lifecycle of the main tasks at which the task
+	// is started.
+	Hook string
+
+	// Sidecar marks the task as running for as long as the main tasks. Tasks
+	// that are not sidecars are expected to run to completion.
+	Sidecar bool
+}
+
+func (d *TaskLifecycleConfig) Copy() *TaskLifecycleConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(TaskLifecycleConfig)
+	*nd = *d
+	return nd
+}
+
+func (d *TaskLifecycleConfig) Validate() error {
+	switch d.Hook {
+	case TaskLifecycleHookPrestart, TaskLifecycleHookPoststart:
+	case TaskLifecycleHookPoststop:
+		if d.Sidecar {
+			return fmt.Errorf("%q tasks can not be sidecars", d.Hook)
+		}
+	case "":
+		return fmt.Errorf("missing hook")
+	default:
+		return fmt.Errorf("invalid hook %q", d.Hook)
+	}
+
+	return nil
+}
+
 var (
 	DefaultServiceJobRestartPolicy = RestartPolicy{
 		Delay:    15 * time.Second,
@@ -3184,6 +3599,14 @@ type TaskGroup struct {
 	// all the tasks contained.
 	Constraints []*Constraint
 
+	// Affinities can be specified at the task group level to express
+	// scheduling preferences
+	Affinities []*Affinity
+
+	// Spreads can be specified at the task group level to express spreading
+	// allocations across a desired attribute, such as datacenter
+	Spreads []*Spread
+
 	//RestartPolicy of a TaskGroup
 	RestartPolicy *RestartPolicy
 
@@ -3200,6 +3623,15 @@ type TaskGroup struct {
 	// ReschedulePolicy is used to configure how the scheduler should
 	// retry failed allocations.
 	ReschedulePolicy *ReschedulePolicy
+
+	// Volumes is the set of volumes the tasks of the group can mount, by
+	// name. Only nodes providing the volumes are feasible.
+	Volumes map[string]*VolumeRequest
+
+	// Networks are the network resources shared by the tasks of the group.
+	// Only one network is supported and its mode selects whether the tasks
+	// share the network namespace of the host or one of the allocation.
+	Networks Networks
 }
 
 func (tg *TaskGroup) Copy() *TaskGroup {
@@ -3210,6 +3642,8 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	*ntg = *tg
 	ntg.Update = ntg.Update.Copy()
 	ntg.Constraints = CopySliceConstraints(ntg.Constraints)
+	ntg.Affinities = CopySliceAffinities(ntg.Affinities)
+	ntg.Spreads = CopySliceSpreads(ntg.Spreads)
 	ntg.RestartPolicy = ntg.RestartPolicy.Copy()
 	ntg.ReschedulePolicy = ntg.ReschedulePolicy.Copy()
 
@@ -3222,6 +3656,15 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	}
 
 	ntg.Meta = helper.CopyMapStringString(ntg.Meta)
+	ntg.Volumes = CopyMapVolumeRequest(ntg.Volumes)
+
+	if tg.Networks != nil {
+		networks := make([]*NetworkResource, len(tg.Networks))
+		for i, n := range tg.Networks {
+			networks[i] = n.Copy()
+		}
+		ntg.Networks = networks
+	}
 
 	if tg.EphemeralDisk != nil {
 		ntg.EphemeralDisk = tg.EphemeralDisk.Copy()
@@ -3292,6 +3735,49 @@ func (tg *TaskGroup) Validate(j *Job) error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+	if j.Type == JobTypeSystem {
+		if len(tg.Affinities) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("System jobs may not have an affinity stanza"))
+		}
+		for _, task := range tg.Tasks {
+			if len(task.Affinities) != 0 {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("System jobs may not have an affinity stanza"))
+				break
+			}
+		}
+		if len(tg.Spreads) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("System jobs may not have a spread stanza"))
+		}
+	} else {
+		for idx, affinity := range tg.Affinities {
+			if err := affinity.Validate(); err != nil {
+				outer := fmt.Errorf("Affinity %d validation failed: %s", idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+		}
+		// The spreads of the job apply to every task group so a task group
+		// may not spread on the same attribute again
+		jobSpreads := make(map[string]int)
+		for idx, spread := range j.Spreads {
+			if _, ok := jobSpreads[spread.Attribute]; !ok {
+				jobSpreads[spread.Attribute] = idx
+			}
+		}
+		spreads := make(map[string]int)
+		for idx, spread := range tg.Spreads {
+			if err := spread.Validate(); err != nil {
+				outer := fmt.Errorf("Spread %d validation failed: %s", idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+			if existing, ok := jobSpreads[spread.Attribute]; ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread %d redefines attribute %q from job spread %d", idx+1, spread.Attribute, existing+1))
+			} else if existing, ok := spreads[spread.Attribute]; ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread %d redefines attribute %q from spread %d", idx+1, spread.Attribute, existing+1))
+			} else if spread.Attribute != "" {
+				spreads[spread.Attribute] = idx
+			}
+		}
+	}
 
 	if tg.RestartPolicy != nil {
 		if err := tg.RestartPolicy.Validate(); err != nil {
@@ -3354,6 +3840,28 @@ func (tg *TaskGroup) Validate(j *Job) error {
 	tasks := make(map[string]int)
 	staticPorts := make(map[int]string)
 	leaderTasks := 0
+	mainTasks := 0
+
+	// Validate the group network and reserve its static ports
+	if len(tg.Networks) > 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Only one network resource may be specified at the group level"))
+	}
+	for _, net := range tg.Networks {
+		if err := net.validateGroupNetwork(); err != nil {
+			outer := fmt.Errorf("Network validation failed: %v", err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+		for _, port := range net.ReservedPorts {
+			if other, ok := staticPorts[port.Value]; ok {
+				err := fmt.Errorf("Static port %d already reserved by %s", port.Value, other)
+				mErr.Errors = append(mErr.Errors, err)
+			} else {
+				staticPorts[port.Value] = fmt.Sprintf("group:%s", port.Label)
+			}
+		}
+	}
+	groupMode := tg.NetworkMode()
+
 	for idx, task := range tg.Tasks {
 		if task.Name == "" {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("Task %d missing name", idx+1))
@@ -3365,12 +3873,25 @@ func (tg *TaskGroup) Validate(j *Job) error {
 
 		if task.Leader {
 			leaderTasks++
+			if !task.IsMain() {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("Task %q with a lifecycle can not be marked as leader", task.Name))
+			}
+		}
+
+		if task.IsMain() {
+			mainTasks++
 		}
 
 		if task.Resources == nil {
 			continue
 		}
 
+		// Host ports aren't reachable from the network namespace of the
+		// allocation
+		if groupMode != NetworkModeHost && len(task.Resources.Networks) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Task %q can't request a network when the group network is in %q mode", task.Name, groupMode))
+		}
+
 		for _, net := range task.Resources.Networks {
 			for _, port := range net.ReservedPorts {
 				if other, ok := staticPorts[port.Value]; ok {
@@ -3387,12 +3908,38 @@ func (tg *TaskGroup) Validate(j *Job) error {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("Only one task may be marked as leader"))
 	}
 
+	if mainTasks == 0 && len(tg.Tasks) > 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Task group must have at least one task without a lifecycle"))
+	}
+
+	// Validate the volumes
+	for name, volume := range tg.Volumes {
+		if volume == nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q is empty", name))
+			continue
+		}
+		if volume.Name != name {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q has mismatched name %q", name, volume.Name))
+		}
+		if err := volume.Validate(); err != nil {
+			outer := fmt.Errorf("Volume %q validation failed: %v", name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
 	// Validate the tasks
 	for _, task := range tg.Tasks {
-		if err := task.Validate(tg.EphemeralDisk); err != nil {
+		if err := task.Validate(tg.EphemeralDisk, tg.Networks); err != nil {
 			outer := fmt.Errorf("Task %s validation failed: %v", task.Name, err)
 			mErr.Errors = append(mErr.Errors, outer)
 		}
+
+		for idx, mount := range task.VolumeMounts {
+			if err := mount.Validate(tg.Volumes); err != nil {
+				outer := fmt.Errorf("Task %s volume mount %d validation failed: %v", task.Name, idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+		}
 	}
 	return mErr.ErrorOrNil()
 }
@@ -3415,6 +3962,15 @@ func (tg *TaskGroup) Warnings(j *Job) error {
 	return mErr.ErrorOrNil()
 }
 
+// NetworkMode returns the mode of the group network, which defaults to the
+// host mode.
+func (tg *TaskGroup) NetworkMode() string {
+	if len(tg.Networks) == 0 || tg.Networks[0].Mode == "" {
+		return NetworkModeHost
+	}
+	return tg.Networks[0].Mode
+}
+
 // LookupTask finds a task by name
 func (tg *TaskGroup) LookupTask(name string) *Task {
 	for _, t := range tg.Tasks {
@@ -3807,10 +4363,32 @@ const (
 	DefaultKillTimeout = 5 * time.Second
 )
 
+const (
+	// MinLogRotationInterval is the minimum interval log files can be
+	// rotated at
+	MinLogRotationInterval = time.Minute
+)
+
 // LogConfig provides configuration for log rotation
 type LogConfig struct {
 	MaxFiles      int
 	MaxFileSizeMB int
+
+	// RotationInterval rotates the log files at this interval, aligned to
+	// the wall clock, in addition to rotating them by size. Zero disables
+	// rotating by time.
+	RotationInterval time.Duration
+
+	// Compress gzips the rotated log files
+	Compress bool
+
+	// MaxTotalSizeMB caps the disk used by the log files of the task, split
+	// evenly between stdout and stderr. Zero disables the cap.
+	MaxTotalSizeMB int
+
+	// Shippers ship the log lines of the task to sinks in addition to the
+	// rotated log files
+	Shippers []*LogShipper
 }
 
 // DefaultLogConfig returns the default LogConfig values.
@@ -3831,6 +4409,137 @@ func (l *LogConfig) Validate() error {
 	if l.MaxFileSizeMB < 1 {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum file size is 1MB; got %d", l.MaxFileSizeMB))
 	}
+	if l.RotationInterval < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("rotation interval can't be negative; got %v", l.RotationInterval))
+	} else if l.RotationInterval != 0 && l.RotationInterval < MinLogRotationInterval {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum rotation interval is %v; got %v", MinLogRotationInterval, l.RotationInterval))
+	}
+	if l.MaxTotalSizeMB < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("total size can't be negative; got %d", l.MaxTotalSizeMB))
+	} else if l.MaxTotalSizeMB != 0 && l.MaxTotalSizeMB < 2*l.MaxFileSizeMB {
+		// Each of stdout and stderr must fit at least one file
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("total size must be at least twice the file size (%dMB); got %d",
+			2*l.MaxFileSizeMB, l.MaxTotalSizeMB))
+	}
+	for i, shipper := range l.Shippers {
+		if err := shipper.Validate(); err != nil {
+			outer := fmt.Errorf("Log shipper %d validation failed: %v", i+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a deep copy of the log config
+func (l *LogConfig) Copy() *LogConfig {
+	if l == nil {
+		return nil
+	}
+	nl := new(LogConfig)
+	*nl = *l
+	if l.Shippers != nil {
+		shippers := make([]*LogShipper, len(l.Shippers))
+		for i, s := range l.Shippers {
+			shippers[i] = s.Copy()
+		}
+		nl.Shippers = shippers
+	}
+	return nl
+}
+
+const (
+	// LogShipperTypeTCP ships line-delimited JSON to a TCP endpoint
+	LogShipperTypeTCP = "tcp"
+
+	// LogShipperTypeUDP ships a JSON datagram per line to a UDP endpoint
+	LogShipperTypeUDP = "udp"
+
+	// LogShipperTypeFile writes the lines to a file in the log directory of
+	// the task in the journald export format
+	LogShipperTypeFile = "file"
+
+	// LogShipperTypeHTTP POSTs batches of lines as a JSON array to an HTTP
+	// endpoint
+	LogShipperTypeHTTP = "http"
+)
+
+// LogShipper configures shipping the log lines of a task to a sink
+type LogShipper struct {
+	// Type is the type of the sink the lines are shipped to
+	Type string
+
+	// Address is where the lines are shipped to. It is the host:port of tcp
+	// and udp sinks, the URL of http sinks and the path relative to the log
+	// directory of the task of file sinks.
+	Address string
+
+	// BufferSize is the number of lines buffered before writing the logs of
+	// the task blocks
+	BufferSize int
+
+	// BatchSize is the maximum number of lines sent at once
+	BatchSize int
+
+	// FlushInterval is the maximum time lines are buffered before being sent
+	FlushInterval time.Duration
+}
+
+// Copy returns a copy of the log shipper
+func (s *LogShipper) Copy() *LogShipper {
+	if s == nil {
+		return nil
+	}
+	ns := new(LogShipper)
+	*ns = *s
+	return ns
+}
+
+// Validate returns an error if the log shipper is invalid
+func (s *LogShipper) Validate() error {
+	var mErr multierror.Error
+	if s.Type == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("missing type"))
+	}
+	if s.Address == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("missing address"))
+	}
+	if s.BufferSize < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum buffer size is 1; got %d", s.BufferSize))
+	}
+	if s.BatchSize < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum batch size is 1; got %d", s.BatchSize))
+	} else if s.BatchSize > s.BufferSize {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("batch size %d can't be larger than the buffer size %d", s.BatchSize, s.BufferSize))
+	}
+	if s.FlushInterval <= 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("flush interval must be positive; got %v", s.FlushInterval))
+	}
+	if s.Address == "" {
+		return mErr.ErrorOrNil()
+	}
+
+	switch s.Type {
+	case LogShipperTypeTCP, LogShipperTypeUDP:
+		if _, _, err := net.SplitHostPort(s.Address); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid address %q: %v", s.Address, err))
+		}
+	case LogShipperTypeHTTP:
+		u, err := url.Parse(s.Address)
+		if err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid URL %q: %v", s.Address, err))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("URL %q must use the http or https scheme", s.Address))
+		}
+	case LogShipperTypeFile:
+		// The file must be within the log directory of the task
+		if filepath.IsAbs(s.Address) {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("file %q must be relative to the log directory", s.Address))
+		} else if escapes, err := PathEscapesAllocDir("", s.Address); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid file %q: %v", s.Address, err))
+		} else if escapes {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("file %q escapes the log directory", s.Address))
+		}
+	}
 	return mErr.ErrorOrNil()
 }
 
@@ -3866,6 +4575,10 @@ type Task struct {
 	// the particular task.
 	Constraints []*Constraint
 
+	// Affinities can be specified at the task level to express
+	// scheduling preferences
+	Affinities []*Affinity
+
 	// Resources is the resources needed by this task
 	Resources *Resources
 
@@ -3891,6 +4604,10 @@ type Task struct {
 	// task exits, other tasks will be gracefully terminated.
 	Leader bool
 
+	// Lifecycle runs the task before, alongside or after the main tasks of
+	// the group. Tasks without a lifecycle are main tasks.
+	Lifecycle *TaskLifecycleConfig
+
 	// ShutdownDelay is the duration of the delay between deregistering a
 	// task from Consul and sending it a signal to shutdown. See #2441
 	ShutdownDelay time.Duration
@@ -3900,6 +4617,10 @@ type Task struct {
 	// KillSignal is the kill signal to use for the task. This is an optional
 	// specification and defaults to SIGINT
 	KillSignal string
+
+	// VolumeMounts is the list of volumes of the task group mounted into the
+	// task
+	VolumeMounts []*VolumeMount
 }
 
 func (t *Task) Copy() *Task {
@@ -3919,11 +4640,15 @@ func (t *Task) Copy() *Task {
 	}
 
 	nt.Constraints = CopySliceConstraints(nt.Constraints)
+	nt.Affinities = CopySliceAffinities(nt.Affinities)
 
 	nt.Vault = nt.Vault.Copy()
 	nt.Resources = nt.Resources.Copy()
 	nt.Meta = helper.CopyMapStringString(nt.Meta)
 	nt.DispatchPayload = nt.DispatchPayload.Copy()
+	nt.Lifecycle = nt.Lifecycle.Copy()
+	nt.LogConfig = nt.LogConfig.Copy()
+	nt.VolumeMounts = CopySliceVolumeMount(nt.VolumeMounts)
 
 	if t.Artifacts != nil {
 		artifacts := make([]*TaskArtifact, 0, len(t.Artifacts))
@@ -3993,8 +4718,33 @@ func (t *Task) GoString() string {
 	return fmt.Sprintf("*%#v", *t)
 }
 
+// IsMain returns whether the task is a main task of its group, meaning it has
+// no lifecycle hook.
+func (t *Task) IsMain() bool {
+	return t.Lifecycle == nil
+}
+
+// IsLifecycleHook returns whether the task runs at the given lifecycle hook.
+func (t *Task) IsLifecycleHook(hook string) bool {
+	return t != nil && t.Lifecycle != nil && t.Lifecycle.Hook == hook
+}
+
+// IsSidecar returns whether the task is a lifecycle task that runs for as long
+// as the main tasks.
+func (t *Task) IsSidecar() bool {
+	return t.Lifecycle != nil && t.Lifecycle.Sidecar
+}
+
+// IsEphemeral returns whether the task is a lifecycle task that is expected to
+// run to completion.
+func (t *Task) IsEphemeral() bool {
+	return t.Lifecycle != nil && !t.Lifecycle.Sidecar
+}
+
 // Validate is used to sanity check a task
-func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
+// Validate validates the task. The networks of the task group are given to
+// validate the ports the services of the task refer to.
+func (t *Task) Validate(ephemeralDisk *EphemeralDisk, tgNetworks Networks) error {
 	var mErr multierror.Error
 	if t.Name == "" {
 		mErr.Errors = append(mErr.Errors, errors.New("Missing task name"))
@@ -4048,13 +4798,23 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	for idx, affinity := range t.Affinities {
+		if err := affinity.Validate(); err != nil {
+			outer := fmt.Errorf("Affinity %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
 	// Validate Services
-	if err := validateServices(t); err != nil {
+	if err := validateServices(t, tgNetworks); err != nil {
 		mErr.Errors = append(mErr.Errors, err)
 	}
 
 	if t.LogConfig != nil && ephemeralDisk != nil {
 		logUsage := (t.LogConfig.MaxFiles * t.LogConfig.MaxFileSizeMB)
+		if t.LogConfig.MaxTotalSizeMB != 0 && t.LogConfig.MaxTotalSizeMB < logUsage {
+			logUsage = t.LogConfig.MaxTotalSizeMB
+		}
 		if ephemeralDisk.SizeMB <= logUsage {
 			mErr.Errors = append(mErr.Errors,
 				fmt.Errorf("log storage (%d MB) must be less than requested disk capacity (%d MB)",
@@ -4097,12 +4857,19 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	// Validate the Lifecycle block
+	if t.Lifecycle != nil {
+		if err := t.Lifecycle.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Lifecycle validation failed: %v", err))
+		}
+	}
+
 	return mErr.ErrorOrNil()
 }
 
 // validateServices takes a task and validates the services within it are valid
 // and reference ports that exist.
-func validateServices(t *Task) error {
+func validateServices(t *Task, tgNetworks Networks) error {
 	var mErr multierror.Error
 
 	// Ensure that services don't ask for nonexistent ports and their names are
@@ -4189,8 +4956,14 @@ func validateServices(t *Task) error {
 		}
 	}
 
-	// Get the set of port labels.
+	// Get the set of port labels, the ports of the group network being
+	// available to every task
 	portLabels := make(map[string]struct{})
+	for _, network := range tgNetworks {
+		for portLabel := range network.PortLabels() {
+			portLabels[portLabel] = struct{}{}
+		}
+	}
 	if t.Resources != nil {
 		for _, network := range t.Resources.Networks {
 			ports := network.PortLabels()
@@ -4519,6 +5292,10 @@ const (
 
 	// TaskLeaderDead indicates that the leader task within the has finished.
 	TaskLeaderDead = "Leader Task Dead"
+
+	// TaskMainDead indicates that the main tasks within the group have
+	// finished and the sidecar tasks are being stopped.
+	TaskMainDead = "Main Tasks Dead"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
@@ -4728,6 +5505,8 @@ func (event *TaskEvent) PopulateEventDisplayMessage() {
 		desc = event.DriverMessage
 	case TaskLeaderDead:
 		desc = "Leader Task in Group dead"
+	case TaskMainDead:
+		desc = "Main tasks in the group died"
 	default:
 		desc = event.Message
 	}
@@ -5110,6 +5889,183 @@ func (c *Constraint) Validate() error {
 	return mErr.ErrorOrNil()
 }
 
+// Affinity is used to score placement options based on a weight
+type Affinity struct {
+	LTarget string // Left-hand target
+	RTarget string // Right-hand target
+	Operand string // Affinity operand (<=, <, =, !=, >, >=), set_contains, regexp, version
+	Weight  int    // Weight applied to nodes that match the affinity. Can be negative
+	str     string // Memoized string
+}
+
+// Equal checks if two affinities are equal
+func (a *Affinity) Equal(o *Affinity) bool {
+	return a.LTarget == o.LTarget &&
+		a.RTarget == o.RTarget &&
+		a.Operand == o.Operand &&
+		a.Weight == o.Weight
+}
+
+func (a *Affinity) Copy() *Affinity {
+	if a == nil {
+		return nil
+	}
+	na := new(Affinity)
+	*na = *a
+	return na
+}
+
+func (a *Affinity) String() string {
+	if a.str != "" {
+		return a.str
+	}
+	a.str = fmt.Sprintf("%s %s %s %v", a.LTarget, a.Operand, a.RTarget, a.Weight)
+	return a.str
+}
+
+func (a *Affinity) Validate() error {
+	var mErr multierror.Error
+	if a.Operand == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing affinity operand"))
+	}
+
+	// Perform additional validation based on operand
+	switch a.Operand {
+	case ConstraintSetContains:
+		if a.RTarget == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Set contains operator requires an RTarget"))
+		}
+	case ConstraintRegex:
+		if _, err := regexp.Compile(a.RTarget); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Regular expression failed to compile: %v", err))
+		}
+	case ConstraintVersion:
+		if _, err := version.NewConstraint(a.RTarget); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Version affinity is invalid: %v", err))
+		}
+	case "=", "==", "is", "!=", "not", "<", "<=", ">", ">=":
+		if a.RTarget == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Operator %q requires an RTarget", a.Operand))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unknown affinity operator %q", a.Operand))
+	}
+
+	// Ensure we have an LTarget
+	if a.LTarget == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("No LTarget provided but is required"))
+	}
+
+	// Ensure that weight is between -100 and 100, and not zero
+	if a.Weight == 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Affinity weight cannot be zero"))
+	}
+
+	if a.Weight > 100 || a.Weight < -100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Affinity weight must be within the range [-100,100]"))
+	}
+
+	return mErr.ErrorOrNil()
+}
+
+// Spread is used to specify the desired distribution of allocations across
+// the values of a node attribute.
+type Spread struct {
+	// Attribute is the node attribute used as the spread criteria
+	Attribute string
+
+	// Weight is the relative weight of this spread, useful when there are
+	// multiple spread and affinity stanzas
+	Weight int
+
+	// SpreadTarget is used to describe desired percentages for each attribute
+	// value. If no targets are given, allocations are spread evenly across
+	// all the values of the attribute.
+	SpreadTarget []*SpreadTarget
+
+	// Memoized string representation
+	str string
+}
+
+func (s *Spread) Copy() *Spread {
+	if s == nil {
+		return nil
+	}
+	ns := new(Spread)
+	*ns = *s
+
+	ns.SpreadTarget = CopySliceSpreadTarget(s.SpreadTarget)
+	return ns
+}
+
+func (s *Spread) String() string {
+	if s.str != "" {
+		return s.str
+	}
+	s.str = fmt.Sprintf("%s %s %v", s.Attribute, s.SpreadTarget, s.Weight)
+	return s.str
+}
+
+func (s *Spread) Validate() error {
+	var mErr multierror.Error
+	if s.Attribute == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing spread attribute"))
+	}
+	if s.Weight <= 0 || s.Weight > 100 {
+		mErr.Errors = append(mErr.Errors, errors.New("Spread stanza must have a weight between 1 and 100"))
+	}
+	seen := make(map[string]struct{})
+	sumPercent := uint32(0)
+
+	for _, target := range s.SpreadTarget {
+		// Make sure there are no duplicates
+		_, ok := seen[target.Value]
+		if !ok {
+			seen[target.Value] = struct{}{}
+		} else {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target value %q already defined", target.Value))
+		}
+		if target.Percent > 100 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target percentage for value %q must be between 0 and 100", target.Value))
+		}
+		sumPercent += target.Percent
+	}
+	if sumPercent > 100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Sum of spread target percentages must not be greater than 100%%; got %d%%", sumPercent))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// SpreadTarget is used to specify desired percentages for each attribute value
+type SpreadTarget struct {
+	// Value is a single attribute value, like "dc1"
+	Value string
+
+	// Percent is the desired percentage of allocs
+	Percent uint32
+
+	// Memoized string representation
+	str string
+}
+
+func (s *SpreadTarget) Copy() *SpreadTarget {
+	if s == nil {
+		return nil
+	}
+
+	ns := new(SpreadTarget)
+	*ns = *s
+	return ns
+}
+
+func (s *SpreadTarget) String() string {
+	if s.str != "" {
+		return s.str
+	}
+	s.str = fmt.Sprintf("%q %v%%", s.Value, s.Percent)
+	return s.str
+}
+
 // EphemeralDisk is an ephemeral disk object
 type EphemeralDisk struct {
 	// Sticky indicates whether the allocation is sticky to a node
@@ -5252,6 +6208,7 @@ const (
 	DeploymentStatusDescriptionNewerJob              = "Cancelled due to newer version of job"
 	DeploymentStatusDescriptionFailedAllocations     = "Failed due to unhealthy allocations"
 	DeploymentStatusDescriptionFailedByUser          = "Deployment marked as failed"
+	DeploymentStatusDescriptionProgressDeadline      = "Failed due to progress deadline"
 )
 
 // DeploymentStatusDescriptionRollback is used to get the status description of
@@ -5403,6 +6360,19 @@ type DeploymentState struct {
 	// reverted on failure
 	AutoRevert bool
 
+	// AutoPromote marks whether the task group has indicated the canaries
+	// should be promoted once they are all healthy
+	AutoPromote bool
+
+	// ProgressDeadline is the deadline by which an allocation must transition
+	// to healthy before the deployment is considered failed.
+	ProgressDeadline time.Duration
+
+	// RequireProgressBy is the time by which an allocation must transition
+	// to healthy before the deployment is considered failed. It is extended
+	// by the ProgressDeadline each time an allocation is marked healthy.
+	RequireProgressBy time.Time
+
 	// Promoted marks whether the canaries have been promoted
 	Promoted bool
 
@@ -5435,6 +6405,8 @@ func (d *DeploymentState) GoString() string {
 	base += fmt.Sprintf("\n\tHealthy: %d", d.HealthyAllocs)
 	base += fmt.Sprintf("\n\tUnhealthy: %d", d.UnhealthyAllocs)
 	base += fmt.Sprintf("\n\tAutoRevert: %v", d.AutoRevert)
+	base += fmt.Sprintf("\n\tAutoPromote: %v", d.AutoPromote)
+	base += fmt.Sprintf("\n\tRequireProgressBy: %v", d.RequireProgressBy)
 	return base
 }
 
@@ -5624,6 +6596,14 @@ type Allocation struct {
 	// that can be rescheduled in the future
 	FollowupEvalID string
 
+	// PreemptedAllocations captures IDs of any allocations that were preempted
+	// in order to place this allocation
+	PreemptedAllocations []string
+
+	// PreemptedByAllocation tracks the alloc ID of the allocation that caused this allocation
+	// to stop running because it got preempted
+	PreemptedByAllocation string
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -5937,47 +6917,51 @@ func (a *Allocation) SetEventDisplayMessages() {
 // Stub returns a list stub for the allocation
 func (a *Allocation) Stub() *AllocListStub {
 	return &AllocListStub{
-		ID:                 a.ID,
-		EvalID:             a.EvalID,
-		Name:               a.Name,
-		NodeID:             a.NodeID,
-		JobID:              a.JobID,
-		JobVersion:         a.Job.Version,
-		TaskGroup:          a.TaskGroup,
-		DesiredStatus:      a.DesiredStatus,
-		DesiredDescription: a.DesiredDescription,
-		ClientStatus:       a.ClientStatus,
-		ClientDescription:  a.ClientDescription,
-		TaskStates:         a.TaskStates,
-		DeploymentStatus:   a.DeploymentStatus,
-		FollowupEvalID:     a.FollowupEvalID,
-		CreateIndex:        a.CreateIndex,
-		ModifyIndex:        a.ModifyIndex,
-		CreateTime:         a.CreateTime,
-		ModifyTime:         a.ModifyTime,
+		ID:                    a.ID,
+		EvalID:                a.EvalID,
+		Name:                  a.Name,
+		NodeID:                a.NodeID,
+		JobID:                 a.JobID,
+		JobVersion:            a.Job.Version,
+		TaskGroup:             a.TaskGroup,
+		DesiredStatus:         a.DesiredStatus,
+		DesiredDescription:    a.DesiredDescription,
+		ClientStatus:          a.ClientStatus,
+		ClientDescription:     a.ClientDescription,
+		TaskStates:            a.TaskStates,
+		DeploymentStatus:      a.DeploymentStatus,
+		FollowupEvalID:        a.FollowupEvalID,
+		PreemptedAllocations:  a.PreemptedAllocations,
+		PreemptedByAllocation: a.PreemptedByAllocation,
+		CreateIndex:           a.CreateIndex,
+		ModifyIndex:           a.ModifyIndex,
+		CreateTime:            a.CreateTime,
+		ModifyTime:            a.ModifyTime,
 	}
 }
 
 // AllocListStub is used to return a subset of alloc information
 type AllocListStub struct {
-	ID                 string
-	EvalID             string
-	Name               string
-	NodeID             string
-	JobID              string
-	JobVersion         uint64
-	TaskGroup          string
-	DesiredStatus      string
-	DesiredDescription string
-	ClientStatus       string
-	ClientDescription  string
-	TaskStates         map[string]*TaskState
-	DeploymentStatus   *AllocDeploymentStatus
-	FollowupEvalID     string
-	CreateIndex        uint64
-	ModifyIndex        uint64
-	CreateTime         int64
-	ModifyTime         int64
+	ID                    string
+	EvalID                string
+	Name                  string
+	NodeID                string
+	JobID                 string
+	JobVersion            uint64
+	TaskGroup             string
+	DesiredStatus         string
+	DesiredDescription    string
+	ClientStatus          string
+	ClientDescription     string
+	TaskStates            map[string]*TaskState
+	DeploymentStatus      *AllocDeploymentStatus
+	FollowupEvalID        string
+	PreemptedAllocations  []string
+	PreemptedByAllocation string
+	CreateIndex           uint64
+	ModifyIndex           uint64
+	CreateTime            int64
+	ModifyTime            int64
 }
 
 // SetEventDisplayMessage populates the display message if its not already set,
@@ -6033,6 +7017,14 @@ type AllocMetric struct {
 	// for placement. The top score is typically selected.
 	Scores map[string]float64
 
+	// ScoreMetaData is the normalized score breakdown of the top scoring
+	// nodes for the placement, ordered from the highest score.
+	ScoreMetaData []*NodeScoreMeta
+
+	// nodeScoreMeta collects the scores of the node currently being ranked
+	// until they are normalized.
+	nodeScoreMeta *NodeScoreMeta
+
 	// AllocationTime is a measure of how long the allocation
 	// attempt took. This can affect performance and SLAs.
 	AllocationTime time.Duration
@@ -6057,6 +7049,13 @@ func (a *AllocMetric) Copy() *AllocMetric {
 	na.DimensionExhausted = helper.CopyMapStringInt(na.DimensionExhausted)
 	na.QuotaExhausted = helper.CopySliceString(na.QuotaExhausted)
 	na.Scores = helper.CopyMapStringFloat64(na.Scores)
+	na.nodeScoreMeta = nil
+	if a.ScoreMetaData != nil {
+		na.ScoreMetaData = make([]*NodeScoreMeta, len(a.ScoreMetaData))
+		for i, meta := range a.ScoreMetaData {
+			na.ScoreMetaData[i] = meta.Copy()
+		}
+	}
 	return na
 }
 
@@ -6110,6 +7109,76 @@ func (a *AllocMetric) ScoreNode(node *Node, name string, score float64) {
 	}
 	key := fmt.Sprintf("%s.%s", node.ID, name)
 	a.Scores[key] = score
+
+	if a.nodeScoreMeta == nil || a.nodeScoreMeta.NodeID != node.ID {
+		a.nodeScoreMeta = &NodeScoreMeta{
+			NodeID: node.ID,
+			Scores: make(map[string]float64),
+		}
+	}
+	a.nodeScoreMeta.Scores[name] = score
+}
+
+// NormalizeNodeScores completes the scoring of the node. The scores recorded
+// for the node by ScoreNode and its final score are divided by maxScore, and
+// the node is retained in ScoreMetaData if it is amongst the
+// MaxRetainedNodeScores highest scoring nodes.
+func (a *AllocMetric) NormalizeNodeScores(node *Node, finalScore, maxScore float64) {
+	meta := a.nodeScoreMeta
+	a.nodeScoreMeta = nil
+	if meta == nil || meta.NodeID != node.ID {
+		meta = &NodeScoreMeta{
+			NodeID: node.ID,
+			Scores: make(map[string]float64),
+		}
+	}
+	for name, score := range meta.Scores {
+		meta.Scores[name] = score / maxScore
+	}
+	meta.NormScore = finalScore / maxScore
+
+	// Insert the node in score order, dropping the lowest scoring node if
+	// too many are retained
+	i := sort.Search(len(a.ScoreMetaData), func(i int) bool {
+		return a.ScoreMetaData[i].NormScore < meta.NormScore
+	})
+	if i >= MaxRetainedNodeScores {
+		return
+	}
+	a.ScoreMetaData = append(a.ScoreMetaData, nil)
+	copy(a.ScoreMetaData[i+1:], a.ScoreMetaData[i:])
+	a.ScoreMetaData[i] = meta
+	if len(a.ScoreMetaData) > MaxRetainedNodeScores {
+		a.ScoreMetaData = a.ScoreMetaData[:MaxRetainedNodeScores]
+	}
+}
+
+// MaxRetainedNodeScores is the number of top scoring nodes whose score
+// breakdown is retained in an AllocMetric.
+const MaxRetainedNodeScores = 5
+
+// NodeScoreMeta is the breakdown of the score of a node that was ranked for
+// a placement.
+type NodeScoreMeta struct {
+	// NodeID is the ID of the ranked node
+	NodeID string
+
+	// Scores is the normalized score given to the node by each of the
+	// scoring iterators, keyed by the iterator's name
+	Scores map[string]float64
+
+	// NormScore is the normalized final score of the node
+	NormScore float64
+}
+
+func (s *NodeScoreMeta) Copy() *NodeScoreMeta {
+	if s == nil {
+		return nil
+	}
+	ns := new(NodeScoreMeta)
+	*ns = *s
+	ns.Scores = helper.CopyMapStringFloat64(s.Scores)
+	return ns
 }
 
 // AllocDeploymentStatus captures the status of the allocation as part of the
@@ -6121,6 +7190,9 @@ type AllocDeploymentStatus struct {
 	// healthy or unhealthy.
 	Healthy *bool
 
+	// Timestamp is the time at which the health of the allocation was set.
+	Timestamp time.Time
+
 	// ModifyIndex is the raft index in which the deployment status was last
 	// changed.
 	ModifyIndex uint64
@@ -6186,6 +7258,8 @@ const (
 	EvalTriggerFailedFollowUp    = "failed-follow-up"
 	EvalTriggerMaxPlans          = "max-plan-attempts"
 	EvalTriggerRetryFailedAlloc  = "alloc-failure"
+	EvalTriggerPreemption        = "preemption"
+	EvalTriggerAllocStop         = "alloc-stop"
 )
 
 const (
@@ -6525,6 +7599,11 @@ type Plan struct {
 	// deployments. This allows the scheduler to cancel any unneeded deployment
 	// because the job is stopped or the update block is removed.
 	DeploymentUpdates []*DeploymentStatusUpdate
+
+	// NodePreemptions is a map from node id to a set of allocations from other
+	// lower priority jobs that are preempted. Preempted allocations are marked
+	// as evicted.
+	NodePreemptions map[string][]*Allocation
 }
 
 // AppendUpdate marks the allocation for eviction. The clientStatus of the
@@ -6557,6 +7636,35 @@ func (p *Plan) AppendUpdate(alloc *Allocation, desiredStatus, desiredDesc, clien
 	p.NodeUpdate[node] = append(existing, newAlloc)
 }
 
+// AppendPreemptedAlloc is used to record an allocation of a lower priority job
+// that is preempted to make room for the allocation with the given ID.
+func (p *Plan) AppendPreemptedAlloc(alloc *Allocation, desiredStatus, preemptingAllocID string) {
+	newAlloc := &Allocation{}
+	newAlloc.ID = alloc.ID
+	newAlloc.JobID = alloc.JobID
+	newAlloc.Namespace = alloc.Namespace
+	newAlloc.NodeID = alloc.NodeID
+	newAlloc.TaskGroup = alloc.TaskGroup
+	newAlloc.DesiredStatus = desiredStatus
+	newAlloc.PreemptedByAllocation = preemptingAllocID
+
+	desiredDesc := fmt.Sprintf("Preempted by alloc ID %v", preemptingAllocID)
+	newAlloc.DesiredDescription = desiredDesc
+
+	// TaskResources are needed by the plan applier to check if allocations fit
+	// after removing preempted allocations
+	newAlloc.TaskResources = alloc.TaskResources
+	newAlloc.SharedResources = alloc.SharedResources
+
+	// Append this alloc to slice for this node
+	if p.NodePreemptions == nil {
+		p.NodePreemptions = make(map[string][]*Allocation)
+	}
+	node := alloc.NodeID
+	existing := p.NodePreemptions[node]
+	p.NodePreemptions[node] = append(existing, newAlloc)
+}
+
 func (p *Plan) PopUpdate(alloc *Allocation) {
 	existing := p.NodeUpdate[alloc.NodeID]
 	n := len(existing)
@@ -6580,6 +7688,7 @@ func (p *Plan) AppendAlloc(alloc *Allocation) {
 func (p *Plan) IsNoOp() bool {
 	return len(p.NodeUpdate) == 0 &&
 		len(p.NodeAllocation) == 0 &&
+		len(p.NodePreemptions) == 0 &&
 		p.Deployment == nil &&
 		len(p.DeploymentUpdates) == 0
 }
@@ -6598,6 +7707,10 @@ type PlanResult struct {
 	// DeploymentUpdates is the set of deployment updates that were committed.
 	DeploymentUpdates []*DeploymentStatusUpdate
 
+	// NodePreemptions is a map from node id to a set of allocations from other
+	// lower priority jobs that are preempted.
+	NodePreemptions map[string][]*Allocation
+
 	// RefreshIndex is the index the worker should refresh state up to.
 	// This allows all evictions and allocations to be materialized.
 	// If any allocations were rejected due to stale data (node state,
@@ -6612,7 +7725,8 @@ type PlanResult struct {
 // IsNoOp checks if this plan result would do nothing
 func (p *PlanResult) IsNoOp() bool {
 	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
-		len(p.DeploymentUpdates) == 0 && p.Deployment == nil
+		len(p.NodePreemptions) == 0 && len(p.DeploymentUpdates) == 0 &&
+		p.Deployment == nil
 }
 
 // FullCommit is used to check if all the allocations in a plan
@@ -6634,6 +7748,9 @@ func (p *PlanResult) FullCommit(plan *Plan) (bool, int, int) {
 type PlanAnnotations struct {
 	// DesiredTGUpdates is the set of desired updates per task group.
 	DesiredTGUpdates map[string]*DesiredUpdates
+
+	// PreemptedAllocs is the set of allocations to be preempted to make the placement successful.
+	PreemptedAllocs []*AllocListStub
 }
 
 // DesiredUpdates is the set of changes the scheduler would like to make given
@@ -6646,11 +7763,12 @@ type DesiredUpdates struct {
 	InPlaceUpdate     uint64
 	DestructiveUpdate uint64
 	Canary            uint64
+	Preemptions       uint64
 }
 
 func (d *DesiredUpdates) GoString() string {
-	return fmt.Sprintf("(place %d) (inplace %d) (destructive %d) (stop %d) (migrate %d) (ignore %d) (canary %d)",
-		d.Place, d.InPlaceUpdate, d.DestructiveUpdate, d.Stop, d.Migrate, d.Ignore, d.Canary)
+	return fmt.Sprintf("(place %d) (inplace %d) (destructive %d) (stop %d) (migrate %d) (ignore %d) (canary %d) (preemptions %d)",
+		d.Place, d.InPlaceUpdate, d.DestructiveUpdate, d.Stop, d.Migrate, d.Ignore, d.Canary, d.Preemptions)
 }
 
 // msgpackHandle is a shared handle for encoding/decoding of structs
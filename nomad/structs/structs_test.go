@@ -10,6 +10,7 @@ import (
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -736,10 +737,129 @@ func TestTaskGroup_Validate(t *testing.T) {
 	}
 }
 
+func TestTaskGroup_Validate_Lifecycle(t *testing.T) {
+	j := testJob()
+	tg := &TaskGroup{
+		Name:  "web",
+		Count: 1,
+		Tasks: []*Task{
+			{
+				Name:      "init",
+				Leader:    true,
+				Lifecycle: &TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart},
+			},
+		},
+		EphemeralDisk: DefaultEphemeralDisk(),
+		RestartPolicy: NewRestartPolicy(JobTypeService),
+	}
+
+	err := tg.Validate(j)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `Task "init" with a lifecycle can not be marked as leader`)
+	require.Contains(t, err.Error(), "Task group must have at least one task without a lifecycle")
+}
+
+func TestTaskGroup_Validate_Networks(t *testing.T) {
+	require := require.New(t)
+
+	// The services of the tasks can use the ports of the group network
+	j := testJob()
+	tg := j.TaskGroups[0]
+	tg.Networks = []*NetworkResource{
+		{
+			Mode:         NetworkModeBridge,
+			DynamicPorts: []Port{{Label: "http", To: 8080}},
+		},
+	}
+	tg.Tasks[0].Resources.Networks = nil
+	require.NoError(tg.Validate(j))
+
+	// Tasks can't request host networks in bridge mode
+	tg.Tasks[0].Resources.Networks = []*NetworkResource{
+		{
+			MBits:        50,
+			DynamicPorts: []Port{{Label: "admin"}},
+		},
+	}
+	err := tg.Validate(j)
+	require.Error(err)
+	require.Contains(err.Error(), `can't request a network when the group network is in "bridge" mode`)
+
+	// Ports can't be mapped in host mode
+	tg.Tasks[0].Resources.Networks = nil
+	tg.Networks[0].Mode = NetworkModeHost
+	err = tg.Validate(j)
+	require.Error(err)
+	require.Contains(err.Error(), `port "http" can't be mapped in host mode`)
+
+	tg.Networks = append(tg.Networks, &NetworkResource{Mode: "overlay"})
+	err = tg.Validate(j)
+	require.Error(err)
+	require.Contains(err.Error(), "Only one network resource")
+	require.Contains(err.Error(), `unsupported network mode "overlay"`)
+
+	// CNI networks are named after the mode prefix
+	tg.Networks = []*NetworkResource{{Mode: "cni/mynet", DynamicPorts: []Port{{Label: "http", To: 8080}}}}
+	require.NoError(tg.Validate(j))
+
+	tg.Networks[0].Mode = NetworkModeCNIPrefix
+	err = tg.Validate(j)
+	require.Error(err)
+	require.Contains(err.Error(), "missing the name of the CNI network")
+}
+
+func TestTaskLifecycleConfig_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		config *TaskLifecycleConfig
+		err    string
+	}{
+		{
+			name:   "prestart",
+			config: &TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart},
+		},
+		{
+			name:   "poststart sidecar",
+			config: &TaskLifecycleConfig{Hook: TaskLifecycleHookPoststart, Sidecar: true},
+		},
+		{
+			name:   "poststop",
+			config: &TaskLifecycleConfig{Hook: TaskLifecycleHookPoststop},
+		},
+		{
+			name:   "poststop sidecar",
+			config: &TaskLifecycleConfig{Hook: TaskLifecycleHookPoststop, Sidecar: true},
+			err:    "can not be sidecars",
+		},
+		{
+			name:   "missing hook",
+			config: &TaskLifecycleConfig{},
+			err:    "missing hook",
+		},
+		{
+			name:   "invalid hook",
+			config: &TaskLifecycleConfig{Hook: "prestop"},
+			err:    "invalid hook",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.config.Validate()
+			if c.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.err)
+			}
+		})
+	}
+}
+
 func TestTask_Validate(t *testing.T) {
 	task := &Task{}
 	ephemeralDisk := DefaultEphemeralDisk()
-	err := task.Validate(ephemeralDisk)
+	err := task.Validate(ephemeralDisk, nil)
 	mErr := err.(*multierror.Error)
 	if !strings.Contains(mErr.Errors[0].Error(), "task name") {
 		t.Fatalf("err: %s", err)
@@ -752,7 +872,7 @@ func TestTask_Validate(t *testing.T) {
 	}
 
 	task = &Task{Name: "web/foo"}
-	err = task.Validate(ephemeralDisk)
+	err = task.Validate(ephemeralDisk, nil)
 	mErr = err.(*multierror.Error)
 	if !strings.Contains(mErr.Errors[0].Error(), "slashes") {
 		t.Fatalf("err: %s", err)
@@ -769,7 +889,7 @@ func TestTask_Validate(t *testing.T) {
 		LogConfig: DefaultLogConfig(),
 	}
 	ephemeralDisk.SizeMB = 200
-	err = task.Validate(ephemeralDisk)
+	err = task.Validate(ephemeralDisk, nil)
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -783,7 +903,7 @@ func TestTask_Validate(t *testing.T) {
 			LTarget: "${meta.rack}",
 		})
 
-	err = task.Validate(ephemeralDisk)
+	err = task.Validate(ephemeralDisk, nil)
 	mErr = err.(*multierror.Error)
 	if !strings.Contains(mErr.Errors[0].Error(), "task level: distinct_hosts") {
 		t.Fatalf("err: %s", err)
@@ -866,7 +986,7 @@ func TestTask_Validate_Services(t *testing.T) {
 		},
 	}
 
-	err := task.Validate(ephemeralDisk)
+	err := task.Validate(ephemeralDisk, nil)
 	if err == nil {
 		t.Fatal("expected an error")
 	}
@@ -887,7 +1007,7 @@ func TestTask_Validate_Services(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if err = task1.Validate(ephemeralDisk); err != nil {
+	if err = task1.Validate(ephemeralDisk, nil); err != nil {
 		t.Fatalf("err : %v", err)
 	}
 }
@@ -946,7 +1066,7 @@ func TestTask_Validate_Service_AddressMode_Ok(t *testing.T) {
 	for _, service := range cases {
 		task := getTask(service)
 		t.Run(service.Name, func(t *testing.T) {
-			if err := task.Validate(ephemeralDisk); err != nil {
+			if err := task.Validate(ephemeralDisk, nil); err != nil {
 				t.Fatalf("unexpected err: %v", err)
 			}
 		})
@@ -999,7 +1119,7 @@ func TestTask_Validate_Service_AddressMode_Bad(t *testing.T) {
 	for _, service := range cases {
 		task := getTask(service)
 		t.Run(service.Name, func(t *testing.T) {
-			err := task.Validate(ephemeralDisk)
+			err := task.Validate(ephemeralDisk, nil)
 			if err == nil {
 				t.Fatalf("expected an error")
 			}
@@ -1252,7 +1372,7 @@ func TestTask_Validate_Service_Check_AddressMode(t *testing.T) {
 		tc := tc
 		task := getTask(tc.Service)
 		t.Run(tc.Service.Name, func(t *testing.T) {
-			err := validateServices(task)
+			err := validateServices(task, nil)
 			if err == nil && tc.ErrContains == "" {
 				// Ok!
 				return
@@ -1293,13 +1413,180 @@ func TestTask_Validate_LogConfig(t *testing.T) {
 		SizeMB: 1,
 	}
 
-	err := task.Validate(ephemeralDisk)
+	err := task.Validate(ephemeralDisk, nil)
 	mErr := err.(*multierror.Error)
 	if !strings.Contains(mErr.Errors[3].Error(), "log storage") {
 		t.Fatalf("err: %s", err)
 	}
 }
 
+func TestLogConfig_Validate_Rotation(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*LogConfig)
+		err    string
+	}{
+		{
+			name:   "defaults",
+			modify: func(l *LogConfig) {},
+		},
+		{
+			name: "hourly compressed",
+			modify: func(l *LogConfig) {
+				l.RotationInterval = time.Hour
+				l.Compress = true
+				l.MaxTotalSizeMB = 100
+			},
+		},
+		{
+			name:   "negative interval",
+			modify: func(l *LogConfig) { l.RotationInterval = -time.Hour },
+			err:    "rotation interval can't be negative",
+		},
+		{
+			name:   "interval too short",
+			modify: func(l *LogConfig) { l.RotationInterval = time.Second },
+			err:    "minimum rotation interval",
+		},
+		{
+			name:   "negative total size",
+			modify: func(l *LogConfig) { l.MaxTotalSizeMB = -1 },
+			err:    "total size can't be negative",
+		},
+		{
+			name:   "total size too small",
+			modify: func(l *LogConfig) { l.MaxTotalSizeMB = 15 },
+			err:    "total size must be at least twice the file size",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := DefaultLogConfig()
+			c.modify(l)
+			err := l.Validate()
+			if c.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected error containing %q; got %v", c.err, err)
+			}
+		})
+	}
+}
+
+func TestLogShipper_Validate(t *testing.T) {
+	valid := func(typ, addr string) *LogShipper {
+		return &LogShipper{
+			Type:          typ,
+			Address:       addr,
+			BufferSize:    1024,
+			BatchSize:     100,
+			FlushInterval: time.Second,
+		}
+	}
+
+	cases := []struct {
+		name    string
+		shipper *LogShipper
+		err     string
+	}{
+		{
+			name:    "tcp",
+			shipper: valid(LogShipperTypeTCP, "127.0.0.1:5140"),
+		},
+		{
+			name:    "udp",
+			shipper: valid(LogShipperTypeUDP, "logs.example.com:5140"),
+		},
+		{
+			name:    "http",
+			shipper: valid(LogShipperTypeHTTP, "https://logs.example.com/ingest"),
+		},
+		{
+			name:    "file",
+			shipper: valid(LogShipperTypeFile, "journal/task.journal"),
+		},
+		{
+			name:    "missing type",
+			shipper: valid("", "127.0.0.1:5140"),
+			err:     "missing type",
+		},
+		{
+			name:    "missing address",
+			shipper: valid(LogShipperTypeTCP, ""),
+			err:     "missing address",
+		},
+		{
+			name:    "invalid tcp address",
+			shipper: valid(LogShipperTypeTCP, "127.0.0.1"),
+			err:     "invalid address",
+		},
+		{
+			name:    "invalid http scheme",
+			shipper: valid(LogShipperTypeHTTP, "ftp://logs.example.com"),
+			err:     "http or https",
+		},
+		{
+			name:    "absolute file",
+			shipper: valid(LogShipperTypeFile, "/etc/passwd"),
+			err:     "must be relative",
+		},
+		{
+			name:    "escaping file",
+			shipper: valid(LogShipperTypeFile, "../../../etc/passwd"),
+			err:     "escapes the log directory",
+		},
+		{
+			name: "batch larger than buffer",
+			shipper: &LogShipper{
+				Type:          LogShipperTypeTCP,
+				Address:       "127.0.0.1:5140",
+				BufferSize:    10,
+				BatchSize:     100,
+				FlushInterval: time.Second,
+			},
+			err: "can't be larger than the buffer size",
+		},
+		{
+			name: "no flush interval",
+			shipper: &LogShipper{
+				Type:       LogShipperTypeTCP,
+				Address:    "127.0.0.1:5140",
+				BufferSize: 10,
+				BatchSize:  1,
+			},
+			err: "flush interval",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.shipper.Validate()
+			if c.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), c.err)
+			}
+		})
+	}
+}
+
+func TestLogConfig_Copy(t *testing.T) {
+	l := DefaultLogConfig()
+	l.Shippers = []*LogShipper{{Type: LogShipperTypeTCP, Address: "127.0.0.1:5140"}}
+
+	c := l.Copy()
+	require.Equal(t, l, c)
+
+	c.Shippers[0].Address = "127.0.0.1:5141"
+	require.Equal(t, "127.0.0.1:5140", l.Shippers[0].Address)
+}
+
 func TestTask_Validate_Template(t *testing.T) {
 
 	bad := &Template{}
@@ -1310,7 +1597,7 @@ func TestTask_Validate_Template(t *testing.T) {
 		SizeMB: 1,
 	}
 
-	err := task.Validate(ephemeralDisk)
+	err := task.Validate(ephemeralDisk, nil)
 	if !strings.Contains(err.Error(), "Template 1 validation failed") {
 		t.Fatalf("err: %s", err)
 	}
@@ -1323,7 +1610,7 @@ func TestTask_Validate_Template(t *testing.T) {
 	}
 
 	task.Templates = []*Template{good, good}
-	err = task.Validate(ephemeralDisk)
+	err = task.Validate(ephemeralDisk, nil)
 	if !strings.Contains(err.Error(), "same destination as") {
 		t.Fatalf("err: %s", err)
 	}
@@ -1336,7 +1623,7 @@ func TestTask_Validate_Template(t *testing.T) {
 		},
 	}
 
-	err = task.Validate(ephemeralDisk)
+	err = task.Validate(ephemeralDisk, nil)
 	if err == nil {
 		t.Fatalf("expected error from Template.Validate")
 	}
@@ -1448,6 +1735,239 @@ func TestTemplate_Validate(t *testing.T) {
 	}
 }
 
+func TestAffinity_Validate(t *testing.T) {
+	type tc struct {
+		affinity *Affinity
+		err      error
+	}
+
+	testCases := []tc{
+		{
+			affinity: &Affinity{},
+			err:      fmt.Errorf("Missing affinity operand"),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "foo",
+				LTarget: "${meta.node_class}",
+				Weight:  10,
+			},
+			err: fmt.Errorf("Unknown affinity operator \"foo\""),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "=",
+				LTarget: "${meta.node_class}",
+				Weight:  10,
+			},
+			err: fmt.Errorf("Operator \"=\" requires an RTarget"),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "=",
+				LTarget: "${meta.node_class}",
+				RTarget: "c4",
+				Weight:  0,
+			},
+			err: fmt.Errorf("Affinity weight cannot be zero"),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "=",
+				LTarget: "${meta.node_class}",
+				RTarget: "c4",
+				Weight:  500,
+			},
+			err: fmt.Errorf("Affinity weight must be within the range [-100,100]"),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "=",
+				LTarget: "${node.class}",
+				Weight:  10,
+			},
+			err: fmt.Errorf("Operator \"=\" requires an RTarget"),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "version",
+				LTarget: "${meta.os}",
+				RTarget: ">>2.0",
+				Weight:  500,
+			},
+			err: fmt.Errorf("Version affinity is invalid"),
+		},
+		{
+			affinity: &Affinity{
+				Operand: "regexp",
+				LTarget: "${meta.os}",
+				RTarget: "\\Dabc",
+				Weight:  -10,
+			},
+			err: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.affinity.Validate()
+		if tc.err != nil {
+			require.NotNil(t, err)
+			require.Contains(t, err.Error(), tc.err.Error())
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestSpread_Validate(t *testing.T) {
+	type tc struct {
+		spread *Spread
+		err    error
+		name   string
+	}
+
+	testCases := []tc{
+		{
+			spread: &Spread{},
+			err:    fmt.Errorf("Missing spread attribute"),
+			name:   "empty spread",
+		},
+		{
+			spread: &Spread{
+				Attribute: "${node.datacenter}",
+				Weight:    -1,
+			},
+			err:  fmt.Errorf("Spread stanza must have a weight between 1 and 100"),
+			name: "invalid weight",
+		},
+		{
+			spread: &Spread{
+				Attribute: "${node.datacenter}",
+				Weight:    50,
+				SpreadTarget: []*SpreadTarget{
+					{
+						Value:   "dc1",
+						Percent: 25,
+					},
+					{
+						Value:   "dc1",
+						Percent: 25,
+					},
+				},
+			},
+			err:  fmt.Errorf("Spread target value \"dc1\" already defined"),
+			name: "duplicate spread target",
+		},
+		{
+			spread: &Spread{
+				Attribute: "${node.datacenter}",
+				Weight:    50,
+				SpreadTarget: []*SpreadTarget{
+					{
+						Value:   "dc1",
+						Percent: 70,
+					},
+					{
+						Value:   "dc2",
+						Percent: 40,
+					},
+				},
+			},
+			err:  fmt.Errorf("Sum of spread target percentages must not be greater than 100%%; got %d%%", 110),
+			name: "sum of percentages over 100",
+		},
+		{
+			spread: &Spread{
+				Attribute: "${node.datacenter}",
+				Weight:    50,
+				SpreadTarget: []*SpreadTarget{
+					{
+						Value:   "dc1",
+						Percent: 70,
+					},
+					{
+						Value:   "dc2",
+						Percent: 30,
+					},
+				},
+			},
+			err:  nil,
+			name: "valid spread",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.spread.Validate()
+			if tc.err != nil {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tc.err.Error())
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestJob_Validate_SystemSpread(t *testing.T) {
+	j := testJob()
+	j.Type = JobTypeSystem
+	j.Spreads = []*Spread{
+		{
+			Attribute: "${node.datacenter}",
+			Weight:    50,
+		},
+	}
+	j.TaskGroups[0].Count = 1
+	j.TaskGroups[0].ReschedulePolicy = nil
+
+	err := j.Validate()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "System jobs may not have a spread stanza")
+}
+
+func TestJob_Validate_DuplicateSpreads(t *testing.T) {
+	require := require.New(t)
+
+	// A task group may not spread on an attribute the job spreads on
+	j := testJob()
+	j.Spreads = []*Spread{
+		{
+			Attribute: "${node.datacenter}",
+			Weight:    50,
+		},
+	}
+	j.TaskGroups[0].Spreads = []*Spread{
+		{
+			Attribute: "${meta.rack}",
+			Weight:    50,
+		},
+		{
+			Attribute: "${node.datacenter}",
+			Weight:    50,
+		},
+	}
+	err := j.Validate()
+	require.NotNil(err)
+	require.Contains(err.Error(), `Spread 2 redefines attribute "${node.datacenter}" from job spread 1`)
+
+	// Spreads may not repeat an attribute at the same level
+	j.TaskGroups[0].Spreads[1].Attribute = "${meta.rack}"
+	j.Spreads = append(j.Spreads, &Spread{
+		Attribute: "${node.datacenter}",
+		Weight:    20,
+	})
+	err = j.Validate()
+	require.NotNil(err)
+	require.Contains(err.Error(), `Spread 2 redefines attribute "${node.datacenter}" from spread 1`)
+	require.Contains(err.Error(), `Spread 2 redefines attribute "${meta.rack}" from spread 1`)
+
+	// Distinct attributes are valid
+	j.Spreads = j.Spreads[:1]
+	j.TaskGroups[0].Spreads = j.TaskGroups[0].Spreads[:1]
+	require.Nil(j.Validate())
+}
+
 func TestConstraint_Validate(t *testing.T) {
 	c := &Constraint{}
 	err := c.Validate()
@@ -1538,12 +2058,13 @@ func TestConstraint_Validate(t *testing.T) {
 
 func TestUpdateStrategy_Validate(t *testing.T) {
 	u := &UpdateStrategy{
-		MaxParallel:     0,
-		HealthCheck:     "foo",
-		MinHealthyTime:  -10,
-		HealthyDeadline: -15,
-		AutoRevert:      false,
-		Canary:          -1,
+		MaxParallel:      0,
+		HealthCheck:      "foo",
+		MinHealthyTime:   -10,
+		HealthyDeadline:  -15,
+		ProgressDeadline: -25,
+		AutoRevert:       false,
+		Canary:           -1,
 	}
 
 	err := u.Validate()
@@ -1566,6 +2087,32 @@ func TestUpdateStrategy_Validate(t *testing.T) {
 	if !strings.Contains(mErr.Errors[5].Error(), "Minimum healthy time must be less than healthy deadline") {
 		t.Fatalf("err: %s", err)
 	}
+	if !strings.Contains(mErr.Errors[6].Error(), "Progress deadline must be zero or greater") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestUpdateStrategy_Validate_ProgressDeadline(t *testing.T) {
+	u := DefaultUpdateStrategy.Copy()
+	u.ProgressDeadline = u.HealthyDeadline
+	err := u.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Healthy deadline must be less than progress deadline") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A zero progress deadline disables it
+	u.ProgressDeadline = 0
+	if err := u.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The progress deadline is disabled by default so long healthy deadlines
+	// remain valid
+	u = DefaultUpdateStrategy.Copy()
+	u.HealthyDeadline = 15 * time.Minute
+	if err := u.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 }
 
 func TestResource_NetIndex(t *testing.T) {
@@ -1615,6 +2162,23 @@ func TestResource_Superset(t *testing.T) {
 	}
 }
 
+func TestResource_MeetsMinResources_MemoryMax(t *testing.T) {
+	r := &Resources{
+		CPU:         100,
+		MemoryMB:    256,
+		MemoryMaxMB: 1024,
+	}
+	if err := r.MeetsMinResources(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r.MemoryMaxMB = 128
+	err := r.MeetsMinResources()
+	if err == nil || !strings.Contains(err.Error(), "MemoryMaxMB value (128) must be greater than or equal to MemoryMB (256)") {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
 func TestResource_Add(t *testing.T) {
 	r1 := &Resources{
 		CPU:      2000,
@@ -1625,7 +2189,7 @@ func TestResource_Add(t *testing.T) {
 			{
 				CIDR:          "10.0.0.0/8",
 				MBits:         100,
-				ReservedPorts: []Port{{"ssh", 22}},
+				ReservedPorts: []Port{{"ssh", 22, 0}},
 			},
 		},
 	}
@@ -1638,7 +2202,7 @@ func TestResource_Add(t *testing.T) {
 			{
 				IP:            "10.0.0.1",
 				MBits:         50,
-				ReservedPorts: []Port{{"web", 80}},
+				ReservedPorts: []Port{{"web", 80, 0}},
 			},
 		},
 	}
@@ -1657,7 +2221,7 @@ func TestResource_Add(t *testing.T) {
 			{
 				CIDR:          "10.0.0.0/8",
 				MBits:         150,
-				ReservedPorts: []Port{{"ssh", 22}, {"web", 80}},
+				ReservedPorts: []Port{{"ssh", 22, 0}, {"web", 80, 0}},
 			},
 		},
 	}
@@ -1673,7 +2237,7 @@ func TestResource_Add_Network(t *testing.T) {
 		Networks: []*NetworkResource{
 			{
 				MBits:        50,
-				DynamicPorts: []Port{{"http", 0}, {"https", 0}},
+				DynamicPorts: []Port{{"http", 0, 0}, {"https", 0, 0}},
 			},
 		},
 	}
@@ -1681,7 +2245,7 @@ func TestResource_Add_Network(t *testing.T) {
 		Networks: []*NetworkResource{
 			{
 				MBits:        25,
-				DynamicPorts: []Port{{"admin", 0}},
+				DynamicPorts: []Port{{"admin", 0, 0}},
 			},
 		},
 	}
@@ -1699,7 +2263,7 @@ func TestResource_Add_Network(t *testing.T) {
 		Networks: []*NetworkResource{
 			{
 				MBits:        75,
-				DynamicPorts: []Port{{"http", 0}, {"https", 0}, {"admin", 0}},
+				DynamicPorts: []Port{{"http", 0, 0}, {"https", 0, 0}, {"admin", 0, 0}},
 			},
 		},
 	}
@@ -2022,6 +2586,91 @@ func TestPeriodicConfig_NextCron(t *testing.T) {
 	}
 }
 
+func TestPeriodicConfig_InvalidSpecs(t *testing.T) {
+	p := &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Specs: []string{"@hourly", "foo"}}
+	p.Canonicalize()
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "Invalid cron spec \"foo\"") {
+		t.Fatalf("expected an invalid cron spec error; got %v", err)
+	}
+
+	p = &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Specs: []string{""}}
+	p.Canonicalize()
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "Specs can't be empty") {
+		t.Fatalf("expected an empty spec error; got %v", err)
+	}
+
+	p = &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: "@hourly", CatchUpWindow: -time.Hour}
+	p.Canonicalize()
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "Catch-up window") {
+		t.Fatalf("expected a catch-up window error; got %v", err)
+	}
+
+	p = &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: "@hourly", CatchUpWindow: MaxPeriodicCatchUpWindow + time.Hour}
+	p.Canonicalize()
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "Catch-up window must be at most") {
+		t.Fatalf("expected a catch-up window error; got %v", err)
+	}
+
+	p = &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: "@hourly", CatchUpWindow: MaxPeriodicCatchUpWindow}
+	p.Canonicalize()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestPeriodicConfig_NextMultipleSpecs(t *testing.T) {
+	// Weekdays at 8am and weekends at 10am
+	p := &PeriodicConfig{
+		Enabled:  true,
+		SpecType: PeriodicSpecCron,
+		Spec:     "0 8 * * 1-5",
+		Specs:    []string{"0 10 * * 0,6"},
+	}
+	p.Canonicalize()
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Valid specs errored: %v", err)
+	}
+
+	// Friday November 10 2017
+	from := time.Date(2017, time.November, 10, 9, 0, 0, 0, time.UTC)
+	expected := []time.Time{
+		time.Date(2017, time.November, 11, 10, 0, 0, 0, time.UTC),
+		time.Date(2017, time.November, 12, 10, 0, 0, 0, time.UTC),
+		time.Date(2017, time.November, 13, 8, 0, 0, 0, time.UTC),
+	}
+	for _, e := range expected {
+		n := p.Next(from)
+		if e != n {
+			t.Fatalf("Next(%v) returned %v; want %v", from, n, e)
+		}
+		from = n
+	}
+}
+
+func TestPeriodicConfig_Launches(t *testing.T) {
+	p := &PeriodicConfig{Enabled: true, SpecType: PeriodicSpecCron, Spec: "0 * * * *"}
+	p.Canonicalize()
+
+	from := time.Date(2017, time.November, 10, 9, 0, 0, 0, time.UTC)
+	to := time.Date(2017, time.November, 10, 12, 0, 0, 0, time.UTC)
+
+	launches := p.Launches(from, to, 10)
+	expected := []time.Time{
+		time.Date(2017, time.November, 10, 10, 0, 0, 0, time.UTC),
+		time.Date(2017, time.November, 10, 11, 0, 0, 0, time.UTC),
+		time.Date(2017, time.November, 10, 12, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(launches, expected) {
+		t.Fatalf("Launches(%v, %v) returned %v; want %v", from, to, launches, expected)
+	}
+
+	// The latest launches are kept when limited
+	launches = p.Launches(from, to, 2)
+	if !reflect.DeepEqual(launches, expected[1:]) {
+		t.Fatalf("Launches(%v, %v) returned %v; want %v", from, to, launches, expected[1:])
+	}
+}
+
 func TestPeriodicConfig_ValidTimeZone(t *testing.T) {
 	zones := []string{"Africa/Abidjan", "America/Chicago", "Europe/Minsk", "UTC"}
 	for _, zone := range zones {
@@ -2493,6 +3142,43 @@ func TestTaskArtifact_Validate_Checksum(t *testing.T) {
 	}
 }
 
+func TestAllocMetric_NormalizeNodeScores(t *testing.T) {
+	require := require.New(t)
+
+	metric := &AllocMetric{}
+	nodes := make([]*Node, MaxRetainedNodeScores+2)
+	for i := range nodes {
+		nodes[i] = &Node{ID: fmt.Sprintf("node-%d", i)}
+		metric.ScoreNode(nodes[i], "binpack", float64(i))
+		if i%2 == 0 {
+			metric.ScoreNode(nodes[i], "job-anti-affinity", -0.5)
+			metric.NormalizeNodeScores(nodes[i], float64(i)-0.5, 2)
+		} else {
+			metric.NormalizeNodeScores(nodes[i], float64(i), 2)
+		}
+	}
+
+	// The raw scores are still recorded
+	require.Equal(6.0, metric.Scores["node-6.binpack"])
+	require.Equal(-0.5, metric.Scores["node-6.job-anti-affinity"])
+
+	// Only the top scoring nodes are retained, highest first
+	require.Len(metric.ScoreMetaData, MaxRetainedNodeScores)
+	expected := []string{"node-6", "node-5", "node-4", "node-3", "node-2"}
+	for i, meta := range metric.ScoreMetaData {
+		require.Equal(expected[i], meta.NodeID)
+	}
+
+	top := metric.ScoreMetaData[0]
+	require.Equal(2.75, top.NormScore)
+	require.Equal(map[string]float64{"binpack": 3, "job-anti-affinity": -0.25}, top.Scores)
+
+	// Copies don't share the score breakdown
+	c := metric.Copy()
+	c.ScoreMetaData[0].Scores["binpack"] = 0
+	require.Equal(3.0, top.Scores["binpack"])
+}
+
 func TestAllocation_Terminated(t *testing.T) {
 	type desiredState struct {
 		ClientStatus  string
@@ -3514,12 +4200,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 			},
 			true,
@@ -3530,12 +4216,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:            "10.0.0.0",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 			},
 			false,
@@ -3546,12 +4232,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:            "10.0.0.1",
 					MBits:         40,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 			},
 			false,
@@ -3562,12 +4248,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}, {"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}, {"web", 80, 0}},
 				},
 			},
 			false,
@@ -3578,7 +4264,7 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:            "10.0.0.1",
@@ -3594,12 +4280,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"web", 80}},
+					ReservedPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"notweb", 80}},
+					ReservedPorts: []Port{{"notweb", 80, 0}},
 				},
 			},
 			false,
@@ -3610,12 +4296,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:           "10.0.0.1",
 					MBits:        50,
-					DynamicPorts: []Port{{"web", 80}},
+					DynamicPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:           "10.0.0.1",
 					MBits:        50,
-					DynamicPorts: []Port{{"web", 80}, {"web", 80}},
+					DynamicPorts: []Port{{"web", 80, 0}, {"web", 80, 0}},
 				},
 			},
 			false,
@@ -3626,7 +4312,7 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:           "10.0.0.1",
 					MBits:        50,
-					DynamicPorts: []Port{{"web", 80}},
+					DynamicPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:           "10.0.0.1",
@@ -3642,12 +4328,12 @@ func TestNetworkResourcesEquals(t *testing.T) {
 				{
 					IP:           "10.0.0.1",
 					MBits:        50,
-					DynamicPorts: []Port{{"web", 80}},
+					DynamicPorts: []Port{{"web", 80, 0}},
 				},
 				{
 					IP:           "10.0.0.1",
 					MBits:        50,
-					DynamicPorts: []Port{{"notweb", 80}},
+					DynamicPorts: []Port{{"notweb", 80, 0}},
 				},
 			},
 			false,
@@ -3676,3 +4362,42 @@ func TestNode_Canonicalize(t *testing.T) {
 	node.Canonicalize()
 	require.Equal(NodeSchedulingIneligible, node.SchedulingEligibility)
 }
+
+func TestSchedulerConfiguration_Validate(t *testing.T) {
+	require := require.New(t)
+
+	c := &SchedulerConfiguration{}
+	require.Nil(c.Validate())
+
+	c.Canonicalize()
+	require.Nil(c.Validate())
+	require.Equal(DefaultSchedulerConfiguration(), c)
+	require.Equal(SchedulerAlgorithmBinpack, c.SchedulerAlgorithm)
+	require.Equal(DefaultMaxServiceScheduleAttempts, *c.MaxServiceScheduleAttempts)
+
+	// Zero values are kept where they are meaningful
+	c = &SchedulerConfiguration{
+		MaxSkippedNodes:               helper.IntToPtr(0),
+		ServiceJobAntiAffinityPenalty: helper.Float64ToPtr(0),
+	}
+	require.Nil(c.Validate())
+	c.Canonicalize()
+	require.Zero(*c.MaxSkippedNodes)
+	require.Zero(*c.ServiceJobAntiAffinityPenalty)
+	require.Equal(DefaultBatchJobAntiAffinityPenalty, *c.BatchJobAntiAffinityPenalty)
+
+	c = &SchedulerConfiguration{
+		SchedulerAlgorithm:          "random",
+		MinNodeLimit:                helper.IntToPtr(0),
+		BatchJobAntiAffinityPenalty: helper.Float64ToPtr(-2),
+		MaxBatchScheduleAttempts:    helper.IntToPtr(0),
+	}
+	err := c.Validate()
+	require.NotNil(err)
+	mErr := err.(*multierror.Error)
+	require.Len(mErr.Errors, 4)
+	require.Contains(mErr.Errors[0].Error(), "scheduler algorithm")
+	require.Contains(mErr.Errors[1].Error(), "minimum node limit")
+	require.Contains(mErr.Errors[2].Error(), "batch job anti-affinity penalty")
+	require.Contains(mErr.Errors[3].Error(), "maximum batch schedule attempts")
+}
@@ -0,0 +1,204 @@
+package structs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// VolumeTypeHost is the type of the volumes backed by a directory of
+	// the host configured by the operator.
+	VolumeTypeHost = "host"
+)
+
+// ClientHostVolumeConfig is a directory of a client host that operators make
+// available to tasks under a name.
+type ClientHostVolumeConfig struct {
+	Name     string `hcl:"-"`
+	Path     string `hcl:"path"`
+	ReadOnly bool   `hcl:"read_only"`
+}
+
+func (p *ClientHostVolumeConfig) Copy() *ClientHostVolumeConfig {
+	if p == nil {
+		return nil
+	}
+
+	c := new(ClientHostVolumeConfig)
+	*c = *p
+	return c
+}
+
+// Validate validates the host volume configuration of a client.
+func (p *ClientHostVolumeConfig) Validate() error {
+	var mErr multierror.Error
+	if p.Name == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("missing host volume name"))
+	}
+	if p.Path == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("host volume %q is missing a path", p.Name))
+	} else if !filepath.IsAbs(p.Path) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("host volume %q path must be absolute; got %q", p.Name, p.Path))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopyMapStringClientHostVolumeConfig copies a map of host volumes.
+func CopyMapStringClientHostVolumeConfig(m map[string]*ClientHostVolumeConfig) map[string]*ClientHostVolumeConfig {
+	if m == nil {
+		return nil
+	}
+
+	nm := make(map[string]*ClientHostVolumeConfig, len(m))
+	for k, v := range m {
+		nm[k] = v.Copy()
+	}
+	return nm
+}
+
+// HostVolumeSliceMerge merges two slices of host volumes, the volumes of b
+// replacing the volumes of a with the same name.
+func HostVolumeSliceMerge(a, b []*ClientHostVolumeConfig) []*ClientHostVolumeConfig {
+	n := make([]*ClientHostVolumeConfig, len(a))
+	seenKeys := make(map[string]int, len(a))
+
+	for i, config := range a {
+		n[i] = config.Copy()
+		seenKeys[config.Name] = i
+	}
+
+	for _, config := range b {
+		if fIndex, ok := seenKeys[config.Name]; ok {
+			n[fIndex] = config.Copy()
+			continue
+		}
+
+		n = append(n, config.Copy())
+	}
+
+	return n
+}
+
+// VolumeRequest is a request of a task group for a volume. The tasks of the
+// group mount it with a VolumeMount.
+type VolumeRequest struct {
+	// Name is the name of the volume within the task group
+	Name string
+
+	// Type is the type of volume, only host volumes are supported
+	Type string
+
+	// Source is the name of the host volume on the client
+	Source string
+
+	// ReadOnly requests the volume to be mounted read only
+	ReadOnly bool
+}
+
+func (v *VolumeRequest) Copy() *VolumeRequest {
+	if v == nil {
+		return nil
+	}
+
+	nv := new(VolumeRequest)
+	*nv = *v
+	return nv
+}
+
+// Validate validates the volume request of a task group.
+func (v *VolumeRequest) Validate() error {
+	var mErr multierror.Error
+	if v.Name == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("missing volume name"))
+	}
+	switch v.Type {
+	case VolumeTypeHost:
+		if v.Source == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("volume %q is missing a source", v.Name))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("volume %q has unsupported type %q", v.Name, v.Type))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopyMapVolumeRequest copies a map of volume requests.
+func CopyMapVolumeRequest(m map[string]*VolumeRequest) map[string]*VolumeRequest {
+	if m == nil {
+		return nil
+	}
+
+	nm := make(map[string]*VolumeRequest, len(m))
+	for k, v := range m {
+		nm[k] = v.Copy()
+	}
+	return nm
+}
+
+// VolumeMount mounts a volume of the task group into a task.
+type VolumeMount struct {
+	// Volume is the name of the volume of the task group
+	Volume string
+
+	// Destination is the path the volume is mounted at in the task. It is
+	// relative to the task directory for tasks without image isolation.
+	Destination string
+
+	// ReadOnly mounts the volume read only
+	ReadOnly bool
+}
+
+func (v *VolumeMount) Copy() *VolumeMount {
+	if v == nil {
+		return nil
+	}
+
+	nv := new(VolumeMount)
+	*nv = *v
+	return nv
+}
+
+// Validate validates the volume mount of a task against the volumes of its
+// task group.
+func (v *VolumeMount) Validate(volumes map[string]*VolumeRequest) error {
+	var mErr multierror.Error
+	if v.Volume == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("missing volume"))
+	} else if _, ok := volumes[v.Volume]; !ok {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("volume %q is not defined by the task group", v.Volume))
+	}
+
+	// The destination is relative to the root of the task, which is the task
+	// directory for tasks without image isolation. The directories managed by
+	// Nomad are excluded as they are migrated and garbage collected.
+	dest := filepath.Clean(strings.TrimLeft(v.Destination, "/"))
+	top := strings.SplitN(dest, "/", 2)[0]
+	switch {
+	case v.Destination == "":
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("missing destination"))
+	case dest == ".":
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("destination can't be the root of the task"))
+	case dest == ".." || strings.HasPrefix(dest, "../"):
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("destination escapes the task's directory"))
+	case top == "alloc" || top == "local" || top == "secrets":
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("destination can't be within the %q directory of the task", top))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopySliceVolumeMount copies a slice of volume mounts.
+func CopySliceVolumeMount(s []*VolumeMount) []*VolumeMount {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	c := make([]*VolumeMount, l)
+	for i, v := range s {
+		c[i] = v.Copy()
+	}
+	return c
+}
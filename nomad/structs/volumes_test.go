@@ -0,0 +1,146 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientHostVolumeConfig_Validate(t *testing.T) {
+	require := require.New(t)
+
+	v := &ClientHostVolumeConfig{Name: "data", Path: "/srv/data"}
+	require.NoError(v.Validate())
+
+	v.Path = "srv/data"
+	err := v.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "must be absolute")
+
+	v = &ClientHostVolumeConfig{}
+	err = v.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "missing host volume name")
+	require.Contains(err.Error(), "missing a path")
+}
+
+func TestHostVolumeSliceMerge(t *testing.T) {
+	require := require.New(t)
+
+	a := []*ClientHostVolumeConfig{
+		{Name: "certs", Path: "/etc/ssl/certs", ReadOnly: true},
+		{Name: "data", Path: "/srv/data"},
+	}
+	b := []*ClientHostVolumeConfig{
+		{Name: "data", Path: "/mnt/data", ReadOnly: true},
+		{Name: "logs", Path: "/var/log"},
+	}
+
+	expected := []*ClientHostVolumeConfig{
+		{Name: "certs", Path: "/etc/ssl/certs", ReadOnly: true},
+		{Name: "data", Path: "/mnt/data", ReadOnly: true},
+		{Name: "logs", Path: "/var/log"},
+	}
+	require.Equal(expected, HostVolumeSliceMerge(a, b))
+
+	// The merged volumes are copies
+	require.Equal("/srv/data", a[1].Path)
+}
+
+func TestVolumeRequest_Validate(t *testing.T) {
+	require := require.New(t)
+
+	v := &VolumeRequest{Name: "data", Type: VolumeTypeHost, Source: "shared-data"}
+	require.NoError(v.Validate())
+
+	v.Source = ""
+	err := v.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "missing a source")
+
+	v = &VolumeRequest{Name: "data", Type: "csi", Source: "shared-data"}
+	err = v.Validate()
+	require.Error(err)
+	require.Contains(err.Error(), "unsupported type")
+}
+
+func TestVolumeMount_Validate(t *testing.T) {
+	volumes := map[string]*VolumeRequest{
+		"data": {Name: "data", Type: VolumeTypeHost, Source: "shared-data"},
+	}
+
+	cases := []struct {
+		Name  string
+		Mount *VolumeMount
+		Err   string
+	}{
+		{
+			Name:  "valid",
+			Mount: &VolumeMount{Volume: "data", Destination: "/data"},
+		},
+		{
+			Name:  "valid relative",
+			Mount: &VolumeMount{Volume: "data", Destination: "srv/data"},
+		},
+		{
+			Name:  "undefined volume",
+			Mount: &VolumeMount{Volume: "other", Destination: "/data"},
+			Err:   "not defined by the task group",
+		},
+		{
+			Name:  "missing volume",
+			Mount: &VolumeMount{Destination: "/data"},
+			Err:   "missing volume",
+		},
+		{
+			Name:  "missing destination",
+			Mount: &VolumeMount{Volume: "data"},
+			Err:   "missing destination",
+		},
+		{
+			Name:  "root destination",
+			Mount: &VolumeMount{Volume: "data", Destination: "/"},
+			Err:   "root of the task",
+		},
+		{
+			Name:  "escaping destination",
+			Mount: &VolumeMount{Volume: "data", Destination: "srv/../../data"},
+			Err:   "escapes",
+		},
+		{
+			Name:  "managed destination",
+			Mount: &VolumeMount{Volume: "data", Destination: "/local/data"},
+			Err:   "within the \"local\" directory",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			err := c.Mount.Validate(volumes)
+			if c.Err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.Err)
+		})
+	}
+}
+
+func TestTaskGroup_Validate_Volumes(t *testing.T) {
+	require := require.New(t)
+
+	j := testJob()
+	tg := j.TaskGroups[0]
+	tg.Volumes = map[string]*VolumeRequest{
+		"data": {Name: "other", Type: VolumeTypeHost, Source: "shared-data"},
+	}
+	tg.Tasks[0].VolumeMounts = []*VolumeMount{
+		{Volume: "missing", Destination: "/data"},
+	}
+
+	err := tg.Validate(j)
+	require.Error(err)
+	require.Contains(err.Error(), "mismatched name")
+	require.Contains(err.Error(), "volume mount 1 validation failed")
+}
@@ -165,6 +165,94 @@ func (c *DriverChecker) hasDrivers(option *structs.Node) bool {
 	return true
 }
 
+// HostVolumeChecker is a FeasibilityChecker which returns whether a node has
+// the host volumes necessary to schedule a task group.
+type HostVolumeChecker struct {
+	ctx     Context
+	volumes map[string]*structs.VolumeRequest
+}
+
+// NewHostVolumeChecker creates a HostVolumeChecker
+func NewHostVolumeChecker(ctx Context) *HostVolumeChecker {
+	return &HostVolumeChecker{
+		ctx: ctx,
+	}
+}
+
+// SetVolumes takes the volumes required by a task group and updates the
+// checker.
+func (h *HostVolumeChecker) SetVolumes(volumes map[string]*structs.VolumeRequest) {
+	h.volumes = volumes
+}
+
+func (h *HostVolumeChecker) Feasible(candidate *structs.Node) bool {
+	if h.hasVolumes(candidate) {
+		return true
+	}
+
+	h.ctx.Metrics().FilterNode(candidate, "missing compatible host volumes")
+	return false
+}
+
+// hasVolumes is used to check if the node provides the host volumes requested
+// by the task group, writable unless the request is read only.
+func (h *HostVolumeChecker) hasVolumes(n *structs.Node) bool {
+	for _, req := range h.volumes {
+		if req.Type != structs.VolumeTypeHost {
+			continue
+		}
+
+		volume, ok := n.HostVolumes[req.Source]
+		if !ok || volume == nil {
+			return false
+		}
+
+		if volume.ReadOnly && !req.ReadOnly {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NetworkChecker is a FeasibilityChecker which returns whether a node supports
+// the mode of the network requested by a task group.
+type NetworkChecker struct {
+	ctx  Context
+	mode string
+}
+
+// NewNetworkChecker creates a NetworkChecker
+func NewNetworkChecker(ctx Context) *NetworkChecker {
+	return &NetworkChecker{
+		ctx: ctx,
+	}
+}
+
+// SetNetworks takes the networks requested by a task group and updates the
+// checker.
+func (c *NetworkChecker) SetNetworks(networks []*structs.NetworkResource) {
+	c.mode = structs.NetworkModeHost
+	if len(networks) > 0 && networks[0].Mode != "" {
+		c.mode = networks[0].Mode
+	}
+}
+
+func (c *NetworkChecker) Feasible(option *structs.Node) bool {
+	// Every node supports the network of the host
+	if c.mode == structs.NetworkModeHost {
+		return true
+	}
+
+	// Clients fingerprint the other network modes they support
+	if _, ok := option.Attributes[structs.NodeNetworkModeAttrPrefix+c.mode]; ok {
+		return true
+	}
+
+	c.ctx.Metrics().FilterNode(option, fmt.Sprintf("missing network mode %q", c.mode))
+	return false
+}
+
 // DistinctHostsIterator is a FeasibleIterator which returns nodes that pass the
 // distinct_hosts constraint. The constraint ensures that multiple allocations
 // do not exist on the same node.
@@ -127,6 +127,127 @@ func TestDriverChecker(t *testing.T) {
 	}
 }
 
+func TestHostVolumeChecker(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	nodes[1].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"shared-data": {Name: "shared-data", Path: "/srv/data"},
+	}
+	nodes[2].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"shared-data": {Name: "shared-data", Path: "/srv/data", ReadOnly: true},
+	}
+
+	writable := map[string]*structs.VolumeRequest{
+		"data": {Name: "data", Type: structs.VolumeTypeHost, Source: "shared-data"},
+	}
+	readOnly := map[string]*structs.VolumeRequest{
+		"data": {Name: "data", Type: structs.VolumeTypeHost, Source: "shared-data", ReadOnly: true},
+	}
+
+	checker := NewHostVolumeChecker(ctx)
+	cases := []struct {
+		Node    *structs.Node
+		Volumes map[string]*structs.VolumeRequest
+		Result  bool
+	}{
+		{
+			Node:    nodes[0],
+			Volumes: nil,
+			Result:  true,
+		},
+		{
+			Node:    nodes[0],
+			Volumes: writable,
+			Result:  false,
+		},
+		{
+			Node:    nodes[1],
+			Volumes: writable,
+			Result:  true,
+		},
+		{
+			Node:    nodes[2],
+			Volumes: writable,
+			Result:  false,
+		},
+		{
+			Node:    nodes[2],
+			Volumes: readOnly,
+			Result:  true,
+		},
+	}
+
+	for i, c := range cases {
+		checker.SetVolumes(c.Volumes)
+		if act := checker.Feasible(c.Node); act != c.Result {
+			t.Fatalf("case(%d) failed: got %v; want %v", i, act, c.Result)
+		}
+	}
+}
+
+func TestNetworkChecker(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+	}
+	nodes[1].Attributes[structs.NodeNetworkModeAttrPrefix+structs.NetworkModeBridge] = "true"
+	nodes[1].Attributes[structs.NodeNetworkModeAttrPrefix+"cni/mynet"] = "1"
+
+	bridge := []*structs.NetworkResource{{Mode: structs.NetworkModeBridge}}
+	cni := []*structs.NetworkResource{{Mode: "cni/mynet"}}
+	host := []*structs.NetworkResource{{Mode: structs.NetworkModeHost}}
+
+	checker := NewNetworkChecker(ctx)
+	cases := []struct {
+		Node     *structs.Node
+		Networks []*structs.NetworkResource
+		Result   bool
+	}{
+		{
+			Node:     nodes[0],
+			Networks: nil,
+			Result:   true,
+		},
+		{
+			Node:     nodes[0],
+			Networks: host,
+			Result:   true,
+		},
+		{
+			Node:     nodes[0],
+			Networks: bridge,
+			Result:   false,
+		},
+		{
+			Node:     nodes[1],
+			Networks: bridge,
+			Result:   true,
+		},
+		{
+			Node:     nodes[0],
+			Networks: cni,
+			Result:   false,
+		},
+		{
+			Node:     nodes[1],
+			Networks: cni,
+			Result:   true,
+		},
+	}
+
+	for i, c := range cases {
+		checker.SetNetworks(c.Networks)
+		if act := checker.Feasible(c.Node); act != c.Result {
+			t.Fatalf("case(%d) failed: got %v; want %v", i, act, c.Result)
+		}
+	}
+}
+
 func Test_HealthChecks(t *testing.T) {
 	require := require.New(t)
 	_, ctx := testContext(t)
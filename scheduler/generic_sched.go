@@ -12,14 +12,6 @@ import (
 )
 
 const (
-	// maxServiceScheduleAttempts is used to limit the number of times
-	// we will attempt to schedule if we continue to hit conflicts for services.
-	maxServiceScheduleAttempts = 5
-
-	// maxBatchScheduleAttempts is used to limit the number of times
-	// we will attempt to schedule if we continue to hit conflicts for batch.
-	maxBatchScheduleAttempts = 2
-
 	// allocNotNeeded is the status used when a job no longer requires an allocation
 	allocNotNeeded = "alloc not needed due to job update"
 
@@ -77,6 +69,10 @@ type GenericScheduler struct {
 	planner Planner
 	batch   bool
 
+	// config is the scheduler configuration read at the start of the
+	// evaluation
+	config *structs.SchedulerConfiguration
+
 	eval       *structs.Evaluation
 	job        *structs.Job
 	plan       *structs.Plan
@@ -135,19 +131,24 @@ func (s *GenericScheduler) Process(eval *structs.Evaluation) error {
 			s.deployment.GetID())
 	}
 
-	// Retry up to the maxScheduleAttempts and reset if progress is made.
+	// Retry up to the maximum schedule attempts, which are used to limit the
+	// number of times we will attempt to schedule if we continue to hit
+	// conflicts, and reset if progress is made.
+	s.config = schedulerConfig(s.state, s.logger)
 	progress := func() bool { return progressMade(s.planResult) }
-	limit := maxServiceScheduleAttempts
+	limit := *s.config.MaxServiceScheduleAttempts
 	if s.batch {
-		limit = maxBatchScheduleAttempts
+		limit = *s.config.MaxBatchScheduleAttempts
 	}
 	if err := retryMax(limit, s.process, progress); err != nil {
 		if statusErr, ok := err.(*SetStatusError); ok {
 			// Scheduling was tried but made no forward progress so create a
 			// blocked eval to retry once resources become available.
 			var mErr multierror.Error
-			if err := s.createBlockedEval(true); err != nil {
-				mErr.Errors = append(mErr.Errors, err)
+			if !s.config.DisableBlockedEvals {
+				if err := s.createBlockedEval(true); err != nil {
+					mErr.Errors = append(mErr.Errors, err)
+				}
 			}
 			if err := setStatus(s.logger, s.planner, s.eval, nil, s.blocked,
 				s.failedTGAllocs, statusErr.EvalStatus, err.Error(),
@@ -160,8 +161,9 @@ func (s *GenericScheduler) Process(eval *structs.Evaluation) error {
 	}
 
 	// If the current evaluation is a blocked evaluation and we didn't place
-	// everything, do not update the status to complete.
-	if s.eval.Status == structs.EvalStatusBlocked && len(s.failedTGAllocs) != 0 {
+	// everything, do not update the status to complete unless blocked evals
+	// have been disabled since it was created.
+	if s.eval.Status == structs.EvalStatusBlocked && len(s.failedTGAllocs) != 0 && !s.config.DisableBlockedEvals {
 		e := s.ctx.Eligibility()
 		newEval := s.eval.Copy()
 		newEval.EscapedComputedClass = e.HasEscaped()
@@ -249,8 +251,10 @@ func (s *GenericScheduler) process() (bool, error) {
 
 	// If there are failed allocations, we need to create a blocked evaluation
 	// to place the failed allocations when resources become available. If the
-	// current evaluation is already a blocked eval, we reuse it.
-	if s.eval.Status != structs.EvalStatusBlocked && len(s.failedTGAllocs) != 0 && s.blocked == nil {
+	// current evaluation is already a blocked eval, we reuse it. Operators may
+	// disable blocked evals, leaving the placements to the next evaluation.
+	if s.eval.Status != structs.EvalStatusBlocked && len(s.failedTGAllocs) != 0 && s.blocked == nil &&
+		!s.config.DisableBlockedEvals {
 		if err := s.createBlockedEval(false); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make blocked eval: %v", s.eval, err)
 			return false, err
@@ -489,6 +493,11 @@ func (s *GenericScheduler) computePlacements(destructive, place []placementResul
 					},
 				}
 
+				// Add the group network shared by the tasks, if any
+				if option.AllocResources != nil {
+					alloc.SharedResources.Networks = option.AllocResources.Networks
+				}
+
 				// If the new allocation is replacing an older allocation then we
 				// set the record the older allocation id so that they are chained
 				if prevAllocation != nil {
@@ -506,6 +515,9 @@ func (s *GenericScheduler) computePlacements(destructive, place []placementResul
 					}
 				}
 
+				// Preempt any allocations needed to make room for the placement
+				appendPreemptions(s.plan, option, alloc)
+
 				// Track the placement
 				s.plan.AppendAlloc(alloc)
 
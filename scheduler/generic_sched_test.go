@@ -345,6 +345,128 @@ func TestServiceSched_JobRegister_DistinctHosts(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_JobRegister_Affinity(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes, only a few of which have SSDs
+	var ssdNodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		if i%5 == 0 {
+			node.Meta["disk"] = "ssd"
+			ssdNodes = append(ssdNodes, node)
+		}
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a job that prefers nodes with SSDs
+	job := mock.Job()
+	job.TaskGroups[0].Count = 2
+	job.TaskGroups[0].Affinities = []*structs.Affinity{
+		{
+			LTarget: "${meta.disk}",
+			RTarget: "ssd",
+			Operand: "=",
+			Weight:  100,
+		},
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          uuid.Generate(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+		Status:      structs.EvalStatusPending,
+	}
+	noErr(t, h.State.UpsertEvals(h.NextIndex(), []*structs.Evaluation{eval}))
+
+	// Process the evaluation
+	noErr(t, h.Process(NewServiceScheduler, eval))
+
+	// Ensure a single plan
+	require := require.New(t)
+	require.Len(h.Plans, 1)
+	plan := h.Plans[0]
+
+	// Ensure the allocations were placed on the nodes with SSDs
+	require.Len(plan.NodeAllocation, 2)
+	for _, node := range ssdNodes {
+		require.Len(plan.NodeAllocation[node.ID], 1)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobRegister_Spread(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create enough nodes in each datacenter that no node needs more than
+	// one allocation
+	for i := 0; i < 20; i++ {
+		node := mock.Node()
+		if i%2 == 0 {
+			node.Datacenter = "dc2"
+		}
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a job that spreads its allocations across datacenters
+	job := mock.Job()
+	job.Datacenters = []string{"dc1", "dc2"}
+	job.TaskGroups[0].Count = 10
+	job.TaskGroups[0].Spreads = []*structs.Spread{
+		{
+			Attribute: "${node.datacenter}",
+			Weight:    100,
+			SpreadTarget: []*structs.SpreadTarget{
+				{
+					Value:   "dc1",
+					Percent: 70,
+				},
+				{
+					Value:   "dc2",
+					Percent: 30,
+				},
+			},
+		},
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          uuid.Generate(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+		Status:      structs.EvalStatusPending,
+	}
+	noErr(t, h.State.UpsertEvals(h.NextIndex(), []*structs.Evaluation{eval}))
+
+	// Process the evaluation
+	noErr(t, h.Process(NewServiceScheduler, eval))
+
+	// Ensure a single plan
+	require := require.New(t)
+	require.Len(h.Plans, 1)
+	plan := h.Plans[0]
+
+	// Ensure the allocations are spread according to the targets
+	ws := memdb.NewWatchSet()
+	dcAllocs := make(map[string]int)
+	for nodeID, allocs := range plan.NodeAllocation {
+		node, err := h.State.NodeByID(ws, nodeID)
+		noErr(t, err)
+		dcAllocs[node.Datacenter] += len(allocs)
+	}
+	require.Equal(map[string]int{"dc1": 7, "dc2": 3}, dcAllocs)
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestServiceSched_JobRegister_DistinctProperty(t *testing.T) {
 	h := NewHarness(t)
 
@@ -804,6 +926,133 @@ func TestServiceSched_JobRegister_AllocFail(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_JobRegister_AllocFail_DisableBlockedEvals(t *testing.T) {
+	h := NewHarness(t)
+
+	// Disable blocked evals
+	noErr(t, h.State.SchedulerSetConfig(h.NextIndex(), &structs.SchedulerConfiguration{
+		DisableBlockedEvals: true,
+	}))
+
+	// Create NO nodes
+	// Create a job
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:   structs.DefaultNamespace,
+		ID:          uuid.Generate(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+		Status:      structs.EvalStatusPending,
+	}
+
+	noErr(t, h.State.UpsertEvals(h.NextIndex(), []*structs.Evaluation{eval}))
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure there is no follow up eval.
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+
+	if len(h.Evals) != 1 {
+		t.Fatalf("incorrect number of updated eval: %#v", h.Evals)
+	}
+	outEval := h.Evals[0]
+
+	// Ensure the eval didn't spawn a blocked eval but recorded the failures
+	if outEval.BlockedEval != "" {
+		t.Fatalf("bad: %#v", outEval)
+	}
+	if len(outEval.FailedTGAllocs) != 1 {
+		t.Fatalf("bad: %#v", outEval)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobRegister_Preemption(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a low priority batch job which uses most of the node's CPU
+	lowJob := mock.Job()
+	lowJob.Type = structs.JobTypeBatch
+	lowJob.Priority = 20
+	noErr(t, h.State.UpsertJob(h.NextIndex(), lowJob))
+
+	lowAlloc := mock.Alloc()
+	lowAlloc.Job = lowJob
+	lowAlloc.JobID = lowJob.ID
+	lowAlloc.NodeID = node.ID
+	lowAlloc.Resources.CPU = 3500
+	lowAlloc.TaskResources["web"].CPU = 3500
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{lowAlloc}))
+
+	// Create a high priority service job that only fits if the batch job's
+	// allocation is preempted
+	job := mock.Job()
+	job.Priority = 90
+	job.TaskGroups[0].Count = 1
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		Namespace:    structs.DefaultNamespace,
+		ID:           uuid.Generate(),
+		Priority:     job.Priority,
+		TriggeredBy:  structs.EvalTriggerJobRegister,
+		JobID:        job.ID,
+		AnnotatePlan: true,
+		Status:       structs.EvalStatusPending,
+	}
+	noErr(t, h.State.UpsertEvals(h.NextIndex(), []*structs.Evaluation{eval}))
+
+	// Process the evaluation
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	require := require.New(t)
+	require.Len(h.Plans, 1)
+	plan := h.Plans[0]
+
+	// Ensure the plan places the allocation and preempts the batch allocation
+	require.Len(plan.NodeAllocation[node.ID], 1)
+	placed := plan.NodeAllocation[node.ID][0]
+	require.Len(plan.NodePreemptions[node.ID], 1)
+	preempted := plan.NodePreemptions[node.ID][0]
+	require.Equal(lowAlloc.ID, preempted.ID)
+	require.Equal(structs.AllocDesiredStatusEvict, preempted.DesiredStatus)
+	require.Equal(placed.ID, preempted.PreemptedByAllocation)
+	require.Equal([]string{lowAlloc.ID}, placed.PreemptedAllocations)
+
+	// Ensure the plan is annotated with the preemption
+	require.NotNil(plan.Annotations)
+	require.Len(plan.Annotations.PreemptedAllocs, 1)
+	require.Equal(lowAlloc.ID, plan.Annotations.PreemptedAllocs[0].ID)
+	require.EqualValues(1, plan.Annotations.DesiredTGUpdates["web"].Preemptions)
+
+	// Ensure the preempted allocation was evicted
+	ws := memdb.NewWatchSet()
+	out, err := h.State.AllocByID(ws, lowAlloc.ID)
+	noErr(t, err)
+	require.Equal(structs.AllocDesiredStatusEvict, out.DesiredStatus)
+	require.Equal(placed.ID, out.PreemptedByAllocation)
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestServiceSched_JobRegister_CreateBlockedEval(t *testing.T) {
 	h := NewHarness(t)
 
@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// PreemptionPriorityDelta is the minimum difference between the priority
+	// of the job being placed and the priority of the job of an allocation for
+	// the allocation to be preempted. This avoids jobs of similar priority
+	// preempting each other back and forth. The plan applier enforces it too.
+	PreemptionPriorityDelta = 10
+)
+
+// preemptForTaskGroup attempts to make room for the task group on the node by
+// preempting allocations of lower priority jobs. It returns the allocations to
+// preempt and the resulting utilization of the node, or nil if the task group
+// can not be made to fit.
+func (iter *BinPackIterator) preemptForTaskGroup(option *RankedNode, proposed []*structs.Allocation) ([]*structs.Allocation, *structs.Resources) {
+	remaining, candidates := iter.preemptionCandidates(option.Node.ID, proposed)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Preempt the candidates in order until the task group fits
+	sortPreemptionCandidates(candidates, iter.taskGroupAsk())
+	var preempted []*structs.Allocation
+	fit := false
+	for i, alloc := range candidates {
+		preempted = append(preempted, alloc)
+		allocs := append(remaining[:len(remaining):len(remaining)], candidates[i+1:]...)
+		if fit, _, _ = iter.fitTaskGroup(option, allocs); fit {
+			remaining = allocs
+			break
+		}
+	}
+	if !fit {
+		return nil, nil
+	}
+
+	// Earlier candidates may no longer need to be preempted now that later
+	// ones are, so try to keep as many of them running as possible, starting
+	// with the highest priority ones.
+	var needed []*structs.Allocation
+	for i := len(preempted) - 1; i >= 0; i-- {
+		allocs := append(remaining[:len(remaining):len(remaining)], preempted[i])
+		if fit, _, _ := iter.fitTaskGroup(option, allocs); fit {
+			remaining = allocs
+			continue
+		}
+		needed = append(needed, preempted[i])
+	}
+
+	// Assign the resources of the task group against the final set of
+	// allocations
+	_, _, util := iter.fitTaskGroup(option, remaining)
+	return needed, util
+}
+
+// preemptionCandidates splits the proposed allocations on a node into those
+// that must keep running and those that may be preempted. Allocations may be
+// preempted if they belong to a job of a sufficiently lower priority and are
+// not being placed by the current plan.
+func (iter *BinPackIterator) preemptionCandidates(nodeID string, proposed []*structs.Allocation) (keep, candidates []*structs.Allocation) {
+	planned := make(map[string]struct{})
+	for _, alloc := range iter.ctx.Plan().NodeAllocation[nodeID] {
+		planned[alloc.ID] = struct{}{}
+	}
+
+	for _, alloc := range proposed {
+		if _, ok := planned[alloc.ID]; ok || !iter.canPreempt(alloc) {
+			keep = append(keep, alloc)
+			continue
+		}
+		candidates = append(candidates, alloc)
+	}
+	return keep, candidates
+}
+
+// canPreempt returns whether the allocation may be preempted by the job being
+// placed.
+func (iter *BinPackIterator) canPreempt(alloc *structs.Allocation) bool {
+	if alloc.Job == nil {
+		return false
+	}
+	if alloc.Namespace == iter.jobID.Namespace && alloc.JobID == iter.jobID.ID {
+		return false
+	}
+	return alloc.Job.Priority+PreemptionPriorityDelta <= iter.priority
+}
+
+// taskGroupAsk returns the total resources requested by the task group.
+func (iter *BinPackIterator) taskGroupAsk() *structs.Resources {
+	ask := &structs.Resources{
+		DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
+	}
+	for _, task := range iter.taskGroup.Tasks {
+		ask.Add(task.Resources)
+	}
+	return ask
+}
+
+// sortPreemptionCandidates sorts the allocations in the order they should be
+// preempted. Allocations of the lowest priority jobs come first and amongst
+// allocations of jobs with the same priority, those whose resources most
+// closely match the ask are preferred.
+func sortPreemptionCandidates(allocs []*structs.Allocation, ask *structs.Resources) {
+	distances := make(map[string]float64, len(allocs))
+	for _, alloc := range allocs {
+		distances[alloc.ID] = resourceDistance(allocResources(alloc), ask)
+	}
+
+	sort.SliceStable(allocs, func(i, j int) bool {
+		pi, pj := allocs[i].Job.Priority, allocs[j].Job.Priority
+		if pi != pj {
+			return pi < pj
+		}
+		return distances[allocs[i].ID] < distances[allocs[j].ID]
+	})
+}
+
+// allocResources returns the total resources used by the allocation.
+func allocResources(alloc *structs.Allocation) *structs.Resources {
+	if alloc.Resources != nil {
+		return alloc.Resources
+	}
+
+	total := alloc.SharedResources.Copy()
+	if total == nil {
+		total = new(structs.Resources)
+	}
+	for _, r := range alloc.TaskResources {
+		total.Add(r)
+	}
+	return total
+}
+
+// resourceDistance returns how far apart the used resources are from the
+// asked resources, as the euclidean distance of the difference in each
+// dimension relative to the ask.
+func resourceDistance(used, ask *structs.Resources) float64 {
+	coord := func(used, ask int) float64 {
+		if ask == 0 {
+			return 0
+		}
+		return float64(ask-used) / float64(ask)
+	}
+
+	cpu := coord(used.CPU, ask.CPU)
+	memory := coord(used.MemoryMB, ask.MemoryMB)
+	disk := coord(used.DiskMB, ask.DiskMB)
+	return math.Sqrt(cpu*cpu + memory*memory + disk*disk)
+}
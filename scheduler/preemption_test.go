@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+// preemptionTestAlloc returns a running allocation for a new job of the given
+// priority using the given resources.
+func preemptionTestAlloc(priority, cpu, memory int) *structs.Allocation {
+	job := mock.Job()
+	job.Priority = priority
+	return &structs.Allocation{
+		Namespace: structs.DefaultNamespace,
+		ID:        uuid.Generate(),
+		EvalID:    uuid.Generate(),
+		JobID:     job.ID,
+		Job:       job,
+		Resources: &structs.Resources{
+			CPU:      cpu,
+			MemoryMB: memory,
+		},
+		DesiredStatus: structs.AllocDesiredStatusRun,
+		ClientStatus:  structs.AllocClientStatusRunning,
+		TaskGroup:     "web",
+	}
+}
+
+func preemptionTestSetup(t *testing.T, allocs ...*structs.Allocation) (*EvalContext, *RankedNode) {
+	state, ctx := testContext(t)
+	node := &RankedNode{
+		Node: &structs.Node{
+			ID: uuid.Generate(),
+			Resources: &structs.Resources{
+				CPU:      2048,
+				MemoryMB: 2048,
+			},
+		},
+	}
+
+	for i, alloc := range allocs {
+		alloc.NodeID = node.Node.ID
+		noErr(t, state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID)))
+	}
+	noErr(t, state.UpsertAllocs(1000, allocs))
+	return ctx, node
+}
+
+func preemptionTestTaskGroup(cpu, memory int) *structs.TaskGroup {
+	return &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      cpu,
+					MemoryMB: memory,
+				},
+			},
+		},
+	}
+}
+
+func TestBinPackIterator_Preemption(t *testing.T) {
+	require := require.New(t)
+
+	// Fill the node with allocations of jobs with varying priorities
+	lowLarge := preemptionTestAlloc(20, 1024, 1024)
+	lowSmall := preemptionTestAlloc(20, 512, 512)
+	high := preemptionTestAlloc(70, 512, 512)
+	ctx, node := preemptionTestSetup(t, lowLarge, lowSmall, high)
+
+	job := mock.Job()
+	job.Priority = 90
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, []*RankedNode{node}), true, 0)
+	binp.SetJob(job)
+	binp.SetTaskGroup(preemptionTestTaskGroup(1024, 1024))
+
+	// Without preemption enabled the node is exhausted
+	out := collectRanked(binp)
+	require.Empty(out)
+	require.Equal(1, ctx.Metrics().NodesExhausted)
+
+	// With preemption only the low priority allocation that matches the ask
+	// is preempted
+	binp.Reset()
+	binp.SetPreemption(true)
+	out = collectRanked(binp)
+	require.Len(out, 1)
+	require.Len(out[0].PreemptedAllocs, 1)
+	require.Equal(lowLarge.ID, out[0].PreemptedAllocs[0].ID)
+	require.Equal(18.0, out[0].Score)
+}
+
+func TestBinPackIterator_Preemption_MultipleAllocs(t *testing.T) {
+	require := require.New(t)
+
+	// Only the combination of the two smaller low priority allocations frees
+	// up enough resources
+	low1 := preemptionTestAlloc(20, 512, 512)
+	low2 := preemptionTestAlloc(30, 512, 512)
+	high := preemptionTestAlloc(80, 1024, 1024)
+	ctx, node := preemptionTestSetup(t, low1, low2, high)
+
+	job := mock.Job()
+	job.Priority = 90
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, []*RankedNode{node}), true, 0)
+	binp.SetJob(job)
+	binp.SetTaskGroup(preemptionTestTaskGroup(1024, 1024))
+	binp.SetPreemption(true)
+
+	out := collectRanked(binp)
+	require.Len(out, 1)
+	require.Len(out[0].PreemptedAllocs, 2)
+
+	ids := make(map[string]struct{})
+	for _, alloc := range out[0].PreemptedAllocs {
+		ids[alloc.ID] = struct{}{}
+	}
+	require.Contains(ids, low1.ID)
+	require.Contains(ids, low2.ID)
+}
+
+func TestBinPackIterator_Preemption_PriorityDelta(t *testing.T) {
+	require := require.New(t)
+
+	// The running allocation's job priority is too close to the job being
+	// placed to be preempted
+	alloc := preemptionTestAlloc(45, 2048, 2048)
+	ctx, node := preemptionTestSetup(t, alloc)
+
+	job := mock.Job()
+	job.Priority = 50
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, []*RankedNode{node}), true, 0)
+	binp.SetJob(job)
+	binp.SetTaskGroup(preemptionTestTaskGroup(1024, 1024))
+	binp.SetPreemption(true)
+
+	out := collectRanked(binp)
+	require.Empty(out)
+}
+
+func TestBinPackIterator_Preemption_EvictDisabled(t *testing.T) {
+	require := require.New(t)
+
+	alloc := preemptionTestAlloc(20, 2048, 2048)
+	ctx, node := preemptionTestSetup(t, alloc)
+
+	job := mock.Job()
+	job.Priority = 90
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, []*RankedNode{node}), false, 0)
+	binp.SetJob(job)
+	binp.SetTaskGroup(preemptionTestTaskGroup(1024, 1024))
+	binp.SetPreemption(true)
+
+	out := collectRanked(binp)
+	require.Empty(out)
+}
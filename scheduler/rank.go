@@ -2,10 +2,17 @@ package scheduler
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+const (
+	// implicitSpreadTarget is used to represent any remaining attribute
+	// values when the spread target percentages don't add up to 100
+	implicitSpreadTarget = "*"
+)
+
 // Rank is used to provide a score and various ranking metadata
 // along with a node when iterating. This state can be modified as
 // various rank methods are applied.
@@ -14,9 +21,17 @@ type RankedNode struct {
 	Score         float64
 	TaskResources map[string]*structs.Resources
 
+	// AllocResources are the resources shared by the tasks of the task
+	// group, such as the offer for the group network.
+	AllocResources *structs.Resources
+
 	// Allocs is used to cache the proposed allocations on the
 	// node. This can be shared between iterators that require it.
 	Proposed []*structs.Allocation
+
+	// PreemptedAllocs is used by the BinPackIterator to identify allocs
+	// that should be preempted to make room for the placement.
+	PreemptedAllocs []*structs.Allocation
 }
 
 func (r *RankedNode) GoString() string {
@@ -134,8 +149,18 @@ type BinPackIterator struct {
 	ctx       Context
 	source    RankIterator
 	evict     bool
+	preempt   bool
 	priority  int
+	jobID     structs.NamespacedID
 	taskGroup *structs.TaskGroup
+
+	// scoreFit scores the fit of the task group on a node given the
+	// resulting utilization of the node
+	scoreFit func(*structs.Node, *structs.Resources) float64
+
+	// memoryOversubscription is whether tasks may be limited to a larger
+	// amount of memory than they reserve
+	memoryOversubscription bool
 }
 
 // NewBinPackIterator returns a BinPackIterator which tries to fit tasks
@@ -146,20 +171,50 @@ func NewBinPackIterator(ctx Context, source RankIterator, evict bool, priority i
 		source:   source,
 		evict:    evict,
 		priority: priority,
+		scoreFit: structs.ScoreFit,
 	}
 	return iter
 }
 
+// SetSchedulerConfiguration sets the scheduler configuration, which selects
+// the algorithm used to score the fit of task groups on nodes and whether
+// memory may be oversubscribed.
+func (iter *BinPackIterator) SetSchedulerConfiguration(config *structs.SchedulerConfiguration) {
+	switch config.EffectiveSchedulerAlgorithm() {
+	case structs.SchedulerAlgorithmSpread:
+		iter.scoreFit = structs.ScoreFitSpread
+	default:
+		iter.scoreFit = structs.ScoreFit
+	}
+	iter.memoryOversubscription = config.MemoryOversubscriptionEnabled
+}
+
 func (iter *BinPackIterator) SetPriority(p int) {
 	iter.priority = p
 }
 
+// SetJob sets the job being placed. Allocations of the job itself are never
+// preempted.
+func (iter *BinPackIterator) SetJob(job *structs.Job) {
+	iter.priority = job.Priority
+	iter.jobID = structs.NamespacedID{
+		ID:        job.ID,
+		Namespace: job.Namespace,
+	}
+}
+
+// SetPreemption sets whether allocations of lower priority jobs may be
+// preempted when the task group does not otherwise fit on a node. Preemption
+// is only possible if the iterator was created with eviction enabled.
+func (iter *BinPackIterator) SetPreemption(preempt bool) {
+	iter.preempt = preempt && iter.evict
+}
+
 func (iter *BinPackIterator) SetTaskGroup(taskGroup *structs.TaskGroup) {
 	iter.taskGroup = taskGroup
 }
 
 func (iter *BinPackIterator) Next() *RankedNode {
-OUTER:
 	for {
 		// Get the next potential option
 		option := iter.source.Next()
@@ -176,67 +231,143 @@ OUTER:
 			continue
 		}
 
-		// Index the existing network usage
-		netIdx := structs.NewNetworkIndex()
-		netIdx.SetNode(option.Node)
-		netIdx.AddAllocs(proposed)
-
-		// Assign the resources for each task
-		total := &structs.Resources{
-			DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
-		}
-		for _, task := range iter.taskGroup.Tasks {
-			taskResources := task.Resources.Copy()
-
-			// Check if we need a network resource
-			if len(taskResources.Networks) > 0 {
-				ask := taskResources.Networks[0]
-				offer, err := netIdx.AssignNetwork(ask)
-				if offer == nil {
-					iter.ctx.Metrics().ExhaustedNode(option.Node,
-						fmt.Sprintf("network: %s", err))
-					netIdx.Release()
-					continue OUTER
-				}
-
-				// Reserve this to prevent another task from colliding
-				netIdx.AddReserved(offer)
-
-				// Update the network ask to the offer
-				taskResources.Networks = []*structs.NetworkResource{offer}
+		// Check if these allocations fit, if they do not try to preempt
+		// allocations of lower priority jobs and otherwise skip this node
+		fit, dim, util := iter.fitTaskGroup(option, proposed)
+		if !fit && iter.preempt {
+			var preempted []*structs.Allocation
+			preempted, util = iter.preemptForTaskGroup(option, proposed)
+			if preempted != nil {
+				fit = true
+				option.PreemptedAllocs = preempted
 			}
-
-			// Store the task resource
-			option.SetTaskResources(task, taskResources)
-
-			// Accumulate the total resource requirement
-			total.Add(taskResources)
 		}
-
-		// Add the resources we are trying to fit
-		proposed = append(proposed, &structs.Allocation{Resources: total})
-
-		// Check if these allocations fit, if they do not, simply skip this node
-		fit, dim, util, _ := structs.AllocsFit(option.Node, proposed, netIdx)
-		netIdx.Release()
 		if !fit {
 			iter.ctx.Metrics().ExhaustedNode(option.Node, dim)
 			continue
 		}
 
-		// XXX: For now we completely ignore evictions. We should use that flag
-		// to determine if its possible to evict other lower priority allocations
-		// to make room. This explodes the search space, so it must be done
-		// carefully.
-
 		// Score the fit normally otherwise
-		fitness := structs.ScoreFit(option.Node, util)
+		fitness := iter.scoreFit(option.Node, util)
 		option.Score += fitness
 		iter.ctx.Metrics().ScoreNode(option.Node, "binpack", fitness)
 		return option
 	}
 }
 
+// fitTaskGroup assigns the resources of each task in the task group on the
+// node, given the allocations proposed for the node. It returns whether the
+// task group fits, the exhausted dimension if it does not and the resulting
+// utilization of the node.
+func (iter *BinPackIterator) fitTaskGroup(option *RankedNode, proposed []*structs.Allocation) (bool, string, *structs.Resources) {
+	// Index the existing network usage
+	netIdx := structs.NewNetworkIndex()
+	defer netIdx.Release()
+	netIdx.SetNode(option.Node)
+	netIdx.AddAllocs(proposed)
+
+	// Index the existing device usage
+	devAcct := structs.NewDeviceAccounter(option.Node)
+	devAcct.AddAllocs(proposed)
+
+	// Index the existing dedicated cores
+	coreAcct := structs.NewCoreAccounter(option.Node)
+	coreAcct.AddAllocs(proposed)
+
+	// Assign the resources for each task
+	total := &structs.Resources{
+		DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
+	}
+
+	// Assign the group network shared by the tasks
+	option.AllocResources = nil
+	if len(iter.taskGroup.Networks) > 0 {
+		ask := iter.taskGroup.Networks[0].Copy()
+		offer, err := netIdx.AssignNetwork(ask)
+		if offer == nil {
+			return false, fmt.Sprintf("network: %s", err), nil
+		}
+
+		// Reserve this to prevent the tasks from colliding
+		netIdx.AddReserved(offer)
+
+		option.AllocResources = &structs.Resources{
+			Networks: []*structs.NetworkResource{offer},
+		}
+		total.Add(option.AllocResources)
+	}
+
+	for _, task := range iter.taskGroup.Tasks {
+		taskResources := task.Resources.Copy()
+
+		// The memory limit is only raised above the reservation when
+		// oversubscription is enabled
+		if !iter.memoryOversubscription {
+			taskResources.MemoryMaxMB = 0
+		}
+
+		// Check if we need a network resource
+		if len(taskResources.Networks) > 0 {
+			ask := taskResources.Networks[0]
+			offer, err := netIdx.AssignNetwork(ask)
+			if offer == nil {
+				return false, fmt.Sprintf("network: %s", err), nil
+			}
+
+			// Reserve this to prevent another task from colliding
+			netIdx.AddReserved(offer)
+
+			// Update the network ask to the offer
+			taskResources.Networks = []*structs.NetworkResource{offer}
+		}
+
+		// Assign the instances of the requested devices
+		for i, ask := range taskResources.Devices {
+			offer, err := devAcct.AssignDevice(ask)
+			if offer == nil {
+				return false, fmt.Sprintf("devices: %s", err), nil
+			}
+
+			// Reserve this to prevent another task from using the instances
+			devAcct.AddReserved(offer)
+
+			// Update the device ask to the offer
+			taskResources.Devices[i] = offer
+		}
+
+		// Dedicate the requested cores to the task
+		if taskResources.Cores > 0 {
+			cores, err := coreAcct.AssignCores(taskResources.Cores)
+			if err != nil {
+				return false, fmt.Sprintf("cores: %s", err), nil
+			}
+
+			// Reserve this to prevent another task from using the cores
+			coreAcct.AddReserved(cores)
+			taskResources.CoreIDs = cores
+
+			// The task is entitled to the full compute of its cores
+			taskResources.CPU = taskResources.Cores * coreCompute(option.Node)
+		}
+
+		// Store the task resource
+		option.SetTaskResources(task, taskResources)
+
+		// Accumulate the total resource requirement
+		total.Add(taskResources)
+	}
+
+	// Add the resources we are trying to fit without modifying the proposed
+	// allocations, which may be shared between iterators
+	allocs := make([]*structs.Allocation, 0, len(proposed)+1)
+	allocs = append(allocs, proposed...)
+	allocs = append(allocs, &structs.Allocation{Resources: total})
+
+	// Check if these allocations fit
+	fit, dim, util, _ := structs.AllocsFit(option.Node, allocs, netIdx)
+	return fit, dim, util
+}
+
 func (iter *BinPackIterator) Reset() {
 	iter.source.Reset()
 }
@@ -305,6 +436,235 @@ func (iter *JobAntiAffinityIterator) Reset() {
 	iter.source.Reset()
 }
 
+// SpreadIterator is used to spread allocations across the values of a node
+// attribute according to the spread stanzas of the job and task group. Nodes
+// whose attribute value is further below its desired share of allocations
+// are scored higher.
+type SpreadIterator struct {
+	ctx    Context
+	source RankIterator
+	job    *structs.Job
+	tg     *structs.TaskGroup
+
+	// maxBoost is the score applied to a node whose attribute value has none
+	// of its desired allocations
+	maxBoost float64
+
+	// tgSpreadInfo is a memoized map from task group to the desired counts
+	// and weights of the spreads that apply to the task group
+	tgSpreadInfo map[string]*spreadGroupInfo
+
+	// groupPropertySets is a memoized map from task group to property sets.
+	// Existing allocs are computed once, and allocs from the plan are updated
+	// when Reset is called.
+	groupPropertySets map[string][]*propertySet
+}
+
+// spreadGroupInfo holds the precomputed desired counts and weights of all the
+// spreads that apply to a task group
+type spreadGroupInfo struct {
+	// spreads is keyed by the spread attribute
+	spreads map[string]*spreadInfo
+
+	// sumWeights is the total weight across the spreads
+	sumWeights int
+}
+
+// spreadInfo holds the desired count for each attribute value of a spread
+type spreadInfo struct {
+	weight        int
+	desiredCounts map[string]float64
+}
+
+// NewSpreadIterator is used to create a SpreadIterator that boosts the score
+// of nodes by at most the given amount.
+func NewSpreadIterator(ctx Context, source RankIterator, maxBoost float64) *SpreadIterator {
+	iter := &SpreadIterator{
+		ctx:               ctx,
+		source:            source,
+		maxBoost:          maxBoost,
+		tgSpreadInfo:      make(map[string]*spreadGroupInfo),
+		groupPropertySets: make(map[string][]*propertySet),
+	}
+	return iter
+}
+
+func (iter *SpreadIterator) SetJob(job *structs.Job) {
+	iter.job = job
+	iter.tgSpreadInfo = make(map[string]*spreadGroupInfo)
+	iter.groupPropertySets = make(map[string][]*propertySet)
+}
+
+func (iter *SpreadIterator) SetTaskGroup(tg *structs.TaskGroup) {
+	iter.tg = tg
+
+	// Build the property sets and spread info at the task group level
+	if _, ok := iter.tgSpreadInfo[tg.Name]; ok {
+		return
+	}
+
+	info := &spreadGroupInfo{spreads: make(map[string]*spreadInfo)}
+	var sets []*propertySet
+	for _, spread := range iter.spreads(tg) {
+		pset := NewPropertySet(iter.ctx, iter.job)
+		pset.SetTargetAttribute(spread.Attribute, tg.Name)
+		sets = append(sets, pset)
+
+		info.spreads[spread.Attribute] = computeSpreadInfo(spread, tg.Count)
+		info.sumWeights += spread.Weight
+	}
+
+	iter.tgSpreadInfo[tg.Name] = info
+	iter.groupPropertySets[tg.Name] = sets
+}
+
+// hasSpreads returns whether any spreads apply to the current task group.
+func (iter *SpreadIterator) hasSpreads() bool {
+	return len(iter.groupPropertySets[iter.tg.Name]) != 0
+}
+
+// spreads returns the job level spreads followed by the task group level
+// spreads.
+func (iter *SpreadIterator) spreads(tg *structs.TaskGroup) []*structs.Spread {
+	var spreads []*structs.Spread
+	if iter.job != nil {
+		spreads = append(spreads, iter.job.Spreads...)
+	}
+	return append(spreads, tg.Spreads...)
+}
+
+func (iter *SpreadIterator) Next() *RankedNode {
+	for {
+		option := iter.source.Next()
+
+		// Hot path if there is nothing to check
+		if option == nil || !iter.hasSpreads() {
+			return option
+		}
+		propertySets := iter.groupPropertySets[iter.tg.Name]
+
+		// Combine the boosts of every spread attribute weighted by its
+		// relative weight
+		info := iter.tgSpreadInfo[iter.tg.Name]
+		totalBoost := 0.0
+		for _, pset := range propertySets {
+			details := info.spreads[pset.targetAttribute]
+			weight := float64(details.weight) / float64(info.sumWeights)
+
+			nValue, errorMsg, usedCount := pset.UsedCount(option.Node, iter.tg.Name)
+			if errorMsg != "" {
+				// Apply the maximum penalty when the attribute can't be
+				// resolved for the node
+				totalBoost -= weight
+				continue
+			}
+
+			var boost float64
+			if len(details.desiredCounts) == 0 {
+				boost = evenSpreadBoost(pset, nValue)
+			} else {
+				// Include the placement on this node when comparing against
+				// the desired count
+				boost = targetSpreadBoost(details, nValue, usedCount+1)
+			}
+			totalBoost += boost * weight
+		}
+
+		if totalBoost != 0.0 {
+			score := totalBoost * iter.maxBoost
+			option.Score += score
+			iter.ctx.Metrics().ScoreNode(option.Node, "allocation-spread", score)
+		}
+		return option
+	}
+}
+
+func (iter *SpreadIterator) Reset() {
+	iter.source.Reset()
+	for _, sets := range iter.groupPropertySets {
+		for _, pset := range sets {
+			pset.PopulateProposed()
+		}
+	}
+}
+
+// computeSpreadInfo computes the desired number of allocations for each of
+// the spread's target values given the count of the task group. Any count not
+// covered by the targets is assigned to the implicit target.
+func computeSpreadInfo(spread *structs.Spread, count int) *spreadInfo {
+	info := &spreadInfo{
+		weight:        spread.Weight,
+		desiredCounts: make(map[string]float64, len(spread.SpreadTarget)),
+	}
+
+	sumDesiredCounts := 0.0
+	for _, target := range spread.SpreadTarget {
+		desiredCount := float64(target.Percent) / 100.0 * float64(count)
+		info.desiredCounts[target.Value] = desiredCount
+		sumDesiredCounts += desiredCount
+	}
+
+	// Account for the remaining count only if there are any targets
+	if len(spread.SpreadTarget) != 0 && sumDesiredCounts < float64(count) {
+		info.desiredCounts[implicitSpreadTarget] = float64(count) - sumDesiredCounts
+	}
+	return info
+}
+
+// targetSpreadBoost returns a boost in the range [-1, 1] proportional to how
+// far the used count of the value is from its desired count.
+func targetSpreadBoost(info *spreadInfo, value string, usedCount uint64) float64 {
+	desiredCount, ok := info.desiredCounts[value]
+	if !ok {
+		desiredCount, ok = info.desiredCounts[implicitSpreadTarget]
+	}
+	if !ok || desiredCount == 0 {
+		// No allocations are desired for this value
+		return -1.0
+	}
+
+	boost := (desiredCount - float64(usedCount)) / desiredCount
+	return math.Max(boost, -1.0)
+}
+
+// evenSpreadBoost returns a boost in the range [-1, 1] used when allocations
+// should be spread evenly across all the values of the attribute. Values that
+// are used less than the others are boosted and values that are used more are
+// penalized.
+func evenSpreadBoost(pset *propertySet, value string) float64 {
+	combinedUse := pset.GetCombinedUseMap()
+	if len(combinedUse) == 0 {
+		// Nothing placed yet
+		return 0.0
+	}
+
+	current, ok := combinedUse[value]
+	if !ok || current == 0 {
+		// The value hasn't been used yet
+		return 1.0
+	}
+
+	minCount, maxCount := current, current
+	for _, count := range combinedUse {
+		if count < minCount {
+			minCount = count
+		}
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	switch {
+	case minCount == maxCount:
+		// The allocations are already spread evenly
+		return 0.0
+	case current == minCount:
+		return float64(maxCount-current) / float64(maxCount)
+	default:
+		return -1.0 * float64(current-minCount) / float64(current)
+	}
+}
+
 // NodeAntiAffinityIterator is used to apply a penalty to
 // a node that had a previous failed allocation for the same job.
 // This is used when attempting to reschedule a failed alloc
@@ -350,3 +710,130 @@ func (iter *NodeAntiAffinityIterator) Reset() {
 	iter.penaltyNodes = make(map[string]struct{})
 	iter.source.Reset()
 }
+
+// NodeAffinityIterator is used to resolve any affinity rules in the job or
+// task group, and apply a weighted score to nodes if they match.
+type NodeAffinityIterator struct {
+	ctx           Context
+	source        RankIterator
+	maxBoost      float64
+	jobAffinities []*structs.Affinity
+	affinities    []*structs.Affinity
+}
+
+// NewNodeAffinityIterator is used to create a NodeAffinityIterator that
+// boosts or penalizes the score of nodes by at most the given amount.
+func NewNodeAffinityIterator(ctx Context, source RankIterator, maxBoost float64) *NodeAffinityIterator {
+	return &NodeAffinityIterator{
+		ctx:      ctx,
+		source:   source,
+		maxBoost: maxBoost,
+	}
+}
+
+func (iter *NodeAffinityIterator) SetJob(job *structs.Job) {
+	iter.jobAffinities = job.Affinities
+}
+
+func (iter *NodeAffinityIterator) SetTaskGroup(tg *structs.TaskGroup) {
+	// Merge job affinities
+	iter.affinities = nil
+	iter.affinities = append(iter.affinities, iter.jobAffinities...)
+
+	// Merge task group affinities and task affinities
+	iter.affinities = append(iter.affinities, tg.Affinities...)
+	for _, task := range tg.Tasks {
+		iter.affinities = append(iter.affinities, task.Affinities...)
+	}
+}
+
+// hasAffinities returns whether any affinities apply to the current task
+// group.
+func (iter *NodeAffinityIterator) hasAffinities() bool {
+	return len(iter.affinities) != 0
+}
+
+func (iter *NodeAffinityIterator) Next() *RankedNode {
+	option := iter.source.Next()
+	if option == nil || !iter.hasAffinities() {
+		return option
+	}
+
+	// Sum the weights of the matching affinities relative to the total
+	// weight of all the affinities
+	sumWeight := 0.0
+	totalAffinityScore := 0.0
+	for _, affinity := range iter.affinities {
+		sumWeight += math.Abs(float64(affinity.Weight))
+		if matchesAffinity(iter.ctx, affinity, option.Node) {
+			totalAffinityScore += float64(affinity.Weight)
+		}
+	}
+
+	if totalAffinityScore != 0.0 {
+		score := totalAffinityScore / sumWeight * iter.maxBoost
+		option.Score += score
+		iter.ctx.Metrics().ScoreNode(option.Node, "node-affinity", score)
+	}
+	return option
+}
+
+func (iter *NodeAffinityIterator) Reset() {
+	iter.source.Reset()
+}
+
+// matchesAffinity returns whether the node satisfies the affinity.
+func matchesAffinity(ctx Context, affinity *structs.Affinity, option *structs.Node) bool {
+	// Resolve the targets
+	lVal, ok := resolveConstraintTarget(affinity.LTarget, option)
+	if !ok {
+		return false
+	}
+	rVal, ok := resolveConstraintTarget(affinity.RTarget, option)
+	if !ok {
+		return false
+	}
+
+	// Check if satisfied
+	return checkConstraint(ctx, affinity.Operand, lVal, rVal)
+}
+
+// ScoreNormalizationIterator is a RankIterator that records the normalized
+// score breakdown of each option in the placement metrics. It is expected to
+// come after all the scoring iterators of a stack.
+type ScoreNormalizationIterator struct {
+	ctx      Context
+	source   RankIterator
+	maxScore float64
+}
+
+// NewScoreNormalizationIterator is used to create a ScoreNormalizationIterator
+// that normalizes scores against the given maximum score.
+func NewScoreNormalizationIterator(ctx Context, source RankIterator, maxScore float64) *ScoreNormalizationIterator {
+	return &ScoreNormalizationIterator{
+		ctx:      ctx,
+		source:   source,
+		maxScore: maxScore,
+	}
+}
+
+func (iter *ScoreNormalizationIterator) Next() *RankedNode {
+	option := iter.source.Next()
+	if option == nil {
+		return nil
+	}
+	iter.ctx.Metrics().NormalizeNodeScores(option.Node, option.Score, iter.maxScore)
+	return option
+}
+
+func (iter *ScoreNormalizationIterator) Reset() {
+	iter.source.Reset()
+}
+
+// coreCompute returns the compute in MHz of a single core of the node.
+func coreCompute(node *structs.Node) int {
+	if node.Resources == nil || len(node.Resources.CoreIDs) == 0 {
+		return 0
+	}
+	return node.Resources.CPU / len(node.Resources.CoreIDs)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	require "github.com/stretchr/testify/require"
 )
@@ -101,6 +102,119 @@ func TestBinPackIterator_NoExistingAlloc(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_GroupNetwork(t *testing.T) {
+	require := require.New(t)
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		{
+			Node: mock.Node(),
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Networks: []*structs.NetworkResource{
+			{
+				Mode:          structs.NetworkModeBridge,
+				MBits:         10,
+				ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+				DynamicPorts:  []structs.Port{{Label: "http", To: 8080}},
+			},
+		},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	require.Len(out, 1)
+	require.NotNil(out[0].AllocResources)
+	require.Len(out[0].AllocResources.Networks, 1)
+
+	offer := out[0].AllocResources.Networks[0]
+	require.Equal(structs.NetworkModeBridge, offer.Mode)
+	require.Equal("192.168.0.100", offer.IP)
+	require.Equal(9000, offer.ReservedPorts[0].Value)
+	require.Equal(8080, offer.DynamicPorts[0].To)
+	require.True(offer.DynamicPorts[0].Value >= structs.MinDynamicPort)
+
+	// The ask of the task group isn't modified
+	require.Zero(taskGroup.Networks[0].DynamicPorts[0].Value)
+
+	// A static port reserved by the node doesn't fit
+	static.Reset()
+	taskGroup.Networks[0].ReservedPorts[0].Value = 22
+	out = collectRanked(binp)
+	require.Empty(out)
+}
+
+func TestBinPackIterator_SpreadAlgorithm(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		{
+			Node: &structs.Node{
+				// Perfect fit
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+				},
+				Reserved: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+		{
+			Node: &structs.Node{
+				// 50% fit
+				Resources: &structs.Resources{
+					CPU:      4096,
+					MemoryMB: 4096,
+				},
+				Reserved: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetSchedulerConfiguration(&structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	})
+	binp.SetTaskGroup(taskGroup)
+
+	// The node with the most free resources is preferred
+	out := collectRanked(binp)
+	require := require.New(t)
+	require.Len(out, 2)
+	require.Equal(0.0, out[0].Score)
+	require.True(out[1].Score > 2 && out[1].Score < 8, "bad score %v", out[1].Score)
+}
+
 func TestBinPackIterator_PlannedAlloc(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -464,3 +578,475 @@ func TestNodeAntiAffinity_PenaltyNodes(t *testing.T) {
 	require.Equal(0.0, out[1].Score)
 
 }
+
+// spreadTestNodes upserts a node in each of the given datacenters and returns
+// them as ranked nodes.
+func spreadTestNodes(t *testing.T, state *state.StateStore, dcs ...string) []*RankedNode {
+	var nodes []*RankedNode
+	for i, dc := range dcs {
+		node := mock.Node()
+		node.Datacenter = dc
+		noErr(t, state.UpsertNode(uint64(100+i), node))
+		nodes = append(nodes, &RankedNode{Node: node})
+	}
+	return nodes
+}
+
+// spreadTestAllocs upserts an allocation of the job on each of the given
+// nodes.
+func spreadTestAllocs(t *testing.T, state *state.StateStore, job *structs.Job, nodes ...*RankedNode) {
+	var allocs []*structs.Allocation
+	for _, node := range nodes {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.TaskGroup = job.TaskGroups[0].Name
+		alloc.NodeID = node.Node.ID
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, state.UpsertJobSummary(999, mock.JobSummary(job.ID)))
+	noErr(t, state.UpsertAllocs(1000, allocs))
+}
+
+func TestSpreadIterator_SingleAttribute(t *testing.T) {
+	require := require.New(t)
+	state, ctx := testContext(t)
+	nodes := spreadTestNodes(t, state, "dc1", "dc2", "dc1", "dc2")
+
+	job := mock.Job()
+	tg := job.TaskGroups[0]
+	tg.Count = 10
+	job.Spreads = []*structs.Spread{
+		{
+			Attribute: "${node.datacenter}",
+			Weight:    100,
+			SpreadTarget: []*structs.SpreadTarget{
+				{
+					Value:   "dc1",
+					Percent: 80,
+				},
+				{
+					Value:   "dc2",
+					Percent: 20,
+				},
+			},
+		},
+	}
+
+	// Place two existing allocations in dc1
+	spreadTestAllocs(t, state, job, nodes[0], nodes[2])
+
+	static := NewStaticRankIterator(ctx, nodes)
+	spreadIter := NewSpreadIterator(ctx, static, 10.0)
+	spreadIter.SetJob(job)
+	spreadIter.SetTaskGroup(tg)
+
+	// dc1 wants 8 and would have 3, dc2 wants 2 and would have 1
+	out := collectRanked(spreadIter)
+	require.Len(out, 4)
+	for _, option := range out {
+		switch option.Node.Datacenter {
+		case "dc1":
+			require.Equal(6.25, option.Score)
+		case "dc2":
+			require.Equal(5.0, option.Score)
+		}
+	}
+
+	// Plan an allocation in dc2 so that it reaches its desired count
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = nodes[1].Node.ID
+	ctx.Plan().NodeAllocation[nodes[1].Node.ID] = []*structs.Allocation{alloc}
+	for _, node := range nodes {
+		node.Score = 0
+	}
+
+	static.Reset()
+	spreadIter.Reset()
+	out = collectRanked(spreadIter)
+	require.Len(out, 4)
+	for _, option := range out {
+		switch option.Node.Datacenter {
+		case "dc1":
+			require.Equal(6.25, option.Score)
+		case "dc2":
+			require.Equal(0.0, option.Score)
+		}
+	}
+}
+
+func TestSpreadIterator_EvenSpread(t *testing.T) {
+	require := require.New(t)
+	state, ctx := testContext(t)
+	nodes := spreadTestNodes(t, state, "dc1", "dc1", "dc2", "dc3")
+
+	job := mock.Job()
+	tg := job.TaskGroups[0]
+	tg.Spreads = []*structs.Spread{
+		{
+			Attribute: "${node.datacenter}",
+			Weight:    50,
+		},
+	}
+
+	// Place two existing allocations in dc1 and one in dc2
+	spreadTestAllocs(t, state, job, nodes[0], nodes[1], nodes[2])
+
+	static := NewStaticRankIterator(ctx, nodes)
+	spreadIter := NewSpreadIterator(ctx, static, 10.0)
+	spreadIter.SetJob(job)
+	spreadIter.SetTaskGroup(tg)
+
+	out := collectRanked(spreadIter)
+	require.Len(out, 4)
+	expected := map[string]float64{
+		"dc1": -5.0,
+		"dc2": 5.0,
+		"dc3": 10.0,
+	}
+	for _, option := range out {
+		require.Equal(expected[option.Node.Datacenter], option.Score, option.Node.Datacenter)
+	}
+}
+
+func TestSpreadIterator_MissingAttribute(t *testing.T) {
+	require := require.New(t)
+	state, ctx := testContext(t)
+	nodes := spreadTestNodes(t, state, "dc1", "dc2")
+	nodes[0].Node.Meta["rack"] = "r1"
+
+	job := mock.Job()
+	tg := job.TaskGroups[0]
+	tg.Spreads = []*structs.Spread{
+		{
+			Attribute: "${meta.rack}",
+			Weight:    50,
+		},
+	}
+
+	static := NewStaticRankIterator(ctx, nodes)
+	spreadIter := NewSpreadIterator(ctx, static, 10.0)
+	spreadIter.SetJob(job)
+	spreadIter.SetTaskGroup(tg)
+
+	// Nodes without the attribute are penalized
+	out := collectRanked(spreadIter)
+	require.Len(out, 2)
+	require.Equal(0.0, out[0].Score)
+	require.Equal(-10.0, out[1].Score)
+}
+
+func TestNodeAffinityIterator(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		{Node: mock.Node()},
+		{Node: mock.Node()},
+		{Node: mock.Node()},
+		{Node: mock.Node()},
+	}
+
+	nodes[0].Node.Attributes["kernel.version"] = "4.9"
+	nodes[1].Node.Datacenter = "dc2"
+	nodes[2].Node.Datacenter = "dc2"
+	nodes[2].Node.NodeClass = "large"
+
+	affinities := []*structs.Affinity{
+		{
+			Operand: "=",
+			LTarget: "${node.datacenter}",
+			RTarget: "dc1",
+			Weight:  100,
+		},
+		{
+			Operand: "=",
+			LTarget: "${node.datacenter}",
+			RTarget: "dc2",
+			Weight:  -50,
+		},
+		{
+			Operand: "version",
+			LTarget: "${attr.kernel.version}",
+			RTarget: ">4.0",
+			Weight:  25,
+		},
+		{
+			Operand: "is",
+			LTarget: "${node.class}",
+			RTarget: "large",
+			Weight:  25,
+		},
+	}
+
+	static := NewStaticRankIterator(ctx, nodes)
+
+	job := mock.Job()
+	job.ID = "foo"
+	tg := job.TaskGroups[0]
+	tg.Affinities = affinities
+
+	nodeAffinity := NewNodeAffinityIterator(ctx, static, 10.0)
+	nodeAffinity.SetJob(job)
+	nodeAffinity.SetTaskGroup(tg)
+
+	out := collectRanked(nodeAffinity)
+	expectedScores := make(map[string]float64)
+	// Total weight = 200
+	expectedScores[nodes[0].Node.ID] = 6.25
+	expectedScores[nodes[1].Node.ID] = -2.5
+	expectedScores[nodes[2].Node.ID] = -1.25
+	expectedScores[nodes[3].Node.ID] = 5.0
+
+	require := require.New(t)
+	for _, n := range out {
+		require.Equal(expectedScores[n.Node.ID], n.Score)
+	}
+
+	// The affinity scores are reported in the metrics
+	require.Equal(6.25, ctx.Metrics().Scores[nodes[0].Node.ID+".node-affinity"])
+}
+
+func TestScoreNormalizationIterator(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		{Node: mock.Node()},
+		{Node: mock.Node()},
+	}
+	nodes[1].Node.Datacenter = "dc2"
+
+	job := mock.Job()
+	tg := job.TaskGroups[0]
+	tg.Affinities = []*structs.Affinity{
+		{
+			Operand: "=",
+			LTarget: "${node.datacenter}",
+			RTarget: "dc1",
+			Weight:  100,
+		},
+	}
+
+	static := NewStaticRankIterator(ctx, nodes)
+	nodeAffinity := NewNodeAffinityIterator(ctx, static, 9.0)
+	nodeAffinity.SetJob(job)
+	nodeAffinity.SetTaskGroup(tg)
+	scoreNorm := NewScoreNormalizationIterator(ctx, nodeAffinity, 18.0)
+
+	// Raw scores are left untouched
+	out := collectRanked(scoreNorm)
+	require := require.New(t)
+	require.Len(out, 2)
+	require.Equal(9.0, out[0].Score)
+	require.Equal(0.0, out[1].Score)
+
+	// Both nodes are recorded, highest score first
+	meta := ctx.Metrics().ScoreMetaData
+	require.Len(meta, 2)
+	require.Equal(nodes[0].Node.ID, meta[0].NodeID)
+	require.Equal(0.5, meta[0].NormScore)
+	require.Equal(0.5, meta[0].Scores["node-affinity"])
+	require.Equal(nodes[1].Node.ID, meta[1].NodeID)
+	require.Equal(0.0, meta[1].NormScore)
+	require.Empty(meta[1].Scores)
+}
+
+func TestBinPackIterator_Devices(t *testing.T) {
+	state, ctx := testContext(t)
+	node := mock.Node()
+	node.Resources.Devices = []*structs.DeviceResource{
+		{
+			Vendor: "046d",
+			Type:   "usb",
+			Name:   "c52b",
+			Instances: []*structs.DeviceInstance{
+				{ID: "1-1.2", Healthy: true},
+				{ID: "1-1.3", Healthy: true},
+				{ID: "1-1.4", Healthy: true},
+			},
+		},
+	}
+	nodes := []*RankedNode{{Node: node}}
+
+	// Add an existing allocation using one of the instances
+	j1 := mock.Job()
+	alloc1 := &structs.Allocation{
+		Namespace: structs.DefaultNamespace,
+		ID:        uuid.Generate(),
+		EvalID:    uuid.Generate(),
+		NodeID:    node.ID,
+		JobID:     j1.ID,
+		Job:       j1,
+		Resources: &structs.Resources{
+			CPU:      256,
+			MemoryMB: 256,
+		},
+		TaskResources: map[string]*structs.Resources{
+			"web": {
+				CPU:      256,
+				MemoryMB: 256,
+				Devices: []*structs.DeviceResource{
+					{
+						Vendor:    "046d",
+						Type:      "usb",
+						Name:      "c52b",
+						Count:     1,
+						Instances: []*structs.DeviceInstance{{ID: "1-1.2", Healthy: true}},
+					},
+				},
+			},
+		},
+		DesiredStatus: structs.AllocDesiredStatusRun,
+		ClientStatus:  structs.AllocClientStatusPending,
+		TaskGroup:     "web",
+	}
+	noErr(t, state.UpsertJobSummary(999, mock.JobSummary(alloc1.JobID)))
+	noErr(t, state.UpsertAllocs(1000, []*structs.Allocation{alloc1}))
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      256,
+					MemoryMB: 256,
+					Devices: []*structs.DeviceResource{
+						{Vendor: "046d", Type: "usb", Count: 2},
+					},
+				},
+			},
+		},
+	}
+
+	// The remaining instances are assigned
+	require := require.New(t)
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, nodes), false, 0)
+	binp.SetTaskGroup(taskGroup)
+	out := collectRanked(binp)
+	require.Len(out, 1)
+	devices := out[0].TaskResources["web"].Devices
+	require.Len(devices, 1)
+	require.Equal("046d/usb/c52b", devices[0].ID())
+	require.Equal([]string{"1-1.3", "1-1.4"}, devices[0].InstanceIDs())
+
+	// The ask of the task group isn't modified
+	require.Empty(taskGroup.Tasks[0].Resources.Devices[0].Instances)
+
+	// Asking for more instances than remain exhausts the node
+	taskGroup.Tasks[0].Resources.Devices[0].Count = 3
+	nodes = []*RankedNode{{Node: node}}
+	binp = NewBinPackIterator(ctx, NewStaticRankIterator(ctx, nodes), false, 0)
+	binp.SetTaskGroup(taskGroup)
+	out = collectRanked(binp)
+	require.Empty(out)
+	require.Equal(1, ctx.Metrics().DimensionExhausted[`devices: not enough "046d/usb" instances available`])
+}
+
+func TestBinPackIterator_MemoryOversubscription(t *testing.T) {
+	_, ctx := testContext(t)
+	node := mock.Node()
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:         256,
+					MemoryMB:    256,
+					MemoryMaxMB: 1024,
+				},
+			},
+		},
+	}
+
+	// The memory limit is ignored by default
+	require := require.New(t)
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, []*RankedNode{{Node: node}}), false, 0)
+	binp.SetSchedulerConfiguration(structs.DefaultSchedulerConfiguration())
+	binp.SetTaskGroup(taskGroup)
+	out := collectRanked(binp)
+	require.Len(out, 1)
+	require.Equal(256, out[0].TaskResources["web"].MemoryMB)
+	require.Zero(out[0].TaskResources["web"].MemoryMaxMB)
+
+	// The memory limit is kept once oversubscription is enabled
+	config := structs.DefaultSchedulerConfiguration()
+	config.MemoryOversubscriptionEnabled = true
+	binp = NewBinPackIterator(ctx, NewStaticRankIterator(ctx, []*RankedNode{{Node: node}}), false, 0)
+	binp.SetSchedulerConfiguration(config)
+	binp.SetTaskGroup(taskGroup)
+	out = collectRanked(binp)
+	require.Len(out, 1)
+	require.Equal(256, out[0].TaskResources["web"].MemoryMB)
+	require.Equal(1024, out[0].TaskResources["web"].MemoryMaxMB)
+}
+
+func TestBinPackIterator_Cores(t *testing.T) {
+	state, ctx := testContext(t)
+	node := mock.Node()
+	node.Resources.CPU = 4000
+	node.Resources.CoreIDs = []uint16{0, 1, 2, 3}
+	node.Reserved.CoreIDs = []uint16{0}
+	nodes := []*RankedNode{{Node: node}}
+
+	// Add an existing allocation using one of the cores
+	j1 := mock.Job()
+	alloc1 := &structs.Allocation{
+		Namespace: structs.DefaultNamespace,
+		ID:        uuid.Generate(),
+		EvalID:    uuid.Generate(),
+		NodeID:    node.ID,
+		JobID:     j1.ID,
+		Job:       j1,
+		Resources: &structs.Resources{
+			CPU:      1000,
+			MemoryMB: 256,
+		},
+		TaskResources: map[string]*structs.Resources{
+			"web": {
+				CPU:      1000,
+				MemoryMB: 256,
+				Cores:    1,
+				CoreIDs:  []uint16{1},
+			},
+		},
+		DesiredStatus: structs.AllocDesiredStatusRun,
+		ClientStatus:  structs.AllocClientStatusPending,
+		TaskGroup:     "web",
+	}
+	noErr(t, state.UpsertJobSummary(999, mock.JobSummary(alloc1.JobID)))
+	noErr(t, state.UpsertAllocs(1000, []*structs.Allocation{alloc1}))
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      100,
+					MemoryMB: 256,
+					Cores:    2,
+				},
+			},
+		},
+	}
+
+	// The remaining cores are dedicated and the task gets their compute
+	require := require.New(t)
+	binp := NewBinPackIterator(ctx, NewStaticRankIterator(ctx, nodes), false, 0)
+	binp.SetTaskGroup(taskGroup)
+	out := collectRanked(binp)
+	require.Len(out, 1)
+	resources := out[0].TaskResources["web"]
+	require.Equal([]uint16{2, 3}, resources.CoreIDs)
+	require.Equal(2000, resources.CPU)
+
+	// Asking for more cores than remain exhausts the node
+	taskGroup.Tasks[0].Resources.Cores = 3
+	nodes = []*RankedNode{{Node: node}}
+	binp = NewBinPackIterator(ctx, NewStaticRankIterator(ctx, nodes), false, 0)
+	binp.SetTaskGroup(taskGroup)
+	out = collectRanked(binp)
+	require.Empty(out)
+	require.Equal(1, ctx.Metrics().DimensionExhausted["cores: not enough cores available"])
+}
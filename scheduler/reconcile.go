@@ -334,12 +334,17 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 		dstate, existingDeployment = a.deployment.TaskGroups[group]
 	}
 	if !existingDeployment {
-		autorevert := false
-		if tg.Update != nil && tg.Update.AutoRevert {
-			autorevert = true
-		}
-		dstate = &structs.DeploymentState{
-			AutoRevert: autorevert,
+		dstate = &structs.DeploymentState{}
+		if tg.Update != nil {
+			dstate.AutoRevert = tg.Update.AutoRevert
+			dstate.AutoPromote = tg.Update.AutoPromote
+			dstate.ProgressDeadline = tg.Update.ProgressDeadline
+
+			// Start the progress deadline with the deployment so it fails
+			// even if no allocation can be placed
+			if dstate.ProgressDeadline != 0 {
+				dstate.RequireProgressBy = a.now.Add(dstate.ProgressDeadline)
+			}
 		}
 	}
 
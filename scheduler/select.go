@@ -32,6 +32,13 @@ func (iter *LimitIterator) SetLimit(limit int) {
 	iter.limit = limit
 }
 
+// SetSkip sets the score at or below which up to maxSkip options are skipped.
+// No option is skipped if maxSkip is zero.
+func (iter *LimitIterator) SetSkip(scoreThreshold float64, maxSkip int) {
+	iter.scoreThreshold = scoreThreshold
+	iter.maxSkip = maxSkip
+}
+
 func (iter *LimitIterator) Next() *RankedNode {
 	if iter.seen == iter.limit {
 		return nil
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"log"
 	"math"
 	"time"
 
@@ -8,26 +9,25 @@ import (
 )
 
 const (
-	// serviceJobAntiAffinityPenalty is the penalty applied
-	// to the score for placing an alloc on a node that
-	// already has an alloc for this job.
-	serviceJobAntiAffinityPenalty = 20.0
-
-	// batchJobAntiAffinityPenalty is the same as the
-	// serviceJobAntiAffinityPenalty but for batch type jobs.
-	batchJobAntiAffinityPenalty = 10.0
-
 	// previousFailedAllocNodePenalty is a scoring penalty for nodes
 	// that a failed allocation was previously run on
 	previousFailedAllocNodePenalty = 50.0
 
-	// skipScoreThreshold is a threshold used in the limit iterator to skip nodes
-	// that have a score lower than this. -10 is the highest possible score for a
-	// node with penalty (based on batchJobAntiAffinityPenalty)
-	skipScoreThreshold = -10.0
-
-	// maxSkip limits the number of nodes that can be skipped in the limit iterator
-	maxSkip = 3
+	// binPackingMaxFitScore is the highest score the bin packing iterator
+	// gives a node. Scores recorded in the placement metrics are normalized
+	// against it.
+	binPackingMaxFitScore = 18.0
+
+	// maxSpreadBoost is the score added to a node whose spread attribute value
+	// has none of its desired allocations. It matches the highest bin packing
+	// score so that spreading can outweigh packing.
+	maxSpreadBoost = 18.0
+
+	// maxAffinityBoost is the score added to a node that matches all of the
+	// affinities of a task group, or subtracted if the affinities all have
+	// negative weights. Like maxSpreadBoost it matches the highest bin packing
+	// score.
+	maxAffinityBoost = 18.0
 )
 
 // Stack is a chained collection of iterators. The stack is used to
@@ -56,27 +56,53 @@ type GenericStack struct {
 	ctx    Context
 	source *StaticIterator
 
-	wrappedChecks       *FeasibilityWrapper
-	quota               FeasibleIterator
-	jobConstraint       *ConstraintChecker
-	taskGroupDrivers    *DriverChecker
-	taskGroupConstraint *ConstraintChecker
+	wrappedChecks        *FeasibilityWrapper
+	quota                FeasibleIterator
+	jobConstraint        *ConstraintChecker
+	taskGroupDrivers     *DriverChecker
+	taskGroupConstraint  *ConstraintChecker
+	taskGroupHostVolumes *HostVolumeChecker
+	taskGroupNetwork     *NetworkChecker
 
 	distinctHostsConstraint    *DistinctHostsIterator
 	distinctPropertyConstraint *DistinctPropertyIterator
 	binPack                    *BinPackIterator
 	jobAntiAff                 *JobAntiAffinityIterator
 	nodeAntiAff                *NodeAntiAffinityIterator
+	nodeAffinity               *NodeAffinityIterator
+	spread                     *SpreadIterator
+	scoreNorm                  *ScoreNormalizationIterator
 	limit                      *LimitIterator
 	maxScore                   *MaxScoreIterator
+
+	// minNodeLimit is the minimum number of nodes the limit iterator visits
+	minNodeLimit int
+
+	// nodeLimit is the number of nodes the limit iterator visits when the
+	// task group has no spreads
+	nodeLimit int
+
+	// maxSkippedNodes is the maximum number of nodes the limit iterator
+	// passes over for scoring at or below the skip threshold
+	maxSkippedNodes int
+
+	// serviceJobAntiAffinityPenalty and batchJobAntiAffinityPenalty are the
+	// anti-affinity penalties of the job types, which set the skip threshold
+	serviceJobAntiAffinityPenalty float64
+	batchJobAntiAffinityPenalty   float64
 }
 
 // NewGenericStack constructs a stack used for selecting service placements
 func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// Create a new stack
+	config := schedulerConfig(ctx.State(), ctx.Logger())
 	s := &GenericStack{
-		batch: batch,
-		ctx:   ctx,
+		batch:                         batch,
+		ctx:                           ctx,
+		minNodeLimit:                  *config.MinNodeLimit,
+		maxSkippedNodes:               *config.MaxSkippedNodes,
+		serviceJobAntiAffinityPenalty: *config.ServiceJobAntiAffinityPenalty,
+		batchJobAntiAffinityPenalty:   *config.BatchJobAntiAffinityPenalty,
 	}
 
 	// Create the source iterator. We randomize the order we visit nodes
@@ -97,12 +123,16 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// Filter on task group constraints second
 	s.taskGroupConstraint = NewConstraintChecker(ctx, nil)
 
+	// Filter on task group host volumes
+	s.taskGroupHostVolumes = NewHostVolumeChecker(ctx)
+	s.taskGroupNetwork = NewNetworkChecker(ctx)
+
 	// Create the feasibility wrapper which wraps all feasibility checks in
 	// which feasibility checking can be skipped if the computed node class has
 	// previously been marked as eligible or ineligible. Generally this will be
 	// checks that only needs to examine the single node to determine feasibility.
 	jobs := []FeasibilityChecker{s.jobConstraint}
-	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint}
+	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint, s.taskGroupHostVolumes, s.taskGroupNetwork}
 	s.wrappedChecks = NewFeasibilityWrapper(ctx, s.quota, jobs, tgs)
 
 	// Filter on distinct host constraints.
@@ -119,20 +149,34 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// scheduler as that logic is expensive.
 	evict := !batch
 	s.binPack = NewBinPackIterator(ctx, rankSource, evict, 0)
+	s.binPack.SetSchedulerConfiguration(config)
 
 	// Apply the job anti-affinity iterator. This is to avoid placing
 	// multiple allocations on the same node for this job. The penalty
 	// is less for batch jobs as it matters less.
-	penalty := serviceJobAntiAffinityPenalty
+	penalty := s.serviceJobAntiAffinityPenalty
 	if batch {
-		penalty = batchJobAntiAffinityPenalty
+		penalty = s.batchJobAntiAffinityPenalty
 	}
 	s.jobAntiAff = NewJobAntiAffinityIterator(ctx, s.binPack, penalty, "")
 
 	s.nodeAntiAff = NewNodeAntiAffinityIterator(ctx, s.jobAntiAff, previousFailedAllocNodePenalty)
 
+	// Apply the node affinity iterator. This scores nodes by how well they
+	// match the affinities of the job, task group and tasks.
+	s.nodeAffinity = NewNodeAffinityIterator(ctx, s.nodeAntiAff, maxAffinityBoost)
+
+	// Apply the spread iterator. This boosts nodes whose attribute values are
+	// below their desired share of the job's allocations.
+	s.spread = NewSpreadIterator(ctx, s.nodeAffinity, maxSpreadBoost)
+
+	// Record the normalized scores of the ranked nodes
+	s.scoreNorm = NewScoreNormalizationIterator(ctx, s.spread, binPackingMaxFitScore)
+
 	// Apply a limit function. This is to avoid scanning *every* possible node.
-	s.limit = NewLimitIterator(ctx, s.nodeAntiAff, 2, skipScoreThreshold, maxSkip)
+	// The nodes passed over are set with the job.
+	s.nodeLimit = s.minNodeLimit
+	s.limit = NewLimitIterator(ctx, s.scoreNorm, s.nodeLimit, 0, 0)
 
 	// Select the node with the maximum score for placement
 	s.maxScore = NewMaxScoreIterator(ctx, s.limit)
@@ -150,14 +194,15 @@ func (s *GenericStack) SetNodes(baseNodes []*structs.Node) {
 	// For batch jobs we only need to evaluate 2 options and depend on the
 	// power of two choices. For services jobs we need to visit "enough".
 	// Using a log of the total number of nodes is a good restriction, with
-	// at least 2 as the floor
-	limit := 2
+	// the configured minimum, 2 by default, as the floor
+	limit := s.minNodeLimit
 	if n := len(baseNodes); !s.batch && n > 0 {
 		logLimit := int(math.Ceil(math.Log2(float64(n))))
 		if logLimit > limit {
 			limit = logLimit
 		}
 	}
+	s.nodeLimit = limit
 	s.limit.SetLimit(limit)
 }
 
@@ -165,13 +210,29 @@ func (s *GenericStack) SetJob(job *structs.Job) {
 	s.jobConstraint.SetConstraints(job.Constraints)
 	s.distinctHostsConstraint.SetJob(job)
 	s.distinctPropertyConstraint.SetJob(job)
-	s.binPack.SetPriority(job.Priority)
+	s.binPack.SetJob(job)
 	s.jobAntiAff.SetJob(job.ID)
+	s.nodeAffinity.SetJob(job)
+	s.spread.SetJob(job)
 	s.ctx.Eligibility().SetJob(job)
 
 	if contextual, ok := s.quota.(ContextualIterator); ok {
 		contextual.SetJob(job)
 	}
+
+	// Nodes scoring at or below the skip threshold, which is the highest
+	// possible score for a node with the anti-affinity penalty of the job's
+	// type, are passed over in favour of the next nodes if possible. Without
+	// a penalty no node is penalized, so none is passed over.
+	penalty := s.serviceJobAntiAffinityPenalty
+	if job.Type == structs.JobTypeBatch {
+		penalty = s.batchJobAntiAffinityPenalty
+	}
+	maxSkip := s.maxSkippedNodes
+	if penalty == 0 {
+		maxSkip = 0
+	}
+	s.limit.SetSkip(-penalty, maxSkip)
 }
 
 func (s *GenericStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*RankedNode, *structs.Resources) {
@@ -202,14 +263,26 @@ func (s *GenericStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*R
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
+	s.taskGroupHostVolumes.SetVolumes(tg.Volumes)
+	s.taskGroupNetwork.SetNetworks(tg.Networks)
 	s.distinctHostsConstraint.SetTaskGroup(tg)
 	s.distinctPropertyConstraint.SetTaskGroup(tg)
 	s.wrappedChecks.SetTaskGroup(tg.Name)
 	s.binPack.SetTaskGroup(tg)
+	s.nodeAffinity.SetTaskGroup(tg)
+	s.spread.SetTaskGroup(tg)
 	if options != nil {
 		s.nodeAntiAff.SetPenaltyNodes(options.PenaltyNodeIDs)
 	}
 
+	// Affinities and spreading require comparing every node, so don't limit
+	// the number of nodes visited when the task group has either
+	if s.nodeAffinity.hasAffinities() || s.spread.hasSpreads() {
+		s.limit.SetLimit(math.MaxInt32)
+	} else {
+		s.limit.SetLimit(s.nodeLimit)
+	}
+
 	if contextual, ok := s.quota.(ContextualIterator); ok {
 		contextual.SetTaskGroup(tg)
 	}
@@ -217,6 +290,16 @@ func (s *GenericStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*R
 	// Find the node with the max score
 	option := s.maxScore.Next()
 
+	// If no node fits, try again allowing allocations of lower priority jobs
+	// to be preempted
+	if option == nil && s.binPack.evict {
+		s.maxScore.Reset()
+		s.ctx.Reset()
+		s.binPack.SetPreemption(true)
+		option = s.maxScore.Next()
+		s.binPack.SetPreemption(false)
+	}
+
 	// Ensure that the task resources were specified
 	if option != nil && len(option.TaskResources) != len(tg.Tasks) {
 		for _, task := range tg.Tasks {
@@ -239,8 +322,11 @@ type SystemStack struct {
 	jobConstraint              *ConstraintChecker
 	taskGroupDrivers           *DriverChecker
 	taskGroupConstraint        *ConstraintChecker
+	taskGroupHostVolumes       *HostVolumeChecker
+	taskGroupNetwork           *NetworkChecker
 	distinctPropertyConstraint *DistinctPropertyIterator
 	binPack                    *BinPackIterator
+	scoreNorm                  *ScoreNormalizationIterator
 }
 
 // NewSystemStack constructs a stack used for selecting service placements
@@ -265,12 +351,16 @@ func NewSystemStack(ctx Context) *SystemStack {
 	// Filter on task group constraints second
 	s.taskGroupConstraint = NewConstraintChecker(ctx, nil)
 
+	// Filter on task group host volumes
+	s.taskGroupHostVolumes = NewHostVolumeChecker(ctx)
+	s.taskGroupNetwork = NewNetworkChecker(ctx)
+
 	// Create the feasibility wrapper which wraps all feasibility checks in
 	// which feasibility checking can be skipped if the computed node class has
 	// previously been marked as eligible or ineligible. Generally this will be
 	// checks that only needs to examine the single node to determine feasibility.
 	jobs := []FeasibilityChecker{s.jobConstraint}
-	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint}
+	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint, s.taskGroupHostVolumes, s.taskGroupNetwork}
 	s.wrappedChecks = NewFeasibilityWrapper(ctx, s.quota, jobs, tgs)
 
 	// Filter on distinct property constraints.
@@ -283,6 +373,10 @@ func NewSystemStack(ctx Context) *SystemStack {
 	// by a particular task group. Enable eviction as system jobs are high
 	// priority.
 	s.binPack = NewBinPackIterator(ctx, rankSource, true, 0)
+	s.binPack.SetSchedulerConfiguration(schedulerConfig(ctx.State(), ctx.Logger()))
+
+	// Record the normalized scores of the ranked nodes
+	s.scoreNorm = NewScoreNormalizationIterator(ctx, s.binPack, binPackingMaxFitScore)
 	return s
 }
 
@@ -294,7 +388,7 @@ func (s *SystemStack) SetNodes(baseNodes []*structs.Node) {
 func (s *SystemStack) SetJob(job *structs.Job) {
 	s.jobConstraint.SetConstraints(job.Constraints)
 	s.distinctPropertyConstraint.SetJob(job)
-	s.binPack.SetPriority(job.Priority)
+	s.binPack.SetJob(job)
 	s.ctx.Eligibility().SetJob(job)
 
 	if contextual, ok := s.quota.(ContextualIterator); ok {
@@ -304,7 +398,7 @@ func (s *SystemStack) SetJob(job *structs.Job) {
 
 func (s *SystemStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*RankedNode, *structs.Resources) {
 	// Reset the binpack selector and context
-	s.binPack.Reset()
+	s.scoreNorm.Reset()
 	s.ctx.Reset()
 	start := time.Now()
 
@@ -314,6 +408,8 @@ func (s *SystemStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*Ra
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
+	s.taskGroupHostVolumes.SetVolumes(tg.Volumes)
+	s.taskGroupNetwork.SetNetworks(tg.Networks)
 	s.wrappedChecks.SetTaskGroup(tg.Name)
 	s.distinctPropertyConstraint.SetTaskGroup(tg)
 	s.binPack.SetTaskGroup(tg)
@@ -323,7 +419,17 @@ func (s *SystemStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*Ra
 	}
 
 	// Get the next option that satisfies the constraints.
-	option := s.binPack.Next()
+	option := s.scoreNorm.Next()
+
+	// If the node does not fit, try again allowing allocations of lower
+	// priority jobs to be preempted
+	if option == nil && s.binPack.evict {
+		s.scoreNorm.Reset()
+		s.ctx.Reset()
+		s.binPack.SetPreemption(true)
+		option = s.scoreNorm.Next()
+		s.binPack.SetPreemption(false)
+	}
 
 	// Ensure that the task resources were specified
 	if option != nil && len(option.TaskResources) != len(tg.Tasks) {
@@ -336,3 +442,21 @@ func (s *SystemStack) Select(tg *structs.TaskGroup, options *SelectOptions) (*Ra
 	s.ctx.Metrics().AllocationTime = time.Since(start)
 	return option, tgConstr.size
 }
+
+// schedulerConfig returns the cluster's canonicalized scheduler
+// configuration. The default configuration is used if it is unset or can't be
+// read.
+func schedulerConfig(state State, logger *log.Logger) *structs.SchedulerConfiguration {
+	_, config, err := state.SchedulerConfig()
+	if err != nil {
+		logger.Printf("[ERR] sched: failed to get scheduler configuration: %v", err)
+		return structs.DefaultSchedulerConfiguration()
+	}
+	if config == nil {
+		return structs.DefaultSchedulerConfiguration()
+	}
+
+	config = config.Copy()
+	config.Canonicalize()
+	return config
+}
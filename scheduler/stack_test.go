@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/stretchr/testify/require"
@@ -352,6 +353,147 @@ func TestSystemStack_SetJob(t *testing.T) {
 	}
 }
 
+func TestServiceStack_Select_SchedulerAlgorithm(t *testing.T) {
+	state, ctx := testContext(t)
+	require := require.New(t)
+
+	// Load one of the nodes with an allocation of another job
+	nodes := []*structs.Node{mock.Node(), mock.Node()}
+	alloc := mock.Alloc()
+	alloc.NodeID = nodes[0].ID
+	require.Nil(state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)))
+	require.Nil(state.UpsertAllocs(1000, []*structs.Allocation{alloc}))
+
+	job := mock.Job()
+	loaded, idle := nodes[0].ID, nodes[1].ID
+	selectNode := func() *structs.Node {
+		stack := NewGenericStack(false, ctx)
+		stack.SetNodes(nodes)
+		stack.SetJob(job)
+		option, _ := stack.Select(job.TaskGroups[0], &SelectOptions{})
+		require.NotNil(option)
+		return option.Node
+	}
+
+	// Bin packing prefers the loaded node
+	require.Equal(loaded, selectNode().ID)
+
+	// Spreading prefers the idle node
+	require.Nil(state.SchedulerSetConfig(1001, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}))
+	require.Equal(idle, selectNode().ID)
+}
+
+func TestServiceStack_SchedulerConfiguration(t *testing.T) {
+	state, ctx := testContext(t)
+	require := require.New(t)
+
+	nodes := make([]*structs.Node, 100)
+	for i := range nodes {
+		nodes[i] = mock.Node()
+	}
+
+	batchJob := mock.Job()
+	batchJob.Type = structs.JobTypeBatch
+
+	// The defaults are used when the configuration is unset
+	stack := NewGenericStack(false, ctx)
+	stack.SetNodes(nodes)
+	stack.SetJob(mock.Job())
+	require.Equal(7, stack.nodeLimit)
+	require.Equal(structs.DefaultServiceJobAntiAffinityPenalty, stack.jobAntiAff.penalty)
+	require.Equal(-structs.DefaultServiceJobAntiAffinityPenalty, stack.limit.scoreThreshold)
+	require.Equal(structs.DefaultSchedulerMaxSkippedNodes, stack.limit.maxSkip)
+
+	stack = NewGenericStack(true, ctx)
+	stack.SetNodes(nodes)
+	stack.SetJob(batchJob)
+	require.Equal(-structs.DefaultBatchJobAntiAffinityPenalty, stack.limit.scoreThreshold)
+	require.Equal(structs.DefaultSchedulerMaxSkippedNodes, stack.limit.maxSkip)
+
+	require.Nil(state.SchedulerSetConfig(1000, &structs.SchedulerConfiguration{
+		MinNodeLimit:                  helper.IntToPtr(10),
+		MaxSkippedNodes:               helper.IntToPtr(5),
+		ServiceJobAntiAffinityPenalty: helper.Float64ToPtr(8),
+		BatchJobAntiAffinityPenalty:   helper.Float64ToPtr(0),
+	}))
+
+	stack = NewGenericStack(false, ctx)
+	stack.SetNodes(nodes)
+	stack.SetJob(mock.Job())
+	require.Equal(10, stack.nodeLimit)
+	require.Equal(8.0, stack.jobAntiAff.penalty)
+	require.Equal(-8.0, stack.limit.scoreThreshold)
+	require.Equal(5, stack.limit.maxSkip)
+
+	// Nodes aren't skipped for batch jobs without a penalty
+	stack = NewGenericStack(true, ctx)
+	stack.SetNodes(nodes)
+	stack.SetJob(batchJob)
+	require.Equal(10, stack.nodeLimit)
+	require.Zero(stack.jobAntiAff.penalty)
+	require.Zero(stack.limit.maxSkip)
+}
+
+func TestServiceStack_Select_ZeroAntiAffinityPenalty(t *testing.T) {
+	state, ctx := testContext(t)
+	require := require.New(t)
+
+	require.Nil(state.SchedulerSetConfig(1000, &structs.SchedulerConfiguration{
+		BatchJobAntiAffinityPenalty: helper.Float64ToPtr(0),
+	}))
+
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	stack := NewGenericStack(true, ctx)
+	stack.SetNodes([]*structs.Node{mock.Node()})
+	stack.SetJob(job)
+
+	// A node scoring zero is not passed over for the next one
+	nodes := []*RankedNode{
+		{Node: mock.Node(), Score: 0},
+		{Node: mock.Node(), Score: 1},
+	}
+	stack.limit.source = NewStaticRankIterator(ctx, nodes)
+	stack.limit.SetLimit(1)
+	out := collectRanked(stack.limit)
+	require.Len(out, 1)
+	require.Equal(nodes[0], out[0])
+
+	// The service jobs keep skipping with their own penalty
+	stack = NewGenericStack(false, ctx)
+	stack.SetNodes([]*structs.Node{mock.Node()})
+	stack.SetJob(mock.Job())
+	require.Equal(-structs.DefaultServiceJobAntiAffinityPenalty, stack.limit.scoreThreshold)
+	require.Equal(structs.DefaultSchedulerMaxSkippedNodes, stack.limit.maxSkip)
+}
+
+func TestSystemStack_Select_SchedulerAlgorithm(t *testing.T) {
+	state, ctx := testContext(t)
+	require := require.New(t)
+
+	nodes := []*structs.Node{mock.Node()}
+	job := mock.Job()
+	score := func() float64 {
+		stack := NewSystemStack(ctx)
+		stack.SetNodes(nodes)
+		stack.SetJob(job)
+		option, _ := stack.Select(job.TaskGroups[0], &SelectOptions{})
+		require.NotNil(option)
+		return option.Score
+	}
+
+	// A mostly idle node scores low when bin packing and high when spreading
+	binpack := score()
+	require.Nil(state.SchedulerSetConfig(1000, &structs.SchedulerConfiguration{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}))
+	spread := score()
+	require.True(spread > binpack, "bad scores %v %v", binpack, spread)
+	require.InDelta(18.0, binpack+spread, 0.0001)
+}
+
 func TestSystemStack_Select_Size(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*structs.Node{mock.Node()}
@@ -325,12 +325,20 @@ func (s *SystemScheduler) computePlacements(place []allocTuple) error {
 				},
 			}
 
+			// Add the group network shared by the tasks, if any
+			if option.AllocResources != nil {
+				alloc.SharedResources.Networks = option.AllocResources.Networks
+			}
+
 			// If the new allocation is replacing an older allocation then we
 			// set the record the older allocation id so that they are chained
 			if missing.Alloc != nil {
 				alloc.PreviousAllocation = missing.Alloc.ID
 			}
 
+			// Preempt any allocations needed to make room for the placement
+			appendPreemptions(s.plan, option, alloc)
+
 			s.plan.AppendAlloc(alloc)
 		} else {
 			// Lazy initialize the failed map
@@ -274,16 +274,26 @@ func TestSystemSched_ExhaustResources(t *testing.T) {
 		JobID:       job.ID,
 		Status:      structs.EvalStatusPending,
 	}
-	noErr(t, h.State.UpsertEvals(h.NextIndex(), []*structs.Evaluation{eval}))
+	noErr(t, h.State.UpsertEvals(h.NextIndex(), []*structs.Evaluation{eval1}))
 	// Process the evaluation
 	if err := h.Process(NewSystemScheduler, eval1); err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Ensure that we have one allocation queued from the system job eval
+	// The system job has a higher priority so it preempts the service job
+	plan := h.Plans[1]
+	if len(plan.NodeAllocation[node.ID]) != 1 || plan.NodeAllocation[node.ID][0].JobID != job.ID {
+		t.Fatalf("bad: %#v", plan.NodeAllocation)
+	}
+	preempted := plan.NodePreemptions[node.ID]
+	if len(preempted) != 1 || preempted[0].JobID != svcJob.ID {
+		t.Fatalf("bad: %#v", plan.NodePreemptions)
+	}
+
+	// Ensure that no allocation is queued from the system job eval
 	queued := h.Evals[1].QueuedAllocations["web"]
-	if queued != 1 {
-		t.Fatalf("expected: %v, actual: %v", 1, queued)
+	if queued != 0 {
+		t.Fatalf("expected: %v, actual: %v", 0, queued)
 	}
 }
 
@@ -96,6 +96,7 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	result := new(structs.PlanResult)
 	result.NodeUpdate = plan.NodeUpdate
 	result.NodeAllocation = plan.NodeAllocation
+	result.NodePreemptions = plan.NodePreemptions
 	result.AllocIndex = index
 
 	// Flatten evicts and allocs
@@ -107,6 +108,12 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 		allocs = append(allocs, allocList...)
 	}
 
+	// Flatten preemptions
+	var preempted []*structs.Allocation
+	for _, preemptions := range plan.NodePreemptions {
+		preempted = append(preempted, preemptions...)
+	}
+
 	// Set the time the alloc was applied for the first time. This can be used
 	// to approximate the scheduling time.
 	now := time.Now().UTC().UnixNano()
@@ -124,6 +131,7 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 		},
 		Deployment:        plan.Deployment,
 		DeploymentUpdates: plan.DeploymentUpdates,
+		NodePreemptions:   preempted,
 		EvalID:            plan.EvalID,
 	}
 
@@ -194,6 +202,15 @@ func (h *Harness) NextIndex() uint64 {
 	return idx
 }
 
+// SetNextIndex sets the index at which the next plan is applied. It is used
+// when running the schedulers against a copy of an existing state so that the
+// plans are applied after its latest index.
+func (h *Harness) SetNextIndex(index uint64) {
+	h.nextIndexLock.Lock()
+	defer h.nextIndexLock.Unlock()
+	h.nextIndex = index
+}
+
 // Snapshot is used to snapshot the current state
 func (h *Harness) Snapshot() State {
 	snap, _ := h.State.Snapshot()
@@ -334,6 +334,23 @@ func shuffleNodes(nodes []*structs.Node) {
 	}
 }
 
+// logShippersUpdated returns whether the log shippers of two log configs
+// differ. The shippers are started with the task so changing them requires a
+// destructive update.
+func logShippersUpdated(a, b *structs.LogConfig) bool {
+	var as, bs []*structs.LogShipper
+	if a != nil {
+		as = a.Shippers
+	}
+	if b != nil {
+		bs = b.Shippers
+	}
+	if len(as) == 0 && len(bs) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(as, bs)
+}
+
 // tasksUpdated does a diff between task groups to see if the
 // tasks, their drivers, environment variables or config have updated. The
 // inputs are the task group name to diff and two jobs to diff.
@@ -351,6 +368,16 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 		return true
 	}
 
+	// Check the volumes, which are mounted when the tasks start
+	if !reflect.DeepEqual(a.Volumes, b.Volumes) {
+		return true
+	}
+
+	// Check the group network, whose ports are kept by in-place updates
+	if networksUpdated(a.Networks, b.Networks) {
+		return true
+	}
+
 	// Check each task
 	for _, at := range a.Tasks {
 		bt := b.LookupTask(at.Name)
@@ -378,6 +405,15 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 		if !reflect.DeepEqual(at.Templates, bt.Templates) {
 			return true
 		}
+		if !reflect.DeepEqual(at.Lifecycle, bt.Lifecycle) {
+			return true
+		}
+		if !reflect.DeepEqual(at.VolumeMounts, bt.VolumeMounts) {
+			return true
+		}
+		if logShippersUpdated(at.LogConfig, bt.LogConfig) {
+			return true
+		}
 
 		// Check the metadata
 		if !reflect.DeepEqual(
@@ -387,19 +423,18 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 		}
 
 		// Inspect the network to see if the dynamic ports are different
-		if len(at.Resources.Networks) != len(bt.Resources.Networks) {
+		if networksUpdated(at.Resources.Networks, bt.Resources.Networks) {
 			return true
 		}
-		for idx := range at.Resources.Networks {
-			an := at.Resources.Networks[idx]
-			bn := bt.Resources.Networks[idx]
 
-			if an.MBits != bn.MBits {
-				return true
-			}
-
-			aPorts, bPorts := networkPortMap(an), networkPortMap(bn)
-			if !reflect.DeepEqual(aPorts, bPorts) {
+		// Inspect the requested devices
+		if len(at.Resources.Devices) != len(bt.Resources.Devices) {
+			return true
+		}
+		for idx := range at.Resources.Devices {
+			ad := at.Resources.Devices[idx]
+			bd := bt.Resources.Devices[idx]
+			if ad.ID() != bd.ID() || ad.Count != bd.Count {
 				return true
 			}
 		}
@@ -409,6 +444,10 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 			return true
 		} else if ar.MemoryMB != br.MemoryMB {
 			return true
+		} else if ar.MemoryMaxMB != br.MemoryMaxMB {
+			return true
+		} else if ar.Cores != br.Cores {
+			return true
 		} else if ar.IOPS != br.IOPS {
 			return true
 		}
@@ -416,6 +455,31 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 	return false
 }
 
+// networksUpdated returns whether the network asks differ in a way that
+// requires new offers, ignoring the values assigned to dynamic ports.
+func networksUpdated(a, b []*structs.NetworkResource) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for idx := range a {
+		an, bn := a[idx], b[idx]
+		if an.MBits != bn.MBits || an.Mode != bn.Mode {
+			return true
+		}
+
+		aPorts, bPorts := networkPortMap(an), networkPortMap(bn)
+		if !reflect.DeepEqual(aPorts, bPorts) {
+			return true
+		}
+
+		// The ports are mapped when the allocation starts
+		if !reflect.DeepEqual(networkPortToMap(an), networkPortToMap(bn)) {
+			return true
+		}
+	}
+	return false
+}
+
 // networkPortMap takes a network resource and returns a map of port labels to
 // values. The value for dynamic ports is disregarded even if it is set. This
 // makes this function suitable for comparing two network resources for changes.
@@ -430,6 +494,20 @@ func networkPortMap(n *structs.NetworkResource) map[string]int {
 	return m
 }
 
+// networkPortToMap returns a map of port labels to the ports they are mapped
+// to in the network namespace of the allocation.
+func networkPortToMap(n *structs.NetworkResource) map[string]int {
+	m := make(map[string]int, len(n.DynamicPorts)+len(n.ReservedPorts))
+	for _, ports := range [][]structs.Port{n.ReservedPorts, n.DynamicPorts} {
+		for _, p := range ports {
+			if p.To != 0 {
+				m[p.Label] = p.To
+			}
+		}
+	}
+	return m
+}
+
 // setStatus is used to update the status of the evaluation
 func setStatus(logger *log.Logger, planner Planner,
 	eval, nextEval, spawnedBlocked *structs.Evaluation,
@@ -524,13 +602,15 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 			continue
 		}
 
-		// Restore the network offers from the existing allocation.
-		// We do not allow network resources (reserved/dynamic ports)
-		// to be updated. This is guarded in taskUpdated, so we can
-		// safely restore those here.
+		// Restore the network offers, device instances and dedicated cores
+		// from the existing allocation. We do not allow network resources
+		// (reserved/dynamic ports), devices or cores to be updated. This is
+		// guarded in taskUpdated, so we can safely restore those here.
 		for task, resources := range option.TaskResources {
 			existing := update.Alloc.TaskResources[task]
 			resources.Networks = existing.Networks
+			resources.Devices = existing.Devices
+			resources.CoreIDs = existing.CoreIDs
 		}
 
 		// Create a shallow copy
@@ -786,18 +866,21 @@ func genericAllocUpdateFn(ctx Context, stack Stack, evalID string) allocUpdateTy
 		// Pop the allocation
 		ctx.Plan().PopUpdate(existing)
 
-		// Require destructive if we could not do an in-place update
-		if option == nil {
+		// Require destructive if we could not do an in-place update, including
+		// when the update would only fit by preempting other allocations
+		if option == nil || len(option.PreemptedAllocs) > 0 {
 			return false, true, nil
 		}
 
-		// Restore the network offers from the existing allocation.
-		// We do not allow network resources (reserved/dynamic ports)
-		// to be updated. This is guarded in taskUpdated, so we can
-		// safely restore those here.
+		// Restore the network offers, device instances and dedicated cores
+		// from the existing allocation. We do not allow network resources
+		// (reserved/dynamic ports), devices or cores to be updated. This is
+		// guarded in taskUpdated, so we can safely restore those here.
 		for task, resources := range option.TaskResources {
 			existingResources := existing.TaskResources[task]
 			resources.Networks = existingResources.Networks
+			resources.Devices = existingResources.Devices
+			resources.CoreIDs = existingResources.CoreIDs
 		}
 
 		// Create a shallow copy
@@ -813,3 +896,26 @@ func genericAllocUpdateFn(ctx Context, stack Stack, evalID string) allocUpdateTy
 		return false, false, newAlloc
 	}
 }
+
+// appendPreemptions adds the allocations that were preempted to place the
+// given allocation to the plan, recording them on the allocation and in the
+// plan annotations if present.
+func appendPreemptions(plan *structs.Plan, option *RankedNode, alloc *structs.Allocation) {
+	if len(option.PreemptedAllocs) == 0 {
+		return
+	}
+
+	preemptedAllocIDs := make([]string, 0, len(option.PreemptedAllocs))
+	for _, stop := range option.PreemptedAllocs {
+		plan.AppendPreemptedAlloc(stop, structs.AllocDesiredStatusEvict, alloc.ID)
+		preemptedAllocIDs = append(preemptedAllocIDs, stop.ID)
+
+		if plan.Annotations != nil {
+			plan.Annotations.PreemptedAllocs = append(plan.Annotations.PreemptedAllocs, stop.Stub())
+			if desired, ok := plan.Annotations.DesiredTGUpdates[alloc.TaskGroup]; ok {
+				desired.Preemptions += 1
+			}
+		}
+	}
+	alloc.PreemptedAllocations = preemptedAllocIDs
+}
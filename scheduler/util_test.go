@@ -571,6 +571,99 @@ func TestTasksUpdated(t *testing.T) {
 	if !tasksUpdated(j1, j18, name) {
 		t.Fatal("bad")
 	}
+
+	// Change the memory limit
+	j19 := mock.Job()
+	j19.TaskGroups[0].Tasks[0].Resources.MemoryMaxMB = 1024
+	if !tasksUpdated(j1, j19, name) {
+		t.Fatal("bad")
+	}
+
+	// Change the dedicated cores
+	j20 := mock.Job()
+	j20.TaskGroups[0].Tasks[0].Resources.Cores = 2
+	if !tasksUpdated(j1, j20, name) {
+		t.Fatal("bad")
+	}
+
+	// Change the lifecycle
+	j21 := mock.Job()
+	j21.TaskGroups[0].Tasks[0].Lifecycle = &structs.TaskLifecycleConfig{
+		Hook: structs.TaskLifecycleHookPrestart,
+	}
+	if !tasksUpdated(j1, j21, name) {
+		t.Fatal("bad")
+	}
+
+	// Add a log shipper
+	j22 := mock.Job()
+	j22.TaskGroups[0].Tasks[0].LogConfig.Shippers = []*structs.LogShipper{
+		{
+			Type:    structs.LogShipperTypeTCP,
+			Address: "127.0.0.1:5140",
+		},
+	}
+	if !tasksUpdated(j1, j22, name) {
+		t.Fatal("bad")
+	}
+
+	// Changing the rotation of the log files is an in-place update
+	j23 := mock.Job()
+	j23.TaskGroups[0].Tasks[0].LogConfig.MaxFiles = 20
+	if tasksUpdated(j1, j23, name) {
+		t.Fatal("bad")
+	}
+
+	// Add a volume
+	j24 := mock.Job()
+	j24.TaskGroups[0].Volumes = map[string]*structs.VolumeRequest{
+		"data": {
+			Name:   "data",
+			Type:   structs.VolumeTypeHost,
+			Source: "shared-data",
+		},
+	}
+	if !tasksUpdated(j1, j24, name) {
+		t.Fatal("bad")
+	}
+
+	// Mount the volume
+	j25 := j24.Copy()
+	j25.TaskGroups[0].Tasks[0].VolumeMounts = []*structs.VolumeMount{
+		{
+			Volume:      "data",
+			Destination: "/data",
+		},
+	}
+	if !tasksUpdated(j24, j25, name) {
+		t.Fatal("bad")
+	}
+
+	// Request a group network
+	j26 := mock.Job()
+	j26.TaskGroups[0].Networks = []*structs.NetworkResource{
+		{
+			Mode:         structs.NetworkModeBridge,
+			DynamicPorts: []structs.Port{{Label: "http"}},
+		},
+	}
+	if !tasksUpdated(j1, j26, name) {
+		t.Fatal("bad")
+	}
+
+	// The assigned dynamic ports aren't an update
+	j27 := j26.Copy()
+	j27.TaskGroups[0].Networks[0].DynamicPorts[0].Value = 25000
+	if tasksUpdated(j26, j27, name) {
+		t.Fatal("bad")
+	}
+
+	// Map the port
+	j28 := j26.Copy()
+	j28.TaskGroups[0].Networks[0].DynamicPorts[0].To = 8080
+	if !tasksUpdated(j26, j28, name) {
+		t.Fatal("bad")
+	}
 }
 
 func TestEvictAndPlace_LimitLessThanAllocs(t *testing.T) {